@@ -0,0 +1,165 @@
+// Package capture reads RADIUS and Diameter messages from pcap and pcapng
+// capture files, such as carrier traces taken for troubleshooting. RADIUS is
+// extracted from UDP datagrams and TCP streams, and Diameter from TCP streams
+// and SCTP DATA chunks, each identified by its well-known ports.
+package capture
+
+import (
+	"errors"
+	"io"
+	"net/netip"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/tinybluerobots/radius-diameter-message/diameter"
+	"github.com/tinybluerobots/radius-diameter-message/radius"
+)
+
+// ErrFormat is returned when a file is not a pcap or pcapng capture.
+var ErrFormat = errors.New("not a pcap or pcapng file")
+
+// Protocol identifies the protocol of a captured message.
+type Protocol byte
+
+const (
+	RADIUS Protocol = iota + 1
+	Diameter
+)
+
+// String returns the name of the protocol.
+func (p Protocol) String() string {
+	switch p {
+	case RADIUS:
+		return "RADIUS"
+	case Diameter:
+		return "Diameter"
+	}
+	return "unknown"
+}
+
+// Transports messages are captured from.
+const (
+	TransportUDP  = "udp"
+	TransportTCP  = "tcp"
+	TransportSCTP = "sctp"
+)
+
+// Packet is a message captured from the network. Exactly one of RADIUS and
+// Diameter is set, unless the message could not be decoded, when Err is.
+type Packet struct {
+	Timestamp   time.Time
+	Source      netip.AddrPort
+	Destination netip.AddrPort
+	Transport   string
+	Protocol    Protocol
+	RADIUS      *radius.Message
+	Diameter    *diameter.Message
+	// Data is the message as captured.
+	Data []byte
+	// Err is why the message could not be decoded.
+	Err error
+}
+
+// Config configures which ports carry which protocol.
+type Config struct {
+	// RADIUSPorts are the ports of RADIUS traffic, defaulting to 1812, 1813,
+	// the historic 1645 and 1646, and 3799 for dynamic authorization.
+	RADIUSPorts []uint16
+	// DiameterPorts are the ports of Diameter traffic, defaulting to 3868.
+	// SCTP DATA chunks with the Diameter payload protocol identifier are
+	// read whatever their ports.
+	DiameterPorts []uint16
+}
+
+// withDefaults returns the configuration with defaults for unset fields.
+func (c Config) withDefaults() Config {
+	if c.RADIUSPorts == nil {
+		c.RADIUSPorts = []uint16{1812, 1813, 1645, 1646, 3799}
+	}
+	if c.DiameterPorts == nil {
+		c.DiameterPorts = []uint16{3868}
+	}
+	return c
+}
+
+// protocol returns the protocol carried between the ports, or zero if
+// neither is a known port.
+func (c Config) protocol(source uint16, destination uint16) Protocol {
+	switch {
+	case slices.Contains(c.DiameterPorts, source), slices.Contains(c.DiameterPorts, destination):
+		return Diameter
+	case slices.Contains(c.RADIUSPorts, source), slices.Contains(c.RADIUSPorts, destination):
+		return RADIUS
+	}
+	return 0
+}
+
+// Reader reads the messages of a capture in the order their last byte was
+// captured.
+type Reader struct {
+	config  Config
+	frames  frameReader
+	streams *streams
+	queue   []Packet
+}
+
+// NewReader creates a reader of the pcap or pcapng capture.
+func NewReader(reader io.Reader, config Config) (*Reader, error) {
+	frames, err := newFrameReader(reader)
+	if err != nil {
+		return nil, err
+	}
+	return &Reader{config: config.withDefaults(), frames: frames, streams: newStreams()}, nil
+}
+
+// Next returns the next message of the capture, or io.EOF when there are no
+// more. Frames that carry no RADIUS or Diameter message are skipped.
+func (r *Reader) Next() (Packet, error) {
+	for len(r.queue) == 0 {
+		frame, err := r.frames.next()
+		if err != nil {
+			return Packet{}, err
+		}
+		r.queue = r.decode(frame)
+	}
+	packet := r.queue[0]
+	r.queue = r.queue[1:]
+	return packet, nil
+}
+
+// ReadFile reads every message of the capture file.
+func ReadFile(path string, config Config) ([]Packet, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	reader, err := NewReader(file, config)
+	if err != nil {
+		return nil, err
+	}
+	var packets []Packet
+	for {
+		packet, err := reader.Next()
+		if err == io.EOF {
+			return packets, nil
+		}
+		if err != nil {
+			return packets, err
+		}
+		packets = append(packets, packet)
+	}
+}
+
+// newPacket decodes a message of the protocol.
+func newPacket(protocol Protocol, data []byte) Packet {
+	packet := Packet{Protocol: protocol, Data: data}
+	switch protocol {
+	case RADIUS:
+		packet.RADIUS, packet.Err = radius.ReadMessage(data)
+	case Diameter:
+		packet.Diameter, packet.Err = diameter.ReadMessage(data)
+	}
+	return packet
+}
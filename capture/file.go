@@ -0,0 +1,290 @@
+package capture
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// Magic numbers of capture files.
+const (
+	pcapMagicMicroseconds = 0xa1b2c3d4
+	pcapMagicNanoseconds  = 0xa1b23c4d
+	pcapngSectionHeader   = 0x0a0d0d0a
+	pcapngByteOrderMagic  = 0x1a2b3c4d
+)
+
+// Block types of pcapng files.
+const (
+	blockInterfaceDescription = 1
+	blockPacket               = 2
+	blockSimplePacket         = 3
+	blockEnhancedPacket       = 6
+)
+
+// maxBlockLength bounds the blocks and records read, so a corrupt length
+// cannot exhaust memory.
+const maxBlockLength = 16 << 20
+
+// frame is a link layer frame read from a capture file.
+type frame struct {
+	timestamp time.Time
+	linkType  uint16
+	data      []byte
+}
+
+// frameReader reads the frames of a capture file.
+type frameReader interface {
+	next() (frame, error)
+}
+
+// newFrameReader detects the format of the capture file from its first
+// bytes and creates a reader of its frames.
+func newFrameReader(reader io.Reader) (frameReader, error) {
+	buffered := bufio.NewReader(reader)
+	magic, err := buffered.Peek(4)
+	if err != nil {
+		return nil, ErrFormat
+	}
+	if binary.BigEndian.Uint32(magic) == pcapngSectionHeader {
+		return &pcapngReader{reader: buffered}, nil
+	}
+	return newPcapReader(buffered)
+}
+
+// pcapReader reads the records of a pcap file.
+type pcapReader struct {
+	reader      io.Reader
+	order       binary.ByteOrder
+	nanoseconds bool
+	linkType    uint16
+}
+
+// newPcapReader reads the global header of a pcap file.
+func newPcapReader(reader io.Reader) (*pcapReader, error) {
+	header := make([]byte, 24)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return nil, ErrFormat
+	}
+	r := &pcapReader{reader: reader}
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		switch order.Uint32(header) {
+		case pcapMagicMicroseconds:
+			r.order = order
+		case pcapMagicNanoseconds:
+			r.order, r.nanoseconds = order, true
+		}
+	}
+	if r.order == nil {
+		return nil, ErrFormat
+	}
+	r.linkType = uint16(r.order.Uint32(header[20:24]))
+	return r, nil
+}
+
+// next reads the next record.
+func (r *pcapReader) next() (frame, error) {
+	header := make([]byte, 16)
+	if _, err := io.ReadFull(r.reader, header); err != nil {
+		return frame{}, err
+	}
+	length := r.order.Uint32(header[8:12])
+	if length > maxBlockLength {
+		return frame{}, fmt.Errorf("invalid record length %d", length)
+	}
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r.reader, data); err != nil {
+		return frame{}, unexpected(err)
+	}
+	fraction := int64(r.order.Uint32(header[4:8]))
+	if !r.nanoseconds {
+		fraction *= 1000
+	}
+	timestamp := time.Unix(int64(r.order.Uint32(header[0:4])), fraction).UTC()
+	return frame{timestamp: timestamp, linkType: r.linkType, data: data}, nil
+}
+
+// pcapngInterface describes an interface of a pcapng section.
+type pcapngInterface struct {
+	linkType uint16
+	snapLen  uint32
+	// units is the number of timestamp units per second.
+	units uint64
+}
+
+// pcapngReader reads the packet blocks of a pcapng file.
+type pcapngReader struct {
+	reader     io.Reader
+	order      binary.ByteOrder
+	interfaces []pcapngInterface
+}
+
+// next reads blocks until the next packet block.
+func (r *pcapngReader) next() (frame, error) {
+	for {
+		blockType, body, err := r.block()
+		if err != nil {
+			return frame{}, err
+		}
+		switch blockType {
+		case pcapngSectionHeader:
+			if err := r.section(body); err != nil {
+				return frame{}, err
+			}
+		case blockInterfaceDescription:
+			r.addInterface(body)
+		case blockEnhancedPacket, blockPacket, blockSimplePacket:
+			if f, ok := r.packet(blockType, body); ok {
+				return f, nil
+			}
+		}
+	}
+}
+
+// block reads the type and body of the next block. The byte order of a
+// section header block is read from the block itself.
+func (r *pcapngReader) block() (uint32, []byte, error) {
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(r.reader, header); err != nil {
+		return 0, nil, err
+	}
+	blockType := binary.BigEndian.Uint32(header)
+	if blockType == pcapngSectionHeader {
+		magic := make([]byte, 4)
+		if _, err := io.ReadFull(r.reader, magic); err != nil {
+			return 0, nil, unexpected(err)
+		}
+		r.order = nil
+		for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+			if order.Uint32(magic) == pcapngByteOrderMagic {
+				r.order = order
+			}
+		}
+		if r.order == nil {
+			return 0, nil, ErrFormat
+		}
+		header = append(header, magic...)
+	} else if r.order == nil {
+		return 0, nil, ErrFormat
+	} else {
+		blockType = r.order.Uint32(header)
+	}
+	length := r.order.Uint32(header[4:8])
+	if length < 12 || length%4 != 0 || length > maxBlockLength || int(length) < len(header)+4 {
+		return 0, nil, fmt.Errorf("invalid block length %d", length)
+	}
+	rest := make([]byte, int(length)-len(header))
+	if _, err := io.ReadFull(r.reader, rest); err != nil {
+		return 0, nil, unexpected(err)
+	}
+	body := append(header[8:], rest[:len(rest)-4]...)
+	return blockType, body, nil
+}
+
+// section starts a new section, whose interfaces replace those before it.
+func (r *pcapngReader) section(body []byte) error {
+	if len(body) < 16 {
+		return fmt.Errorf("invalid section header length %d", len(body))
+	}
+	r.interfaces = nil
+	return nil
+}
+
+// addInterface records the link type and timestamp resolution of an
+// interface.
+func (r *pcapngReader) addInterface(body []byte) {
+	if len(body) < 8 {
+		return
+	}
+	description := pcapngInterface{
+		linkType: r.order.Uint16(body[0:2]),
+		snapLen:  r.order.Uint32(body[4:8]),
+		units:    1_000_000,
+	}
+	options := body[8:]
+	for len(options) >= 4 {
+		code, length := r.order.Uint16(options[0:2]), int(r.order.Uint16(options[2:4]))
+		if code == 0 || 4+length > len(options) {
+			break
+		}
+		if code == 9 && length == 1 {
+			description.units = resolution(options[4])
+		}
+		options = options[4+(length+3)&^3:]
+	}
+	r.interfaces = append(r.interfaces, description)
+}
+
+// resolution returns the timestamp units per second of an if_tsresol
+// option, a negative power of 10 or, with the high bit set, of 2.
+func resolution(value byte) uint64 {
+	exponent := uint64(value & 0x7f)
+	if value&0x80 != 0 {
+		if exponent > 63 {
+			return 1 << 63
+		}
+		return 1 << exponent
+	}
+	units := uint64(1)
+	for range min(exponent, 19) {
+		units *= 10
+	}
+	return units
+}
+
+// packet decodes a packet block, reporting false if it is malformed or for
+// an unknown interface.
+func (r *pcapngReader) packet(blockType uint32, body []byte) (frame, bool) {
+	var interfaceId, high, low, length uint32
+	var data []byte
+	switch blockType {
+	case blockEnhancedPacket:
+		if len(body) < 20 {
+			return frame{}, false
+		}
+		interfaceId, high, low = r.order.Uint32(body[0:4]), r.order.Uint32(body[4:8]), r.order.Uint32(body[8:12])
+		length, data = r.order.Uint32(body[12:16]), body[20:]
+	case blockPacket:
+		if len(body) < 20 {
+			return frame{}, false
+		}
+		interfaceId, high, low = uint32(r.order.Uint16(body[0:2])), r.order.Uint32(body[4:8]), r.order.Uint32(body[8:12])
+		length, data = r.order.Uint32(body[12:16]), body[20:]
+	case blockSimplePacket:
+		if len(body) < 4 || len(r.interfaces) == 0 {
+			return frame{}, false
+		}
+		length, data = r.order.Uint32(body[0:4]), body[4:]
+		if snapLen := r.interfaces[0].snapLen; snapLen != 0 {
+			length = min(length, snapLen)
+		}
+	}
+	if int(interfaceId) >= len(r.interfaces) || uint64(length) > uint64(len(data)) {
+		return frame{}, false
+	}
+	description := r.interfaces[interfaceId]
+	f := frame{linkType: description.linkType, data: data[:length]}
+	if blockType != blockSimplePacket {
+		f.timestamp = timestamp(uint64(high)<<32|uint64(low), description.units)
+	}
+	return f, true
+}
+
+// timestamp converts a count of units per second since the epoch to a time.
+func timestamp(count uint64, units uint64) time.Time {
+	seconds := count / units
+	fraction := count % units
+	nanoseconds := uint64(math.Round(float64(fraction) * 1e9 / float64(units)))
+	return time.Unix(int64(seconds), int64(nanoseconds)).UTC()
+}
+
+// unexpected reports a capture that ends part way through a record.
+func unexpected(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
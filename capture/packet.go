@@ -0,0 +1,187 @@
+package capture
+
+import (
+	"encoding/binary"
+	"net/netip"
+)
+
+// Link types of capture files.
+const (
+	linkTypeNull     = 0
+	linkTypeEthernet = 1
+	linkTypeRaw      = 101
+	linkTypeLoop     = 108
+	linkTypeLinuxSLL = 113
+	linkTypeIPv4     = 228
+	linkTypeIPv6     = 229
+	linkTypeSLL2     = 276
+)
+
+// EtherTypes of network layer protocols.
+const (
+	etherTypeIPv4 = 0x0800
+	etherTypeIPv6 = 0x86dd
+	etherTypeVLAN = 0x8100
+	etherTypeQinQ = 0x88a8
+)
+
+// IP protocol numbers.
+const (
+	protocolTCP  = 6
+	protocolUDP  = 17
+	protocolSCTP = 132
+)
+
+// segment is a transport layer payload with its endpoints.
+type segment struct {
+	protocol    byte
+	source      netip.Addr
+	destination netip.Addr
+	payload     []byte
+}
+
+// decode decodes the messages carried by a frame.
+func (r *Reader) decode(f frame) []Packet {
+	ip, ok := network(f.linkType, f.data)
+	if !ok {
+		return nil
+	}
+	s, ok := internet(ip)
+	if !ok {
+		return nil
+	}
+	var packets []Packet
+	switch s.protocol {
+	case protocolUDP:
+		packets = r.udp(s)
+	case protocolTCP:
+		packets = r.tcp(s)
+	case protocolSCTP:
+		packets = r.sctp(s)
+	}
+	for i := range packets {
+		packets[i].Timestamp = f.timestamp
+	}
+	return packets
+}
+
+// network returns the IP packet carried by a link layer frame.
+func network(linkType uint16, data []byte) ([]byte, bool) {
+	var etherType uint16
+	switch linkType {
+	case linkTypeNull, linkTypeLoop:
+		if len(data) < 4 {
+			return nil, false
+		}
+		return data[4:], true
+	case linkTypeRaw, linkTypeIPv4, linkTypeIPv6:
+		return data, true
+	case linkTypeEthernet:
+		if len(data) < 14 {
+			return nil, false
+		}
+		etherType, data = binary.BigEndian.Uint16(data[12:14]), data[14:]
+		for (etherType == etherTypeVLAN || etherType == etherTypeQinQ) && len(data) >= 4 {
+			etherType, data = binary.BigEndian.Uint16(data[2:4]), data[4:]
+		}
+	case linkTypeLinuxSLL:
+		if len(data) < 16 {
+			return nil, false
+		}
+		etherType, data = binary.BigEndian.Uint16(data[14:16]), data[16:]
+	case linkTypeSLL2:
+		if len(data) < 20 {
+			return nil, false
+		}
+		etherType, data = binary.BigEndian.Uint16(data[0:2]), data[20:]
+	default:
+		return nil, false
+	}
+	return data, etherType == etherTypeIPv4 || etherType == etherTypeIPv6
+}
+
+// internet returns the transport layer payload of an IP packet. Fragmented
+// packets are not reassembled and are skipped.
+func internet(data []byte) (segment, bool) {
+	if len(data) < 1 {
+		return segment{}, false
+	}
+	switch data[0] >> 4 {
+	case 4:
+		if len(data) < 20 {
+			return segment{}, false
+		}
+		headerLength := int(data[0]&0x0f) * 4
+		totalLength := int(binary.BigEndian.Uint16(data[2:4]))
+		if headerLength < 20 || totalLength < headerLength || totalLength > len(data) {
+			return segment{}, false
+		}
+		if binary.BigEndian.Uint16(data[6:8])&0x3fff != 0 {
+			return segment{}, false
+		}
+		return segment{
+			protocol:    data[9],
+			source:      netip.AddrFrom4([4]byte(data[12:16])),
+			destination: netip.AddrFrom4([4]byte(data[16:20])),
+			payload:     data[headerLength:totalLength],
+		}, true
+	case 6:
+		if len(data) < 40 {
+			return segment{}, false
+		}
+		payloadLength := int(binary.BigEndian.Uint16(data[4:6]))
+		if 40+payloadLength > len(data) {
+			return segment{}, false
+		}
+		s := segment{
+			protocol:    data[6],
+			source:      netip.AddrFrom16([16]byte(data[8:24])),
+			destination: netip.AddrFrom16([16]byte(data[24:40])),
+			payload:     data[40 : 40+payloadLength],
+		}
+		for {
+			var length int
+			switch s.protocol {
+			case 0, 43, 60:
+				if len(s.payload) < 2 {
+					return segment{}, false
+				}
+				length = (int(s.payload[1]) + 1) * 8
+			case 51:
+				if len(s.payload) < 2 {
+					return segment{}, false
+				}
+				length = (int(s.payload[1]) + 2) * 4
+			case 44:
+				return segment{}, false
+			default:
+				return s, true
+			}
+			if length > len(s.payload) {
+				return segment{}, false
+			}
+			s.protocol, s.payload = s.payload[0], s.payload[length:]
+		}
+	}
+	return segment{}, false
+}
+
+// udp decodes the RADIUS message of a UDP datagram.
+func (r *Reader) udp(s segment) []Packet {
+	if len(s.payload) < 8 {
+		return nil
+	}
+	source, destination := binary.BigEndian.Uint16(s.payload[0:2]), binary.BigEndian.Uint16(s.payload[2:4])
+	length := int(binary.BigEndian.Uint16(s.payload[4:6]))
+	if length < 8 || length > len(s.payload) {
+		return nil
+	}
+	if r.config.protocol(source, destination) != RADIUS {
+		return nil
+	}
+	packet := newPacket(RADIUS, append([]byte(nil), s.payload[8:length]...))
+	packet.Transport = TransportUDP
+	packet.Source = netip.AddrPortFrom(s.source, source)
+	packet.Destination = netip.AddrPortFrom(s.destination, destination)
+	return []Packet{packet}
+}
@@ -0,0 +1,236 @@
+package capture
+
+import (
+	"encoding/binary"
+	"net/netip"
+
+	"github.com/tinybluerobots/radius-diameter-message/diameter"
+)
+
+// TCP flags.
+const (
+	tcpFIN = 0x01
+	tcpSYN = 0x02
+	tcpRST = 0x04
+)
+
+// maxPendingSegments bounds the out of order segments held for a stream;
+// beyond it the missing data is assumed lost and skipped.
+const maxPendingSegments = 64
+
+// diameterPayloadProtocol is the SCTP payload protocol identifier of Diameter.
+const diameterPayloadProtocol = 46
+
+// flow identifies one direction of a connection.
+type flow struct {
+	source      netip.AddrPort
+	destination netip.AddrPort
+}
+
+// tcpStream reassembles one direction of a TCP connection.
+type tcpStream struct {
+	next    uint32
+	buffer  []byte
+	pending map[uint32][]byte
+}
+
+// sctpMessage is a user message being reassembled from fragmented SCTP DATA
+// chunks.
+type sctpMessage struct {
+	flow   flow
+	stream uint16
+}
+
+// streams holds the reassembly state of the connections of a capture.
+type streams struct {
+	tcp  map[flow]*tcpStream
+	sctp map[sctpMessage][]byte
+}
+
+// newStreams creates empty reassembly state.
+func newStreams() *streams {
+	return &streams{tcp: make(map[flow]*tcpStream), sctp: make(map[sctpMessage][]byte)}
+}
+
+// tcp adds a TCP segment to its stream and decodes the messages it
+// completes.
+func (r *Reader) tcp(s segment) []Packet {
+	if len(s.payload) < 20 {
+		return nil
+	}
+	sourcePort, destinationPort := binary.BigEndian.Uint16(s.payload[0:2]), binary.BigEndian.Uint16(s.payload[2:4])
+	protocol := r.config.protocol(sourcePort, destinationPort)
+	if protocol == 0 {
+		return nil
+	}
+	headerLength := int(s.payload[12]>>4) * 4
+	if headerLength < 20 || headerLength > len(s.payload) {
+		return nil
+	}
+	sequence := binary.BigEndian.Uint32(s.payload[4:8])
+	flags := s.payload[13]
+	payload := s.payload[headerLength:]
+	f := flow{netip.AddrPortFrom(s.source, sourcePort), netip.AddrPortFrom(s.destination, destinationPort)}
+	stream, ok := r.streams.tcp[f]
+	if flags&tcpSYN != 0 || !ok {
+		stream = &tcpStream{next: sequence, pending: make(map[uint32][]byte)}
+		if flags&tcpSYN != 0 {
+			stream.next++
+		}
+		r.streams.tcp[f] = stream
+	}
+	stream.add(sequence, payload)
+	var packets []Packet
+	for {
+		data, ok := stream.message(protocol)
+		if !ok {
+			break
+		}
+		packet := newPacket(protocol, data)
+		packet.Transport = TransportTCP
+		packet.Source, packet.Destination = f.source, f.destination
+		packets = append(packets, packet)
+	}
+	if flags&(tcpFIN|tcpRST) != 0 {
+		delete(r.streams.tcp, f)
+	}
+	return packets
+}
+
+// add adds the payload of a segment, holding it until the data before it
+// arrives and trimming data already received.
+func (t *tcpStream) add(sequence uint32, payload []byte) {
+	if len(payload) == 0 {
+		return
+	}
+	offset := int32(sequence - t.next)
+	if offset > 0 {
+		t.pending[sequence] = append([]byte(nil), payload...)
+		if len(t.pending) > maxPendingSegments {
+			t.skip()
+		}
+		return
+	}
+	if -int(offset) >= len(payload) {
+		return
+	}
+	t.buffer = append(t.buffer, payload[-offset:]...)
+	t.next += uint32(len(payload)) - uint32(-offset)
+	for {
+		advanced := false
+		for sequence, payload := range t.pending {
+			offset := int32(sequence - t.next)
+			if offset > 0 {
+				continue
+			}
+			delete(t.pending, sequence)
+			if -int(offset) < len(payload) {
+				t.buffer = append(t.buffer, payload[-offset:]...)
+				t.next += uint32(len(payload)) - uint32(-offset)
+				advanced = true
+			}
+		}
+		if !advanced {
+			return
+		}
+	}
+}
+
+// skip gives up on missing data, discarding the partial message before it
+// and continuing from the earliest segment held.
+func (t *tcpStream) skip() {
+	first := true
+	var earliest uint32
+	for sequence := range t.pending {
+		if first || int32(sequence-earliest) < 0 {
+			earliest, first = sequence, false
+		}
+	}
+	t.buffer = nil
+	t.next = earliest
+	payload := t.pending[earliest]
+	delete(t.pending, earliest)
+	t.add(earliest, payload)
+}
+
+// message removes the next complete message of the protocol from the
+// stream. A stream that does not start with a valid header, including one
+// declaring a length beyond the protocol's maximum, is discarded, so that a
+// capture started part way through a message resynchronizes at the start of
+// a later segment.
+func (t *tcpStream) message(protocol Protocol) ([]byte, bool) {
+	if len(t.buffer) < 4 {
+		return nil, false
+	}
+	var length int
+	switch protocol {
+	case Diameter:
+		length = int(t.buffer[1])<<16 | int(t.buffer[2])<<8 | int(t.buffer[3])
+		if t.buffer[0] != 1 || length < 20 || length > diameter.DefaultMaxMessageSize {
+			t.buffer = nil
+			return nil, false
+		}
+	case RADIUS:
+		length = int(binary.BigEndian.Uint16(t.buffer[2:4]))
+		if length < 20 || length > 4096 {
+			t.buffer = nil
+			return nil, false
+		}
+	}
+	if len(t.buffer) < length {
+		return nil, false
+	}
+	data := append([]byte(nil), t.buffer[:length]...)
+	t.buffer = t.buffer[length:]
+	if len(t.buffer) == 0 {
+		t.buffer = nil
+	}
+	return data, true
+}
+
+// sctp decodes the Diameter messages of the DATA chunks of an SCTP packet,
+// reassembling those fragmented across chunks.
+func (r *Reader) sctp(s segment) []Packet {
+	if len(s.payload) < 12 {
+		return nil
+	}
+	sourcePort, destinationPort := binary.BigEndian.Uint16(s.payload[0:2]), binary.BigEndian.Uint16(s.payload[2:4])
+	f := flow{netip.AddrPortFrom(s.source, sourcePort), netip.AddrPortFrom(s.destination, destinationPort)}
+	byPort := r.config.protocol(sourcePort, destinationPort) == Diameter
+	var packets []Packet
+	chunks := s.payload[12:]
+	for len(chunks) >= 4 {
+		chunkType, chunkFlags := chunks[0], chunks[1]
+		length := int(binary.BigEndian.Uint16(chunks[2:4]))
+		if length < 4 || length > len(chunks) {
+			break
+		}
+		chunk := chunks[:length]
+		chunks = chunks[min((length+3)&^3, len(chunks)):]
+		if chunkType != 0 || len(chunk) < 16 {
+			continue
+		}
+		if !byPort && binary.BigEndian.Uint32(chunk[12:16]) != diameterPayloadProtocol {
+			continue
+		}
+		key := sctpMessage{f, binary.BigEndian.Uint16(chunk[8:10])}
+		data := chunk[16:]
+		if chunkFlags&0x02 != 0 {
+			delete(r.streams.sctp, key)
+		} else if fragments, ok := r.streams.sctp[key]; ok {
+			data = append(fragments, data...)
+		} else {
+			continue
+		}
+		if chunkFlags&0x01 == 0 {
+			r.streams.sctp[key] = append([]byte(nil), data...)
+			continue
+		}
+		delete(r.streams.sctp, key)
+		packet := newPacket(Diameter, append([]byte(nil), data...))
+		packet.Transport = TransportSCTP
+		packet.Source, packet.Destination = f.source, f.destination
+		packets = append(packets, packet)
+	}
+	return packets
+}
@@ -0,0 +1,230 @@
+package capture
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"net/netip"
+	"time"
+)
+
+// maxSegment is the largest TCP payload or SCTP chunk written in one frame.
+const maxSegment = 65000
+
+// castagnoli is the CRC32c table of SCTP checksums.
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// Synthetic MAC addresses of the Ethernet headers written.
+var (
+	sourceMAC      = []byte{0x02, 0, 0, 0, 0, 0x01}
+	destinationMAC = []byte{0x02, 0, 0, 0, 0, 0x02}
+)
+
+// Writer writes messages to a pcap file with synthetic Ethernet, IP and
+// transport headers, so that scenarios built with this library can be
+// inspected in Wireshark. Messages written over TCP or SCTP continue the
+// connection of earlier ones between the same endpoints. It is not safe for
+// concurrent use.
+type Writer struct {
+	writer   io.Writer
+	sequence map[flow]uint32
+}
+
+// NewWriter writes the header of a pcap file with nanosecond timestamps.
+func NewWriter(writer io.Writer) (*Writer, error) {
+	header := make([]byte, 24)
+	binary.LittleEndian.PutUint32(header[0:4], pcapMagicNanoseconds)
+	binary.LittleEndian.PutUint16(header[4:6], 2)
+	binary.LittleEndian.PutUint16(header[6:8], 4)
+	binary.LittleEndian.PutUint32(header[16:20], 262144)
+	binary.LittleEndian.PutUint32(header[20:24], linkTypeEthernet)
+	if _, err := writer.Write(header); err != nil {
+		return nil, err
+	}
+	return &Writer{writer: writer, sequence: make(map[flow]uint32)}, nil
+}
+
+// WritePacket writes the message of the packet: its Data, or else its
+// RADIUS or Diameter message encoded. The transport defaults to UDP for
+// RADIUS and TCP for Diameter, and the timestamp to now. Source and
+// Destination must both be IPv4 or both IPv6 addresses.
+func (w *Writer) WritePacket(packet Packet) error {
+	data := packet.Data
+	if data == nil {
+		switch {
+		case packet.RADIUS != nil:
+			data = packet.RADIUS.ToBytes()
+		case packet.Diameter != nil:
+			data = packet.Diameter.ToBytes()
+		default:
+			return errors.New("packet has no message")
+		}
+	}
+	transport := packet.Transport
+	if transport == "" {
+		transport = TransportUDP
+		if packet.Diameter != nil || packet.Protocol == Diameter {
+			transport = TransportTCP
+		}
+	}
+	timestamp := packet.Timestamp
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+	source, destination := packet.Source.Addr().Unmap(), packet.Destination.Addr().Unmap()
+	if !source.IsValid() || !destination.IsValid() || source.Is4() != destination.Is4() {
+		return errors.New("source and destination must be addresses of the same family")
+	}
+	f := flow{netip.AddrPortFrom(source, packet.Source.Port()), netip.AddrPortFrom(destination, packet.Destination.Port())}
+	switch transport {
+	case TransportUDP:
+		if len(data) > 0xffff-8 {
+			return errors.New("message too large for UDP")
+		}
+		return w.writeFrame(timestamp, f, protocolUDP, udpHeader(f, data))
+	case TransportTCP:
+		for len(data) > 0 {
+			n := min(len(data), maxSegment)
+			if err := w.writeFrame(timestamp, f, protocolTCP, w.tcpHeader(f, data[:n])); err != nil {
+				return err
+			}
+			data = data[n:]
+		}
+		return nil
+	case TransportSCTP:
+		for flags := byte(0x02); len(data) > 0; flags = 0 {
+			n := min(len(data), maxSegment)
+			if n == len(data) {
+				flags |= 0x01
+			}
+			if err := w.writeFrame(timestamp, f, protocolSCTP, w.sctpPacket(f, flags, data[:n])); err != nil {
+				return err
+			}
+			data = data[n:]
+		}
+		return nil
+	}
+	return errors.New("unknown transport " + transport)
+}
+
+// writeFrame writes a transport layer segment as a record, in IP and
+// Ethernet headers.
+func (w *Writer) writeFrame(timestamp time.Time, f flow, protocol byte, segment []byte) error {
+	frame := make([]byte, 14, 14+40+len(segment))
+	copy(frame[0:6], destinationMAC)
+	copy(frame[6:12], sourceMAC)
+	source, destination := f.source.Addr(), f.destination.Addr()
+	if source.Is4() {
+		binary.BigEndian.PutUint16(frame[12:14], etherTypeIPv4)
+		header := make([]byte, 20)
+		header[0] = 0x45
+		binary.BigEndian.PutUint16(header[2:4], uint16(20+len(segment)))
+		binary.BigEndian.PutUint16(header[6:8], 0x4000)
+		header[8], header[9] = 64, protocol
+		copy(header[12:16], source.AsSlice())
+		copy(header[16:20], destination.AsSlice())
+		binary.BigEndian.PutUint16(header[10:12], ^checksum(0, header))
+		frame = append(frame, header...)
+	} else {
+		binary.BigEndian.PutUint16(frame[12:14], etherTypeIPv6)
+		header := make([]byte, 40)
+		header[0] = 0x60
+		binary.BigEndian.PutUint16(header[4:6], uint16(len(segment)))
+		header[6], header[7] = protocol, 64
+		copy(header[8:24], source.AsSlice())
+		copy(header[24:40], destination.AsSlice())
+		frame = append(frame, header...)
+	}
+	frame = append(frame, segment...)
+	record := make([]byte, 16, 16+len(frame))
+	binary.LittleEndian.PutUint32(record[0:4], uint32(timestamp.Unix()))
+	binary.LittleEndian.PutUint32(record[4:8], uint32(timestamp.Nanosecond()))
+	binary.LittleEndian.PutUint32(record[8:12], uint32(len(frame)))
+	binary.LittleEndian.PutUint32(record[12:16], uint32(len(frame)))
+	_, err := w.writer.Write(append(record, frame...))
+	return err
+}
+
+// udpHeader prefixes the data with a UDP header.
+func udpHeader(f flow, data []byte) []byte {
+	segment := make([]byte, 8, 8+len(data))
+	binary.BigEndian.PutUint16(segment[0:2], f.source.Port())
+	binary.BigEndian.PutUint16(segment[2:4], f.destination.Port())
+	binary.BigEndian.PutUint16(segment[4:6], uint16(8+len(data)))
+	segment = append(segment, data...)
+	sum := ^checksum(pseudoHeader(f, protocolUDP, len(segment)), segment)
+	if sum == 0 {
+		sum = 0xffff
+	}
+	binary.BigEndian.PutUint16(segment[6:8], sum)
+	return segment
+}
+
+// tcpHeader prefixes the data with a TCP header continuing the connection,
+// acknowledging everything sent in the other direction.
+func (w *Writer) tcpHeader(f flow, data []byte) []byte {
+	sequence := w.next(f)
+	w.sequence[f] = sequence + uint32(len(data))
+	segment := make([]byte, 20, 20+len(data))
+	binary.BigEndian.PutUint16(segment[0:2], f.source.Port())
+	binary.BigEndian.PutUint16(segment[2:4], f.destination.Port())
+	binary.BigEndian.PutUint32(segment[4:8], sequence)
+	binary.BigEndian.PutUint32(segment[8:12], w.next(flow{f.destination, f.source}))
+	segment[12], segment[13] = 5<<4, 0x18
+	binary.BigEndian.PutUint16(segment[14:16], 0xffff)
+	segment = append(segment, data...)
+	binary.BigEndian.PutUint16(segment[16:18], ^checksum(pseudoHeader(f, protocolTCP, len(segment)), segment))
+	return segment
+}
+
+// sctpPacket wraps the data in a DATA chunk with the B and E flags, the
+// Diameter payload protocol identifier and the next TSN of the association.
+func (w *Writer) sctpPacket(f flow, flags byte, data []byte) []byte {
+	tsn := w.next(f)
+	w.sequence[f] = tsn + 1
+	packet := make([]byte, 28, 28+len(data)+3)
+	binary.BigEndian.PutUint16(packet[0:2], f.source.Port())
+	binary.BigEndian.PutUint16(packet[2:4], f.destination.Port())
+	packet[13] = flags
+	binary.BigEndian.PutUint16(packet[14:16], uint16(16+len(data)))
+	binary.BigEndian.PutUint32(packet[16:20], tsn)
+	binary.BigEndian.PutUint32(packet[24:28], diameterPayloadProtocol)
+	packet = append(packet, data...)
+	for len(packet)%4 != 0 {
+		packet = append(packet, 0)
+	}
+	binary.LittleEndian.PutUint32(packet[8:12], crc32.Checksum(packet, castagnoli))
+	return packet
+}
+
+// next returns the next sequence number of the flow, starting at 1.
+func (w *Writer) next(f flow) uint32 {
+	if sequence, ok := w.sequence[f]; ok {
+		return sequence
+	}
+	return 1
+}
+
+// pseudoHeader returns the sum of the IP pseudo-header of a UDP or TCP
+// checksum.
+func pseudoHeader(f flow, protocol byte, length int) uint32 {
+	sum := uint32(checksum(0, f.source.Addr().AsSlice()))
+	sum = uint32(checksum(sum, f.destination.Addr().AsSlice()))
+	return sum + uint32(protocol) + uint32(length)
+}
+
+// checksum adds the data to a ones' complement sum, returning it folded to
+// 16 bits.
+func checksum(sum uint32, data []byte) uint16 {
+	for i := 0; i+1 < len(data); i += 2 {
+		sum += uint32(binary.BigEndian.Uint16(data[i : i+2]))
+	}
+	if len(data)%2 == 1 {
+		sum += uint32(data[len(data)-1]) << 8
+	}
+	for sum > 0xffff {
+		sum = sum&0xffff + sum>>16
+	}
+	return uint16(sum)
+}
@@ -0,0 +1,246 @@
+// Package cdr folds the accounting records of RADIUS and Diameter sessions
+// into flattened call detail records for billing pipelines. The START,
+// INTERIM and STOP records of a session are correlated by its
+// Acct-Session-Id, qualified by the NAS, or its Diameter Session-Id, and a
+// record is written when the session stops.
+package cdr
+
+import (
+	"net"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/tinybluerobots/radius-diameter-message/diameter"
+	"github.com/tinybluerobots/radius-diameter-message/diameter/accounting"
+	"github.com/tinybluerobots/radius-diameter-message/diameter/session"
+	"github.com/tinybluerobots/radius-diameter-message/radius"
+)
+
+// Protocols of the sessions of records.
+const (
+	ProtocolRADIUS   = "radius"
+	ProtocolDiameter = "diameter"
+)
+
+// NASREQ accounting AVP codes (RFC 7155).
+const (
+	avpNASIPAddress            diameter.Code = 4
+	avpFramedIPAddress         diameter.Code = 8
+	avpCalledStationId         diameter.Code = 30
+	avpCallingStationId        diameter.Code = 31
+	avpNASIdentifier           diameter.Code = 32
+	avpAcctSessionTime         diameter.Code = 46
+	avpAccountingInputOctets   diameter.Code = 363
+	avpAccountingOutputOctets  diameter.Code = 364
+	avpAccountingInputPackets  diameter.Code = 365
+	avpAccountingOutputPackets diameter.Code = 366
+)
+
+// Record is the call detail record of an accounting session. The counters
+// are the highest reported, since both protocols report totals for the
+// session so far and a retransmitted record may arrive late.
+type Record struct {
+	Protocol  string
+	SessionId string
+	UserName  string
+	// NAS is the NAS-IP-Address or NAS-Identifier of a RADIUS session, or the
+	// Origin-Host of a Diameter one.
+	NAS              string
+	CallingStationId string
+	CalledStationId  string
+	FramedIPAddress  net.IP
+	Start            time.Time
+	Stop             time.Time
+	// Duration is the session time reported, or else the time from Start to
+	// Stop.
+	Duration       time.Duration
+	InputOctets    uint64
+	OutputOctets   uint64
+	InputPackets   uint64
+	OutputPackets  uint64
+	TerminateCause string
+	// Records is the number of accounting records folded into the record.
+	Records int
+	// Complete reports whether the session stopped, rather than being
+	// written by Flush.
+	Complete bool
+}
+
+// Writer writes call detail records.
+type Writer interface {
+	Write(record Record) error
+}
+
+// sessionKey identifies an accounting session.
+type sessionKey struct {
+	protocol  string
+	nas       string
+	sessionId string
+}
+
+// Exporter folds accounting records into call detail records, writing each
+// when its session stops, or at once for an EVENT record. It is safe for
+// concurrent use, and writes one record at a time.
+type Exporter struct {
+	writer   Writer
+	mutex    sync.Mutex
+	sessions map[sessionKey]*Record
+}
+
+// NewExporter creates an Exporter writing to the writer.
+func NewExporter(writer Writer) *Exporter {
+	return &Exporter{writer: writer, sessions: make(map[sessionKey]*Record)}
+}
+
+// AddRADIUS folds a RADIUS Accounting-Request into its session. Other
+// messages, and Accounting-On and Accounting-Off records, are ignored.
+func (e *Exporter) AddRADIUS(message radius.Message) error {
+	var request radius.AccountingRequest
+	if request.FromMessage(message) != nil {
+		return nil
+	}
+	nas := request.NASIdentifier
+	if request.NASIPAddress != nil {
+		nas = request.NASIPAddress.String()
+	}
+	var stop bool
+	switch request.StatusType {
+	case radius.AcctStart, radius.AcctInterimUpdate:
+	case radius.AcctStop:
+		stop = true
+	default:
+		return nil
+	}
+	timestamp := request.EventTimestamp
+	if timestamp.IsZero() {
+		timestamp = time.Now().Add(-request.DelayTime)
+	}
+	return e.add(sessionKey{ProtocolRADIUS, nas, request.SessionId}, request.StatusType == radius.AcctStart, stop, timestamp, func(record *Record) {
+		record.UserName = first(request.UserName, record.UserName)
+		record.CallingStationId = first(request.CallingStationId, record.CallingStationId)
+		record.CalledStationId = first(request.CalledStationId, record.CalledStationId)
+		if request.FramedIPAddress != nil {
+			record.FramedIPAddress = request.FramedIPAddress
+		}
+		if request.SessionTime != 0 {
+			record.Duration = request.SessionTime
+		}
+		record.InputOctets = max(record.InputOctets, request.InputOctets)
+		record.OutputOctets = max(record.OutputOctets, request.OutputOctets)
+		record.InputPackets = max(record.InputPackets, uint64(request.InputPackets))
+		record.OutputPackets = max(record.OutputPackets, uint64(request.OutputPackets))
+		if request.TerminateCause != 0 {
+			record.TerminateCause = request.TerminateCause.String()
+		}
+	})
+}
+
+// AddDiameter folds a Diameter Accounting-Request into its session, reading
+// the NASREQ accounting AVPs for the usage. Other messages are ignored.
+func (e *Exporter) AddDiameter(message diameter.Message) error {
+	var acr accounting.ACR
+	if acr.FromMessage(message) != nil {
+		return nil
+	}
+	timestamp := acr.EventTimestamp
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+	key := sessionKey{ProtocolDiameter, "", acr.SessionId}
+	start := acr.RecordType == accounting.StartRecord || acr.RecordType == accounting.EventRecord
+	stop := acr.RecordType == accounting.StopRecord || acr.RecordType == accounting.EventRecord
+	avps := message.Avps
+	return e.add(key, start, stop, timestamp, func(record *Record) {
+		record.NAS = first(acr.OriginHost, record.NAS)
+		record.UserName = first(acr.UserName, record.UserName)
+		record.CallingStationId = first(avps.GetFirst(avpCallingStationId, 0).ToStringOrDefault(), record.CallingStationId)
+		record.CalledStationId = first(avps.GetFirst(avpCalledStationId, 0).ToStringOrDefault(), record.CalledStationId)
+		if address := avps.GetFirst(avpFramedIPAddress, 0); address != nil && len(address.Data) == 4 {
+			record.FramedIPAddress = net.IP(append([]byte(nil), address.Data...))
+		}
+		if seconds := avps.GetFirst(avpAcctSessionTime, 0).ToUint32OrDefault(); seconds != 0 {
+			record.Duration = time.Duration(seconds) * time.Second
+		}
+		record.InputOctets = max(record.InputOctets, avps.GetFirst(avpAccountingInputOctets, 0).ToUint64OrDefault())
+		record.OutputOctets = max(record.OutputOctets, avps.GetFirst(avpAccountingOutputOctets, 0).ToUint64OrDefault())
+		record.InputPackets = max(record.InputPackets, avps.GetFirst(avpAccountingInputPackets, 0).ToUint64OrDefault())
+		record.OutputPackets = max(record.OutputPackets, avps.GetFirst(avpAccountingOutputPackets, 0).ToUint64OrDefault())
+		if cause, ok := avps.GetFirst(diameter.AvpTerminationCause, 0).ToUint32Ok(); ok {
+			record.TerminateCause = terminationCause(cause)
+		}
+	})
+}
+
+// add applies an accounting record to its session, writing the session's
+// record if it stops. A record for a session not yet seen starts one, so that
+// sessions whose START was missed are still recorded.
+func (e *Exporter) add(key sessionKey, start bool, stop bool, timestamp time.Time, update func(record *Record)) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	record, ok := e.sessions[key]
+	if !ok {
+		record = &Record{Protocol: key.protocol, SessionId: key.sessionId, NAS: key.nas}
+		e.sessions[key] = record
+	}
+	if start || record.Start.IsZero() {
+		record.Start = timestamp
+	}
+	record.Records++
+	update(record)
+	if !stop {
+		return nil
+	}
+	delete(e.sessions, key)
+	record.Stop = timestamp
+	record.Complete = true
+	if record.Duration == 0 {
+		record.Duration = max(record.Stop.Sub(record.Start), 0)
+	}
+	return e.writer.Write(*record)
+}
+
+// Sessions returns the number of sessions started and not yet stopped.
+func (e *Exporter) Sessions() int {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return len(e.sessions)
+}
+
+// Flush writes the records of the sessions that have not stopped, as
+// incomplete, in the order they started, and forgets them.
+func (e *Exporter) Flush() error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	records := make([]*Record, 0, len(e.sessions))
+	for _, record := range e.sessions {
+		records = append(records, record)
+	}
+	slices.SortFunc(records, func(a, b *Record) int {
+		return a.Start.Compare(b.Start)
+	})
+	for _, record := range records {
+		if err := e.writer.Write(*record); err != nil {
+			return err
+		}
+	}
+	clear(e.sessions)
+	return nil
+}
+
+// terminationCause names the value of a Termination-Cause AVP. NASREQ maps
+// the RADIUS causes to their values plus ten.
+func terminationCause(value uint32) string {
+	if value > 10 {
+		return radius.AcctTerminateCause(value - 10).String()
+	}
+	return session.TerminationCause(value).String()
+}
+
+// first returns the value if it is not empty, or else the fallback.
+func first(value string, fallback string) string {
+	if value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,127 @@
+package cdr
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+)
+
+// csvHeader names the columns of the CSV written by CSVWriter.
+var csvHeader = []string{
+	"protocol", "session_id", "user_name", "nas", "calling_station_id", "called_station_id",
+	"framed_ip_address", "start", "stop", "duration", "input_octets", "output_octets",
+	"input_packets", "output_packets", "terminate_cause", "records", "complete",
+}
+
+// CSVWriter writes records as CSV with a header row, times as RFC 3339 and
+// the duration in seconds. It is not safe for concurrent use.
+type CSVWriter struct {
+	writer *csv.Writer
+	header bool
+}
+
+// NewCSVWriter creates a CSVWriter writing to the writer.
+func NewCSVWriter(writer io.Writer) *CSVWriter {
+	return &CSVWriter{writer: csv.NewWriter(writer)}
+}
+
+// Write writes the record as a row, preceded by the header if it is the
+// first.
+func (w *CSVWriter) Write(record Record) error {
+	if !w.header {
+		if err := w.writer.Write(csvHeader); err != nil {
+			return err
+		}
+		w.header = true
+	}
+	var framedIPAddress string
+	if record.FramedIPAddress != nil {
+		framedIPAddress = record.FramedIPAddress.String()
+	}
+	err := w.writer.Write([]string{
+		record.Protocol, record.SessionId, record.UserName, record.NAS, record.CallingStationId, record.CalledStationId,
+		framedIPAddress, formatTime(record.Start), formatTime(record.Stop), strconv.FormatInt(int64(record.Duration/time.Second), 10),
+		strconv.FormatUint(record.InputOctets, 10), strconv.FormatUint(record.OutputOctets, 10),
+		strconv.FormatUint(record.InputPackets, 10), strconv.FormatUint(record.OutputPackets, 10),
+		record.TerminateCause, strconv.Itoa(record.Records), strconv.FormatBool(record.Complete),
+	})
+	if err != nil {
+		return err
+	}
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+// jsonRecord is the JSON representation of a record.
+type jsonRecord struct {
+	Protocol         string `json:"protocol"`
+	SessionId        string `json:"sessionId"`
+	UserName         string `json:"userName,omitempty"`
+	NAS              string `json:"nas,omitempty"`
+	CallingStationId string `json:"callingStationId,omitempty"`
+	CalledStationId  string `json:"calledStationId,omitempty"`
+	FramedIPAddress  string `json:"framedIpAddress,omitempty"`
+	Start            string `json:"start,omitempty"`
+	Stop             string `json:"stop,omitempty"`
+	Duration         int64  `json:"duration"`
+	InputOctets      uint64 `json:"inputOctets"`
+	OutputOctets     uint64 `json:"outputOctets"`
+	InputPackets     uint64 `json:"inputPackets"`
+	OutputPackets    uint64 `json:"outputPackets"`
+	TerminateCause   string `json:"terminateCause,omitempty"`
+	Records          int    `json:"records"`
+	Complete         bool   `json:"complete"`
+}
+
+// MarshalJSON converts the record to JSON, with times as RFC 3339 and the
+// duration in seconds.
+func (r Record) MarshalJSON() ([]byte, error) {
+	value := jsonRecord{
+		Protocol:         r.Protocol,
+		SessionId:        r.SessionId,
+		UserName:         r.UserName,
+		NAS:              r.NAS,
+		CallingStationId: r.CallingStationId,
+		CalledStationId:  r.CalledStationId,
+		Start:            formatTime(r.Start),
+		Stop:             formatTime(r.Stop),
+		Duration:         int64(r.Duration / time.Second),
+		InputOctets:      r.InputOctets,
+		OutputOctets:     r.OutputOctets,
+		InputPackets:     r.InputPackets,
+		OutputPackets:    r.OutputPackets,
+		TerminateCause:   r.TerminateCause,
+		Records:          r.Records,
+		Complete:         r.Complete,
+	}
+	if r.FramedIPAddress != nil {
+		value.FramedIPAddress = r.FramedIPAddress.String()
+	}
+	return json.Marshal(value)
+}
+
+// JSONWriter writes records as JSON lines. It is not safe for concurrent
+// use.
+type JSONWriter struct {
+	encoder *json.Encoder
+}
+
+// NewJSONWriter creates a JSONWriter writing to the writer.
+func NewJSONWriter(writer io.Writer) *JSONWriter {
+	return &JSONWriter{encoder: json.NewEncoder(writer)}
+}
+
+// Write writes the record as a line of JSON.
+func (w *JSONWriter) Write(record Record) error {
+	return w.encoder.Encode(record)
+}
+
+// formatTime formats a time as RFC 3339 in UTC, or as empty if it is zero.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,259 @@
+package accounting
+
+import (
+	"errors"
+	"time"
+
+	"github.com/tinybluerobots/radius-diameter-message/diameter"
+)
+
+const mandatoryFlags = diameter.FlagMandatory
+
+// RecordType represents the value of an Accounting-Record-Type AVP.
+type RecordType uint32
+
+const (
+	EventRecord   RecordType = 1
+	StartRecord   RecordType = 2
+	InterimRecord RecordType = 3
+	StopRecord    RecordType = 4
+)
+
+// String returns the RFC 6733 name of the record type.
+func (r RecordType) String() string {
+	switch r {
+	case EventRecord:
+		return "EVENT_RECORD"
+	case StartRecord:
+		return "START_RECORD"
+	case InterimRecord:
+		return "INTERIM_RECORD"
+	case StopRecord:
+		return "STOP_RECORD"
+	}
+	return "UNKNOWN"
+}
+
+// RealtimeRequired represents the value of an Accounting-Realtime-Required
+// AVP. The zero value means the AVP is absent.
+type RealtimeRequired uint32
+
+const (
+	DeliverAndGrant RealtimeRequired = 1
+	GrantAndStore   RealtimeRequired = 2
+	GrantAndLose    RealtimeRequired = 3
+)
+
+// String returns the RFC 6733 name of the value.
+func (r RealtimeRequired) String() string {
+	switch r {
+	case DeliverAndGrant:
+		return "DELIVER_AND_GRANT"
+	case GrantAndStore:
+		return "GRANT_AND_STORE"
+	case GrantAndLose:
+		return "GRANT_AND_LOSE"
+	}
+	return "UNKNOWN"
+}
+
+// GrantOnFailure reports whether service may continue when accounting
+// records cannot be delivered, which is only prohibited by DELIVER_AND_GRANT.
+func (r RealtimeRequired) GrantOnFailure() bool {
+	return r != DeliverAndGrant
+}
+
+// StoreOnFailure reports whether accounting records that cannot be
+// delivered must be stored and delivered later, as GRANT_AND_STORE requires.
+func (r RealtimeRequired) StoreOnFailure() bool {
+	return r == GrantAndStore
+}
+
+// ACR represents an Accounting-Request. AcctApplicationId defaults to the
+// base accounting application. AVPs without a field are carried in Avps and
+// appended after the others.
+type ACR struct {
+	SessionId           string
+	OriginHost          string
+	OriginRealm         string
+	DestinationRealm    string
+	DestinationHost     string
+	RecordType          RecordType
+	RecordNumber        uint32
+	AcctApplicationId   diameter.ApplicationId
+	UserName            string
+	AcctInterimInterval uint32
+	RealtimeRequired    RealtimeRequired
+	OriginStateId       uint32
+	EventTimestamp      time.Time
+	Avps                diameter.Avps
+}
+
+// acrCodes are the AVP codes ACR has fields for.
+var acrCodes = []diameter.Code{
+	diameter.AvpSessionId, diameter.AvpOriginHost, diameter.AvpOriginRealm, diameter.AvpDestinationRealm,
+	diameter.AvpAccountingRecordType, diameter.AvpAccountingRecordNumber, diameter.AvpAcctApplicationId,
+	diameter.AvpUserName, diameter.AvpDestinationHost, diameter.AvpAcctInterimInterval,
+	diameter.AvpAccountingRealtimeRequired, diameter.AvpOriginStateId, diameter.AvpEventTimestamp,
+}
+
+// applicationId returns the accounting application ID, defaulting to base accounting.
+func (a ACR) applicationId() diameter.ApplicationId {
+	if a.AcctApplicationId == 0 {
+		return diameter.ApplicationBaseAccounting
+	}
+	return a.AcctApplicationId
+}
+
+// ToMessage converts the ACR to a Diameter message.
+func (a ACR) ToMessage(hopByHopId [4]byte, endToEndId [4]byte) diameter.Message {
+	avps := diameter.NewAvps()
+	avps = avps.AddString(diameter.AvpSessionId, mandatoryFlags, 0, a.SessionId)
+	avps = avps.AddString(diameter.AvpOriginHost, mandatoryFlags, 0, a.OriginHost)
+	avps = avps.AddString(diameter.AvpOriginRealm, mandatoryFlags, 0, a.OriginRealm)
+	avps = avps.AddString(diameter.AvpDestinationRealm, mandatoryFlags, 0, a.DestinationRealm)
+	avps = avps.AddUint32(diameter.AvpAccountingRecordType, mandatoryFlags, 0, uint32(a.RecordType))
+	avps = avps.AddUint32(diameter.AvpAccountingRecordNumber, mandatoryFlags, 0, a.RecordNumber)
+	avps = avps.AddUint32(diameter.AvpAcctApplicationId, mandatoryFlags, 0, uint32(a.applicationId()))
+	if a.UserName != "" {
+		avps = avps.AddString(diameter.AvpUserName, mandatoryFlags, 0, a.UserName)
+	}
+	if a.DestinationHost != "" {
+		avps = avps.AddString(diameter.AvpDestinationHost, mandatoryFlags, 0, a.DestinationHost)
+	}
+	avps = addCommon(avps, a.AcctInterimInterval, a.RealtimeRequired, a.OriginStateId, a.EventTimestamp)
+	avps = append(avps, a.Avps...)
+	return diameter.NewMessage(1, diameter.FlagRequest|diameter.FlagProxiable, diameter.CommandAccounting, a.applicationId(), hopByHopId, endToEndId, avps...)
+}
+
+// FromMessage reads an ACR from a Diameter message.
+func (a *ACR) FromMessage(message diameter.Message) error {
+	if message.CommandCode != diameter.CommandAccounting || !message.IsRequest() {
+		return errors.New("message is not an Accounting-Request")
+	}
+	avps := message.Avps
+	a.SessionId = avps.GetFirst(diameter.AvpSessionId, 0).ToStringOrDefault()
+	a.OriginHost = avps.GetFirst(diameter.AvpOriginHost, 0).ToStringOrDefault()
+	a.OriginRealm = avps.GetFirst(diameter.AvpOriginRealm, 0).ToStringOrDefault()
+	a.DestinationRealm = avps.GetFirst(diameter.AvpDestinationRealm, 0).ToStringOrDefault()
+	a.DestinationHost = avps.GetFirst(diameter.AvpDestinationHost, 0).ToStringOrDefault()
+	a.RecordType = RecordType(avps.GetFirst(diameter.AvpAccountingRecordType, 0).ToUint32OrDefault())
+	a.RecordNumber = avps.GetFirst(diameter.AvpAccountingRecordNumber, 0).ToUint32OrDefault()
+	a.AcctApplicationId = diameter.ApplicationId(avps.GetFirst(diameter.AvpAcctApplicationId, 0).ToUint32OrDefault())
+	a.UserName = avps.GetFirst(diameter.AvpUserName, 0).ToStringOrDefault()
+	a.AcctInterimInterval = avps.GetFirst(diameter.AvpAcctInterimInterval, 0).ToUint32OrDefault()
+	a.RealtimeRequired = RealtimeRequired(avps.GetFirst(diameter.AvpAccountingRealtimeRequired, 0).ToUint32OrDefault())
+	a.OriginStateId = avps.GetFirst(diameter.AvpOriginStateId, 0).ToUint32OrDefault()
+	a.EventTimestamp = readTime(avps, diameter.AvpEventTimestamp)
+	a.Avps = avps.Without(0, acrCodes...)
+	return nil
+}
+
+// ACA represents an Accounting-Answer. AVPs without a field are carried in
+// Avps and appended after the others.
+type ACA struct {
+	ResultCode          diameter.ResultCode
+	OriginHost          string
+	OriginRealm         string
+	RecordType          RecordType
+	RecordNumber        uint32
+	AcctApplicationId   diameter.ApplicationId
+	UserName            string
+	ErrorMessage        string
+	AcctInterimInterval uint32
+	RealtimeRequired    RealtimeRequired
+	OriginStateId       uint32
+	EventTimestamp      time.Time
+	Avps                diameter.Avps
+}
+
+// acaCodes are the AVP codes ACA has fields for.
+var acaCodes = []diameter.Code{
+	diameter.AvpSessionId, diameter.AvpResultCode, diameter.AvpOriginHost, diameter.AvpOriginRealm,
+	diameter.AvpAccountingRecordType, diameter.AvpAccountingRecordNumber, diameter.AvpAcctApplicationId,
+	diameter.AvpUserName, diameter.AvpErrorMessage, diameter.AvpAcctInterimInterval,
+	diameter.AvpAccountingRealtimeRequired, diameter.AvpOriginStateId, diameter.AvpEventTimestamp,
+	diameter.AvpProxyInfo,
+}
+
+// NewACA creates an ACA answering the ACR with the result code.
+func NewACA(acr ACR, resultCode diameter.ResultCode, originHost string, originRealm string) ACA {
+	return ACA{
+		ResultCode:        resultCode,
+		OriginHost:        originHost,
+		OriginRealm:       originRealm,
+		RecordType:        acr.RecordType,
+		RecordNumber:      acr.RecordNumber,
+		AcctApplicationId: acr.applicationId(),
+		UserName:          acr.UserName,
+	}
+}
+
+// ToMessage converts the ACA to a Diameter message answering the request.
+func (a ACA) ToMessage(request diameter.Message) diameter.Message {
+	avps := diameter.NewAvps()
+	avps = avps.AddUint32(diameter.AvpResultCode, mandatoryFlags, 0, uint32(a.ResultCode))
+	avps = avps.AddString(diameter.AvpOriginHost, mandatoryFlags, 0, a.OriginHost)
+	avps = avps.AddString(diameter.AvpOriginRealm, mandatoryFlags, 0, a.OriginRealm)
+	avps = avps.AddUint32(diameter.AvpAccountingRecordType, mandatoryFlags, 0, uint32(a.RecordType))
+	avps = avps.AddUint32(diameter.AvpAccountingRecordNumber, mandatoryFlags, 0, a.RecordNumber)
+	if a.AcctApplicationId != 0 {
+		avps = avps.AddUint32(diameter.AvpAcctApplicationId, mandatoryFlags, 0, uint32(a.AcctApplicationId))
+	}
+	if a.UserName != "" {
+		avps = avps.AddString(diameter.AvpUserName, mandatoryFlags, 0, a.UserName)
+	}
+	if a.ErrorMessage != "" {
+		avps = avps.AddString(diameter.AvpErrorMessage, 0, 0, a.ErrorMessage)
+	}
+	avps = addCommon(avps, a.AcctInterimInterval, a.RealtimeRequired, a.OriginStateId, a.EventTimestamp)
+	avps = append(avps, a.Avps...)
+	return request.NewAnswer(avps...)
+}
+
+// FromMessage reads an ACA from a Diameter message.
+func (a *ACA) FromMessage(message diameter.Message) error {
+	if message.CommandCode != diameter.CommandAccounting || message.IsRequest() {
+		return errors.New("message is not an Accounting-Answer")
+	}
+	avps := message.Avps
+	a.ResultCode = diameter.ResultCode(avps.GetFirst(diameter.AvpResultCode, 0).ToUint32OrDefault())
+	a.OriginHost = avps.GetFirst(diameter.AvpOriginHost, 0).ToStringOrDefault()
+	a.OriginRealm = avps.GetFirst(diameter.AvpOriginRealm, 0).ToStringOrDefault()
+	a.RecordType = RecordType(avps.GetFirst(diameter.AvpAccountingRecordType, 0).ToUint32OrDefault())
+	a.RecordNumber = avps.GetFirst(diameter.AvpAccountingRecordNumber, 0).ToUint32OrDefault()
+	a.AcctApplicationId = diameter.ApplicationId(avps.GetFirst(diameter.AvpAcctApplicationId, 0).ToUint32OrDefault())
+	a.UserName = avps.GetFirst(diameter.AvpUserName, 0).ToStringOrDefault()
+	a.ErrorMessage = avps.GetFirst(diameter.AvpErrorMessage, 0).ToStringOrDefault()
+	a.AcctInterimInterval = avps.GetFirst(diameter.AvpAcctInterimInterval, 0).ToUint32OrDefault()
+	a.RealtimeRequired = RealtimeRequired(avps.GetFirst(diameter.AvpAccountingRealtimeRequired, 0).ToUint32OrDefault())
+	a.OriginStateId = avps.GetFirst(diameter.AvpOriginStateId, 0).ToUint32OrDefault()
+	a.EventTimestamp = readTime(avps, diameter.AvpEventTimestamp)
+	a.Avps = avps.Without(0, acaCodes...)
+	return nil
+}
+
+// addCommon adds the optional AVPs common to ACR and ACA if they are set.
+func addCommon(avps diameter.Avps, interimInterval uint32, realtimeRequired RealtimeRequired, originStateId uint32, eventTimestamp time.Time) diameter.Avps {
+	if interimInterval != 0 {
+		avps = avps.AddUint32(diameter.AvpAcctInterimInterval, mandatoryFlags, 0, interimInterval)
+	}
+	if realtimeRequired != 0 {
+		avps = avps.AddUint32(diameter.AvpAccountingRealtimeRequired, mandatoryFlags, 0, uint32(realtimeRequired))
+	}
+	if originStateId != 0 {
+		avps = avps.AddUint32(diameter.AvpOriginStateId, mandatoryFlags, 0, originStateId)
+	}
+	if !eventTimestamp.IsZero() {
+		avps = avps.AddTime(diameter.AvpEventTimestamp, mandatoryFlags, 0, eventTimestamp)
+	}
+	return avps
+}
+
+// readTime reads a time AVP, returning the zero time if it is absent.
+func readTime(avps diameter.Avps, code diameter.Code) time.Time {
+	if value, ok := avps.GetFirst(code, 0).ToTimeOk(); ok {
+		return value
+	}
+	return time.Time{}
+}
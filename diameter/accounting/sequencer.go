@@ -0,0 +1,75 @@
+package accounting
+
+import (
+	"errors"
+	"sync"
+)
+
+var (
+	// ErrSessionStarted is returned when a START or EVENT record is sequenced
+	// for a session that has already started.
+	ErrSessionStarted = errors.New("accounting session already started")
+	// ErrSessionNotStarted is returned when an INTERIM or STOP record is
+	// sequenced for a session that has not started.
+	ErrSessionNotStarted = errors.New("accounting session not started")
+)
+
+// Sequencer numbers the accounting records of each session, enforcing that
+// a session is a START, any number of INTERIMs and a STOP, or a single EVENT.
+// It is safe for concurrent use.
+type Sequencer struct {
+	mutex    sync.Mutex
+	sessions map[string]uint32
+}
+
+// NewSequencer creates a new Sequencer.
+func NewSequencer() *Sequencer {
+	return &Sequencer{sessions: make(map[string]uint32)}
+}
+
+// Next returns the Accounting-Record-Number of the session's next record of
+// the given type. The session is forgotten after its STOP record.
+func (s *Sequencer) Next(sessionId string, recordType RecordType) (uint32, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	number, started := s.sessions[sessionId]
+	switch recordType {
+	case EventRecord, StartRecord:
+		if started {
+			return 0, ErrSessionStarted
+		}
+		if recordType == StartRecord {
+			s.sessions[sessionId] = 0
+		}
+		return 0, nil
+	case InterimRecord, StopRecord:
+		if !started {
+			return 0, ErrSessionNotStarted
+		}
+		number++
+		s.sessions[sessionId] = number
+		if recordType == StopRecord {
+			delete(s.sessions, sessionId)
+		}
+		return number, nil
+	}
+	return 0, errors.New("unknown accounting record type")
+}
+
+// Sequence sets the RecordNumber of the ACR from its SessionId and RecordType.
+func (s *Sequencer) Sequence(acr *ACR) error {
+	number, err := s.Next(acr.SessionId, acr.RecordType)
+	if err != nil {
+		return err
+	}
+	acr.RecordNumber = number
+	return nil
+}
+
+// Active reports whether the session has started and not yet stopped.
+func (s *Sequencer) Active(sessionId string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	_, started := s.sessions[sessionId]
+	return started
+}
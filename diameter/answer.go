@@ -0,0 +1,21 @@
+package diameter
+
+// NewAnswer creates an answer to the request, with the same command code,
+// application ID and identifiers. The Session-Id of the request is placed
+// first, followed by the given AVPs and a copy of the request's Proxy-Info
+// AVPs. Route-Record AVPs are never copied into an answer.
+func (m Message) NewAnswer(avps ...Avp) Message {
+	answerAvps := NewAvps()
+	if sessionId := m.Avps.GetFirst(AvpSessionId, 0); sessionId != nil {
+		answerAvps = append(answerAvps, *sessionId)
+	}
+	for _, avp := range avps {
+		if avp.Code == AvpSessionId && avp.VendorId == 0 && len(answerAvps) > 0 {
+			continue
+		}
+		answerAvps = append(answerAvps, avp)
+	}
+	answerAvps = append(answerAvps, m.Avps.Get(AvpProxyInfo, 0)...)
+	flags := m.Flags & FlagProxiable
+	return NewMessage(m.Version, flags, m.CommandCode, m.ApplicationId, m.HopByHopId, m.EndToEndId, answerAvps...)
+}
@@ -0,0 +1,88 @@
+package diameter
+
+import "github.com/tinybluerobots/radius-diameter-message/threegpp"
+
+const (
+	// Vendor3GPP is the IANA enterprise number of 3GPP.
+	Vendor3GPP VendorId = 10415
+
+	AvpCalledStationId                 Code = 30
+	AvpServiceSelection                Code = 493
+	AvpThreeGPPChargingCharacteristics Code = 13
+)
+
+// NewAvpChargingCharacteristics creates a new 3GPP-Charging-Characteristics AVP.
+func NewAvpChargingCharacteristics(value threegpp.ChargingCharacteristics) Avp {
+	return NewAvpString(AvpThreeGPPChargingCharacteristics, FlagVendorSpecific, Vendor3GPP, value.String())
+}
+
+// AddChargingCharacteristics adds a new 3GPP-Charging-Characteristics AVP to the slice.
+func (a Avps) AddChargingCharacteristics(value threegpp.ChargingCharacteristics) Avps {
+	return append(a, NewAvpChargingCharacteristics(value))
+}
+
+// ToChargingCharacteristics converts the AVP to 3GPP charging characteristics.
+func (a *Avp) ToChargingCharacteristics() *threegpp.ChargingCharacteristics {
+	value, ok := a.ToChargingCharacteristicsOk()
+	if !ok {
+		return nil
+	}
+	return &value
+}
+
+// ToChargingCharacteristicsOrDefault converts the AVP to 3GPP charging characteristics or returns a default value.
+func (a *Avp) ToChargingCharacteristicsOrDefault() threegpp.ChargingCharacteristics {
+	value, _ := a.ToChargingCharacteristicsOk()
+	return value
+}
+
+// ToChargingCharacteristicsOk converts the AVP to 3GPP charging characteristics, reporting whether the AVP is present and well formed.
+func (a *Avp) ToChargingCharacteristicsOk() (threegpp.ChargingCharacteristics, bool) {
+	if a == nil || a.Data == nil {
+		return 0, false
+	}
+	value, err := threegpp.ParseChargingCharacteristics(string(a.Data))
+	return value, err == nil
+}
+
+// ToAPN converts the AVP to a normalized APN.
+func (a *Avp) ToAPN() *string {
+	value, ok := a.ToAPNOk()
+	if !ok {
+		return nil
+	}
+	return &value
+}
+
+// ToAPNOrDefault converts the AVP to a normalized APN or returns a default value.
+func (a *Avp) ToAPNOrDefault() string {
+	value, _ := a.ToAPNOk()
+	return value
+}
+
+// ToAPNOk converts the AVP to a normalized APN, reporting whether the AVP is present.
+func (a *Avp) ToAPNOk() (string, bool) {
+	value, ok := a.ToStringOk()
+	if !ok {
+		return "", false
+	}
+	return threegpp.NormalizeAPN(value), true
+}
+
+// ChargingCharacteristics retrieves the 3GPP-Charging-Characteristics AVP value.
+func (a Avps) ChargingCharacteristics() *threegpp.ChargingCharacteristics {
+	return a.GetFirst(AvpThreeGPPChargingCharacteristics, Vendor3GPP).ToChargingCharacteristics()
+}
+
+// CalledStationId retrieves the Called-Station-Id AVP value.
+func (a Avps) CalledStationId() *string {
+	return a.GetFirst(AvpCalledStationId, 0).ToString()
+}
+
+// APN retrieves the normalized APN from Called-Station-Id, falling back to Service-Selection.
+func (a Avps) APN() *string {
+	if apn := a.GetFirst(AvpCalledStationId, 0).ToAPN(); apn != nil {
+		return apn
+	}
+	return a.GetFirst(AvpServiceSelection, 0).ToAPN()
+}
@@ -0,0 +1,144 @@
+package archive
+
+import (
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/tinybluerobots/radius-diameter-message/diameter"
+)
+
+// Record is the stable column schema of an archived Diameter message: key
+// fields as columns and the whole message as dictionary-decoded JSON.
+type Record struct {
+	Timestamp        time.Time
+	CommandCode      uint32
+	ApplicationId    uint32
+	Request          bool
+	HopByHopId       uint32
+	EndToEndId       uint32
+	SessionId        string
+	OriginHost       string
+	OriginRealm      string
+	DestinationHost  string
+	DestinationRealm string
+	ResultCode       uint32
+	Message          string
+}
+
+// NewRecord creates a Record from a message captured at the timestamp,
+// using the dictionary, which may be nil, to decode the JSON column.
+func NewRecord(message diameter.Message, timestamp time.Time, dictionary *diameter.Dictionary) (Record, error) {
+	json, err := diameter.MarshalMessageJSON(message, dictionary)
+	if err != nil {
+		return Record{}, err
+	}
+	avps := message.Avps
+	return Record{
+		Timestamp:        timestamp.UTC(),
+		CommandCode:      uint32(message.CommandCode),
+		ApplicationId:    uint32(message.ApplicationId),
+		Request:          message.IsRequest(),
+		HopByHopId:       binary.BigEndian.Uint32(message.HopByHopId[:]),
+		EndToEndId:       binary.BigEndian.Uint32(message.EndToEndId[:]),
+		SessionId:        avps.GetFirst(diameter.AvpSessionId, 0).ToStringOrDefault(),
+		OriginHost:       avps.GetFirst(diameter.AvpOriginHost, 0).ToStringOrDefault(),
+		OriginRealm:      avps.GetFirst(diameter.AvpOriginRealm, 0).ToStringOrDefault(),
+		DestinationHost:  avps.GetFirst(diameter.AvpDestinationHost, 0).ToStringOrDefault(),
+		DestinationRealm: avps.GetFirst(diameter.AvpDestinationRealm, 0).ToStringOrDefault(),
+		ResultCode:       avps.GetFirst(diameter.AvpResultCode, 0).ToUint32OrDefault(),
+		Message:          string(json),
+	}, nil
+}
+
+// Sink writes archived records, e.g. to a database or a columnar file.
+type Sink interface {
+	Write(ctx context.Context, records ...Record) error
+}
+
+// columns are the column names of a Record, in field order.
+var columns = []string{
+	"timestamp", "command_code", "application_id", "request", "hop_by_hop_id", "end_to_end_id",
+	"session_id", "origin_host", "origin_realm", "destination_host", "destination_realm",
+	"result_code", "message",
+}
+
+// columnTypes are the portable SQL types of the columns.
+var columnTypes = []string{
+	"TIMESTAMP", "BIGINT", "BIGINT", "BOOLEAN", "BIGINT", "BIGINT",
+	"VARCHAR(255)", "VARCHAR(255)", "VARCHAR(255)", "VARCHAR(255)", "VARCHAR(255)",
+	"BIGINT", "TEXT",
+}
+
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// QuestionPlaceholder formats placeholders as "?", as used by MySQL and SQLite.
+func QuestionPlaceholder(int) string {
+	return "?"
+}
+
+// DollarPlaceholder formats placeholders as "$1", "$2"..., as used by PostgreSQL.
+func DollarPlaceholder(n int) string {
+	return fmt.Sprintf("$%d", n)
+}
+
+// SQLSink writes records to a table through database/sql.
+type SQLSink struct {
+	db     *sql.DB
+	table  string
+	insert string
+}
+
+// NewSQLSink creates a new SQLSink writing to the table, formatting query
+// placeholders with the given function, e.g. QuestionPlaceholder.
+func NewSQLSink(db *sql.DB, table string, placeholder func(n int) string) (*SQLSink, error) {
+	if !identifier.MatchString(table) {
+		return nil, fmt.Errorf("invalid table name %q", table)
+	}
+	placeholders := make([]string, len(columns))
+	for i := range columns {
+		placeholders[i] = placeholder(i + 1)
+	}
+	return &SQLSink{
+		db:     db,
+		table:  table,
+		insert: fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", table, strings.Join(columns, ", "), strings.Join(placeholders, ", ")),
+	}, nil
+}
+
+// CreateTable creates the table if it does not exist.
+func (s *SQLSink) CreateTable(ctx context.Context) error {
+	definitions := make([]string, len(columns))
+	for i, column := range columns {
+		definitions[i] = column + " " + columnTypes[i]
+	}
+	_, err := s.db.ExecContext(ctx, fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)", s.table, strings.Join(definitions, ", ")))
+	return err
+}
+
+// Write inserts the records in a single transaction.
+func (s *SQLSink) Write(ctx context.Context, records ...Record) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	statement, err := tx.PrepareContext(ctx, s.insert)
+	if err != nil {
+		return err
+	}
+	defer statement.Close()
+	for _, r := range records {
+		_, err := statement.ExecContext(ctx, r.Timestamp, int64(r.CommandCode), int64(r.ApplicationId), r.Request,
+			int64(r.HopByHopId), int64(r.EndToEndId), r.SessionId, r.OriginHost, r.OriginRealm,
+			r.DestinationHost, r.DestinationRealm, int64(r.ResultCode), r.Message)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,113 @@
+package diameter
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ChangeKind represents the kind of change made to an AVP.
+type ChangeKind byte
+
+const (
+	Added ChangeKind = iota
+	Removed
+	Modified
+)
+
+// String returns the name of the change kind.
+func (k ChangeKind) String() string {
+	switch k {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	case Modified:
+		return "modified"
+	}
+	return "unknown"
+}
+
+// Change records an AVP added, removed or modified on a TrackedMessage.
+// Before is nil for an added AVP and After is nil for a removed one.
+type Change struct {
+	Kind   ChangeKind
+	Before *Avp
+	After  *Avp
+}
+
+// String returns a single line description of the change for audit logs.
+func (c Change) String() string {
+	parts := []string{c.Kind.String()}
+	if c.Before != nil {
+		parts = append(parts, "before="+describeAvp(*c.Before))
+	}
+	if c.After != nil {
+		parts = append(parts, "after="+describeAvp(*c.After))
+	}
+	return strings.Join(parts, " ")
+}
+
+// describeAvp returns a short description of an AVP for audit logs.
+func describeAvp(avp Avp) string {
+	return fmt.Sprintf("%d/%d:%x", avp.VendorId, avp.Code, []byte(avp.Data))
+}
+
+// TrackedMessage wraps a Diameter message and records every change made to its top level AVPs.
+type TrackedMessage struct {
+	message Message
+	changes []Change
+}
+
+// Track wraps a copy of the message for change tracking.
+func Track(message Message) *TrackedMessage {
+	return &TrackedMessage{message: message.Clone()}
+}
+
+// Message returns a copy of the tracked message with all changes applied.
+func (t *TrackedMessage) Message() Message {
+	return t.message.Clone()
+}
+
+// Changes returns the changes made, in order.
+func (t *TrackedMessage) Changes() []Change {
+	return append([]Change(nil), t.changes...)
+}
+
+// Add adds AVPs to the end of the message.
+func (t *TrackedMessage) Add(avps ...Avp) *TrackedMessage {
+	for _, avp := range avps {
+		avp := avp.Clone()
+		t.message.Avps = append(t.message.Avps, avp)
+		t.changes = append(t.changes, Change{Kind: Added, After: &avp})
+	}
+	return t
+}
+
+// Remove removes every AVP with the given code and vendor ID.
+func (t *TrackedMessage) Remove(code Code, vendorId VendorId) *TrackedMessage {
+	avps := make(Avps, 0, len(t.message.Avps))
+	for _, avp := range t.message.Avps {
+		if avp.Code == code && avp.VendorId == vendorId {
+			before := avp
+			t.changes = append(t.changes, Change{Kind: Removed, Before: &before})
+			continue
+		}
+		avps = append(avps, avp)
+	}
+	t.message.Avps = avps
+	return t
+}
+
+// Replace replaces the first AVP with the same code and vendor ID as the given
+// AVP, adding it to the end of the message if there is none.
+func (t *TrackedMessage) Replace(avp Avp) *TrackedMessage {
+	index := t.message.Avps.index(avp.Code, avp.VendorId)
+	if index < 0 {
+		return t.Add(avp)
+	}
+	before := t.message.Avps[index]
+	after := avp.Clone()
+	t.message.Avps[index] = after
+	t.changes = append(t.changes, Change{Kind: Modified, Before: &before, After: &after})
+	return t
+}
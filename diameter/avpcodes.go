@@ -0,0 +1,54 @@
+package diameter
+
+// Base protocol AVP codes (RFC 6733).
+const (
+	AvpUserName                    Code = 1
+	AvpClass                       Code = 25
+	AvpSessionTimeout              Code = 27
+	AvpProxyState                  Code = 33
+	AvpAccountingSessionId         Code = 44
+	AvpAcctMultiSessionId          Code = 50
+	AvpEventTimestamp              Code = 55
+	AvpAcctInterimInterval         Code = 85
+	AvpHostIPAddress               Code = 257
+	AvpAuthApplicationId           Code = 258
+	AvpAcctApplicationId           Code = 259
+	AvpVendorSpecificApplicationId Code = 260
+	AvpRedirectHostUsage           Code = 261
+	AvpRedirectMaxCacheTime        Code = 262
+	AvpSessionId                   Code = 263
+	AvpOriginHost                  Code = 264
+	AvpSupportedVendorId           Code = 265
+	AvpVendorId                    Code = 266
+	AvpFirmwareRevision            Code = 267
+	AvpResultCode                  Code = 268
+	AvpProductName                 Code = 269
+	AvpSessionBinding              Code = 270
+	AvpSessionServerFailover       Code = 271
+	AvpMultiRoundTimeOut           Code = 272
+	AvpDisconnectCause             Code = 273
+	AvpAuthRequestType             Code = 274
+	AvpAuthGracePeriod             Code = 276
+	AvpAuthSessionState            Code = 277
+	AvpOriginStateId               Code = 278
+	AvpFailedAvp                   Code = 279
+	AvpProxyHost                   Code = 280
+	AvpErrorMessage                Code = 281
+	AvpRouteRecord                 Code = 282
+	AvpDestinationRealm            Code = 283
+	AvpProxyInfo                   Code = 284
+	AvpReAuthRequestType           Code = 285
+	AvpAccountingSubSessionId      Code = 287
+	AvpAuthorizationLifetime       Code = 291
+	AvpRedirectHost                Code = 292
+	AvpDestinationHost             Code = 293
+	AvpErrorReportingHost          Code = 294
+	AvpTerminationCause            Code = 295
+	AvpOriginRealm                 Code = 296
+	AvpExperimentalResult          Code = 297
+	AvpExperimentalResultCode      Code = 298
+	AvpInbandSecurityId            Code = 299
+	AvpAccountingRecordType        Code = 480
+	AvpAccountingRealtimeRequired  Code = 483
+	AvpAccountingRecordNumber      Code = 485
+)
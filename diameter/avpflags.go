@@ -0,0 +1,71 @@
+package diameter
+
+// AVP header flags.
+const (
+	FlagVendorSpecific Flags = 0x80
+	FlagMandatory      Flags = 0x40
+	FlagProtected      Flags = 0x20
+)
+
+// IsVendorSpecific reports whether the V bit is set.
+func (f Flags) IsVendorSpecific() bool {
+	return f&FlagVendorSpecific != 0
+}
+
+// IsMandatory reports whether the M bit is set.
+func (f Flags) IsMandatory() bool {
+	return f&FlagMandatory != 0
+}
+
+// IsProtected reports whether the P bit is set.
+func (f Flags) IsProtected() bool {
+	return f&FlagProtected != 0
+}
+
+// vendorFlags sets the V bit if the vendor ID is not zero and clears it otherwise.
+func vendorFlags(flags Flags, vendorId VendorId) Flags {
+	flags.set(FlagVendorSpecific, vendorId != 0)
+	return flags
+}
+
+// VendorSpecific reports whether the AVP has a vendor ID.
+func (a *Avp) VendorSpecific() bool {
+	return a != nil && a.Flags.IsVendorSpecific()
+}
+
+// Mandatory reports whether the AVP must be supported by the receiver.
+func (a *Avp) Mandatory() bool {
+	return a != nil && a.Flags.IsMandatory()
+}
+
+// Protected reports whether the AVP is protected for end-to-end security.
+func (a *Avp) Protected() bool {
+	return a != nil && a.Flags.IsProtected()
+}
+
+// WithMandatory sets or clears the M bit of the AVP.
+func (a *Avp) WithMandatory(value bool) *Avp {
+	if a == nil {
+		return nil
+	}
+	a.Flags.set(FlagMandatory, value)
+	return a
+}
+
+// WithProtected sets or clears the P bit of the AVP.
+func (a *Avp) WithProtected(value bool) *Avp {
+	if a == nil {
+		return nil
+	}
+	a.Flags.set(FlagProtected, value)
+	return a
+}
+
+// WithVendor sets the vendor ID of the AVP, updating the V bit and length to match.
+func (a *Avp) WithVendor(vendorId VendorId) *Avp {
+	if a == nil {
+		return nil
+	}
+	*a = NewAvp(a.Code, a.Flags, vendorId, a.Data)
+	return a
+}
@@ -0,0 +1,76 @@
+package diameter
+
+import "sort"
+
+// Canonicalization selects how a message is normalized before it is re-encoded,
+// e.g. by a proxy forwarding it to a peer. The zero value preserves the message
+// exactly as it was received, including non-zero padding and reserved flag bits.
+type Canonicalization struct {
+	// ZeroPadding discards padding bytes received with non-zero values.
+	ZeroPadding bool
+	// ClearReservedBits clears reserved header and AVP flag bits.
+	ClearReservedBits bool
+	// ClearProtectedBit clears the AVP P bit, which RFC 6733 deprecates.
+	ClearProtectedBit bool
+	// SessionIdFirst moves the Session-Id AVP to the front of the message.
+	SessionIdFirst bool
+	// SortAvps orders AVPs by vendor ID then code, keeping repeated AVPs in order.
+	SortAvps bool
+	// Dictionary, if set, identifies grouped AVPs so their children are
+	// canonicalized too, down to DefaultMaxDepth levels of nesting.
+	Dictionary *Dictionary
+}
+
+var (
+	// Preserve re-encodes messages exactly as received.
+	Preserve = Canonicalization{}
+	// Interop re-encodes messages in the form RFC 6733 requires of senders.
+	Interop = Canonicalization{ZeroPadding: true, ClearReservedBits: true, ClearProtectedBit: true, SessionIdFirst: true}
+)
+
+// Canonicalize returns a copy of the message normalized according to the canonicalization.
+func (m Message) Canonicalize(c Canonicalization) Message {
+	m = m.Clone()
+	if c.ClearReservedBits {
+		m.Flags &= FlagRequest | FlagProxiable | FlagError | FlagRetransmitted
+	}
+	m.Avps = c.avps(m.Avps, 0)
+	if c.SessionIdFirst {
+		if index := m.Avps.index(AvpSessionId, 0); index > 0 {
+			sessionId := m.Avps[index]
+			copy(m.Avps[1:index+1], m.Avps[:index])
+			m.Avps[0] = sessionId
+		}
+	}
+	return m
+}
+
+// avps canonicalizes a slice of AVPs, recursing into grouped AVPs known to the dictionary.
+func (c Canonicalization) avps(avps Avps, depth int) Avps {
+	for i, avp := range avps {
+		if c.ZeroPadding {
+			avp.pad = nil
+		}
+		if c.ClearReservedBits {
+			avp.Flags &= FlagVendorSpecific | FlagMandatory | FlagProtected
+		}
+		if c.ClearProtectedBit {
+			avp.Flags &^= FlagProtected
+		}
+		if definition := c.Dictionary.Avp(avp.Code, avp.VendorId); definition != nil && definition.Type == Grouped && depth < DefaultMaxDepth {
+			if children, err := decodeAvps(avp.Data); err == nil {
+				avp = NewAvpGroup(avp.Code, avp.Flags, avp.VendorId, c.avps(children, depth+1)...)
+			}
+		}
+		avps[i] = avp
+	}
+	if c.SortAvps {
+		sort.SliceStable(avps, func(i, j int) bool {
+			if avps[i].VendorId != avps[j].VendorId {
+				return avps[i].VendorId < avps[j].VendorId
+			}
+			return avps[i].Code < avps[j].Code
+		})
+	}
+	return avps
+}
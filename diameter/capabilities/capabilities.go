@@ -0,0 +1,239 @@
+package capabilities
+
+import (
+	"errors"
+	"net"
+	"slices"
+
+	"github.com/tinybluerobots/radius-diameter-message/diameter"
+)
+
+const mandatoryFlags = diameter.FlagMandatory
+
+// VendorSpecificApplicationId represents a Vendor-Specific-Application-Id AVP.
+// Exactly one of AuthApplicationId and AcctApplicationId should be set.
+type VendorSpecificApplicationId struct {
+	VendorId          diameter.VendorId
+	AuthApplicationId diameter.ApplicationId
+	AcctApplicationId diameter.ApplicationId
+}
+
+// Capabilities represents the capabilities a Diameter node advertises in a CER or CEA.
+// Zero values are omitted from messages.
+type Capabilities struct {
+	OriginHost                   string
+	OriginRealm                  string
+	HostIPAddresses              []net.IP
+	VendorId                     diameter.VendorId
+	ProductName                  string
+	OriginStateId                uint32
+	SupportedVendorIds           []diameter.VendorId
+	AuthApplicationIds           []diameter.ApplicationId
+	AcctApplicationIds           []diameter.ApplicationId
+	VendorSpecificApplicationIds []VendorSpecificApplicationId
+	InbandSecurityIds            []uint32
+	FirmwareRevision             uint32
+}
+
+// CER represents a Capabilities-Exchange-Request.
+type CER struct {
+	Capabilities
+}
+
+// CEA represents a Capabilities-Exchange-Answer.
+type CEA struct {
+	ResultCode   diameter.ResultCode
+	ErrorMessage string
+	Capabilities
+}
+
+// ToMessage converts the CER to a Diameter message.
+func (c CER) ToMessage(hopByHopId [4]byte, endToEndId [4]byte) diameter.Message {
+	return diameter.NewMessage(1, diameter.FlagRequest, diameter.CommandCapabilitiesExchange, diameter.ApplicationCommon, hopByHopId, endToEndId, c.Capabilities.toAvps()...)
+}
+
+// FromMessage reads a CER from a Diameter message.
+func (c *CER) FromMessage(message diameter.Message) error {
+	if message.CommandCode != diameter.CommandCapabilitiesExchange || !message.IsRequest() {
+		return errors.New("message is not a Capabilities-Exchange-Request")
+	}
+	c.Capabilities.fromAvps(message.Avps)
+	return nil
+}
+
+// ToMessage converts the CEA to a Diameter message answering the request.
+func (c CEA) ToMessage(request diameter.Message) diameter.Message {
+	avps := diameter.NewAvps()
+	avps = avps.AddUint32(diameter.AvpResultCode, mandatoryFlags, 0, uint32(c.ResultCode))
+	avps = append(avps, c.Capabilities.toAvps()...)
+	if c.ErrorMessage != "" {
+		avps = avps.AddString(diameter.AvpErrorMessage, 0, 0, c.ErrorMessage)
+	}
+	answer := request.NewAnswer(avps...)
+	answer.SetProxiable(false)
+	return answer
+}
+
+// FromMessage reads a CEA from a Diameter message.
+func (c *CEA) FromMessage(message diameter.Message) error {
+	if message.CommandCode != diameter.CommandCapabilitiesExchange || message.IsRequest() {
+		return errors.New("message is not a Capabilities-Exchange-Answer")
+	}
+	c.ResultCode = diameter.ResultCode(message.Avps.GetFirst(diameter.AvpResultCode, 0).ToUint32OrDefault())
+	c.ErrorMessage = message.Avps.GetFirst(diameter.AvpErrorMessage, 0).ToStringOrDefault()
+	c.Capabilities.fromAvps(message.Avps)
+	return nil
+}
+
+// toAvps converts the capabilities to AVPs in the order of the RFC 6733 CCF.
+func (c Capabilities) toAvps() diameter.Avps {
+	avps := diameter.NewAvps()
+	avps = avps.AddString(diameter.AvpOriginHost, mandatoryFlags, 0, c.OriginHost)
+	avps = avps.AddString(diameter.AvpOriginRealm, mandatoryFlags, 0, c.OriginRealm)
+	for _, address := range c.HostIPAddresses {
+		avps = avps.AddNetIP(diameter.AvpHostIPAddress, mandatoryFlags, 0, address)
+	}
+	avps = avps.AddUint32(diameter.AvpVendorId, mandatoryFlags, 0, uint32(c.VendorId))
+	avps = avps.AddString(diameter.AvpProductName, 0, 0, c.ProductName)
+	if c.OriginStateId != 0 {
+		avps = avps.AddUint32(diameter.AvpOriginStateId, mandatoryFlags, 0, c.OriginStateId)
+	}
+	for _, vendorId := range c.SupportedVendorIds {
+		avps = avps.AddUint32(diameter.AvpSupportedVendorId, mandatoryFlags, 0, uint32(vendorId))
+	}
+	for _, applicationId := range c.AuthApplicationIds {
+		avps = avps.AddUint32(diameter.AvpAuthApplicationId, mandatoryFlags, 0, uint32(applicationId))
+	}
+	for _, securityId := range c.InbandSecurityIds {
+		avps = avps.AddUint32(diameter.AvpInbandSecurityId, mandatoryFlags, 0, securityId)
+	}
+	for _, applicationId := range c.AcctApplicationIds {
+		avps = avps.AddUint32(diameter.AvpAcctApplicationId, mandatoryFlags, 0, uint32(applicationId))
+	}
+	for _, applicationId := range c.VendorSpecificApplicationIds {
+		avps = append(avps, applicationId.toAvp())
+	}
+	if c.FirmwareRevision != 0 {
+		avps = avps.AddUint32(diameter.AvpFirmwareRevision, 0, 0, c.FirmwareRevision)
+	}
+	return avps
+}
+
+// fromAvps reads the capabilities from AVPs.
+func (c *Capabilities) fromAvps(avps diameter.Avps) {
+	c.OriginHost = avps.GetFirst(diameter.AvpOriginHost, 0).ToStringOrDefault()
+	c.OriginRealm = avps.GetFirst(diameter.AvpOriginRealm, 0).ToStringOrDefault()
+	c.HostIPAddresses = nil
+	for _, avp := range avps.Get(diameter.AvpHostIPAddress, 0) {
+		if address, ok := avp.ToNetIPOk(); ok {
+			c.HostIPAddresses = append(c.HostIPAddresses, address)
+		}
+	}
+	c.VendorId = diameter.VendorId(avps.GetFirst(diameter.AvpVendorId, 0).ToUint32OrDefault())
+	c.ProductName = avps.GetFirst(diameter.AvpProductName, 0).ToStringOrDefault()
+	c.OriginStateId = avps.GetFirst(diameter.AvpOriginStateId, 0).ToUint32OrDefault()
+	c.SupportedVendorIds = nil
+	for _, avp := range avps.Get(diameter.AvpSupportedVendorId, 0) {
+		c.SupportedVendorIds = append(c.SupportedVendorIds, diameter.VendorId(avp.ToUint32OrDefault()))
+	}
+	c.AuthApplicationIds = readApplicationIds(avps, diameter.AvpAuthApplicationId)
+	c.AcctApplicationIds = readApplicationIds(avps, diameter.AvpAcctApplicationId)
+	c.VendorSpecificApplicationIds = nil
+	for _, avp := range avps.Get(diameter.AvpVendorSpecificApplicationId, 0) {
+		group := avp.ToGroup()
+		c.VendorSpecificApplicationIds = append(c.VendorSpecificApplicationIds, VendorSpecificApplicationId{
+			VendorId:          diameter.VendorId(group.GetFirst(diameter.AvpVendorId, 0).ToUint32OrDefault()),
+			AuthApplicationId: diameter.ApplicationId(group.GetFirst(diameter.AvpAuthApplicationId, 0).ToUint32OrDefault()),
+			AcctApplicationId: diameter.ApplicationId(group.GetFirst(diameter.AvpAcctApplicationId, 0).ToUint32OrDefault()),
+		})
+	}
+	c.InbandSecurityIds = nil
+	for _, avp := range avps.Get(diameter.AvpInbandSecurityId, 0) {
+		c.InbandSecurityIds = append(c.InbandSecurityIds, avp.ToUint32OrDefault())
+	}
+	c.FirmwareRevision = avps.GetFirst(diameter.AvpFirmwareRevision, 0).ToUint32OrDefault()
+}
+
+// readApplicationIds reads every application ID AVP with the given code.
+func readApplicationIds(avps diameter.Avps, code diameter.Code) []diameter.ApplicationId {
+	var applicationIds []diameter.ApplicationId
+	for _, avp := range avps.Get(code, 0) {
+		applicationIds = append(applicationIds, diameter.ApplicationId(avp.ToUint32OrDefault()))
+	}
+	return applicationIds
+}
+
+// toAvp converts the Vendor-Specific-Application-Id to a grouped AVP.
+func (v VendorSpecificApplicationId) toAvp() diameter.Avp {
+	avps := diameter.NewAvps().AddUint32(diameter.AvpVendorId, mandatoryFlags, 0, uint32(v.VendorId))
+	if v.AuthApplicationId != 0 {
+		avps = avps.AddUint32(diameter.AvpAuthApplicationId, mandatoryFlags, 0, uint32(v.AuthApplicationId))
+	}
+	if v.AcctApplicationId != 0 {
+		avps = avps.AddUint32(diameter.AvpAcctApplicationId, mandatoryFlags, 0, uint32(v.AcctApplicationId))
+	}
+	return diameter.NewAvpGroup(diameter.AvpVendorSpecificApplicationId, mandatoryFlags, 0, avps...)
+}
+
+// Applications represents the applications two peers have in common.
+type Applications struct {
+	Auth []diameter.ApplicationId
+	Acct []diameter.ApplicationId
+}
+
+// Empty reports whether the peers have no applications in common.
+func (a Applications) Empty() bool {
+	return len(a.Auth) == 0 && len(a.Acct) == 0
+}
+
+// applicationIds returns every auth or acct application ID advertised, including vendor specific ones.
+func (c Capabilities) applicationIds(auth bool) []diameter.ApplicationId {
+	applicationIds := slices.Clone(c.AcctApplicationIds)
+	if auth {
+		applicationIds = slices.Clone(c.AuthApplicationIds)
+	}
+	for _, vendorSpecific := range c.VendorSpecificApplicationIds {
+		applicationId := vendorSpecific.AcctApplicationId
+		if auth {
+			applicationId = vendorSpecific.AuthApplicationId
+		}
+		if applicationId != 0 {
+			applicationIds = append(applicationIds, applicationId)
+		}
+	}
+	return applicationIds
+}
+
+// Negotiate computes the applications the local and remote peers have in
+// common. A peer advertising the Relay application supports every application.
+func Negotiate(local Capabilities, remote Capabilities) Applications {
+	return Applications{
+		Auth: common(local.applicationIds(true), remote.applicationIds(true)),
+		Acct: common(local.applicationIds(false), remote.applicationIds(false)),
+	}
+}
+
+// common returns the application IDs in both lists, in the order of the first.
+func common(local []diameter.ApplicationId, remote []diameter.ApplicationId) []diameter.ApplicationId {
+	if slices.Contains(local, diameter.ApplicationRelay) {
+		return slices.Compact(remote)
+	}
+	applicationIds := make([]diameter.ApplicationId, 0)
+	relay := slices.Contains(remote, diameter.ApplicationRelay)
+	for _, applicationId := range local {
+		if (relay || slices.Contains(remote, applicationId)) && !slices.Contains(applicationIds, applicationId) {
+			applicationIds = append(applicationIds, applicationId)
+		}
+	}
+	return applicationIds
+}
+
+// Answer creates the CEA a node with the local capabilities sends in reply to
+// the CER, with DIAMETER_NO_COMMON_APPLICATION if they share no applications.
+func Answer(local Capabilities, cer CER) CEA {
+	cea := CEA{ResultCode: diameter.ResultCodeSuccess, Capabilities: local}
+	if Negotiate(local, cer.Capabilities).Empty() {
+		cea.ResultCode = diameter.ResultCodeNoCommonApplication
+	}
+	return cea
+}
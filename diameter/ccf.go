@@ -0,0 +1,164 @@
+package diameter
+
+import "fmt"
+
+// Unbounded is the maximum occurrence of a Rule that may repeat without limit.
+const Unbounded = -1
+
+// Rule describes how many times an AVP may occur in a Grammar. Group, if set,
+// is the grammar the AVP's children are validated against.
+type Rule struct {
+	Code     Code
+	VendorId VendorId
+	Min      int
+	Max      int
+	Group    *Grammar
+}
+
+// NewRule creates a new Rule allowing between min and max occurrences of an AVP.
+func NewRule(code Code, vendorId VendorId, min int, max int) Rule {
+	return Rule{
+		Code:     code,
+		VendorId: vendorId,
+		Min:      min,
+		Max:      max,
+	}
+}
+
+// WithGroup sets the grammar the AVP's children are validated against.
+func (r Rule) WithGroup(grammar Grammar) Rule {
+	r.Group = &grammar
+	return r
+}
+
+// Grammar describes the AVPs allowed in a command or grouped AVP, in the style
+// of the RFC 6733 Command Code Format. Fixed AVPs must appear first and in order.
+// AnyAvp permits AVPs not covered by a rule, i.e. "* [ AVP ]".
+type Grammar struct {
+	Fixed    []Rule
+	Required []Rule
+	Optional []Rule
+	AnyAvp   bool
+}
+
+// CCF represents the Command Code Format of a request or answer.
+type CCF struct {
+	Name        string
+	CommandCode CommandCode
+	Request     bool
+	Grammar
+}
+
+// Violation describes an AVP that breaks a grammar. Avp is the offending AVP,
+// or an empty AVP with the expected code for a missing one, and Parents are
+// the grouped AVPs it is nested in, outermost first.
+type Violation struct {
+	ResultCode ResultCode
+	Avp        Avp
+	Parents    Avps
+	Reason     string
+}
+
+// Error returns the reason for the violation.
+func (v Violation) Error() string {
+	return fmt.Sprintf("AVP %d (vendor %d): %s", v.Avp.Code, v.Avp.VendorId, v.Reason)
+}
+
+// Validate checks the message against the command grammar, returning every violation found.
+func (m Message) Validate(ccf CCF) []Violation {
+	if m.CommandCode != ccf.CommandCode || m.IsRequest() != ccf.Request {
+		return []Violation{{
+			ResultCode: ResultCodeCommandUnsupported,
+			Reason:     fmt.Sprintf("message is not a %s", ccf.Name),
+		}}
+	}
+	return ccf.Grammar.Validate(m.Avps)
+}
+
+// Validate checks the AVPs against the grammar, returning every violation found.
+func (g Grammar) Validate(avps Avps) []Violation {
+	return g.validate(avps, nil)
+}
+
+// validate checks the AVPs against the grammar, recursing into grouped AVPs.
+func (g Grammar) validate(avps Avps, parents Avps) []Violation {
+	violations := make([]Violation, 0)
+	report := func(resultCode ResultCode, avp Avp, reason string) {
+		violations = append(violations, Violation{resultCode, avp, parents, reason})
+	}
+	for i, rule := range g.Fixed {
+		if i >= len(avps) || !rule.matches(avps[i]) {
+			if position := avps.index(rule.Code, rule.VendorId); position >= 0 {
+				report(ResultCodeAvpNotAllowed, avps[position], fmt.Sprintf("must be at position %d", i))
+			}
+		}
+	}
+	rules := make([]Rule, 0, len(g.Fixed)+len(g.Required)+len(g.Optional))
+	rules = append(append(append(rules, g.Fixed...), g.Required...), g.Optional...)
+	for _, rule := range rules {
+		matching := avps.Get(rule.Code, rule.VendorId)
+		if len(matching) < rule.Min {
+			report(ResultCodeMissingAvp, rule.missingAvp(), fmt.Sprintf("occurs %d times, minimum is %d", len(matching), rule.Min))
+		}
+		if rule.Max != Unbounded && len(matching) > rule.Max {
+			reason := fmt.Sprintf("occurs %d times, maximum is %d", len(matching), rule.Max)
+			if rule.Max == 0 {
+				report(ResultCodeAvpNotAllowed, matching[0], reason)
+			} else {
+				report(ResultCodeAvpOccursTooManyTimes, matching[rule.Max], reason)
+			}
+		}
+		if rule.Group == nil {
+			continue
+		}
+		for _, avp := range matching {
+			children, err := decodeAvps(avp.Data)
+			if err != nil {
+				report(ResultCodeInvalidAvpLength, avp, err.Error())
+				continue
+			}
+			nested := append(append(Avps{}, parents...), avp)
+			violations = append(violations, rule.Group.validate(children, nested)...)
+		}
+	}
+	if !g.AnyAvp {
+		for _, avp := range avps {
+			if !g.allows(avp) {
+				report(ResultCodeAvpNotAllowed, avp, "is not allowed")
+			}
+		}
+	}
+	return violations
+}
+
+// allows reports whether any rule of the grammar matches the AVP.
+func (g Grammar) allows(avp Avp) bool {
+	for _, rules := range [][]Rule{g.Fixed, g.Required, g.Optional} {
+		for _, rule := range rules {
+			if rule.matches(avp) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matches reports whether the rule applies to the AVP.
+func (r Rule) matches(avp Avp) bool {
+	return avp.Code == r.Code && avp.VendorId == r.VendorId
+}
+
+// missingAvp synthesizes an empty AVP with the rule's code, for reporting a missing AVP.
+func (r Rule) missingAvp() Avp {
+	return NewAvpMissing(r.Code, r.VendorId, 0)
+}
+
+// index returns the position of the first AVP with the given code and vendor ID, or -1.
+func (a Avps) index(code Code, vendorId VendorId) int {
+	for i, avp := range a {
+		if avp.Code == code && avp.VendorId == vendorId {
+			return i
+		}
+	}
+	return -1
+}
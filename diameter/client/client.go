@@ -0,0 +1,417 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/tinybluerobots/radius-diameter-message/diameter"
+	"github.com/tinybluerobots/radius-diameter-message/diameter/capabilities"
+	"github.com/tinybluerobots/radius-diameter-message/diameter/disconnect"
+	"github.com/tinybluerobots/radius-diameter-message/diameter/metrics"
+	"github.com/tinybluerobots/radius-diameter-message/diameter/originstate"
+	"github.com/tinybluerobots/radius-diameter-message/diameter/transaction"
+	"github.com/tinybluerobots/radius-diameter-message/diameter/watchdog"
+)
+
+// ErrClosed is returned when sending a request on a closed client.
+var ErrClosed = errors.New("client closed")
+
+// Handler answers a request sent by the peer, such as a RAR.
+type Handler func(request diameter.Message) diameter.Message
+
+// Config configures a Client.
+type Config struct {
+	// Capabilities are the local capabilities sent in the CER.
+	Capabilities capabilities.Capabilities
+	// Tw is the watchdog interval, which defaults to 30 seconds.
+	Tw time.Duration
+	// Handler answers requests sent by the peer. If nil, they are answered
+	// with DIAMETER_COMMAND_UNSUPPORTED.
+	Handler Handler
+	// Middleware wraps Handler, the first running first.
+	Middleware []Middleware
+	// SendMiddleware wraps SendRequest, the first running first.
+	SendMiddleware []SendMiddleware
+	// Ids generates the identifiers of requests. A new generator is used if nil.
+	Ids *diameter.IdGenerator
+	// Metrics receives measurements of the connection. Nothing is measured if nil.
+	Metrics metrics.Sink
+	// OriginState, if set, supplies the Origin-State-Id of the CER and of
+	// requests without one, and observes the peer's to detect restarts.
+	OriginState *originstate.Tracker
+	// Transactions configures the table correlating requests with answers.
+	// The transactions of its Store, if it has one, are restored when the
+	// client is created, and Ids resumes after their identifiers. Its Ids
+	// defaults to the client's.
+	Transactions transaction.Config
+	// OnUnsolicited receives every answer that matched no pending request,
+	// after it is counted by Metrics. Such answers are dropped if nil.
+	OnUnsolicited UnsolicitedHandler
+	// WriteQueue is how many messages of each Priority may wait to be
+	// written before the queue of that class is full, which defaults to 64.
+	WriteQueue int
+	// OnQueueFull decides what becomes of a message whose class of the write
+	// queue is full. If nil, its sender waits for room.
+	OnQueueFull QueueFullPolicy
+	// WriteTimeout bounds the time from queueing a message to finishing
+	// writing it, unless the sender's context has an earlier deadline. A
+	// message not written in time fails with os.ErrDeadlineExceeded, and if
+	// its write had begun the connection is closed, since the peer has
+	// received part of it. There is no bound if zero.
+	WriteTimeout time.Duration
+}
+
+// Client is a connection to a Diameter peer. It performs the capabilities
+// exchange, maintains the connection with watchdog requests, and correlates
+// requests with their answers by Hop-by-Hop identifier. It is safe for
+// concurrent use.
+type Client struct {
+	conn         net.Conn
+	config       Config
+	peer         capabilities.Capabilities
+	applications capabilities.Applications
+	watchdog     *watchdog.Watchdog
+	cancel       context.CancelFunc
+	writes       [PriorityWatchdog + 1]chan *queuedWrite
+	transactions *transaction.Table
+	handler      Handler
+	metrics      metrics.Sink
+	send         Sender
+	done         chan struct{}
+	closeOnce    sync.Once
+	err          error
+}
+
+// Dial connects to the peer at the TCP address and performs the capabilities exchange.
+func Dial(ctx context.Context, address string, config Config) (*Client, error) {
+	dialer := net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	return New(ctx, conn, config)
+}
+
+// New performs the capabilities exchange on an established connection,
+// closing it if the exchange fails.
+func New(ctx context.Context, conn net.Conn, config Config) (*Client, error) {
+	if config.Ids == nil {
+		config.Ids = diameter.NewIdGenerator()
+	}
+	if config.Transactions.Ids == nil {
+		config.Transactions.Ids = config.Ids
+	}
+	if config.OriginState != nil && config.Capabilities.OriginStateId == 0 {
+		config.Capabilities.OriginStateId = config.OriginState.Local()
+	}
+	if config.WriteQueue <= 0 {
+		config.WriteQueue = 64
+	}
+	c := &Client{
+		conn:         conn,
+		config:       config,
+		transactions: transaction.New(config.Transactions),
+		metrics:      metrics.OrNop(config.Metrics),
+		done:         make(chan struct{}),
+	}
+	if config.Handler == nil {
+		config.Handler = c.unsupported
+	}
+	if err := c.transactions.Restore(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	c.handler = chainHandler(config.Handler, config.Middleware)
+	c.send = chainSender(c.roundTrip, config.SendMiddleware)
+	for priority := range c.writes {
+		c.writes[priority] = make(chan *queuedWrite, config.WriteQueue)
+	}
+	go c.writeLoop()
+	reader := bufio.NewReader(conn)
+	if err := c.exchangeCapabilities(ctx, reader); err != nil {
+		c.close(err)
+		return nil, err
+	}
+	c.watchdog = watchdog.New(watchdog.Config{
+		Tw:            config.Tw,
+		OriginHost:    config.Capabilities.OriginHost,
+		OriginRealm:   config.Capabilities.OriginRealm,
+		OriginStateId: config.Capabilities.OriginStateId,
+		Ids:           config.Ids,
+		OnStateChange: func(state watchdog.State) {
+			c.metrics.WatchdogState(c.peer.OriginHost, state)
+		},
+	}, c.post)
+	watchdogCtx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+	go c.read(reader)
+	go func() {
+		if err := c.watchdog.Run(watchdogCtx); errors.Is(err, watchdog.ErrPeerFailed) {
+			c.close(err)
+		}
+	}()
+	return c, nil
+}
+
+// exchangeCapabilities sends the CER and reads the CEA.
+func (c *Client) exchangeCapabilities(ctx context.Context, reader *bufio.Reader) error {
+	stop := context.AfterFunc(ctx, func() {
+		c.conn.SetDeadline(time.Now())
+	})
+	defer func() {
+		stop()
+		c.conn.SetDeadline(time.Time{})
+	}()
+	cer := capabilities.CER{Capabilities: c.config.Capabilities}
+	if err := c.writeContext(ctx, cer.ToMessage(c.config.Ids.NextHopByHopId(), c.config.Ids.NextEndToEndId())); err != nil {
+		return err
+	}
+	message, err := diameter.ReadMessageFrom(reader)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+	c.received(*message)
+	cea := capabilities.CEA{}
+	if err := cea.FromMessage(*message); err != nil {
+		return err
+	}
+	if !cea.ResultCode.IsSuccess() {
+		return fmt.Errorf("capabilities exchange failed with result code %d", cea.ResultCode)
+	}
+	c.peer = cea.Capabilities
+	c.applications = capabilities.Negotiate(c.config.Capabilities, cea.Capabilities)
+	if c.applications.Empty() {
+		return errors.New("capabilities exchange found no common applications")
+	}
+	return nil
+}
+
+// Peer returns the capabilities the peer advertised in its CEA.
+func (c *Client) Peer() capabilities.Capabilities {
+	return c.peer
+}
+
+// Applications returns the applications negotiated with the peer.
+func (c *Client) Applications() capabilities.Applications {
+	return c.applications
+}
+
+// State returns the watchdog state of the connection.
+func (c *Client) State() watchdog.State {
+	return c.watchdog.State()
+}
+
+// Transactions returns a snapshot of the outstanding and completed requests.
+func (c *Client) Transactions() transaction.Stats {
+	return c.transactions.Stats()
+}
+
+// Done returns a channel that is closed when the connection is closed.
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+// Err returns the reason the connection was closed, or nil if it is open.
+func (c *Client) Err() error {
+	select {
+	case <-c.done:
+		return c.err
+	default:
+		return nil
+	}
+}
+
+// SendRequest sends the request through the send middleware and waits for
+// its answer. The request is given the next Hop-by-Hop identifier, and an
+// End-to-End identifier if it has none.
+func (c *Client) SendRequest(ctx context.Context, request diameter.Message) (diameter.Message, error) {
+	return c.send(ctx, request)
+}
+
+// roundTrip sends the request and waits for its answer.
+func (c *Client) roundTrip(ctx context.Context, request diameter.Message) (diameter.Message, error) {
+	c.identify(&request)
+	pending, err := c.transactions.Add(request)
+	if err != nil {
+		return diameter.Message{}, err
+	}
+	if err := c.writeContext(ctx, request); err != nil {
+		pending.Cancel()
+		return diameter.Message{}, err
+	}
+	answer, err := pending.Wait(ctx)
+	if err == nil {
+		c.metrics.TransactionLatency(request.CommandCode, time.Since(pending.Started))
+	}
+	return answer, err
+}
+
+// identify sets the R bit and identifiers of the request, and its
+// Origin-State-Id if the client has a tracker.
+func (c *Client) identify(request *diameter.Message) {
+	request.SetRequest(true)
+	request.HopByHopId = c.config.Ids.NextHopByHopId()
+	if request.EndToEndId == [4]byte{} {
+		request.EndToEndId = c.config.Ids.NextEndToEndId()
+	}
+	if c.config.OriginState != nil {
+		c.config.OriginState.Inject(request)
+	}
+}
+
+// Answer is the answer to a request sent with SendAsync, or the error that
+// prevented it arriving.
+type Answer struct {
+	Message diameter.Message
+	Err     error
+}
+
+// SendAsync sends the request and returns a channel that receives its answer
+// once, without a goroutine waiting for it. The request is identified as by
+// SendRequest, but does not pass through the send middleware. The answer is
+// abandoned with context.DeadlineExceeded only if the transaction table has a
+// timeout.
+func (c *Client) SendAsync(request diameter.Message) (<-chan Answer, error) {
+	c.identify(&request)
+	answers := make(chan Answer, 1)
+	started := time.Now()
+	pending, err := c.transactions.AddCallback(request, func(answer diameter.Message, err error) {
+		if err == nil {
+			c.metrics.TransactionLatency(request.CommandCode, time.Since(started))
+		}
+		answers <- Answer{Message: answer, Err: err}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := c.write(request); err != nil {
+		pending.Cancel()
+		return nil, err
+	}
+	return answers, nil
+}
+
+// SendAnswer writes an answer to a request from the peer without waiting,
+// for applications answering outside Handler.
+func (c *Client) SendAnswer(answer diameter.Message) error {
+	if answer.IsRequest() {
+		return errors.New("message is not an answer")
+	}
+	if err := c.Err(); err != nil {
+		return err
+	}
+	return c.write(answer)
+}
+
+// Send writes the message to the peer as it is, without waiting for an
+// answer, and returns once it is written. Messages sent concurrently are
+// written whole, one after another, those of a higher Priority first and
+// those of the same Priority in the order they are queued. The message must
+// be written before the context's deadline and the WriteTimeout.
+func (c *Client) Send(ctx context.Context, message diameter.Message) error {
+	return c.writeContext(ctx, message)
+}
+
+// Disconnect gracefully disconnects from the peer, sending a DPR and
+// waiting for the DPA before closing the connection.
+func (c *Client) Disconnect(ctx context.Context, cause disconnect.Cause) error {
+	dpr := disconnect.DPR{
+		OriginHost:      c.config.Capabilities.OriginHost,
+		OriginRealm:     c.config.Capabilities.OriginRealm,
+		DisconnectCause: cause,
+	}
+	_, err := c.SendRequest(ctx, dpr.ToMessage([4]byte{}, [4]byte{}))
+	return errors.Join(err, c.Close())
+}
+
+// Close closes the connection immediately.
+func (c *Client) Close() error {
+	c.close(ErrClosed)
+	return nil
+}
+
+// close closes the connection, recording the reason.
+func (c *Client) close(err error) {
+	c.closeOnce.Do(func() {
+		c.err = err
+		close(c.done)
+		c.transactions.Close(err)
+		if c.cancel != nil {
+			c.cancel()
+		}
+		c.conn.Close()
+	})
+}
+
+// read reads messages from the connection until it is closed, passing
+// answers to the requests waiting for them.
+func (c *Client) read(reader *bufio.Reader) {
+	for {
+		message, err := diameter.ReadMessageFrom(reader)
+		if err != nil {
+			if metrics.IsParseError(err) {
+				c.metrics.ParseError(err)
+			}
+			c.close(err)
+			return
+		}
+		c.received(*message)
+		handled, err := c.watchdog.Handle(*message)
+		if err != nil {
+			c.close(err)
+			return
+		}
+		if handled {
+			continue
+		}
+		if message.IsRequest() {
+			go c.answer(*message)
+			continue
+		}
+		if outcome := c.transactions.Deliver(*message); outcome != transaction.Delivered {
+			c.unmatched(*message, outcome)
+		}
+	}
+}
+
+// received measures and observes a message read from the peer.
+func (c *Client) received(message diameter.Message) {
+	c.metrics.MessageReceived(message)
+	if c.config.OriginState != nil {
+		c.config.OriginState.Observe(message)
+	}
+}
+
+// answer answers a request sent by the peer.
+func (c *Client) answer(request diameter.Message) {
+	if disconnect.IsDPR(request) {
+		dpa := disconnect.DPA{
+			ResultCode:  diameter.ResultCodeSuccess,
+			OriginHost:  c.config.Capabilities.OriginHost,
+			OriginRealm: c.config.Capabilities.OriginRealm,
+		}
+		c.write(dpa.ToMessage(request))
+		c.close(errors.New("peer disconnected"))
+		return
+	}
+	c.write(c.handler(request))
+}
+
+// unsupported answers requests when no Handler is configured.
+func (c *Client) unsupported(request diameter.Message) diameter.Message {
+	answer := request.NewAnswer(
+		diameter.NewAvpUint32(diameter.AvpResultCode, diameter.FlagMandatory, 0, uint32(diameter.ResultCodeCommandUnsupported)),
+		diameter.NewAvpString(diameter.AvpOriginHost, diameter.FlagMandatory, 0, c.config.Capabilities.OriginHost),
+		diameter.NewAvpString(diameter.AvpOriginRealm, diameter.FlagMandatory, 0, c.config.Capabilities.OriginRealm),
+	)
+	answer.SetError(true)
+	return answer
+}
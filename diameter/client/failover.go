@@ -0,0 +1,94 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/tinybluerobots/radius-diameter-message/diameter"
+)
+
+// ErrNoPeer is returned when no peer is connected to send a request to.
+var ErrNoPeer = errors.New("no peer available")
+
+// Policy decides whether a request outstanding on a failed peer is
+// retransmitted to an alternate peer.
+type Policy func(request diameter.Message) bool
+
+// RetransmitAll retransmits every request.
+func RetransmitAll(diameter.Message) bool {
+	return true
+}
+
+// RetransmitNone never retransmits, failing requests with the peer.
+func RetransmitNone(diameter.Message) bool {
+	return false
+}
+
+// RetransmitCommands retransmits only requests with the given command codes.
+func RetransmitCommands(commandCodes ...diameter.CommandCode) Policy {
+	return func(request diameter.Message) bool {
+		return slices.Contains(commandCodes, request.CommandCode)
+	}
+}
+
+// FailoverConfig configures a Failover.
+type FailoverConfig struct {
+	// Policy decides which requests are retransmitted, defaulting to RetransmitAll.
+	Policy Policy
+	// MaxAttempts limits how many peers a request is sent to. Zero tries every peer.
+	MaxAttempts int
+	// Ids generates the End-to-End identifiers of requests. A new generator is used if nil.
+	Ids *diameter.IdGenerator
+}
+
+// Failover sends requests to the first connected peer in order of preference.
+// If the connection fails before the answer arrives, the request is resent to
+// the next peer with the T flag set and the same End-to-End identifier, as
+// RFC 6733 section 5.5.4 describes.
+type Failover struct {
+	config  FailoverConfig
+	clients []*Client
+}
+
+// NewFailover creates a Failover over the clients, most preferred first.
+func NewFailover(config FailoverConfig, clients ...*Client) *Failover {
+	if config.Policy == nil {
+		config.Policy = RetransmitAll
+	}
+	if config.Ids == nil {
+		config.Ids = diameter.NewIdGenerator()
+	}
+	return &Failover{config: config, clients: clients}
+}
+
+// SendRequest sends the request to the first connected peer, failing over to
+// the following peers if a connection fails while the request is outstanding.
+func (f *Failover) SendRequest(ctx context.Context, request diameter.Message) (diameter.Message, error) {
+	if request.EndToEndId == [4]byte{} {
+		request.EndToEndId = f.config.Ids.NextEndToEndId()
+	}
+	attempts := 0
+	err := ErrNoPeer
+	for _, client := range f.clients {
+		if client.Err() != nil {
+			continue
+		}
+		if attempts > 0 {
+			if !f.config.Policy(request) {
+				return diameter.Message{}, err
+			}
+			request.SetRetransmitted(true)
+		}
+		attempts++
+		var answer diameter.Message
+		answer, err = client.SendRequest(ctx, request)
+		if err == nil || ctx.Err() != nil || client.Err() == nil {
+			return answer, err
+		}
+		if f.config.MaxAttempts > 0 && attempts >= f.config.MaxAttempts {
+			break
+		}
+	}
+	return diameter.Message{}, err
+}
@@ -0,0 +1,33 @@
+package client
+
+import (
+	"context"
+
+	"github.com/tinybluerobots/radius-diameter-message/diameter"
+)
+
+// Sender sends a request and waits for its answer.
+type Sender func(ctx context.Context, request diameter.Message) (diameter.Message, error)
+
+// Middleware wraps the Handler answering requests sent by the peer.
+type Middleware func(next Handler) Handler
+
+// SendMiddleware wraps the Sender behind SendRequest, for concerns such as
+// logging, metrics or rewriting AVPs of outgoing requests.
+type SendMiddleware func(next Sender) Sender
+
+// chainHandler wraps the handler so the first middleware runs first.
+func chainHandler(handler Handler, middleware []Middleware) Handler {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		handler = middleware[i](handler)
+	}
+	return handler
+}
+
+// chainSender wraps the sender so the first middleware runs first.
+func chainSender(sender Sender, middleware []SendMiddleware) Sender {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		sender = middleware[i](sender)
+	}
+	return sender
+}
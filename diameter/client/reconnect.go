@@ -0,0 +1,164 @@
+package client
+
+import (
+	"context"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/tinybluerobots/radius-diameter-message/diameter"
+)
+
+// PeerState is the connection state of a Peer.
+type PeerState int
+
+const (
+	// PeerConnecting means a connection attempt is in progress.
+	PeerConnecting PeerState = iota
+	// PeerOpen means the peer is connected and has completed the capabilities exchange.
+	PeerOpen
+	// PeerWaiting means the peer is waiting for the Tc timer before reconnecting.
+	PeerWaiting
+	// PeerClosed means the peer has stopped and will not reconnect.
+	PeerClosed
+)
+
+// String returns the name of the state.
+func (s PeerState) String() string {
+	switch s {
+	case PeerConnecting:
+		return "CONNECTING"
+	case PeerOpen:
+		return "OPEN"
+	case PeerWaiting:
+		return "WAITING"
+	case PeerClosed:
+		return "CLOSED"
+	}
+	return "UNKNOWN"
+}
+
+// DialFunc connects to a peer, such as a call to Dial with fixed arguments.
+type DialFunc func(ctx context.Context) (*Client, error)
+
+// ReconnectConfig configures a Peer.
+type ReconnectConfig struct {
+	// Tc is the time between connection attempts, which defaults to the 30
+	// seconds RFC 6733 recommends.
+	Tc time.Duration
+	// Backoff doubles the delay after each failed attempt, up to MaxTc.
+	Backoff bool
+	// MaxTc caps the delay when Backoff is set, defaulting to ten times Tc.
+	MaxTc time.Duration
+	// Jitter randomizes each delay by up to this fraction of it, from 0 to 1.
+	Jitter float64
+	// OnStateChange, if set, is called whenever the state changes, with the
+	// error that caused it if any.
+	OnStateChange func(state PeerState, err error)
+}
+
+// Peer keeps a connection to a peer open, reconnecting with the Tc timer
+// whenever the connection fails. It is safe for concurrent use.
+type Peer struct {
+	dial   DialFunc
+	config ReconnectConfig
+	mutex  sync.Mutex
+	client *Client
+	state  PeerState
+}
+
+// NewPeer creates a Peer that connects with the dial function once Run is called.
+func NewPeer(dial DialFunc, config ReconnectConfig) *Peer {
+	if config.Tc <= 0 {
+		config.Tc = 30 * time.Second
+	}
+	if config.MaxTc <= 0 {
+		config.MaxTc = 10 * config.Tc
+	}
+	return &Peer{dial: dial, config: config}
+}
+
+// State returns the connection state.
+func (p *Peer) State() PeerState {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.state
+}
+
+// Client returns the open connection, if any.
+func (p *Peer) Client() (*Client, bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.client, p.client != nil
+}
+
+// SendRequest sends the request on the open connection.
+func (p *Peer) SendRequest(ctx context.Context, request diameter.Message) (diameter.Message, error) {
+	client, ok := p.Client()
+	if !ok {
+		return diameter.Message{}, ErrNoPeer
+	}
+	return client.SendRequest(ctx, request)
+}
+
+// Run connects to the peer and reconnects whenever the connection fails,
+// until the context is done. The open connection is closed when it returns.
+func (p *Peer) Run(ctx context.Context) error {
+	failures := 0
+	for {
+		p.setState(PeerConnecting, nil, nil)
+		client, err := p.dial(ctx)
+		if err == nil {
+			failures = 0
+			p.setState(PeerOpen, client, nil)
+			select {
+			case <-client.Done():
+				err = client.Err()
+			case <-ctx.Done():
+				client.Close()
+			}
+		} else {
+			failures++
+		}
+		if ctx.Err() != nil {
+			p.setState(PeerClosed, nil, ctx.Err())
+			return ctx.Err()
+		}
+		p.setState(PeerWaiting, nil, err)
+		timer := time.NewTimer(p.delay(failures))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			p.setState(PeerClosed, nil, ctx.Err())
+			return ctx.Err()
+		}
+	}
+}
+
+// delay returns the time to wait after the number of consecutive failures.
+func (p *Peer) delay(failures int) time.Duration {
+	delay := p.config.Tc
+	if p.config.Backoff {
+		for i := 1; i < failures && delay < p.config.MaxTc; i++ {
+			delay *= 2
+		}
+		delay = min(delay, p.config.MaxTc)
+	}
+	if p.config.Jitter > 0 {
+		spread := float64(delay) * min(p.config.Jitter, 1)
+		delay += time.Duration(spread * (2*rand.Float64() - 1))
+	}
+	return delay
+}
+
+// setState records the state and open connection, calling OnStateChange.
+func (p *Peer) setState(state PeerState, client *Client, err error) {
+	p.mutex.Lock()
+	p.state = state
+	p.client = client
+	p.mutex.Unlock()
+	if p.config.OnStateChange != nil {
+		p.config.OnStateChange(state, err)
+	}
+}
@@ -0,0 +1,44 @@
+package client
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/tinybluerobots/radius-diameter-message/diameter"
+	"github.com/tinybluerobots/radius-diameter-message/diameter/transaction"
+)
+
+// UnsolicitedHandler receives an answer that matched no pending request,
+// with whether it answered a request that had already expired, the outcome
+// transaction.Late, or one never sent, transaction.Unsolicited. Such answers
+// are the first sign of a peer misbehaving. It is called by the goroutine
+// reading the connection, so it must not block.
+type UnsolicitedHandler func(answer diameter.Message, outcome transaction.Outcome)
+
+// DropUnsolicited is an UnsolicitedHandler that discards every answer, as
+// the client does when none is configured.
+func DropUnsolicited(diameter.Message, transaction.Outcome) {}
+
+// LogUnsolicited returns an UnsolicitedHandler logging every answer as a
+// warning with the logger, or the default logger if it is nil.
+func LogUnsolicited(logger *slog.Logger) UnsolicitedHandler {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return func(answer diameter.Message, outcome transaction.Outcome) {
+		logger.Warn("unmatched Diameter answer",
+			"outcome", outcome.String(),
+			"command", answer.CommandCode,
+			"hop_by_hop_id", fmt.Sprintf("0x%x", answer.HopByHopId[:]),
+			"origin_host", answer.Avps.GetFirst(diameter.AvpOriginHost, 0).ToStringOrDefault())
+	}
+}
+
+// unmatched counts an answer that matched no pending request and passes it
+// to the configured handler.
+func (c *Client) unmatched(answer diameter.Message, outcome transaction.Outcome) {
+	c.metrics.UnmatchedAnswer(answer, outcome)
+	if c.config.OnUnsolicited != nil {
+		c.config.OnUnsolicited(answer, outcome)
+	}
+}
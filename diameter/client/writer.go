@@ -0,0 +1,216 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"os"
+	"time"
+
+	"github.com/tinybluerobots/radius-diameter-message/diameter"
+)
+
+// ErrQueueFull is returned for a message rejected because its class of the
+// write queue is full.
+var ErrQueueFull = errors.New("write queue full")
+
+// Priority is the class of a message in the write queue. Queued messages of
+// a higher class are written before those of a lower class, so watchdog
+// messages and answers are not held up behind requests on a congested
+// connection.
+type Priority int
+
+const (
+	// PriorityRequest is the class of requests.
+	PriorityRequest Priority = iota
+	// PriorityAnswer is the class of answers.
+	PriorityAnswer
+	// PriorityWatchdog is the class of the capabilities exchange, watchdog
+	// and disconnect messages.
+	PriorityWatchdog
+)
+
+// priorityOf returns the class of the message.
+func priorityOf(message diameter.Message) Priority {
+	switch {
+	case message.CommandCode == diameter.CommandCapabilitiesExchange,
+		message.CommandCode == diameter.CommandDeviceWatchdog,
+		message.CommandCode == diameter.CommandDisconnectPeer:
+		return PriorityWatchdog
+	case !message.IsRequest():
+		return PriorityAnswer
+	}
+	return PriorityRequest
+}
+
+// QueueAction is what becomes of a message whose class of the write queue is
+// full.
+type QueueAction int
+
+const (
+	// QueueWait makes the sender wait for room.
+	QueueWait QueueAction = iota
+	// QueueReject fails the message with ErrQueueFull.
+	QueueReject
+	// QueueDrop discards the message as if the network had lost it, so its
+	// sender sees no error and a request goes unanswered.
+	QueueDrop
+)
+
+// QueueFullPolicy decides what becomes of a message whose class of the write
+// queue is full.
+type QueueFullPolicy func(message diameter.Message, priority Priority) QueueAction
+
+// RejectRequests is a QueueFullPolicy rejecting requests, so their senders
+// can try another peer, while answers and watchdog messages wait.
+func RejectRequests(message diameter.Message, priority Priority) QueueAction {
+	if priority == PriorityRequest {
+		return QueueReject
+	}
+	return QueueWait
+}
+
+// queuedWrite is a message waiting to be written, with the deadline for
+// writing it and the channel receiving the result.
+type queuedWrite struct {
+	message  diameter.Message
+	bytes    []byte
+	deadline time.Time
+	result   chan error
+}
+
+// write writes a message to the connection, waiting for room in the queue.
+func (c *Client) write(message diameter.Message) error {
+	return c.writeContext(context.Background(), message)
+}
+
+// writeContext queues a message for the writer in its class, applying the
+// QueueFullPolicy if the class is full, and waits until it is written. The
+// context bounds only the wait for room in the queue and, through its
+// deadline, the write.
+func (c *Client) writeContext(ctx context.Context, message diameter.Message) error {
+	w := c.newQueuedWrite(ctx, message)
+	priority := priorityOf(message)
+	queue := c.writes[priority]
+	select {
+	case queue <- w:
+		return c.waitWritten(w)
+	default:
+	}
+	if c.config.OnQueueFull != nil {
+		switch c.config.OnQueueFull(message, priority) {
+		case QueueReject:
+			return ErrQueueFull
+		case QueueDrop:
+			return nil
+		}
+	}
+	select {
+	case queue <- w:
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-c.done:
+		return c.err
+	}
+	return c.waitWritten(w)
+}
+
+// post queues a message without waiting for it to be written, for the
+// goroutine reading the connection, which must keep reading while writes
+// are congested. If its class of the queue is full, a goroutine waits for
+// room; the QueueFullPolicy does not apply. A failed write closes the
+// connection, so no error is reported.
+func (c *Client) post(message diameter.Message) error {
+	w := c.newQueuedWrite(context.Background(), message)
+	queue := c.writes[priorityOf(message)]
+	select {
+	case queue <- w:
+	default:
+		go func() {
+			select {
+			case queue <- w:
+			case <-c.done:
+			}
+		}()
+	}
+	return nil
+}
+
+// newQueuedWrite prepares a message for the queue, with the earlier of the
+// context's deadline and the WriteTimeout.
+func (c *Client) newQueuedWrite(ctx context.Context, message diameter.Message) *queuedWrite {
+	w := &queuedWrite{message: message, bytes: message.ToBytes(), result: make(chan error, 1)}
+	if c.config.WriteTimeout > 0 {
+		w.deadline = time.Now().Add(c.config.WriteTimeout)
+	}
+	if deadline, ok := ctx.Deadline(); ok && (w.deadline.IsZero() || deadline.Before(w.deadline)) {
+		w.deadline = deadline
+	}
+	return w
+}
+
+// waitWritten waits for the result of writing a queued message.
+func (c *Client) waitWritten(w *queuedWrite) error {
+	select {
+	case err := <-w.result:
+		return err
+	case <-c.done:
+		return c.err
+	}
+}
+
+// QueueLen returns how many messages of the class are waiting to be written.
+func (c *Client) QueueLen(priority Priority) int {
+	if priority < PriorityRequest || priority > PriorityWatchdog {
+		return 0
+	}
+	return len(c.writes[priority])
+}
+
+// writeLoop writes queued messages one at a time until the connection is
+// closed.
+func (c *Client) writeLoop() {
+	for {
+		w, ok := c.nextWrite()
+		if !ok {
+			return
+		}
+		w.result <- c.writeQueued(w)
+	}
+}
+
+// nextWrite waits for a queued message of the highest class waiting,
+// reporting false once the connection is closed.
+func (c *Client) nextWrite() (*queuedWrite, bool) {
+	for priority := PriorityWatchdog; priority > PriorityRequest; priority-- {
+		select {
+		case w := <-c.writes[priority]:
+			return w, true
+		default:
+		}
+	}
+	select {
+	case w := <-c.writes[PriorityWatchdog]:
+		return w, true
+	case w := <-c.writes[PriorityAnswer]:
+		return w, true
+	case w := <-c.writes[PriorityRequest]:
+		return w, true
+	case <-c.done:
+		return nil, false
+	}
+}
+
+// writeQueued writes a message before its deadline, closing the connection
+// if the write fails part way.
+func (c *Client) writeQueued(w *queuedWrite) error {
+	if !w.deadline.IsZero() && !time.Now().Before(w.deadline) {
+		return os.ErrDeadlineExceeded
+	}
+	c.conn.SetWriteDeadline(w.deadline)
+	if _, err := c.conn.Write(w.bytes); err != nil {
+		c.close(err)
+		return err
+	}
+	c.metrics.MessageSent(w.message)
+	return nil
+}
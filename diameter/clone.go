@@ -0,0 +1,31 @@
+package diameter
+
+// Clone returns a deep copy of the AVP, including its data.
+func (a Avp) Clone() Avp {
+	if a.Data != nil {
+		a.Data = append(avpData(nil), a.Data...)
+	}
+	if a.pad != nil {
+		a.pad = append([]byte(nil), a.pad...)
+	}
+	return a
+}
+
+// Clone returns a deep copy of the slice of AVPs. Grouped AVPs are stored
+// encoded in Data, so copying the data also copies every nested AVP.
+func (a Avps) Clone() Avps {
+	if a == nil {
+		return nil
+	}
+	avps := make(Avps, len(a))
+	for i, avp := range a {
+		avps[i] = avp.Clone()
+	}
+	return avps
+}
+
+// Clone returns a deep copy of the Diameter message.
+func (m Message) Clone() Message {
+	m.Avps = m.Avps.Clone()
+	return m
+}
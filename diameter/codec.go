@@ -0,0 +1,55 @@
+package diameter
+
+import "fmt"
+
+// Codec converts the data of AVPs with a proprietary format, such as packed
+// structs or BCD-coded numbers, to and from Go values.
+type Codec struct {
+	// Decode converts the data of an AVP to its value.
+	Decode func(data []byte) (any, error)
+	// Encode converts a value to the data of an AVP.
+	Encode func(value any) ([]byte, error)
+}
+
+// AddCodec registers the codec of the AVP with the code and vendor ID, which
+// TypedValue then decodes with it ahead of its data type.
+func (d *Dictionary) AddCodec(code Code, vendorId VendorId, codec Codec) *Dictionary {
+	d.codecs[avpKey{code, vendorId}] = codec
+	return d
+}
+
+// Codec retrieves the codec of the AVP with the code and vendor ID.
+func (d *Dictionary) Codec(code Code, vendorId VendorId) *Codec {
+	if d == nil {
+		return nil
+	}
+	codec, ok := d.codecs[avpKey{code, vendorId}]
+	if !ok {
+		return nil
+	}
+	return &codec
+}
+
+// NewAvpValue creates an AVP from a value encoded with the codec the
+// dictionary holds for the code and vendor ID.
+func NewAvpValue(dictionary *Dictionary, code Code, flags Flags, vendorId VendorId, value any) (Avp, error) {
+	codec := dictionary.Codec(code, vendorId)
+	if codec == nil || codec.Encode == nil {
+		return Avp{}, fmt.Errorf("no codec encodes AVP %d (vendor %d)", code, vendorId)
+	}
+	data, err := codec.Encode(value)
+	if err != nil {
+		return Avp{}, fmt.Errorf("AVP %d (vendor %d): %w", code, vendorId, err)
+	}
+	return NewAvp(code, flags, vendorId, data), nil
+}
+
+// decode converts the data of the AVP with the codec, reporting false if it
+// has none or the data is malformed.
+func (c *Codec) decode(data []byte) (any, bool) {
+	if c == nil || c.Decode == nil {
+		return nil, false
+	}
+	value, err := c.Decode(data)
+	return value, err == nil
+}
@@ -0,0 +1,28 @@
+package diameter
+
+// Base protocol and common application command codes.
+const (
+	CommandCapabilitiesExchange      CommandCode = 257
+	CommandReAuth                    CommandCode = 258
+	CommandAA                        CommandCode = 265
+	CommandAccounting                CommandCode = 271
+	CommandCreditControl             CommandCode = 272
+	CommandAbortSession              CommandCode = 274
+	CommandSessionTermination        CommandCode = 275
+	CommandDeviceWatchdog            CommandCode = 280
+	CommandDisconnectPeer            CommandCode = 282
+	CommandUpdateLocation            CommandCode = 316
+	CommandAuthenticationInformation CommandCode = 318
+)
+
+// Application IDs.
+const (
+	ApplicationCommon         ApplicationId = 0
+	ApplicationNASREQ         ApplicationId = 1
+	ApplicationBaseAccounting ApplicationId = 3
+	ApplicationCreditControl  ApplicationId = 4
+	ApplicationRx             ApplicationId = 16777236
+	ApplicationGx             ApplicationId = 16777238
+	ApplicationS6a            ApplicationId = 16777251
+	ApplicationRelay          ApplicationId = 0xffffffff
+)
@@ -0,0 +1,63 @@
+package creditcontrol
+
+import "github.com/tinybluerobots/radius-diameter-message/diameter"
+
+// Credit-Control AVP codes (RFC 4006).
+const (
+	AvpCCCorrelationId               diameter.Code = 411
+	AvpCCInputOctets                 diameter.Code = 412
+	AvpCCMoney                       diameter.Code = 413
+	AvpCCOutputOctets                diameter.Code = 414
+	AvpCCRequestNumber               diameter.Code = 415
+	AvpCCRequestType                 diameter.Code = 416
+	AvpCCServiceSpecificUnits        diameter.Code = 417
+	AvpCCSessionFailover             diameter.Code = 418
+	AvpCCSubSessionId                diameter.Code = 419
+	AvpCCTime                        diameter.Code = 420
+	AvpCCTotalOctets                 diameter.Code = 421
+	AvpCheckBalanceResult            diameter.Code = 422
+	AvpCostInformation               diameter.Code = 423
+	AvpCostUnit                      diameter.Code = 424
+	AvpCurrencyCode                  diameter.Code = 425
+	AvpCreditControl                 diameter.Code = 426
+	AvpCreditControlFailureHandling  diameter.Code = 427
+	AvpDirectDebitingFailureHandling diameter.Code = 428
+	AvpExponent                      diameter.Code = 429
+	AvpFinalUnitIndication           diameter.Code = 430
+	AvpGrantedServiceUnit            diameter.Code = 431
+	AvpRatingGroup                   diameter.Code = 432
+	AvpRedirectAddressType           diameter.Code = 433
+	AvpRedirectServer                diameter.Code = 434
+	AvpRedirectServerAddress         diameter.Code = 435
+	AvpRequestedAction               diameter.Code = 436
+	AvpRequestedServiceUnit          diameter.Code = 437
+	AvpRestrictionFilterRule         diameter.Code = 438
+	AvpServiceIdentifier             diameter.Code = 439
+	AvpServiceParameterInfo          diameter.Code = 440
+	AvpServiceParameterType          diameter.Code = 441
+	AvpServiceParameterValue         diameter.Code = 442
+	AvpSubscriptionId                diameter.Code = 443
+	AvpSubscriptionIdData            diameter.Code = 444
+	AvpUnitValue                     diameter.Code = 445
+	AvpUsedServiceUnit               diameter.Code = 446
+	AvpValueDigits                   diameter.Code = 447
+	AvpValidityTime                  diameter.Code = 448
+	AvpFinalUnitAction               diameter.Code = 449
+	AvpSubscriptionIdType            diameter.Code = 450
+	AvpTariffTimeChange              diameter.Code = 451
+	AvpTariffChangeUsage             diameter.Code = 452
+	AvpGSUPoolIdentifier             diameter.Code = 453
+	AvpCCUnitType                    diameter.Code = 454
+	AvpMultipleServicesIndicator     diameter.Code = 455
+	AvpMultipleServicesCreditControl diameter.Code = 456
+	AvpGSUPoolReference              diameter.Code = 457
+	AvpUserEquipmentInfo             diameter.Code = 458
+	AvpUserEquipmentInfoType         diameter.Code = 459
+	AvpUserEquipmentInfoValue        diameter.Code = 460
+	AvpServiceContextId              diameter.Code = 461
+)
+
+// NASREQ AVP codes used by Final-Unit-Indication (RFC 7155).
+const (
+	AvpFilterId diameter.Code = 11
+)
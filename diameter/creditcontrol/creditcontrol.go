@@ -0,0 +1,213 @@
+package creditcontrol
+
+import (
+	"errors"
+	"time"
+
+	"github.com/tinybluerobots/radius-diameter-message/diameter"
+)
+
+const mandatoryFlags = diameter.FlagMandatory
+
+// RequestType represents the value of a CC-Request-Type AVP.
+type RequestType uint32
+
+const (
+	InitialRequest     RequestType = 1
+	UpdateRequest      RequestType = 2
+	TerminationRequest RequestType = 3
+	EventRequest       RequestType = 4
+)
+
+// String returns the RFC 4006 name of the request type.
+func (r RequestType) String() string {
+	switch r {
+	case InitialRequest:
+		return "INITIAL_REQUEST"
+	case UpdateRequest:
+		return "UPDATE_REQUEST"
+	case TerminationRequest:
+		return "TERMINATION_REQUEST"
+	case EventRequest:
+		return "EVENT_REQUEST"
+	}
+	return "UNKNOWN"
+}
+
+// CCR represents a Credit-Control-Request. AVPs without a field, such as
+// Service-Information, are carried in Avps and appended after the others.
+type CCR struct {
+	SessionId                     string
+	OriginHost                    string
+	OriginRealm                   string
+	DestinationRealm              string
+	DestinationHost               string
+	ServiceContextId              string
+	RequestType                   RequestType
+	RequestNumber                 uint32
+	UserName                      string
+	OriginStateId                 uint32
+	EventTimestamp                time.Time
+	SubscriptionIds               []SubscriptionId
+	UserEquipmentInfo             *UserEquipmentInfo
+	TerminationCause              uint32
+	MultipleServicesIndicator     bool
+	MultipleServicesCreditControl []MSCC
+	Avps                          diameter.Avps
+}
+
+// ccrCodes are the AVP codes CCR has fields for.
+var ccrCodes = []diameter.Code{
+	diameter.AvpSessionId, diameter.AvpOriginHost, diameter.AvpOriginRealm, diameter.AvpDestinationRealm,
+	diameter.AvpAuthApplicationId, AvpServiceContextId, AvpCCRequestType, AvpCCRequestNumber,
+	diameter.AvpDestinationHost, diameter.AvpUserName, diameter.AvpOriginStateId, diameter.AvpEventTimestamp,
+	AvpSubscriptionId, AvpUserEquipmentInfo, diameter.AvpTerminationCause, AvpMultipleServicesIndicator, AvpMultipleServicesCreditControl,
+}
+
+// ToMessage converts the CCR to a Diameter message.
+func (c CCR) ToMessage(hopByHopId [4]byte, endToEndId [4]byte) diameter.Message {
+	avps := diameter.NewAvps()
+	avps = avps.AddString(diameter.AvpSessionId, mandatoryFlags, 0, c.SessionId)
+	avps = avps.AddString(diameter.AvpOriginHost, mandatoryFlags, 0, c.OriginHost)
+	avps = avps.AddString(diameter.AvpOriginRealm, mandatoryFlags, 0, c.OriginRealm)
+	avps = avps.AddString(diameter.AvpDestinationRealm, mandatoryFlags, 0, c.DestinationRealm)
+	avps = avps.AddUint32(diameter.AvpAuthApplicationId, mandatoryFlags, 0, uint32(diameter.ApplicationCreditControl))
+	avps = avps.AddString(AvpServiceContextId, mandatoryFlags, 0, c.ServiceContextId)
+	avps = avps.AddUint32(AvpCCRequestType, mandatoryFlags, 0, uint32(c.RequestType))
+	avps = avps.AddUint32(AvpCCRequestNumber, mandatoryFlags, 0, c.RequestNumber)
+	if c.DestinationHost != "" {
+		avps = avps.AddString(diameter.AvpDestinationHost, mandatoryFlags, 0, c.DestinationHost)
+	}
+	if c.UserName != "" {
+		avps = avps.AddString(diameter.AvpUserName, mandatoryFlags, 0, c.UserName)
+	}
+	if c.OriginStateId != 0 {
+		avps = avps.AddUint32(diameter.AvpOriginStateId, mandatoryFlags, 0, c.OriginStateId)
+	}
+	if !c.EventTimestamp.IsZero() {
+		avps = avps.AddTime(diameter.AvpEventTimestamp, mandatoryFlags, 0, c.EventTimestamp)
+	}
+	for _, subscriptionId := range c.SubscriptionIds {
+		avps = append(avps, subscriptionId.ToAvp())
+	}
+	if c.UserEquipmentInfo != nil {
+		avps = append(avps, c.UserEquipmentInfo.ToAvp())
+	}
+	if c.TerminationCause != 0 {
+		avps = avps.AddUint32(diameter.AvpTerminationCause, mandatoryFlags, 0, c.TerminationCause)
+	}
+	if c.MultipleServicesIndicator {
+		avps = avps.AddUint32(AvpMultipleServicesIndicator, mandatoryFlags, 0, 1)
+	}
+	for _, mscc := range c.MultipleServicesCreditControl {
+		avps = append(avps, mscc.ToAvp())
+	}
+	avps = append(avps, c.Avps...)
+	return diameter.NewMessage(1, diameter.FlagRequest|diameter.FlagProxiable, diameter.CommandCreditControl, diameter.ApplicationCreditControl, hopByHopId, endToEndId, avps...)
+}
+
+// FromMessage reads a CCR from a Diameter message.
+func (c *CCR) FromMessage(message diameter.Message) error {
+	if message.CommandCode != diameter.CommandCreditControl || !message.IsRequest() {
+		return errors.New("message is not a Credit-Control-Request")
+	}
+	avps := message.Avps
+	c.SessionId = avps.GetFirst(diameter.AvpSessionId, 0).ToStringOrDefault()
+	c.OriginHost = avps.GetFirst(diameter.AvpOriginHost, 0).ToStringOrDefault()
+	c.OriginRealm = avps.GetFirst(diameter.AvpOriginRealm, 0).ToStringOrDefault()
+	c.DestinationRealm = avps.GetFirst(diameter.AvpDestinationRealm, 0).ToStringOrDefault()
+	c.DestinationHost = avps.GetFirst(diameter.AvpDestinationHost, 0).ToStringOrDefault()
+	c.ServiceContextId = avps.GetFirst(AvpServiceContextId, 0).ToStringOrDefault()
+	c.RequestType = RequestType(avps.GetFirst(AvpCCRequestType, 0).ToUint32OrDefault())
+	c.RequestNumber = avps.GetFirst(AvpCCRequestNumber, 0).ToUint32OrDefault()
+	c.UserName = avps.GetFirst(diameter.AvpUserName, 0).ToStringOrDefault()
+	c.OriginStateId = avps.GetFirst(diameter.AvpOriginStateId, 0).ToUint32OrDefault()
+	c.EventTimestamp = time.Time{}
+	if eventTimestamp, ok := avps.GetFirst(diameter.AvpEventTimestamp, 0).ToTimeOk(); ok {
+		c.EventTimestamp = eventTimestamp
+	}
+	c.SubscriptionIds = SubscriptionIds(avps)
+	c.UserEquipmentInfo = nil
+	if userEquipmentInfo, ok := ReadUserEquipmentInfo(avps); ok {
+		c.UserEquipmentInfo = &userEquipmentInfo
+	}
+	c.TerminationCause = avps.GetFirst(diameter.AvpTerminationCause, 0).ToUint32OrDefault()
+	c.MultipleServicesIndicator = avps.GetFirst(AvpMultipleServicesIndicator, 0).ToUint32OrDefault() == 1
+	c.MultipleServicesCreditControl = readMSCCs(avps)
+	c.Avps = avps.Without(0, ccrCodes...)
+	return nil
+}
+
+// IMSI returns the END_USER_IMSI Subscription-Id of the CCR.
+func (c CCR) IMSI() (string, bool) {
+	return subscriptionIdData(c.SubscriptionIds, EndUserIMSI)
+}
+
+// MSISDN returns the END_USER_E164 Subscription-Id of the CCR.
+func (c CCR) MSISDN() (string, bool) {
+	return subscriptionIdData(c.SubscriptionIds, EndUserE164)
+}
+
+// IMEISV returns the IMEISV User-Equipment-Info of the CCR.
+func (c CCR) IMEISV() (string, bool) {
+	if c.UserEquipmentInfo == nil || c.UserEquipmentInfo.Type != IMEISV {
+		return "", false
+	}
+	return string(c.UserEquipmentInfo.Value), true
+}
+
+// CCA represents a Credit-Control-Answer. AVPs without a field are carried
+// in Avps and appended after the others.
+type CCA struct {
+	ResultCode                    diameter.ResultCode
+	OriginHost                    string
+	OriginRealm                   string
+	RequestType                   RequestType
+	RequestNumber                 uint32
+	ErrorMessage                  string
+	MultipleServicesCreditControl []MSCC
+	Avps                          diameter.Avps
+}
+
+// ccaCodes are the AVP codes CCA has fields for.
+var ccaCodes = []diameter.Code{
+	diameter.AvpSessionId, diameter.AvpResultCode, diameter.AvpOriginHost, diameter.AvpOriginRealm,
+	diameter.AvpAuthApplicationId, AvpCCRequestType, AvpCCRequestNumber, diameter.AvpErrorMessage,
+	AvpMultipleServicesCreditControl, diameter.AvpProxyInfo,
+}
+
+// ToMessage converts the CCA to a Diameter message answering the request.
+func (c CCA) ToMessage(request diameter.Message) diameter.Message {
+	avps := diameter.NewAvps()
+	avps = avps.AddUint32(diameter.AvpResultCode, mandatoryFlags, 0, uint32(c.ResultCode))
+	avps = avps.AddString(diameter.AvpOriginHost, mandatoryFlags, 0, c.OriginHost)
+	avps = avps.AddString(diameter.AvpOriginRealm, mandatoryFlags, 0, c.OriginRealm)
+	avps = avps.AddUint32(diameter.AvpAuthApplicationId, mandatoryFlags, 0, uint32(diameter.ApplicationCreditControl))
+	avps = avps.AddUint32(AvpCCRequestType, mandatoryFlags, 0, uint32(c.RequestType))
+	avps = avps.AddUint32(AvpCCRequestNumber, mandatoryFlags, 0, c.RequestNumber)
+	if c.ErrorMessage != "" {
+		avps = avps.AddString(diameter.AvpErrorMessage, 0, 0, c.ErrorMessage)
+	}
+	for _, mscc := range c.MultipleServicesCreditControl {
+		avps = append(avps, mscc.ToAvp())
+	}
+	avps = append(avps, c.Avps...)
+	return request.NewAnswer(avps...)
+}
+
+// FromMessage reads a CCA from a Diameter message.
+func (c *CCA) FromMessage(message diameter.Message) error {
+	if message.CommandCode != diameter.CommandCreditControl || message.IsRequest() {
+		return errors.New("message is not a Credit-Control-Answer")
+	}
+	avps := message.Avps
+	c.ResultCode = diameter.ResultCode(avps.GetFirst(diameter.AvpResultCode, 0).ToUint32OrDefault())
+	c.OriginHost = avps.GetFirst(diameter.AvpOriginHost, 0).ToStringOrDefault()
+	c.OriginRealm = avps.GetFirst(diameter.AvpOriginRealm, 0).ToStringOrDefault()
+	c.RequestType = RequestType(avps.GetFirst(AvpCCRequestType, 0).ToUint32OrDefault())
+	c.RequestNumber = avps.GetFirst(AvpCCRequestNumber, 0).ToUint32OrDefault()
+	c.ErrorMessage = avps.GetFirst(diameter.AvpErrorMessage, 0).ToStringOrDefault()
+	c.MultipleServicesCreditControl = readMSCCs(avps)
+	c.Avps = avps.Without(0, ccaCodes...)
+	return nil
+}
@@ -0,0 +1,132 @@
+package creditcontrol
+
+import "github.com/tinybluerobots/radius-diameter-message/diameter"
+
+// FinalUnitAction represents the value of a Final-Unit-Action AVP.
+type FinalUnitAction uint32
+
+const (
+	Terminate      FinalUnitAction = 0
+	Redirect       FinalUnitAction = 1
+	RestrictAccess FinalUnitAction = 2
+)
+
+// String returns the RFC 4006 name of the action.
+func (f FinalUnitAction) String() string {
+	switch f {
+	case Terminate:
+		return "TERMINATE"
+	case Redirect:
+		return "REDIRECT"
+	case RestrictAccess:
+		return "RESTRICT_ACCESS"
+	}
+	return "UNKNOWN"
+}
+
+// RedirectAddressType represents the value of a Redirect-Address-Type AVP.
+type RedirectAddressType uint32
+
+const (
+	RedirectIPv4Address RedirectAddressType = 0
+	RedirectIPv6Address RedirectAddressType = 1
+	RedirectURL         RedirectAddressType = 2
+	RedirectSIPURI      RedirectAddressType = 3
+)
+
+// String returns the RFC 4006 name of the address type.
+func (r RedirectAddressType) String() string {
+	switch r {
+	case RedirectIPv4Address:
+		return "IPv4 Address"
+	case RedirectIPv6Address:
+		return "IPv6 Address"
+	case RedirectURL:
+		return "URL"
+	case RedirectSIPURI:
+		return "SIP URI"
+	}
+	return "UNKNOWN"
+}
+
+// RedirectServer represents a Redirect-Server AVP.
+type RedirectServer struct {
+	AddressType RedirectAddressType
+	Address     string
+}
+
+// ToAvp converts the Redirect-Server to a grouped AVP.
+func (r RedirectServer) ToAvp() diameter.Avp {
+	avps := diameter.NewAvps()
+	avps = avps.AddUint32(AvpRedirectAddressType, mandatoryFlags, 0, uint32(r.AddressType))
+	avps = avps.AddString(AvpRedirectServerAddress, mandatoryFlags, 0, r.Address)
+	return diameter.NewAvpGroup(AvpRedirectServer, mandatoryFlags, 0, avps...)
+}
+
+// FromAvp reads the Redirect-Server from a grouped AVP.
+func (r *RedirectServer) FromAvp(avp *diameter.Avp) {
+	group := avp.ToGroup()
+	r.AddressType = RedirectAddressType(group.GetFirst(AvpRedirectAddressType, 0).ToUint32OrDefault())
+	r.Address = group.GetFirst(AvpRedirectServerAddress, 0).ToStringOrDefault()
+}
+
+// FinalUnitIndication represents a Final-Unit-Indication AVP. RedirectServer
+// applies to the REDIRECT action, and RestrictionFilterRules and FilterIds
+// to the RESTRICT_ACCESS action.
+type FinalUnitIndication struct {
+	FinalUnitAction        FinalUnitAction
+	RestrictionFilterRules []string
+	FilterIds              []string
+	RedirectServer         *RedirectServer
+}
+
+// NewRedirect creates a Final-Unit-Indication redirecting to the address.
+func NewRedirect(addressType RedirectAddressType, address string) FinalUnitIndication {
+	return FinalUnitIndication{
+		FinalUnitAction: Redirect,
+		RedirectServer:  &RedirectServer{AddressType: addressType, Address: address},
+	}
+}
+
+// NewRestrictAccess creates a Final-Unit-Indication restricting access with
+// the IPFilterRule rules.
+func NewRestrictAccess(rules ...string) FinalUnitIndication {
+	return FinalUnitIndication{FinalUnitAction: RestrictAccess, RestrictionFilterRules: rules}
+}
+
+// ToAvp converts the Final-Unit-Indication to a grouped AVP.
+func (f FinalUnitIndication) ToAvp() diameter.Avp {
+	avps := diameter.NewAvps().AddUint32(AvpFinalUnitAction, mandatoryFlags, 0, uint32(f.FinalUnitAction))
+	for _, rule := range f.RestrictionFilterRules {
+		avps = avps.AddString(AvpRestrictionFilterRule, mandatoryFlags, 0, rule)
+	}
+	for _, filterId := range f.FilterIds {
+		avps = avps.AddString(AvpFilterId, mandatoryFlags, 0, filterId)
+	}
+	if f.RedirectServer != nil {
+		avps = append(avps, f.RedirectServer.ToAvp())
+	}
+	return diameter.NewAvpGroup(AvpFinalUnitIndication, mandatoryFlags, 0, avps...)
+}
+
+// FromAvp reads the Final-Unit-Indication from a grouped AVP.
+func (f *FinalUnitIndication) FromAvp(avp *diameter.Avp) {
+	group := avp.ToGroup()
+	f.FinalUnitAction = FinalUnitAction(group.GetFirst(AvpFinalUnitAction, 0).ToUint32OrDefault())
+	f.RestrictionFilterRules = readStrings(group, AvpRestrictionFilterRule)
+	f.FilterIds = readStrings(group, AvpFilterId)
+	f.RedirectServer = nil
+	if redirectServer := group.GetFirst(AvpRedirectServer, 0); redirectServer != nil {
+		f.RedirectServer = &RedirectServer{}
+		f.RedirectServer.FromAvp(redirectServer)
+	}
+}
+
+// readStrings reads every string AVP with the given code.
+func readStrings(avps diameter.Avps, code diameter.Code) []string {
+	var values []string
+	for _, avp := range avps.Get(code, 0) {
+		values = append(values, avp.ToStringOrDefault())
+	}
+	return values
+}
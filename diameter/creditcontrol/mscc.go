@@ -0,0 +1,205 @@
+package creditcontrol
+
+import (
+	"maps"
+
+	"github.com/tinybluerobots/radius-diameter-message/diameter"
+)
+
+// ServiceUnit represents a Requested-Service-Unit, Granted-Service-Unit or
+// Used-Service-Unit AVP. Zero values are omitted from messages.
+type ServiceUnit struct {
+	Time                 uint32
+	TotalOctets          uint64
+	InputOctets          uint64
+	OutputOctets         uint64
+	ServiceSpecificUnits uint64
+}
+
+// toAvp converts the service unit to a grouped AVP with the given code.
+func (s ServiceUnit) toAvp(code diameter.Code) diameter.Avp {
+	avps := diameter.NewAvps()
+	if s.Time != 0 {
+		avps = avps.AddUint32(AvpCCTime, mandatoryFlags, 0, s.Time)
+	}
+	if s.TotalOctets != 0 {
+		avps = avps.AddUint64(AvpCCTotalOctets, mandatoryFlags, 0, s.TotalOctets)
+	}
+	if s.InputOctets != 0 {
+		avps = avps.AddUint64(AvpCCInputOctets, mandatoryFlags, 0, s.InputOctets)
+	}
+	if s.OutputOctets != 0 {
+		avps = avps.AddUint64(AvpCCOutputOctets, mandatoryFlags, 0, s.OutputOctets)
+	}
+	if s.ServiceSpecificUnits != 0 {
+		avps = avps.AddUint64(AvpCCServiceSpecificUnits, mandatoryFlags, 0, s.ServiceSpecificUnits)
+	}
+	return diameter.NewAvpGroup(code, mandatoryFlags, 0, avps...)
+}
+
+// Add returns the sum of the service units.
+func (s ServiceUnit) Add(other ServiceUnit) ServiceUnit {
+	return ServiceUnit{
+		Time:                 s.Time + other.Time,
+		TotalOctets:          s.TotalOctets + other.TotalOctets,
+		InputOctets:          s.InputOctets + other.InputOctets,
+		OutputOctets:         s.OutputOctets + other.OutputOctets,
+		ServiceSpecificUnits: s.ServiceSpecificUnits + other.ServiceSpecificUnits,
+	}
+}
+
+// readServiceUnit reads a service unit from a grouped AVP.
+func readServiceUnit(avp *diameter.Avp) ServiceUnit {
+	group := avp.ToGroup()
+	return ServiceUnit{
+		Time:                 group.GetFirst(AvpCCTime, 0).ToUint32OrDefault(),
+		TotalOctets:          group.GetFirst(AvpCCTotalOctets, 0).ToUint64OrDefault(),
+		InputOctets:          group.GetFirst(AvpCCInputOctets, 0).ToUint64OrDefault(),
+		OutputOctets:         group.GetFirst(AvpCCOutputOctets, 0).ToUint64OrDefault(),
+		ServiceSpecificUnits: group.GetFirst(AvpCCServiceSpecificUnits, 0).ToUint64OrDefault(),
+	}
+}
+
+// readOptionalServiceUnit reads a service unit if the AVP is present.
+func readOptionalServiceUnit(avp *diameter.Avp) *ServiceUnit {
+	if avp == nil {
+		return nil
+	}
+	serviceUnit := readServiceUnit(avp)
+	return &serviceUnit
+}
+
+// MSCC represents a Multiple-Services-Credit-Control AVP. A nil
+// RequestedServiceUnit or GrantedServiceUnit is omitted, whereas an empty
+// one is sent as an empty group.
+type MSCC struct {
+	RatingGroup          *uint32
+	ServiceIdentifiers   []uint32
+	RequestedServiceUnit *ServiceUnit
+	GrantedServiceUnit   *ServiceUnit
+	UsedServiceUnits     []ServiceUnit
+	ResultCode           diameter.ResultCode
+	FinalUnitIndication  *FinalUnitIndication
+	ValidityTime         uint32
+}
+
+// ToAvp converts the MSCC to a grouped AVP.
+func (m MSCC) ToAvp() diameter.Avp {
+	avps := diameter.NewAvps()
+	if m.GrantedServiceUnit != nil {
+		avps = append(avps, m.GrantedServiceUnit.toAvp(AvpGrantedServiceUnit))
+	}
+	if m.RequestedServiceUnit != nil {
+		avps = append(avps, m.RequestedServiceUnit.toAvp(AvpRequestedServiceUnit))
+	}
+	for _, usedServiceUnit := range m.UsedServiceUnits {
+		avps = append(avps, usedServiceUnit.toAvp(AvpUsedServiceUnit))
+	}
+	for _, serviceIdentifier := range m.ServiceIdentifiers {
+		avps = avps.AddUint32(AvpServiceIdentifier, mandatoryFlags, 0, serviceIdentifier)
+	}
+	if m.RatingGroup != nil {
+		avps = avps.AddUint32(AvpRatingGroup, mandatoryFlags, 0, *m.RatingGroup)
+	}
+	if m.ResultCode != 0 {
+		avps = avps.AddUint32(diameter.AvpResultCode, mandatoryFlags, 0, uint32(m.ResultCode))
+	}
+	if m.FinalUnitIndication != nil {
+		avps = append(avps, m.FinalUnitIndication.ToAvp())
+	}
+	if m.ValidityTime != 0 {
+		avps = avps.AddUint32(AvpValidityTime, mandatoryFlags, 0, m.ValidityTime)
+	}
+	return diameter.NewAvpGroup(AvpMultipleServicesCreditControl, mandatoryFlags, 0, avps...)
+}
+
+// FromAvp reads the MSCC from a grouped AVP.
+func (m *MSCC) FromAvp(avp *diameter.Avp) {
+	group := avp.ToGroup()
+	m.RatingGroup = group.GetFirst(AvpRatingGroup, 0).ToUint32()
+	m.ServiceIdentifiers = nil
+	for _, serviceIdentifier := range group.Get(AvpServiceIdentifier, 0) {
+		m.ServiceIdentifiers = append(m.ServiceIdentifiers, serviceIdentifier.ToUint32OrDefault())
+	}
+	m.RequestedServiceUnit = readOptionalServiceUnit(group.GetFirst(AvpRequestedServiceUnit, 0))
+	m.GrantedServiceUnit = readOptionalServiceUnit(group.GetFirst(AvpGrantedServiceUnit, 0))
+	m.UsedServiceUnits = nil
+	for _, usedServiceUnit := range group.Get(AvpUsedServiceUnit, 0) {
+		m.UsedServiceUnits = append(m.UsedServiceUnits, readServiceUnit(&usedServiceUnit))
+	}
+	m.ResultCode = diameter.ResultCode(group.GetFirst(diameter.AvpResultCode, 0).ToUint32OrDefault())
+	m.FinalUnitIndication = nil
+	if finalUnitIndication := group.GetFirst(AvpFinalUnitIndication, 0); finalUnitIndication != nil {
+		m.FinalUnitIndication = &FinalUnitIndication{}
+		m.FinalUnitIndication.FromAvp(finalUnitIndication)
+	}
+	m.ValidityTime = group.GetFirst(AvpValidityTime, 0).ToUint32OrDefault()
+}
+
+// Key returns the key identifying the service the MSCC is for.
+func (m MSCC) Key() ServiceKey {
+	key := ServiceKey{}
+	if m.RatingGroup != nil {
+		key.RatingGroup = *m.RatingGroup
+	}
+	if len(m.ServiceIdentifiers) > 0 {
+		key.ServiceIdentifier = m.ServiceIdentifiers[0]
+	}
+	return key
+}
+
+// readMSCCs reads every Multiple-Services-Credit-Control AVP.
+func readMSCCs(avps diameter.Avps) []MSCC {
+	var msccs []MSCC
+	for _, avp := range avps.Get(AvpMultipleServicesCreditControl, 0) {
+		mscc := MSCC{}
+		mscc.FromAvp(&avp)
+		msccs = append(msccs, mscc)
+	}
+	return msccs
+}
+
+// ServiceKey identifies a service by Rating-Group and Service-Identifier,
+// either of which is zero when absent.
+type ServiceKey struct {
+	RatingGroup       uint32
+	ServiceIdentifier uint32
+}
+
+// UsageAggregator totals the Used-Service-Unit AVPs reported for each
+// service across the updates of a credit-control session.
+type UsageAggregator struct {
+	totals map[ServiceKey]ServiceUnit
+}
+
+// NewUsageAggregator creates a new UsageAggregator.
+func NewUsageAggregator() *UsageAggregator {
+	return &UsageAggregator{totals: make(map[ServiceKey]ServiceUnit)}
+}
+
+// Add adds the usage reported in the MSCCs.
+func (u *UsageAggregator) Add(msccs ...MSCC) {
+	for _, mscc := range msccs {
+		key := mscc.Key()
+		total := u.totals[key]
+		for _, usedServiceUnit := range mscc.UsedServiceUnits {
+			total = total.Add(usedServiceUnit)
+		}
+		u.totals[key] = total
+	}
+}
+
+// AddCCR adds the usage reported in the CCR.
+func (u *UsageAggregator) AddCCR(ccr CCR) {
+	u.Add(ccr.MultipleServicesCreditControl...)
+}
+
+// Total returns the usage of the service.
+func (u *UsageAggregator) Total(key ServiceKey) ServiceUnit {
+	return u.totals[key]
+}
+
+// Totals returns the usage of every service.
+func (u *UsageAggregator) Totals() map[ServiceKey]ServiceUnit {
+	return maps.Clone(u.totals)
+}
@@ -0,0 +1,146 @@
+package creditcontrol
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var (
+	// ErrSessionStarted is returned for an INITIAL or EVENT request in a
+	// session that has already started.
+	ErrSessionStarted = errors.New("credit control session already started")
+	// ErrSessionNotStarted is returned for an UPDATE or TERMINATION request in
+	// a session that has not started.
+	ErrSessionNotStarted = errors.New("credit control session not started")
+	// ErrRequestNumberGap is returned when a CC-Request-Number skips ahead.
+	ErrRequestNumberGap = errors.New("CC-Request-Number gap")
+	// ErrRequestNumberReplay is returned when a CC-Request-Number repeats an earlier one.
+	ErrRequestNumberReplay = errors.New("CC-Request-Number replay")
+	// errUnknownRequestType is returned for a CC-Request-Type outside RFC 4006.
+	errUnknownRequestType = errors.New("unknown CC-Request-Type")
+)
+
+// Sequencer numbers the requests of each credit control session, enforcing
+// that a session is an INITIAL request numbered 0, any number of UPDATEs
+// numbered upwards and a TERMINATION, or a single EVENT numbered 0. It is
+// safe for concurrent use.
+type Sequencer struct {
+	mutex    sync.Mutex
+	sessions map[string]uint32
+}
+
+// NewSequencer creates a new Sequencer.
+func NewSequencer() *Sequencer {
+	return &Sequencer{sessions: make(map[string]uint32)}
+}
+
+// Next returns the CC-Request-Number of the session's next request of the
+// given type. The session is forgotten after its TERMINATION request.
+func (s *Sequencer) Next(sessionId string, requestType RequestType) (uint32, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	number, started := s.sessions[sessionId]
+	switch requestType {
+	case InitialRequest, EventRequest:
+		if started {
+			return 0, ErrSessionStarted
+		}
+		if requestType == InitialRequest {
+			s.sessions[sessionId] = 0
+		}
+		return 0, nil
+	case UpdateRequest, TerminationRequest:
+		if !started {
+			return 0, ErrSessionNotStarted
+		}
+		number++
+		s.sessions[sessionId] = number
+		if requestType == TerminationRequest {
+			delete(s.sessions, sessionId)
+		}
+		return number, nil
+	}
+	return 0, errUnknownRequestType
+}
+
+// Sequence sets the RequestNumber of the CCR from its SessionId and RequestType.
+func (s *Sequencer) Sequence(ccr *CCR) error {
+	number, err := s.Next(ccr.SessionId, ccr.RequestType)
+	if err != nil {
+		return err
+	}
+	ccr.RequestNumber = number
+	return nil
+}
+
+// Active reports whether the session has started and not yet terminated.
+func (s *Sequencer) Active(sessionId string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	_, started := s.sessions[sessionId]
+	return started
+}
+
+// Validator checks the CC-Request-Type and CC-Request-Number of the requests
+// a server receives follow the sequence Sequencer produces, flagging gaps and
+// replays. It is safe for concurrent use.
+type Validator struct {
+	mutex    sync.Mutex
+	sessions map[string]uint32
+}
+
+// NewValidator creates a new Validator.
+func NewValidator() *Validator {
+	return &Validator{sessions: make(map[string]uint32)}
+}
+
+// Check validates the next request of the session, recording it if it is
+// valid. The session is forgotten after a valid TERMINATION request.
+func (v *Validator) Check(sessionId string, requestType RequestType, number uint32) error {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	last, started := v.sessions[sessionId]
+	switch requestType {
+	case InitialRequest, EventRequest:
+		if started {
+			return ErrSessionStarted
+		}
+		if number != 0 {
+			return fmt.Errorf("%w: %s request numbered %d", ErrRequestNumberGap, requestType, number)
+		}
+		if requestType == InitialRequest {
+			v.sessions[sessionId] = 0
+		}
+		return nil
+	case UpdateRequest, TerminationRequest:
+		if !started {
+			return ErrSessionNotStarted
+		}
+		if number <= last {
+			return fmt.Errorf("%w: expected %d, got %d", ErrRequestNumberReplay, last+1, number)
+		}
+		if number != last+1 {
+			return fmt.Errorf("%w: expected %d, got %d", ErrRequestNumberGap, last+1, number)
+		}
+		v.sessions[sessionId] = number
+		if requestType == TerminationRequest {
+			delete(v.sessions, sessionId)
+		}
+		return nil
+	}
+	return errUnknownRequestType
+}
+
+// Validate checks the CCR with Check.
+func (v *Validator) Validate(ccr CCR) error {
+	return v.Check(ccr.SessionId, ccr.RequestType, ccr.RequestNumber)
+}
+
+// Forget removes the session, for example when it expires without a
+// TERMINATION request.
+func (v *Validator) Forget(sessionId string) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	delete(v.sessions, sessionId)
+}
@@ -0,0 +1,99 @@
+package creditcontrol
+
+import "github.com/tinybluerobots/radius-diameter-message/diameter"
+
+// SubscriptionIdType represents the value of a Subscription-Id-Type AVP.
+type SubscriptionIdType uint32
+
+const (
+	EndUserE164    SubscriptionIdType = 0
+	EndUserIMSI    SubscriptionIdType = 1
+	EndUserSIPURI  SubscriptionIdType = 2
+	EndUserNAI     SubscriptionIdType = 3
+	EndUserPrivate SubscriptionIdType = 4
+)
+
+// SubscriptionId represents a Subscription-Id AVP.
+type SubscriptionId struct {
+	Type SubscriptionIdType
+	Data string
+}
+
+// NewSubscriptionId creates a Subscription-Id AVP.
+func NewSubscriptionId(subscriptionIdType SubscriptionIdType, data string) diameter.Avp {
+	return SubscriptionId{Type: subscriptionIdType, Data: data}.ToAvp()
+}
+
+// ToAvp converts the Subscription-Id to a grouped AVP.
+func (s SubscriptionId) ToAvp() diameter.Avp {
+	avps := diameter.NewAvps()
+	avps = avps.AddUint32(AvpSubscriptionIdType, mandatoryFlags, 0, uint32(s.Type))
+	avps = avps.AddString(AvpSubscriptionIdData, mandatoryFlags, 0, s.Data)
+	return diameter.NewAvpGroup(AvpSubscriptionId, mandatoryFlags, 0, avps...)
+}
+
+// SubscriptionIds returns the type and data of every Subscription-Id AVP.
+func SubscriptionIds(avps diameter.Avps) []SubscriptionId {
+	var subscriptionIds []SubscriptionId
+	for _, avp := range avps.Get(AvpSubscriptionId, 0) {
+		group := avp.ToGroup()
+		subscriptionIds = append(subscriptionIds, SubscriptionId{
+			Type: SubscriptionIdType(group.GetFirst(AvpSubscriptionIdType, 0).ToUint32OrDefault()),
+			Data: group.GetFirst(AvpSubscriptionIdData, 0).ToStringOrDefault(),
+		})
+	}
+	return subscriptionIds
+}
+
+// subscriptionIdData returns the data of the first Subscription-Id of the type.
+func subscriptionIdData(subscriptionIds []SubscriptionId, subscriptionIdType SubscriptionIdType) (string, bool) {
+	for _, subscriptionId := range subscriptionIds {
+		if subscriptionId.Type == subscriptionIdType {
+			return subscriptionId.Data, true
+		}
+	}
+	return "", false
+}
+
+// UserEquipmentInfoType represents the value of a User-Equipment-Info-Type AVP.
+type UserEquipmentInfoType uint32
+
+const (
+	IMEISV        UserEquipmentInfoType = 0
+	MAC           UserEquipmentInfoType = 1
+	EUI64         UserEquipmentInfoType = 2
+	ModifiedEUI64 UserEquipmentInfoType = 3
+)
+
+// UserEquipmentInfo represents a User-Equipment-Info AVP.
+type UserEquipmentInfo struct {
+	Type  UserEquipmentInfoType
+	Value []byte
+}
+
+// NewUserEquipmentInfo creates a User-Equipment-Info AVP.
+func NewUserEquipmentInfo(userEquipmentInfoType UserEquipmentInfoType, value []byte) diameter.Avp {
+	return UserEquipmentInfo{Type: userEquipmentInfoType, Value: value}.ToAvp()
+}
+
+// ToAvp converts the User-Equipment-Info to a grouped AVP.
+func (u UserEquipmentInfo) ToAvp() diameter.Avp {
+	avps := diameter.NewAvps()
+	avps = avps.AddUint32(AvpUserEquipmentInfoType, 0, 0, uint32(u.Type))
+	avps = avps.Add(AvpUserEquipmentInfoValue, 0, 0, u.Value)
+	return diameter.NewAvpGroup(AvpUserEquipmentInfo, 0, 0, avps...)
+}
+
+// ReadUserEquipmentInfo returns the type and value of the User-Equipment-Info
+// AVP, returning false if it is absent.
+func ReadUserEquipmentInfo(avps diameter.Avps) (UserEquipmentInfo, bool) {
+	avp := avps.GetFirst(AvpUserEquipmentInfo, 0)
+	if avp == nil {
+		return UserEquipmentInfo{}, false
+	}
+	group := avp.ToGroup()
+	return UserEquipmentInfo{
+		Type:  UserEquipmentInfoType(group.GetFirst(AvpUserEquipmentInfoType, 0).ToUint32OrDefault()),
+		Value: group.GetFirst(AvpUserEquipmentInfoValue, 0).ToData(),
+	}, true
+}
@@ -0,0 +1,109 @@
+package diameter
+
+import (
+	"fmt"
+	"io"
+	"slices"
+)
+
+// Decoder reads Diameter messages with decode policies applied.
+// The zero value behaves like ReadMessage within the default Limits.
+type Decoder struct {
+	// Dictionary holds the AVPs known to the receiver.
+	Dictionary *Dictionary
+	// RejectUnknownMandatory reports AVPs with the M bit set that are not in
+	// the dictionary, including those nested in known grouped AVPs, as an
+	// *UnknownMandatoryError.
+	RejectUnknownMandatory bool
+	// Pool, if set, supplies the messages read, which the caller releases to
+	// it when done with them.
+	Pool *Pool
+	// CopyData makes the data of the AVPs read refer to a copy of the byte
+	// slice, so that the caller may reuse it while keeping the message. By
+	// default the data refers to the byte slice itself, which is faster.
+	CopyData bool
+	// Limits bound the size, AVP count and nesting depth of the messages
+	// read, which are rejected with a *LimitError beyond them.
+	Limits Limits
+}
+
+// UnknownMandatoryError is returned, with the decoded message, when a message
+// contains mandatory AVPs unknown to the receiver. Per RFC 6733 the request
+// must be answered with DIAMETER_AVP_UNSUPPORTED and the AVPs in Failed-AVP.
+type UnknownMandatoryError struct {
+	Avps Avps
+}
+
+// Error returns the codes of the unknown mandatory AVPs.
+func (e *UnknownMandatoryError) Error() string {
+	codes := make([]string, 0, len(e.Avps))
+	for _, avp := range e.Avps {
+		codes = append(codes, fmt.Sprintf("%d (vendor %d)", avp.Code, avp.VendorId))
+	}
+	return fmt.Sprintf("unknown mandatory AVPs %v", codes)
+}
+
+// ResultCode returns DIAMETER_AVP_UNSUPPORTED.
+func (e *UnknownMandatoryError) ResultCode() ResultCode {
+	return ResultCodeAvpUnsupported
+}
+
+// Is reports whether the target is ErrUnknownMandatoryAvp.
+func (e *UnknownMandatoryError) Is(target error) bool {
+	return target == ErrUnknownMandatoryAvp
+}
+
+// FailedAvp creates the Failed-AVP reporting the unknown mandatory AVPs.
+func (e *UnknownMandatoryError) FailedAvp() Avp {
+	return NewAvpFailedAvp(e.Avps...)
+}
+
+// ReadMessage reads a byte slice and converts it to a Diameter message. If
+// the message breaks a decode policy it is returned along with the error.
+func (d Decoder) ReadMessage(bytes []byte) (*Message, error) {
+	limits := d.Limits.withDefaults()
+	if err := limits.check(bytes, d.Dictionary); err != nil {
+		return nil, err
+	}
+	if d.CopyData {
+		bytes = slices.Clone(bytes)
+	}
+	message, err := d.Pool.ReadMessage(bytes)
+	if err != nil {
+		return nil, err
+	}
+	if d.RejectUnknownMandatory {
+		unknown := d.unknownMandatory(message.Avps, NewAvps())
+		if len(unknown) > 0 {
+			return message, &UnknownMandatoryError{unknown}
+		}
+	}
+	return message, nil
+}
+
+// ReadMessageFrom reads a single message from a stream as the package's
+// ReadMessageFrom does, rejecting a message whose header declares a length
+// beyond the limits before reading the rest of it. If the message breaks a
+// decode policy it is returned along with a *MessageError.
+func (d Decoder) ReadMessageFrom(reader io.Reader) (*Message, error) {
+	message, _, err := readMessageFrom(reader, d.Limits.withDefaults().MaxMessageSize, d.ReadMessage)
+	return message, err
+}
+
+// unknownMandatory collects the mandatory AVPs missing from the dictionary,
+// recursing into known grouped AVPs, whose depth the limits have bounded.
+func (d Decoder) unknownMandatory(avps Avps, unknown Avps) Avps {
+	for _, avp := range avps {
+		definition := d.Dictionary.Avp(avp.Code, avp.VendorId)
+		if definition == nil {
+			if avp.Flags.IsMandatory() {
+				unknown = append(unknown, avp)
+			}
+			continue
+		}
+		if definition.Type == Grouped {
+			unknown = d.unknownMandatory(avp.ToGroup(), unknown)
+		}
+	}
+	return unknown
+}
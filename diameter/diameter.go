@@ -2,12 +2,17 @@ package diameter
 
 import (
 	"encoding/binary"
-	"errors"
+	"fmt"
 	"math"
 	"net"
+	"slices"
 	"time"
 )
 
+// ntpEpochOffset is the number of seconds from the NTP epoch, 1900, which
+// Time AVPs count from, to the Unix epoch.
+const ntpEpochOffset = 2208988800
+
 // Flags represents the flags in a Diameter AVP.
 type Flags byte
 
@@ -28,18 +33,20 @@ type Avp struct {
 	VendorId VendorId
 	Data     avpData
 	padding  uint32
+	pad      []byte
 }
 
-// WithFlags sets the flags for the AVP.
+// WithFlags sets the flags for the AVP, keeping the V bit consistent with the vendor ID.
 func (a *Avp) WithFlags(flags Flags) *Avp {
 	if a == nil {
 		return nil
 	}
-	a.Flags = flags
+	a.Flags = vendorFlags(flags, a.VendorId)
 	return a
 }
 
 // NewAvp creates a new AVP with the given code, flags, vendor ID, and data.
+// The V bit is set if and only if the vendor ID is not zero.
 func NewAvp(code Code, flags Flags, vendorId VendorId, avpData avpData) Avp {
 	padding := uint32(len(avpData) % 4)
 	if padding != 0 {
@@ -51,7 +58,7 @@ func NewAvp(code Code, flags Flags, vendorId VendorId, avpData avpData) Avp {
 	}
 	return Avp{
 		Code:     code,
-		Flags:    flags,
+		Flags:    vendorFlags(flags, vendorId),
 		length:   length,
 		VendorId: vendorId,
 		Data:     avpData,
@@ -115,26 +122,60 @@ func NewAvpNetIP(code Code, flags Flags, vendorId VendorId, value net.IP) Avp {
 	}
 }
 
-// NewAvpTime creates a new AVP with a time.Time value.
+// NewAvpTime creates a new AVP with a time.Time value, encoded as the
+// seconds since 1900 that RFC 6733 section 4.3.1 specifies for the Time
+// format.
 func NewAvpTime(code Code, flags Flags, vendorId VendorId, value time.Time) Avp {
 	buffer := make([]byte, 4)
-	binary.BigEndian.PutUint32(buffer, uint32(value.Unix()))
+	binary.BigEndian.PutUint32(buffer, uint32(value.Unix()+ntpEpochOffset))
 	return NewAvp(code, flags, vendorId, buffer)
 }
 
 // ToBytes converts the AVP to a byte slice.
 func (a Avp) ToBytes() []byte {
-	bytes := make([]byte, a.length+a.padding)
+	return a.AppendBytes(make([]byte, 0, a.length+a.padding))
+}
+
+// AppendBytes appends the AVP, with its padding, to the byte slice and
+// returns the extended slice, so that a buffer can be reused across messages.
+func (a Avp) AppendBytes(dst []byte) []byte {
+	start := len(dst)
+	dst = slices.Grow(dst, int(a.length+a.padding))[:start+int(a.length+a.padding)]
+	bytes := dst[start:]
+	clear(bytes)
 	binary.BigEndian.PutUint32(bytes, uint32(a.Code))
 	bytes[4] = byte(a.Flags)
-	copy(bytes[5:8], writeUInt24(a.length))
+	putUInt24(bytes[5:8], a.length)
 	if a.VendorId != 0 {
 		binary.BigEndian.PutUint32(bytes[8:12], uint32(a.VendorId))
 		copy(bytes[12:], a.Data)
 	} else {
 		copy(bytes[8:], a.Data)
 	}
-	return bytes
+	if len(a.pad) == int(a.padding) {
+		copy(bytes[a.length:], a.pad)
+	}
+	return dst
+}
+
+// appendHeader appends the bytes before the data of the AVP to the byte
+// slice.
+func (a Avp) appendHeader(dst []byte) []byte {
+	dst = binary.BigEndian.AppendUint32(dst, uint32(a.Code))
+	dst = append(dst, byte(a.Flags))
+	dst = appendUInt24(dst, a.length)
+	if a.VendorId != 0 {
+		dst = binary.BigEndian.AppendUint32(dst, uint32(a.VendorId))
+	}
+	return dst
+}
+
+// headerLength returns the number of bytes before the data of the AVP.
+func (a Avp) headerLength() int {
+	if a.VendorId != 0 {
+		return 12
+	}
+	return 8
 }
 
 // Avps represents a slice of AVPs.
@@ -147,11 +188,25 @@ func NewAvps() Avps {
 
 // ToBytes converts the slice of AVPs to a byte slice.
 func (a Avps) ToBytes() []byte {
-	bytes := make([]byte, 0)
+	return a.AppendBytes(make([]byte, 0, a.size()))
+}
+
+// AppendBytes appends the AVPs to the byte slice and returns the extended
+// slice.
+func (a Avps) AppendBytes(dst []byte) []byte {
+	for _, avp := range a {
+		dst = avp.AppendBytes(dst)
+	}
+	return dst
+}
+
+// size returns the number of bytes of the encoded AVPs.
+func (a Avps) size() uint32 {
+	size := uint32(0)
 	for _, avp := range a {
-		bytes = append(bytes, avp.ToBytes()...)
+		size += avp.length + avp.padding
 	}
-	return bytes
+	return size
 }
 
 // Add adds a new AVP to the slice.
@@ -207,28 +262,19 @@ func (a Avps) AddGroup(code Code, flags Flags, vendorId VendorId, groupAvps ...A
 // ApplicationId represents the application ID in a Diameter message.
 type ApplicationId uint32
 
-// toBytes converts the ApplicationId to a byte slice.
-func (a ApplicationId) toBytes() []byte {
-	bytes := make([]byte, 4)
-	binary.BigEndian.PutUint32(bytes, uint32(a))
-	return bytes
+// putUInt24 writes a uint32 value to a 3-byte slice.
+func putUInt24(bytes []byte, value uint32) {
+	bytes[0], bytes[1], bytes[2] = byte(value>>16), byte(value>>8), byte(value)
 }
 
-// writeUInt24 writes a uint32 value as a 3-byte slice.
-func writeUInt24(value uint32) []byte {
-	bytes := make([]byte, 4)
-	binary.BigEndian.PutUint32(bytes, value)
-	return bytes[1:]
+// appendUInt24 appends a uint32 value as 3 bytes to the byte slice.
+func appendUInt24(dst []byte, value uint32) []byte {
+	return append(dst, byte(value>>16), byte(value>>8), byte(value))
 }
 
 // CommandCode represents the command code in a Diameter message.
 type CommandCode uint32
 
-// toBytes converts the CommandCode to a byte slice.
-func (c CommandCode) toBytes() []byte {
-	return writeUInt24(uint32(c))
-}
-
 // Message represents a Diameter message.
 type Message struct {
 	Version       byte
@@ -242,11 +288,7 @@ type Message struct {
 
 // length calculates the length of the Diameter message.
 func (m Message) length() uint32 {
-	length := uint32(20)
-	for _, avp := range m.Avps {
-		length += avp.length + avp.padding
-	}
-	return length
+	return 20 + m.Avps.size()
 }
 
 // NewMessage creates a new Diameter message.
@@ -264,16 +306,24 @@ func NewMessage(version byte, flags Flags, commandCode CommandCode, applicationI
 
 // ToBytes converts the Diameter message to a byte slice.
 func (message Message) ToBytes() []byte {
-	bytes := make([]byte, 0)
-	bytes = append(bytes, message.Version)
-	bytes = append(bytes, writeUInt24(message.length())...)
-	bytes = append(bytes, byte(message.Flags))
-	bytes = append(bytes, message.CommandCode.toBytes()...)
-	bytes = append(bytes, message.ApplicationId.toBytes()...)
-	bytes = append(bytes, message.HopByHopId[:]...)
-	bytes = append(bytes, message.EndToEndId[:]...)
-	bytes = append(bytes, message.Avps.ToBytes()...)
-	return bytes
+	return message.AppendBytes(make([]byte, 0, message.length()))
+}
+
+// AppendBytes appends the Diameter message to the byte slice and returns the
+// extended slice, so that a buffer can be reused across messages.
+func (message Message) AppendBytes(dst []byte) []byte {
+	return message.Avps.AppendBytes(message.appendHeader(dst))
+}
+
+// appendHeader appends the 20 byte header of the message to the byte slice.
+func (message Message) appendHeader(dst []byte) []byte {
+	dst = append(dst, message.Version)
+	dst = appendUInt24(dst, message.length())
+	dst = append(dst, byte(message.Flags))
+	dst = appendUInt24(dst, uint32(message.CommandCode))
+	dst = binary.BigEndian.AppendUint32(dst, uint32(message.ApplicationId))
+	dst = append(dst, message.HopByHopId[:]...)
+	return append(dst, message.EndToEndId[:]...)
 }
 
 // Get retrieves all AVPs with the given code and vendor ID.
@@ -287,6 +337,18 @@ func (a Avps) Get(code Code, vendorId VendorId) Avps {
 	return filteredAvps
 }
 
+// Without returns the AVPs other than those with the vendor ID and one of
+// the codes, such as those left over once a command's own AVPs are read.
+func (a Avps) Without(vendorId VendorId, codes ...Code) Avps {
+	var others Avps
+	for _, avp := range a {
+		if avp.VendorId != vendorId || !slices.Contains(codes, avp.Code) {
+			others = append(others, avp)
+		}
+	}
+	return others
+}
+
 // GetFirst retrieves the first AVP with the given code and vendor ID.
 func (a Avps) GetFirst(code Code, vendorId VendorId) *Avp {
 	for _, avp := range a {
@@ -307,143 +369,192 @@ func (a *Avp) ToData() []byte {
 
 // ToString converts the AVP to a string.
 func (a *Avp) ToString() *string {
-	if a == nil || a.Data == nil {
+	value, ok := a.ToStringOk()
+	if !ok {
 		return nil
 	}
-	value := string(a.Data)
 	return &value
 }
 
 // ToStringOrDefault converts the AVP to a string or returns a default value.
 func (a *Avp) ToStringOrDefault() string {
-	value := a.ToString()
-	if value == nil {
+	value, _ := a.ToStringOk()
+	return value
+}
+
+// ToStringOk converts the AVP to a string, reporting whether the AVP is present and well formed.
+func (a *Avp) ToStringOk() (string, bool) {
+	if a == nil || a.Data == nil {
 		var value string
-		return value
+		return value, false
 	}
-	return *value
+	return string(a.Data), true
 }
 
 // ToUint32 converts the AVP to a uint32.
 func (a *Avp) ToUint32() *uint32 {
-	if a == nil || a.Data == nil {
+	value, ok := a.ToUint32Ok()
+	if !ok {
 		return nil
 	}
-	value := binary.BigEndian.Uint32(a.Data)
 	return &value
 }
 
 // ToUint32OrDefault converts the AVP to a uint32 or returns a default value.
 func (a *Avp) ToUint32OrDefault() uint32 {
-	value := a.ToUint32()
-	if value == nil {
+	value, _ := a.ToUint32Ok()
+	return value
+}
+
+// ToUint32Ok converts the AVP to a uint32, reporting whether the AVP is present and well formed.
+func (a *Avp) ToUint32Ok() (uint32, bool) {
+	if a == nil || a.Data == nil {
 		var value uint32
-		return value
+		return value, false
+	}
+	if len(a.Data) < 4 {
+		return 0, false
 	}
-	return *value
+	return binary.BigEndian.Uint32(a.Data), true
 }
 
 // ToUint64 converts the AVP to a uint64.
 func (a *Avp) ToUint64() *uint64 {
-	if a == nil || a.Data == nil {
+	value, ok := a.ToUint64Ok()
+	if !ok {
 		return nil
 	}
-	value := binary.BigEndian.Uint64(a.Data)
 	return &value
 }
 
 // ToUint64OrDefault converts the AVP to a uint64 or returns a default value.
 func (a *Avp) ToUint64OrDefault() uint64 {
-	value := a.ToUint64()
-	if value == nil {
+	value, _ := a.ToUint64Ok()
+	return value
+}
+
+// ToUint64Ok converts the AVP to a uint64, reporting whether the AVP is present and well formed.
+func (a *Avp) ToUint64Ok() (uint64, bool) {
+	if a == nil || a.Data == nil {
 		var value uint64
-		return value
+		return value, false
 	}
-	return *value
+	if len(a.Data) < 8 {
+		return 0, false
+	}
+	return binary.BigEndian.Uint64(a.Data), true
 }
 
 // ToFloat32 converts the AVP to a float32.
 func (a *Avp) ToFloat32() *float32 {
-	if a == nil || a.Data == nil {
+	value, ok := a.ToFloat32Ok()
+	if !ok {
 		return nil
 	}
-	bits := binary.BigEndian.Uint32(a.Data)
-	value := math.Float32frombits(bits)
 	return &value
 }
 
 // ToFloat32OrDefault converts the AVP to a float32 or returns a default value.
 func (a *Avp) ToFloat32OrDefault() float32 {
-	value := a.ToFloat32()
-	if value == nil {
+	value, _ := a.ToFloat32Ok()
+	return value
+}
+
+// ToFloat32Ok converts the AVP to a float32, reporting whether the AVP is present and well formed.
+func (a *Avp) ToFloat32Ok() (float32, bool) {
+	if a == nil || a.Data == nil {
 		var value float32
-		return value
+		return value, false
 	}
-	return *value
+	if len(a.Data) < 4 {
+		return 0, false
+	}
+	return math.Float32frombits(binary.BigEndian.Uint32(a.Data)), true
 }
 
 // ToFloat64 converts the AVP to a float64.
 func (a *Avp) ToFloat64() *float64 {
-	if a == nil || a.Data == nil {
+	value, ok := a.ToFloat64Ok()
+	if !ok {
 		return nil
 	}
-	bits := binary.BigEndian.Uint64(a.Data)
-	value := math.Float64frombits(bits)
 	return &value
 }
 
 // ToFloat64OrDefault converts the AVP to a float64 or returns a default value.
 func (a *Avp) ToFloat64OrDefault() float64 {
-	value := a.ToFloat64()
-	if value == nil {
+	value, _ := a.ToFloat64Ok()
+	return value
+}
+
+// ToFloat64Ok converts the AVP to a float64, reporting whether the AVP is present and well formed.
+func (a *Avp) ToFloat64Ok() (float64, bool) {
+	if a == nil || a.Data == nil {
 		var value float64
-		return value
+		return value, false
+	}
+	if len(a.Data) < 8 {
+		return 0, false
 	}
-	return *value
+	return math.Float64frombits(binary.BigEndian.Uint64(a.Data)), true
 }
 
 // ToNetIP converts the AVP to a net.IP.
 func (a *Avp) ToNetIP() *net.IP {
-	if a == nil || a.Data == nil {
+	value, ok := a.ToNetIPOk()
+	if !ok {
 		return nil
 	}
-	if a.Data[1] == 1 {
-		value := net.IP(a.Data[2:6])
-		return &value
-	} else {
-		value := net.IP(a.Data[2:18])
-		return &value
-	}
+	return &value
 }
 
 // ToNetIPOrDefault converts the AVP to a net.IP or returns a default value.
 func (a *Avp) ToNetIPOrDefault() net.IP {
-	value := a.ToNetIP()
-	if value == nil {
+	value, _ := a.ToNetIPOk()
+	return value
+}
+
+// ToNetIPOk converts the AVP to a net.IP, reporting whether the AVP is present and well formed.
+func (a *Avp) ToNetIPOk() (net.IP, bool) {
+	if a == nil || a.Data == nil {
 		var value net.IP
-		return value
+		return value, false
+	}
+	if len(a.Data) >= 6 && a.Data[1] == 1 {
+		return net.IP(a.Data[2:6]), true
+	}
+	if len(a.Data) >= 18 && a.Data[1] == 2 {
+		return net.IP(a.Data[2:18]), true
 	}
-	return *value
+	return nil, false
 }
 
 // ToTime converts the AVP to a time.Time.
 func (a *Avp) ToTime() *time.Time {
-	if a == nil || a.Data == nil {
+	value, ok := a.ToTimeOk()
+	if !ok {
 		return nil
 	}
-	timestamp := int64(binary.BigEndian.Uint32(a.Data))
-	value := time.Unix(timestamp-2208988800, 0)
 	return &value
 }
 
 // ToTimeOrDefault converts the AVP to a time.Time or returns a default value.
 func (a *Avp) ToTimeOrDefault() time.Time {
-	value := a.ToTime()
-	if value == nil {
+	value, _ := a.ToTimeOk()
+	return value
+}
+
+// ToTimeOk converts the AVP to a time.Time, reporting whether the AVP is present and well formed.
+func (a *Avp) ToTimeOk() (time.Time, bool) {
+	if a == nil || a.Data == nil {
 		var value time.Time
-		return value
+		return value, false
 	}
-	return *value
+	if len(a.Data) < 4 {
+		return time.Time{}, false
+	}
+	timestamp := int64(binary.BigEndian.Uint32(a.Data))
+	return time.Unix(timestamp-ntpEpochOffset, 0), true
 }
 
 // ToGroup converts the AVP to a grouped AVP.
@@ -454,55 +565,111 @@ func (a *Avp) ToGroup() Avps {
 	return readAvps(a.Data)
 }
 
-// readAvps reads a byte slice and converts it to a slice of AVPs.
+// readAvps reads a byte slice and converts it to a slice of AVPs, stopping at the first malformed AVP.
 func readAvps(bytes []byte) Avps {
+	avps, _ := decodeAvps(bytes)
+	return avps
+}
+
+// decodeAvps reads a byte slice and converts it to a slice of AVPs, returning
+// the AVPs read so far and an error if an AVP header or length is malformed.
+func decodeAvps(bytes []byte) (Avps, error) {
+	return appendAvps(NewAvps(), bytes)
+}
+
+// appendAvps reads a byte slice and appends its AVPs to the slice, returning
+// the AVPs read so far and an error if an AVP header or length is malformed.
+func appendAvps(avps Avps, bytes []byte) (Avps, error) {
 	offset := 0
-	avps := NewAvps()
 	for offset < len(bytes) {
-		code := Code(binary.BigEndian.Uint32(bytes[offset : offset+4]))
-		flags := Flags(bytes[offset+4])
-		vendorSpecific := flags&0x80 != 0
-		length := int(readUInt24(bytes[offset+5 : offset+8]))
-		var vendorId VendorId
-		var avpData avpData
-		if vendorSpecific {
-			vendorId = VendorId(binary.BigEndian.Uint32(bytes[offset+8 : offset+12]))
-			avpData = bytes[offset+12 : offset+length]
-		} else {
-			avpData = bytes[offset+8 : offset+length]
+		avp, next, err := readAvp(bytes, offset)
+		if err != nil {
+			return avps, err
 		}
-		avp := NewAvp(code, flags, vendorId, avpData)
 		avps = append(avps, avp)
-		offset += length + int(avp.padding)
+		offset = next
+	}
+	return avps, nil
+}
+
+// readAvp reads the AVP at the offset, returning it and the offset of the next AVP.
+func readAvp(bytes []byte, offset int) (Avp, int, error) {
+	if len(bytes)-offset < 8 {
+		return Avp{}, 0, &AvpError{ResultCode: ResultCodeInvalidMessageLength, Offset: offset, Reason: "truncated AVP header", Err: ErrTruncated}
+	}
+	code := Code(binary.BigEndian.Uint32(bytes[offset : offset+4]))
+	flags := Flags(bytes[offset+4])
+	vendorSpecific := flags.IsVendorSpecific()
+	length := int(readUInt24(bytes[offset+5 : offset+8]))
+	headerLength := 8
+	if vendorSpecific {
+		headerLength = 12
+	}
+	var vendorId VendorId
+	if vendorSpecific && len(bytes)-offset >= 12 {
+		vendorId = VendorId(binary.BigEndian.Uint32(bytes[offset+8 : offset+12]))
+	}
+	if length < headerLength || offset+length > len(bytes) {
+		failed := NewAvp(code, flags, vendorId, bytes[min(offset+headerLength, len(bytes)):])
+		return Avp{}, 0, &AvpError{
+			ResultCode: ResultCodeInvalidAvpLength,
+			Offset:     offset,
+			Avp:        &failed,
+			Reason:     fmt.Sprintf("invalid AVP length %d", length),
+			Err:        &InvalidLengthError{Offset: offset, Declared: length, Available: len(bytes) - offset},
+		}
 	}
-	return avps
+	avp := NewAvp(code, flags, vendorId, bytes[offset+headerLength:offset+length])
+	next := offset + length + int(avp.padding)
+	if next <= len(bytes) && !isZero(bytes[offset+length:next]) {
+		avp.pad = bytes[offset+length : next]
+	}
+	return avp, next, nil
+}
+
+// isZero reports whether every byte is zero.
+func isZero(bytes []byte) bool {
+	for _, b := range bytes {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
 }
 
 // readUInt24 reads a 3-byte slice and converts it to a uint32.
 func readUInt24(bytes []byte) uint32 {
-	if len(bytes) == 3 {
-		bytes = append([]byte{0}, bytes[:]...)
-	}
-	return binary.BigEndian.Uint32(bytes)
+	return uint32(bytes[0])<<16 | uint32(bytes[1])<<8 | uint32(bytes[2])
 }
 
-// ReadMessage reads a byte slice and converts it to a Diameter message.
+// ReadMessage reads a byte slice and converts it to a Diameter message. The
+// data of its AVPs refers to the byte slice rather than copying it, so the
+// byte slice must not be reused while the message is; a Decoder with
+// CopyData set copies it.
 func ReadMessage(bytes []byte) (*Message, error) {
-	if len(bytes) < 20 {
-		return nil, errors.New("invalid message length")
-	}
-	hopByHopId := [4]byte{}
-	copy(hopByHopId[:], bytes[12:16])
-	endToEndId := [4]byte{}
-	copy(endToEndId[:], bytes[16:20])
-	message := Message{
-		Version:       bytes[0],
-		Flags:         Flags(bytes[4]),
-		CommandCode:   CommandCode(readUInt24(bytes[5:8])),
-		ApplicationId: ApplicationId(binary.BigEndian.Uint32(bytes[8:12])),
-		HopByHopId:    hopByHopId,
-		EndToEndId:    endToEndId,
-		Avps:          readAvps(bytes[20:]),
+	message := &Message{Avps: NewAvps()}
+	if err := readMessage(bytes, message); err != nil {
+		return nil, err
 	}
-	return &message, nil
+	return message, nil
+}
+
+// readMessage reads a byte slice into the message, appending its AVPs to
+// those of the message.
+func readMessage(bytes []byte, message *Message) error {
+	if len(bytes) < 20 {
+		return ErrTruncated
+	}
+	avps, err := appendAvps(message.Avps, bytes[20:])
+	if err != nil {
+		return err
+	}
+	message.Version = bytes[0]
+	message.Flags = Flags(bytes[4])
+	message.CommandCode = CommandCode(readUInt24(bytes[5:8]))
+	message.ApplicationId = ApplicationId(binary.BigEndian.Uint32(bytes[8:12]))
+	copy(message.HopByHopId[:], bytes[12:16])
+	copy(message.EndToEndId[:], bytes[16:20])
+	message.Avps = avps
+	return nil
 }
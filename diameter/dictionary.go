@@ -0,0 +1,247 @@
+package diameter
+
+import (
+	"net"
+	"time"
+)
+
+// DataType represents the data format of a Diameter AVP value.
+type DataType byte
+
+const (
+	OctetString DataType = iota
+	Integer32
+	Integer64
+	Unsigned32
+	Unsigned64
+	Float32
+	Float64
+	Grouped
+	Address
+	Time
+	UTF8String
+	DiameterIdentity
+	DiameterURI
+	Enumerated
+)
+
+var dataTypeNames = map[DataType]string{
+	OctetString:      "OctetString",
+	Integer32:        "Integer32",
+	Integer64:        "Integer64",
+	Unsigned32:       "Unsigned32",
+	Unsigned64:       "Unsigned64",
+	Float32:          "Float32",
+	Float64:          "Float64",
+	Grouped:          "Grouped",
+	Address:          "Address",
+	Time:             "Time",
+	UTF8String:       "UTF8String",
+	DiameterIdentity: "DiameterIdentity",
+	DiameterURI:      "DiameterURI",
+	Enumerated:       "Enumerated",
+}
+
+// String returns the RFC 6733 name of the data type.
+func (t DataType) String() string {
+	if name, ok := dataTypeNames[t]; ok {
+		return name
+	}
+	return "Unknown"
+}
+
+// AvpDefinition describes an AVP in a Dictionary. Grammar describes the
+// children of a grouped AVP.
+type AvpDefinition struct {
+	Name     string
+	Code     Code
+	VendorId VendorId
+	Type     DataType
+	Values   map[uint32]string
+	Grammar  *Grammar
+}
+
+// avpKey identifies an AVP by code and vendor ID.
+type avpKey struct {
+	code     Code
+	vendorId VendorId
+}
+
+// Dictionary maps AVP codes and command codes to names and data types.
+type Dictionary struct {
+	avps     map[avpKey]AvpDefinition
+	names    map[string]AvpDefinition
+	commands map[CommandCode]string
+	ccfs     map[ccfKey]CCF
+	codecs   map[avpKey]Codec
+}
+
+// ccfKey identifies a CCF by command code and request or answer.
+type ccfKey struct {
+	commandCode CommandCode
+	request     bool
+}
+
+// NewDictionary creates a new empty Dictionary.
+func NewDictionary() *Dictionary {
+	return &Dictionary{
+		avps:     make(map[avpKey]AvpDefinition),
+		names:    make(map[string]AvpDefinition),
+		commands: make(map[CommandCode]string),
+		ccfs:     make(map[ccfKey]CCF),
+		codecs:   make(map[avpKey]Codec),
+	}
+}
+
+// AddAvp adds AVP definitions to the dictionary.
+func (d *Dictionary) AddAvp(definitions ...AvpDefinition) *Dictionary {
+	for _, definition := range definitions {
+		d.avps[avpKey{definition.Code, definition.VendorId}] = definition
+		d.names[definition.Name] = definition
+	}
+	return d
+}
+
+// AddCommand adds a command name to the dictionary, e.g. "Credit-Control".
+func (d *Dictionary) AddCommand(commandCode CommandCode, name string) *Dictionary {
+	d.commands[commandCode] = name
+	return d
+}
+
+// Avp retrieves the definition of the AVP with the given code and vendor ID.
+func (d *Dictionary) Avp(code Code, vendorId VendorId) *AvpDefinition {
+	if d == nil {
+		return nil
+	}
+	definition, ok := d.avps[avpKey{code, vendorId}]
+	if !ok {
+		return nil
+	}
+	return &definition
+}
+
+// AvpByName retrieves the definition of the AVP with the given name.
+func (d *Dictionary) AvpByName(name string) *AvpDefinition {
+	if d == nil {
+		return nil
+	}
+	definition, ok := d.names[name]
+	if !ok {
+		return nil
+	}
+	return &definition
+}
+
+// Command retrieves the name of the command with the given code.
+func (d *Dictionary) Command(commandCode CommandCode) *string {
+	if d == nil {
+		return nil
+	}
+	name, ok := d.commands[commandCode]
+	if !ok {
+		return nil
+	}
+	return &name
+}
+
+// AddCCF adds command grammars to the dictionary.
+func (d *Dictionary) AddCCF(ccfs ...CCF) *Dictionary {
+	for _, ccf := range ccfs {
+		d.ccfs[ccfKey{ccf.CommandCode, ccf.Request}] = ccf
+	}
+	return d
+}
+
+// CCF retrieves the grammar of a request or answer, with the grammars of
+// grouped AVPs filled in from their definitions where a rule has none.
+func (d *Dictionary) CCF(commandCode CommandCode, request bool) *CCF {
+	if d == nil {
+		return nil
+	}
+	ccf, ok := d.ccfs[ccfKey{commandCode, request}]
+	if !ok {
+		return nil
+	}
+	ccf.Grammar = d.resolveGrammar(ccf.Grammar, 0)
+	return &ccf
+}
+
+// resolveGrammar copies the grammar, filling in group grammars from AVP
+// definitions down to DefaultMaxDepth levels, which also ends recursive ones.
+func (d *Dictionary) resolveGrammar(grammar Grammar, depth int) Grammar {
+	resolve := func(rules []Rule) []Rule {
+		resolved := make([]Rule, len(rules))
+		for i, rule := range rules {
+			if rule.Group == nil && depth < DefaultMaxDepth {
+				if definition := d.Avp(rule.Code, rule.VendorId); definition != nil && definition.Grammar != nil {
+					rule.Group = definition.Grammar
+				}
+			}
+			if rule.Group != nil && depth < DefaultMaxDepth {
+				group := d.resolveGrammar(*rule.Group, depth+1)
+				rule.Group = &group
+			}
+			resolved[i] = rule
+		}
+		return resolved
+	}
+	grammar.Fixed = resolve(grammar.Fixed)
+	grammar.Required = resolve(grammar.Required)
+	grammar.Optional = resolve(grammar.Optional)
+	return grammar
+}
+
+// TypedValue decodes the AVP with the codec the dictionary holds for it, or
+// else as the Go value of the data type the dictionary gives it: an int32,
+// int64, uint32, uint64, float32 or float64 for numbers, the name of an
+// enumerated value or a uint32 when it has no name, a net.IP, a time.Time, a
+// string for text, Avps for a grouped AVP, and the data as []byte for octet
+// strings, AVPs the dictionary does not know and malformed values.
+func (a *Avp) TypedValue(dictionary *Dictionary) any {
+	if a == nil {
+		return nil
+	}
+	if value, ok := dictionary.Codec(a.Code, a.VendorId).decode(a.Data); ok {
+		return value
+	}
+	definition := dictionary.Avp(a.Code, a.VendorId)
+	if definition == nil {
+		return []byte(a.Data)
+	}
+	var value any
+	ok := false
+	switch definition.Type {
+	case Integer32:
+		value, ok = As[int32](a)
+	case Integer64:
+		value, ok = As[int64](a)
+	case Unsigned32:
+		value, ok = As[uint32](a)
+	case Unsigned64:
+		value, ok = As[uint64](a)
+	case Float32:
+		value, ok = As[float32](a)
+	case Float64:
+		value, ok = As[float64](a)
+	case Enumerated:
+		var number uint32
+		if number, ok = a.ToUint32Ok(); ok {
+			value = number
+			if name, named := definition.Values[number]; named {
+				value = name
+			}
+		}
+	case Address:
+		value, ok = As[net.IP](a)
+	case Time:
+		value, ok = As[time.Time](a)
+	case UTF8String, DiameterIdentity, DiameterURI:
+		value, ok = string(a.Data), true
+	case Grouped:
+		value, ok = As[Avps](a)
+	}
+	if !ok {
+		return []byte(a.Data)
+	}
+	return value
+}
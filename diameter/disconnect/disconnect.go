@@ -0,0 +1,139 @@
+package disconnect
+
+import (
+	"context"
+	"errors"
+
+	"github.com/tinybluerobots/radius-diameter-message/diameter"
+)
+
+const mandatoryFlags = diameter.FlagMandatory
+
+// Cause represents the value of a Disconnect-Cause AVP.
+type Cause uint32
+
+const (
+	CauseRebooting            Cause = 0
+	CauseBusy                 Cause = 1
+	CauseDoNotWantToTalkToYou Cause = 2
+)
+
+// String returns the RFC 6733 name of the cause.
+func (c Cause) String() string {
+	switch c {
+	case CauseRebooting:
+		return "REBOOTING"
+	case CauseBusy:
+		return "BUSY"
+	case CauseDoNotWantToTalkToYou:
+		return "DO_NOT_WANT_TO_TALK_TO_YOU"
+	}
+	return "UNKNOWN"
+}
+
+// DPR represents a Disconnect-Peer-Request.
+type DPR struct {
+	OriginHost      string
+	OriginRealm     string
+	DisconnectCause Cause
+}
+
+// ToMessage converts the DPR to a Diameter message.
+func (d DPR) ToMessage(hopByHopId [4]byte, endToEndId [4]byte) diameter.Message {
+	avps := diameter.NewAvps()
+	avps = avps.AddString(diameter.AvpOriginHost, mandatoryFlags, 0, d.OriginHost)
+	avps = avps.AddString(diameter.AvpOriginRealm, mandatoryFlags, 0, d.OriginRealm)
+	avps = avps.AddUint32(diameter.AvpDisconnectCause, mandatoryFlags, 0, uint32(d.DisconnectCause))
+	return diameter.NewMessage(1, diameter.FlagRequest, diameter.CommandDisconnectPeer, diameter.ApplicationCommon, hopByHopId, endToEndId, avps...)
+}
+
+// FromMessage reads a DPR from a Diameter message.
+func (d *DPR) FromMessage(message diameter.Message) error {
+	if !IsDPR(message) {
+		return errors.New("message is not a Disconnect-Peer-Request")
+	}
+	d.OriginHost = message.Avps.GetFirst(diameter.AvpOriginHost, 0).ToStringOrDefault()
+	d.OriginRealm = message.Avps.GetFirst(diameter.AvpOriginRealm, 0).ToStringOrDefault()
+	d.DisconnectCause = Cause(message.Avps.GetFirst(diameter.AvpDisconnectCause, 0).ToUint32OrDefault())
+	return nil
+}
+
+// DPA represents a Disconnect-Peer-Answer.
+type DPA struct {
+	ResultCode   diameter.ResultCode
+	OriginHost   string
+	OriginRealm  string
+	ErrorMessage string
+}
+
+// ToMessage converts the DPA to a Diameter message answering the request.
+func (d DPA) ToMessage(request diameter.Message) diameter.Message {
+	avps := diameter.NewAvps()
+	avps = avps.AddUint32(diameter.AvpResultCode, mandatoryFlags, 0, uint32(d.ResultCode))
+	avps = avps.AddString(diameter.AvpOriginHost, mandatoryFlags, 0, d.OriginHost)
+	avps = avps.AddString(diameter.AvpOriginRealm, mandatoryFlags, 0, d.OriginRealm)
+	if d.ErrorMessage != "" {
+		avps = avps.AddString(diameter.AvpErrorMessage, 0, 0, d.ErrorMessage)
+	}
+	return request.NewAnswer(avps...)
+}
+
+// FromMessage reads a DPA from a Diameter message.
+func (d *DPA) FromMessage(message diameter.Message) error {
+	if !IsDPA(message) {
+		return errors.New("message is not a Disconnect-Peer-Answer")
+	}
+	d.ResultCode = diameter.ResultCode(message.Avps.GetFirst(diameter.AvpResultCode, 0).ToUint32OrDefault())
+	d.OriginHost = message.Avps.GetFirst(diameter.AvpOriginHost, 0).ToStringOrDefault()
+	d.OriginRealm = message.Avps.GetFirst(diameter.AvpOriginRealm, 0).ToStringOrDefault()
+	d.ErrorMessage = message.Avps.GetFirst(diameter.AvpErrorMessage, 0).ToStringOrDefault()
+	return nil
+}
+
+// IsDPR reports whether the message is a Disconnect-Peer-Request.
+func IsDPR(message diameter.Message) bool {
+	return message.CommandCode == diameter.CommandDisconnectPeer && message.IsRequest()
+}
+
+// IsDPA reports whether the message is a Disconnect-Peer-Answer.
+func IsDPA(message diameter.Message) bool {
+	return message.CommandCode == diameter.CommandDisconnectPeer && !message.IsRequest()
+}
+
+// Transport is a connection to a peer.
+type Transport interface {
+	Send(message diameter.Message) error
+	Close() error
+}
+
+// Disconnect gracefully disconnects from a peer: it sends the DPR, waits for
+// the matching DPA on received, then closes the transport. The transport is
+// closed even if no DPA arrives before the context is done, in which case
+// the context's error is returned alongside the DPA, which is nil.
+func Disconnect(ctx context.Context, transport Transport, received <-chan diameter.Message, dpr DPR, ids *diameter.IdGenerator) (*DPA, error) {
+	if ids == nil {
+		ids = diameter.NewIdGenerator()
+	}
+	request := dpr.ToMessage(ids.NextHopByHopId(), ids.NextEndToEndId())
+	if err := transport.Send(request); err != nil {
+		return nil, errors.Join(err, transport.Close())
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, errors.Join(ctx.Err(), transport.Close())
+		case message, ok := <-received:
+			if !ok {
+				return nil, errors.Join(errors.New("connection closed before Disconnect-Peer-Answer"), transport.Close())
+			}
+			if !IsDPA(message) || message.HopByHopId != request.HopByHopId {
+				continue
+			}
+			dpa := &DPA{}
+			if err := dpa.FromMessage(message); err != nil {
+				return nil, errors.Join(err, transport.Close())
+			}
+			return dpa, transport.Close()
+		}
+	}
+}
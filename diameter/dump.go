@@ -0,0 +1,126 @@
+package diameter
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+// Dump returns the bytes of a Diameter message as hex, each field on its own
+// lines annotated with its name and decoded value, like the bytes pane of
+// Wireshark. The dictionary, which may be nil, names commands and decodes
+// AVPs. The bytes need not be a valid message: dumping stops at the first
+// malformed field, which is annotated with the problem.
+func Dump(bytes []byte, dictionary *Dictionary) string {
+	d := &dumper{bytes: bytes}
+	if len(bytes) < 20 {
+		d.field(len(bytes), "Malformed: truncated header")
+		return d.String()
+	}
+	flags := Flags(bytes[4])
+	commandCode := CommandCode(readUInt24(bytes[5:8]))
+	length := int(readUInt24(bytes[1:4]))
+	d.field(1, fmt.Sprintf("Version: %d", bytes[0]))
+	d.field(3, fmt.Sprintf("Length: %d", length))
+	d.field(1, fmt.Sprintf("Flags: 0x%02x %s", byte(flags), messageFlagsString(flags)))
+	d.field(3, fmt.Sprintf("Command Code: %d%s", commandCode, commandName(dictionary, commandCode, flags)))
+	d.field(4, fmt.Sprintf("ApplicationId: %d", binary.BigEndian.Uint32(bytes[8:12])))
+	d.field(4, fmt.Sprintf("Hop-by-Hop Identifier: 0x%x", bytes[12:16]))
+	d.field(4, fmt.Sprintf("End-to-End Identifier: 0x%x", bytes[16:20]))
+	end := len(bytes)
+	if length < 20 || length > len(bytes) {
+		d.note(fmt.Sprintf("Malformed: length %d does not match %d bytes", length, len(bytes)))
+	} else {
+		end = length
+	}
+	d.avps(dictionary, end, "")
+	if d.offset < len(bytes) {
+		d.field(len(bytes)-d.offset, "Trailing bytes")
+	}
+	return d.String()
+}
+
+// dumper writes annotated hex lines for consecutive fields of a message.
+type dumper struct {
+	bytes   []byte
+	offset  int
+	builder strings.Builder
+}
+
+// field writes the next length bytes, up to 16 a line, annotating the first
+// line.
+func (d *dumper) field(length int, annotation string) {
+	length = min(length, len(d.bytes)-d.offset)
+	for i := 0; i < length || i == 0; i += 16 {
+		chunk := d.bytes[d.offset+i : d.offset+min(i+16, length)]
+		hex := fmt.Sprintf("% x", chunk)
+		if i > 0 {
+			annotation = ""
+		}
+		fmt.Fprintf(&d.builder, "%04x  %-47s  %s\n", d.offset+i, hex, annotation)
+	}
+	d.offset += length
+}
+
+// note writes an annotation without bytes.
+func (d *dumper) note(annotation string) {
+	fmt.Fprintf(&d.builder, "%4s  %-47s  %s\n", "", "", annotation)
+}
+
+// String returns the lines written.
+func (d *dumper) String() string {
+	return d.builder.String()
+}
+
+// avps writes the AVPs up to the end offset, recursing into grouped AVPs the
+// dictionary knows, with the annotations indented.
+func (d *dumper) avps(dictionary *Dictionary, end int, indent string) {
+	for d.offset < end {
+		if end-d.offset < 8 {
+			d.field(end-d.offset, indent+"Malformed: truncated AVP header")
+			return
+		}
+		header := d.bytes[d.offset:]
+		code := Code(binary.BigEndian.Uint32(header[0:4]))
+		flags := Flags(header[4])
+		length := int(readUInt24(header[5:8]))
+		headerLength := 8
+		var vendorId VendorId
+		if flags.IsVendorSpecific() {
+			headerLength = 12
+			if end-d.offset < 12 {
+				d.field(end-d.offset, indent+"Malformed: truncated AVP header")
+				return
+			}
+			vendorId = VendorId(binary.BigEndian.Uint32(header[8:12]))
+		}
+		definition := dictionary.Avp(code, vendorId)
+		name := "Unknown"
+		if definition != nil {
+			name = definition.Name
+		}
+		d.field(4, fmt.Sprintf("%sAVP Code: %d (%s)", indent, code, name))
+		d.field(1, fmt.Sprintf("%sAVP Flags: 0x%02x %s", indent, byte(flags), avpFlagsString(flags)))
+		if length < headerLength || d.offset-5+length > end {
+			d.field(3, fmt.Sprintf("%sMalformed: AVP length %d", indent, length))
+			d.field(end-d.offset, indent+"Remaining bytes")
+			return
+		}
+		d.field(3, fmt.Sprintf("%sAVP Length: %d", indent, length))
+		if vendorId != 0 {
+			d.field(4, fmt.Sprintf("%sVendor-Id: %d (%s)", indent, vendorId, vendorName(vendorId)))
+		}
+		data := d.bytes[d.offset : d.offset+length-headerLength]
+		if definition != nil && definition.Type == Grouped {
+			d.note(indent + "Grouped AVPs:")
+			d.avps(dictionary, d.offset+len(data), indent+"  ")
+		} else if value, ok := formatValue(definition, data); ok {
+			d.field(len(data), fmt.Sprintf("%sValue: %s", indent, value))
+		} else if len(data) > 0 {
+			d.field(len(data), indent+"Data")
+		}
+		if padding := min((4-length%4)%4, end-d.offset); padding > 0 {
+			d.field(padding, indent+"Padding")
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package diameter
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Header represents the fixed 20 byte header of a Diameter message.
+type Header struct {
+	Version       byte
+	Length        uint32
+	Flags         Flags
+	CommandCode   CommandCode
+	ApplicationId ApplicationId
+	HopByHopId    [4]byte
+	EndToEndId    [4]byte
+}
+
+// HeaderError describes a header that breaks RFC 6733, with the result code
+// to answer it with.
+type HeaderError struct {
+	ResultCode ResultCode
+	Reason     string
+}
+
+// Error returns the reason for the error.
+func (e *HeaderError) Error() string {
+	return e.Reason
+}
+
+// AvpError describes an AVP that could not be decoded. Avp holds as much of
+// it as could be read, for reporting in a Failed-AVP.
+type AvpError struct {
+	ResultCode ResultCode
+	Offset     int
+	Avp        *Avp
+	Reason     string
+	// Err is ErrTruncated or an *InvalidLengthError.
+	Err error
+}
+
+// Error returns the reason for the error and the offset of the AVP.
+func (e *AvpError) Error() string {
+	return fmt.Sprintf("%s at offset %d", e.Reason, e.Offset)
+}
+
+// Unwrap returns the error the reason describes.
+func (e *AvpError) Unwrap() error {
+	return e.Err
+}
+
+// MessageError is returned by ReadMessageFrom when a complete message was
+// framed but could not be decoded, so it can still be answered from its header.
+type MessageError struct {
+	Header Header
+	Err    error
+}
+
+// Error returns the error decoding the message.
+func (e *MessageError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the error decoding the message.
+func (e *MessageError) Unwrap() error {
+	return e.Err
+}
+
+// ReadHeader reads the header of a message. The header is returned even when
+// it breaks RFC 6733, with a *HeaderError describing how, so that the
+// message can be answered.
+func ReadHeader(bytes []byte) (Header, error) {
+	if len(bytes) < 20 {
+		return Header{}, ErrTruncated
+	}
+	header := Header{
+		Version:       bytes[0],
+		Length:        readUInt24(bytes[1:4]),
+		Flags:         Flags(bytes[4]),
+		CommandCode:   CommandCode(readUInt24(bytes[5:8])),
+		ApplicationId: ApplicationId(binary.BigEndian.Uint32(bytes[8:12])),
+	}
+	copy(header.HopByHopId[:], bytes[12:16])
+	copy(header.EndToEndId[:], bytes[16:20])
+	switch {
+	case header.Version != 1:
+		return header, &HeaderError{ResultCode: ResultCodeUnsupportedVersion, Reason: fmt.Sprintf("unsupported version %d", header.Version)}
+	case header.Length < 20 || header.Length%4 != 0:
+		return header, &HeaderError{ResultCode: ResultCodeInvalidMessageLength, Reason: fmt.Sprintf("invalid message length %d", header.Length)}
+	case header.Flags&0x0f != 0:
+		return header, &HeaderError{ResultCode: ResultCodeInvalidHdrBits, Reason: "reserved header bits set"}
+	case header.Flags.IsRequest() && header.Flags.IsError():
+		return header, &HeaderError{ResultCode: ResultCodeInvalidHdrBits, Reason: "E bit set in request"}
+	}
+	return header, nil
+}
+
+// ErrorAnswer creates the answer to the request with the header reporting the
+// error, for requests that could not be decoded or validated. The Result-Code
+// and Failed-AVP come from a *HeaderError, *AvpError or Violation in the
+// error's chain, and is DIAMETER_UNABLE_TO_COMPLY for any other error. The E
+// bit is set for protocol errors. The caller adds Origin-Host and
+// Origin-Realm, and the Session-Id if it is known.
+func ErrorAnswer(header Header, err error) Message {
+	resultCode := ResultCodeUnableToComply
+	var failedAvp *Avp
+	var headerError *HeaderError
+	var avpError *AvpError
+	var violation Violation
+	switch {
+	case errors.As(err, &headerError):
+		resultCode = headerError.ResultCode
+	case errors.As(err, &avpError):
+		resultCode = avpError.ResultCode
+		if avpError.Avp != nil {
+			avp := NewAvpFailedAvp(*avpError.Avp)
+			failedAvp = &avp
+		}
+	case errors.As(err, &violation):
+		resultCode = violation.ResultCode
+		avp := violation.FailedAvp()
+		failedAvp = &avp
+	}
+	avps := NewAvps().AddUint32(AvpResultCode, FlagMandatory, 0, uint32(resultCode))
+	if err != nil {
+		avps = avps.AddString(AvpErrorMessage, 0, 0, err.Error())
+	}
+	if failedAvp != nil {
+		avps = append(avps, *failedAvp)
+	}
+	answer := NewMessage(1, header.Flags&FlagProxiable, header.CommandCode, header.ApplicationId, header.HopByHopId, header.EndToEndId, avps...)
+	answer.SetError(resultCode.IsProtocolError())
+	return answer
+}
@@ -0,0 +1,38 @@
+package diameter
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrTruncated is matched by errors reading a message or AVP whose bytes
+	// end before its header does.
+	ErrTruncated = errors.New("message truncated")
+	// ErrInvalidLength is matched by every *InvalidLengthError.
+	ErrInvalidLength = errors.New("invalid length")
+	// ErrUnknownMandatoryAvp is matched by every *UnknownMandatoryError.
+	ErrUnknownMandatoryAvp = errors.New("unknown mandatory AVP")
+)
+
+// InvalidLengthError describes the length field of a message or AVP that is
+// shorter than its header or longer than the bytes holding it.
+type InvalidLengthError struct {
+	// Offset is the offset of the message or AVP in the bytes read.
+	Offset int
+	// Declared is the value of the length field.
+	Declared int
+	// Available is the number of bytes from the offset to the end of those
+	// read, or of the enclosing grouped AVP.
+	Available int
+}
+
+// Error returns the length declared and the bytes available.
+func (e *InvalidLengthError) Error() string {
+	return fmt.Sprintf("invalid length %d at offset %d with %d bytes available", e.Declared, e.Offset, e.Available)
+}
+
+// Is reports whether the target is ErrInvalidLength.
+func (e *InvalidLengthError) Is(target error) bool {
+	return target == ErrInvalidLength
+}
@@ -0,0 +1,88 @@
+package diameter
+
+// PathElement identifies an AVP at one level of a Path.
+type PathElement struct {
+	Code     Code
+	VendorId VendorId
+}
+
+// Path identifies an AVP nested in grouped AVPs, outermost first.
+type Path []PathElement
+
+// NewPath creates a new Path from alternating codes and vendor IDs,
+// e.g. NewPath(873, 10415, 874, 10415, 30, 0).
+func NewPath(codesAndVendorIds ...uint32) Path {
+	path := make(Path, 0, len(codesAndVendorIds)/2)
+	for i := 0; i+1 < len(codesAndVendorIds); i += 2 {
+		path = append(path, PathElement{Code(codesAndVendorIds[i]), VendorId(codesAndVendorIds[i+1])})
+	}
+	return path
+}
+
+// Extractor scans the bytes of a Diameter message and returns the first AVP
+// found at each of its paths, or nil where a path is absent. The returned AVPs
+// alias the scanned bytes.
+type Extractor func(bytes []byte) ([]*Avp, error)
+
+// extractorNode is a node of the trie of paths compiled into an Extractor.
+type extractorNode struct {
+	indexes  []int
+	children map[PathElement]*extractorNode
+}
+
+// NewExtractor compiles the paths into an Extractor, which reads only the AVP
+// headers and grouped AVPs needed to reach them.
+func NewExtractor(paths ...Path) Extractor {
+	root := &extractorNode{children: make(map[PathElement]*extractorNode)}
+	for i, path := range paths {
+		node := root
+		for _, element := range path {
+			child, ok := node.children[element]
+			if !ok {
+				child = &extractorNode{children: make(map[PathElement]*extractorNode)}
+				node.children[element] = child
+			}
+			node = child
+		}
+		node.indexes = append(node.indexes, i)
+	}
+	return func(bytes []byte) ([]*Avp, error) {
+		if len(bytes) < 20 {
+			return nil, ErrTruncated
+		}
+		values := make([]*Avp, len(paths))
+		remaining := len(paths)
+		err := root.scan(bytes[20:], values, &remaining)
+		return values, err
+	}
+}
+
+// scan walks the AVPs in bytes, recording matches and descending into
+// grouped AVPs on a path, until every path has been found.
+func (n *extractorNode) scan(bytes []byte, values []*Avp, remaining *int) error {
+	offset := 0
+	for offset < len(bytes) && *remaining > 0 {
+		avp, next, err := readAvp(bytes, offset)
+		if err != nil {
+			return err
+		}
+		offset = next
+		child, ok := n.children[PathElement{avp.Code, avp.VendorId}]
+		if !ok {
+			continue
+		}
+		for _, index := range child.indexes {
+			if values[index] == nil {
+				value := avp
+				values[index] = &value
+				*remaining--
+			}
+		}
+		if len(child.children) > 0 {
+			if err := child.scan(avp.Data, values, remaining); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package diameter
+
+// NewAvpFailedAvp creates a new Failed-AVP grouped AVP containing the offending AVPs.
+func NewAvpFailedAvp(avps ...Avp) Avp {
+	return NewAvpGroup(AvpFailedAvp, FlagMandatory, 0, avps...)
+}
+
+// AddFailedAvp adds a new Failed-AVP grouped AVP containing the offending AVPs to the slice.
+func (a Avps) AddFailedAvp(avps ...Avp) Avps {
+	return append(a, NewAvpFailedAvp(avps...))
+}
+
+// NewAvpMissing creates an AVP standing in for a missing AVP in a Failed-AVP,
+// with the code and vendor ID of the missing AVP and a zero filled data field
+// of the given minimum length.
+func NewAvpMissing(code Code, vendorId VendorId, length int) Avp {
+	return NewAvp(code, 0, vendorId, make([]byte, length))
+}
+
+// FailedAvp creates the Failed-AVP reporting the violation. An AVP nested in
+// grouped AVPs is reported wrapped in those groups, as RFC 6733 recommends.
+func (v Violation) FailedAvp() Avp {
+	avp := v.Avp
+	for i := len(v.Parents) - 1; i >= 0; i-- {
+		parent := v.Parents[i]
+		avp = NewAvpGroup(parent.Code, parent.Flags, parent.VendorId, avp)
+	}
+	return NewAvpFailedAvp(avp)
+}
@@ -0,0 +1,106 @@
+package features
+
+import (
+	"math/bits"
+
+	"github.com/tinybluerobots/radius-diameter-message/diameter"
+)
+
+// Supported-Features AVP codes (3GPP TS 29.229), all with the 3GPP vendor ID.
+const (
+	AvpSupportedFeatures diameter.Code = 628
+	AvpFeatureListId     diameter.Code = 629
+	AvpFeatureList       diameter.Code = 630
+)
+
+// FeatureList represents the value of a Feature-List AVP, a bitmask of features.
+type FeatureList uint32
+
+// Has reports whether every feature in features is in the list.
+func (f FeatureList) Has(features FeatureList) bool {
+	return f&features == features
+}
+
+// Names returns the names of the features in the list in bit order, using
+// the names of an interface such as GxFeatureNames. Unnamed features are omitted.
+func (f FeatureList) Names(names map[FeatureList]string) []string {
+	var featureNames []string
+	for remaining := uint32(f); remaining != 0; remaining &= remaining - 1 {
+		feature := FeatureList(1) << bits.TrailingZeros32(remaining)
+		if name, ok := names[feature]; ok {
+			featureNames = append(featureNames, name)
+		}
+	}
+	return featureNames
+}
+
+// SupportedFeatures represents a Supported-Features AVP.
+type SupportedFeatures struct {
+	VendorId      diameter.VendorId
+	FeatureListId uint32
+	FeatureList   FeatureList
+}
+
+// ToAvp converts the Supported-Features to a grouped AVP.
+func (s SupportedFeatures) ToAvp() diameter.Avp {
+	avps := diameter.NewAvps()
+	avps = avps.AddUint32(diameter.AvpVendorId, diameter.FlagMandatory, 0, uint32(s.VendorId))
+	avps = avps.AddUint32(AvpFeatureListId, 0, diameter.Vendor3GPP, s.FeatureListId)
+	avps = avps.AddUint32(AvpFeatureList, 0, diameter.Vendor3GPP, uint32(s.FeatureList))
+	return diameter.NewAvpGroup(AvpSupportedFeatures, 0, diameter.Vendor3GPP, avps...)
+}
+
+// FromAvp reads the Supported-Features from a grouped AVP.
+func (s *SupportedFeatures) FromAvp(avp *diameter.Avp) {
+	group := avp.ToGroup()
+	s.VendorId = diameter.VendorId(group.GetFirst(diameter.AvpVendorId, 0).ToUint32OrDefault())
+	s.FeatureListId = group.GetFirst(AvpFeatureListId, diameter.Vendor3GPP).ToUint32OrDefault()
+	s.FeatureList = FeatureList(group.GetFirst(AvpFeatureList, diameter.Vendor3GPP).ToUint32OrDefault())
+}
+
+// Add adds a Supported-Features AVP for each of the supported features.
+func Add(avps diameter.Avps, supportedFeatures ...SupportedFeatures) diameter.Avps {
+	for _, features := range supportedFeatures {
+		avps = append(avps, features.ToAvp())
+	}
+	return avps
+}
+
+// Read returns every Supported-Features AVP.
+func Read(avps diameter.Avps) []SupportedFeatures {
+	var supportedFeatures []SupportedFeatures
+	for _, avp := range avps.Get(AvpSupportedFeatures, diameter.Vendor3GPP) {
+		features := SupportedFeatures{}
+		features.FromAvp(&avp)
+		supportedFeatures = append(supportedFeatures, features)
+	}
+	return supportedFeatures
+}
+
+// Intersect computes the negotiated features: for each local feature list
+// the remote peer also sent, the features both support. Local feature lists
+// the remote peer did not send are omitted.
+func Intersect(local []SupportedFeatures, remote []SupportedFeatures) []SupportedFeatures {
+	var negotiated []SupportedFeatures
+	for _, localFeatures := range local {
+		for _, remoteFeatures := range remote {
+			if localFeatures.VendorId == remoteFeatures.VendorId && localFeatures.FeatureListId == remoteFeatures.FeatureListId {
+				localFeatures.FeatureList &= remoteFeatures.FeatureList
+				negotiated = append(negotiated, localFeatures)
+				break
+			}
+		}
+	}
+	return negotiated
+}
+
+// Find returns the feature list with the vendor ID and feature list ID, or
+// an empty list if there is none.
+func Find(supportedFeatures []SupportedFeatures, vendorId diameter.VendorId, featureListId uint32) FeatureList {
+	for _, features := range supportedFeatures {
+		if features.VendorId == vendorId && features.FeatureListId == featureListId {
+			return features.FeatureList
+		}
+	}
+	return 0
+}
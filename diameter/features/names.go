@@ -0,0 +1,71 @@
+package features
+
+// Gx features of Feature-List-ID 1 (3GPP TS 29.212).
+const (
+	GxRel8             FeatureList = 1 << 0
+	GxRel9             FeatureList = 1 << 1
+	GxProvAFsignalFlow FeatureList = 1 << 2
+	GxRel10            FeatureList = 1 << 3
+)
+
+// GxFeatureNames are the names of the Gx features.
+var GxFeatureNames = map[FeatureList]string{
+	GxRel8:             "Rel8",
+	GxRel9:             "Rel9",
+	GxProvAFsignalFlow: "ProvAFsignalFlow",
+	GxRel10:            "Rel10",
+}
+
+// Rx features of Feature-List-ID 1 (3GPP TS 29.214).
+const (
+	RxRel8                  FeatureList = 1 << 0
+	RxRel9                  FeatureList = 1 << 1
+	RxProvAFsignalFlow      FeatureList = 1 << 2
+	RxSponsoredConnectivity FeatureList = 1 << 3
+	RxRel10                 FeatureList = 1 << 4
+)
+
+// RxFeatureNames are the names of the Rx features.
+var RxFeatureNames = map[FeatureList]string{
+	RxRel8:                  "Rel8",
+	RxRel9:                  "Rel9",
+	RxProvAFsignalFlow:      "ProvAFsignalFlow",
+	RxSponsoredConnectivity: "SponsoredConnectivity",
+	RxRel10:                 "Rel10",
+}
+
+// S6a features of Feature-List-ID 1 (3GPP TS 29.272).
+const (
+	S6aODBAllAPN                         FeatureList = 1 << 0
+	S6aODBHPLMNAPN                       FeatureList = 1 << 1
+	S6aODBVPLMNAPN                       FeatureList = 1 << 2
+	S6aODBAllOG                          FeatureList = 1 << 3
+	S6aODBAllInternationalOG             FeatureList = 1 << 4
+	S6aRegSub                            FeatureList = 1 << 9
+	S6aTrace                             FeatureList = 1 << 10
+	S6aSMMOPP                            FeatureList = 1 << 21
+	S6aUEReachabilityNotification        FeatureList = 1 << 26
+	S6aTADSDataRetrieval                 FeatureList = 1 << 27
+	S6aStateLocationInformationRetrieval FeatureList = 1 << 28
+	S6aPartialPurge                      FeatureList = 1 << 29
+	S6aLocalTimeZoneRetrieval            FeatureList = 1 << 30
+	S6aAdditionalMSISDN                  FeatureList = 1 << 31
+)
+
+// S6aFeatureNames are the names of the S6a features.
+var S6aFeatureNames = map[FeatureList]string{
+	S6aODBAllAPN:                         "ODB-all-APN",
+	S6aODBHPLMNAPN:                       "ODB-HPLMN-APN",
+	S6aODBVPLMNAPN:                       "ODB-VPLMN-APN",
+	S6aODBAllOG:                          "ODB-all-OG",
+	S6aODBAllInternationalOG:             "ODB-all-InternationalOG",
+	S6aRegSub:                            "RegSub",
+	S6aTrace:                             "Trace",
+	S6aSMMOPP:                            "SM-MO-PP",
+	S6aUEReachabilityNotification:        "UE-Reachability-Notification",
+	S6aTADSDataRetrieval:                 "T-ADS Data Retrieval",
+	S6aStateLocationInformationRetrieval: "State/Location-Information-Retrieval",
+	S6aPartialPurge:                      "Partial Purge",
+	S6aLocalTimeZoneRetrieval:            "Local Time Zone Retrieval",
+	S6aAdditionalMSISDN:                  "Additional MSISDN",
+}
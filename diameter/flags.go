@@ -0,0 +1,98 @@
+package diameter
+
+// Message header flags.
+const (
+	FlagRequest       Flags = 0x80
+	FlagProxiable     Flags = 0x40
+	FlagError         Flags = 0x20
+	FlagRetransmitted Flags = 0x10
+)
+
+// IsRequest reports whether the R bit is set.
+func (f Flags) IsRequest() bool {
+	return f&FlagRequest != 0
+}
+
+// IsProxiable reports whether the P bit is set.
+func (f Flags) IsProxiable() bool {
+	return f&FlagProxiable != 0
+}
+
+// IsError reports whether the E bit is set.
+func (f Flags) IsError() bool {
+	return f&FlagError != 0
+}
+
+// IsRetransmitted reports whether the T bit is set.
+func (f Flags) IsRetransmitted() bool {
+	return f&FlagRetransmitted != 0
+}
+
+// SetRequest sets or clears the R bit.
+func (f *Flags) SetRequest(value bool) {
+	f.set(FlagRequest, value)
+}
+
+// SetProxiable sets or clears the P bit.
+func (f *Flags) SetProxiable(value bool) {
+	f.set(FlagProxiable, value)
+}
+
+// SetError sets or clears the E bit.
+func (f *Flags) SetError(value bool) {
+	f.set(FlagError, value)
+}
+
+// SetRetransmitted sets or clears the T bit.
+func (f *Flags) SetRetransmitted(value bool) {
+	f.set(FlagRetransmitted, value)
+}
+
+// set sets or clears the given bits.
+func (f *Flags) set(bits Flags, value bool) {
+	if value {
+		*f |= bits
+	} else {
+		*f &^= bits
+	}
+}
+
+// IsRequest reports whether the message is a request.
+func (m Message) IsRequest() bool {
+	return m.Flags.IsRequest()
+}
+
+// IsProxiable reports whether the message may be proxied, relayed or redirected.
+func (m Message) IsProxiable() bool {
+	return m.Flags.IsProxiable()
+}
+
+// IsError reports whether the message is a protocol error answer.
+func (m Message) IsError() bool {
+	return m.Flags.IsError()
+}
+
+// IsRetransmitted reports whether the message is a potentially retransmitted request.
+func (m Message) IsRetransmitted() bool {
+	return m.Flags.IsRetransmitted()
+}
+
+// SetRequest marks the message as a request or an answer.
+func (m *Message) SetRequest(value bool) {
+	m.Flags.SetRequest(value)
+}
+
+// SetProxiable marks the message as proxiable or not.
+func (m *Message) SetProxiable(value bool) {
+	m.Flags.SetProxiable(value)
+}
+
+// SetError marks the message as a protocol error answer or not.
+func (m *Message) SetError(value bool) {
+	m.Flags.SetError(value)
+}
+
+// SetRetransmitted marks the message as a potentially retransmitted request or not.
+func (m *Message) SetRetransmitted(value bool) {
+	m.Flags.SetRetransmitted(value)
+}
@@ -0,0 +1,159 @@
+package diameter
+
+import (
+	"fmt"
+	"net"
+	"time"
+)
+
+// Value is the set of types an AVP can be converted to with As and Get.
+// Integer32 and Integer64 AVPs convert to int32 and int64, and grouped AVPs
+// to Avps.
+type Value interface {
+	string | []byte | uint32 | uint64 | int32 | int64 | float32 | float64 | net.IP | time.Time | Avps
+}
+
+// As converts the AVP to the type parameter, reporting whether the AVP is
+// present and well formed for it, as the ToXxxOk method of the type does.
+func As[T Value](avp *Avp) (T, bool) {
+	var value T
+	var ok bool
+	switch target := any(&value).(type) {
+	case *string:
+		*target, ok = avp.ToStringOk()
+	case *[]byte:
+		*target, ok = avp.ToData(), avp != nil && avp.Data != nil
+	case *uint32:
+		*target, ok = avp.ToUint32Ok()
+	case *uint64:
+		*target, ok = avp.ToUint64Ok()
+	case *int32:
+		var unsigned uint32
+		unsigned, ok = avp.ToUint32Ok()
+		*target = int32(unsigned)
+	case *int64:
+		var unsigned uint64
+		unsigned, ok = avp.ToUint64Ok()
+		*target = int64(unsigned)
+	case *float32:
+		*target, ok = avp.ToFloat32Ok()
+	case *float64:
+		*target, ok = avp.ToFloat64Ok()
+	case *net.IP:
+		*target, ok = avp.ToNetIPOk()
+	case *time.Time:
+		*target, ok = avp.ToTimeOk()
+	case *Avps:
+		*target, ok = avp.ToGroup(), avp != nil && avp.Data != nil
+	}
+	return value, ok
+}
+
+// Get converts the first AVP with the given code and vendor ID to the type
+// parameter, reporting whether it is present and well formed, as in
+// diameter.Get[uint32](avps, AvpResultCode, 0).
+func Get[T Value](avps Avps, code Code, vendorId VendorId) (T, bool) {
+	return As[T](avps.GetFirst(code, vendorId))
+}
+
+// GetAll converts every AVP with the given code and vendor ID to the type
+// parameter, skipping those malformed for it.
+func GetAll[T Value](avps Avps, code Code, vendorId VendorId) []T {
+	var values []T
+	for i := range avps {
+		if avps[i].Code != code || avps[i].VendorId != vendorId {
+			continue
+		}
+		if value, ok := As[T](&avps[i]); ok {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// ToStrings converts every AVP with the given code and vendor ID to a
+// string, such as the Route-Record AVPs of a request.
+func (a Avps) ToStrings(code Code, vendorId VendorId) []string {
+	return GetAll[string](a, code, vendorId)
+}
+
+// ToUint32s converts every well formed AVP with the given code and vendor ID
+// to a uint32.
+func (a Avps) ToUint32s(code Code, vendorId VendorId) []uint32 {
+	return GetAll[uint32](a, code, vendorId)
+}
+
+// ToUint64s converts every well formed AVP with the given code and vendor ID
+// to a uint64.
+func (a Avps) ToUint64s(code Code, vendorId VendorId) []uint64 {
+	return GetAll[uint64](a, code, vendorId)
+}
+
+// ToNetIPs converts every well formed AVP with the given code and vendor ID
+// to a net.IP, such as the Host-IP-Address AVPs of a CER.
+func (a Avps) ToNetIPs(code Code, vendorId VendorId) []net.IP {
+	return GetAll[net.IP](a, code, vendorId)
+}
+
+// ToTimes converts every well formed AVP with the given code and vendor ID
+// to a time.Time.
+func (a Avps) ToTimes(code Code, vendorId VendorId) []time.Time {
+	return GetAll[time.Time](a, code, vendorId)
+}
+
+// ToGroups converts every AVP with the given code and vendor ID to a grouped
+// AVP, such as the Subscription-Id AVPs of a CCR.
+func (a Avps) ToGroups(code Code, vendorId VendorId) []Avps {
+	return GetAll[Avps](a, code, vendorId)
+}
+
+// Must converts the first AVP with the given code and vendor ID to the type
+// parameter, panicking with the code, vendor ID and data length if it is
+// missing or malformed. It suits tests and scripts.
+func Must[T Value](avps Avps, code Code, vendorId VendorId) T {
+	avp := avps.GetFirst(code, vendorId)
+	if avp == nil {
+		panic(fmt.Sprintf("diameter: AVP %d (vendor %d) is missing", code, vendorId))
+	}
+	value, ok := As[T](avp)
+	if !ok {
+		panic(fmt.Sprintf("diameter: AVP %d (vendor %d) of %d bytes is not a valid %T", code, vendorId, len(avp.Data), value))
+	}
+	return value
+}
+
+// MustString converts the first AVP with the given code and vendor ID to a
+// string, panicking if it is missing.
+func (a Avps) MustString(code Code, vendorId VendorId) string {
+	return Must[string](a, code, vendorId)
+}
+
+// MustUint32 converts the first AVP with the given code and vendor ID to a
+// uint32, panicking if it is missing or malformed.
+func (a Avps) MustUint32(code Code, vendorId VendorId) uint32 {
+	return Must[uint32](a, code, vendorId)
+}
+
+// MustUint64 converts the first AVP with the given code and vendor ID to a
+// uint64, panicking if it is missing or malformed.
+func (a Avps) MustUint64(code Code, vendorId VendorId) uint64 {
+	return Must[uint64](a, code, vendorId)
+}
+
+// MustNetIP converts the first AVP with the given code and vendor ID to a
+// net.IP, panicking if it is missing or malformed.
+func (a Avps) MustNetIP(code Code, vendorId VendorId) net.IP {
+	return Must[net.IP](a, code, vendorId)
+}
+
+// MustTime converts the first AVP with the given code and vendor ID to a
+// time.Time, panicking if it is missing or malformed.
+func (a Avps) MustTime(code Code, vendorId VendorId) time.Time {
+	return Must[time.Time](a, code, vendorId)
+}
+
+// MustGroup converts the first AVP with the given code and vendor ID to a
+// grouped AVP, panicking if it is missing.
+func (a Avps) MustGroup(code Code, vendorId VendorId) Avps {
+	return Must[Avps](a, code, vendorId)
+}
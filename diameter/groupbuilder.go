@@ -0,0 +1,135 @@
+package diameter
+
+import (
+	"errors"
+	"net"
+	"time"
+)
+
+// GroupBuilder assembles a grouped AVP fluently, optionally validating its
+// children against the grammar of the group.
+type GroupBuilder struct {
+	code       Code
+	flags      Flags
+	vendorId   VendorId
+	entries    []groupEntry
+	grammar    *Grammar
+	dictionary *Dictionary
+}
+
+// groupEntry is a child of a GroupBuilder, either an AVP or a nested builder.
+type groupEntry struct {
+	avp   Avp
+	group *GroupBuilder
+}
+
+// NewGroupBuilder creates a new GroupBuilder for a grouped AVP with the given code, flags and vendor ID.
+func NewGroupBuilder(code Code, flags Flags, vendorId VendorId) *GroupBuilder {
+	return &GroupBuilder{
+		code:     code,
+		flags:    flags,
+		vendorId: vendorId,
+	}
+}
+
+// WithGrammar sets the grammar the children are validated against when built.
+func (b *GroupBuilder) WithGrammar(grammar Grammar) *GroupBuilder {
+	b.grammar = &grammar
+	return b
+}
+
+// WithDictionary validates the children against the grammar registered for
+// the group in the dictionary, and passes the dictionary on to nested builders.
+func (b *GroupBuilder) WithDictionary(dictionary *Dictionary) *GroupBuilder {
+	b.dictionary = dictionary
+	if definition := dictionary.Avp(b.code, b.vendorId); definition != nil && definition.Grammar != nil && b.grammar == nil {
+		b.grammar = definition.Grammar
+	}
+	return b
+}
+
+// Add adds a new AVP to the group.
+func (b *GroupBuilder) Add(code Code, flags Flags, vendorId VendorId, data avpData) *GroupBuilder {
+	return b.add(NewAvp(code, flags, vendorId, data))
+}
+
+// AddAvps adds multiple AVPs to the group.
+func (b *GroupBuilder) AddAvps(avps ...Avp) *GroupBuilder {
+	return b.add(avps...)
+}
+
+// AddString adds a new AVP with a string value to the group.
+func (b *GroupBuilder) AddString(code Code, flags Flags, vendorId VendorId, value string) *GroupBuilder {
+	return b.add(NewAvpString(code, flags, vendorId, value))
+}
+
+// AddUint32 adds a new AVP with a uint32 value to the group.
+func (b *GroupBuilder) AddUint32(code Code, flags Flags, vendorId VendorId, value uint32) *GroupBuilder {
+	return b.add(NewAvpUint32(code, flags, vendorId, value))
+}
+
+// AddUint64 adds a new AVP with a uint64 value to the group.
+func (b *GroupBuilder) AddUint64(code Code, flags Flags, vendorId VendorId, value uint64) *GroupBuilder {
+	return b.add(NewAvpUint64(code, flags, vendorId, value))
+}
+
+// AddFloat32 adds a new AVP with a float32 value to the group.
+func (b *GroupBuilder) AddFloat32(code Code, flags Flags, vendorId VendorId, value float32) *GroupBuilder {
+	return b.add(NewAvpFloat32(code, flags, vendorId, value))
+}
+
+// AddFloat64 adds a new AVP with a float64 value to the group.
+func (b *GroupBuilder) AddFloat64(code Code, flags Flags, vendorId VendorId, value float64) *GroupBuilder {
+	return b.add(NewAvpFloat64(code, flags, vendorId, value))
+}
+
+// AddNetIP adds a new AVP with a net.IP value to the group.
+func (b *GroupBuilder) AddNetIP(code Code, flags Flags, vendorId VendorId, value net.IP) *GroupBuilder {
+	return b.add(NewAvpNetIP(code, flags, vendorId, value))
+}
+
+// AddTime adds a new AVP with a time.Time value to the group.
+func (b *GroupBuilder) AddTime(code Code, flags Flags, vendorId VendorId, value time.Time) *GroupBuilder {
+	return b.add(NewAvpTime(code, flags, vendorId, value))
+}
+
+// AddGroup adds a nested grouped AVP built by another GroupBuilder to the group.
+func (b *GroupBuilder) AddGroup(child *GroupBuilder) *GroupBuilder {
+	if child.dictionary == nil && b.dictionary != nil {
+		child.WithDictionary(b.dictionary)
+	}
+	b.entries = append(b.entries, groupEntry{group: child})
+	return b
+}
+
+// add adds AVPs to the group.
+func (b *GroupBuilder) add(avps ...Avp) *GroupBuilder {
+	for _, avp := range avps {
+		b.entries = append(b.entries, groupEntry{avp: avp})
+	}
+	return b
+}
+
+// Build builds the grouped AVP, returning the violations of any grammar as a joined error.
+func (b *GroupBuilder) Build() (Avp, error) {
+	avps := make(Avps, 0, len(b.entries))
+	errs := make([]error, 0)
+	for _, entry := range b.entries {
+		avp := entry.avp
+		if entry.group != nil {
+			built, err := entry.group.Build()
+			if err != nil {
+				errs = append(errs, err)
+			}
+			avp = built
+		}
+		avps = append(avps, avp)
+	}
+	group := NewAvpGroup(b.code, b.flags, b.vendorId, avps...)
+	if b.grammar != nil {
+		for _, violation := range b.grammar.validate(avps, Avps{group}) {
+			errs = append(errs, violation)
+		}
+	}
+	return group, errors.Join(errs...)
+}
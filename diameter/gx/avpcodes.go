@@ -0,0 +1,36 @@
+package gx
+
+import "github.com/tinybluerobots/radius-diameter-message/diameter"
+
+// Gx AVP codes (3GPP TS 29.212 and TS 29.214), all with the 3GPP vendor ID.
+const (
+	AvpFlowDescription             diameter.Code = 507
+	AvpMaxRequestedBandwidthDL     diameter.Code = 515
+	AvpMaxRequestedBandwidthUL     diameter.Code = 516
+	AvpChargingRuleInstall         diameter.Code = 1001
+	AvpChargingRuleRemove          diameter.Code = 1002
+	AvpChargingRuleDefinition      diameter.Code = 1003
+	AvpChargingRuleBaseName        diameter.Code = 1004
+	AvpChargingRuleName            diameter.Code = 1005
+	AvpEventTrigger                diameter.Code = 1006
+	AvpPrecedence                  diameter.Code = 1010
+	AvpQoSInformation              diameter.Code = 1016
+	AvpGuaranteedBitrateDL         diameter.Code = 1025
+	AvpGuaranteedBitrateUL         diameter.Code = 1026
+	AvpQoSClassIdentifier          diameter.Code = 1028
+	AvpAllocationRetentionPriority diameter.Code = 1034
+	AvpAPNAggregateMaxBitrateDL    diameter.Code = 1040
+	AvpAPNAggregateMaxBitrateUL    diameter.Code = 1041
+	AvpPriorityLevel               diameter.Code = 1046
+	AvpPreEmptionCapability        diameter.Code = 1047
+	AvpPreEmptionVulnerability     diameter.Code = 1048
+	AvpFlowInformation             diameter.Code = 1058
+	AvpMonitoringKey               diameter.Code = 1066
+	AvpFlowDirection               diameter.Code = 1080
+)
+
+// Credit-Control AVP codes used in Charging-Rule-Definition (RFC 4006), with no vendor ID.
+const (
+	AvpRatingGroup       diameter.Code = 432
+	AvpServiceIdentifier diameter.Code = 439
+)
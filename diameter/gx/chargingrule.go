@@ -0,0 +1,190 @@
+package gx
+
+import "github.com/tinybluerobots/radius-diameter-message/diameter"
+
+const mandatoryFlags = diameter.FlagMandatory
+
+// FlowDirection represents the value of a Flow-Direction AVP.
+type FlowDirection uint32
+
+const (
+	Unspecified   FlowDirection = 0
+	Downlink      FlowDirection = 1
+	Uplink        FlowDirection = 2
+	Bidirectional FlowDirection = 3
+)
+
+// FlowInformation represents a Flow-Information AVP. The Flow-Direction is
+// omitted when Unspecified.
+type FlowInformation struct {
+	FlowDescription string
+	FlowDirection   FlowDirection
+}
+
+// ToAvp converts the Flow-Information to a grouped AVP.
+func (f FlowInformation) ToAvp() diameter.Avp {
+	avps := diameter.NewAvps().AddString(AvpFlowDescription, mandatoryFlags, diameter.Vendor3GPP, f.FlowDescription)
+	if f.FlowDirection != Unspecified {
+		avps = avps.AddUint32(AvpFlowDirection, 0, diameter.Vendor3GPP, uint32(f.FlowDirection))
+	}
+	return diameter.NewAvpGroup(AvpFlowInformation, mandatoryFlags, diameter.Vendor3GPP, avps...)
+}
+
+// FromAvp reads the Flow-Information from a grouped AVP.
+func (f *FlowInformation) FromAvp(avp *diameter.Avp) {
+	group := avp.ToGroup()
+	f.FlowDescription = group.GetFirst(AvpFlowDescription, diameter.Vendor3GPP).ToStringOrDefault()
+	f.FlowDirection = FlowDirection(group.GetFirst(AvpFlowDirection, diameter.Vendor3GPP).ToUint32OrDefault())
+}
+
+// ChargingRuleDefinition represents a Charging-Rule-Definition AVP. Nil
+// pointers are omitted from messages.
+type ChargingRuleDefinition struct {
+	Name              string
+	ServiceIdentifier *uint32
+	RatingGroup       *uint32
+	FlowInformation   []FlowInformation
+	QoSInformation    *QoSInformation
+	Precedence        *uint32
+	MonitoringKey     []byte
+}
+
+// ToAvp converts the Charging-Rule-Definition to a grouped AVP.
+func (c ChargingRuleDefinition) ToAvp() diameter.Avp {
+	avps := diameter.NewAvps().AddString(AvpChargingRuleName, mandatoryFlags, diameter.Vendor3GPP, c.Name)
+	if c.ServiceIdentifier != nil {
+		avps = avps.AddUint32(AvpServiceIdentifier, mandatoryFlags, 0, *c.ServiceIdentifier)
+	}
+	if c.RatingGroup != nil {
+		avps = avps.AddUint32(AvpRatingGroup, mandatoryFlags, 0, *c.RatingGroup)
+	}
+	for _, flowInformation := range c.FlowInformation {
+		avps = append(avps, flowInformation.ToAvp())
+	}
+	if c.QoSInformation != nil {
+		avps = append(avps, c.QoSInformation.ToAvp())
+	}
+	if c.Precedence != nil {
+		avps = avps.AddUint32(AvpPrecedence, mandatoryFlags, diameter.Vendor3GPP, *c.Precedence)
+	}
+	if c.MonitoringKey != nil {
+		avps = avps.Add(AvpMonitoringKey, 0, diameter.Vendor3GPP, c.MonitoringKey)
+	}
+	return diameter.NewAvpGroup(AvpChargingRuleDefinition, mandatoryFlags, diameter.Vendor3GPP, avps...)
+}
+
+// FromAvp reads the Charging-Rule-Definition from a grouped AVP.
+func (c *ChargingRuleDefinition) FromAvp(avp *diameter.Avp) {
+	group := avp.ToGroup()
+	c.Name = group.GetFirst(AvpChargingRuleName, diameter.Vendor3GPP).ToStringOrDefault()
+	c.ServiceIdentifier = group.GetFirst(AvpServiceIdentifier, 0).ToUint32()
+	c.RatingGroup = group.GetFirst(AvpRatingGroup, 0).ToUint32()
+	c.FlowInformation = nil
+	for _, flowInformationAvp := range group.Get(AvpFlowInformation, diameter.Vendor3GPP) {
+		flowInformation := FlowInformation{}
+		flowInformation.FromAvp(&flowInformationAvp)
+		c.FlowInformation = append(c.FlowInformation, flowInformation)
+	}
+	c.QoSInformation = nil
+	if qosInformation := group.GetFirst(AvpQoSInformation, diameter.Vendor3GPP); qosInformation != nil {
+		c.QoSInformation = &QoSInformation{}
+		c.QoSInformation.FromAvp(qosInformation)
+	}
+	c.Precedence = group.GetFirst(AvpPrecedence, diameter.Vendor3GPP).ToUint32()
+	c.MonitoringKey = nil
+	if monitoringKey := group.GetFirst(AvpMonitoringKey, diameter.Vendor3GPP); monitoringKey != nil {
+		c.MonitoringKey = monitoringKey.ToData()
+	}
+}
+
+// ChargingRuleInstall represents a Charging-Rule-Install AVP, installing
+// rules by definition, by predefined name or by predefined base name.
+type ChargingRuleInstall struct {
+	Definitions []ChargingRuleDefinition
+	Names       []string
+	BaseNames   []string
+}
+
+// ToAvp converts the Charging-Rule-Install to a grouped AVP.
+func (c ChargingRuleInstall) ToAvp() diameter.Avp {
+	avps := diameter.NewAvps()
+	for _, definition := range c.Definitions {
+		avps = append(avps, definition.ToAvp())
+	}
+	avps = addNames(avps, c.Names, c.BaseNames)
+	return diameter.NewAvpGroup(AvpChargingRuleInstall, mandatoryFlags, diameter.Vendor3GPP, avps...)
+}
+
+// FromAvp reads the Charging-Rule-Install from a grouped AVP.
+func (c *ChargingRuleInstall) FromAvp(avp *diameter.Avp) {
+	group := avp.ToGroup()
+	c.Definitions = nil
+	for _, definitionAvp := range group.Get(AvpChargingRuleDefinition, diameter.Vendor3GPP) {
+		definition := ChargingRuleDefinition{}
+		definition.FromAvp(&definitionAvp)
+		c.Definitions = append(c.Definitions, definition)
+	}
+	c.Names, c.BaseNames = readNames(group)
+}
+
+// ChargingRuleRemove represents a Charging-Rule-Remove AVP.
+type ChargingRuleRemove struct {
+	Names     []string
+	BaseNames []string
+}
+
+// ToAvp converts the Charging-Rule-Remove to a grouped AVP.
+func (c ChargingRuleRemove) ToAvp() diameter.Avp {
+	avps := addNames(diameter.NewAvps(), c.Names, c.BaseNames)
+	return diameter.NewAvpGroup(AvpChargingRuleRemove, mandatoryFlags, diameter.Vendor3GPP, avps...)
+}
+
+// FromAvp reads the Charging-Rule-Remove from a grouped AVP.
+func (c *ChargingRuleRemove) FromAvp(avp *diameter.Avp) {
+	c.Names, c.BaseNames = readNames(avp.ToGroup())
+}
+
+// addNames adds Charging-Rule-Name and Charging-Rule-Base-Name AVPs.
+func addNames(avps diameter.Avps, names []string, baseNames []string) diameter.Avps {
+	for _, name := range names {
+		avps = avps.AddString(AvpChargingRuleName, mandatoryFlags, diameter.Vendor3GPP, name)
+	}
+	for _, baseName := range baseNames {
+		avps = avps.AddString(AvpChargingRuleBaseName, mandatoryFlags, diameter.Vendor3GPP, baseName)
+	}
+	return avps
+}
+
+// readNames reads the Charging-Rule-Name and Charging-Rule-Base-Name AVPs.
+func readNames(avps diameter.Avps) ([]string, []string) {
+	var names, baseNames []string
+	for _, avp := range avps.Get(AvpChargingRuleName, diameter.Vendor3GPP) {
+		names = append(names, avp.ToStringOrDefault())
+	}
+	for _, avp := range avps.Get(AvpChargingRuleBaseName, diameter.Vendor3GPP) {
+		baseNames = append(baseNames, avp.ToStringOrDefault())
+	}
+	return names, baseNames
+}
+
+// ChargingRuleInstalls returns the Charging-Rule-Install AVPs of a message.
+func ChargingRuleInstalls(avps diameter.Avps) []ChargingRuleInstall {
+	var installs []ChargingRuleInstall
+	for _, avp := range avps.Get(AvpChargingRuleInstall, diameter.Vendor3GPP) {
+		install := ChargingRuleInstall{}
+		install.FromAvp(&avp)
+		installs = append(installs, install)
+	}
+	return installs
+}
+
+// ChargingRuleRemoves returns the Charging-Rule-Remove AVPs of a message.
+func ChargingRuleRemoves(avps diameter.Avps) []ChargingRuleRemove {
+	var removes []ChargingRuleRemove
+	for _, avp := range avps.Get(AvpChargingRuleRemove, diameter.Vendor3GPP) {
+		remove := ChargingRuleRemove{}
+		remove.FromAvp(&avp)
+		removes = append(removes, remove)
+	}
+	return removes
+}
@@ -0,0 +1,104 @@
+package gx
+
+import (
+	"fmt"
+
+	"github.com/tinybluerobots/radius-diameter-message/diameter"
+)
+
+// EventTrigger represents the value of an Event-Trigger AVP.
+type EventTrigger uint32
+
+const (
+	SGSNChange                      EventTrigger = 0
+	QoSChange                       EventTrigger = 1
+	RATChange                       EventTrigger = 2
+	TFTChange                       EventTrigger = 3
+	PLMNChange                      EventTrigger = 4
+	LossOfBearer                    EventTrigger = 5
+	RecoveryOfBearer                EventTrigger = 6
+	IPCANChange                     EventTrigger = 7
+	QoSChangeExceedingAuthorization EventTrigger = 11
+	RAIChange                       EventTrigger = 12
+	UserLocationChange              EventTrigger = 13
+	NoEventTriggers                 EventTrigger = 14
+	OutOfCredit                     EventTrigger = 15
+	ReallocationOfCredit            EventTrigger = 16
+	RevalidationTimeout             EventTrigger = 17
+	UEIPAddressAllocate             EventTrigger = 18
+	UEIPAddressRelease              EventTrigger = 19
+	DefaultEPSBearerQoSChange       EventTrigger = 20
+	ANGWChange                      EventTrigger = 21
+	SuccessfulResourceAllocation    EventTrigger = 22
+	ResourceModificationRequest     EventTrigger = 23
+	PGWTraceControl                 EventTrigger = 24
+	UETimeZoneChange                EventTrigger = 25
+	TAIChange                       EventTrigger = 26
+	ECGIChange                      EventTrigger = 27
+	ChargingCorrelationExchange     EventTrigger = 28
+	APNAMBRModificationFailure      EventTrigger = 29
+	UserCSGInformationChange        EventTrigger = 30
+	UsageReport                     EventTrigger = 33
+)
+
+var eventTriggerNames = map[EventTrigger]string{
+	SGSNChange:                      "SGSN_CHANGE",
+	QoSChange:                       "QOS_CHANGE",
+	RATChange:                       "RAT_CHANGE",
+	TFTChange:                       "TFT_CHANGE",
+	PLMNChange:                      "PLMN_CHANGE",
+	LossOfBearer:                    "LOSS_OF_BEARER",
+	RecoveryOfBearer:                "RECOVERY_OF_BEARER",
+	IPCANChange:                     "IP-CAN_CHANGE",
+	QoSChangeExceedingAuthorization: "QOS_CHANGE_EXCEEDING_AUTHORIZATION",
+	RAIChange:                       "RAI_CHANGE",
+	UserLocationChange:              "USER_LOCATION_CHANGE",
+	NoEventTriggers:                 "NO_EVENT_TRIGGERS",
+	OutOfCredit:                     "OUT_OF_CREDIT",
+	ReallocationOfCredit:            "REALLOCATION_OF_CREDIT",
+	RevalidationTimeout:             "REVALIDATION_TIMEOUT",
+	UEIPAddressAllocate:             "UE_IP_ADDRESS_ALLOCATE",
+	UEIPAddressRelease:              "UE_IP_ADDRESS_RELEASE",
+	DefaultEPSBearerQoSChange:       "DEFAULT_EPS_BEARER_QOS_CHANGE",
+	ANGWChange:                      "AN_GW_CHANGE",
+	SuccessfulResourceAllocation:    "SUCCESSFUL_RESOURCE_ALLOCATION",
+	ResourceModificationRequest:     "RESOURCE_MODIFICATION_REQUEST",
+	PGWTraceControl:                 "PGW_TRACE_CONTROL",
+	UETimeZoneChange:                "UE_TIME_ZONE_CHANGE",
+	TAIChange:                       "TAI_CHANGE",
+	ECGIChange:                      "ECGI_CHANGE",
+	ChargingCorrelationExchange:     "CHARGING_CORRELATION_EXCHANGE",
+	APNAMBRModificationFailure:      "APN-AMBR_MODIFICATION_FAILURE",
+	UserCSGInformationChange:        "USER_CSG_INFORMATION_CHANGE",
+	UsageReport:                     "USAGE_REPORT",
+}
+
+// String returns the TS 29.212 name of the event trigger.
+func (e EventTrigger) String() string {
+	if name, ok := eventTriggerNames[e]; ok {
+		return name
+	}
+	return fmt.Sprintf("UNKNOWN(%d)", uint32(e))
+}
+
+// NewAvpEventTrigger creates an Event-Trigger AVP.
+func NewAvpEventTrigger(trigger EventTrigger) diameter.Avp {
+	return diameter.NewAvpUint32(AvpEventTrigger, mandatoryFlags, diameter.Vendor3GPP, uint32(trigger))
+}
+
+// AddEventTriggers adds an Event-Trigger AVP for each trigger.
+func AddEventTriggers(avps diameter.Avps, triggers ...EventTrigger) diameter.Avps {
+	for _, trigger := range triggers {
+		avps = append(avps, NewAvpEventTrigger(trigger))
+	}
+	return avps
+}
+
+// EventTriggers returns the values of the Event-Trigger AVPs.
+func EventTriggers(avps diameter.Avps) []EventTrigger {
+	var triggers []EventTrigger
+	for _, avp := range avps.Get(AvpEventTrigger, diameter.Vendor3GPP) {
+		triggers = append(triggers, EventTrigger(avp.ToUint32OrDefault()))
+	}
+	return triggers
+}
@@ -0,0 +1,97 @@
+package gx
+
+import "github.com/tinybluerobots/radius-diameter-message/diameter"
+
+// PreEmptionCapability represents the value of a Pre-emption-Capability AVP.
+type PreEmptionCapability uint32
+
+const (
+	PreEmptionCapabilityEnabled  PreEmptionCapability = 0
+	PreEmptionCapabilityDisabled PreEmptionCapability = 1
+)
+
+// PreEmptionVulnerability represents the value of a Pre-emption-Vulnerability AVP.
+type PreEmptionVulnerability uint32
+
+const (
+	PreEmptionVulnerabilityEnabled  PreEmptionVulnerability = 0
+	PreEmptionVulnerabilityDisabled PreEmptionVulnerability = 1
+)
+
+// AllocationRetentionPriority represents an Allocation-Retention-Priority AVP.
+type AllocationRetentionPriority struct {
+	PriorityLevel           uint32
+	PreEmptionCapability    PreEmptionCapability
+	PreEmptionVulnerability PreEmptionVulnerability
+}
+
+// ToAvp converts the Allocation-Retention-Priority to a grouped AVP.
+func (a AllocationRetentionPriority) ToAvp() diameter.Avp {
+	avps := diameter.NewAvps()
+	avps = avps.AddUint32(AvpPriorityLevel, mandatoryFlags, diameter.Vendor3GPP, a.PriorityLevel)
+	avps = avps.AddUint32(AvpPreEmptionCapability, mandatoryFlags, diameter.Vendor3GPP, uint32(a.PreEmptionCapability))
+	avps = avps.AddUint32(AvpPreEmptionVulnerability, mandatoryFlags, diameter.Vendor3GPP, uint32(a.PreEmptionVulnerability))
+	return diameter.NewAvpGroup(AvpAllocationRetentionPriority, mandatoryFlags, diameter.Vendor3GPP, avps...)
+}
+
+// FromAvp reads the Allocation-Retention-Priority from a grouped AVP.
+func (a *AllocationRetentionPriority) FromAvp(avp *diameter.Avp) {
+	group := avp.ToGroup()
+	a.PriorityLevel = group.GetFirst(AvpPriorityLevel, diameter.Vendor3GPP).ToUint32OrDefault()
+	a.PreEmptionCapability = PreEmptionCapability(group.GetFirst(AvpPreEmptionCapability, diameter.Vendor3GPP).ToUint32OrDefault())
+	a.PreEmptionVulnerability = PreEmptionVulnerability(group.GetFirst(AvpPreEmptionVulnerability, diameter.Vendor3GPP).ToUint32OrDefault())
+}
+
+// QoSInformation represents a QoS-Information AVP. Bitrates are in bits per
+// second, and zero values are omitted from messages.
+type QoSInformation struct {
+	QoSClassIdentifier          uint32
+	MaxRequestedBandwidthUL     uint32
+	MaxRequestedBandwidthDL     uint32
+	GuaranteedBitrateUL         uint32
+	GuaranteedBitrateDL         uint32
+	AllocationRetentionPriority *AllocationRetentionPriority
+	APNAggregateMaxBitrateUL    uint32
+	APNAggregateMaxBitrateDL    uint32
+}
+
+// ToAvp converts the QoS-Information to a grouped AVP.
+func (q QoSInformation) ToAvp() diameter.Avp {
+	avps := diameter.NewAvps()
+	avps = addUint32(avps, AvpQoSClassIdentifier, q.QoSClassIdentifier)
+	avps = addUint32(avps, AvpMaxRequestedBandwidthUL, q.MaxRequestedBandwidthUL)
+	avps = addUint32(avps, AvpMaxRequestedBandwidthDL, q.MaxRequestedBandwidthDL)
+	avps = addUint32(avps, AvpGuaranteedBitrateUL, q.GuaranteedBitrateUL)
+	avps = addUint32(avps, AvpGuaranteedBitrateDL, q.GuaranteedBitrateDL)
+	if q.AllocationRetentionPriority != nil {
+		avps = append(avps, q.AllocationRetentionPriority.ToAvp())
+	}
+	avps = addUint32(avps, AvpAPNAggregateMaxBitrateUL, q.APNAggregateMaxBitrateUL)
+	avps = addUint32(avps, AvpAPNAggregateMaxBitrateDL, q.APNAggregateMaxBitrateDL)
+	return diameter.NewAvpGroup(AvpQoSInformation, mandatoryFlags, diameter.Vendor3GPP, avps...)
+}
+
+// FromAvp reads the QoS-Information from a grouped AVP.
+func (q *QoSInformation) FromAvp(avp *diameter.Avp) {
+	group := avp.ToGroup()
+	q.QoSClassIdentifier = group.GetFirst(AvpQoSClassIdentifier, diameter.Vendor3GPP).ToUint32OrDefault()
+	q.MaxRequestedBandwidthUL = group.GetFirst(AvpMaxRequestedBandwidthUL, diameter.Vendor3GPP).ToUint32OrDefault()
+	q.MaxRequestedBandwidthDL = group.GetFirst(AvpMaxRequestedBandwidthDL, diameter.Vendor3GPP).ToUint32OrDefault()
+	q.GuaranteedBitrateUL = group.GetFirst(AvpGuaranteedBitrateUL, diameter.Vendor3GPP).ToUint32OrDefault()
+	q.GuaranteedBitrateDL = group.GetFirst(AvpGuaranteedBitrateDL, diameter.Vendor3GPP).ToUint32OrDefault()
+	q.AllocationRetentionPriority = nil
+	if arp := group.GetFirst(AvpAllocationRetentionPriority, diameter.Vendor3GPP); arp != nil {
+		q.AllocationRetentionPriority = &AllocationRetentionPriority{}
+		q.AllocationRetentionPriority.FromAvp(arp)
+	}
+	q.APNAggregateMaxBitrateUL = group.GetFirst(AvpAPNAggregateMaxBitrateUL, diameter.Vendor3GPP).ToUint32OrDefault()
+	q.APNAggregateMaxBitrateDL = group.GetFirst(AvpAPNAggregateMaxBitrateDL, diameter.Vendor3GPP).ToUint32OrDefault()
+}
+
+// addUint32 adds a 3GPP uint32 AVP if the value is not zero.
+func addUint32(avps diameter.Avps, code diameter.Code, value uint32) diameter.Avps {
+	if value == 0 {
+		return avps
+	}
+	return avps.AddUint32(code, mandatoryFlags, diameter.Vendor3GPP, value)
+}
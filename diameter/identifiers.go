@@ -0,0 +1,67 @@
+package diameter
+
+import (
+	"encoding/binary"
+	"math/rand/v2"
+	"sync/atomic"
+	"time"
+)
+
+// IdGenerator generates Hop-by-Hop and End-to-End identifiers as RFC 6733
+// recommends: Hop-by-Hop identifiers increase from a random start, and
+// End-to-End identifiers start with the low 12 bits of the current time in
+// the high bits and a random value in the low 20 bits.
+type IdGenerator struct {
+	hopByHopId atomic.Uint32
+	endToEndId atomic.Uint32
+}
+
+// NewIdGenerator creates a new IdGenerator.
+func NewIdGenerator() *IdGenerator {
+	g := &IdGenerator{}
+	g.hopByHopId.Store(rand.Uint32())
+	g.endToEndId.Store(uint32(time.Now().Unix())<<20 | rand.Uint32()&0xfffff)
+	return g
+}
+
+// NextHopByHopId returns the next Hop-by-Hop identifier.
+func (g *IdGenerator) NextHopByHopId() [4]byte {
+	return toId(g.hopByHopId.Add(1))
+}
+
+// NextEndToEndId returns the next End-to-End identifier.
+func (g *IdGenerator) NextEndToEndId() [4]byte {
+	return toId(g.endToEndId.Add(1))
+}
+
+// IdState is the last Hop-by-Hop and End-to-End identifiers an IdGenerator
+// returned, to be checkpointed so a generator can resume after a restart.
+type IdState struct {
+	HopByHopId uint32
+	EndToEndId uint32
+}
+
+// State returns the last identifiers returned.
+func (g *IdGenerator) State() IdState {
+	return IdState{HopByHopId: g.hopByHopId.Load(), EndToEndId: g.endToEndId.Load()}
+}
+
+// Resume continues generating identifiers after those of the state, so they
+// do not repeat those returned before it was checkpointed.
+func (g *IdGenerator) Resume(state IdState) {
+	g.hopByHopId.Store(state.HopByHopId)
+	g.endToEndId.Store(state.EndToEndId)
+}
+
+// After reports whether the state was checkpointed after the other, by the
+// serial number arithmetic of its Hop-by-Hop identifiers.
+func (s IdState) After(other IdState) bool {
+	return int32(s.HopByHopId-other.HopByHopId) > 0
+}
+
+// toId converts a uint32 to an identifier.
+func toId(value uint32) [4]byte {
+	id := [4]byte{}
+	binary.BigEndian.PutUint32(id[:], value)
+	return id
+}
@@ -0,0 +1,318 @@
+package diameter
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/tinybluerobots/radius-diameter-message/enterprise"
+)
+
+// jsonMessage is the JSON representation of a Diameter message.
+type jsonMessage struct {
+	Version       byte          `json:"version"`
+	Flags         Flags         `json:"flags"`
+	CommandCode   CommandCode   `json:"commandCode"`
+	Command       string        `json:"command,omitempty"`
+	ApplicationId ApplicationId `json:"applicationId"`
+	HopByHopId    uint32        `json:"hopByHopId"`
+	EndToEndId    uint32        `json:"endToEndId"`
+	Avps          []jsonAvp     `json:"avps"`
+}
+
+// jsonAvp is the JSON representation of a Diameter AVP. Typed values are
+// written to Value, grouped AVPs to Avps and anything else to Data as base64.
+type jsonAvp struct {
+	Code     Code            `json:"code"`
+	VendorId VendorId        `json:"vendorId,omitempty"`
+	Vendor   string          `json:"vendor,omitempty"`
+	Flags    Flags           `json:"flags"`
+	Name     string          `json:"name,omitempty"`
+	Type     string          `json:"type,omitempty"`
+	Value    json.RawMessage `json:"value,omitempty"`
+	Data     []byte          `json:"data,omitempty"`
+	Avps     []jsonAvp       `json:"avps,omitempty"`
+}
+
+// MarshalJSON converts the Diameter message to JSON.
+func (m Message) MarshalJSON() ([]byte, error) {
+	return MarshalMessageJSON(m, nil)
+}
+
+// UnmarshalJSON reads JSON produced by MarshalJSON into the Diameter message.
+func (m *Message) UnmarshalJSON(bytes []byte) error {
+	message, err := UnmarshalMessageJSON(bytes, nil)
+	if err != nil {
+		return err
+	}
+	*m = *message
+	return nil
+}
+
+// MarshalJSON converts the AVP to JSON.
+func (a Avp) MarshalJSON() ([]byte, error) {
+	return json.Marshal(toJSONAvp(a, nil))
+}
+
+// UnmarshalJSON reads JSON produced by MarshalJSON into the AVP.
+func (a *Avp) UnmarshalJSON(bytes []byte) error {
+	var value jsonAvp
+	if err := json.Unmarshal(bytes, &value); err != nil {
+		return err
+	}
+	avp, err := fromJSONAvp(value, nil)
+	if err != nil {
+		return err
+	}
+	*a = avp
+	return nil
+}
+
+// MarshalMessageJSON converts the Diameter message to JSON, using the
+// dictionary, which may be nil, to name and type AVPs.
+func MarshalMessageJSON(m Message, dictionary *Dictionary) ([]byte, error) {
+	value := jsonMessage{
+		Version:       m.Version,
+		Flags:         m.Flags,
+		CommandCode:   m.CommandCode,
+		ApplicationId: m.ApplicationId,
+		HopByHopId:    binary.BigEndian.Uint32(m.HopByHopId[:]),
+		EndToEndId:    binary.BigEndian.Uint32(m.EndToEndId[:]),
+		Avps:          toJSONAvps(m.Avps, dictionary),
+	}
+	if name := dictionary.Command(m.CommandCode); name != nil {
+		value.Command = *name
+	}
+	return json.Marshal(value)
+}
+
+// UnmarshalMessageJSON reads JSON into a Diameter message, using the
+// dictionary, which may be nil, to resolve AVP names and types.
+func UnmarshalMessageJSON(bytes []byte, dictionary *Dictionary) (*Message, error) {
+	var value jsonMessage
+	if err := json.Unmarshal(bytes, &value); err != nil {
+		return nil, err
+	}
+	avps, err := fromJSONAvps(value.Avps, dictionary)
+	if err != nil {
+		return nil, err
+	}
+	message := Message{
+		Version:       value.Version,
+		Flags:         value.Flags,
+		CommandCode:   value.CommandCode,
+		ApplicationId: value.ApplicationId,
+		Avps:          avps,
+	}
+	binary.BigEndian.PutUint32(message.HopByHopId[:], value.HopByHopId)
+	binary.BigEndian.PutUint32(message.EndToEndId[:], value.EndToEndId)
+	return &message, nil
+}
+
+// toJSONAvps converts a slice of AVPs to their JSON representation.
+func toJSONAvps(avps Avps, dictionary *Dictionary) []jsonAvp {
+	values := make([]jsonAvp, 0, len(avps))
+	for _, avp := range avps {
+		values = append(values, toJSONAvp(avp, dictionary))
+	}
+	return values
+}
+
+// toJSONAvp converts an AVP to its JSON representation.
+func toJSONAvp(avp Avp, dictionary *Dictionary) jsonAvp {
+	value := jsonAvp{
+		Code:     avp.Code,
+		VendorId: avp.VendorId,
+		Flags:    avp.Flags,
+	}
+	if avp.VendorId != 0 {
+		if name := enterprise.Name(uint32(avp.VendorId)); name != nil {
+			value.Vendor = *name
+		}
+	}
+	definition := dictionary.Avp(avp.Code, avp.VendorId)
+	if definition == nil {
+		value.Data = avp.Data
+		return value
+	}
+	value.Name = definition.Name
+	value.Type = definition.Type.String()
+	if definition.Type == Grouped {
+		value.Avps = toJSONAvps(avp.ToGroup(), dictionary)
+		return value
+	}
+	typed, ok := typedValue(definition.Type, avp.Data)
+	if !ok {
+		value.Type = ""
+		value.Data = avp.Data
+		return value
+	}
+	value.Value, _ = json.Marshal(typed)
+	return value
+}
+
+// typedValue decodes data as a Go value suitable for JSON, reporting false
+// when the type has no typed form or the data is malformed for its type.
+func typedValue(dataType DataType, data avpData) (any, bool) {
+	avp := &Avp{Data: data}
+	switch dataType {
+	case Integer32:
+		if len(data) == 4 {
+			return int32(*avp.ToUint32()), true
+		}
+	case Integer64:
+		if len(data) == 8 {
+			return int64(*avp.ToUint64()), true
+		}
+	case Unsigned32, Enumerated:
+		if len(data) == 4 {
+			return *avp.ToUint32(), true
+		}
+	case Unsigned64:
+		if len(data) == 8 {
+			return *avp.ToUint64(), true
+		}
+	case Float32:
+		if len(data) == 4 {
+			return *avp.ToFloat32(), true
+		}
+	case Float64:
+		if len(data) == 8 {
+			return *avp.ToFloat64(), true
+		}
+	case Address:
+		if len(data) == 6 && data[1] == 1 || len(data) == 18 && data[1] == 2 {
+			return avp.ToNetIP().String(), true
+		}
+	case Time:
+		if len(data) == 4 {
+			return avp.ToTime().UTC().Format(time.RFC3339), true
+		}
+	case UTF8String, DiameterIdentity, DiameterURI:
+		return string(data), true
+	}
+	return nil, false
+}
+
+// fromJSONAvps converts a JSON representation back into a slice of AVPs.
+func fromJSONAvps(values []jsonAvp, dictionary *Dictionary) (Avps, error) {
+	avps := NewAvps()
+	for _, value := range values {
+		avp, err := fromJSONAvp(value, dictionary)
+		if err != nil {
+			return nil, err
+		}
+		avps = append(avps, avp)
+	}
+	return avps, nil
+}
+
+// fromJSONAvp converts a JSON representation back into an AVP.
+func fromJSONAvp(value jsonAvp, dictionary *Dictionary) (Avp, error) {
+	code, vendorId := value.Code, value.VendorId
+	if vendorId == 0 && value.Vendor != "" {
+		number := enterprise.Number(value.Vendor)
+		if number == nil {
+			return Avp{}, fmt.Errorf("unknown vendor %q", value.Vendor)
+		}
+		vendorId = VendorId(*number)
+	}
+	var definition *AvpDefinition
+	if value.Name != "" && code == 0 {
+		definition = dictionary.AvpByName(value.Name)
+		if definition == nil {
+			return Avp{}, fmt.Errorf("unknown AVP name %q", value.Name)
+		}
+		code, vendorId = definition.Code, definition.VendorId
+	} else {
+		definition = dictionary.Avp(code, vendorId)
+	}
+	if value.Avps != nil {
+		avps, err := fromJSONAvps(value.Avps, dictionary)
+		if err != nil {
+			return Avp{}, err
+		}
+		return NewAvpGroup(code, value.Flags, vendorId, avps...), nil
+	}
+	if value.Value == nil {
+		data := value.Data
+		if data == nil {
+			data = make([]byte, 0)
+		}
+		return NewAvp(code, value.Flags, vendorId, data), nil
+	}
+	dataType, ok := parseDataType(value.Type)
+	if !ok {
+		if definition == nil {
+			return Avp{}, fmt.Errorf("AVP %d has a value but no type", code)
+		}
+		dataType = definition.Type
+	}
+	avp, err := newTypedAvp(code, value.Flags, vendorId, dataType, value.Value)
+	if err != nil {
+		return Avp{}, fmt.Errorf("AVP %d: %w", code, err)
+	}
+	return avp, nil
+}
+
+// parseDataType converts the name of a data type back to a DataType.
+func parseDataType(name string) (DataType, bool) {
+	for dataType, dataTypeName := range dataTypeNames {
+		if dataTypeName == name {
+			return dataType, true
+		}
+	}
+	return 0, false
+}
+
+// newTypedAvp creates an AVP from a JSON value of the given data type.
+func newTypedAvp(code Code, flags Flags, vendorId VendorId, dataType DataType, raw json.RawMessage) (Avp, error) {
+	switch dataType {
+	case Integer32:
+		var value int32
+		err := json.Unmarshal(raw, &value)
+		return NewAvpUint32(code, flags, vendorId, uint32(value)), err
+	case Integer64:
+		var value int64
+		err := json.Unmarshal(raw, &value)
+		return NewAvpUint64(code, flags, vendorId, uint64(value)), err
+	case Unsigned32, Enumerated:
+		var value uint32
+		err := json.Unmarshal(raw, &value)
+		return NewAvpUint32(code, flags, vendorId, value), err
+	case Unsigned64:
+		var value uint64
+		err := json.Unmarshal(raw, &value)
+		return NewAvpUint64(code, flags, vendorId, value), err
+	case Float32:
+		var value float32
+		err := json.Unmarshal(raw, &value)
+		return NewAvpFloat32(code, flags, vendorId, value), err
+	case Float64:
+		var value float64
+		err := json.Unmarshal(raw, &value)
+		return NewAvpFloat64(code, flags, vendorId, value), err
+	case Address:
+		var value string
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return Avp{}, err
+		}
+		ip := net.ParseIP(value)
+		if ip == nil {
+			return Avp{}, fmt.Errorf("invalid address %q", value)
+		}
+		return NewAvpNetIP(code, flags, vendorId, ip), nil
+	case Time:
+		var value time.Time
+		err := json.Unmarshal(raw, &value)
+		return NewAvpTime(code, flags, vendorId, value), err
+	case UTF8String, DiameterIdentity, DiameterURI:
+		var value string
+		err := json.Unmarshal(raw, &value)
+		return NewAvpString(code, flags, vendorId, value), err
+	}
+	return Avp{}, errors.New("unsupported value type " + dataType.String())
+}
@@ -0,0 +1,112 @@
+package diameter
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Default limits on decoding a message.
+const (
+	DefaultMaxMessageSize = 1 << 20
+	DefaultMaxAvps        = 16384
+	DefaultMaxDepth       = 16
+)
+
+// ErrLimitExceeded is matched by every *LimitError.
+var ErrLimitExceeded = errors.New("limit exceeded")
+
+// Limits bound the resources decoding a message may use, to guard against
+// messages crafted to exhaust them. Zero fields take the defaults.
+type Limits struct {
+	// MaxMessageSize is the largest message length accepted, checked before
+	// a message is read from a stream.
+	MaxMessageSize int
+	// MaxAvps is the most AVPs a message may hold, counting those nested in
+	// grouped AVPs the dictionary knows.
+	MaxAvps int
+	// MaxDepth is the deepest grouped AVPs the dictionary knows may nest.
+	MaxDepth int
+}
+
+// withDefaults returns the limits with zero fields set to their defaults.
+func (l Limits) withDefaults() Limits {
+	if l.MaxMessageSize == 0 {
+		l.MaxMessageSize = DefaultMaxMessageSize
+	}
+	if l.MaxAvps == 0 {
+		l.MaxAvps = DefaultMaxAvps
+	}
+	if l.MaxDepth == 0 {
+		l.MaxDepth = DefaultMaxDepth
+	}
+	return l
+}
+
+// LimitError is returned when a message exceeds one of its Limits.
+type LimitError struct {
+	// Limit names the limit: "message size", "AVP count" or "nesting depth".
+	Limit string
+	Max   int
+}
+
+// Error returns the limit exceeded.
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%s exceeds the limit of %d", e.Limit, e.Max)
+}
+
+// Is reports whether the target is ErrLimitExceeded.
+func (e *LimitError) Is(target error) bool {
+	return target == ErrLimitExceeded
+}
+
+// check walks the AVP headers of a message without decoding them, returning
+// a *LimitError if it exceeds the limits. Malformed AVPs end the walk, to be
+// reported when the message is read.
+func (l Limits) check(bytes []byte, dictionary *Dictionary) error {
+	if len(bytes) > l.MaxMessageSize {
+		return &LimitError{Limit: "message size", Max: l.MaxMessageSize}
+	}
+	if len(bytes) < 20 {
+		return nil
+	}
+	count := 0
+	return l.checkAvps(bytes[20:], dictionary, &count, 0)
+}
+
+// checkAvps counts the AVPs in the bytes, recursing into grouped AVPs the
+// dictionary knows.
+func (l Limits) checkAvps(bytes []byte, dictionary *Dictionary, count *int, depth int) error {
+	if depth > l.MaxDepth {
+		return &LimitError{Limit: "nesting depth", Max: l.MaxDepth}
+	}
+	for offset := 0; len(bytes)-offset >= 8; {
+		*count++
+		if *count > l.MaxAvps {
+			return &LimitError{Limit: "AVP count", Max: l.MaxAvps}
+		}
+		flags := Flags(bytes[offset+4])
+		length := int(readUInt24(bytes[offset+5 : offset+8]))
+		headerLength := 8
+		if flags.IsVendorSpecific() {
+			headerLength = 12
+		}
+		if length < headerLength || offset+length > len(bytes) {
+			return nil
+		}
+		if dictionary != nil {
+			code := Code(binary.BigEndian.Uint32(bytes[offset : offset+4]))
+			var vendorId VendorId
+			if flags.IsVendorSpecific() {
+				vendorId = VendorId(binary.BigEndian.Uint32(bytes[offset+8 : offset+12]))
+			}
+			if definition := dictionary.Avp(code, vendorId); definition != nil && definition.Type == Grouped {
+				if err := l.checkAvps(bytes[offset+headerLength:offset+length], dictionary, count, depth+1); err != nil {
+					return err
+				}
+			}
+		}
+		offset += length + (4-length%4)%4
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"errors"
+	"io"
+	"net"
+	"time"
+
+	"github.com/tinybluerobots/radius-diameter-message/diameter"
+	"github.com/tinybluerobots/radius-diameter-message/diameter/transaction"
+	"github.com/tinybluerobots/radius-diameter-message/diameter/watchdog"
+)
+
+// Sink receives measurements from the client and server. Implementations
+// must be safe for concurrent use and should not block.
+type Sink interface {
+	// MessageReceived is called for every message read from a peer.
+	MessageReceived(message diameter.Message)
+	// MessageSent is called for every message written to a peer.
+	MessageSent(message diameter.Message)
+	// ParseError is called when a malformed message is read from a peer.
+	ParseError(err error)
+	// TransactionLatency is called when an answer to a request arrives.
+	TransactionLatency(commandCode diameter.CommandCode, latency time.Duration)
+	// WatchdogState is called when the watchdog state of a peer changes.
+	WatchdogState(peer string, state watchdog.State)
+	// UnmatchedAnswer is called for an answer that matched no pending
+	// request, with whether it was late or unsolicited.
+	UnmatchedAnswer(answer diameter.Message, outcome transaction.Outcome)
+}
+
+// Nop is a Sink that discards every measurement.
+type Nop struct{}
+
+func (Nop) MessageReceived(diameter.Message)                       {}
+func (Nop) MessageSent(diameter.Message)                           {}
+func (Nop) ParseError(error)                                       {}
+func (Nop) TransactionLatency(diameter.CommandCode, time.Duration) {}
+func (Nop) WatchdogState(string, watchdog.State)                   {}
+func (Nop) UnmatchedAnswer(diameter.Message, transaction.Outcome)  {}
+
+// OrNop returns the sink, or Nop if it is nil.
+func OrNop(sink Sink) Sink {
+	if sink == nil {
+		return Nop{}
+	}
+	return sink
+}
+
+// IsParseError reports whether an error from diameter.ReadMessageFrom was
+// caused by a malformed message rather than by the connection.
+func IsParseError(err error) bool {
+	if err == nil || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, net.ErrClosed) {
+		return false
+	}
+	var netErr net.Error
+	return !errors.As(err, &netErr)
+}
+
+// ResultCode returns the Result-Code of an answer, or the
+// Experimental-Result-Code if it has none, and false for requests.
+func ResultCode(message diameter.Message) (diameter.ResultCode, bool) {
+	if message.IsRequest() {
+		return 0, false
+	}
+	if resultCode, ok := message.Avps.GetFirst(diameter.AvpResultCode, 0).ToUint32Ok(); ok {
+		return diameter.ResultCode(resultCode), true
+	}
+	experimental := message.Avps.GetFirst(diameter.AvpExperimentalResult, 0).ToGroup()
+	if resultCode, ok := experimental.GetFirst(diameter.AvpExperimentalResultCode, 0).ToUint32Ok(); ok {
+		return diameter.ResultCode(resultCode), true
+	}
+	return 0, false
+}
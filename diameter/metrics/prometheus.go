@@ -0,0 +1,216 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tinybluerobots/radius-diameter-message/diameter"
+	"github.com/tinybluerobots/radius-diameter-message/diameter/transaction"
+	"github.com/tinybluerobots/radius-diameter-message/diameter/watchdog"
+)
+
+// LatencyBuckets are the upper bounds, in seconds, of the transaction latency histogram.
+var LatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+type messageKey struct {
+	direction   string
+	commandCode diameter.CommandCode
+	request     bool
+	resultCode  diameter.ResultCode
+}
+
+type histogram struct {
+	buckets []uint64
+	count   uint64
+	sum     float64
+}
+
+// Prometheus is a Sink that keeps its measurements in memory and serves them
+// over HTTP in the Prometheus text exposition format.
+type Prometheus struct {
+	mutex       sync.Mutex
+	messages    map[messageKey]uint64
+	parseErrors uint64
+	latencies   map[diameter.CommandCode]*histogram
+	watchdogs   map[string]watchdog.State
+	unmatched   map[transaction.Outcome]uint64
+}
+
+// NewPrometheus creates an empty Prometheus sink.
+func NewPrometheus() *Prometheus {
+	return &Prometheus{
+		messages:  make(map[messageKey]uint64),
+		latencies: make(map[diameter.CommandCode]*histogram),
+		watchdogs: make(map[string]watchdog.State),
+		unmatched: make(map[transaction.Outcome]uint64),
+	}
+}
+
+// MessageReceived counts a message read from a peer.
+func (p *Prometheus) MessageReceived(message diameter.Message) {
+	p.countMessage("in", message)
+}
+
+// MessageSent counts a message written to a peer.
+func (p *Prometheus) MessageSent(message diameter.Message) {
+	p.countMessage("out", message)
+}
+
+// ParseError counts a malformed message.
+func (p *Prometheus) ParseError(error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.parseErrors++
+}
+
+// TransactionLatency observes the time taken to answer a request.
+func (p *Prometheus) TransactionLatency(commandCode diameter.CommandCode, latency time.Duration) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	h, ok := p.latencies[commandCode]
+	if !ok {
+		h = &histogram{buckets: make([]uint64, len(LatencyBuckets))}
+		p.latencies[commandCode] = h
+	}
+	seconds := latency.Seconds()
+	for i, bound := range LatencyBuckets {
+		if seconds <= bound {
+			h.buckets[i]++
+		}
+	}
+	h.count++
+	h.sum += seconds
+}
+
+// WatchdogState records the watchdog state of a peer.
+func (p *Prometheus) WatchdogState(peer string, state watchdog.State) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.watchdogs[peer] = state
+}
+
+// UnmatchedAnswer counts an answer that matched no pending request.
+func (p *Prometheus) UnmatchedAnswer(_ diameter.Message, outcome transaction.Outcome) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.unmatched[outcome]++
+}
+
+// countMessage counts a message in the direction.
+func (p *Prometheus) countMessage(direction string, message diameter.Message) {
+	resultCode, _ := ResultCode(message)
+	key := messageKey{direction, message.CommandCode, message.IsRequest(), resultCode}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.messages[key]++
+}
+
+// ServeHTTP writes the measurements in the Prometheus text exposition format.
+func (p *Prometheus) ServeHTTP(writer http.ResponseWriter, _ *http.Request) {
+	writer.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	p.WriteTo(writer)
+}
+
+// WriteTo writes the measurements in the Prometheus text exposition format.
+func (p *Prometheus) WriteTo(writer io.Writer) (int64, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	w := &countingWriter{writer: writer}
+
+	w.printf("# HELP diameter_messages_total Diameter messages by direction, command and result code.\n")
+	w.printf("# TYPE diameter_messages_total counter\n")
+	keys := make([]messageKey, 0, len(p.messages))
+	for key := range p.messages {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, compareMessageKeys)
+	for _, key := range keys {
+		resultCode := ""
+		if !key.request {
+			resultCode = strconv.FormatUint(uint64(key.resultCode), 10)
+		}
+		w.printf("diameter_messages_total{direction=%q,command=\"%d\",request=\"%t\",result_code=%q} %d\n",
+			key.direction, key.commandCode, key.request, resultCode, p.messages[key])
+	}
+
+	w.printf("# HELP diameter_parse_errors_total Malformed Diameter messages received.\n")
+	w.printf("# TYPE diameter_parse_errors_total counter\n")
+	w.printf("diameter_parse_errors_total %d\n", p.parseErrors)
+
+	w.printf("# HELP diameter_transaction_latency_seconds Time from sending a request to receiving its answer.\n")
+	w.printf("# TYPE diameter_transaction_latency_seconds histogram\n")
+	commandCodes := make([]diameter.CommandCode, 0, len(p.latencies))
+	for commandCode := range p.latencies {
+		commandCodes = append(commandCodes, commandCode)
+	}
+	slices.Sort(commandCodes)
+	for _, commandCode := range commandCodes {
+		h := p.latencies[commandCode]
+		for i, bound := range LatencyBuckets {
+			w.printf("diameter_transaction_latency_seconds_bucket{command=\"%d\",le=\"%s\"} %d\n", commandCode, strconv.FormatFloat(bound, 'g', -1, 64), h.buckets[i])
+		}
+		w.printf("diameter_transaction_latency_seconds_bucket{command=\"%d\",le=\"+Inf\"} %d\n", commandCode, h.count)
+		w.printf("diameter_transaction_latency_seconds_sum{command=\"%d\"} %s\n", commandCode, strconv.FormatFloat(h.sum, 'g', -1, 64))
+		w.printf("diameter_transaction_latency_seconds_count{command=\"%d\"} %d\n", commandCode, h.count)
+	}
+
+	w.printf("# HELP diameter_watchdog_state Watchdog state of each peer: 0 okay, 1 suspect, 2 down.\n")
+	w.printf("# TYPE diameter_watchdog_state gauge\n")
+	peers := make([]string, 0, len(p.watchdogs))
+	for peer := range p.watchdogs {
+		peers = append(peers, peer)
+	}
+	slices.Sort(peers)
+	for _, peer := range peers {
+		w.printf("diameter_watchdog_state{peer=%q} %d\n", peer, p.watchdogs[peer])
+	}
+
+	w.printf("# HELP diameter_unmatched_answers_total Answers that matched no pending request, late or unsolicited.\n")
+	w.printf("# TYPE diameter_unmatched_answers_total counter\n")
+	for _, outcome := range []transaction.Outcome{transaction.Late, transaction.Unsolicited} {
+		w.printf("diameter_unmatched_answers_total{outcome=%q} %d\n", strings.ToLower(outcome.String()), p.unmatched[outcome])
+	}
+	return w.count, w.err
+}
+
+// compareMessageKeys orders message keys for stable output.
+func compareMessageKeys(a messageKey, b messageKey) int {
+	switch {
+	case a.direction != b.direction:
+		if a.direction < b.direction {
+			return -1
+		}
+		return 1
+	case a.commandCode != b.commandCode:
+		return int(a.commandCode) - int(b.commandCode)
+	case a.request != b.request:
+		if a.request {
+			return -1
+		}
+		return 1
+	}
+	return int(a.resultCode) - int(b.resultCode)
+}
+
+// countingWriter counts bytes written and keeps the first error.
+type countingWriter struct {
+	writer io.Writer
+	count  int64
+	err    error
+}
+
+// printf writes formatted output unless an earlier write failed.
+func (w *countingWriter) printf(format string, args ...any) {
+	if w.err != nil {
+		return
+	}
+	n, err := fmt.Fprintf(w.writer, format, args...)
+	w.count += int64(n)
+	w.err = err
+}
@@ -0,0 +1,78 @@
+package originstate
+
+import (
+	"sync"
+	"time"
+
+	"github.com/tinybluerobots/radius-diameter-message/diameter"
+)
+
+// RestartFunc is called when a peer's Origin-State-Id changes, meaning it has
+// restarted and lost the sessions it held.
+type RestartFunc func(originHost string, previous uint32, current uint32)
+
+// Tracker owns the local Origin-State-Id and watches the Origin-State-Id of
+// every peer to detect restarts. It is safe for concurrent use.
+type Tracker struct {
+	local     uint32
+	onRestart RestartFunc
+	mutex     sync.Mutex
+	peers     map[string]uint32
+}
+
+// New creates a tracker whose local Origin-State-Id is the current Unix time,
+// so it increases each time the process starts.
+func New(onRestart RestartFunc) *Tracker {
+	return NewWithId(uint32(time.Now().Unix()), onRestart)
+}
+
+// NewWithId creates a tracker with the given local Origin-State-Id, such as
+// one persisted and incremented across restarts.
+func NewWithId(local uint32, onRestart RestartFunc) *Tracker {
+	return &Tracker{local: local, onRestart: onRestart, peers: make(map[string]uint32)}
+}
+
+// Local returns the local Origin-State-Id.
+func (t *Tracker) Local() uint32 {
+	return t.local
+}
+
+// Inject adds the local Origin-State-Id to the message if it has none.
+func (t *Tracker) Inject(message *diameter.Message) {
+	if message.Avps.GetFirst(diameter.AvpOriginStateId, 0) != nil {
+		return
+	}
+	message.Avps = message.Avps.AddUint32(diameter.AvpOriginStateId, diameter.FlagMandatory, 0, t.local)
+}
+
+// Observe records the Origin-State-Id of the message's Origin-Host, calling
+// the restart callback and returning true if it differs from the last one seen.
+func (t *Tracker) Observe(message diameter.Message) bool {
+	originHost, ok := message.Avps.GetFirst(diameter.AvpOriginHost, 0).ToStringOk()
+	if !ok {
+		return false
+	}
+	current, ok := message.Avps.GetFirst(diameter.AvpOriginStateId, 0).ToUint32Ok()
+	if !ok {
+		return false
+	}
+	t.mutex.Lock()
+	previous, seen := t.peers[originHost]
+	t.peers[originHost] = current
+	t.mutex.Unlock()
+	if !seen || previous == current {
+		return false
+	}
+	if t.onRestart != nil {
+		t.onRestart(originHost, previous, current)
+	}
+	return true
+}
+
+// Peer returns the last Origin-State-Id seen from the host.
+func (t *Tracker) Peer(originHost string) (uint32, bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	stateId, ok := t.peers[originHost]
+	return stateId, ok
+}
@@ -0,0 +1,338 @@
+package peerconfig
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/tinybluerobots/radius-diameter-message/diameter"
+	"github.com/tinybluerobots/radius-diameter-message/diameter/capabilities"
+	"github.com/tinybluerobots/radius-diameter-message/diameter/client"
+	"github.com/tinybluerobots/radius-diameter-message/diameter/server"
+	"gopkg.in/yaml.v3"
+)
+
+// Transports supported by peers.
+const (
+	TransportTCP = "tcp"
+	TransportTLS = "tls"
+)
+
+// Config is the configuration of a Diameter node and the peers it connects to.
+type Config struct {
+	Local Local  `json:"local" yaml:"local"`
+	Peers []Peer `json:"peers" yaml:"peers"`
+}
+
+// Local describes the local node.
+type Local struct {
+	OriginHost      string       `json:"originHost" yaml:"originHost"`
+	OriginRealm     string       `json:"originRealm" yaml:"originRealm"`
+	HostIPAddresses []string     `json:"hostIPAddresses" yaml:"hostIPAddresses"`
+	VendorId        uint32       `json:"vendorId" yaml:"vendorId"`
+	ProductName     string       `json:"productName" yaml:"productName"`
+	OriginStateId   uint32       `json:"originStateId" yaml:"originStateId"`
+	Listen          string       `json:"listen" yaml:"listen"`
+	Transport       string       `json:"transport" yaml:"transport"`
+	TLS             *TLS         `json:"tls" yaml:"tls"`
+	Watchdog        Duration     `json:"watchdog" yaml:"watchdog"`
+	Applications    Applications `json:"applications" yaml:"applications"`
+}
+
+// Peer describes a peer the local node connects to.
+type Peer struct {
+	Host      string   `json:"host" yaml:"host"`
+	Realm     string   `json:"realm" yaml:"realm"`
+	Addresses []string `json:"addresses" yaml:"addresses"`
+	Transport string   `json:"transport" yaml:"transport"`
+	TLS       *TLS     `json:"tls" yaml:"tls"`
+	Watchdog  Duration `json:"watchdog" yaml:"watchdog"`
+	// Applications, if set, replaces the local applications advertised to this peer.
+	Applications *Applications `json:"applications" yaml:"applications"`
+}
+
+// Applications lists the applications a node supports.
+type Applications struct {
+	Auth           []uint32                    `json:"auth" yaml:"auth"`
+	Acct           []uint32                    `json:"acct" yaml:"acct"`
+	VendorSpecific []VendorSpecificApplication `json:"vendorSpecific" yaml:"vendorSpecific"`
+}
+
+// VendorSpecificApplication describes a Vendor-Specific-Application-Id.
+type VendorSpecificApplication struct {
+	VendorId uint32 `json:"vendorId" yaml:"vendorId"`
+	Auth     uint32 `json:"auth" yaml:"auth"`
+	Acct     uint32 `json:"acct" yaml:"acct"`
+}
+
+// TLS configures TLS for a listener or a peer connection.
+type TLS struct {
+	CertFile           string `json:"certFile" yaml:"certFile"`
+	KeyFile            string `json:"keyFile" yaml:"keyFile"`
+	CAFile             string `json:"caFile" yaml:"caFile"`
+	ServerName         string `json:"serverName" yaml:"serverName"`
+	InsecureSkipVerify bool   `json:"insecureSkipVerify" yaml:"insecureSkipVerify"`
+}
+
+// Duration is a time.Duration written as a string such as "30s".
+type Duration time.Duration
+
+// UnmarshalText parses the duration.
+func (d *Duration) UnmarshalText(text []byte) error {
+	duration, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(duration)
+	return nil
+}
+
+// MarshalText formats the duration.
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// Load reads the configuration from a file, as YAML if its extension is
+// .yaml or .yml and as JSON otherwise, and validates it.
+func Load(path string) (Config, error) {
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return ParseYAML(bytes)
+	}
+	return ParseJSON(bytes)
+}
+
+// ParseJSON parses and validates a JSON configuration.
+func ParseJSON(bytes []byte) (Config, error) {
+	config := Config{}
+	if err := json.Unmarshal(bytes, &config); err != nil {
+		return Config{}, err
+	}
+	return config, config.Validate()
+}
+
+// ParseYAML parses and validates a YAML configuration.
+func ParseYAML(bytes []byte) (Config, error) {
+	config := Config{}
+	if err := yaml.Unmarshal(bytes, &config); err != nil {
+		return Config{}, err
+	}
+	return config, config.Validate()
+}
+
+// Validate checks the configuration is complete and consistent.
+func (c Config) Validate() error {
+	var errs []error
+	if c.Local.OriginHost == "" {
+		errs = append(errs, errors.New("local: originHost is required"))
+	}
+	if c.Local.OriginRealm == "" {
+		errs = append(errs, errors.New("local: originRealm is required"))
+	}
+	for _, address := range c.Local.HostIPAddresses {
+		if net.ParseIP(address) == nil {
+			errs = append(errs, fmt.Errorf("local: invalid host IP address %q", address))
+		}
+	}
+	if err := validateTransport(c.Local.Transport, c.Local.TLS); err != nil {
+		errs = append(errs, fmt.Errorf("local: %w", err))
+	}
+	if c.Local.Transport == TransportTLS && c.Local.TLS != nil && (c.Local.TLS.CertFile == "" || c.Local.TLS.KeyFile == "") {
+		errs = append(errs, errors.New("local: tls listener requires certFile and keyFile"))
+	}
+	hosts := make(map[string]bool)
+	for i, peer := range c.Peers {
+		if peer.Host == "" {
+			errs = append(errs, fmt.Errorf("peers[%d]: host is required", i))
+		} else if hosts[peer.Host] {
+			errs = append(errs, fmt.Errorf("peers[%d]: duplicate host %q", i, peer.Host))
+		}
+		hosts[peer.Host] = true
+		if len(peer.Addresses) == 0 {
+			errs = append(errs, fmt.Errorf("peers[%d]: at least one address is required", i))
+		}
+		if err := validateTransport(peer.Transport, peer.TLS); err != nil {
+			errs = append(errs, fmt.Errorf("peers[%d]: %w", i, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// validateTransport checks the transport is known and has TLS settings if it needs them.
+func validateTransport(transport string, tlsConfig *TLS) error {
+	switch transport {
+	case "", TransportTCP:
+		return nil
+	case TransportTLS:
+		if tlsConfig == nil {
+			return errors.New("transport tls requires tls settings")
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown transport %q", transport)
+}
+
+// Peer returns the peer with the host.
+func (c Config) Peer(host string) (Peer, bool) {
+	for _, peer := range c.Peers {
+		if peer.Host == host {
+			return peer, true
+		}
+	}
+	return Peer{}, false
+}
+
+// Capabilities returns the capabilities the local node advertises.
+func (l Local) Capabilities() capabilities.Capabilities {
+	result := capabilities.Capabilities{
+		OriginHost:    l.OriginHost,
+		OriginRealm:   l.OriginRealm,
+		VendorId:      diameter.VendorId(l.VendorId),
+		ProductName:   l.ProductName,
+		OriginStateId: l.OriginStateId,
+	}
+	for _, address := range l.HostIPAddresses {
+		result.HostIPAddresses = append(result.HostIPAddresses, net.ParseIP(address))
+	}
+	l.Applications.apply(&result)
+	return result
+}
+
+// apply sets the application IDs of the capabilities.
+func (a Applications) apply(c *capabilities.Capabilities) {
+	c.AuthApplicationIds = nil
+	c.AcctApplicationIds = nil
+	c.VendorSpecificApplicationIds = nil
+	for _, id := range a.Auth {
+		c.AuthApplicationIds = append(c.AuthApplicationIds, diameter.ApplicationId(id))
+	}
+	for _, id := range a.Acct {
+		c.AcctApplicationIds = append(c.AcctApplicationIds, diameter.ApplicationId(id))
+	}
+	for _, application := range a.VendorSpecific {
+		c.VendorSpecificApplicationIds = append(c.VendorSpecificApplicationIds, capabilities.VendorSpecificApplicationId{
+			VendorId:          diameter.VendorId(application.VendorId),
+			AuthApplicationId: diameter.ApplicationId(application.Auth),
+			AcctApplicationId: diameter.ApplicationId(application.Acct),
+		})
+		if !slices.Contains(c.SupportedVendorIds, diameter.VendorId(application.VendorId)) {
+			c.SupportedVendorIds = append(c.SupportedVendorIds, diameter.VendorId(application.VendorId))
+		}
+	}
+}
+
+// ServerConfig returns the server configuration of the local node.
+func (c Config) ServerConfig() server.Config {
+	return server.Config{
+		Capabilities: c.Local.Capabilities(),
+		Tw:           time.Duration(c.Local.Watchdog),
+	}
+}
+
+// Listen opens the listener the local node accepts peers on.
+func (c Config) Listen() (net.Listener, error) {
+	listener, err := net.Listen("tcp", c.Local.Listen)
+	if err != nil {
+		return nil, err
+	}
+	if c.Local.Transport != TransportTLS {
+		return listener, nil
+	}
+	tlsConfig, err := c.Local.TLS.Config(true)
+	if err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return tls.NewListener(listener, tlsConfig), nil
+}
+
+// ClientConfig returns the client configuration for connecting to the peer.
+func (c Config) ClientConfig(peer Peer) client.Config {
+	local := c.Local.Capabilities()
+	if peer.Applications != nil {
+		peer.Applications.apply(&local)
+	}
+	tw := time.Duration(peer.Watchdog)
+	if tw == 0 {
+		tw = time.Duration(c.Local.Watchdog)
+	}
+	return client.Config{Capabilities: local, Tw: tw}
+}
+
+// Dial connects to the first reachable address of the peer and performs the
+// capabilities exchange.
+func (c Config) Dial(ctx context.Context, peer Peer) (*client.Client, error) {
+	var errs []error
+	for _, address := range peer.Addresses {
+		conn, err := peer.dial(ctx, address)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		return client.New(ctx, conn, c.ClientConfig(peer))
+	}
+	return nil, fmt.Errorf("peer %s: %w", peer.Host, errors.Join(errs...))
+}
+
+// dial opens a connection to the address with the peer's transport.
+func (p Peer) dial(ctx context.Context, address string) (net.Conn, error) {
+	if p.Transport != TransportTLS {
+		dialer := net.Dialer{}
+		return dialer.DialContext(ctx, "tcp", address)
+	}
+	tlsConfig, err := p.TLS.Config(false)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig.ServerName == "" {
+		tlsConfig.ServerName = p.Host
+	}
+	dialer := tls.Dialer{Config: tlsConfig}
+	return dialer.DialContext(ctx, "tcp", address)
+}
+
+// Config builds the crypto/tls configuration. For a server, the CA file
+// verifies client certificates; for a client, it verifies the server.
+func (t *TLS) Config(isServer bool) (*tls.Config, error) {
+	if t == nil {
+		return nil, errors.New("tls settings are required")
+	}
+	config := &tls.Config{ServerName: t.ServerName, InsecureSkipVerify: t.InsecureSkipVerify}
+	if t.CertFile != "" || t.KeyFile != "" {
+		certificate, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{certificate}
+	}
+	if t.CAFile != "" {
+		pem, err := os.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates in %s", t.CAFile)
+		}
+		if isServer {
+			config.ClientCAs = pool
+			config.ClientAuth = tls.RequireAndVerifyClientCert
+		} else {
+			config.RootCAs = pool
+		}
+	}
+	return config, nil
+}
@@ -0,0 +1,96 @@
+package diameter
+
+import "sync"
+
+// maxPooledBytes bounds the capacity of the buffers a Pool keeps, so that an
+// occasional large message does not pin its memory.
+const maxPooledBytes = 64 * 1024
+
+// maxPooledAvps bounds the capacity of the AVP slices a Pool keeps.
+const maxPooledAvps = 1024
+
+// Pool recycles messages, AVP slices and encode buffers, so that gateways
+// processing many messages a second can run with few allocations. Values
+// acquired from a Pool must not be used after they are released to it.
+// The zero value is ready to use, a nil *Pool allocates afresh and releases
+// nothing, and a Pool is safe for concurrent use.
+type Pool struct {
+	messages sync.Pool
+	avps     sync.Pool
+	buffers  sync.Pool
+}
+
+// AcquireMessage returns an empty message, whose Avps keep the capacity of a
+// released message.
+func (p *Pool) AcquireMessage() *Message {
+	if p != nil {
+		if message, ok := p.messages.Get().(*Message); ok {
+			return message
+		}
+	}
+	return &Message{Avps: NewAvps()}
+}
+
+// ReleaseMessage returns the message to the pool.
+func (p *Pool) ReleaseMessage(message *Message) {
+	if p == nil || message == nil || cap(message.Avps) > maxPooledAvps {
+		return
+	}
+	clear(message.Avps)
+	*message = Message{Avps: message.Avps[:0]}
+	p.messages.Put(message)
+}
+
+// AcquireAvps returns an empty slice of AVPs to append to.
+func (p *Pool) AcquireAvps() *Avps {
+	if p != nil {
+		if avps, ok := p.avps.Get().(*Avps); ok {
+			return avps
+		}
+	}
+	avps := NewAvps()
+	return &avps
+}
+
+// ReleaseAvps returns the slice of AVPs to the pool.
+func (p *Pool) ReleaseAvps(avps *Avps) {
+	if p == nil || avps == nil || cap(*avps) > maxPooledAvps {
+		return
+	}
+	clear(*avps)
+	*avps = (*avps)[:0]
+	p.avps.Put(avps)
+}
+
+// AcquireBuffer returns an empty buffer to encode a message into with
+// AppendBytes.
+func (p *Pool) AcquireBuffer() *[]byte {
+	if p != nil {
+		if buffer, ok := p.buffers.Get().(*[]byte); ok {
+			return buffer
+		}
+	}
+	buffer := make([]byte, 0, 4096)
+	return &buffer
+}
+
+// ReleaseBuffer returns the buffer to the pool.
+func (p *Pool) ReleaseBuffer(buffer *[]byte) {
+	if p == nil || buffer == nil || cap(*buffer) > maxPooledBytes {
+		return
+	}
+	*buffer = (*buffer)[:0]
+	p.buffers.Put(buffer)
+}
+
+// ReadMessage reads a byte slice into a message acquired from the pool, to
+// be released to it when done with. The data of its AVPs refers to the byte
+// slice, which must not be reused until then.
+func (p *Pool) ReadMessage(bytes []byte) (*Message, error) {
+	message := p.AcquireMessage()
+	if err := readMessage(bytes, message); err != nil {
+		p.ReleaseMessage(message)
+		return nil, err
+	}
+	return message, nil
+}
@@ -0,0 +1,70 @@
+package diameter
+
+import "errors"
+
+// ErrLoopDetected is returned when a Route-Record already contains the identity being added.
+var ErrLoopDetected = errors.New("loop detected")
+
+// HasRouteRecord reports whether a Route-Record AVP contains the identity.
+func (m Message) HasRouteRecord(identity string) bool {
+	for _, avp := range m.Avps.Get(AvpRouteRecord, 0) {
+		if avp.ToStringOrDefault() == identity {
+			return true
+		}
+	}
+	return false
+}
+
+// AddRouteRecord appends a Route-Record AVP with the identity of the relaying
+// node, returning ErrLoopDetected if the identity is already present.
+func (m *Message) AddRouteRecord(identity string) error {
+	if m.HasRouteRecord(identity) {
+		return ErrLoopDetected
+	}
+	m.Avps = m.Avps.AddString(AvpRouteRecord, FlagMandatory, 0, identity)
+	return nil
+}
+
+// StripRouteRecords removes every Route-Record AVP.
+func (m *Message) StripRouteRecords() {
+	m.Avps = m.Avps.remove(AvpRouteRecord, 0)
+}
+
+// PushProxyInfo appends a Proxy-Info AVP with the host and opaque state of the proxying node.
+func (m *Message) PushProxyInfo(host string, state []byte) {
+	m.Avps = m.Avps.AddGroup(AvpProxyInfo, FlagMandatory, 0,
+		NewAvpString(AvpProxyHost, FlagMandatory, 0, host),
+		NewAvp(AvpProxyState, FlagMandatory, 0, state),
+	)
+}
+
+// PopProxyInfo removes the last Proxy-Info AVP, returning its host and state,
+// or false if there is none.
+func (m *Message) PopProxyInfo() (string, []byte, bool) {
+	for i := len(m.Avps) - 1; i >= 0; i-- {
+		avp := m.Avps[i]
+		if avp.Code != AvpProxyInfo || avp.VendorId != 0 {
+			continue
+		}
+		m.Avps = append(m.Avps[:i:i], m.Avps[i+1:]...)
+		group := avp.ToGroup()
+		return group.GetFirst(AvpProxyHost, 0).ToStringOrDefault(), group.GetFirst(AvpProxyState, 0).ToData(), true
+	}
+	return "", nil, false
+}
+
+// StripProxyInfo removes every Proxy-Info AVP.
+func (m *Message) StripProxyInfo() {
+	m.Avps = m.Avps.remove(AvpProxyInfo, 0)
+}
+
+// remove returns a copy of the slice without the AVPs with the given code and vendor ID.
+func (a Avps) remove(code Code, vendorId VendorId) Avps {
+	avps := make(Avps, 0, len(a))
+	for _, avp := range a {
+		if avp.Code != code || avp.VendorId != vendorId {
+			avps = append(avps, avp)
+		}
+	}
+	return avps
+}
@@ -0,0 +1,54 @@
+package diameter
+
+// ResultCode represents the value of a Diameter Result-Code AVP.
+type ResultCode uint32
+
+const (
+	ResultCodeMultiRoundAuth ResultCode = 1001
+
+	ResultCodeSuccess        ResultCode = 2001
+	ResultCodeLimitedSuccess ResultCode = 2002
+
+	ResultCodeCommandUnsupported     ResultCode = 3001
+	ResultCodeUnableToDeliver        ResultCode = 3002
+	ResultCodeRealmNotServed         ResultCode = 3003
+	ResultCodeTooBusy                ResultCode = 3004
+	ResultCodeLoopDetected           ResultCode = 3005
+	ResultCodeRedirectIndication     ResultCode = 3006
+	ResultCodeApplicationUnsupported ResultCode = 3007
+	ResultCodeInvalidHdrBits         ResultCode = 3008
+	ResultCodeInvalidAvpBits         ResultCode = 3009
+	ResultCodeUnknownPeer            ResultCode = 3010
+
+	ResultCodeAuthenticationRejected ResultCode = 4001
+	ResultCodeOutOfSpace             ResultCode = 4002
+	ResultCodeElectionLost           ResultCode = 4003
+
+	ResultCodeAvpUnsupported        ResultCode = 5001
+	ResultCodeUnknownSessionId      ResultCode = 5002
+	ResultCodeAuthorizationRejected ResultCode = 5003
+	ResultCodeInvalidAvpValue       ResultCode = 5004
+	ResultCodeMissingAvp            ResultCode = 5005
+	ResultCodeResourcesExceeded     ResultCode = 5006
+	ResultCodeContradictingAvps     ResultCode = 5007
+	ResultCodeAvpNotAllowed         ResultCode = 5008
+	ResultCodeAvpOccursTooManyTimes ResultCode = 5009
+	ResultCodeNoCommonApplication   ResultCode = 5010
+	ResultCodeUnsupportedVersion    ResultCode = 5011
+	ResultCodeUnableToComply        ResultCode = 5012
+	ResultCodeInvalidBitInHeader    ResultCode = 5013
+	ResultCodeInvalidAvpLength      ResultCode = 5014
+	ResultCodeInvalidMessageLength  ResultCode = 5015
+	ResultCodeInvalidAvpBitCombo    ResultCode = 5016
+	ResultCodeNoCommonSecurity      ResultCode = 5017
+)
+
+// IsSuccess reports whether the result code is in the 2xxx success class.
+func (r ResultCode) IsSuccess() bool {
+	return r >= 2000 && r < 3000
+}
+
+// IsProtocolError reports whether the result code is in the 3xxx protocol error class.
+func (r ResultCode) IsProtocolError() bool {
+	return r >= 3000 && r < 4000
+}
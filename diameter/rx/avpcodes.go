@@ -0,0 +1,28 @@
+package rx
+
+import "github.com/tinybluerobots/radius-diameter-message/diameter"
+
+// Rx AVP codes (3GPP TS 29.214), all with the 3GPP vendor ID.
+const (
+	AvpAFApplicationIdentifier   diameter.Code = 504
+	AvpAFChargingIdentifier      diameter.Code = 505
+	AvpFlowDescription           diameter.Code = 507
+	AvpFlowNumber                diameter.Code = 509
+	AvpFlowStatus                diameter.Code = 511
+	AvpFlowUsage                 diameter.Code = 512
+	AvpSpecificAction            diameter.Code = 513
+	AvpMaxRequestedBandwidthDL   diameter.Code = 515
+	AvpMaxRequestedBandwidthUL   diameter.Code = 516
+	AvpMediaComponentDescription diameter.Code = 517
+	AvpMediaComponentNumber      diameter.Code = 518
+	AvpMediaSubComponent         diameter.Code = 519
+	AvpMediaType                 diameter.Code = 520
+	AvpRRBandwidth               diameter.Code = 521
+	AvpRSBandwidth               diameter.Code = 522
+	AvpCodecData                 diameter.Code = 524
+)
+
+// NASREQ AVP codes used in AAR (RFC 7155), with no vendor ID.
+const (
+	AvpFramedIPAddress diameter.Code = 8
+)
@@ -0,0 +1,184 @@
+package rx
+
+import "github.com/tinybluerobots/radius-diameter-message/diameter"
+
+const mandatoryFlags = diameter.FlagMandatory
+
+// MediaType represents the value of a Media-Type AVP.
+type MediaType uint32
+
+const (
+	Audio       MediaType = 0
+	Video       MediaType = 1
+	Data        MediaType = 2
+	Application MediaType = 3
+	Control     MediaType = 4
+	Text        MediaType = 5
+	Message     MediaType = 6
+	Other       MediaType = 0xffffffff
+)
+
+// FlowStatus represents the value of a Flow-Status AVP.
+type FlowStatus uint32
+
+const (
+	EnabledUplink   FlowStatus = 0
+	EnabledDownlink FlowStatus = 1
+	Enabled         FlowStatus = 2
+	Disabled        FlowStatus = 3
+	Removed         FlowStatus = 4
+)
+
+// String returns the TS 29.214 name of the flow status.
+func (f FlowStatus) String() string {
+	switch f {
+	case EnabledUplink:
+		return "ENABLED-UPLINK"
+	case EnabledDownlink:
+		return "ENABLED-DOWNLINK"
+	case Enabled:
+		return "ENABLED"
+	case Disabled:
+		return "DISABLED"
+	case Removed:
+		return "REMOVED"
+	}
+	return "UNKNOWN"
+}
+
+// FlowUsage represents the value of a Flow-Usage AVP.
+type FlowUsage uint32
+
+const (
+	NoInformation FlowUsage = 0
+	RTCP          FlowUsage = 1
+	AFSignalling  FlowUsage = 2
+)
+
+// MediaSubComponent represents a Media-Sub-Component AVP. Nil pointers and
+// zero bandwidths are omitted from messages.
+type MediaSubComponent struct {
+	FlowNumber              uint32
+	FlowDescriptions        []string
+	FlowStatus              *FlowStatus
+	FlowUsage               *FlowUsage
+	MaxRequestedBandwidthUL uint32
+	MaxRequestedBandwidthDL uint32
+}
+
+// ToAvp converts the Media-Sub-Component to a grouped AVP.
+func (m MediaSubComponent) ToAvp() diameter.Avp {
+	avps := diameter.NewAvps().AddUint32(AvpFlowNumber, mandatoryFlags, diameter.Vendor3GPP, m.FlowNumber)
+	for _, flowDescription := range m.FlowDescriptions {
+		avps = avps.AddString(AvpFlowDescription, mandatoryFlags, diameter.Vendor3GPP, flowDescription)
+	}
+	if m.FlowStatus != nil {
+		avps = avps.AddUint32(AvpFlowStatus, mandatoryFlags, diameter.Vendor3GPP, uint32(*m.FlowStatus))
+	}
+	if m.FlowUsage != nil {
+		avps = avps.AddUint32(AvpFlowUsage, mandatoryFlags, diameter.Vendor3GPP, uint32(*m.FlowUsage))
+	}
+	avps = addUint32(avps, AvpMaxRequestedBandwidthUL, m.MaxRequestedBandwidthUL)
+	avps = addUint32(avps, AvpMaxRequestedBandwidthDL, m.MaxRequestedBandwidthDL)
+	return diameter.NewAvpGroup(AvpMediaSubComponent, mandatoryFlags, diameter.Vendor3GPP, avps...)
+}
+
+// FromAvp reads the Media-Sub-Component from a grouped AVP.
+func (m *MediaSubComponent) FromAvp(avp *diameter.Avp) {
+	group := avp.ToGroup()
+	m.FlowNumber = group.GetFirst(AvpFlowNumber, diameter.Vendor3GPP).ToUint32OrDefault()
+	m.FlowDescriptions = nil
+	for _, flowDescription := range group.Get(AvpFlowDescription, diameter.Vendor3GPP) {
+		m.FlowDescriptions = append(m.FlowDescriptions, flowDescription.ToStringOrDefault())
+	}
+	m.FlowStatus = nil
+	if flowStatus, ok := group.GetFirst(AvpFlowStatus, diameter.Vendor3GPP).ToUint32Ok(); ok {
+		m.FlowStatus = (*FlowStatus)(&flowStatus)
+	}
+	m.FlowUsage = nil
+	if flowUsage, ok := group.GetFirst(AvpFlowUsage, diameter.Vendor3GPP).ToUint32Ok(); ok {
+		m.FlowUsage = (*FlowUsage)(&flowUsage)
+	}
+	m.MaxRequestedBandwidthUL = group.GetFirst(AvpMaxRequestedBandwidthUL, diameter.Vendor3GPP).ToUint32OrDefault()
+	m.MaxRequestedBandwidthDL = group.GetFirst(AvpMaxRequestedBandwidthDL, diameter.Vendor3GPP).ToUint32OrDefault()
+}
+
+// MediaComponentDescription represents a Media-Component-Description AVP.
+// Nil pointers and slices and zero bandwidths are omitted from messages.
+type MediaComponentDescription struct {
+	Number                  uint32
+	SubComponents           []MediaSubComponent
+	AFApplicationIdentifier []byte
+	MediaType               *MediaType
+	MaxRequestedBandwidthUL uint32
+	MaxRequestedBandwidthDL uint32
+	FlowStatus              *FlowStatus
+	RRBandwidth             uint32
+	RSBandwidth             uint32
+	CodecData               [][]byte
+}
+
+// ToAvp converts the Media-Component-Description to a grouped AVP.
+func (m MediaComponentDescription) ToAvp() diameter.Avp {
+	avps := diameter.NewAvps().AddUint32(AvpMediaComponentNumber, mandatoryFlags, diameter.Vendor3GPP, m.Number)
+	for _, subComponent := range m.SubComponents {
+		avps = append(avps, subComponent.ToAvp())
+	}
+	if m.AFApplicationIdentifier != nil {
+		avps = avps.Add(AvpAFApplicationIdentifier, mandatoryFlags, diameter.Vendor3GPP, m.AFApplicationIdentifier)
+	}
+	if m.MediaType != nil {
+		avps = avps.AddUint32(AvpMediaType, mandatoryFlags, diameter.Vendor3GPP, uint32(*m.MediaType))
+	}
+	avps = addUint32(avps, AvpMaxRequestedBandwidthUL, m.MaxRequestedBandwidthUL)
+	avps = addUint32(avps, AvpMaxRequestedBandwidthDL, m.MaxRequestedBandwidthDL)
+	if m.FlowStatus != nil {
+		avps = avps.AddUint32(AvpFlowStatus, mandatoryFlags, diameter.Vendor3GPP, uint32(*m.FlowStatus))
+	}
+	avps = addUint32(avps, AvpRRBandwidth, m.RRBandwidth)
+	avps = addUint32(avps, AvpRSBandwidth, m.RSBandwidth)
+	for _, codecData := range m.CodecData {
+		avps = avps.Add(AvpCodecData, mandatoryFlags, diameter.Vendor3GPP, codecData)
+	}
+	return diameter.NewAvpGroup(AvpMediaComponentDescription, mandatoryFlags, diameter.Vendor3GPP, avps...)
+}
+
+// FromAvp reads the Media-Component-Description from a grouped AVP.
+func (m *MediaComponentDescription) FromAvp(avp *diameter.Avp) {
+	group := avp.ToGroup()
+	m.Number = group.GetFirst(AvpMediaComponentNumber, diameter.Vendor3GPP).ToUint32OrDefault()
+	m.SubComponents = nil
+	for _, subComponentAvp := range group.Get(AvpMediaSubComponent, diameter.Vendor3GPP) {
+		subComponent := MediaSubComponent{}
+		subComponent.FromAvp(&subComponentAvp)
+		m.SubComponents = append(m.SubComponents, subComponent)
+	}
+	m.AFApplicationIdentifier = nil
+	if afApplicationIdentifier := group.GetFirst(AvpAFApplicationIdentifier, diameter.Vendor3GPP); afApplicationIdentifier != nil {
+		m.AFApplicationIdentifier = afApplicationIdentifier.ToData()
+	}
+	m.MediaType = nil
+	if mediaType, ok := group.GetFirst(AvpMediaType, diameter.Vendor3GPP).ToUint32Ok(); ok {
+		m.MediaType = (*MediaType)(&mediaType)
+	}
+	m.MaxRequestedBandwidthUL = group.GetFirst(AvpMaxRequestedBandwidthUL, diameter.Vendor3GPP).ToUint32OrDefault()
+	m.MaxRequestedBandwidthDL = group.GetFirst(AvpMaxRequestedBandwidthDL, diameter.Vendor3GPP).ToUint32OrDefault()
+	m.FlowStatus = nil
+	if flowStatus, ok := group.GetFirst(AvpFlowStatus, diameter.Vendor3GPP).ToUint32Ok(); ok {
+		m.FlowStatus = (*FlowStatus)(&flowStatus)
+	}
+	m.RRBandwidth = group.GetFirst(AvpRRBandwidth, diameter.Vendor3GPP).ToUint32OrDefault()
+	m.RSBandwidth = group.GetFirst(AvpRSBandwidth, diameter.Vendor3GPP).ToUint32OrDefault()
+	m.CodecData = nil
+	for _, codecData := range group.Get(AvpCodecData, diameter.Vendor3GPP) {
+		m.CodecData = append(m.CodecData, codecData.ToData())
+	}
+}
+
+// addUint32 adds a 3GPP uint32 AVP if the value is not zero.
+func addUint32(avps diameter.Avps, code diameter.Code, value uint32) diameter.Avps {
+	if value == 0 {
+		return avps
+	}
+	return avps.AddUint32(code, mandatoryFlags, diameter.Vendor3GPP, value)
+}
@@ -0,0 +1,202 @@
+package rx
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/tinybluerobots/radius-diameter-message/diameter"
+)
+
+// SpecificAction represents the value of a Specific-Action AVP.
+type SpecificAction uint32
+
+const (
+	ChargingCorrelationExchange               SpecificAction = 1
+	IndicationOfLossOfBearer                  SpecificAction = 2
+	IndicationOfRecoveryOfBearer              SpecificAction = 3
+	IndicationOfReleaseOfBearer               SpecificAction = 4
+	IPCANChange                               SpecificAction = 6
+	IndicationOfOutOfCredit                   SpecificAction = 7
+	IndicationOfSuccessfulResourcesAllocation SpecificAction = 8
+	IndicationOfFailedResourcesAllocation     SpecificAction = 9
+	IndicationOfLimitedPCCDeployment          SpecificAction = 10
+	UsageReport                               SpecificAction = 11
+	AccessNetworkInfoReport                   SpecificAction = 12
+)
+
+var specificActionNames = map[SpecificAction]string{
+	ChargingCorrelationExchange:               "CHARGING_CORRELATION_EXCHANGE",
+	IndicationOfLossOfBearer:                  "INDICATION_OF_LOSS_OF_BEARER",
+	IndicationOfRecoveryOfBearer:              "INDICATION_OF_RECOVERY_OF_BEARER",
+	IndicationOfReleaseOfBearer:               "INDICATION_OF_RELEASE_OF_BEARER",
+	IPCANChange:                               "IP-CAN_CHANGE",
+	IndicationOfOutOfCredit:                   "INDICATION_OF_OUT_OF_CREDIT",
+	IndicationOfSuccessfulResourcesAllocation: "INDICATION_OF_SUCCESSFUL_RESOURCES_ALLOCATION",
+	IndicationOfFailedResourcesAllocation:     "INDICATION_OF_FAILED_RESOURCES_ALLOCATION",
+	IndicationOfLimitedPCCDeployment:          "INDICATION_OF_LIMITED_PCC_DEPLOYMENT",
+	UsageReport:                               "USAGE_REPORT",
+	AccessNetworkInfoReport:                   "ACCESS_NETWORK_INFO_REPORT",
+}
+
+// String returns the TS 29.214 name of the specific action.
+func (s SpecificAction) String() string {
+	if name, ok := specificActionNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("UNKNOWN(%d)", uint32(s))
+}
+
+// SpecificActions returns the values of the Specific-Action AVPs, as found
+// in an AAR or in a RAR from the PCRF.
+func SpecificActions(avps diameter.Avps) []SpecificAction {
+	var actions []SpecificAction
+	for _, avp := range avps.Get(AvpSpecificAction, diameter.Vendor3GPP) {
+		actions = append(actions, SpecificAction(avp.ToUint32OrDefault()))
+	}
+	return actions
+}
+
+// AAR represents an Rx AA-Request. AVPs without a field, such as
+// Subscription-Id, are carried in Avps and appended after the others, as is
+// a Framed-IP-Address that is not an IPv4 address.
+type AAR struct {
+	SessionId                  string
+	OriginHost                 string
+	OriginRealm                string
+	DestinationRealm           string
+	DestinationHost            string
+	AFApplicationIdentifier    []byte
+	MediaComponentDescriptions []MediaComponentDescription
+	AFChargingIdentifier       []byte
+	SpecificActions            []SpecificAction
+	FramedIPAddress            net.IP
+	Avps                       diameter.Avps
+}
+
+// aarCodes are the AVP codes AAR has fields for, other than 3GPP ones and
+// Framed-IP-Address, which has one only when it holds an IPv4 address.
+var aarCodes = []diameter.Code{
+	diameter.AvpSessionId, diameter.AvpAuthApplicationId, diameter.AvpOriginHost, diameter.AvpOriginRealm,
+	diameter.AvpDestinationRealm, diameter.AvpDestinationHost,
+}
+
+// aar3GPPCodes are the 3GPP AVP codes AAR has fields for.
+var aar3GPPCodes = []diameter.Code{
+	AvpAFApplicationIdentifier, AvpMediaComponentDescription, AvpAFChargingIdentifier, AvpSpecificAction,
+}
+
+// ToMessage converts the AAR to a Diameter message.
+func (a AAR) ToMessage(hopByHopId [4]byte, endToEndId [4]byte) diameter.Message {
+	avps := diameter.NewAvps()
+	avps = avps.AddString(diameter.AvpSessionId, mandatoryFlags, 0, a.SessionId)
+	avps = avps.AddUint32(diameter.AvpAuthApplicationId, mandatoryFlags, 0, uint32(diameter.ApplicationRx))
+	avps = avps.AddString(diameter.AvpOriginHost, mandatoryFlags, 0, a.OriginHost)
+	avps = avps.AddString(diameter.AvpOriginRealm, mandatoryFlags, 0, a.OriginRealm)
+	avps = avps.AddString(diameter.AvpDestinationRealm, mandatoryFlags, 0, a.DestinationRealm)
+	if a.DestinationHost != "" {
+		avps = avps.AddString(diameter.AvpDestinationHost, mandatoryFlags, 0, a.DestinationHost)
+	}
+	if a.AFApplicationIdentifier != nil {
+		avps = avps.Add(AvpAFApplicationIdentifier, mandatoryFlags, diameter.Vendor3GPP, a.AFApplicationIdentifier)
+	}
+	for _, mediaComponentDescription := range a.MediaComponentDescriptions {
+		avps = append(avps, mediaComponentDescription.ToAvp())
+	}
+	if a.AFChargingIdentifier != nil {
+		avps = avps.Add(AvpAFChargingIdentifier, mandatoryFlags, diameter.Vendor3GPP, a.AFChargingIdentifier)
+	}
+	for _, action := range a.SpecificActions {
+		avps = avps.AddUint32(AvpSpecificAction, mandatoryFlags, diameter.Vendor3GPP, uint32(action))
+	}
+	if ipv4 := a.FramedIPAddress.To4(); ipv4 != nil {
+		avps = avps.Add(AvpFramedIPAddress, mandatoryFlags, 0, []byte(ipv4))
+	}
+	avps = append(avps, a.Avps...)
+	return diameter.NewMessage(1, diameter.FlagRequest|diameter.FlagProxiable, diameter.CommandAA, diameter.ApplicationRx, hopByHopId, endToEndId, avps...)
+}
+
+// FromMessage reads an AAR from a Diameter message.
+func (a *AAR) FromMessage(message diameter.Message) error {
+	if message.CommandCode != diameter.CommandAA || !message.IsRequest() {
+		return errors.New("message is not an AA-Request")
+	}
+	avps := message.Avps
+	a.SessionId = avps.GetFirst(diameter.AvpSessionId, 0).ToStringOrDefault()
+	a.OriginHost = avps.GetFirst(diameter.AvpOriginHost, 0).ToStringOrDefault()
+	a.OriginRealm = avps.GetFirst(diameter.AvpOriginRealm, 0).ToStringOrDefault()
+	a.DestinationRealm = avps.GetFirst(diameter.AvpDestinationRealm, 0).ToStringOrDefault()
+	a.DestinationHost = avps.GetFirst(diameter.AvpDestinationHost, 0).ToStringOrDefault()
+	a.AFApplicationIdentifier = nil
+	if afApplicationIdentifier := avps.GetFirst(AvpAFApplicationIdentifier, diameter.Vendor3GPP); afApplicationIdentifier != nil {
+		a.AFApplicationIdentifier = afApplicationIdentifier.ToData()
+	}
+	a.MediaComponentDescriptions = nil
+	for _, avp := range avps.Get(AvpMediaComponentDescription, diameter.Vendor3GPP) {
+		mediaComponentDescription := MediaComponentDescription{}
+		mediaComponentDescription.FromAvp(&avp)
+		a.MediaComponentDescriptions = append(a.MediaComponentDescriptions, mediaComponentDescription)
+	}
+	a.AFChargingIdentifier = nil
+	if afChargingIdentifier := avps.GetFirst(AvpAFChargingIdentifier, diameter.Vendor3GPP); afChargingIdentifier != nil {
+		a.AFChargingIdentifier = afChargingIdentifier.ToData()
+	}
+	a.SpecificActions = SpecificActions(avps)
+	a.FramedIPAddress = nil
+	if framedIPAddress := avps.GetFirst(AvpFramedIPAddress, 0).ToData(); len(framedIPAddress) == net.IPv4len {
+		a.FramedIPAddress = net.IP(framedIPAddress)
+	}
+	a.Avps = avps.Without(0, aarCodes...).Without(diameter.Vendor3GPP, aar3GPPCodes...)
+	if a.FramedIPAddress != nil {
+		a.Avps = a.Avps.Without(0, AvpFramedIPAddress)
+	}
+	return nil
+}
+
+// AAA represents an Rx AA-Answer. AVPs without a field, such as
+// Experimental-Result, are carried in Avps and appended after the others.
+type AAA struct {
+	ResultCode   diameter.ResultCode
+	OriginHost   string
+	OriginRealm  string
+	ErrorMessage string
+	Avps         diameter.Avps
+}
+
+// aaaCodes are the AVP codes AAA has fields for.
+var aaaCodes = []diameter.Code{
+	diameter.AvpSessionId, diameter.AvpAuthApplicationId, diameter.AvpResultCode, diameter.AvpOriginHost,
+	diameter.AvpOriginRealm, diameter.AvpErrorMessage, diameter.AvpProxyInfo,
+}
+
+// ToMessage converts the AAA to a Diameter message answering the request.
+// The Result-Code is omitted if it is zero, for answers that carry an
+// Experimental-Result instead.
+func (a AAA) ToMessage(request diameter.Message) diameter.Message {
+	avps := diameter.NewAvps()
+	avps = avps.AddUint32(diameter.AvpAuthApplicationId, mandatoryFlags, 0, uint32(diameter.ApplicationRx))
+	avps = avps.AddString(diameter.AvpOriginHost, mandatoryFlags, 0, a.OriginHost)
+	avps = avps.AddString(diameter.AvpOriginRealm, mandatoryFlags, 0, a.OriginRealm)
+	if a.ResultCode != 0 {
+		avps = avps.AddUint32(diameter.AvpResultCode, mandatoryFlags, 0, uint32(a.ResultCode))
+	}
+	if a.ErrorMessage != "" {
+		avps = avps.AddString(diameter.AvpErrorMessage, 0, 0, a.ErrorMessage)
+	}
+	avps = append(avps, a.Avps...)
+	return request.NewAnswer(avps...)
+}
+
+// FromMessage reads an AAA from a Diameter message.
+func (a *AAA) FromMessage(message diameter.Message) error {
+	if message.CommandCode != diameter.CommandAA || message.IsRequest() {
+		return errors.New("message is not an AA-Answer")
+	}
+	avps := message.Avps
+	a.ResultCode = diameter.ResultCode(avps.GetFirst(diameter.AvpResultCode, 0).ToUint32OrDefault())
+	a.OriginHost = avps.GetFirst(diameter.AvpOriginHost, 0).ToStringOrDefault()
+	a.OriginRealm = avps.GetFirst(diameter.AvpOriginRealm, 0).ToStringOrDefault()
+	a.ErrorMessage = avps.GetFirst(diameter.AvpErrorMessage, 0).ToStringOrDefault()
+	a.Avps = avps.Without(0, aaaCodes...)
+	return nil
+}
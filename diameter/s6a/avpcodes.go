@@ -0,0 +1,45 @@
+package s6a
+
+import "github.com/tinybluerobots/radius-diameter-message/diameter"
+
+// S6a AVP codes (3GPP TS 29.272 and TS 29.212), all with the 3GPP vendor ID.
+const (
+	AvpMSISDN                                diameter.Code = 701
+	AvpServedPartyIPAddress                  diameter.Code = 848
+	AvpMaxRequestedBandwidthDL               diameter.Code = 515
+	AvpMaxRequestedBandwidthUL               diameter.Code = 516
+	AvpQoSClassIdentifier                    diameter.Code = 1028
+	AvpRATType                               diameter.Code = 1032
+	AvpAllocationRetentionPriority           diameter.Code = 1034
+	AvpSubscriptionData                      diameter.Code = 1400
+	AvpULRFlags                              diameter.Code = 1405
+	AvpULAFlags                              diameter.Code = 1406
+	AvpVisitedPLMNId                         diameter.Code = 1407
+	AvpRequestedEUTRANAuthenticationInfo     diameter.Code = 1408
+	AvpNumberOfRequestedVectors              diameter.Code = 1410
+	AvpReSynchronizationInfo                 diameter.Code = 1411
+	AvpImmediateResponsePreferred            diameter.Code = 1412
+	AvpAuthenticationInfo                    diameter.Code = 1413
+	AvpEUTRANVector                          diameter.Code = 1414
+	AvpNetworkAccessMode                     diameter.Code = 1417
+	AvpContextIdentifier                     diameter.Code = 1423
+	AvpSubscriberStatus                      diameter.Code = 1424
+	AvpAccessRestrictionData                 diameter.Code = 1426
+	AvpAPNOIReplacement                      diameter.Code = 1427
+	AvpAllAPNConfigurationsIncludedIndicator diameter.Code = 1428
+	AvpAPNConfigurationProfile               diameter.Code = 1429
+	AvpAPNConfiguration                      diameter.Code = 1430
+	AvpEPSSubscribedQoSProfile               diameter.Code = 1431
+	AvpAMBR                                  diameter.Code = 1435
+	AvpRAND                                  diameter.Code = 1447
+	AvpXRES                                  diameter.Code = 1448
+	AvpAUTN                                  diameter.Code = 1449
+	AvpKASME                                 diameter.Code = 1450
+	AvpPDNType                               diameter.Code = 1456
+	AvpSubscribedPeriodicRAUTAUTimer         diameter.Code = 1619
+)
+
+// Base AVP codes used in APN-Configuration (RFC 5778), with no vendor ID.
+const (
+	AvpServiceSelection diameter.Code = 493
+)
@@ -0,0 +1,328 @@
+package s6a
+
+import (
+	"errors"
+
+	"github.com/tinybluerobots/radius-diameter-message/diameter"
+)
+
+const mandatoryFlags = diameter.FlagMandatory
+
+// noStateMaintained is the Auth-Session-State S6a sessions use.
+const noStateMaintained = 1
+
+// RATTypeEUTRAN is the RAT-Type of E-UTRAN access.
+const RATTypeEUTRAN = 1004
+
+// ULR-Flags bits.
+const (
+	SingleRegistrationIndication uint32 = 1 << iota
+	S6aS6dIndicator
+	SkipSubscriberData
+	GPRSSubscriptionDataIndicator
+	NodeTypeIndicator
+	InitialAttachIndicator
+	PSLCSNotSupportedByUE
+)
+
+// ULA-Flags bits.
+const (
+	SeparationIndication uint32 = 1 << iota
+	MMERegisteredForSMS
+)
+
+// Experimental-Result-Code values (3GPP TS 29.272).
+const (
+	ErrorAuthenticationDataUnavailable uint32 = 4181
+	ErrorUserUnknown                   uint32 = 5001
+	ErrorRoamingNotAllowed             uint32 = 5004
+	ErrorUnknownEPSSubscription        uint32 = 5420
+	ErrorRATNotAllowed                 uint32 = 5421
+	ErrorEquipmentUnknown              uint32 = 5422
+)
+
+// ExperimentalResult represents an Experimental-Result AVP.
+type ExperimentalResult struct {
+	VendorId diameter.VendorId
+	Code     uint32
+}
+
+// Header holds the AVPs common to S6a requests.
+type Header struct {
+	SessionId        string
+	OriginHost       string
+	OriginRealm      string
+	DestinationHost  string
+	DestinationRealm string
+	UserName         string
+}
+
+// toAvps converts the header to AVPs in the order of the TS 29.272 CCFs.
+func (h Header) toAvps() diameter.Avps {
+	avps := diameter.NewAvps()
+	avps = avps.AddString(diameter.AvpSessionId, mandatoryFlags, 0, h.SessionId)
+	avps = avps.AddGroup(diameter.AvpVendorSpecificApplicationId, mandatoryFlags, 0,
+		diameter.NewAvpUint32(diameter.AvpVendorId, mandatoryFlags, 0, uint32(diameter.Vendor3GPP)),
+		diameter.NewAvpUint32(diameter.AvpAuthApplicationId, mandatoryFlags, 0, uint32(diameter.ApplicationS6a)))
+	avps = avps.AddUint32(diameter.AvpAuthSessionState, mandatoryFlags, 0, noStateMaintained)
+	avps = avps.AddString(diameter.AvpOriginHost, mandatoryFlags, 0, h.OriginHost)
+	avps = avps.AddString(diameter.AvpOriginRealm, mandatoryFlags, 0, h.OriginRealm)
+	if h.DestinationHost != "" {
+		avps = avps.AddString(diameter.AvpDestinationHost, mandatoryFlags, 0, h.DestinationHost)
+	}
+	avps = avps.AddString(diameter.AvpDestinationRealm, mandatoryFlags, 0, h.DestinationRealm)
+	avps = avps.AddString(diameter.AvpUserName, mandatoryFlags, 0, h.UserName)
+	return avps
+}
+
+// fromAvps reads the header from AVPs.
+func (h *Header) fromAvps(avps diameter.Avps) {
+	h.SessionId = avps.GetFirst(diameter.AvpSessionId, 0).ToStringOrDefault()
+	h.OriginHost = avps.GetFirst(diameter.AvpOriginHost, 0).ToStringOrDefault()
+	h.OriginRealm = avps.GetFirst(diameter.AvpOriginRealm, 0).ToStringOrDefault()
+	h.DestinationHost = avps.GetFirst(diameter.AvpDestinationHost, 0).ToStringOrDefault()
+	h.DestinationRealm = avps.GetFirst(diameter.AvpDestinationRealm, 0).ToStringOrDefault()
+	h.UserName = avps.GetFirst(diameter.AvpUserName, 0).ToStringOrDefault()
+}
+
+// headerCodes are the AVP codes of the request header.
+var headerCodes = []diameter.Code{
+	diameter.AvpSessionId, diameter.AvpVendorSpecificApplicationId, diameter.AvpAuthSessionState,
+	diameter.AvpOriginHost, diameter.AvpOriginRealm, diameter.AvpDestinationHost, diameter.AvpDestinationRealm,
+	diameter.AvpUserName,
+}
+
+// Result holds the AVPs common to S6a answers. Exactly one of ResultCode
+// and ExperimentalResult should be set.
+type Result struct {
+	ResultCode         diameter.ResultCode
+	ExperimentalResult *ExperimentalResult
+	OriginHost         string
+	OriginRealm        string
+}
+
+// toAvps converts the result to AVPs in the order of the TS 29.272 CCFs.
+func (r Result) toAvps() diameter.Avps {
+	avps := diameter.NewAvps()
+	avps = avps.AddGroup(diameter.AvpVendorSpecificApplicationId, mandatoryFlags, 0,
+		diameter.NewAvpUint32(diameter.AvpVendorId, mandatoryFlags, 0, uint32(diameter.Vendor3GPP)),
+		diameter.NewAvpUint32(diameter.AvpAuthApplicationId, mandatoryFlags, 0, uint32(diameter.ApplicationS6a)))
+	if r.ResultCode != 0 {
+		avps = avps.AddUint32(diameter.AvpResultCode, mandatoryFlags, 0, uint32(r.ResultCode))
+	}
+	if r.ExperimentalResult != nil {
+		avps = avps.AddGroup(diameter.AvpExperimentalResult, mandatoryFlags, 0,
+			diameter.NewAvpUint32(diameter.AvpVendorId, mandatoryFlags, 0, uint32(r.ExperimentalResult.VendorId)),
+			diameter.NewAvpUint32(diameter.AvpExperimentalResultCode, mandatoryFlags, 0, r.ExperimentalResult.Code))
+	}
+	avps = avps.AddUint32(diameter.AvpAuthSessionState, mandatoryFlags, 0, noStateMaintained)
+	avps = avps.AddString(diameter.AvpOriginHost, mandatoryFlags, 0, r.OriginHost)
+	avps = avps.AddString(diameter.AvpOriginRealm, mandatoryFlags, 0, r.OriginRealm)
+	return avps
+}
+
+// fromAvps reads the result from AVPs.
+func (r *Result) fromAvps(avps diameter.Avps) {
+	r.ResultCode = diameter.ResultCode(avps.GetFirst(diameter.AvpResultCode, 0).ToUint32OrDefault())
+	r.ExperimentalResult = nil
+	if experimentalResult := avps.GetFirst(diameter.AvpExperimentalResult, 0); experimentalResult != nil {
+		group := experimentalResult.ToGroup()
+		r.ExperimentalResult = &ExperimentalResult{
+			VendorId: diameter.VendorId(group.GetFirst(diameter.AvpVendorId, 0).ToUint32OrDefault()),
+			Code:     group.GetFirst(diameter.AvpExperimentalResultCode, 0).ToUint32OrDefault(),
+		}
+	}
+	r.OriginHost = avps.GetFirst(diameter.AvpOriginHost, 0).ToStringOrDefault()
+	r.OriginRealm = avps.GetFirst(diameter.AvpOriginRealm, 0).ToStringOrDefault()
+}
+
+// resultCodes are the AVP codes of the answer result.
+var resultCodes = []diameter.Code{
+	diameter.AvpSessionId, diameter.AvpVendorSpecificApplicationId, diameter.AvpResultCode,
+	diameter.AvpExperimentalResult, diameter.AvpAuthSessionState, diameter.AvpOriginHost,
+	diameter.AvpOriginRealm, diameter.AvpProxyInfo,
+}
+
+// ULR represents an Update-Location-Request. AVPs without a field are
+// carried in Avps and appended after the others.
+type ULR struct {
+	Header
+	RATType       uint32
+	ULRFlags      uint32
+	VisitedPLMNId []byte
+	Avps          diameter.Avps
+}
+
+// ToMessage converts the ULR to a Diameter message.
+func (u ULR) ToMessage(hopByHopId [4]byte, endToEndId [4]byte) diameter.Message {
+	avps := u.Header.toAvps()
+	avps = avps.AddUint32(AvpRATType, mandatoryFlags, diameter.Vendor3GPP, u.RATType)
+	avps = avps.AddUint32(AvpULRFlags, mandatoryFlags, diameter.Vendor3GPP, u.ULRFlags)
+	avps = avps.Add(AvpVisitedPLMNId, mandatoryFlags, diameter.Vendor3GPP, u.VisitedPLMNId)
+	avps = append(avps, u.Avps...)
+	return diameter.NewMessage(1, diameter.FlagRequest|diameter.FlagProxiable, diameter.CommandUpdateLocation, diameter.ApplicationS6a, hopByHopId, endToEndId, avps...)
+}
+
+// FromMessage reads a ULR from a Diameter message.
+func (u *ULR) FromMessage(message diameter.Message) error {
+	if message.CommandCode != diameter.CommandUpdateLocation || !message.IsRequest() {
+		return errors.New("message is not an Update-Location-Request")
+	}
+	u.Header.fromAvps(message.Avps)
+	u.RATType = message.Avps.GetFirst(AvpRATType, diameter.Vendor3GPP).ToUint32OrDefault()
+	u.ULRFlags = message.Avps.GetFirst(AvpULRFlags, diameter.Vendor3GPP).ToUint32OrDefault()
+	u.VisitedPLMNId = message.Avps.GetFirst(AvpVisitedPLMNId, diameter.Vendor3GPP).ToData()
+	u.Avps = message.Avps.Without(0, headerCodes...).Without(diameter.Vendor3GPP, AvpRATType, AvpULRFlags, AvpVisitedPLMNId)
+	return nil
+}
+
+// ULA represents an Update-Location-Answer. AVPs without a field are
+// carried in Avps and appended after the others.
+type ULA struct {
+	Result
+	ULAFlags         uint32
+	SubscriptionData *SubscriptionData
+	Avps             diameter.Avps
+}
+
+// ToMessage converts the ULA to a Diameter message answering the request.
+func (u ULA) ToMessage(request diameter.Message) diameter.Message {
+	avps := u.Result.toAvps()
+	if u.ULAFlags != 0 {
+		avps = avps.AddUint32(AvpULAFlags, mandatoryFlags, diameter.Vendor3GPP, u.ULAFlags)
+	}
+	if u.SubscriptionData != nil {
+		avps = append(avps, u.SubscriptionData.ToAvp())
+	}
+	avps = append(avps, u.Avps...)
+	return request.NewAnswer(avps...)
+}
+
+// FromMessage reads a ULA from a Diameter message.
+func (u *ULA) FromMessage(message diameter.Message) error {
+	if message.CommandCode != diameter.CommandUpdateLocation || message.IsRequest() {
+		return errors.New("message is not an Update-Location-Answer")
+	}
+	u.Result.fromAvps(message.Avps)
+	u.ULAFlags = message.Avps.GetFirst(AvpULAFlags, diameter.Vendor3GPP).ToUint32OrDefault()
+	u.SubscriptionData = nil
+	if subscriptionData := message.Avps.GetFirst(AvpSubscriptionData, diameter.Vendor3GPP); subscriptionData != nil {
+		u.SubscriptionData = &SubscriptionData{}
+		u.SubscriptionData.FromAvp(subscriptionData)
+	}
+	u.Avps = message.Avps.Without(0, resultCodes...).Without(diameter.Vendor3GPP, AvpULAFlags, AvpSubscriptionData)
+	return nil
+}
+
+// AIR represents an Authentication-Information-Request. AVPs without a
+// field are carried in Avps and appended after the others.
+type AIR struct {
+	Header
+	NumberOfRequestedVectors   uint32
+	ImmediateResponsePreferred bool
+	ReSynchronizationInfo      []byte
+	VisitedPLMNId              []byte
+	Avps                       diameter.Avps
+}
+
+// ToMessage converts the AIR to a Diameter message.
+func (a AIR) ToMessage(hopByHopId [4]byte, endToEndId [4]byte) diameter.Message {
+	avps := a.Header.toAvps()
+	if a.NumberOfRequestedVectors != 0 {
+		info := diameter.NewAvps().AddUint32(AvpNumberOfRequestedVectors, mandatoryFlags, diameter.Vendor3GPP, a.NumberOfRequestedVectors)
+		if a.ImmediateResponsePreferred {
+			info = info.AddUint32(AvpImmediateResponsePreferred, mandatoryFlags, diameter.Vendor3GPP, 1)
+		}
+		if a.ReSynchronizationInfo != nil {
+			info = info.Add(AvpReSynchronizationInfo, mandatoryFlags, diameter.Vendor3GPP, a.ReSynchronizationInfo)
+		}
+		avps = avps.AddGroup(AvpRequestedEUTRANAuthenticationInfo, mandatoryFlags, diameter.Vendor3GPP, info...)
+	}
+	avps = avps.Add(AvpVisitedPLMNId, mandatoryFlags, diameter.Vendor3GPP, a.VisitedPLMNId)
+	avps = append(avps, a.Avps...)
+	return diameter.NewMessage(1, diameter.FlagRequest|diameter.FlagProxiable, diameter.CommandAuthenticationInformation, diameter.ApplicationS6a, hopByHopId, endToEndId, avps...)
+}
+
+// FromMessage reads an AIR from a Diameter message.
+func (a *AIR) FromMessage(message diameter.Message) error {
+	if message.CommandCode != diameter.CommandAuthenticationInformation || !message.IsRequest() {
+		return errors.New("message is not an Authentication-Information-Request")
+	}
+	a.Header.fromAvps(message.Avps)
+	info := message.Avps.GetFirst(AvpRequestedEUTRANAuthenticationInfo, diameter.Vendor3GPP).ToGroup()
+	a.NumberOfRequestedVectors = info.GetFirst(AvpNumberOfRequestedVectors, diameter.Vendor3GPP).ToUint32OrDefault()
+	a.ImmediateResponsePreferred = info.GetFirst(AvpImmediateResponsePreferred, diameter.Vendor3GPP) != nil
+	a.ReSynchronizationInfo = nil
+	if reSynchronizationInfo := info.GetFirst(AvpReSynchronizationInfo, diameter.Vendor3GPP); reSynchronizationInfo != nil {
+		a.ReSynchronizationInfo = reSynchronizationInfo.ToData()
+	}
+	a.VisitedPLMNId = message.Avps.GetFirst(AvpVisitedPLMNId, diameter.Vendor3GPP).ToData()
+	a.Avps = message.Avps.Without(0, headerCodes...).Without(diameter.Vendor3GPP, AvpRequestedEUTRANAuthenticationInfo, AvpVisitedPLMNId)
+	return nil
+}
+
+// EUTRANVector represents an E-UTRAN-Vector AVP.
+type EUTRANVector struct {
+	RAND  []byte
+	XRES  []byte
+	AUTN  []byte
+	KASME []byte
+}
+
+// ToAvp converts the E-UTRAN-Vector to a grouped AVP.
+func (e EUTRANVector) ToAvp() diameter.Avp {
+	avps := diameter.NewAvps()
+	avps = avps.Add(AvpRAND, mandatoryFlags, diameter.Vendor3GPP, e.RAND)
+	avps = avps.Add(AvpXRES, mandatoryFlags, diameter.Vendor3GPP, e.XRES)
+	avps = avps.Add(AvpAUTN, mandatoryFlags, diameter.Vendor3GPP, e.AUTN)
+	avps = avps.Add(AvpKASME, mandatoryFlags, diameter.Vendor3GPP, e.KASME)
+	return diameter.NewAvpGroup(AvpEUTRANVector, mandatoryFlags, diameter.Vendor3GPP, avps...)
+}
+
+// FromAvp reads the E-UTRAN-Vector from a grouped AVP.
+func (e *EUTRANVector) FromAvp(avp *diameter.Avp) {
+	group := avp.ToGroup()
+	e.RAND = group.GetFirst(AvpRAND, diameter.Vendor3GPP).ToData()
+	e.XRES = group.GetFirst(AvpXRES, diameter.Vendor3GPP).ToData()
+	e.AUTN = group.GetFirst(AvpAUTN, diameter.Vendor3GPP).ToData()
+	e.KASME = group.GetFirst(AvpKASME, diameter.Vendor3GPP).ToData()
+}
+
+// AIA represents an Authentication-Information-Answer. AVPs without a field
+// are carried in Avps and appended after the others.
+type AIA struct {
+	Result
+	EUTRANVectors []EUTRANVector
+	Avps          diameter.Avps
+}
+
+// ToMessage converts the AIA to a Diameter message answering the request.
+func (a AIA) ToMessage(request diameter.Message) diameter.Message {
+	avps := a.Result.toAvps()
+	if len(a.EUTRANVectors) > 0 {
+		vectors := diameter.NewAvps()
+		for _, vector := range a.EUTRANVectors {
+			vectors = append(vectors, vector.ToAvp())
+		}
+		avps = avps.AddGroup(AvpAuthenticationInfo, mandatoryFlags, diameter.Vendor3GPP, vectors...)
+	}
+	avps = append(avps, a.Avps...)
+	return request.NewAnswer(avps...)
+}
+
+// FromMessage reads an AIA from a Diameter message.
+func (a *AIA) FromMessage(message diameter.Message) error {
+	if message.CommandCode != diameter.CommandAuthenticationInformation || message.IsRequest() {
+		return errors.New("message is not an Authentication-Information-Answer")
+	}
+	a.Result.fromAvps(message.Avps)
+	a.EUTRANVectors = nil
+	info := message.Avps.GetFirst(AvpAuthenticationInfo, diameter.Vendor3GPP).ToGroup()
+	for _, vectorAvp := range info.Get(AvpEUTRANVector, diameter.Vendor3GPP) {
+		vector := EUTRANVector{}
+		vector.FromAvp(&vectorAvp)
+		a.EUTRANVectors = append(a.EUTRANVectors, vector)
+	}
+	a.Avps = message.Avps.Without(0, resultCodes...).Without(diameter.Vendor3GPP, AvpAuthenticationInfo)
+	return nil
+}
@@ -0,0 +1,232 @@
+package s6a
+
+import (
+	"net"
+
+	"github.com/tinybluerobots/radius-diameter-message/diameter"
+	"github.com/tinybluerobots/radius-diameter-message/diameter/gx"
+)
+
+// PDNType represents the value of a PDN-Type AVP.
+type PDNType uint32
+
+const (
+	IPv4       PDNType = 0
+	IPv6       PDNType = 1
+	IPv4v6     PDNType = 2
+	IPv4OrIPv6 PDNType = 3
+)
+
+// AMBR represents an AMBR AVP, with bandwidths in bits per second.
+type AMBR struct {
+	MaxRequestedBandwidthUL uint32
+	MaxRequestedBandwidthDL uint32
+}
+
+// ToAvp converts the AMBR to a grouped AVP.
+func (a AMBR) ToAvp() diameter.Avp {
+	avps := diameter.NewAvps()
+	avps = avps.AddUint32(AvpMaxRequestedBandwidthUL, mandatoryFlags, diameter.Vendor3GPP, a.MaxRequestedBandwidthUL)
+	avps = avps.AddUint32(AvpMaxRequestedBandwidthDL, mandatoryFlags, diameter.Vendor3GPP, a.MaxRequestedBandwidthDL)
+	return diameter.NewAvpGroup(AvpAMBR, mandatoryFlags, diameter.Vendor3GPP, avps...)
+}
+
+// FromAvp reads the AMBR from a grouped AVP.
+func (a *AMBR) FromAvp(avp *diameter.Avp) {
+	group := avp.ToGroup()
+	a.MaxRequestedBandwidthUL = group.GetFirst(AvpMaxRequestedBandwidthUL, diameter.Vendor3GPP).ToUint32OrDefault()
+	a.MaxRequestedBandwidthDL = group.GetFirst(AvpMaxRequestedBandwidthDL, diameter.Vendor3GPP).ToUint32OrDefault()
+}
+
+// readAMBR reads the AMBR AVP if it is present.
+func readAMBR(avps diameter.Avps) *AMBR {
+	avp := avps.GetFirst(AvpAMBR, diameter.Vendor3GPP)
+	if avp == nil {
+		return nil
+	}
+	ambr := &AMBR{}
+	ambr.FromAvp(avp)
+	return ambr
+}
+
+// EPSSubscribedQoSProfile represents an EPS-Subscribed-QoS-Profile AVP.
+type EPSSubscribedQoSProfile struct {
+	QoSClassIdentifier          uint32
+	AllocationRetentionPriority gx.AllocationRetentionPriority
+}
+
+// ToAvp converts the EPS-Subscribed-QoS-Profile to a grouped AVP.
+func (e EPSSubscribedQoSProfile) ToAvp() diameter.Avp {
+	avps := diameter.NewAvps().AddUint32(AvpQoSClassIdentifier, mandatoryFlags, diameter.Vendor3GPP, e.QoSClassIdentifier)
+	avps = append(avps, e.AllocationRetentionPriority.ToAvp())
+	return diameter.NewAvpGroup(AvpEPSSubscribedQoSProfile, mandatoryFlags, diameter.Vendor3GPP, avps...)
+}
+
+// FromAvp reads the EPS-Subscribed-QoS-Profile from a grouped AVP.
+func (e *EPSSubscribedQoSProfile) FromAvp(avp *diameter.Avp) {
+	group := avp.ToGroup()
+	e.QoSClassIdentifier = group.GetFirst(AvpQoSClassIdentifier, diameter.Vendor3GPP).ToUint32OrDefault()
+	e.AllocationRetentionPriority.FromAvp(group.GetFirst(AvpAllocationRetentionPriority, diameter.Vendor3GPP))
+}
+
+// APNConfiguration represents an APN-Configuration AVP. Nil pointers are
+// omitted from messages.
+type APNConfiguration struct {
+	ContextIdentifier       uint32
+	ServedPartyIPAddresses  []net.IP
+	PDNType                 PDNType
+	ServiceSelection        string
+	EPSSubscribedQoSProfile *EPSSubscribedQoSProfile
+	AMBR                    *AMBR
+}
+
+// ToAvp converts the APN-Configuration to a grouped AVP.
+func (a APNConfiguration) ToAvp() diameter.Avp {
+	avps := diameter.NewAvps().AddUint32(AvpContextIdentifier, mandatoryFlags, diameter.Vendor3GPP, a.ContextIdentifier)
+	for _, address := range a.ServedPartyIPAddresses {
+		avps = avps.AddNetIP(AvpServedPartyIPAddress, mandatoryFlags, diameter.Vendor3GPP, address)
+	}
+	avps = avps.AddUint32(AvpPDNType, mandatoryFlags, diameter.Vendor3GPP, uint32(a.PDNType))
+	avps = avps.AddString(AvpServiceSelection, mandatoryFlags, 0, a.ServiceSelection)
+	if a.EPSSubscribedQoSProfile != nil {
+		avps = append(avps, a.EPSSubscribedQoSProfile.ToAvp())
+	}
+	if a.AMBR != nil {
+		avps = append(avps, a.AMBR.ToAvp())
+	}
+	return diameter.NewAvpGroup(AvpAPNConfiguration, mandatoryFlags, diameter.Vendor3GPP, avps...)
+}
+
+// FromAvp reads the APN-Configuration from a grouped AVP.
+func (a *APNConfiguration) FromAvp(avp *diameter.Avp) {
+	group := avp.ToGroup()
+	a.ContextIdentifier = group.GetFirst(AvpContextIdentifier, diameter.Vendor3GPP).ToUint32OrDefault()
+	a.ServedPartyIPAddresses = nil
+	for _, address := range group.Get(AvpServedPartyIPAddress, diameter.Vendor3GPP) {
+		if ip, ok := address.ToNetIPOk(); ok {
+			a.ServedPartyIPAddresses = append(a.ServedPartyIPAddresses, ip)
+		}
+	}
+	a.PDNType = PDNType(group.GetFirst(AvpPDNType, diameter.Vendor3GPP).ToUint32OrDefault())
+	a.ServiceSelection = group.GetFirst(AvpServiceSelection, 0).ToStringOrDefault()
+	a.EPSSubscribedQoSProfile = nil
+	if profile := group.GetFirst(AvpEPSSubscribedQoSProfile, diameter.Vendor3GPP); profile != nil {
+		a.EPSSubscribedQoSProfile = &EPSSubscribedQoSProfile{}
+		a.EPSSubscribedQoSProfile.FromAvp(profile)
+	}
+	a.AMBR = readAMBR(group)
+}
+
+// APNConfigurationProfile represents an APN-Configuration-Profile AVP.
+type APNConfigurationProfile struct {
+	ContextIdentifier            uint32
+	AllAPNConfigurationsIncluded bool
+	APNConfigurations            []APNConfiguration
+}
+
+// ToAvp converts the APN-Configuration-Profile to a grouped AVP.
+func (a APNConfigurationProfile) ToAvp() diameter.Avp {
+	indicator := uint32(1)
+	if a.AllAPNConfigurationsIncluded {
+		indicator = 0
+	}
+	avps := diameter.NewAvps()
+	avps = avps.AddUint32(AvpContextIdentifier, mandatoryFlags, diameter.Vendor3GPP, a.ContextIdentifier)
+	avps = avps.AddUint32(AvpAllAPNConfigurationsIncludedIndicator, mandatoryFlags, diameter.Vendor3GPP, indicator)
+	for _, configuration := range a.APNConfigurations {
+		avps = append(avps, configuration.ToAvp())
+	}
+	return diameter.NewAvpGroup(AvpAPNConfigurationProfile, mandatoryFlags, diameter.Vendor3GPP, avps...)
+}
+
+// FromAvp reads the APN-Configuration-Profile from a grouped AVP.
+func (a *APNConfigurationProfile) FromAvp(avp *diameter.Avp) {
+	group := avp.ToGroup()
+	a.ContextIdentifier = group.GetFirst(AvpContextIdentifier, diameter.Vendor3GPP).ToUint32OrDefault()
+	a.AllAPNConfigurationsIncluded = group.GetFirst(AvpAllAPNConfigurationsIncludedIndicator, diameter.Vendor3GPP).ToUint32OrDefault() == 0
+	a.APNConfigurations = nil
+	for _, configurationAvp := range group.Get(AvpAPNConfiguration, diameter.Vendor3GPP) {
+		configuration := APNConfiguration{}
+		configuration.FromAvp(&configurationAvp)
+		a.APNConfigurations = append(a.APNConfigurations, configuration)
+	}
+}
+
+// APN returns the APN-Configuration with the context identifier, or nil.
+func (a *APNConfigurationProfile) APN(contextIdentifier uint32) *APNConfiguration {
+	if a == nil {
+		return nil
+	}
+	for i := range a.APNConfigurations {
+		if a.APNConfigurations[i].ContextIdentifier == contextIdentifier {
+			return &a.APNConfigurations[i]
+		}
+	}
+	return nil
+}
+
+// Default returns the default APN-Configuration, or nil.
+func (a *APNConfigurationProfile) Default() *APNConfiguration {
+	if a == nil {
+		return nil
+	}
+	return a.APN(a.ContextIdentifier)
+}
+
+// SubscriptionData represents a Subscription-Data AVP. Zero values and nil
+// pointers are omitted from messages.
+type SubscriptionData struct {
+	SubscriberStatus              uint32
+	MSISDN                        []byte
+	NetworkAccessMode             uint32
+	AccessRestrictionData         uint32
+	AMBR                          *AMBR
+	APNOIReplacement              string
+	APNConfigurationProfile       *APNConfigurationProfile
+	SubscribedPeriodicRAUTAUTimer uint32
+}
+
+// ToAvp converts the Subscription-Data to a grouped AVP.
+func (s SubscriptionData) ToAvp() diameter.Avp {
+	avps := diameter.NewAvps().AddUint32(AvpSubscriberStatus, mandatoryFlags, diameter.Vendor3GPP, s.SubscriberStatus)
+	if s.MSISDN != nil {
+		avps = avps.Add(AvpMSISDN, mandatoryFlags, diameter.Vendor3GPP, s.MSISDN)
+	}
+	avps = avps.AddUint32(AvpNetworkAccessMode, mandatoryFlags, diameter.Vendor3GPP, s.NetworkAccessMode)
+	if s.AccessRestrictionData != 0 {
+		avps = avps.AddUint32(AvpAccessRestrictionData, mandatoryFlags, diameter.Vendor3GPP, s.AccessRestrictionData)
+	}
+	if s.AMBR != nil {
+		avps = append(avps, s.AMBR.ToAvp())
+	}
+	if s.APNOIReplacement != "" {
+		avps = avps.AddString(AvpAPNOIReplacement, mandatoryFlags, diameter.Vendor3GPP, s.APNOIReplacement)
+	}
+	if s.APNConfigurationProfile != nil {
+		avps = append(avps, s.APNConfigurationProfile.ToAvp())
+	}
+	if s.SubscribedPeriodicRAUTAUTimer != 0 {
+		avps = avps.AddUint32(AvpSubscribedPeriodicRAUTAUTimer, 0, diameter.Vendor3GPP, s.SubscribedPeriodicRAUTAUTimer)
+	}
+	return diameter.NewAvpGroup(AvpSubscriptionData, mandatoryFlags, diameter.Vendor3GPP, avps...)
+}
+
+// FromAvp reads the Subscription-Data from a grouped AVP.
+func (s *SubscriptionData) FromAvp(avp *diameter.Avp) {
+	group := avp.ToGroup()
+	s.SubscriberStatus = group.GetFirst(AvpSubscriberStatus, diameter.Vendor3GPP).ToUint32OrDefault()
+	s.MSISDN = nil
+	if msisdn := group.GetFirst(AvpMSISDN, diameter.Vendor3GPP); msisdn != nil {
+		s.MSISDN = msisdn.ToData()
+	}
+	s.NetworkAccessMode = group.GetFirst(AvpNetworkAccessMode, diameter.Vendor3GPP).ToUint32OrDefault()
+	s.AccessRestrictionData = group.GetFirst(AvpAccessRestrictionData, diameter.Vendor3GPP).ToUint32OrDefault()
+	s.AMBR = readAMBR(group)
+	s.APNOIReplacement = group.GetFirst(AvpAPNOIReplacement, diameter.Vendor3GPP).ToStringOrDefault()
+	s.APNConfigurationProfile = nil
+	if profile := group.GetFirst(AvpAPNConfigurationProfile, diameter.Vendor3GPP); profile != nil {
+		s.APNConfigurationProfile = &APNConfigurationProfile{}
+		s.APNConfigurationProfile.FromAvp(profile)
+	}
+	s.SubscribedPeriodicRAUTAUTimer = group.GetFirst(AvpSubscribedPeriodicRAUTAUTimer, diameter.Vendor3GPP).ToUint32OrDefault()
+}
@@ -0,0 +1,99 @@
+package diameter
+
+import (
+	"fmt"
+	"sync"
+)
+
+// SchemaRegistry holds versioned sets of command grammars per application,
+// one version of which is active for validating traffic.
+type SchemaRegistry struct {
+	mutex    sync.RWMutex
+	versions map[ApplicationId]map[string]map[ccfKey]CCF
+	active   map[ApplicationId]string
+}
+
+// NewSchemaRegistry creates a new empty SchemaRegistry.
+func NewSchemaRegistry() *SchemaRegistry {
+	return &SchemaRegistry{
+		versions: make(map[ApplicationId]map[string]map[ccfKey]CCF),
+		active:   make(map[ApplicationId]string),
+	}
+}
+
+// Register adds command grammars to a version of an application's schema.
+// The first version registered for an application becomes its active version.
+func (r *SchemaRegistry) Register(applicationId ApplicationId, version string, ccfs ...CCF) *SchemaRegistry {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	versions, ok := r.versions[applicationId]
+	if !ok {
+		versions = make(map[string]map[ccfKey]CCF)
+		r.versions[applicationId] = versions
+	}
+	schema, ok := versions[version]
+	if !ok {
+		schema = make(map[ccfKey]CCF)
+		versions[version] = schema
+	}
+	for _, ccf := range ccfs {
+		schema[ccfKey{ccf.CommandCode, ccf.Request}] = ccf
+	}
+	if _, ok := r.active[applicationId]; !ok {
+		r.active[applicationId] = version
+	}
+	return r
+}
+
+// Activate makes a registered version the active schema of an application.
+func (r *SchemaRegistry) Activate(applicationId ApplicationId, version string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if _, ok := r.versions[applicationId][version]; !ok {
+		return fmt.Errorf("no schema version %q for application %d", version, applicationId)
+	}
+	r.active[applicationId] = version
+	return nil
+}
+
+// ActiveVersion retrieves the active schema version of an application.
+func (r *SchemaRegistry) ActiveVersion(applicationId ApplicationId) *string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	version, ok := r.active[applicationId]
+	if !ok {
+		return nil
+	}
+	return &version
+}
+
+// CCF retrieves the grammar of a request or answer in a version of an application's schema.
+func (r *SchemaRegistry) CCF(applicationId ApplicationId, version string, commandCode CommandCode, request bool) *CCF {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	ccf, ok := r.versions[applicationId][version][ccfKey{commandCode, request}]
+	if !ok {
+		return nil
+	}
+	return &ccf
+}
+
+// Validate checks the message against the active schema of its application,
+// returning an error if there is no grammar for the message.
+func (r *SchemaRegistry) Validate(message Message) ([]Violation, error) {
+	version := r.ActiveVersion(message.ApplicationId)
+	if version == nil {
+		return nil, fmt.Errorf("no schema for application %d", message.ApplicationId)
+	}
+	return r.ValidateVersion(message, *version)
+}
+
+// ValidateVersion checks the message against a version of its application's
+// schema, returning an error if there is no grammar for the message.
+func (r *SchemaRegistry) ValidateVersion(message Message, version string) ([]Violation, error) {
+	ccf := r.CCF(message.ApplicationId, version, message.CommandCode, message.IsRequest())
+	if ccf == nil {
+		return nil, fmt.Errorf("no grammar for command %d in schema version %q of application %d", message.CommandCode, version, message.ApplicationId)
+	}
+	return message.Validate(*ccf), nil
+}
@@ -0,0 +1,150 @@
+package server
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/tinybluerobots/radius-diameter-message/diameter"
+)
+
+type dedupKey struct {
+	endToEndId [4]byte
+	originHost string
+}
+
+type dedupEntry struct {
+	key      dedupKey
+	answer   diameter.Message
+	answered bool
+	expires  time.Time
+}
+
+// DedupCache remembers requests by End-to-End identifier and Origin-Host, so a
+// request retransmitted with the T flag is answered with the answer already
+// sent, or dropped while the original is still being handled, instead of
+// being handled again, as RFC 6733 section 6.1.1 recommends. Requests are
+// kept in the order they expire, so remembering one costs constant time
+// however many are remembered. It is safe for concurrent use.
+type DedupCache struct {
+	ttl     time.Duration
+	mutex   sync.Mutex
+	entries map[dedupKey]*list.Element
+	// order holds the *dedupEntry of every request, closest to expiring first.
+	order *list.List
+}
+
+// NewDedupCache creates a cache that remembers answers for the TTL.
+func NewDedupCache(ttl time.Duration) *DedupCache {
+	return &DedupCache{ttl: ttl, entries: make(map[dedupKey]*list.Element), order: list.New()}
+}
+
+// Lookup returns the cached answer to an earlier copy of the request, with the
+// Hop-by-Hop identifier of the request.
+func (d *DedupCache) Lookup(request diameter.Message) (diameter.Message, bool) {
+	key := newDedupKey(request)
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	element, ok := d.entries[key]
+	if !ok {
+		return diameter.Message{}, false
+	}
+	entry := element.Value.(*dedupEntry)
+	if time.Now().After(entry.expires) {
+		d.remove(element)
+		return diameter.Message{}, false
+	}
+	if !entry.answered {
+		return diameter.Message{}, false
+	}
+	answer := entry.answer
+	answer.HopByHopId = request.HopByHopId
+	return answer, true
+}
+
+// Store caches the answer to the request, remembering the request if it was
+// not already.
+func (d *DedupCache) Store(request diameter.Message, answer diameter.Message) {
+	key := newDedupKey(request)
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	var entry *dedupEntry
+	if element, ok := d.entries[key]; ok {
+		entry = element.Value.(*dedupEntry)
+	} else {
+		entry = d.remember(key, time.Now())
+	}
+	entry.answer, entry.answered = answer, true
+}
+
+// begin remembers the request as being handled, reporting false if it is a
+// retransmission of one remembered, with the answer already sent, or nil if
+// the original is still being handled.
+func (d *DedupCache) begin(request diameter.Message) (*diameter.Message, bool) {
+	key := newDedupKey(request)
+	now := time.Now()
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if element, ok := d.entries[key]; ok && request.IsRetransmitted() {
+		if entry := element.Value.(*dedupEntry); !now.After(entry.expires) {
+			if !entry.answered {
+				return nil, false
+			}
+			answer := entry.answer
+			answer.HopByHopId = request.HopByHopId
+			return &answer, false
+		}
+	}
+	d.remember(key, now)
+	return nil, true
+}
+
+// remember records a request not yet answered, forgetting any earlier one
+// with the same key and those whose TTL has passed, which are at the front
+// of the order since every request is remembered for the same TTL.
+func (d *DedupCache) remember(key dedupKey, now time.Time) *dedupEntry {
+	for element := d.order.Front(); element != nil && now.After(element.Value.(*dedupEntry).expires); element = d.order.Front() {
+		d.remove(element)
+	}
+	if element, ok := d.entries[key]; ok {
+		d.remove(element)
+	}
+	entry := &dedupEntry{key: key, expires: now.Add(d.ttl)}
+	d.entries[key] = d.order.PushBack(entry)
+	return entry
+}
+
+// remove forgets a cached answer.
+func (d *DedupCache) remove(element *list.Element) {
+	delete(d.entries, element.Value.(*dedupEntry).key)
+	d.order.Remove(element)
+}
+
+// Len returns the number of remembered requests, including those not yet
+// answered and any that have expired but not yet been removed.
+func (d *DedupCache) Len() int {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return len(d.entries)
+}
+
+// newDedupKey creates the key identifying the request.
+func newDedupKey(request diameter.Message) dedupKey {
+	return dedupKey{
+		endToEndId: request.EndToEndId,
+		originHost: request.Avps.GetFirst(diameter.AvpOriginHost, 0).ToStringOrDefault(),
+	}
+}
+
+// dedupWriter caches answers as they are written.
+type dedupWriter struct {
+	AnswerWriter
+	cache   *DedupCache
+	request diameter.Message
+}
+
+// WriteAnswer caches and writes the answer.
+func (w dedupWriter) WriteAnswer(answer diameter.Message) error {
+	w.cache.Store(w.request, answer)
+	return w.AnswerWriter.WriteAnswer(answer)
+}
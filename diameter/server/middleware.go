@@ -0,0 +1,22 @@
+package server
+
+// Middleware wraps a Handler, for concerns such as logging, metrics,
+// validation or rewriting AVPs that apply to every request.
+type Middleware func(next Handler) Handler
+
+// Use appends middleware to the chain wrapping every handler, including the
+// one answering requests no handler is registered for. The first middleware
+// registered runs first.
+func (s *Server) Use(middleware ...Middleware) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.middleware = append(s.middleware, middleware...)
+}
+
+// chain wraps the handler in the middleware.
+func chain(handler Handler, middleware []Middleware) Handler {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		handler = middleware[i](handler)
+	}
+	return handler
+}
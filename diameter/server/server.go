@@ -0,0 +1,455 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/tinybluerobots/radius-diameter-message/diameter"
+	"github.com/tinybluerobots/radius-diameter-message/diameter/capabilities"
+	"github.com/tinybluerobots/radius-diameter-message/diameter/disconnect"
+	"github.com/tinybluerobots/radius-diameter-message/diameter/metrics"
+	"github.com/tinybluerobots/radius-diameter-message/diameter/originstate"
+	"github.com/tinybluerobots/radius-diameter-message/diameter/watchdog"
+)
+
+// ErrServerClosed is returned by Serve after the server is closed.
+var ErrServerClosed = errors.New("server closed")
+
+// AnswerWriter writes the answer to a request back to the peer that sent it.
+type AnswerWriter interface {
+	WriteAnswer(answer diameter.Message) error
+}
+
+// Handler handles a request, writing its answer with the AnswerWriter. The
+// context is cancelled when the connection to the peer closes.
+type Handler func(ctx context.Context, writer AnswerWriter, request diameter.Message)
+
+// Config configures a Server.
+type Config struct {
+	// Capabilities are the local capabilities sent in each CEA.
+	Capabilities capabilities.Capabilities
+	// Tw is the watchdog interval, which defaults to 30 seconds.
+	Tw time.Duration
+	// Ids generates the identifiers of watchdog requests. A new generator is used if nil.
+	Ids *diameter.IdGenerator
+	// Metrics receives measurements of every connection. Nothing is measured if nil.
+	Metrics metrics.Sink
+	// OriginState, if set, supplies the Origin-State-Id of each CEA and
+	// observes the Origin-State-Id of peers to detect restarts.
+	OriginState *originstate.Tracker
+	// Dedup, if set, caches answers so retransmitted requests with the T flag
+	// are answered from the cache, or dropped while the original is still
+	// being handled, instead of being handled again.
+	Dedup *DedupCache
+}
+
+type handlerKey struct {
+	applicationId diameter.ApplicationId
+	commandCode   diameter.CommandCode
+}
+
+type peerKey struct{}
+
+// Server accepts connections from Diameter peers, answers their CERs and
+// dispatches their requests to the handlers registered for the application
+// and command.
+type Server struct {
+	config     Config
+	mutex      sync.Mutex
+	handlers   map[handlerKey]Handler
+	middleware []Middleware
+	listeners  map[net.Listener]struct{}
+	conns      map[*conn]struct{}
+	handling   sync.WaitGroup
+	closed     bool
+}
+
+// New creates a server with the configuration.
+func New(config Config) *Server {
+	if config.Ids == nil {
+		config.Ids = diameter.NewIdGenerator()
+	}
+	config.Metrics = metrics.OrNop(config.Metrics)
+	if config.OriginState != nil && config.Capabilities.OriginStateId == 0 {
+		config.Capabilities.OriginStateId = config.OriginState.Local()
+	}
+	return &Server{
+		config:    config,
+		handlers:  make(map[handlerKey]Handler),
+		listeners: make(map[net.Listener]struct{}),
+		conns:     make(map[*conn]struct{}),
+	}
+}
+
+// Handle registers the handler for requests with the application ID and
+// command code, replacing any handler already registered.
+func (s *Server) Handle(applicationId diameter.ApplicationId, commandCode diameter.CommandCode, handler Handler) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.handlers[handlerKey{applicationId, commandCode}] = handler
+}
+
+// PeerFromContext returns the capabilities of the peer that sent the request
+// being handled.
+func PeerFromContext(ctx context.Context) (capabilities.Capabilities, bool) {
+	peer, ok := ctx.Value(peerKey{}).(capabilities.Capabilities)
+	return peer, ok
+}
+
+// ListenAndServe listens on the TCP address and serves connections from it.
+func (s *Server) ListenAndServe(address string) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	return s.Serve(listener)
+}
+
+// Serve accepts connections from the listener until the server is closed,
+// serving each in its own goroutine.
+func (s *Server) Serve(listener net.Listener) error {
+	s.mutex.Lock()
+	if s.closed {
+		s.mutex.Unlock()
+		listener.Close()
+		return ErrServerClosed
+	}
+	s.listeners[listener] = struct{}{}
+	s.mutex.Unlock()
+	defer func() {
+		s.mutex.Lock()
+		delete(s.listeners, listener)
+		s.mutex.Unlock()
+	}()
+	for {
+		netConn, err := listener.Accept()
+		if err != nil {
+			if s.isClosed() {
+				return ErrServerClosed
+			}
+			return err
+		}
+		c := &conn{server: s, conn: netConn, done: make(chan struct{})}
+		if !s.track(c) {
+			netConn.Close()
+			return ErrServerClosed
+		}
+		go c.serve()
+	}
+}
+
+// Close closes every listener and connection.
+func (s *Server) Close() error {
+	s.mutex.Lock()
+	s.closed = true
+	var err error
+	for listener := range s.listeners {
+		err = errors.Join(err, listener.Close())
+	}
+	conns := make([]*conn, 0, len(s.conns))
+	for c := range s.conns {
+		conns = append(conns, c)
+	}
+	s.mutex.Unlock()
+	for _, c := range conns {
+		c.close()
+	}
+	return err
+}
+
+// Shutdown closes the server gracefully: it closes every listener and
+// answers new requests with DIAMETER_TOO_BUSY, so peers send them elsewhere,
+// waits for the handlers of the requests already received to finish, then
+// sends each peer a DPR and closes its connection once the peer answers with
+// a DPA. If the context is done first, the server is closed without waiting
+// longer and the context's error is returned.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mutex.Lock()
+	s.closed = true
+	var err error
+	for listener := range s.listeners {
+		err = errors.Join(err, listener.Close())
+		delete(s.listeners, listener)
+	}
+	s.mutex.Unlock()
+	handled := make(chan struct{})
+	go func() {
+		s.handling.Wait()
+		close(handled)
+	}()
+	select {
+	case <-handled:
+	case <-ctx.Done():
+		return errors.Join(err, ctx.Err(), s.Close())
+	}
+	s.mutex.Lock()
+	conns := make([]*conn, 0, len(s.conns))
+	for c := range s.conns {
+		conns = append(conns, c)
+	}
+	s.mutex.Unlock()
+	for _, c := range conns {
+		c.disconnect()
+	}
+	for _, c := range conns {
+		select {
+		case <-c.done:
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err(), s.Close())
+		}
+	}
+	return errors.Join(err, s.Close())
+}
+
+// isClosed reports whether the server has been closed.
+func (s *Server) isClosed() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.closed
+}
+
+// track records the connection so it is closed with the server.
+func (s *Server) track(c *conn) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.closed {
+		return false
+	}
+	s.conns[c] = struct{}{}
+	return true
+}
+
+// connect records that the capabilities exchange with the peer of the
+// connection completed, reporting false if the server is closed or shutting
+// down.
+func (s *Server) connect(c *conn) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.closed {
+		return false
+	}
+	c.connected = true
+	return true
+}
+
+// startHandling records a request about to be handled, reporting false if
+// the server is closed or shutting down.
+func (s *Server) startHandling() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.closed {
+		return false
+	}
+	s.handling.Add(1)
+	return true
+}
+
+// untrack forgets a closed connection.
+func (s *Server) untrack(c *conn) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.conns, c)
+}
+
+// handler returns the handler for the request wrapped in the middleware,
+// or the fallback if none is registered.
+func (s *Server) handler(request diameter.Message, fallback Handler) Handler {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	handler, ok := s.handlers[handlerKey{request.ApplicationId, request.CommandCode}]
+	if !ok {
+		handler = fallback
+	}
+	return chain(handler, s.middleware)
+}
+
+// conn is a connection from a peer.
+type conn struct {
+	server     *Server
+	conn       net.Conn
+	writeMutex sync.Mutex
+	closeOnce  sync.Once
+	done       chan struct{}
+	// connected is guarded by the mutex of the server.
+	connected bool
+}
+
+// serve performs the capabilities exchange and handles requests until the
+// connection closes.
+func (c *conn) serve() {
+	defer func() {
+		c.close()
+		c.server.untrack(c)
+		close(c.done)
+	}()
+	config := c.server.config
+	reader := bufio.NewReader(c.conn)
+	message, err := c.read(reader)
+	if err != nil {
+		return
+	}
+	cer := capabilities.CER{}
+	if err := cer.FromMessage(*message); err != nil {
+		return
+	}
+	cea := capabilities.Answer(config.Capabilities, cer)
+	if err := c.write(cea.ToMessage(*message)); err != nil || !cea.ResultCode.IsSuccess() || !c.server.connect(c) {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), peerKey{}, cer.Capabilities))
+	defer cancel()
+	wd := watchdog.New(watchdog.Config{
+		Tw:            config.Tw,
+		OriginHost:    config.Capabilities.OriginHost,
+		OriginRealm:   config.Capabilities.OriginRealm,
+		OriginStateId: config.Capabilities.OriginStateId,
+		Ids:           config.Ids,
+		OnStateChange: func(state watchdog.State) {
+			config.Metrics.WatchdogState(cer.OriginHost, state)
+		},
+	}, c.write)
+	go func() {
+		if err := wd.Run(ctx); errors.Is(err, watchdog.ErrPeerFailed) {
+			c.close()
+		}
+	}()
+	for {
+		message, err := c.read(reader)
+		var messageError *diameter.MessageError
+		if errors.As(err, &messageError) && messageError.Header.Flags.IsRequest() {
+			go c.write(c.errorAnswer(messageError.Header, messageError.Err))
+			continue
+		}
+		if err != nil {
+			return
+		}
+		handled, err := wd.Handle(*message)
+		if err != nil {
+			return
+		}
+		if disconnect.IsDPA(*message) {
+			return
+		}
+		if handled || !message.IsRequest() {
+			continue
+		}
+		if disconnect.IsDPR(*message) {
+			dpa := disconnect.DPA{
+				ResultCode:  diameter.ResultCodeSuccess,
+				OriginHost:  config.Capabilities.OriginHost,
+				OriginRealm: config.Capabilities.OriginRealm,
+			}
+			c.write(dpa.ToMessage(*message))
+			return
+		}
+		var writer AnswerWriter = c
+		if config.Dedup != nil {
+			if answer, ok := config.Dedup.begin(*message); !ok {
+				if answer != nil {
+					go c.write(*answer)
+				}
+				continue
+			}
+			writer = dedupWriter{AnswerWriter: c, cache: config.Dedup, request: *message}
+		}
+		if !c.server.startHandling() {
+			go writer.WriteAnswer(c.resultAnswer(*message, diameter.ResultCodeTooBusy))
+			continue
+		}
+		handler, request := c.server.handler(*message, c.unsupported), *message
+		go func() {
+			defer c.server.handling.Done()
+			handler(ctx, writer, request)
+		}()
+	}
+}
+
+// WriteAnswer writes the answer to the peer.
+func (c *conn) WriteAnswer(answer diameter.Message) error {
+	return c.write(answer)
+}
+
+// unsupported answers a request no handler is registered for.
+func (c *conn) unsupported(ctx context.Context, writer AnswerWriter, request diameter.Message) {
+	writer.WriteAnswer(c.resultAnswer(request, diameter.ResultCodeCommandUnsupported))
+}
+
+// resultAnswer creates an answer to the request with the protocol error.
+func (c *conn) resultAnswer(request diameter.Message, resultCode diameter.ResultCode) diameter.Message {
+	answer := request.NewAnswer(
+		diameter.NewAvpUint32(diameter.AvpResultCode, diameter.FlagMandatory, 0, uint32(resultCode)),
+		diameter.NewAvpString(diameter.AvpOriginHost, diameter.FlagMandatory, 0, c.server.config.Capabilities.OriginHost),
+		diameter.NewAvpString(diameter.AvpOriginRealm, diameter.FlagMandatory, 0, c.server.config.Capabilities.OriginRealm),
+	)
+	answer.SetError(true)
+	return answer
+}
+
+// errorAnswer creates the answer to a request that could not be decoded.
+func (c *conn) errorAnswer(header diameter.Header, err error) diameter.Message {
+	answer := diameter.ErrorAnswer(header, err)
+	answer.Avps = answer.Avps.
+		AddString(diameter.AvpOriginHost, diameter.FlagMandatory, 0, c.server.config.Capabilities.OriginHost).
+		AddString(diameter.AvpOriginRealm, diameter.FlagMandatory, 0, c.server.config.Capabilities.OriginRealm)
+	return answer
+}
+
+// write writes a message to the connection.
+func (c *conn) write(message diameter.Message) error {
+	bytes := message.ToBytes()
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+	if _, err := c.conn.Write(bytes); err != nil {
+		return err
+	}
+	c.server.config.Metrics.MessageSent(message)
+	return nil
+}
+
+// read reads a message from the connection.
+func (c *conn) read(reader *bufio.Reader) (*diameter.Message, error) {
+	message, err := diameter.ReadMessageFrom(reader)
+	if err != nil {
+		if metrics.IsParseError(err) {
+			c.server.config.Metrics.ParseError(err)
+		}
+		return nil, err
+	}
+	c.server.config.Metrics.MessageReceived(*message)
+	if c.server.config.OriginState != nil {
+		c.server.config.OriginState.Observe(*message)
+	}
+	return message, nil
+}
+
+// disconnect sends the peer a DPR, whose DPA ends serve, or closes the
+// connection if the capabilities exchange has not completed.
+func (c *conn) disconnect() {
+	c.server.mutex.Lock()
+	connected := c.connected
+	c.server.mutex.Unlock()
+	if !connected {
+		c.close()
+		return
+	}
+	config := c.server.config
+	dpr := disconnect.DPR{
+		OriginHost:      config.Capabilities.OriginHost,
+		OriginRealm:     config.Capabilities.OriginRealm,
+		DisconnectCause: disconnect.CauseRebooting,
+	}
+	if err := c.write(dpr.ToMessage(config.Ids.NextHopByHopId(), config.Ids.NextEndToEndId())); err != nil {
+		c.close()
+	}
+}
+
+// close closes the connection.
+func (c *conn) close() {
+	c.closeOnce.Do(func() {
+		c.conn.Close()
+	})
+}
@@ -0,0 +1,42 @@
+package serviceinfo
+
+import "github.com/tinybluerobots/radius-diameter-message/diameter"
+
+// Service-Information AVP codes (3GPP TS 32.299 and TS 29.061), all with the 3GPP vendor ID.
+const (
+	AvpThreeGPPChargingId              diameter.Code = 2
+	AvpThreeGPPPDPType                 diameter.Code = 3
+	AvpThreeGPPIMSIMCCMNC              diameter.Code = 8
+	AvpThreeGPPGGSNMCCMNC              diameter.Code = 9
+	AvpThreeGPPNSAPI                   diameter.Code = 10
+	AvpThreeGPPSelectionMode           diameter.Code = 12
+	AvpThreeGPPChargingCharacteristics diameter.Code = 13
+	AvpThreeGPPSGSNMCCMNC              diameter.Code = 18
+	AvpThreeGPPRATType                 diameter.Code = 21
+	AvpThreeGPPUserLocationInfo        diameter.Code = 22
+	AvpRoleOfNode                      diameter.Code = 829
+	AvpUserSessionId                   diameter.Code = 830
+	AvpCallingPartyAddress             diameter.Code = 831
+	AvpCalledPartyAddress              diameter.Code = 832
+	AvpIMSChargingIdentifier           diameter.Code = 841
+	AvpGGSNAddress                     diameter.Code = 847
+	AvpCauseCode                       diameter.Code = 861
+	AvpNodeFunctionality               diameter.Code = 862
+	AvpServiceInformation              diameter.Code = 873
+	AvpPSInformation                   diameter.Code = 874
+	AvpIMSInformation                  diameter.Code = 876
+	AvpPDPAddress                      diameter.Code = 1227
+	AvpSGSNAddress                     diameter.Code = 1228
+	AvpRequestedPartyAddress           diameter.Code = 1251
+	AvpDataCodingScheme                diameter.Code = 2001
+	AvpSMSInformation                  diameter.Code = 2000
+	AvpSMMessageType                   diameter.Code = 2007
+	AvpOriginatorSCCPAddress           diameter.Code = 2008
+	AvpSMDischargeTime                 diameter.Code = 2012
+	AvpSMSCAddress                     diameter.Code = 2017
+)
+
+// Base AVP codes used in PS-Information (RFC 4005), with no vendor ID.
+const (
+	AvpCalledStationId diameter.Code = 30
+)
@@ -0,0 +1,305 @@
+package serviceinfo
+
+import (
+	"net"
+	"time"
+
+	"github.com/tinybluerobots/radius-diameter-message/diameter"
+)
+
+const mandatoryFlags = diameter.FlagMandatory
+
+// RoleOfNode represents the value of a Role-Of-Node AVP.
+type RoleOfNode uint32
+
+const (
+	OriginatingRole RoleOfNode = 0
+	TerminatingRole RoleOfNode = 1
+	ForwardingRole  RoleOfNode = 2
+)
+
+// NodeFunctionality represents the value of a Node-Functionality AVP.
+type NodeFunctionality uint32
+
+const (
+	SCSCF NodeFunctionality = 0
+	PCSCF NodeFunctionality = 1
+	ICSCF NodeFunctionality = 2
+	MRFC  NodeFunctionality = 3
+	MGCF  NodeFunctionality = 4
+	BGCF  NodeFunctionality = 5
+	AS    NodeFunctionality = 6
+	IBCF  NodeFunctionality = 7
+)
+
+// SMMessageType represents the value of an SM-Message-Type AVP.
+type SMMessageType uint32
+
+const (
+	Submission       SMMessageType = 0
+	DeliveryReport   SMMessageType = 1
+	SMServiceRequest SMMessageType = 2
+)
+
+// PSInformation represents a PS-Information AVP. Zero values are omitted
+// from messages.
+type PSInformation struct {
+	ChargingId              uint32
+	PDPType                 uint32
+	PDPAddresses            []net.IP
+	SGSNAddresses           []net.IP
+	GGSNAddresses           []net.IP
+	IMSIMCCMNC              string
+	GGSNMCCMNC              string
+	SGSNMCCMNC              string
+	NSAPI                   string
+	CalledStationId         string
+	SelectionMode           string
+	ChargingCharacteristics string
+	RATType                 []byte
+	UserLocationInfo        []byte
+}
+
+// ToAvp converts the PS-Information to a grouped AVP.
+func (p PSInformation) ToAvp() diameter.Avp {
+	avps := diameter.NewAvps()
+	if p.ChargingId != 0 {
+		avps = avps.AddUint32(AvpThreeGPPChargingId, mandatoryFlags, diameter.Vendor3GPP, p.ChargingId)
+	}
+	if p.PDPType != 0 {
+		avps = avps.AddUint32(AvpThreeGPPPDPType, mandatoryFlags, diameter.Vendor3GPP, p.PDPType)
+	}
+	avps = addAddresses(avps, AvpPDPAddress, p.PDPAddresses)
+	avps = addAddresses(avps, AvpSGSNAddress, p.SGSNAddresses)
+	avps = addAddresses(avps, AvpGGSNAddress, p.GGSNAddresses)
+	avps = addString(avps, AvpThreeGPPIMSIMCCMNC, diameter.Vendor3GPP, p.IMSIMCCMNC)
+	avps = addString(avps, AvpThreeGPPGGSNMCCMNC, diameter.Vendor3GPP, p.GGSNMCCMNC)
+	avps = addString(avps, AvpThreeGPPNSAPI, diameter.Vendor3GPP, p.NSAPI)
+	avps = addString(avps, AvpCalledStationId, 0, p.CalledStationId)
+	avps = addString(avps, AvpThreeGPPSelectionMode, diameter.Vendor3GPP, p.SelectionMode)
+	avps = addString(avps, AvpThreeGPPChargingCharacteristics, diameter.Vendor3GPP, p.ChargingCharacteristics)
+	avps = addString(avps, AvpThreeGPPSGSNMCCMNC, diameter.Vendor3GPP, p.SGSNMCCMNC)
+	if p.RATType != nil {
+		avps = avps.Add(AvpThreeGPPRATType, mandatoryFlags, diameter.Vendor3GPP, p.RATType)
+	}
+	if p.UserLocationInfo != nil {
+		avps = avps.Add(AvpThreeGPPUserLocationInfo, mandatoryFlags, diameter.Vendor3GPP, p.UserLocationInfo)
+	}
+	return diameter.NewAvpGroup(AvpPSInformation, mandatoryFlags, diameter.Vendor3GPP, avps...)
+}
+
+// FromAvp reads the PS-Information from a grouped AVP.
+func (p *PSInformation) FromAvp(avp *diameter.Avp) {
+	group := avp.ToGroup()
+	p.ChargingId = group.GetFirst(AvpThreeGPPChargingId, diameter.Vendor3GPP).ToUint32OrDefault()
+	p.PDPType = group.GetFirst(AvpThreeGPPPDPType, diameter.Vendor3GPP).ToUint32OrDefault()
+	p.PDPAddresses = readAddresses(group, AvpPDPAddress)
+	p.SGSNAddresses = readAddresses(group, AvpSGSNAddress)
+	p.GGSNAddresses = readAddresses(group, AvpGGSNAddress)
+	p.IMSIMCCMNC = group.GetFirst(AvpThreeGPPIMSIMCCMNC, diameter.Vendor3GPP).ToStringOrDefault()
+	p.GGSNMCCMNC = group.GetFirst(AvpThreeGPPGGSNMCCMNC, diameter.Vendor3GPP).ToStringOrDefault()
+	p.SGSNMCCMNC = group.GetFirst(AvpThreeGPPSGSNMCCMNC, diameter.Vendor3GPP).ToStringOrDefault()
+	p.NSAPI = group.GetFirst(AvpThreeGPPNSAPI, diameter.Vendor3GPP).ToStringOrDefault()
+	p.CalledStationId = group.GetFirst(AvpCalledStationId, 0).ToStringOrDefault()
+	p.SelectionMode = group.GetFirst(AvpThreeGPPSelectionMode, diameter.Vendor3GPP).ToStringOrDefault()
+	p.ChargingCharacteristics = group.GetFirst(AvpThreeGPPChargingCharacteristics, diameter.Vendor3GPP).ToStringOrDefault()
+	p.RATType = group.GetFirst(AvpThreeGPPRATType, diameter.Vendor3GPP).ToData()
+	p.UserLocationInfo = group.GetFirst(AvpThreeGPPUserLocationInfo, diameter.Vendor3GPP).ToData()
+}
+
+// IMSInformation represents an IMS-Information AVP. Nil pointers and zero
+// values are omitted from messages.
+type IMSInformation struct {
+	RoleOfNode              *RoleOfNode
+	NodeFunctionality       NodeFunctionality
+	UserSessionId           string
+	CallingPartyAddresses   []string
+	CalledPartyAddress      string
+	RequestedPartyAddresses []string
+	IMSChargingIdentifier   string
+	CauseCode               *int32
+}
+
+// ToAvp converts the IMS-Information to a grouped AVP.
+func (i IMSInformation) ToAvp() diameter.Avp {
+	avps := diameter.NewAvps()
+	if i.RoleOfNode != nil {
+		avps = avps.AddUint32(AvpRoleOfNode, mandatoryFlags, diameter.Vendor3GPP, uint32(*i.RoleOfNode))
+	}
+	avps = avps.AddUint32(AvpNodeFunctionality, mandatoryFlags, diameter.Vendor3GPP, uint32(i.NodeFunctionality))
+	avps = addString(avps, AvpUserSessionId, diameter.Vendor3GPP, i.UserSessionId)
+	for _, address := range i.CallingPartyAddresses {
+		avps = avps.AddString(AvpCallingPartyAddress, mandatoryFlags, diameter.Vendor3GPP, address)
+	}
+	avps = addString(avps, AvpCalledPartyAddress, diameter.Vendor3GPP, i.CalledPartyAddress)
+	for _, address := range i.RequestedPartyAddresses {
+		avps = avps.AddString(AvpRequestedPartyAddress, mandatoryFlags, diameter.Vendor3GPP, address)
+	}
+	avps = addString(avps, AvpIMSChargingIdentifier, diameter.Vendor3GPP, i.IMSChargingIdentifier)
+	if i.CauseCode != nil {
+		avps = avps.AddUint32(AvpCauseCode, mandatoryFlags, diameter.Vendor3GPP, uint32(*i.CauseCode))
+	}
+	return diameter.NewAvpGroup(AvpIMSInformation, mandatoryFlags, diameter.Vendor3GPP, avps...)
+}
+
+// FromAvp reads the IMS-Information from a grouped AVP.
+func (i *IMSInformation) FromAvp(avp *diameter.Avp) {
+	group := avp.ToGroup()
+	i.RoleOfNode = nil
+	if roleOfNode, ok := group.GetFirst(AvpRoleOfNode, diameter.Vendor3GPP).ToUint32Ok(); ok {
+		i.RoleOfNode = (*RoleOfNode)(&roleOfNode)
+	}
+	i.NodeFunctionality = NodeFunctionality(group.GetFirst(AvpNodeFunctionality, diameter.Vendor3GPP).ToUint32OrDefault())
+	i.UserSessionId = group.GetFirst(AvpUserSessionId, diameter.Vendor3GPP).ToStringOrDefault()
+	i.CallingPartyAddresses = readStrings(group, AvpCallingPartyAddress)
+	i.CalledPartyAddress = group.GetFirst(AvpCalledPartyAddress, diameter.Vendor3GPP).ToStringOrDefault()
+	i.RequestedPartyAddresses = readStrings(group, AvpRequestedPartyAddress)
+	i.IMSChargingIdentifier = group.GetFirst(AvpIMSChargingIdentifier, diameter.Vendor3GPP).ToStringOrDefault()
+	i.CauseCode = nil
+	if causeCode, ok := group.GetFirst(AvpCauseCode, diameter.Vendor3GPP).ToUint32Ok(); ok {
+		value := int32(causeCode)
+		i.CauseCode = &value
+	}
+}
+
+// SMSInformation represents an SMS-Information AVP. Nil pointers and zero
+// values are omitted from messages.
+type SMSInformation struct {
+	DataCodingScheme      *int32
+	SMMessageType         *SMMessageType
+	OriginatorSCCPAddress net.IP
+	SMDischargeTime       time.Time
+	SMSCAddress           net.IP
+}
+
+// ToAvp converts the SMS-Information to a grouped AVP.
+func (s SMSInformation) ToAvp() diameter.Avp {
+	avps := diameter.NewAvps()
+	if s.DataCodingScheme != nil {
+		avps = avps.AddUint32(AvpDataCodingScheme, mandatoryFlags, diameter.Vendor3GPP, uint32(*s.DataCodingScheme))
+	}
+	if s.SMMessageType != nil {
+		avps = avps.AddUint32(AvpSMMessageType, mandatoryFlags, diameter.Vendor3GPP, uint32(*s.SMMessageType))
+	}
+	if s.OriginatorSCCPAddress != nil {
+		avps = avps.AddNetIP(AvpOriginatorSCCPAddress, mandatoryFlags, diameter.Vendor3GPP, s.OriginatorSCCPAddress)
+	}
+	if !s.SMDischargeTime.IsZero() {
+		avps = avps.AddTime(AvpSMDischargeTime, mandatoryFlags, diameter.Vendor3GPP, s.SMDischargeTime)
+	}
+	if s.SMSCAddress != nil {
+		avps = avps.AddNetIP(AvpSMSCAddress, mandatoryFlags, diameter.Vendor3GPP, s.SMSCAddress)
+	}
+	return diameter.NewAvpGroup(AvpSMSInformation, mandatoryFlags, diameter.Vendor3GPP, avps...)
+}
+
+// FromAvp reads the SMS-Information from a grouped AVP.
+func (s *SMSInformation) FromAvp(avp *diameter.Avp) {
+	group := avp.ToGroup()
+	s.DataCodingScheme = nil
+	if dataCodingScheme, ok := group.GetFirst(AvpDataCodingScheme, diameter.Vendor3GPP).ToUint32Ok(); ok {
+		value := int32(dataCodingScheme)
+		s.DataCodingScheme = &value
+	}
+	s.SMMessageType = nil
+	if messageType, ok := group.GetFirst(AvpSMMessageType, diameter.Vendor3GPP).ToUint32Ok(); ok {
+		s.SMMessageType = (*SMMessageType)(&messageType)
+	}
+	s.OriginatorSCCPAddress = group.GetFirst(AvpOriginatorSCCPAddress, diameter.Vendor3GPP).ToNetIPOrDefault()
+	s.SMDischargeTime = time.Time{}
+	if dischargeTime, ok := group.GetFirst(AvpSMDischargeTime, diameter.Vendor3GPP).ToTimeOk(); ok {
+		s.SMDischargeTime = dischargeTime
+	}
+	s.SMSCAddress = group.GetFirst(AvpSMSCAddress, diameter.Vendor3GPP).ToNetIPOrDefault()
+}
+
+// ServiceInformation represents a Service-Information AVP. Nil children are
+// omitted from messages.
+type ServiceInformation struct {
+	PSInformation  *PSInformation
+	IMSInformation *IMSInformation
+	SMSInformation *SMSInformation
+}
+
+// ToAvp converts the Service-Information to a grouped AVP.
+func (s ServiceInformation) ToAvp() diameter.Avp {
+	avps := diameter.NewAvps()
+	if s.PSInformation != nil {
+		avps = append(avps, s.PSInformation.ToAvp())
+	}
+	if s.IMSInformation != nil {
+		avps = append(avps, s.IMSInformation.ToAvp())
+	}
+	if s.SMSInformation != nil {
+		avps = append(avps, s.SMSInformation.ToAvp())
+	}
+	return diameter.NewAvpGroup(AvpServiceInformation, mandatoryFlags, diameter.Vendor3GPP, avps...)
+}
+
+// FromAvp reads the Service-Information from a grouped AVP.
+func (s *ServiceInformation) FromAvp(avp *diameter.Avp) {
+	group := avp.ToGroup()
+	s.PSInformation = nil
+	if psInformation := group.GetFirst(AvpPSInformation, diameter.Vendor3GPP); psInformation != nil {
+		s.PSInformation = &PSInformation{}
+		s.PSInformation.FromAvp(psInformation)
+	}
+	s.IMSInformation = nil
+	if imsInformation := group.GetFirst(AvpIMSInformation, diameter.Vendor3GPP); imsInformation != nil {
+		s.IMSInformation = &IMSInformation{}
+		s.IMSInformation.FromAvp(imsInformation)
+	}
+	s.SMSInformation = nil
+	if smsInformation := group.GetFirst(AvpSMSInformation, diameter.Vendor3GPP); smsInformation != nil {
+		s.SMSInformation = &SMSInformation{}
+		s.SMSInformation.FromAvp(smsInformation)
+	}
+}
+
+// Read reads the Service-Information AVP from the AVPs of a message, such
+// as the Avps of a creditcontrol.CCR, returning false if it is absent.
+func Read(avps diameter.Avps) (ServiceInformation, bool) {
+	serviceInformation := ServiceInformation{}
+	avp := avps.GetFirst(AvpServiceInformation, diameter.Vendor3GPP)
+	if avp == nil {
+		return serviceInformation, false
+	}
+	serviceInformation.FromAvp(avp)
+	return serviceInformation, true
+}
+
+// addString adds a string AVP if the value is not empty.
+func addString(avps diameter.Avps, code diameter.Code, vendorId diameter.VendorId, value string) diameter.Avps {
+	if value == "" {
+		return avps
+	}
+	return avps.AddString(code, mandatoryFlags, vendorId, value)
+}
+
+// addAddresses adds a 3GPP address AVP for each address.
+func addAddresses(avps diameter.Avps, code diameter.Code, addresses []net.IP) diameter.Avps {
+	for _, address := range addresses {
+		avps = avps.AddNetIP(code, mandatoryFlags, diameter.Vendor3GPP, address)
+	}
+	return avps
+}
+
+// readAddresses reads every 3GPP address AVP with the code.
+func readAddresses(avps diameter.Avps, code diameter.Code) []net.IP {
+	var addresses []net.IP
+	for _, avp := range avps.Get(code, diameter.Vendor3GPP) {
+		if address, ok := avp.ToNetIPOk(); ok {
+			addresses = append(addresses, address)
+		}
+	}
+	return addresses
+}
+
+// readStrings reads every 3GPP string AVP with the code.
+func readStrings(avps diameter.Avps, code diameter.Code) []string {
+	var values []string
+	for _, avp := range avps.Get(code, diameter.Vendor3GPP) {
+		values = append(values, avp.ToStringOrDefault())
+	}
+	return values
+}
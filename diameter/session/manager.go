@@ -0,0 +1,259 @@
+package session
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/tinybluerobots/radius-diameter-message/diameter"
+	"github.com/tinybluerobots/radius-diameter-message/diameter/creditcontrol"
+)
+
+// ExpiryReason describes why a session expired.
+type ExpiryReason int
+
+const (
+	// ExpiredIdle means no activity was seen for the idle timeout.
+	ExpiredIdle ExpiryReason = iota
+	// ExpiredLifetime means the lifetime granted in the answer passed.
+	ExpiredLifetime
+)
+
+// String returns the name of the reason.
+func (e ExpiryReason) String() string {
+	switch e {
+	case ExpiredIdle:
+		return "IDLE"
+	case ExpiredLifetime:
+		return "LIFETIME"
+	}
+	return "UNKNOWN"
+}
+
+// Session is a session held by a Manager.
+type Session struct {
+	Id           string
+	Request      diameter.Message
+	Answer       *diameter.Message
+	State        any
+	Created      time.Time
+	LastActivity time.Time
+	// Expires is when the granted lifetime ends, or zero if it does not.
+	Expires time.Time
+}
+
+// ExpiryFunc is called for each session that expires, after it is removed.
+type ExpiryFunc func(session Session, reason ExpiryReason)
+
+// ManagerConfig configures a Manager.
+type ManagerConfig struct {
+	// IdleTimeout expires sessions with no activity for this long. Zero disables it.
+	IdleTimeout time.Duration
+	// OnExpire is called for each expired session.
+	OnExpire ExpiryFunc
+}
+
+// Manager is a registry of sessions keyed by Session-Id. Sessions expire when
+// idle, or when the lifetime granted by the Authorization-Lifetime (plus
+// Auth-Grace-Period) or Validity-Time of their latest answer passes. It is
+// safe for concurrent use.
+type Manager struct {
+	config   ManagerConfig
+	mutex    sync.Mutex
+	sessions map[string]*Session
+}
+
+// NewManager creates an empty manager.
+func NewManager(config ManagerConfig) *Manager {
+	return &Manager{config: config, sessions: make(map[string]*Session)}
+}
+
+// Start registers the session begun by the request, replacing any session
+// with the same Session-Id. The answer, if not nil, sets its lifetime.
+func (m *Manager) Start(request diameter.Message, answer *diameter.Message, state any) Session {
+	now := time.Now()
+	session := &Session{
+		Id:           request.Avps.GetFirst(diameter.AvpSessionId, 0).ToStringOrDefault(),
+		Request:      request,
+		State:        state,
+		Created:      now,
+		LastActivity: now,
+	}
+	session.setAnswer(answer, now)
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.sessions[session.Id] = session
+	return *session
+}
+
+// Get returns the session with the Session-Id.
+func (m *Manager) Get(id string) (Session, bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	session, ok := m.sessions[id]
+	if !ok {
+		return Session{}, false
+	}
+	return *session, true
+}
+
+// Touch records activity on the session, resetting its idle timeout.
+func (m *Manager) Touch(id string) bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	session, ok := m.sessions[id]
+	if ok {
+		session.LastActivity = time.Now()
+	}
+	return ok
+}
+
+// Update records a new answer for the session, such as a CCA-Update or the
+// answer to a re-authorization, renewing its lifetime.
+func (m *Manager) Update(id string, answer diameter.Message) bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	session, ok := m.sessions[id]
+	if ok {
+		now := time.Now()
+		session.LastActivity = now
+		session.setAnswer(&answer, now)
+	}
+	return ok
+}
+
+// SetState replaces the application state of the session.
+func (m *Manager) SetState(id string, state any) bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	session, ok := m.sessions[id]
+	if ok {
+		session.State = state
+	}
+	return ok
+}
+
+// Remove removes the session without calling OnExpire.
+func (m *Manager) Remove(id string) (Session, bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	session, ok := m.sessions[id]
+	if !ok {
+		return Session{}, false
+	}
+	delete(m.sessions, id)
+	return *session, true
+}
+
+// Len returns the number of sessions.
+func (m *Manager) Len() int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return len(m.sessions)
+}
+
+// Expire removes the sessions that have expired at the time, calling
+// OnExpire for each, and returns them.
+func (m *Manager) Expire(now time.Time) []Session {
+	type expiry struct {
+		session Session
+		reason  ExpiryReason
+	}
+	var expired []expiry
+	m.mutex.Lock()
+	for id, session := range m.sessions {
+		switch {
+		case !session.Expires.IsZero() && !now.Before(session.Expires):
+			expired = append(expired, expiry{*session, ExpiredLifetime})
+		case m.config.IdleTimeout > 0 && now.Sub(session.LastActivity) >= m.config.IdleTimeout:
+			expired = append(expired, expiry{*session, ExpiredIdle})
+		default:
+			continue
+		}
+		delete(m.sessions, id)
+	}
+	m.mutex.Unlock()
+	sessions := make([]Session, 0, len(expired))
+	for _, e := range expired {
+		if m.config.OnExpire != nil {
+			m.config.OnExpire(e.session, e.reason)
+		}
+		sessions = append(sessions, e.session)
+	}
+	return sessions
+}
+
+// Run expires sessions every interval until the context is done.
+func (m *Manager) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case now := <-ticker.C:
+			m.Expire(now)
+		}
+	}
+}
+
+// setAnswer records the answer and the lifetime it grants.
+func (s *Session) setAnswer(answer *diameter.Message, now time.Time) {
+	s.Answer = answer
+	s.Expires = time.Time{}
+	if answer == nil {
+		return
+	}
+	if lifetime, ok := Lifetime(*answer); ok {
+		s.Expires = now.Add(lifetime)
+	}
+}
+
+// Lifetime returns the shortest lifetime the answer grants: the
+// Authorization-Lifetime plus any Auth-Grace-Period, or a Validity-Time at the
+// top level or in a Multiple-Services-Credit-Control AVP. An
+// Authorization-Lifetime of all ones means no limit.
+func Lifetime(answer diameter.Message) (time.Duration, bool) {
+	var lifetime time.Duration
+	found := false
+	add := func(seconds uint32) {
+		duration := time.Duration(seconds) * time.Second
+		if !found || duration < lifetime {
+			lifetime = duration
+			found = true
+		}
+	}
+	if authorizationLifetime, ok := answer.Avps.GetFirst(diameter.AvpAuthorizationLifetime, 0).ToUint32Ok(); ok && authorizationLifetime != 0xFFFFFFFF {
+		add(authorizationLifetime + answer.Avps.GetFirst(diameter.AvpAuthGracePeriod, 0).ToUint32OrDefault())
+	}
+	if validityTime, ok := answer.Avps.GetFirst(creditcontrol.AvpValidityTime, 0).ToUint32Ok(); ok {
+		add(validityTime)
+	}
+	for _, mscc := range answer.Avps.Get(creditcontrol.AvpMultipleServicesCreditControl, 0) {
+		if validityTime, ok := mscc.ToGroup().GetFirst(creditcontrol.AvpValidityTime, 0).ToUint32Ok(); ok {
+			add(validityTime)
+		}
+	}
+	return lifetime, found
+}
+
+// SendSTR returns an ExpiryFunc for a client that sends an STR for each
+// expired session, with DIAMETER_SESSION_TIMEOUT for idle sessions and
+// DIAMETER_AUTH_EXPIRED for those whose lifetime passed.
+func SendSTR(send func(STR)) ExpiryFunc {
+	return func(session Session, reason ExpiryReason) {
+		cause := AuthExpired
+		if reason == ExpiredIdle {
+			cause = SessionTimeout
+		}
+		send(NewSTR(session.Request, session.Answer, cause))
+	}
+}
+
+// SendASR returns an ExpiryFunc for a server with the given origin that
+// sends an ASR for each expired session.
+func SendASR(originHost string, originRealm string, send func(ASR)) ExpiryFunc {
+	return func(session Session, _ ExpiryReason) {
+		send(NewASR(session.Request, originHost, originRealm))
+	}
+}
@@ -0,0 +1,124 @@
+package session
+
+import (
+	"errors"
+
+	"github.com/tinybluerobots/radius-diameter-message/diameter"
+)
+
+// ReAuthRequestType represents the value of a Re-Auth-Request-Type AVP.
+type ReAuthRequestType uint32
+
+const (
+	AuthorizeOnly         ReAuthRequestType = 0
+	AuthorizeAuthenticate ReAuthRequestType = 1
+)
+
+// String returns the RFC 6733 name of the request type.
+func (r ReAuthRequestType) String() string {
+	switch r {
+	case AuthorizeOnly:
+		return "AUTHORIZE_ONLY"
+	case AuthorizeAuthenticate:
+		return "AUTHORIZE_AUTHENTICATE"
+	}
+	return "UNKNOWN"
+}
+
+// RAR represents a Re-Auth-Request. AVPs without a field are carried in
+// Avps and appended after the others.
+type RAR struct {
+	SessionId         string
+	OriginHost        string
+	OriginRealm       string
+	DestinationRealm  string
+	DestinationHost   string
+	AuthApplicationId diameter.ApplicationId
+	ReAuthRequestType ReAuthRequestType
+	UserName          string
+	OriginStateId     uint32
+	Avps              diameter.Avps
+}
+
+// rarCodes are the AVP codes RAR has fields for.
+var rarCodes = []diameter.Code{
+	diameter.AvpSessionId, diameter.AvpOriginHost, diameter.AvpOriginRealm, diameter.AvpDestinationRealm,
+	diameter.AvpDestinationHost, diameter.AvpAuthApplicationId, diameter.AvpReAuthRequestType,
+	diameter.AvpUserName, diameter.AvpOriginStateId,
+}
+
+// ToMessage converts the RAR to a Diameter message.
+func (r RAR) ToMessage(hopByHopId [4]byte, endToEndId [4]byte) diameter.Message {
+	avps := diameter.NewAvps()
+	avps = avps.AddString(diameter.AvpSessionId, mandatoryFlags, 0, r.SessionId)
+	avps = avps.AddString(diameter.AvpOriginHost, mandatoryFlags, 0, r.OriginHost)
+	avps = avps.AddString(diameter.AvpOriginRealm, mandatoryFlags, 0, r.OriginRealm)
+	avps = avps.AddString(diameter.AvpDestinationRealm, mandatoryFlags, 0, r.DestinationRealm)
+	avps = avps.AddString(diameter.AvpDestinationHost, mandatoryFlags, 0, r.DestinationHost)
+	avps = avps.AddUint32(diameter.AvpAuthApplicationId, mandatoryFlags, 0, uint32(r.AuthApplicationId))
+	avps = avps.AddUint32(diameter.AvpReAuthRequestType, mandatoryFlags, 0, uint32(r.ReAuthRequestType))
+	avps = addOptional(avps, r.UserName, r.OriginStateId)
+	avps = append(avps, r.Avps...)
+	return diameter.NewMessage(1, diameter.FlagRequest|diameter.FlagProxiable, diameter.CommandReAuth, r.AuthApplicationId, hopByHopId, endToEndId, avps...)
+}
+
+// FromMessage reads a RAR from a Diameter message.
+func (r *RAR) FromMessage(message diameter.Message) error {
+	if message.CommandCode != diameter.CommandReAuth || !message.IsRequest() {
+		return errors.New("message is not a Re-Auth-Request")
+	}
+	avps := message.Avps
+	r.SessionId = avps.GetFirst(diameter.AvpSessionId, 0).ToStringOrDefault()
+	r.OriginHost = avps.GetFirst(diameter.AvpOriginHost, 0).ToStringOrDefault()
+	r.OriginRealm = avps.GetFirst(diameter.AvpOriginRealm, 0).ToStringOrDefault()
+	r.DestinationRealm = avps.GetFirst(diameter.AvpDestinationRealm, 0).ToStringOrDefault()
+	r.DestinationHost = avps.GetFirst(diameter.AvpDestinationHost, 0).ToStringOrDefault()
+	r.AuthApplicationId = diameter.ApplicationId(avps.GetFirst(diameter.AvpAuthApplicationId, 0).ToUint32OrDefault())
+	r.ReAuthRequestType = ReAuthRequestType(avps.GetFirst(diameter.AvpReAuthRequestType, 0).ToUint32OrDefault())
+	r.UserName = avps.GetFirst(diameter.AvpUserName, 0).ToStringOrDefault()
+	r.OriginStateId = avps.GetFirst(diameter.AvpOriginStateId, 0).ToUint32OrDefault()
+	r.Avps = avps.Without(0, rarCodes...)
+	return nil
+}
+
+// RAA represents a Re-Auth-Answer. AVPs without a field are carried in Avps
+// and appended after the others.
+type RAA struct {
+	ResultCode    diameter.ResultCode
+	OriginHost    string
+	OriginRealm   string
+	UserName      string
+	OriginStateId uint32
+	ErrorMessage  string
+	Avps          diameter.Avps
+}
+
+// ToMessage converts the RAA to a Diameter message answering the request.
+func (r RAA) ToMessage(request diameter.Message) diameter.Message {
+	return answer(r).toMessage(request)
+}
+
+// FromMessage reads a RAA from a Diameter message.
+func (r *RAA) FromMessage(message diameter.Message) error {
+	return (*answer)(r).fromMessage(message, diameter.CommandReAuth, "Re-Auth-Answer")
+}
+
+// ReAuthorizer reauthorizes the session the RAR is for, returning the
+// Result-Code of the RAA, or false if there is no session with its Session-Id.
+type ReAuthorizer func(rar RAR) (diameter.ResultCode, bool)
+
+// AnswerReAuth answers a Re-Auth-Request from the given origin, answering
+// DIAMETER_UNKNOWN_SESSION_ID if reauthorize does not find the session.
+func AnswerReAuth(request diameter.Message, originHost string, originRealm string, reauthorize ReAuthorizer) (diameter.Message, error) {
+	rar := RAR{}
+	if err := rar.FromMessage(request); err != nil {
+		return diameter.Message{}, err
+	}
+	raa := RAA{ResultCode: diameter.ResultCodeUnknownSessionId, OriginHost: originHost, OriginRealm: originRealm}
+	if resultCode, ok := reauthorize(rar); ok {
+		raa.ResultCode = resultCode
+	}
+	answer := raa.ToMessage(request)
+	answer.SetError(raa.ResultCode.IsProtocolError())
+	return answer, nil
+}
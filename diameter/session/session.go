@@ -0,0 +1,69 @@
+package session
+
+import (
+	"errors"
+
+	"github.com/tinybluerobots/radius-diameter-message/diameter"
+)
+
+const mandatoryFlags = diameter.FlagMandatory
+
+// addOptional adds the string and uint32 AVPs common to session commands if they are set.
+func addOptional(avps diameter.Avps, userName string, originStateId uint32) diameter.Avps {
+	if userName != "" {
+		avps = avps.AddString(diameter.AvpUserName, mandatoryFlags, 0, userName)
+	}
+	if originStateId != 0 {
+		avps = avps.AddUint32(diameter.AvpOriginStateId, mandatoryFlags, 0, originStateId)
+	}
+	return avps
+}
+
+// answer holds the fields shared by the answers to session commands, which
+// convert to and from it: RAA, ASA and STA.
+type answer struct {
+	ResultCode    diameter.ResultCode
+	OriginHost    string
+	OriginRealm   string
+	UserName      string
+	OriginStateId uint32
+	ErrorMessage  string
+	Avps          diameter.Avps
+}
+
+// answerCodes are the AVP codes answer has fields for.
+var answerCodes = []diameter.Code{
+	diameter.AvpSessionId, diameter.AvpResultCode, diameter.AvpOriginHost, diameter.AvpOriginRealm,
+	diameter.AvpUserName, diameter.AvpOriginStateId, diameter.AvpErrorMessage, diameter.AvpProxyInfo,
+}
+
+// toMessage converts the answer to a Diameter message answering the request.
+func (a answer) toMessage(request diameter.Message) diameter.Message {
+	avps := diameter.NewAvps()
+	avps = avps.AddUint32(diameter.AvpResultCode, mandatoryFlags, 0, uint32(a.ResultCode))
+	avps = avps.AddString(diameter.AvpOriginHost, mandatoryFlags, 0, a.OriginHost)
+	avps = avps.AddString(diameter.AvpOriginRealm, mandatoryFlags, 0, a.OriginRealm)
+	avps = addOptional(avps, a.UserName, a.OriginStateId)
+	if a.ErrorMessage != "" {
+		avps = avps.AddString(diameter.AvpErrorMessage, 0, 0, a.ErrorMessage)
+	}
+	avps = append(avps, a.Avps...)
+	return request.NewAnswer(avps...)
+}
+
+// fromMessage reads the answer from a Diameter message, which must be an
+// answer with the command code.
+func (a *answer) fromMessage(message diameter.Message, commandCode diameter.CommandCode, name string) error {
+	if message.CommandCode != commandCode || message.IsRequest() {
+		return errors.New("message is not a " + name)
+	}
+	avps := message.Avps
+	a.ResultCode = diameter.ResultCode(avps.GetFirst(diameter.AvpResultCode, 0).ToUint32OrDefault())
+	a.OriginHost = avps.GetFirst(diameter.AvpOriginHost, 0).ToStringOrDefault()
+	a.OriginRealm = avps.GetFirst(diameter.AvpOriginRealm, 0).ToStringOrDefault()
+	a.UserName = avps.GetFirst(diameter.AvpUserName, 0).ToStringOrDefault()
+	a.OriginStateId = avps.GetFirst(diameter.AvpOriginStateId, 0).ToUint32OrDefault()
+	a.ErrorMessage = avps.GetFirst(diameter.AvpErrorMessage, 0).ToStringOrDefault()
+	a.Avps = avps.Without(0, answerCodes...)
+	return nil
+}
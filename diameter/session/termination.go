@@ -0,0 +1,251 @@
+package session
+
+import (
+	"errors"
+
+	"github.com/tinybluerobots/radius-diameter-message/diameter"
+)
+
+// TerminationCause represents the value of a Termination-Cause AVP.
+type TerminationCause uint32
+
+const (
+	Logout             TerminationCause = 1
+	ServiceNotProvided TerminationCause = 2
+	BadAnswer          TerminationCause = 3
+	Administrative     TerminationCause = 4
+	LinkBroken         TerminationCause = 5
+	AuthExpired        TerminationCause = 6
+	UserMoved          TerminationCause = 7
+	SessionTimeout     TerminationCause = 8
+)
+
+// String returns the RFC 6733 name of the cause.
+func (t TerminationCause) String() string {
+	switch t {
+	case Logout:
+		return "DIAMETER_LOGOUT"
+	case ServiceNotProvided:
+		return "DIAMETER_SERVICE_NOT_PROVIDED"
+	case BadAnswer:
+		return "DIAMETER_BAD_ANSWER"
+	case Administrative:
+		return "DIAMETER_ADMINISTRATIVE"
+	case LinkBroken:
+		return "DIAMETER_LINK_BROKEN"
+	case AuthExpired:
+		return "DIAMETER_AUTH_EXPIRED"
+	case UserMoved:
+		return "DIAMETER_USER_MOVED"
+	case SessionTimeout:
+		return "DIAMETER_SESSION_TIMEOUT"
+	}
+	return "UNKNOWN"
+}
+
+// ASR represents an Abort-Session-Request. AVPs without a field are carried
+// in Avps and appended after the others.
+type ASR struct {
+	SessionId         string
+	OriginHost        string
+	OriginRealm       string
+	DestinationRealm  string
+	DestinationHost   string
+	AuthApplicationId diameter.ApplicationId
+	UserName          string
+	OriginStateId     uint32
+	Avps              diameter.Avps
+}
+
+// asrCodes are the AVP codes ASR has fields for.
+var asrCodes = []diameter.Code{
+	diameter.AvpSessionId, diameter.AvpOriginHost, diameter.AvpOriginRealm, diameter.AvpDestinationRealm,
+	diameter.AvpDestinationHost, diameter.AvpAuthApplicationId, diameter.AvpUserName, diameter.AvpOriginStateId,
+}
+
+// NewASR creates an ASR for the session the request started, sent by the
+// node with the given origin that received the request.
+func NewASR(request diameter.Message, originHost string, originRealm string) ASR {
+	return ASR{
+		SessionId:         request.Avps.GetFirst(diameter.AvpSessionId, 0).ToStringOrDefault(),
+		OriginHost:        originHost,
+		OriginRealm:       originRealm,
+		DestinationRealm:  request.Avps.GetFirst(diameter.AvpOriginRealm, 0).ToStringOrDefault(),
+		DestinationHost:   request.Avps.GetFirst(diameter.AvpOriginHost, 0).ToStringOrDefault(),
+		AuthApplicationId: request.ApplicationId,
+		UserName:          request.Avps.GetFirst(diameter.AvpUserName, 0).ToStringOrDefault(),
+	}
+}
+
+// ToMessage converts the ASR to a Diameter message.
+func (a ASR) ToMessage(hopByHopId [4]byte, endToEndId [4]byte) diameter.Message {
+	avps := diameter.NewAvps()
+	avps = avps.AddString(diameter.AvpSessionId, mandatoryFlags, 0, a.SessionId)
+	avps = avps.AddString(diameter.AvpOriginHost, mandatoryFlags, 0, a.OriginHost)
+	avps = avps.AddString(diameter.AvpOriginRealm, mandatoryFlags, 0, a.OriginRealm)
+	avps = avps.AddString(diameter.AvpDestinationRealm, mandatoryFlags, 0, a.DestinationRealm)
+	avps = avps.AddString(diameter.AvpDestinationHost, mandatoryFlags, 0, a.DestinationHost)
+	avps = avps.AddUint32(diameter.AvpAuthApplicationId, mandatoryFlags, 0, uint32(a.AuthApplicationId))
+	avps = addOptional(avps, a.UserName, a.OriginStateId)
+	avps = append(avps, a.Avps...)
+	return diameter.NewMessage(1, diameter.FlagRequest|diameter.FlagProxiable, diameter.CommandAbortSession, a.AuthApplicationId, hopByHopId, endToEndId, avps...)
+}
+
+// FromMessage reads an ASR from a Diameter message.
+func (a *ASR) FromMessage(message diameter.Message) error {
+	if message.CommandCode != diameter.CommandAbortSession || !message.IsRequest() {
+		return errors.New("message is not an Abort-Session-Request")
+	}
+	avps := message.Avps
+	a.SessionId = avps.GetFirst(diameter.AvpSessionId, 0).ToStringOrDefault()
+	a.OriginHost = avps.GetFirst(diameter.AvpOriginHost, 0).ToStringOrDefault()
+	a.OriginRealm = avps.GetFirst(diameter.AvpOriginRealm, 0).ToStringOrDefault()
+	a.DestinationRealm = avps.GetFirst(diameter.AvpDestinationRealm, 0).ToStringOrDefault()
+	a.DestinationHost = avps.GetFirst(diameter.AvpDestinationHost, 0).ToStringOrDefault()
+	a.AuthApplicationId = diameter.ApplicationId(avps.GetFirst(diameter.AvpAuthApplicationId, 0).ToUint32OrDefault())
+	a.UserName = avps.GetFirst(diameter.AvpUserName, 0).ToStringOrDefault()
+	a.OriginStateId = avps.GetFirst(diameter.AvpOriginStateId, 0).ToUint32OrDefault()
+	a.Avps = avps.Without(0, asrCodes...)
+	return nil
+}
+
+// STR represents a Session-Termination-Request. AVPs without a field are
+// carried in Avps and appended after the others.
+type STR struct {
+	SessionId         string
+	OriginHost        string
+	OriginRealm       string
+	DestinationRealm  string
+	DestinationHost   string
+	AuthApplicationId diameter.ApplicationId
+	TerminationCause  TerminationCause
+	UserName          string
+	Class             [][]byte
+	OriginStateId     uint32
+	Avps              diameter.Avps
+}
+
+// strCodes are the AVP codes STR has fields for.
+var strCodes = []diameter.Code{
+	diameter.AvpSessionId, diameter.AvpOriginHost, diameter.AvpOriginRealm, diameter.AvpDestinationRealm,
+	diameter.AvpDestinationHost, diameter.AvpAuthApplicationId, diameter.AvpTerminationCause,
+	diameter.AvpUserName, diameter.AvpClass, diameter.AvpOriginStateId,
+}
+
+// NewSTR creates an STR for the session the request started, sent by the
+// request's originator. If the answer is not nil, the STR is addressed to
+// the node that answered and carries the Class AVPs it returned.
+func NewSTR(request diameter.Message, answer *diameter.Message, cause TerminationCause) STR {
+	str := STR{
+		SessionId:         request.Avps.GetFirst(diameter.AvpSessionId, 0).ToStringOrDefault(),
+		OriginHost:        request.Avps.GetFirst(diameter.AvpOriginHost, 0).ToStringOrDefault(),
+		OriginRealm:       request.Avps.GetFirst(diameter.AvpOriginRealm, 0).ToStringOrDefault(),
+		DestinationRealm:  request.Avps.GetFirst(diameter.AvpDestinationRealm, 0).ToStringOrDefault(),
+		DestinationHost:   request.Avps.GetFirst(diameter.AvpDestinationHost, 0).ToStringOrDefault(),
+		AuthApplicationId: request.ApplicationId,
+		TerminationCause:  cause,
+		UserName:          request.Avps.GetFirst(diameter.AvpUserName, 0).ToStringOrDefault(),
+	}
+	if answer != nil {
+		if originRealm, ok := answer.Avps.GetFirst(diameter.AvpOriginRealm, 0).ToStringOk(); ok {
+			str.DestinationRealm = originRealm
+		}
+		if originHost, ok := answer.Avps.GetFirst(diameter.AvpOriginHost, 0).ToStringOk(); ok {
+			str.DestinationHost = originHost
+		}
+		for _, class := range answer.Avps.Get(diameter.AvpClass, 0) {
+			str.Class = append(str.Class, class.ToData())
+		}
+	}
+	return str
+}
+
+// ToMessage converts the STR to a Diameter message.
+func (s STR) ToMessage(hopByHopId [4]byte, endToEndId [4]byte) diameter.Message {
+	avps := diameter.NewAvps()
+	avps = avps.AddString(diameter.AvpSessionId, mandatoryFlags, 0, s.SessionId)
+	avps = avps.AddString(diameter.AvpOriginHost, mandatoryFlags, 0, s.OriginHost)
+	avps = avps.AddString(diameter.AvpOriginRealm, mandatoryFlags, 0, s.OriginRealm)
+	avps = avps.AddString(diameter.AvpDestinationRealm, mandatoryFlags, 0, s.DestinationRealm)
+	avps = avps.AddUint32(diameter.AvpAuthApplicationId, mandatoryFlags, 0, uint32(s.AuthApplicationId))
+	avps = avps.AddUint32(diameter.AvpTerminationCause, mandatoryFlags, 0, uint32(s.TerminationCause))
+	if s.UserName != "" {
+		avps = avps.AddString(diameter.AvpUserName, mandatoryFlags, 0, s.UserName)
+	}
+	if s.DestinationHost != "" {
+		avps = avps.AddString(diameter.AvpDestinationHost, mandatoryFlags, 0, s.DestinationHost)
+	}
+	for _, class := range s.Class {
+		avps = avps.Add(diameter.AvpClass, mandatoryFlags, 0, class)
+	}
+	avps = addOptional(avps, "", s.OriginStateId)
+	avps = append(avps, s.Avps...)
+	return diameter.NewMessage(1, diameter.FlagRequest|diameter.FlagProxiable, diameter.CommandSessionTermination, s.AuthApplicationId, hopByHopId, endToEndId, avps...)
+}
+
+// FromMessage reads an STR from a Diameter message.
+func (s *STR) FromMessage(message diameter.Message) error {
+	if message.CommandCode != diameter.CommandSessionTermination || !message.IsRequest() {
+		return errors.New("message is not a Session-Termination-Request")
+	}
+	avps := message.Avps
+	s.SessionId = avps.GetFirst(diameter.AvpSessionId, 0).ToStringOrDefault()
+	s.OriginHost = avps.GetFirst(diameter.AvpOriginHost, 0).ToStringOrDefault()
+	s.OriginRealm = avps.GetFirst(diameter.AvpOriginRealm, 0).ToStringOrDefault()
+	s.DestinationRealm = avps.GetFirst(diameter.AvpDestinationRealm, 0).ToStringOrDefault()
+	s.DestinationHost = avps.GetFirst(diameter.AvpDestinationHost, 0).ToStringOrDefault()
+	s.AuthApplicationId = diameter.ApplicationId(avps.GetFirst(diameter.AvpAuthApplicationId, 0).ToUint32OrDefault())
+	s.TerminationCause = TerminationCause(avps.GetFirst(diameter.AvpTerminationCause, 0).ToUint32OrDefault())
+	s.UserName = avps.GetFirst(diameter.AvpUserName, 0).ToStringOrDefault()
+	s.Class = nil
+	for _, class := range avps.Get(diameter.AvpClass, 0) {
+		s.Class = append(s.Class, class.ToData())
+	}
+	s.OriginStateId = avps.GetFirst(diameter.AvpOriginStateId, 0).ToUint32OrDefault()
+	s.Avps = avps.Without(0, strCodes...)
+	return nil
+}
+
+// ASA represents an Abort-Session-Answer. AVPs without a field are carried
+// in Avps and appended after the others.
+type ASA struct {
+	ResultCode    diameter.ResultCode
+	OriginHost    string
+	OriginRealm   string
+	UserName      string
+	OriginStateId uint32
+	ErrorMessage  string
+	Avps          diameter.Avps
+}
+
+// ToMessage converts the ASA to a Diameter message answering the request.
+func (a ASA) ToMessage(request diameter.Message) diameter.Message {
+	return answer(a).toMessage(request)
+}
+
+// FromMessage reads an ASA from a Diameter message.
+func (a *ASA) FromMessage(message diameter.Message) error {
+	return (*answer)(a).fromMessage(message, diameter.CommandAbortSession, "Abort-Session-Answer")
+}
+
+// STA represents a Session-Termination-Answer. AVPs without a field are
+// carried in Avps and appended after the others.
+type STA struct {
+	ResultCode    diameter.ResultCode
+	OriginHost    string
+	OriginRealm   string
+	UserName      string
+	OriginStateId uint32
+	ErrorMessage  string
+	Avps          diameter.Avps
+}
+
+// ToMessage converts the STA to a Diameter message answering the request.
+func (s STA) ToMessage(request diameter.Message) diameter.Message {
+	return answer(s).toMessage(request)
+}
+
+// FromMessage reads an STA from a Diameter message.
+func (s *STA) FromMessage(message diameter.Message) error {
+	return (*answer)(s).fromMessage(message, diameter.CommandSessionTermination, "Session-Termination-Answer")
+}
@@ -0,0 +1,127 @@
+package diameter
+
+import (
+	"io"
+	"net"
+)
+
+// ReadMessageFrom reads a single message from a stream such as a TCP
+// connection, using the length in the header to frame it. It returns io.EOF
+// if the stream ends cleanly before the message starts, a *LimitError if the
+// length exceeds DefaultMaxMessageSize, and a *MessageError if a complete
+// message was read but could not be decoded.
+func ReadMessageFrom(reader io.Reader) (*Message, error) {
+	message, _, err := readMessageFrom(reader, DefaultMaxMessageSize, ReadMessage)
+	return message, err
+}
+
+// ReadFrom reads a single message from a stream into the message, as
+// ReadMessageFrom does, returning the number of bytes read. It implements
+// io.ReaderFrom, though it stops at the end of the message rather than of
+// the stream.
+func (m *Message) ReadFrom(reader io.Reader) (int64, error) {
+	message, n, err := readMessageFrom(reader, DefaultMaxMessageSize, ReadMessage)
+	if err != nil {
+		return int64(n), err
+	}
+	*m = *message
+	return int64(n), nil
+}
+
+// readMessageFrom reads a single message of up to the maximum length from a
+// stream and decodes it with the function, returning it and the number of
+// bytes read.
+func readMessageFrom(reader io.Reader, maxLength int, decode func([]byte) (*Message, error)) (*Message, int, error) {
+	prefix := make([]byte, 4)
+	n, err := io.ReadFull(reader, prefix)
+	if err != nil {
+		return nil, n, err
+	}
+	length := readUInt24(prefix[1:4])
+	if length < 20 {
+		return nil, n, &InvalidLengthError{Declared: int(length), Available: n}
+	}
+	if int(length) > maxLength {
+		return nil, n, &LimitError{Limit: "message size", Max: maxLength}
+	}
+	bytes := make([]byte, length)
+	copy(bytes, prefix)
+	read, err := io.ReadFull(reader, bytes[4:])
+	n += read
+	if err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, n, err
+	}
+	header, err := ReadHeader(bytes)
+	if err != nil {
+		return nil, n, &MessageError{Header: header, Err: err}
+	}
+	message, err := decode(bytes)
+	if err != nil {
+		return message, n, &MessageError{Header: header, Err: err}
+	}
+	return message, n, nil
+}
+
+// zeroPadding holds the padding written after AVPs read without it.
+var zeroPadding [3]byte
+
+// WriteTo writes the message to a stream such as a connection, as a vector
+// of its header and the data of its AVPs rather than encoded into one byte
+// slice, returning the number of bytes written. It implements io.WriterTo.
+func (message Message) WriteTo(writer io.Writer) (int64, error) {
+	headers := message.appendHeader(make([]byte, 0, 20+12*len(message.Avps)))
+	buffers := make(net.Buffers, 1, 1+3*len(message.Avps))
+	buffers[0] = headers
+	for _, avp := range message.Avps {
+		start := len(headers)
+		if len(avp.Data) != int(avp.length)-avp.headerLength() {
+			headers = avp.AppendBytes(headers)
+			buffers = append(buffers, headers[start:])
+			continue
+		}
+		headers = avp.appendHeader(headers)
+		buffers = append(buffers, headers[start:], avp.Data)
+		if len(avp.pad) == int(avp.padding) {
+			buffers = append(buffers, avp.pad)
+		} else {
+			buffers = append(buffers, zeroPadding[:avp.padding])
+		}
+	}
+	return buffers.WriteTo(writer)
+}
+
+// EncodeAll appends the messages back to back to the byte slice and returns
+// the extended slice, so that a burst of messages can share one write to a
+// stream.
+func EncodeAll(messages []Message, dst []byte) []byte {
+	for _, message := range messages {
+		dst = message.AppendBytes(dst)
+	}
+	return dst
+}
+
+// SplitMessages frames the messages written back to back in a byte slice,
+// such as those read from a stream, using the length in each header. It
+// returns the complete messages, referring to the byte slice, and the bytes
+// of an incomplete message that follows them. It returns an error if a
+// header has an invalid length, along with the messages before it.
+func SplitMessages(bytes []byte) ([][]byte, []byte, error) {
+	var messages [][]byte
+	offset := 0
+	for len(bytes) >= 4 {
+		length := int(readUInt24(bytes[1:4]))
+		if length < 20 {
+			return messages, bytes, &InvalidLengthError{Offset: offset, Declared: length, Available: len(bytes)}
+		}
+		if length > len(bytes) {
+			break
+		}
+		messages = append(messages, bytes[:length:length])
+		bytes = bytes[length:]
+		offset += length
+	}
+	return messages, bytes, nil
+}
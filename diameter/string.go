@@ -0,0 +1,176 @@
+package diameter
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/tinybluerobots/radius-diameter-message/enterprise"
+)
+
+// String returns an indented, human-readable dump of the Diameter message.
+func (m Message) String() string {
+	return m.Describe(nil)
+}
+
+// Describe returns an indented, human-readable dump of the Diameter message,
+// using the dictionary, which may be nil, to name commands and decode AVPs.
+func (m Message) Describe(dictionary *Dictionary) string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "Diameter Protocol\n")
+	fmt.Fprintf(&builder, "  Version: %d\n", m.Version)
+	fmt.Fprintf(&builder, "  Length: %d\n", m.length())
+	fmt.Fprintf(&builder, "  Flags: 0x%02x %s\n", byte(m.Flags), messageFlagsString(m.Flags))
+	fmt.Fprintf(&builder, "  Command Code: %d%s\n", m.CommandCode, commandName(dictionary, m.CommandCode, m.Flags))
+	fmt.Fprintf(&builder, "  ApplicationId: %d\n", m.ApplicationId)
+	fmt.Fprintf(&builder, "  Hop-by-Hop Identifier: 0x%x\n", m.HopByHopId[:])
+	fmt.Fprintf(&builder, "  End-to-End Identifier: 0x%x\n", m.EndToEndId[:])
+	for _, avp := range m.Avps {
+		writeAvp(&builder, dictionary, avp, "  ")
+	}
+	return builder.String()
+}
+
+// String returns an indented, human-readable dump of the AVP.
+func (a Avp) String() string {
+	return a.Describe(nil)
+}
+
+// Describe returns an indented, human-readable dump of the AVP, using the
+// dictionary, which may be nil, to name and decode it.
+func (a Avp) Describe(dictionary *Dictionary) string {
+	var builder strings.Builder
+	writeAvp(&builder, dictionary, a, "")
+	return builder.String()
+}
+
+// commandName returns the dictionary name of a command, suffixed by request or answer.
+func commandName(dictionary *Dictionary, commandCode CommandCode, flags Flags) string {
+	name := dictionary.Command(commandCode)
+	if name == nil {
+		return ""
+	}
+	if flags.IsRequest() {
+		return " " + *name + "-Request"
+	}
+	return " " + *name + "-Answer"
+}
+
+// messageFlagsString decodes the R, P, E and T bits of a message header.
+func messageFlagsString(flags Flags) string {
+	return flagsString(flags, "RPET")
+}
+
+// avpFlagsString decodes the V, M and P bits of an AVP header.
+func avpFlagsString(flags Flags) string {
+	return flagsString(flags, "VMP")
+}
+
+// flagsString renders each named bit from the most significant, using '-' for unset bits.
+func flagsString(flags Flags, names string) string {
+	bytes := []byte(names)
+	for i := range bytes {
+		if flags&(0x80>>i) == 0 {
+			bytes[i] = '-'
+		}
+	}
+	return string(bytes)
+}
+
+// vendorName returns the registered name of a vendor ID, or the number if it has none.
+func vendorName(vendorId VendorId) string {
+	if name := enterprise.Name(uint32(vendorId)); name != nil {
+		return *name
+	}
+	return strconv.FormatUint(uint64(vendorId), 10)
+}
+
+// writeAvp writes a single AVP line, followed by any nested or hex-dumped data.
+func writeAvp(builder *strings.Builder, dictionary *Dictionary, avp Avp, indent string) {
+	definition := dictionary.Avp(avp.Code, avp.VendorId)
+	name := "Unknown"
+	if definition != nil {
+		name = definition.Name
+	}
+	fmt.Fprintf(builder, "%sAVP: %s(%d) l=%d f=%s", indent, name, avp.Code, avp.length, avpFlagsString(avp.Flags))
+	if avp.VendorId != 0 {
+		fmt.Fprintf(builder, " vnd=%s", vendorName(avp.VendorId))
+	}
+	if definition != nil && definition.Type == Grouped {
+		builder.WriteString("\n")
+		for _, child := range avp.ToGroup() {
+			writeAvp(builder, dictionary, child, indent+"  ")
+		}
+		return
+	}
+	if value, ok := formatValue(definition, avp.Data); ok {
+		fmt.Fprintf(builder, " val=%s\n", value)
+		return
+	}
+	if len(avp.Data) <= 16 {
+		fmt.Fprintf(builder, " val=%x\n", []byte(avp.Data))
+		return
+	}
+	builder.WriteString("\n")
+	for _, line := range strings.SplitAfter(strings.TrimSuffix(hex.Dump(avp.Data), "\n"), "\n") {
+		builder.WriteString(indent + "    " + line)
+	}
+	builder.WriteString("\n")
+}
+
+// formatValue decodes data according to the definition's type, reporting false
+// when there is no definition or the data is malformed for its type.
+func formatValue(definition *AvpDefinition, data avpData) (string, bool) {
+	if definition == nil {
+		return "", false
+	}
+	switch definition.Type {
+	case Integer32, Unsigned32, Enumerated, Float32:
+		if len(data) != 4 {
+			return "", false
+		}
+	case Integer64, Unsigned64, Float64:
+		if len(data) != 8 {
+			return "", false
+		}
+	}
+	switch definition.Type {
+	case Integer32:
+		return strconv.FormatInt(int64(int32(binary.BigEndian.Uint32(data))), 10), true
+	case Integer64:
+		return strconv.FormatInt(int64(binary.BigEndian.Uint64(data)), 10), true
+	case Unsigned32:
+		return strconv.FormatUint(uint64(binary.BigEndian.Uint32(data)), 10), true
+	case Unsigned64:
+		return strconv.FormatUint(binary.BigEndian.Uint64(data), 10), true
+	case Float32:
+		return strconv.FormatFloat(float64(math.Float32frombits(binary.BigEndian.Uint32(data))), 'g', -1, 32), true
+	case Float64:
+		return strconv.FormatFloat(math.Float64frombits(binary.BigEndian.Uint64(data)), 'g', -1, 64), true
+	case Enumerated:
+		value := binary.BigEndian.Uint32(data)
+		if name, ok := definition.Values[value]; ok {
+			return fmt.Sprintf("%s (%d)", name, value), true
+		}
+		return strconv.FormatUint(uint64(value), 10), true
+	case Address:
+		if len(data) == 6 && data[1] == 1 || len(data) == 18 && data[1] == 2 {
+			return net.IP(data[2:]).String(), true
+		}
+	case Time:
+		avp := Avp{Data: data}
+		if len(data) == 4 {
+			return avp.ToTime().UTC().Format("2006-01-02 15:04:05 UTC"), true
+		}
+	case UTF8String, DiameterIdentity, DiameterURI:
+		if utf8.Valid(data) {
+			return strconv.Quote(string(data)), true
+		}
+	}
+	return "", false
+}
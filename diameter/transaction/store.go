@@ -0,0 +1,129 @@
+package transaction
+
+import (
+	"sync"
+	"time"
+
+	"github.com/tinybluerobots/radius-diameter-message/diameter"
+)
+
+// Record is a pending transaction checkpointed to a Store.
+type Record struct {
+	Request diameter.Message
+	Started time.Time
+	// Ids is the state of the table's IdGenerator, if it has one, when the
+	// transaction started.
+	Ids diameter.IdState
+}
+
+// Store persists the transactions of a table, so that after a restart the
+// answers to requests sent before it are reported as late rather than
+// unsolicited. A record is saved when its transaction starts and deleted
+// once it is answered, or forgotten after the late window. Implementations
+// must be safe for concurrent use.
+type Store interface {
+	// Save saves the record, replacing any with the same Hop-by-Hop identifier.
+	Save(record Record) error
+	// Delete deletes the record with the Hop-by-Hop identifier, if any.
+	Delete(hopByHopId [4]byte) error
+	// Load returns every saved record.
+	Load() ([]Record, error)
+}
+
+// MemoryStore is a Store keeping records in memory, which survives
+// replacing a table within a process.
+type MemoryStore struct {
+	mutex   sync.Mutex
+	records map[[4]byte]Record
+}
+
+// NewMemoryStore creates an empty store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: make(map[[4]byte]Record)}
+}
+
+// Save saves the record.
+func (s *MemoryStore) Save(record Record) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.records[record.Request.HopByHopId] = record
+	return nil
+}
+
+// Delete deletes the record with the Hop-by-Hop identifier.
+func (s *MemoryStore) Delete(hopByHopId [4]byte) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.records, hopByHopId)
+	return nil
+}
+
+// Load returns every saved record.
+func (s *MemoryStore) Load() ([]Record, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	records := make([]Record, 0, len(s.records))
+	for _, record := range s.records {
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// Restore loads the transactions checkpointed to the store by an earlier
+// table, such as one in the process before a restart, and remembers them
+// for the late window so their answers are reported as late. Nobody waits
+// for them, so they are not made pending again. The table's IdGenerator, if
+// it has one, resumes after the identifiers of the latest record, so new
+// requests do not reuse those of the restored ones.
+func (t *Table) Restore() error {
+	if t.config.Store == nil {
+		return nil
+	}
+	records, err := t.config.Store.Load()
+	if err != nil {
+		return err
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	until := time.Now().Add(t.config.LateWindow)
+	for _, record := range records {
+		id := record.Request.HopByHopId
+		if _, ok := t.pending[id]; !ok {
+			t.remember(id, until)
+		}
+	}
+	if t.config.Ids != nil && len(records) > 0 {
+		latest := records[0].Ids
+		for _, record := range records[1:] {
+			if record.Ids.After(latest) {
+				latest = record.Ids
+			}
+		}
+		t.config.Ids.Resume(latest)
+	}
+	return nil
+}
+
+// save checkpoints a started transaction to the store, if any.
+func (t *Table) save(transaction *Transaction) error {
+	if t.config.Store == nil {
+		return nil
+	}
+	record := Record{Request: transaction.Request, Started: transaction.Started}
+	if t.config.Ids != nil {
+		record.Ids = t.config.Ids.State()
+	}
+	return t.config.Store.Save(record)
+}
+
+// forget deletes the records of finished transactions from the store, if
+// any. Failures are ignored: a record left behind is forgotten by the next
+// table to restore it once its late window passes.
+func (t *Table) forget(ids ...[4]byte) {
+	if t.config.Store == nil {
+		return
+	}
+	for _, id := range ids {
+		t.config.Store.Delete(id)
+	}
+}
@@ -0,0 +1,353 @@
+package transaction
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/tinybluerobots/radius-diameter-message/diameter"
+)
+
+var (
+	// ErrDuplicate is returned when a request's Hop-by-Hop identifier is already pending.
+	ErrDuplicate = errors.New("hop-by-hop identifier already pending")
+	// ErrClosed is returned when waiting on a transaction in a closed table.
+	ErrClosed = errors.New("transaction table closed")
+)
+
+// Outcome describes what happened to an answer passed to Deliver.
+type Outcome int
+
+const (
+	// Delivered means the answer matched a pending transaction.
+	Delivered Outcome = iota
+	// Late means the answer matched a transaction that had already expired.
+	Late
+	// Unsolicited means the answer matched no transaction.
+	Unsolicited
+)
+
+// String returns the name of the outcome.
+func (o Outcome) String() string {
+	switch o {
+	case Delivered:
+		return "DELIVERED"
+	case Late:
+		return "LATE"
+	case Unsolicited:
+		return "UNSOLICITED"
+	}
+	return "UNKNOWN"
+}
+
+// Config configures a Table.
+type Config struct {
+	// Timeout bounds how long Wait waits when the context has no deadline,
+	// and how long a callback transaction waits. Zero means no limit.
+	Timeout time.Duration
+	// LateWindow is how long an expired transaction is remembered so that its
+	// answer is reported as late rather than unsolicited. It defaults to a minute.
+	LateWindow time.Duration
+	// Store, if set, checkpoints the transactions so a table can Restore
+	// them after a restart.
+	Store Store
+	// Ids, if set, is the generator of the requests' identifiers, whose state
+	// is checkpointed with each transaction and resumed by Restore.
+	Ids *diameter.IdGenerator
+}
+
+// Stats is a snapshot of the transactions in a table.
+type Stats struct {
+	Outstanding int
+	Answered    uint64
+	Expired     uint64
+	Late        uint64
+	Unsolicited uint64
+	// Oldest is the age of the oldest outstanding transaction.
+	Oldest time.Duration
+}
+
+// Table correlates requests with their answers by Hop-by-Hop identifier. It
+// does no I/O, so it can be used with any transport. It is safe for
+// concurrent use.
+type Table struct {
+	config  Config
+	mutex   sync.Mutex
+	pending map[[4]byte]*Transaction
+	expired map[[4]byte]time.Time
+	// expiries holds the expired transactions in the order their late
+	// windows pass, so pruning costs constant time amortized. An entry
+	// whose identifier has since been answered or reused is skipped.
+	expiries []expiry
+	stats    Stats
+	err      error
+}
+
+// expiry is the end of the late window of an expired transaction.
+type expiry struct {
+	id    [4]byte
+	until time.Time
+}
+
+// Callback receives the answer to a transaction started with AddCallback,
+// or the error that ended it.
+type Callback func(answer diameter.Message, err error)
+
+// Transaction is a request waiting for its answer.
+type Transaction struct {
+	Request  diameter.Message
+	Started  time.Time
+	table    *Table
+	answer   chan diameter.Message
+	done     chan struct{}
+	callback Callback
+	timer    *time.Timer
+}
+
+// New creates an empty table.
+func New(config Config) *Table {
+	if config.LateWindow <= 0 {
+		config.LateWindow = time.Minute
+	}
+	return &Table{
+		config:  config,
+		pending: make(map[[4]byte]*Transaction),
+		expired: make(map[[4]byte]time.Time),
+	}
+}
+
+// Add starts a transaction for the request, which should already have its
+// Hop-by-Hop identifier. Its answer is received with Wait.
+func (t *Table) Add(request diameter.Message) (*Transaction, error) {
+	return t.add(&Transaction{
+		Request: request,
+		answer:  make(chan diameter.Message, 1),
+		done:    make(chan struct{}),
+	})
+}
+
+// AddCallback starts a transaction for the request whose answer is passed to
+// the callback, without a goroutine waiting for it. The callback is called
+// once, with the answer, with context.DeadlineExceeded if the table's timeout
+// passes first, or with the error the table is closed with. It is not called
+// if the transaction is cancelled.
+func (t *Table) AddCallback(request diameter.Message, callback Callback) (*Transaction, error) {
+	return t.add(&Transaction{Request: request, callback: callback})
+}
+
+// add checkpoints the transaction to the store, before it can be answered,
+// and records it.
+func (t *Table) add(transaction *Transaction) (*Transaction, error) {
+	id := transaction.Request.HopByHopId
+	if err := t.check(id); err != nil {
+		return nil, err
+	}
+	transaction.Started = time.Now()
+	transaction.table = t
+	if err := t.save(transaction); err != nil {
+		return nil, err
+	}
+	t.mutex.Lock()
+	if err := t.checkLocked(id); err != nil {
+		t.mutex.Unlock()
+		return nil, err
+	}
+	delete(t.expired, id)
+	t.pending[id] = transaction
+	t.mutex.Unlock()
+	if transaction.callback != nil && t.config.Timeout > 0 {
+		transaction.timer = time.AfterFunc(t.config.Timeout, func() {
+			if t.expire(transaction) {
+				transaction.callback(diameter.Message{}, context.DeadlineExceeded)
+			}
+		})
+	}
+	return transaction, nil
+}
+
+// check returns the error adding a transaction with the Hop-by-Hop
+// identifier would fail with.
+func (t *Table) check(id [4]byte) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.checkLocked(id)
+}
+
+// checkLocked is check with the mutex held.
+func (t *Table) checkLocked(id [4]byte) error {
+	if t.err != nil {
+		return t.err
+	}
+	if _, ok := t.pending[id]; ok {
+		return ErrDuplicate
+	}
+	return nil
+}
+
+// Deliver passes the answer to the transaction with the same Hop-by-Hop
+// identifier, reporting whether it was pending, late or unsolicited.
+func (t *Table) Deliver(answer diameter.Message) Outcome {
+	t.mutex.Lock()
+	if transaction, ok := t.pending[answer.HopByHopId]; ok {
+		delete(t.pending, answer.HopByHopId)
+		t.stats.Answered++
+		t.mutex.Unlock()
+		t.forget(answer.HopByHopId)
+		transaction.deliver(answer, nil)
+		return Delivered
+	}
+	forgotten := t.prune(time.Now())
+	outcome := Unsolicited
+	if _, ok := t.expired[answer.HopByHopId]; ok {
+		delete(t.expired, answer.HopByHopId)
+		forgotten = append(forgotten, answer.HopByHopId)
+		t.stats.Late++
+		outcome = Late
+	} else {
+		t.stats.Unsolicited++
+	}
+	t.mutex.Unlock()
+	t.forget(forgotten...)
+	return outcome
+}
+
+// Pending returns the requests of the outstanding transactions.
+func (t *Table) Pending() []diameter.Message {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	requests := make([]diameter.Message, 0, len(t.pending))
+	for _, transaction := range t.pending {
+		requests = append(requests, transaction.Request)
+	}
+	return requests
+}
+
+// Stats returns a snapshot of the table's counters.
+func (t *Table) Stats() Stats {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	stats := t.stats
+	stats.Outstanding = len(t.pending)
+	now := time.Now()
+	for _, transaction := range t.pending {
+		stats.Oldest = max(stats.Oldest, now.Sub(transaction.Started))
+	}
+	return stats
+}
+
+// Close fails every outstanding transaction, and every later Add, with the
+// error, or ErrClosed if it is nil.
+func (t *Table) Close(err error) {
+	if err == nil {
+		err = ErrClosed
+	}
+	t.mutex.Lock()
+	if t.err != nil {
+		t.mutex.Unlock()
+		return
+	}
+	t.err = err
+	pending := make([]*Transaction, 0, len(t.pending))
+	for id, transaction := range t.pending {
+		delete(t.pending, id)
+		pending = append(pending, transaction)
+	}
+	t.mutex.Unlock()
+	for _, transaction := range pending {
+		transaction.deliver(diameter.Message{}, err)
+	}
+}
+
+// expire removes a transaction that will not be answered, remembering it so
+// that a late answer can be detected. It reports whether the transaction was
+// still pending.
+func (t *Table) expire(transaction *Transaction) bool {
+	t.mutex.Lock()
+	id := transaction.Request.HopByHopId
+	if t.pending[id] != transaction {
+		t.mutex.Unlock()
+		return false
+	}
+	delete(t.pending, id)
+	now := time.Now()
+	forgotten := t.prune(now)
+	t.remember(id, now.Add(t.config.LateWindow))
+	t.stats.Expired++
+	t.mutex.Unlock()
+	t.forget(forgotten...)
+	return true
+}
+
+// remember remembers an expired transaction until the end of its late
+// window, which must not precede that of any remembered before it.
+func (t *Table) remember(id [4]byte, until time.Time) {
+	t.expired[id] = until
+	t.expiries = append(t.expiries, expiry{id, until})
+}
+
+// prune forgets expired transactions older than the late window, returning
+// their Hop-by-Hop identifiers.
+func (t *Table) prune(now time.Time) [][4]byte {
+	var forgotten [][4]byte
+	for len(t.expiries) > 0 && now.After(t.expiries[0].until) {
+		next := t.expiries[0]
+		t.expiries = t.expiries[1:]
+		if until, ok := t.expired[next.id]; ok && until.Equal(next.until) {
+			delete(t.expired, next.id)
+			forgotten = append(forgotten, next.id)
+		}
+	}
+	return forgotten
+}
+
+// Wait waits for the answer to a transaction started with Add, the context to
+// be done, the table's timeout to pass or the table to close. The transaction
+// expires if no answer arrives.
+func (tx *Transaction) Wait(ctx context.Context) (diameter.Message, error) {
+	if _, ok := ctx.Deadline(); !ok && tx.table.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, tx.table.config.Timeout)
+		defer cancel()
+	}
+	select {
+	case answer := <-tx.answer:
+		return answer, nil
+	case <-tx.done:
+		tx.table.mutex.Lock()
+		defer tx.table.mutex.Unlock()
+		return diameter.Message{}, tx.table.err
+	case <-ctx.Done():
+		tx.table.expire(tx)
+		select {
+		case answer := <-tx.answer:
+			return answer, nil
+		default:
+		}
+		return diameter.Message{}, ctx.Err()
+	}
+}
+
+// Cancel expires the transaction without waiting for its answer.
+func (tx *Transaction) Cancel() {
+	if tx.timer != nil {
+		tx.timer.Stop()
+	}
+	tx.table.expire(tx)
+}
+
+// deliver ends the transaction with the answer or error.
+func (tx *Transaction) deliver(answer diameter.Message, err error) {
+	if tx.callback == nil {
+		if err != nil {
+			close(tx.done)
+		} else {
+			tx.answer <- answer
+		}
+		return
+	}
+	if tx.timer != nil {
+		tx.timer.Stop()
+	}
+	tx.callback(answer, err)
+}
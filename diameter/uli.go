@@ -0,0 +1,46 @@
+package diameter
+
+import "github.com/tinybluerobots/radius-diameter-message/threegpp"
+
+// AvpThreeGPPUserLocationInfo is the code of the 3GPP-User-Location-Info AVP
+// of TS 29.061.
+const AvpThreeGPPUserLocationInfo Code = 22
+
+// NewAvpUserLocationInfo creates a new 3GPP-User-Location-Info AVP,
+// returning an error if the location cannot be encoded.
+func NewAvpUserLocationInfo(value threegpp.UserLocationInfo) (Avp, error) {
+	data, err := threegpp.EncodeUserLocationInfo(value)
+	if err != nil {
+		return Avp{}, err
+	}
+	return NewAvp(AvpThreeGPPUserLocationInfo, FlagVendorSpecific, Vendor3GPP, data), nil
+}
+
+// UserLocationInfo retrieves the 3GPP-User-Location-Info AVP value.
+func (a Avps) UserLocationInfo() *threegpp.UserLocationInfo {
+	return a.GetFirst(AvpThreeGPPUserLocationInfo, Vendor3GPP).ToUserLocationInfo()
+}
+
+// ToUserLocationInfo converts the AVP to a 3GPP user location.
+func (a *Avp) ToUserLocationInfo() *threegpp.UserLocationInfo {
+	value, ok := a.ToUserLocationInfoOk()
+	if !ok {
+		return nil
+	}
+	return &value
+}
+
+// ToUserLocationInfoOrDefault converts the AVP to a 3GPP user location or returns a default value.
+func (a *Avp) ToUserLocationInfoOrDefault() threegpp.UserLocationInfo {
+	value, _ := a.ToUserLocationInfoOk()
+	return value
+}
+
+// ToUserLocationInfoOk converts the AVP to a 3GPP user location, reporting whether the AVP is present and well formed.
+func (a *Avp) ToUserLocationInfoOk() (threegpp.UserLocationInfo, bool) {
+	if a == nil || a.Data == nil {
+		return threegpp.UserLocationInfo{}, false
+	}
+	value, err := threegpp.DecodeUserLocationInfo(a.Data)
+	return value, err == nil
+}
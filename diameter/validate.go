@@ -0,0 +1,139 @@
+package diameter
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Problem describes one way the bytes of a message break RFC 6733, at the
+// offset of the field concerned.
+type Problem struct {
+	Offset int
+	Reason string
+}
+
+// Error returns the reason for the problem and its offset.
+func (p Problem) Error() string {
+	return fmt.Sprintf("%s at offset %d", p.Reason, p.Offset)
+}
+
+// ValidateBytes walks the bytes of a message and returns every problem it
+// finds, rather than stopping at the first as ReadMessage does, which helps
+// when debugging a broken encoder: the header fields, the length of every
+// AVP, reserved flag bits and padding. The dictionary, which may be nil,
+// adds checks of mandatory AVPs it does not know, the size of fixed length
+// values and the AVPs nested in grouped AVPs. An AVP whose length runs past
+// its enclosing message or group ends the walk of that level. It returns
+// nil for a well formed message.
+func ValidateBytes(bytes []byte, dictionary *Dictionary) []Problem {
+	v := &validator{dictionary: dictionary}
+	if len(bytes) < 20 {
+		v.report(0, "truncated header")
+		return v.problems
+	}
+	length := int(readUInt24(bytes[1:4]))
+	flags := Flags(bytes[4])
+	if bytes[0] != 1 {
+		v.report(0, "unsupported version %d", bytes[0])
+	}
+	end := len(bytes)
+	if length != len(bytes) {
+		v.report(1, "message length %d does not match %d bytes", length, len(bytes))
+		if length >= 20 && length < len(bytes) {
+			end = length
+		}
+	}
+	if length%4 != 0 {
+		v.report(1, "message length %d is not a multiple of 4", length)
+	}
+	if flags&0x0f != 0 {
+		v.report(4, "reserved header bits set")
+	}
+	if flags.IsRequest() && flags.IsError() {
+		v.report(4, "E bit set in request")
+	}
+	v.avps(bytes[:end], 20)
+	return v.problems
+}
+
+// validator collects the problems found walking a message.
+type validator struct {
+	dictionary *Dictionary
+	problems   []Problem
+}
+
+// report adds a problem at the offset.
+func (v *validator) report(offset int, format string, args ...any) {
+	v.problems = append(v.problems, Problem{Offset: offset, Reason: fmt.Sprintf(format, args...)})
+}
+
+// avps checks the AVPs from the offset to the end of the bytes.
+func (v *validator) avps(bytes []byte, offset int) {
+	for offset < len(bytes) {
+		if len(bytes)-offset < 8 {
+			v.report(offset, "truncated AVP header")
+			return
+		}
+		code := Code(binary.BigEndian.Uint32(bytes[offset : offset+4]))
+		flags := Flags(bytes[offset+4])
+		length := int(readUInt24(bytes[offset+5 : offset+8]))
+		headerLength := 8
+		var vendorId VendorId
+		if flags.IsVendorSpecific() {
+			headerLength = 12
+			if len(bytes)-offset < 12 {
+				v.report(offset, "truncated AVP header")
+				return
+			}
+			vendorId = VendorId(binary.BigEndian.Uint32(bytes[offset+8 : offset+12]))
+			if vendorId == 0 {
+				v.report(offset+8, "V bit set with vendor ID 0")
+			}
+		}
+		if flags&0x1f != 0 {
+			v.report(offset+4, "reserved bits set in AVP %d flags", code)
+		}
+		if length < headerLength || offset+length > len(bytes) {
+			v.report(offset+5, "invalid AVP length %d", length)
+			return
+		}
+		v.value(code, flags, vendorId, bytes[:offset+length], offset+headerLength)
+		next := offset + length + (4-length%4)%4
+		if next > len(bytes) {
+			v.report(offset+length, "missing padding after AVP %d", code)
+			return
+		}
+		if !isZero(bytes[offset+length : next]) {
+			v.report(offset+length, "non-zero padding after AVP %d", code)
+		}
+		offset = next
+	}
+}
+
+// value checks the data of an AVP, from the offset to the end of the bytes,
+// against the dictionary.
+func (v *validator) value(code Code, flags Flags, vendorId VendorId, bytes []byte, offset int) {
+	if v.dictionary == nil {
+		return
+	}
+	definition := v.dictionary.Avp(code, vendorId)
+	if definition == nil {
+		if flags.IsMandatory() {
+			v.report(offset, "unknown mandatory AVP %d (vendor %d)", code, vendorId)
+		}
+		return
+	}
+	size := len(bytes) - offset
+	switch definition.Type {
+	case Integer32, Unsigned32, Enumerated, Float32:
+		if size != 4 {
+			v.report(offset, "%s AVP %d has %d bytes", definition.Type, code, size)
+		}
+	case Integer64, Unsigned64, Float64:
+		if size != 8 {
+			v.report(offset, "%s AVP %d has %d bytes", definition.Type, code, size)
+		}
+	case Grouped:
+		v.avps(bytes, offset)
+	}
+}
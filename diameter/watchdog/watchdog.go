@@ -0,0 +1,265 @@
+package watchdog
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/tinybluerobots/radius-diameter-message/diameter"
+)
+
+const mandatoryFlags = diameter.FlagMandatory
+
+// ErrPeerFailed is returned by Run when the peer stops answering watchdog requests.
+var ErrPeerFailed = errors.New("peer failed")
+
+// DWR represents a Device-Watchdog-Request.
+type DWR struct {
+	OriginHost    string
+	OriginRealm   string
+	OriginStateId uint32
+}
+
+// ToMessage converts the DWR to a Diameter message.
+func (d DWR) ToMessage(hopByHopId [4]byte, endToEndId [4]byte) diameter.Message {
+	avps := diameter.NewAvps()
+	avps = avps.AddString(diameter.AvpOriginHost, mandatoryFlags, 0, d.OriginHost)
+	avps = avps.AddString(diameter.AvpOriginRealm, mandatoryFlags, 0, d.OriginRealm)
+	if d.OriginStateId != 0 {
+		avps = avps.AddUint32(diameter.AvpOriginStateId, mandatoryFlags, 0, d.OriginStateId)
+	}
+	return diameter.NewMessage(1, diameter.FlagRequest, diameter.CommandDeviceWatchdog, diameter.ApplicationCommon, hopByHopId, endToEndId, avps...)
+}
+
+// FromMessage reads a DWR from a Diameter message.
+func (d *DWR) FromMessage(message diameter.Message) error {
+	if !IsDWR(message) {
+		return errors.New("message is not a Device-Watchdog-Request")
+	}
+	d.OriginHost = message.Avps.GetFirst(diameter.AvpOriginHost, 0).ToStringOrDefault()
+	d.OriginRealm = message.Avps.GetFirst(diameter.AvpOriginRealm, 0).ToStringOrDefault()
+	d.OriginStateId = message.Avps.GetFirst(diameter.AvpOriginStateId, 0).ToUint32OrDefault()
+	return nil
+}
+
+// DWA represents a Device-Watchdog-Answer.
+type DWA struct {
+	ResultCode    diameter.ResultCode
+	OriginHost    string
+	OriginRealm   string
+	ErrorMessage  string
+	OriginStateId uint32
+}
+
+// ToMessage converts the DWA to a Diameter message answering the request.
+func (d DWA) ToMessage(request diameter.Message) diameter.Message {
+	avps := diameter.NewAvps()
+	avps = avps.AddUint32(diameter.AvpResultCode, mandatoryFlags, 0, uint32(d.ResultCode))
+	avps = avps.AddString(diameter.AvpOriginHost, mandatoryFlags, 0, d.OriginHost)
+	avps = avps.AddString(diameter.AvpOriginRealm, mandatoryFlags, 0, d.OriginRealm)
+	if d.ErrorMessage != "" {
+		avps = avps.AddString(diameter.AvpErrorMessage, 0, 0, d.ErrorMessage)
+	}
+	if d.OriginStateId != 0 {
+		avps = avps.AddUint32(diameter.AvpOriginStateId, mandatoryFlags, 0, d.OriginStateId)
+	}
+	return request.NewAnswer(avps...)
+}
+
+// FromMessage reads a DWA from a Diameter message.
+func (d *DWA) FromMessage(message diameter.Message) error {
+	if !IsDWA(message) {
+		return errors.New("message is not a Device-Watchdog-Answer")
+	}
+	d.ResultCode = diameter.ResultCode(message.Avps.GetFirst(diameter.AvpResultCode, 0).ToUint32OrDefault())
+	d.OriginHost = message.Avps.GetFirst(diameter.AvpOriginHost, 0).ToStringOrDefault()
+	d.OriginRealm = message.Avps.GetFirst(diameter.AvpOriginRealm, 0).ToStringOrDefault()
+	d.ErrorMessage = message.Avps.GetFirst(diameter.AvpErrorMessage, 0).ToStringOrDefault()
+	d.OriginStateId = message.Avps.GetFirst(diameter.AvpOriginStateId, 0).ToUint32OrDefault()
+	return nil
+}
+
+// IsDWR reports whether the message is a Device-Watchdog-Request.
+func IsDWR(message diameter.Message) bool {
+	return message.CommandCode == diameter.CommandDeviceWatchdog && message.IsRequest()
+}
+
+// IsDWA reports whether the message is a Device-Watchdog-Answer.
+func IsDWA(message diameter.Message) bool {
+	return message.CommandCode == diameter.CommandDeviceWatchdog && !message.IsRequest()
+}
+
+// State represents the RFC 3539 watchdog state of a peer connection.
+type State byte
+
+const (
+	Okay State = iota
+	Suspect
+	Down
+)
+
+// String returns the RFC 3539 name of the state.
+func (s State) String() string {
+	switch s {
+	case Okay:
+		return "OKAY"
+	case Suspect:
+		return "SUSPECT"
+	case Down:
+		return "DOWN"
+	}
+	return "UNKNOWN"
+}
+
+// Config configures a Watchdog.
+type Config struct {
+	// Tw is the initial watchdog interval, Twinit, which RFC 3539 requires
+	// to be at least 6 seconds. It defaults to 30 seconds.
+	Tw            time.Duration
+	OriginHost    string
+	OriginRealm   string
+	OriginStateId uint32
+	// Ids generates the identifiers of DWRs. A new generator is used if nil.
+	Ids *diameter.IdGenerator
+	// OnStateChange, if set, is called whenever the state changes.
+	OnStateChange func(State)
+}
+
+// Watchdog implements the RFC 3539 watchdog algorithm for a peer
+// connection: it sends DWRs when the connection has been idle for Tw,
+// answers the peer's DWRs, and reports the peer failed when it stops answering.
+type Watchdog struct {
+	config   Config
+	send     func(diameter.Message) error
+	activity chan struct{}
+	failed   chan struct{}
+	failOnce sync.Once
+	mutex    sync.Mutex
+	state    State
+	pending  bool
+}
+
+// New creates a new Watchdog sending messages to the peer with send.
+func New(config Config, send func(diameter.Message) error) *Watchdog {
+	if config.Tw == 0 {
+		config.Tw = 30 * time.Second
+	}
+	if config.Ids == nil {
+		config.Ids = diameter.NewIdGenerator()
+	}
+	return &Watchdog{
+		config:   config,
+		send:     send,
+		activity: make(chan struct{}, 1),
+		failed:   make(chan struct{}),
+	}
+}
+
+// State returns the current state.
+func (w *Watchdog) State() State {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.state
+}
+
+// Failed returns a channel that is closed when the peer is considered failed.
+func (w *Watchdog) Failed() <-chan struct{} {
+	return w.failed
+}
+
+// Handle must be called with every message received from the peer. It
+// answers DWRs and consumes DWAs, reporting whether the message was one of
+// them; any other message still counts as activity on the connection.
+func (w *Watchdog) Handle(message diameter.Message) (bool, error) {
+	w.mutex.Lock()
+	if IsDWA(message) {
+		w.pending = false
+	}
+	if w.state == Suspect {
+		w.setState(Okay)
+	}
+	w.mutex.Unlock()
+	select {
+	case w.activity <- struct{}{}:
+	default:
+	}
+	if IsDWR(message) {
+		dwa := DWA{
+			ResultCode:    diameter.ResultCodeSuccess,
+			OriginHost:    w.config.OriginHost,
+			OriginRealm:   w.config.OriginRealm,
+			OriginStateId: w.config.OriginStateId,
+		}
+		return true, w.send(dwa.ToMessage(message))
+	}
+	return IsDWA(message), nil
+}
+
+// Run runs the watchdog timer until the context is done or the peer fails,
+// returning ErrPeerFailed in the latter case.
+func (w *Watchdog) Run(ctx context.Context) error {
+	timer := time.NewTimer(w.interval())
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-w.activity:
+			if !timer.Stop() {
+				<-timer.C
+			}
+			timer.Reset(w.interval())
+		case <-timer.C:
+			if err := w.expire(); err != nil {
+				return err
+			}
+			timer.Reset(w.interval())
+		}
+	}
+}
+
+// expire handles the expiry of the watchdog timer. The DWR is sent without
+// the mutex held, so a send waiting on a congested connection cannot stop
+// Handle from processing what the peer sends.
+func (w *Watchdog) expire() error {
+	w.mutex.Lock()
+	switch {
+	case w.state == Suspect:
+		w.setState(Down)
+		w.mutex.Unlock()
+		w.failOnce.Do(func() { close(w.failed) })
+		return ErrPeerFailed
+	case w.pending:
+		w.setState(Suspect)
+		w.mutex.Unlock()
+		return nil
+	}
+	dwr := DWR{
+		OriginHost:    w.config.OriginHost,
+		OriginRealm:   w.config.OriginRealm,
+		OriginStateId: w.config.OriginStateId,
+	}
+	w.pending = true
+	w.mutex.Unlock()
+	return w.send(dwr.ToMessage(w.config.Ids.NextHopByHopId(), w.config.Ids.NextEndToEndId()))
+}
+
+// setState changes the state, calling OnStateChange.
+func (w *Watchdog) setState(state State) {
+	w.state = state
+	if w.config.OnStateChange != nil {
+		w.config.OnStateChange(state)
+	}
+}
+
+// interval returns Tw with up to 2 seconds of random jitter either way,
+// limited to a quarter of Tw for short intervals.
+func (w *Watchdog) interval() time.Duration {
+	maxJitter := min(2*time.Second, w.config.Tw/4)
+	if maxJitter <= 0 {
+		return w.config.Tw
+	}
+	return w.config.Tw + time.Duration(rand.Int64N(int64(2*maxJitter))) - maxJitter
+}
@@ -0,0 +1,76 @@
+package enterprise
+
+import "sync"
+
+// names maps IANA private enterprise numbers to vendor names.
+var names = map[uint32]string{
+	9:     "Cisco",
+	94:    "Nokia",
+	193:   "Ericsson",
+	311:   "Microsoft",
+	529:   "Ascend",
+	2011:  "Huawei",
+	2352:  "Redback",
+	2636:  "Juniper",
+	3902:  "ZTE",
+	4874:  "Unisphere",
+	5535:  "3GPP2",
+	8164:  "Starent",
+	10415: "3GPP",
+	11344: "FreeRADIUS",
+	13019: "ETSI",
+	14122: "WISPr",
+	14823: "Aruba",
+	14988: "Mikrotik",
+	24757: "WiMAX",
+	25053: "Ruckus",
+}
+
+var mutex sync.RWMutex
+
+// Name retrieves the vendor name registered for an enterprise number.
+func Name(number uint32) *string {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	name, ok := names[number]
+	if !ok {
+		return nil
+	}
+	return &name
+}
+
+// Number retrieves the enterprise number registered for a vendor name.
+func Number(name string) *uint32 {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	for number, registered := range names {
+		if registered == name {
+			return &number
+		}
+	}
+	return nil
+}
+
+// Register adds or replaces the vendor name of an enterprise number.
+func Register(number uint32, name string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	names[number] = name
+}
+
+// Replace replaces the whole registry with the given names.
+func Replace(registry map[uint32]string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	names = make(map[uint32]string, len(registry))
+	for number, name := range registry {
+		names[number] = name
+	}
+}
+
+// Unregister removes the vendor name of an enterprise number.
+func Unregister(number uint32) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	delete(names, number)
+}
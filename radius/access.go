@@ -0,0 +1,237 @@
+package radius
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"errors"
+	"net"
+)
+
+// Attributes of an Access-Request.
+const (
+	AttributeUserName         AttributeType = 1
+	AttributeUserPassword     AttributeType = 2
+	AttributeNASIPAddress     AttributeType = 4
+	AttributeNASPort          AttributeType = 5
+	AttributeServiceType      AttributeType = 6
+	AttributeFramedProtocol   AttributeType = 7
+	AttributeCalledStationId  AttributeType = 30
+	AttributeCallingStationId AttributeType = 31
+	AttributeNASIdentifier    AttributeType = 32
+	AttributeNASPortType      AttributeType = 61
+	AttributeNASPortId        AttributeType = 87
+	AttributeNASIPv6Address   AttributeType = 95
+)
+
+// maxUserPasswordLength is the longest hidden User-Password RFC 2865
+// section 5.2 allows.
+const maxUserPasswordLength = 128
+
+var (
+	// ErrInvalidUserPassword is returned when a User-Password cannot be revealed.
+	ErrInvalidUserPassword = errors.New("invalid user password")
+	// ErrUserPasswordTooLong is returned when a password padded to a multiple
+	// of 16 bytes would exceed the 128 bytes a User-Password may hold.
+	ErrUserPasswordTooLong = errors.New("user password longer than 128 bytes")
+)
+
+// EncryptUserPassword hides the password as RFC 2865 section 5.2 describes:
+// padded with zeros to a multiple of 16 bytes and XORed with a chain of MD5
+// hashes of the shared secret and the Request Authenticator. It returns
+// ErrUserPasswordTooLong if the padded password exceeds 128 bytes.
+func EncryptUserPassword(password []byte, secret []byte, requestAuthenticator [16]byte) ([]byte, error) {
+	plaintext := append([]byte(nil), password...)
+	if padding := len(plaintext) % md5.Size; padding != 0 || len(plaintext) == 0 {
+		plaintext = append(plaintext, make([]byte, md5.Size-padding)...)
+	}
+	if len(plaintext) > maxUserPasswordLength {
+		return nil, ErrUserPasswordTooLong
+	}
+	return md5Chain(plaintext, secret, requestAuthenticator[:], true), nil
+}
+
+// DecryptUserPassword reveals a password hidden with EncryptUserPassword,
+// removing its padding.
+func DecryptUserPassword(data []byte, secret []byte, requestAuthenticator [16]byte) ([]byte, error) {
+	if len(data) == 0 || len(data)%md5.Size != 0 || len(data) > maxUserPasswordLength {
+		return nil, ErrInvalidUserPassword
+	}
+	password := md5Chain(data, secret, requestAuthenticator[:], false)
+	for len(password) > 0 && password[len(password)-1] == 0 {
+		password = password[:len(password)-1]
+	}
+	return password, nil
+}
+
+// md5Chain XORs the data, a multiple of 16 bytes, with a chain of MD5 hashes
+// of the shared secret and the previous block of ciphertext, starting from
+// the vector, as User-Password and the salted attributes are hidden.
+// Encrypting chains on the blocks it produces, decrypting on those it reads.
+func md5Chain(data []byte, secret []byte, vector []byte, encrypt bool) []byte {
+	result := make([]byte, 0, len(data))
+	previous := vector
+	for offset := 0; offset < len(data); offset += md5.Size {
+		hash := md5.New()
+		hash.Write(secret)
+		hash.Write(previous)
+		block := hash.Sum(nil)
+		for i := range block {
+			block[i] ^= data[offset+i]
+		}
+		result = append(result, block...)
+		if encrypt {
+			previous = block
+		} else {
+			previous = data[offset : offset+md5.Size]
+		}
+	}
+	return result
+}
+
+// AccessRequest represents an Access-Request. UserPassword is the cleartext
+// password, hidden by Build and revealed by FromMessage; CHAPPassword is the
+// data of a CHAP-Password attribute, set by UseCHAP. Zero fields are absent.
+// Attributes without a field are carried in Avps and appended after the
+// others.
+type AccessRequest struct {
+	Identifier       byte
+	UserName         string
+	UserPassword     []byte
+	CHAPPassword     []byte
+	CHAPChallenge    []byte
+	NASIPAddress     net.IP
+	NASIPv6Address   net.IP
+	NASIdentifier    string
+	NASPort          uint32
+	NASPortType      uint32
+	NASPortId        string
+	ServiceType      uint32
+	FramedProtocol   uint32
+	CalledStationId  string
+	CallingStationId string
+	Avps             Avps
+}
+
+// accessRequestTypes are the attribute types AccessRequest has fields for.
+var accessRequestTypes = []AttributeType{
+	AttributeUserName, AttributeUserPassword, AttributeCHAPPassword, AttributeCHAPChallenge,
+	AttributeNASIPAddress, AttributeNASIPv6Address, AttributeNASIdentifier, AttributeNASPort,
+	AttributeNASPortType, AttributeNASPortId, AttributeServiceType, AttributeFramedProtocol,
+	AttributeCalledStationId, AttributeCallingStationId, AttributeMessageAuthenticator,
+}
+
+// UseCHAP authenticates the request with CHAP instead of a User-Password,
+// answering a new random challenge with the password.
+func (r *AccessRequest) UseCHAP(ident byte, password []byte) error {
+	challenge := make([]byte, 16)
+	if _, err := rand.Read(challenge); err != nil {
+		return err
+	}
+	r.UserPassword = nil
+	r.CHAPChallenge = challenge
+	r.CHAPPassword = NewAvpCHAPPassword(ident, password, challenge).Data
+	return nil
+}
+
+// VerifyCHAP reports whether the request's CHAP-Password answers its
+// challenge with the password.
+func (r AccessRequest) VerifyCHAP(password []byte) bool {
+	return VerifyCHAP(r.CHAPChallenge, r.CHAPPassword, password)
+}
+
+// Build converts the request to a RADIUS message with a random Request
+// Authenticator, hiding the User-Password and adding a Message-Authenticator
+// as RFC 5080 and the Blast-RADIUS mitigations recommend.
+func (r AccessRequest) Build(secret []byte) (Message, error) {
+	authenticator, err := NewRequestAuthenticator()
+	if err != nil {
+		return Message{}, err
+	}
+	avps := NewAvps()
+	if r.UserName != "" {
+		avps = avps.AddString(AttributeUserName, 0, r.UserName)
+	}
+	if r.UserPassword != nil {
+		hidden, err := EncryptUserPassword(r.UserPassword, secret, authenticator)
+		if err != nil {
+			return Message{}, err
+		}
+		avps = avps.Add(AttributeUserPassword, 0, hidden)
+	}
+	if r.CHAPPassword != nil {
+		avps = avps.Add(AttributeCHAPPassword, 0, r.CHAPPassword)
+	}
+	if r.CHAPChallenge != nil {
+		avps = avps.Add(AttributeCHAPChallenge, 0, r.CHAPChallenge)
+	}
+	if r.NASIPAddress != nil {
+		avps = avps.AddIPv4(AttributeNASIPAddress, 0, r.NASIPAddress)
+	}
+	if r.NASIPv6Address != nil {
+		avps = avps.AddIPv6(AttributeNASIPv6Address, 0, r.NASIPv6Address)
+	}
+	if r.NASIdentifier != "" {
+		avps = avps.AddString(AttributeNASIdentifier, 0, r.NASIdentifier)
+	}
+	if r.NASPort != 0 {
+		avps = avps.AddUint32(AttributeNASPort, 0, r.NASPort)
+	}
+	if r.NASPortType != 0 {
+		avps = avps.AddUint32(AttributeNASPortType, 0, r.NASPortType)
+	}
+	if r.NASPortId != "" {
+		avps = avps.AddString(AttributeNASPortId, 0, r.NASPortId)
+	}
+	if r.ServiceType != 0 {
+		avps = avps.AddUint32(AttributeServiceType, 0, r.ServiceType)
+	}
+	if r.FramedProtocol != 0 {
+		avps = avps.AddUint32(AttributeFramedProtocol, 0, r.FramedProtocol)
+	}
+	if r.CalledStationId != "" {
+		avps = avps.AddString(AttributeCalledStationId, 0, r.CalledStationId)
+	}
+	if r.CallingStationId != "" {
+		avps = avps.AddString(AttributeCallingStationId, 0, r.CallingStationId)
+	}
+	avps = append(avps, r.Avps...)
+	message := NewMessage(CodeAccessRequest, r.Identifier, authenticator, avps...)
+	message.AddMessageAuthenticator(secret)
+	return message, nil
+}
+
+// FromMessage reads an Access-Request from a RADIUS message, revealing its
+// User-Password with the shared secret.
+func (r *AccessRequest) FromMessage(message Message, secret []byte) error {
+	if message.Code != CodeAccessRequest {
+		return errors.New("message is not an Access-Request")
+	}
+	avps := message.Avps
+	r.Identifier = message.Identifier
+	r.UserName = avps.GetFirst(AttributeUserName, 0).ToStringOrDefault()
+	r.UserPassword = nil
+	if password := avps.GetFirst(AttributeUserPassword, 0); password != nil {
+		value, err := DecryptUserPassword(password.Data, secret, message.Authenticator)
+		if err != nil {
+			return err
+		}
+		r.UserPassword = value
+	}
+	r.CHAPPassword = avps.GetFirst(AttributeCHAPPassword, 0).ToData()
+	r.CHAPChallenge = avps.GetFirst(AttributeCHAPChallenge, 0).ToData()
+	if r.CHAPPassword != nil && r.CHAPChallenge == nil {
+		r.CHAPChallenge = message.CHAPChallenge()
+	}
+	r.NASIPAddress = avps.GetFirst(AttributeNASIPAddress, 0).ToIPv4OrDefault()
+	r.NASIPv6Address = avps.GetFirst(AttributeNASIPv6Address, 0).ToIPv6OrDefault()
+	r.NASIdentifier = avps.GetFirst(AttributeNASIdentifier, 0).ToStringOrDefault()
+	r.NASPort = avps.GetFirst(AttributeNASPort, 0).ToUint32OrDefault()
+	r.NASPortType = avps.GetFirst(AttributeNASPortType, 0).ToUint32OrDefault()
+	r.NASPortId = avps.GetFirst(AttributeNASPortId, 0).ToStringOrDefault()
+	r.ServiceType = avps.GetFirst(AttributeServiceType, 0).ToUint32OrDefault()
+	r.FramedProtocol = avps.GetFirst(AttributeFramedProtocol, 0).ToUint32OrDefault()
+	r.CalledStationId = avps.GetFirst(AttributeCalledStationId, 0).ToStringOrDefault()
+	r.CallingStationId = avps.GetFirst(AttributeCallingStationId, 0).ToStringOrDefault()
+	r.Avps = avps.Without(0, accessRequestTypes...)
+	return nil
+}
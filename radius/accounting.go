@@ -0,0 +1,331 @@
+package radius
+
+import (
+	"errors"
+	"net"
+	"time"
+)
+
+// Attributes of an Accounting-Request.
+const (
+	AttributeFramedIPAddress     AttributeType = 8
+	AttributeAcctStatusType      AttributeType = 40
+	AttributeAcctDelayTime       AttributeType = 41
+	AttributeAcctInputOctets     AttributeType = 42
+	AttributeAcctOutputOctets    AttributeType = 43
+	AttributeAcctSessionId       AttributeType = 44
+	AttributeAcctAuthentic       AttributeType = 45
+	AttributeAcctSessionTime     AttributeType = 46
+	AttributeAcctInputPackets    AttributeType = 47
+	AttributeAcctOutputPackets   AttributeType = 48
+	AttributeAcctTerminateCause  AttributeType = 49
+	AttributeAcctMultiSessionId  AttributeType = 50
+	AttributeAcctInputGigawords  AttributeType = 52
+	AttributeAcctOutputGigawords AttributeType = 53
+	AttributeEventTimestamp      AttributeType = 55
+	AttributeAcctInterimInterval AttributeType = 85
+)
+
+// AcctStatusType represents the value of an Acct-Status-Type attribute.
+type AcctStatusType uint32
+
+const (
+	AcctStart         AcctStatusType = 1
+	AcctStop          AcctStatusType = 2
+	AcctInterimUpdate AcctStatusType = 3
+	AcctAccountingOn  AcctStatusType = 7
+	AcctAccountingOff AcctStatusType = 8
+)
+
+// String returns the RFC 2866 name of the status type.
+func (s AcctStatusType) String() string {
+	switch s {
+	case AcctStart:
+		return "START"
+	case AcctStop:
+		return "STOP"
+	case AcctInterimUpdate:
+		return "INTERIM_UPDATE"
+	case AcctAccountingOn:
+		return "ACCOUNTING_ON"
+	case AcctAccountingOff:
+		return "ACCOUNTING_OFF"
+	}
+	return "UNKNOWN"
+}
+
+// AcctAuthentic represents the value of an Acct-Authentic attribute.
+type AcctAuthentic uint32
+
+const (
+	AuthenticRADIUS   AcctAuthentic = 1
+	AuthenticLocal    AcctAuthentic = 2
+	AuthenticRemote   AcctAuthentic = 3
+	AuthenticDiameter AcctAuthentic = 4
+)
+
+// String returns the RFC 2866 name of the value.
+func (a AcctAuthentic) String() string {
+	switch a {
+	case AuthenticRADIUS:
+		return "RADIUS"
+	case AuthenticLocal:
+		return "LOCAL"
+	case AuthenticRemote:
+		return "REMOTE"
+	case AuthenticDiameter:
+		return "DIAMETER"
+	}
+	return "UNKNOWN"
+}
+
+// AcctTerminateCause represents the value of an Acct-Terminate-Cause attribute.
+type AcctTerminateCause uint32
+
+const (
+	TerminateUserRequest        AcctTerminateCause = 1
+	TerminateLostCarrier        AcctTerminateCause = 2
+	TerminateLostService        AcctTerminateCause = 3
+	TerminateIdleTimeout        AcctTerminateCause = 4
+	TerminateSessionTimeout     AcctTerminateCause = 5
+	TerminateAdminReset         AcctTerminateCause = 6
+	TerminateAdminReboot        AcctTerminateCause = 7
+	TerminatePortError          AcctTerminateCause = 8
+	TerminateNASError           AcctTerminateCause = 9
+	TerminateNASRequest         AcctTerminateCause = 10
+	TerminateNASReboot          AcctTerminateCause = 11
+	TerminatePortUnneeded       AcctTerminateCause = 12
+	TerminatePortPreempted      AcctTerminateCause = 13
+	TerminatePortSuspended      AcctTerminateCause = 14
+	TerminateServiceUnavailable AcctTerminateCause = 15
+	TerminateCallback           AcctTerminateCause = 16
+	TerminateUserError          AcctTerminateCause = 17
+	TerminateHostRequest        AcctTerminateCause = 18
+)
+
+// String returns the RFC 2866 name of the cause.
+func (c AcctTerminateCause) String() string {
+	switch c {
+	case TerminateUserRequest:
+		return "USER_REQUEST"
+	case TerminateLostCarrier:
+		return "LOST_CARRIER"
+	case TerminateLostService:
+		return "LOST_SERVICE"
+	case TerminateIdleTimeout:
+		return "IDLE_TIMEOUT"
+	case TerminateSessionTimeout:
+		return "SESSION_TIMEOUT"
+	case TerminateAdminReset:
+		return "ADMIN_RESET"
+	case TerminateAdminReboot:
+		return "ADMIN_REBOOT"
+	case TerminatePortError:
+		return "PORT_ERROR"
+	case TerminateNASError:
+		return "NAS_ERROR"
+	case TerminateNASRequest:
+		return "NAS_REQUEST"
+	case TerminateNASReboot:
+		return "NAS_REBOOT"
+	case TerminatePortUnneeded:
+		return "PORT_UNNEEDED"
+	case TerminatePortPreempted:
+		return "PORT_PREEMPTED"
+	case TerminatePortSuspended:
+		return "PORT_SUSPENDED"
+	case TerminateServiceUnavailable:
+		return "SERVICE_UNAVAILABLE"
+	case TerminateCallback:
+		return "CALLBACK"
+	case TerminateUserError:
+		return "USER_ERROR"
+	case TerminateHostRequest:
+		return "HOST_REQUEST"
+	}
+	return "UNKNOWN"
+}
+
+// AccountingRequest represents an Accounting-Request. The octet counters are
+// 64 bits wide, carried in Acct-Input-Octets and Acct-Output-Octets with the
+// high 32 bits in the matching Gigawords attribute. If EventTimestamp is set,
+// Build derives Acct-Delay-Time from it; otherwise DelayTime is sent. Zero
+// fields other than StatusType are absent. Attributes without a field are
+// carried in Avps and appended after the others.
+type AccountingRequest struct {
+	Identifier       byte
+	StatusType       AcctStatusType
+	SessionId        string
+	MultiSessionId   string
+	UserName         string
+	NASIPAddress     net.IP
+	NASIdentifier    string
+	NASPort          uint32
+	FramedIPAddress  net.IP
+	CalledStationId  string
+	CallingStationId string
+	Authentic        AcctAuthentic
+	SessionTime      time.Duration
+	InputOctets      uint64
+	OutputOctets     uint64
+	InputPackets     uint32
+	OutputPackets    uint32
+	TerminateCause   AcctTerminateCause
+	InterimInterval  time.Duration
+	EventTimestamp   time.Time
+	DelayTime        time.Duration
+	Avps             Avps
+}
+
+// accountingRequestTypes are the attribute types AccountingRequest has fields for.
+var accountingRequestTypes = []AttributeType{
+	AttributeAcctStatusType, AttributeAcctSessionId, AttributeAcctMultiSessionId, AttributeUserName,
+	AttributeNASIPAddress, AttributeNASIdentifier, AttributeNASPort, AttributeFramedIPAddress,
+	AttributeCalledStationId, AttributeCallingStationId, AttributeAcctAuthentic, AttributeAcctSessionTime,
+	AttributeAcctInputOctets, AttributeAcctOutputOctets, AttributeAcctInputGigawords, AttributeAcctOutputGigawords,
+	AttributeAcctInputPackets, AttributeAcctOutputPackets, AttributeAcctTerminateCause, AttributeAcctInterimInterval,
+	AttributeEventTimestamp, AttributeAcctDelayTime,
+}
+
+// Build converts the request to a RADIUS message with its Request
+// Authenticator computed from the shared secret.
+func (r AccountingRequest) Build(secret []byte) Message {
+	avps := NewAvps()
+	avps = avps.AddUint32(AttributeAcctStatusType, 0, uint32(r.StatusType))
+	if r.SessionId != "" {
+		avps = avps.AddString(AttributeAcctSessionId, 0, r.SessionId)
+	}
+	if r.MultiSessionId != "" {
+		avps = avps.AddString(AttributeAcctMultiSessionId, 0, r.MultiSessionId)
+	}
+	if r.UserName != "" {
+		avps = avps.AddString(AttributeUserName, 0, r.UserName)
+	}
+	if r.NASIPAddress != nil {
+		avps = avps.AddIPv4(AttributeNASIPAddress, 0, r.NASIPAddress)
+	}
+	if r.NASIdentifier != "" {
+		avps = avps.AddString(AttributeNASIdentifier, 0, r.NASIdentifier)
+	}
+	if r.NASPort != 0 {
+		avps = avps.AddUint32(AttributeNASPort, 0, r.NASPort)
+	}
+	if r.FramedIPAddress != nil {
+		avps = avps.AddIPv4(AttributeFramedIPAddress, 0, r.FramedIPAddress)
+	}
+	if r.CalledStationId != "" {
+		avps = avps.AddString(AttributeCalledStationId, 0, r.CalledStationId)
+	}
+	if r.CallingStationId != "" {
+		avps = avps.AddString(AttributeCallingStationId, 0, r.CallingStationId)
+	}
+	if r.Authentic != 0 {
+		avps = avps.AddUint32(AttributeAcctAuthentic, 0, uint32(r.Authentic))
+	}
+	if r.SessionTime != 0 {
+		avps = avps.AddUint32(AttributeAcctSessionTime, 0, uint32(r.SessionTime/time.Second))
+	}
+	avps = addOctets(avps, AttributeAcctInputOctets, AttributeAcctInputGigawords, r.InputOctets)
+	avps = addOctets(avps, AttributeAcctOutputOctets, AttributeAcctOutputGigawords, r.OutputOctets)
+	if r.InputPackets != 0 {
+		avps = avps.AddUint32(AttributeAcctInputPackets, 0, r.InputPackets)
+	}
+	if r.OutputPackets != 0 {
+		avps = avps.AddUint32(AttributeAcctOutputPackets, 0, r.OutputPackets)
+	}
+	if r.TerminateCause != 0 {
+		avps = avps.AddUint32(AttributeAcctTerminateCause, 0, uint32(r.TerminateCause))
+	}
+	if r.InterimInterval != 0 {
+		avps = avps.AddUint32(AttributeAcctInterimInterval, 0, uint32(r.InterimInterval/time.Second))
+	}
+	delay := r.DelayTime
+	if !r.EventTimestamp.IsZero() {
+		avps = avps.AddTime(AttributeEventTimestamp, 0, r.EventTimestamp)
+		delay = max(time.Since(r.EventTimestamp), 0)
+	}
+	if delay != 0 {
+		avps = avps.AddUint32(AttributeAcctDelayTime, 0, uint32(delay/time.Second))
+	}
+	avps = append(avps, r.Avps...)
+	message := NewMessage(CodeAccountingRequest, r.Identifier, [16]byte{}, avps...)
+	message.SetAccountingAuthenticator(secret)
+	return message
+}
+
+// addOctets adds a 64 bit counter as its low 32 bits and, if not zero, its
+// high 32 bits in the gigawords attribute.
+func addOctets(avps Avps, octets AttributeType, gigawords AttributeType, value uint64) Avps {
+	if value == 0 {
+		return avps
+	}
+	avps = avps.AddUint32(octets, 0, uint32(value))
+	if value>>32 != 0 {
+		avps = avps.AddUint32(gigawords, 0, uint32(value>>32))
+	}
+	return avps
+}
+
+// FromMessage reads an Accounting-Request from a RADIUS message.
+func (r *AccountingRequest) FromMessage(message Message) error {
+	if message.Code != CodeAccountingRequest {
+		return errors.New("message is not an Accounting-Request")
+	}
+	avps := message.Avps
+	r.Identifier = message.Identifier
+	r.StatusType = AcctStatusType(avps.GetFirst(AttributeAcctStatusType, 0).ToUint32OrDefault())
+	r.SessionId = avps.GetFirst(AttributeAcctSessionId, 0).ToStringOrDefault()
+	r.MultiSessionId = avps.GetFirst(AttributeAcctMultiSessionId, 0).ToStringOrDefault()
+	r.UserName = avps.GetFirst(AttributeUserName, 0).ToStringOrDefault()
+	r.NASIPAddress = avps.GetFirst(AttributeNASIPAddress, 0).ToIPv4OrDefault()
+	r.NASIdentifier = avps.GetFirst(AttributeNASIdentifier, 0).ToStringOrDefault()
+	r.NASPort = avps.GetFirst(AttributeNASPort, 0).ToUint32OrDefault()
+	r.FramedIPAddress = avps.GetFirst(AttributeFramedIPAddress, 0).ToIPv4OrDefault()
+	r.CalledStationId = avps.GetFirst(AttributeCalledStationId, 0).ToStringOrDefault()
+	r.CallingStationId = avps.GetFirst(AttributeCallingStationId, 0).ToStringOrDefault()
+	r.Authentic = AcctAuthentic(avps.GetFirst(AttributeAcctAuthentic, 0).ToUint32OrDefault())
+	r.SessionTime = time.Duration(avps.GetFirst(AttributeAcctSessionTime, 0).ToUint32OrDefault()) * time.Second
+	r.InputOctets = readOctets(avps, AttributeAcctInputOctets, AttributeAcctInputGigawords)
+	r.OutputOctets = readOctets(avps, AttributeAcctOutputOctets, AttributeAcctOutputGigawords)
+	r.InputPackets = avps.GetFirst(AttributeAcctInputPackets, 0).ToUint32OrDefault()
+	r.OutputPackets = avps.GetFirst(AttributeAcctOutputPackets, 0).ToUint32OrDefault()
+	r.TerminateCause = AcctTerminateCause(avps.GetFirst(AttributeAcctTerminateCause, 0).ToUint32OrDefault())
+	r.InterimInterval = time.Duration(avps.GetFirst(AttributeAcctInterimInterval, 0).ToUint32OrDefault()) * time.Second
+	r.EventTimestamp = avps.GetFirst(AttributeEventTimestamp, 0).ToTimeOrDefault()
+	r.DelayTime = time.Duration(avps.GetFirst(AttributeAcctDelayTime, 0).ToUint32OrDefault()) * time.Second
+	r.Avps = avps.Without(0, accountingRequestTypes...)
+	return nil
+}
+
+// readOctets reads a 64 bit counter from its octets and gigawords attributes.
+func readOctets(avps Avps, octets AttributeType, gigawords AttributeType) uint64 {
+	low := uint64(avps.GetFirst(octets, 0).ToUint32OrDefault())
+	high := uint64(avps.GetFirst(gigawords, 0).ToUint32OrDefault())
+	return high<<32 | low
+}
+
+// RetransmitAccounting prepares an Accounting-Request for retransmission
+// after the time elapsed since it was last sent: its Acct-Delay-Time grows by
+// the elapsed time and, since the packet changes, it takes the new
+// identifier and a new Request Authenticator, as RFC 2866 requires.
+func RetransmitAccounting(message Message, identifier byte, elapsed time.Duration, secret []byte) Message {
+	message = message.Clone()
+	delay := message.Avps.GetFirst(AttributeAcctDelayTime, 0).ToUint32OrDefault() + uint32(elapsed/time.Second)
+	found := false
+	for i, avp := range message.Avps {
+		if avp.is(AttributeAcctDelayTime, 0) {
+			message.Avps[i] = NewAvpUint32(AttributeAcctDelayTime, 0, delay)
+			found = true
+			break
+		}
+	}
+	if !found {
+		message.Avps = message.Avps.AddUint32(AttributeAcctDelayTime, 0, delay)
+	}
+	message.Identifier = identifier
+	if message.Avps.GetFirst(AttributeMessageAuthenticator, 0) != nil {
+		message.AddMessageAuthenticator(secret)
+	}
+	message.SetAccountingAuthenticator(secret)
+	return message
+}
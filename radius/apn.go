@@ -0,0 +1,79 @@
+package radius
+
+import "github.com/tinybluerobots/radius-diameter-message/threegpp"
+
+// Vendor3GPP is the IANA enterprise number of 3GPP.
+const Vendor3GPP VendorId = 10415
+
+// NewAvpChargingCharacteristics creates a new 3GPP-Charging-Characteristics AVP.
+func NewAvpChargingCharacteristics(value threegpp.ChargingCharacteristics) Avp {
+	return NewAvpString(Attribute3GPPChargingCharacteristics, Vendor3GPP, value.String())
+}
+
+// AddChargingCharacteristics adds a new 3GPP-Charging-Characteristics AVP to the slice.
+func (a Avps) AddChargingCharacteristics(value threegpp.ChargingCharacteristics) Avps {
+	return append(a, NewAvpChargingCharacteristics(value))
+}
+
+// ToChargingCharacteristics converts the AVP to 3GPP charging characteristics.
+func (a *Avp) ToChargingCharacteristics() *threegpp.ChargingCharacteristics {
+	value, ok := a.ToChargingCharacteristicsOk()
+	if !ok {
+		return nil
+	}
+	return &value
+}
+
+// ToChargingCharacteristicsOrDefault converts the AVP to 3GPP charging characteristics or returns a default value.
+func (a *Avp) ToChargingCharacteristicsOrDefault() threegpp.ChargingCharacteristics {
+	value, _ := a.ToChargingCharacteristicsOk()
+	return value
+}
+
+// ToChargingCharacteristicsOk converts the AVP to 3GPP charging characteristics, reporting whether the AVP is present and well formed.
+func (a *Avp) ToChargingCharacteristicsOk() (threegpp.ChargingCharacteristics, bool) {
+	if a == nil || a.Data == nil {
+		return 0, false
+	}
+	value, err := threegpp.ParseChargingCharacteristics(string(a.Data))
+	return value, err == nil
+}
+
+// ToAPN converts the AVP to a normalized APN.
+func (a *Avp) ToAPN() *string {
+	value, ok := a.ToAPNOk()
+	if !ok {
+		return nil
+	}
+	return &value
+}
+
+// ToAPNOrDefault converts the AVP to a normalized APN or returns a default value.
+func (a *Avp) ToAPNOrDefault() string {
+	value, _ := a.ToAPNOk()
+	return value
+}
+
+// ToAPNOk converts the AVP to a normalized APN, reporting whether the AVP is present.
+func (a *Avp) ToAPNOk() (string, bool) {
+	value, ok := a.ToStringOk()
+	if !ok {
+		return "", false
+	}
+	return threegpp.NormalizeAPN(value), true
+}
+
+// ChargingCharacteristics retrieves the 3GPP-Charging-Characteristics attribute value.
+func (a Avps) ChargingCharacteristics() *threegpp.ChargingCharacteristics {
+	return a.GetFirst(Attribute3GPPChargingCharacteristics, Vendor3GPP).ToChargingCharacteristics()
+}
+
+// CalledStationId retrieves the Called-Station-Id attribute value.
+func (a Avps) CalledStationId() *string {
+	return a.GetFirst(AttributeCalledStationId, 0).ToString()
+}
+
+// APN retrieves the normalized APN carried in Called-Station-Id.
+func (a Avps) APN() *string {
+	return a.GetFirst(AttributeCalledStationId, 0).ToAPN()
+}
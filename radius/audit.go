@@ -0,0 +1,117 @@
+package radius
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ChangeKind represents the kind of change made to an attribute.
+type ChangeKind byte
+
+const (
+	Added ChangeKind = iota
+	Removed
+	Modified
+)
+
+// String returns the name of the change kind.
+func (k ChangeKind) String() string {
+	switch k {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	case Modified:
+		return "modified"
+	}
+	return "unknown"
+}
+
+// Change records an attribute added, removed or modified on a TrackedMessage.
+// Before is nil for an added attribute and After is nil for a removed one.
+type Change struct {
+	Kind   ChangeKind
+	Before *Avp
+	After  *Avp
+}
+
+// String returns a single line description of the change for audit logs.
+func (c Change) String() string {
+	parts := []string{c.Kind.String()}
+	if c.Before != nil {
+		parts = append(parts, "before="+describeAvp(*c.Before))
+	}
+	if c.After != nil {
+		parts = append(parts, "after="+describeAvp(*c.After))
+	}
+	return strings.Join(parts, " ")
+}
+
+// describeAvp returns a short description of an attribute for audit logs.
+func describeAvp(avp Avp) string {
+	if avp.Extended != 0 {
+		return fmt.Sprintf("%d/%d.%d:%x", avp.VendorId, avp.Extended, avp.Type, []byte(avp.Data))
+	}
+	return fmt.Sprintf("%d/%d:%x", avp.VendorId, avp.Type, []byte(avp.Data))
+}
+
+// TrackedMessage wraps a RADIUS message and records every change made to its attributes.
+type TrackedMessage struct {
+	message Message
+	changes []Change
+}
+
+// Track wraps a copy of the message for change tracking.
+func Track(message Message) *TrackedMessage {
+	return &TrackedMessage{message: message.Clone()}
+}
+
+// Message returns a copy of the tracked message with all changes applied.
+func (t *TrackedMessage) Message() Message {
+	return t.message.Clone()
+}
+
+// Changes returns the changes made, in order.
+func (t *TrackedMessage) Changes() []Change {
+	return append([]Change(nil), t.changes...)
+}
+
+// Add adds attributes to the end of the message.
+func (t *TrackedMessage) Add(avps ...Avp) *TrackedMessage {
+	for _, avp := range avps {
+		avp := avp.Clone()
+		t.message.Avps = append(t.message.Avps, avp)
+		t.changes = append(t.changes, Change{Kind: Added, After: &avp})
+	}
+	return t
+}
+
+// Remove removes every attribute with the given type and vendor ID.
+func (t *TrackedMessage) Remove(attributeType AttributeType, vendorId VendorId) *TrackedMessage {
+	avps := make(Avps, 0, len(t.message.Avps))
+	for _, avp := range t.message.Avps {
+		if avp.is(attributeType, vendorId) {
+			before := avp
+			t.changes = append(t.changes, Change{Kind: Removed, Before: &before})
+			continue
+		}
+		avps = append(avps, avp)
+	}
+	t.message.Avps = avps
+	return t
+}
+
+// Replace replaces the first attribute with the same type and vendor ID as
+// the given attribute, adding it to the end of the message if there is none.
+func (t *TrackedMessage) Replace(avp Avp) *TrackedMessage {
+	for i, existing := range t.message.Avps {
+		if existing.Type == avp.Type && existing.VendorId == avp.VendorId && existing.Extended == avp.Extended {
+			before := existing
+			after := avp.Clone()
+			t.message.Avps[i] = after
+			t.changes = append(t.changes, Change{Kind: Modified, Before: &before, After: &after})
+			return t
+		}
+	}
+	return t.Add(avp)
+}
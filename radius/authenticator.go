@@ -0,0 +1,107 @@
+package radius
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/subtle"
+)
+
+// NewRequestAuthenticator returns a random Request Authenticator for an
+// Access-Request or Status-Server packet.
+func NewRequestAuthenticator() ([16]byte, error) {
+	authenticator := [16]byte{}
+	_, err := rand.Read(authenticator[:])
+	return authenticator, err
+}
+
+// authenticate returns the MD5 hash of the message, with its Authenticator
+// field replaced by the given one, followed by the shared secret.
+func (m Message) authenticate(authenticator [16]byte, secret []byte) [16]byte {
+	m.Authenticator = authenticator
+	hash := md5.New()
+	hash.Write(m.ToBytes())
+	hash.Write(secret)
+	result := [16]byte{}
+	copy(result[:], hash.Sum(nil))
+	return result
+}
+
+// ResponseAuthenticator computes the Response Authenticator of a response to
+// the request with the Request Authenticator: the MD5 hash of the Code,
+// Identifier, Length, Request Authenticator and attributes of the response
+// followed by the shared secret.
+func (m Message) ResponseAuthenticator(requestAuthenticator [16]byte, secret []byte) [16]byte {
+	return m.authenticate(requestAuthenticator, secret)
+}
+
+// SetResponseAuthenticator sets the Authenticator of a response to the
+// request with the Request Authenticator. It must be called after the last
+// change to the attributes.
+func (m *Message) SetResponseAuthenticator(requestAuthenticator [16]byte, secret []byte) {
+	m.Authenticator = m.ResponseAuthenticator(requestAuthenticator, secret)
+}
+
+// VerifyResponseAuthenticator reports whether the Authenticator of a
+// response is correct for the request with the Request Authenticator.
+func (m Message) VerifyResponseAuthenticator(requestAuthenticator [16]byte, secret []byte) bool {
+	expected := m.ResponseAuthenticator(requestAuthenticator, secret)
+	return subtle.ConstantTimeCompare(expected[:], m.Authenticator[:]) == 1
+}
+
+// ErrResponseAuthenticatorInvalid is returned when the Response Authenticator
+// of a response does not match, and matches ErrBadAuthenticator.
+var ErrResponseAuthenticatorInvalid error = authenticatorError("response authenticator invalid")
+
+// VerifyResponse checks the Response Authenticator of a response to the
+// request with the Request Authenticator, then its Message-Authenticator as
+// VerifyResponseMessageAuthenticator does.
+func (m Message) VerifyResponse(requestAuthenticator [16]byte, secret []byte, required bool) error {
+	if !m.VerifyResponseAuthenticator(requestAuthenticator, secret) {
+		return ErrResponseAuthenticatorInvalid
+	}
+	return m.VerifyResponseMessageAuthenticator(requestAuthenticator, secret, required)
+}
+
+// AccountingAuthenticator computes the Request Authenticator of an
+// Accounting-Request, which is also used by CoA-Request and
+// Disconnect-Request: the MD5 hash of the message with an Authenticator of
+// sixteen zero bytes, followed by the shared secret.
+func (m Message) AccountingAuthenticator(secret []byte) [16]byte {
+	return m.authenticate([16]byte{}, secret)
+}
+
+// SetAccountingAuthenticator sets the Authenticator of an Accounting-Request,
+// CoA-Request or Disconnect-Request. It must be called after the last change
+// to the attributes.
+func (m *Message) SetAccountingAuthenticator(secret []byte) {
+	m.Authenticator = m.AccountingAuthenticator(secret)
+}
+
+// VerifyAccountingAuthenticator reports whether the Authenticator of an
+// Accounting-Request, CoA-Request or Disconnect-Request is correct.
+func (m Message) VerifyAccountingAuthenticator(secret []byte) bool {
+	expected := m.AccountingAuthenticator(secret)
+	return subtle.ConstantTimeCompare(expected[:], m.Authenticator[:]) == 1
+}
+
+// SignRequest computes the authenticators of a request after the last change
+// to it, such as setting its Identifier: its Message-Authenticator if it has
+// one, and the Request Authenticator of an Accounting-Request, CoA-Request or
+// Disconnect-Request. Other requests keep their Request Authenticator, which
+// hides their User-Password, or are given a random one if it is zero.
+func (m *Message) SignRequest(secret []byte) error {
+	if !hasAccountingAuthenticator(m.Code) && m.Authenticator == [16]byte{} {
+		authenticator, err := NewRequestAuthenticator()
+		if err != nil {
+			return err
+		}
+		m.Authenticator = authenticator
+	}
+	if m.Avps.GetFirst(AttributeMessageAuthenticator, 0) != nil {
+		m.AddMessageAuthenticator(secret)
+	}
+	if hasAccountingAuthenticator(m.Code) {
+		m.SetAccountingAuthenticator(secret)
+	}
+	return nil
+}
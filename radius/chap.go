@@ -0,0 +1,55 @@
+package radius
+
+import (
+	"crypto/md5"
+	"crypto/subtle"
+)
+
+// Attributes of CHAP authentication.
+const (
+	AttributeCHAPPassword  AttributeType = 3
+	AttributeCHAPChallenge AttributeType = 60
+)
+
+// CHAPResponse computes the CHAP response to the challenge: the MD5 hash of
+// the CHAP identifier, the password and the challenge.
+func CHAPResponse(ident byte, password []byte, challenge []byte) [16]byte {
+	hash := md5.New()
+	hash.Write([]byte{ident})
+	hash.Write(password)
+	hash.Write(challenge)
+	response := [16]byte{}
+	copy(response[:], hash.Sum(nil))
+	return response
+}
+
+// NewAvpCHAPPassword creates a CHAP-Password attribute answering the challenge.
+func NewAvpCHAPPassword(ident byte, password []byte, challenge []byte) Avp {
+	response := CHAPResponse(ident, password, challenge)
+	return NewAvp(AttributeCHAPPassword, 0, append([]byte{ident}, response[:]...))
+}
+
+// NewAvpCHAPChallenge creates a CHAP-Challenge attribute. A request without
+// one uses its Request Authenticator as the challenge.
+func NewAvpCHAPChallenge(challenge []byte) Avp {
+	return NewAvp(AttributeCHAPChallenge, 0, challenge)
+}
+
+// CHAPChallenge returns the CHAP challenge of a request: its CHAP-Challenge,
+// or its Request Authenticator if it has none.
+func (m Message) CHAPChallenge() []byte {
+	if challenge := m.Avps.GetFirst(AttributeCHAPChallenge, 0); challenge != nil {
+		return challenge.Data
+	}
+	return m.Authenticator[:]
+}
+
+// VerifyCHAP reports whether the data of a CHAP-Password attribute is the
+// response to the challenge for the cleartext password.
+func VerifyCHAP(challenge []byte, chapPassword []byte, cleartext []byte) bool {
+	if len(chapPassword) != 1+md5.Size {
+		return false
+	}
+	expected := CHAPResponse(chapPassword[0], cleartext, challenge)
+	return subtle.ConstantTimeCompare(expected[:], chapPassword[1:]) == 1
+}
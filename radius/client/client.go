@@ -0,0 +1,329 @@
+// Package client sends RADIUS requests over UDP, TCP, RadSec and DTLS. DTLS
+// sessions are established by a DTLSDialFunc from a third-party DTLS
+// implementation, as the package does not implement DTLS itself.
+package client
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/tinybluerobots/radius-diameter-message/radius"
+)
+
+var (
+	// ErrClosed is returned when sending a request on a closed client.
+	ErrClosed = errors.New("client closed")
+	// ErrTimeout is returned when a server does not respond to any attempt.
+	ErrTimeout = errors.New("no response from server")
+	// ErrNoIdentifier is returned when every identifier is in use for a server.
+	ErrNoIdentifier = errors.New("no free identifier")
+	// ErrTooLarge is returned when a request is larger than the configured MTU.
+	ErrTooLarge = errors.New("request larger than MTU")
+)
+
+// Config configures a Client.
+type Config struct {
+	// Timeout is the time to wait for a response before retransmitting,
+	// which defaults to 3 seconds.
+	Timeout time.Duration
+	// Attempts is how many times a request is sent to a server before giving
+	// up on it, which defaults to 3.
+	Attempts int
+	// Backoff doubles the timeout after each attempt, up to MaxTimeout.
+	Backoff bool
+	// MaxTimeout caps the timeout when Backoff is set, defaulting to ten times Timeout.
+	MaxTimeout time.Duration
+	// Jitter randomizes each timeout by up to this fraction of it, from 0 to 1.
+	Jitter float64
+	// LocalAddress is the UDP address requests are sent from, defaulting to
+	// an ephemeral port on every interface.
+	LocalAddress string
+	// MTU limits the size of requests, so they are not fragmented, defaulting
+	// to the 4096 bytes RADIUS allows.
+	MTU int
+	// WaitForIdentifier makes a request wait for an Identifier to be freed
+	// when all 256 are in use for its server, rather than fail with
+	// ErrNoIdentifier.
+	WaitForIdentifier bool
+	// OnUnsolicited receives every response that matched no outstanding
+	// request, after it is counted in Unsolicited. Such responses are
+	// dropped if nil.
+	OnUnsolicited UnsolicitedHandler
+	// Store, if set, checkpoints outstanding requests so that a client made
+	// after a restart, with the same LocalAddress, counts responses to them
+	// as Late rather than unsolicited.
+	Store Store
+	// LateWindow is how long after it was sent a request restored from the
+	// Store keeps its Identifier, which defaults to a minute.
+	LateWindow time.Duration
+}
+
+// withDefaults returns the configuration with defaults for unset fields.
+func (c Config) withDefaults() Config {
+	if c.Timeout <= 0 {
+		c.Timeout = 3 * time.Second
+	}
+	if c.Attempts <= 0 {
+		c.Attempts = 3
+	}
+	if c.MaxTimeout <= 0 {
+		c.MaxTimeout = 10 * c.Timeout
+	}
+	if c.LocalAddress == "" {
+		c.LocalAddress = ":0"
+	}
+	if c.MTU <= 0 || c.MTU > 4096 {
+		c.MTU = 4096
+	}
+	if c.LateWindow <= 0 {
+		c.LateWindow = time.Minute
+	}
+	return c
+}
+
+// Client sends RADIUS requests over UDP and waits for their responses. It
+// allocates the Identifier of each request per server, retransmits requests
+// until a response arrives, and accepts only the first response whose
+// Response Authenticator, and Message-Authenticator if it has one, verify.
+// It is safe for concurrent use.
+type Client struct {
+	config      Config
+	conn        net.PacketConn
+	identifiers *IdentifierPool
+	mutex       sync.Mutex
+	pending     map[key]*pending
+	restored    map[key]struct{}
+	unsolicited atomic.Uint64
+	late        atomic.Uint64
+	done        chan struct{}
+	closeOnce   sync.Once
+}
+
+// key identifies an outstanding request by server address and Identifier.
+type key struct {
+	address    string
+	identifier byte
+}
+
+// pending is an outstanding request waiting for its response.
+type pending struct {
+	authenticator [16]byte
+	secret        []byte
+	responses     chan radius.Message
+}
+
+// New creates a client listening for responses on its local address.
+func New(config Config) (*Client, error) {
+	config = config.withDefaults()
+	conn, err := net.ListenPacket("udp", config.LocalAddress)
+	if err != nil {
+		return nil, err
+	}
+	c := &Client{
+		config:      config,
+		conn:        conn,
+		identifiers: NewIdentifierPool(),
+		pending:     make(map[key]*pending),
+		restored:    make(map[key]struct{}),
+		done:        make(chan struct{}),
+	}
+	if err := c.restore(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	go c.read()
+	return c, nil
+}
+
+// LocalAddr returns the address requests are sent from.
+func (c *Client) LocalAddr() net.Addr {
+	return c.conn.LocalAddr()
+}
+
+// Send sends the request to the server at the UDP address and waits for its
+// response. The request is given a free Identifier for the server and signed
+// with the shared secret by radius.Message.SignRequest, so a User-Password it
+// carries must be hidden with the secret and its Request Authenticator. It
+// is retransmitted after each timeout, up to the configured number of
+// attempts: unchanged, except that an Accounting-Request is rebuilt by
+// radius.RetransmitAccounting with a new Identifier.
+func (c *Client) Send(ctx context.Context, request radius.Message, address string, secret []byte) (radius.Message, error) {
+	server, err := net.ResolveUDPAddr("udp", address)
+	if err != nil {
+		return radius.Message{}, err
+	}
+	request = request.Clone()
+	identifier, err := c.config.allocate(ctx, c.identifiers, server.String())
+	if err != nil {
+		return radius.Message{}, err
+	}
+	k := key{server.String(), identifier}
+	defer func() { c.release(k) }()
+	request.Identifier = identifier
+	if err := request.SignRequest(secret); err != nil {
+		return radius.Message{}, err
+	}
+	var waiting *pending
+	var sent time.Time
+	for attempt := range c.config.Attempts {
+		if attempt == 0 || request.Code == radius.CodeAccountingRequest {
+			if attempt > 0 {
+				if request, k, err = c.retransmit(ctx, request, k, secret, time.Since(sent)); err != nil {
+					return radius.Message{}, err
+				}
+			}
+			if waiting, err = c.register(k, request, secret); err != nil {
+				return radius.Message{}, err
+			}
+		}
+		bytes := request.ToBytes()
+		if len(bytes) > c.config.MTU {
+			return radius.Message{}, ErrTooLarge
+		}
+		sent = time.Now()
+		if _, err := c.conn.WriteTo(bytes, server); err != nil {
+			return radius.Message{}, err
+		}
+		timer := time.NewTimer(c.config.timeout(attempt))
+		select {
+		case response := <-waiting.responses:
+			timer.Stop()
+			return response, nil
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return radius.Message{}, ctx.Err()
+		case <-c.done:
+			timer.Stop()
+			return radius.Message{}, ErrClosed
+		}
+	}
+	return radius.Message{}, ErrTimeout
+}
+
+// allocate allocates an Identifier from the pool for a request to the
+// destination, waiting for one if the configuration says to.
+func (c Config) allocate(ctx context.Context, identifiers *IdentifierPool, destination string) (byte, error) {
+	if c.WaitForIdentifier {
+		return identifiers.Acquire(ctx, destination)
+	}
+	return identifiers.Allocate(destination)
+}
+
+// register records the signed request as outstanding and checkpoints it
+// to the store.
+func (c *Client) register(k key, request radius.Message, secret []byte) (*pending, error) {
+	c.mutex.Lock()
+	select {
+	case <-c.done:
+		c.mutex.Unlock()
+		return nil, ErrClosed
+	default:
+	}
+	waiting := &pending{
+		authenticator: request.Authenticator,
+		secret:        secret,
+		responses:     make(chan radius.Message, 1),
+	}
+	c.pending[k] = waiting
+	c.mutex.Unlock()
+	return waiting, c.save(k, request)
+}
+
+// retransmit rebuilds an Accounting-Request that went unanswered for the
+// elapsed time with a new Identifier, as RFC 2866 section 5.2 requires,
+// freeing the old one. On failure the old key is returned, to be released.
+func (c *Client) retransmit(ctx context.Context, request radius.Message, k key, secret []byte, elapsed time.Duration) (radius.Message, key, error) {
+	identifier, err := c.config.allocate(ctx, c.identifiers, k.address)
+	if err != nil {
+		return request, k, err
+	}
+	c.release(k)
+	return radius.RetransmitAccounting(request, identifier, elapsed, secret), key{k.address, identifier}, nil
+}
+
+// release frees the Identifier of a request, so later responses to it are dropped.
+func (c *Client) release(k key) {
+	c.mutex.Lock()
+	delete(c.pending, k)
+	c.mutex.Unlock()
+	c.forget(k)
+	c.identifiers.Release(k.address, k.identifier)
+}
+
+// timeout returns the time to wait for a response to the attempt.
+func (c Config) timeout(attempt int) time.Duration {
+	timeout := c.Timeout
+	if c.Backoff {
+		for i := 0; i < attempt && timeout < c.MaxTimeout; i++ {
+			timeout *= 2
+		}
+		timeout = min(timeout, c.MaxTimeout)
+	}
+	if c.Jitter > 0 {
+		spread := float64(timeout) * min(c.Jitter, 1)
+		timeout += time.Duration(spread * (2*rand.Float64() - 1))
+	}
+	return timeout
+}
+
+// read reads responses until the client is closed, passing each to the
+// request waiting for it. Responses to requests restored from the Store
+// are counted as late; those to no outstanding request and duplicates are
+// passed to the unsolicited handler; those that fail verification are
+// dropped.
+func (c *Client) read() {
+	buffer := make([]byte, 4096)
+	for {
+		n, address, err := c.conn.ReadFrom(buffer)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			continue
+		}
+		response, err := radius.ReadMessage(append([]byte(nil), buffer[:n]...))
+		if err != nil {
+			continue
+		}
+		k := key{address.String(), response.Identifier}
+		if !c.deliver(k, *response) && !c.lateResponse(k) {
+			c.unmatched(*response, address)
+		}
+	}
+}
+
+// deliver passes the response to the outstanding request if it verifies,
+// reporting false if no request with its Identifier is outstanding.
+func (c *Client) deliver(k key, response radius.Message) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	waiting, ok := c.pending[k]
+	if !ok {
+		return false
+	}
+	if response.VerifyResponse(waiting.authenticator, waiting.secret, false) != nil {
+		return true
+	}
+	delete(c.pending, k)
+	waiting.responses <- response
+	return true
+}
+
+// Close stops the client, failing outstanding requests with ErrClosed.
+func (c *Client) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		c.mutex.Lock()
+		close(c.done)
+		c.mutex.Unlock()
+		c.identifiers.Close()
+		err = c.conn.Close()
+	})
+	return err
+}
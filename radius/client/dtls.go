@@ -0,0 +1,123 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+
+	"github.com/tinybluerobots/radius-diameter-message/radius"
+)
+
+// DTLSDialFunc establishes a DTLS session with the server at the UDP
+// address. This package does not implement DTLS itself: the function must
+// come from a third-party DTLS implementation. Each Read and Write of the
+// connection carries a single datagram.
+type DTLSDialFunc func(ctx context.Context, address string) (net.Conn, error)
+
+// NewDTLSStream sends requests over an established DTLS connection with the
+// shared secret RFC 7360 fixes. Requests are retransmitted as Client.Send
+// does, since the transport is not reliable.
+func NewDTLSStream(conn net.Conn, config Config) *Stream {
+	return newStream(conn, radius.DTLSSecret, config.withDefaults(), true)
+}
+
+// DTLSClient sends requests to servers over DTLS, caching one session per
+// server so the handshake is made once rather than for every request, and
+// establishing a new session when one closes. It is safe for concurrent use.
+type DTLSClient struct {
+	dial     DTLSDialFunc
+	config   Config
+	mutex    sync.Mutex
+	sessions map[string]*Stream
+	dialing  map[string]*dialing
+	closed   bool
+}
+
+// dialing is a session being established, closing done once it is.
+type dialing struct {
+	done chan struct{}
+}
+
+// NewDTLSClient creates a client establishing sessions with the dial
+// function.
+func NewDTLSClient(dial DTLSDialFunc, config Config) *DTLSClient {
+	return &DTLSClient{
+		dial:     dial,
+		config:   config,
+		sessions: make(map[string]*Stream),
+		dialing:  make(map[string]*dialing),
+	}
+}
+
+// Send sends the request to the server at the UDP address over its cached
+// session and waits for its response.
+func (c *DTLSClient) Send(ctx context.Context, request radius.Message, address string) (radius.Message, error) {
+	stream, err := c.session(ctx, address)
+	if err != nil {
+		return radius.Message{}, err
+	}
+	return stream.Send(ctx, request)
+}
+
+// session returns the open session with the server, establishing one if
+// there is none. The handshake is made without the mutex held, so a slow
+// server does not hold up requests to the others; requests to the same
+// server wait for it rather than make their own.
+func (c *DTLSClient) session(ctx context.Context, address string) (*Stream, error) {
+	for {
+		c.mutex.Lock()
+		if c.closed {
+			c.mutex.Unlock()
+			return nil, ErrClosed
+		}
+		if stream, ok := c.sessions[address]; ok {
+			if stream.Err() == nil {
+				c.mutex.Unlock()
+				return stream, nil
+			}
+			delete(c.sessions, address)
+		}
+		inFlight, ok := c.dialing[address]
+		if !ok {
+			break
+		}
+		c.mutex.Unlock()
+		select {
+		case <-inFlight.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	inFlight := &dialing{done: make(chan struct{})}
+	c.dialing[address] = inFlight
+	c.mutex.Unlock()
+	conn, err := c.dial(ctx, address)
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	delete(c.dialing, address)
+	close(inFlight.done)
+	if err != nil {
+		return nil, err
+	}
+	if c.closed {
+		conn.Close()
+		return nil, ErrClosed
+	}
+	stream := NewDTLSStream(conn, c.config)
+	c.sessions[address] = stream
+	return stream, nil
+}
+
+// Close closes every session, failing outstanding requests with ErrClosed.
+func (c *DTLSClient) Close() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.closed = true
+	var err error
+	for address, stream := range c.sessions {
+		err = errors.Join(err, stream.Close())
+		delete(c.sessions, address)
+	}
+	return err
+}
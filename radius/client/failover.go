@@ -0,0 +1,62 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+
+	"github.com/tinybluerobots/radius-diameter-message/radius"
+)
+
+// ErrNoServer is returned when no server is given to send a request to.
+var ErrNoServer = errors.New("no server available")
+
+// Server is the address and shared secret of a RADIUS server.
+type Server struct {
+	Address string
+	Secret  []byte
+}
+
+// SendFailover sends the request to the servers in order of preference,
+// moving on to the next when one fails to respond after every attempt. The
+// request is prepared for the first server; its User-Password is hidden
+// again for a server with a different secret.
+func (c *Client) SendFailover(ctx context.Context, request radius.Message, servers ...Server) (radius.Message, error) {
+	err := ErrNoServer
+	for i, server := range servers {
+		attempt := request
+		if i > 0 && !bytes.Equal(server.Secret, servers[0].Secret) {
+			attempt, err = rehide(request, servers[0].Secret, server.Secret)
+			if err != nil {
+				return radius.Message{}, err
+			}
+		}
+		var response radius.Message
+		response, err = c.Send(ctx, attempt, server.Address, server.Secret)
+		if err == nil || ctx.Err() != nil || errors.Is(err, ErrClosed) {
+			return response, err
+		}
+	}
+	return radius.Message{}, err
+}
+
+// rehide hides the User-Password of the request, hidden with one secret,
+// with another.
+func rehide(request radius.Message, from []byte, to []byte) (radius.Message, error) {
+	request = request.Clone()
+	for i, avp := range request.Avps {
+		if avp.Type != radius.AttributeUserPassword || avp.VendorId != 0 || avp.Extended != 0 {
+			continue
+		}
+		password, err := radius.DecryptUserPassword(avp.Data, from, request.Authenticator)
+		if err != nil {
+			return radius.Message{}, err
+		}
+		hidden, err := radius.EncryptUserPassword(password, to, request.Authenticator)
+		if err != nil {
+			return radius.Message{}, err
+		}
+		request.Avps[i] = radius.NewAvp(radius.AttributeUserPassword, 0, hidden)
+	}
+	return request, nil
+}
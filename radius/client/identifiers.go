@@ -0,0 +1,143 @@
+package client
+
+import (
+	"context"
+	"sync"
+)
+
+// IdentifierPool allocates the Identifiers of requests from a single source
+// socket, tracking those outstanding per destination so that no two requests
+// in flight to a destination share one. Identifiers are allocated in turn,
+// so a released one is not reused until the others have been. It is safe
+// for concurrent use.
+type IdentifierPool struct {
+	mutex        sync.Mutex
+	destinations map[string]*identifiers
+	released     chan struct{}
+	closed       bool
+}
+
+// identifiers are the outstanding Identifiers of requests to a destination.
+type identifiers struct {
+	used  [256]bool
+	count int
+	next  byte
+}
+
+// NewIdentifierPool creates a pool with every Identifier free.
+func NewIdentifierPool() *IdentifierPool {
+	return &IdentifierPool{
+		destinations: make(map[string]*identifiers),
+		released:     make(chan struct{}),
+	}
+}
+
+// Allocate returns a free Identifier for a request to the destination,
+// marking it outstanding. It returns ErrNoIdentifier if all 256 are.
+func (p *IdentifierPool) Allocate(destination string) (byte, error) {
+	identifier, _, err := p.allocate(destination)
+	return identifier, err
+}
+
+// Acquire returns a free Identifier for a request to the destination as
+// Allocate does, but waits for one to be released if all 256 are
+// outstanding.
+func (p *IdentifierPool) Acquire(ctx context.Context, destination string) (byte, error) {
+	for {
+		identifier, released, err := p.allocate(destination)
+		if err != ErrNoIdentifier {
+			return identifier, err
+		}
+		select {
+		case <-released:
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+}
+
+// allocate returns a free Identifier, or ErrNoIdentifier and a channel that
+// is closed when one is released.
+func (p *IdentifierPool) allocate(destination string) (byte, <-chan struct{}, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.closed {
+		return 0, nil, ErrClosed
+	}
+	d, ok := p.destinations[destination]
+	if !ok {
+		d = &identifiers{}
+		p.destinations[destination] = d
+	}
+	if d.count == len(d.used) {
+		return 0, p.released, ErrNoIdentifier
+	}
+	for d.used[d.next] {
+		d.next++
+	}
+	identifier := d.next
+	d.used[identifier] = true
+	d.count++
+	d.next++
+	return identifier, nil, nil
+}
+
+// Reserve marks the Identifier outstanding for requests to the destination,
+// such as one restored after a restart, reporting false if it already is.
+// Allocation continues after it.
+func (p *IdentifierPool) Reserve(destination string, identifier byte) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	d, ok := p.destinations[destination]
+	if !ok {
+		d = &identifiers{}
+		p.destinations[destination] = d
+	}
+	if d.used[identifier] {
+		return false
+	}
+	d.used[identifier] = true
+	d.count++
+	d.next = identifier + 1
+	return true
+}
+
+// Release frees an Identifier allocated for a request to the destination,
+// once its response has arrived or it has been given up on.
+func (p *IdentifierPool) Release(destination string, identifier byte) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	d, ok := p.destinations[destination]
+	if !ok || !d.used[identifier] {
+		return
+	}
+	d.used[identifier] = false
+	d.count--
+	if p.closed {
+		return
+	}
+	close(p.released)
+	p.released = make(chan struct{})
+}
+
+// Outstanding returns how many Identifiers are allocated for requests to the
+// destination.
+func (p *IdentifierPool) Outstanding(destination string) int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if d, ok := p.destinations[destination]; ok {
+		return d.count
+	}
+	return 0
+}
+
+// Close fails allocations waiting for an Identifier, and later ones, with
+// ErrClosed.
+func (p *IdentifierPool) Close() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if !p.closed {
+		p.closed = true
+		close(p.released)
+	}
+}
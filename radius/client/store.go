@@ -0,0 +1,148 @@
+package client
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/tinybluerobots/radius-diameter-message/radius"
+)
+
+// Record is an outstanding request checkpointed to a Store.
+type Record struct {
+	// Address is the resolved UDP address of the server.
+	Address string
+	Request radius.Message
+	Started time.Time
+}
+
+// Store persists the outstanding requests of a Client, so that after a
+// restart the responses to requests sent before it are counted as late
+// rather than unsolicited, and their Identifiers are not reused while such
+// responses may arrive. A record is saved once its request is signed and
+// deleted once the request is finished with, or after the late window once
+// restored. Streams do not use a Store, as responses cannot arrive on a
+// connection made after a restart. Implementations must be safe for
+// concurrent use.
+type Store interface {
+	// Save saves the record, replacing any with the same address and Identifier.
+	Save(record Record) error
+	// Delete deletes the record with the address and Identifier, if any.
+	Delete(address string, identifier byte) error
+	// Load returns every saved record.
+	Load() ([]Record, error)
+}
+
+// MemoryStore is a Store keeping records in memory, which survives
+// replacing a client within a process.
+type MemoryStore struct {
+	mutex   sync.Mutex
+	records map[key]Record
+}
+
+// NewMemoryStore creates an empty store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: make(map[key]Record)}
+}
+
+// Save saves the record.
+func (s *MemoryStore) Save(record Record) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.records[key{record.Address, record.Request.Identifier}] = record
+	return nil
+}
+
+// Delete deletes the record with the address and Identifier.
+func (s *MemoryStore) Delete(address string, identifier byte) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.records, key{address, identifier})
+	return nil
+}
+
+// Load returns every saved record.
+func (s *MemoryStore) Load() ([]Record, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	records := make([]Record, 0, len(s.records))
+	for _, record := range s.records {
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// Late returns how many responses have matched a request restored from the
+// Store.
+func (c *Client) Late() uint64 {
+	return c.late.Load()
+}
+
+// restore reserves the Identifiers of the requests checkpointed to the store
+// by an earlier client, oldest first so that allocation continues after the
+// latest, until the late window passes. Nobody waits for them, so their
+// responses are only counted.
+func (c *Client) restore() error {
+	if c.config.Store == nil {
+		return nil
+	}
+	records, err := c.config.Store.Load()
+	if err != nil {
+		return err
+	}
+	slices.SortFunc(records, func(a Record, b Record) int {
+		return a.Started.Compare(b.Started)
+	})
+	for _, record := range records {
+		k := key{record.Address, record.Request.Identifier}
+		if !c.identifiers.Reserve(k.address, k.identifier) {
+			continue
+		}
+		c.restored[k] = struct{}{}
+		time.AfterFunc(time.Until(record.Started.Add(c.config.LateWindow)), func() {
+			c.forgetRestored(k)
+		})
+	}
+	return nil
+}
+
+// lateResponse reports whether the response matched a restored request,
+// counting it and freeing the request's Identifier if so.
+func (c *Client) lateResponse(k key) bool {
+	if !c.forgetRestored(k) {
+		return false
+	}
+	c.late.Add(1)
+	return true
+}
+
+// forgetRestored frees the Identifier of a restored request and deletes its
+// record, reporting whether it was still reserved.
+func (c *Client) forgetRestored(k key) bool {
+	c.mutex.Lock()
+	_, ok := c.restored[k]
+	delete(c.restored, k)
+	c.mutex.Unlock()
+	if ok {
+		c.identifiers.Release(k.address, k.identifier)
+		c.config.Store.Delete(k.address, k.identifier)
+	}
+	return ok
+}
+
+// save checkpoints a signed request to the store, if any.
+func (c *Client) save(k key, request radius.Message) error {
+	if c.config.Store == nil {
+		return nil
+	}
+	return c.config.Store.Save(Record{Address: k.address, Request: request, Started: time.Now()})
+}
+
+// forget deletes the record of a finished request from the store, if any.
+// Failures are ignored: a record left behind is forgotten by the next client
+// to restore it once its late window passes.
+func (c *Client) forget(k key) {
+	if c.config.Store != nil {
+		c.config.Store.Delete(k.address, k.identifier)
+	}
+}
@@ -0,0 +1,259 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/tinybluerobots/radius-diameter-message/radius"
+)
+
+// Stream sends requests over a single TCP connection, as RFC 6613
+// describes, TLS connection, as RFC 6614 describes for RadSec, or DTLS
+// connection, as RFC 7360 describes. Requests are not retransmitted over a
+// reliable transport, and fail with ErrTimeout if no response arrives within
+// the configured timeout. It is safe for concurrent use.
+type Stream struct {
+	conn        net.Conn
+	secret      []byte
+	config      Config
+	datagram    bool
+	writeMutex  sync.Mutex
+	identifiers *IdentifierPool
+	mutex       sync.Mutex
+	pending     map[byte]*pending
+	done        chan struct{}
+	closeOnce   sync.Once
+	err         error
+}
+
+// DialTLS connects to the RadSec server at the TCP address, authenticating
+// with the TLS configuration, and uses the shared secret RFC 6614 fixes.
+func DialTLS(ctx context.Context, address string, tlsConfig *tls.Config, config Config) (*Stream, error) {
+	dialer := tls.Dialer{Config: tlsConfig}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	return NewStream(conn, radius.RadSecSecret, config), nil
+}
+
+// DialTCP connects to the RADIUS over TCP server at the address, as RFC 6613
+// describes, and uses the shared secret.
+func DialTCP(ctx context.Context, address string, secret []byte, config Config) (*Stream, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	return NewStream(conn, secret, config), nil
+}
+
+// NewStream sends requests over an established connection with the shared
+// secret. Only the Timeout, MTU and WaitForIdentifier of the configuration apply.
+func NewStream(conn net.Conn, secret []byte, config Config) *Stream {
+	config = config.withDefaults()
+	config.Attempts = 1
+	config.Backoff = false
+	return newStream(conn, secret, config, false)
+}
+
+// newStream starts reading responses from the connection.
+func newStream(conn net.Conn, secret []byte, config Config, datagram bool) *Stream {
+	s := &Stream{
+		conn:        conn,
+		secret:      secret,
+		config:      config,
+		datagram:    datagram,
+		identifiers: NewIdentifierPool(),
+		pending:     make(map[byte]*pending),
+		done:        make(chan struct{}),
+	}
+	go s.read()
+	return s
+}
+
+// Send sends the request and waits for its response. The request is given a
+// free Identifier on the connection and signed as Client.Send does. Over
+// DTLS it is retransmitted as Client.Send does, an Accounting-Request with
+// a new Identifier.
+func (s *Stream) Send(ctx context.Context, request radius.Message) (radius.Message, error) {
+	request = request.Clone()
+	identifier, err := s.config.allocate(ctx, s.identifiers, "")
+	if err == ErrClosed {
+		return radius.Message{}, s.Err()
+	}
+	if err != nil {
+		return radius.Message{}, err
+	}
+	defer func() { s.release(identifier) }()
+	request.Identifier = identifier
+	if err := request.SignRequest(s.secret); err != nil {
+		return radius.Message{}, err
+	}
+	var waiting *pending
+	var sent time.Time
+	for attempt := range s.config.Attempts {
+		if attempt == 0 || request.Code == radius.CodeAccountingRequest {
+			if attempt > 0 {
+				if request, identifier, err = s.retransmit(ctx, request, identifier, time.Since(sent)); err != nil {
+					return radius.Message{}, err
+				}
+			}
+			if waiting, err = s.register(identifier, request); err != nil {
+				return radius.Message{}, err
+			}
+		}
+		bytes := request.ToBytes()
+		if len(bytes) > s.config.MTU {
+			return radius.Message{}, ErrTooLarge
+		}
+		sent = time.Now()
+		if err := s.write(bytes); err != nil {
+			s.close(err)
+			return radius.Message{}, err
+		}
+		timer := time.NewTimer(s.config.timeout(attempt))
+		select {
+		case response := <-waiting.responses:
+			timer.Stop()
+			return response, nil
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return radius.Message{}, ctx.Err()
+		case <-s.done:
+			timer.Stop()
+			return radius.Message{}, s.err
+		}
+	}
+	return radius.Message{}, ErrTimeout
+}
+
+// register records the signed request as outstanding.
+func (s *Stream) register(identifier byte, request radius.Message) (*pending, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	select {
+	case <-s.done:
+		return nil, s.err
+	default:
+	}
+	waiting := &pending{
+		authenticator: request.Authenticator,
+		secret:        s.secret,
+		responses:     make(chan radius.Message, 1),
+	}
+	s.pending[identifier] = waiting
+	return waiting, nil
+}
+
+// retransmit rebuilds an unanswered Accounting-Request as Client does. On
+// failure the old Identifier is returned, to be released.
+func (s *Stream) retransmit(ctx context.Context, request radius.Message, identifier byte, elapsed time.Duration) (radius.Message, byte, error) {
+	next, err := s.config.allocate(ctx, s.identifiers, "")
+	if err == ErrClosed {
+		return request, identifier, s.Err()
+	}
+	if err != nil {
+		return request, identifier, err
+	}
+	s.release(identifier)
+	return radius.RetransmitAccounting(request, next, elapsed, s.secret), next, nil
+}
+
+// release frees the Identifier of a request. The connection has a single
+// destination, so its Identifiers are allocated for the empty one.
+func (s *Stream) release(identifier byte) {
+	s.mutex.Lock()
+	delete(s.pending, identifier)
+	s.mutex.Unlock()
+	s.identifiers.Release("", identifier)
+}
+
+// write writes a whole message to the connection.
+func (s *Stream) write(bytes []byte) error {
+	s.writeMutex.Lock()
+	defer s.writeMutex.Unlock()
+	_, err := s.conn.Write(bytes)
+	return err
+}
+
+// read reads responses until the connection closes, passing each that
+// verifies to the request waiting for it.
+func (s *Stream) read() {
+	next := s.reader()
+	for {
+		response, err := next()
+		if err != nil {
+			s.close(err)
+			return
+		}
+		if response == nil {
+			continue
+		}
+		s.mutex.Lock()
+		waiting, ok := s.pending[response.Identifier]
+		if ok && response.VerifyResponse(waiting.authenticator, s.secret, false) == nil {
+			delete(s.pending, response.Identifier)
+			waiting.responses <- *response
+		}
+		s.mutex.Unlock()
+	}
+}
+
+// reader returns a function reading the next response from the connection,
+// or nil for a datagram that is not a well formed message.
+func (s *Stream) reader() func() (*radius.Message, error) {
+	if s.datagram {
+		buffer := make([]byte, 4096)
+		return func() (*radius.Message, error) {
+			n, err := s.conn.Read(buffer)
+			if err != nil {
+				return nil, err
+			}
+			response, _ := radius.ReadMessage(append([]byte(nil), buffer[:n]...))
+			return response, nil
+		}
+	}
+	reader := bufio.NewReader(s.conn)
+	return func() (*radius.Message, error) {
+		return radius.ReadMessageFrom(reader)
+	}
+}
+
+// Done returns a channel that is closed when the connection is closed.
+func (s *Stream) Done() <-chan struct{} {
+	return s.done
+}
+
+// Err returns the reason the connection was closed, or nil if it is open.
+func (s *Stream) Err() error {
+	select {
+	case <-s.done:
+		return s.err
+	default:
+		return nil
+	}
+}
+
+// Close closes the connection, failing outstanding requests with ErrClosed.
+func (s *Stream) Close() error {
+	s.close(ErrClosed)
+	return nil
+}
+
+// close closes the connection, recording the reason.
+func (s *Stream) close(err error) {
+	s.closeOnce.Do(func() {
+		s.mutex.Lock()
+		s.err = err
+		close(s.done)
+		s.mutex.Unlock()
+		s.identifiers.Close()
+		s.conn.Close()
+	})
+}
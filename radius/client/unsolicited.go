@@ -0,0 +1,47 @@
+package client
+
+import (
+	"log/slog"
+	"net"
+
+	"github.com/tinybluerobots/radius-diameter-message/radius"
+)
+
+// UnsolicitedHandler receives a response that matched no outstanding
+// request, such as one arriving after its request gave up or a duplicate,
+// with the address of the server that sent it. Such responses are the first
+// sign of a server misbehaving. It is called by the goroutine reading
+// responses, so it must not block.
+type UnsolicitedHandler func(response radius.Message, address net.Addr)
+
+// DropUnsolicited is an UnsolicitedHandler that discards every response, as
+// the client does when none is configured.
+func DropUnsolicited(radius.Message, net.Addr) {}
+
+// LogUnsolicited returns an UnsolicitedHandler logging every response as a
+// warning with the logger, or the default logger if it is nil.
+func LogUnsolicited(logger *slog.Logger) UnsolicitedHandler {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return func(response radius.Message, address net.Addr) {
+		logger.Warn("unsolicited RADIUS response",
+			"code", response.Code.String(),
+			"identifier", response.Identifier,
+			"server", address.String())
+	}
+}
+
+// Unsolicited returns how many responses have matched no outstanding request.
+func (c *Client) Unsolicited() uint64 {
+	return c.unsolicited.Load()
+}
+
+// unmatched counts a response that matched no outstanding request and passes
+// it to the configured handler.
+func (c *Client) unmatched(response radius.Message, address net.Addr) {
+	c.unsolicited.Add(1)
+	if c.config.OnUnsolicited != nil {
+		c.config.OnUnsolicited(response, address)
+	}
+}
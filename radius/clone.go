@@ -0,0 +1,27 @@
+package radius
+
+// Clone returns a deep copy of the AVP, including its data.
+func (a Avp) Clone() Avp {
+	if a.Data != nil {
+		a.Data = append(avpData(nil), a.Data...)
+	}
+	return a
+}
+
+// Clone returns a deep copy of the slice of AVPs.
+func (a Avps) Clone() Avps {
+	if a == nil {
+		return nil
+	}
+	avps := make(Avps, len(a))
+	for i, avp := range a {
+		avps[i] = avp.Clone()
+	}
+	return avps
+}
+
+// Clone returns a deep copy of the RADIUS message.
+func (m Message) Clone() Message {
+	m.Avps = m.Avps.Clone()
+	return m
+}
@@ -0,0 +1,66 @@
+package radius
+
+import "errors"
+
+// ErrUnknownCode is returned by ReadMessageStrict for a message whose code is not a known one.
+var ErrUnknownCode = errors.New("unknown message code")
+
+// Code represents the code in a RADIUS message.
+type Code uint32
+
+const (
+	CodeAccessRequest      Code = 1
+	CodeAccessAccept       Code = 2
+	CodeAccessReject       Code = 3
+	CodeAccountingRequest  Code = 4
+	CodeAccountingResponse Code = 5
+	CodeAccessChallenge    Code = 11
+	CodeStatusServer       Code = 12
+	CodeStatusClient       Code = 13
+	CodeDisconnectRequest  Code = 40
+	CodeDisconnectACK      Code = 41
+	CodeDisconnectNAK      Code = 42
+	CodeCoARequest         Code = 43
+	CodeCoAACK             Code = 44
+	CodeCoANAK             Code = 45
+)
+
+// String returns the name of the code.
+func (c Code) String() string {
+	switch c {
+	case CodeAccessRequest:
+		return "ACCESS_REQUEST"
+	case CodeAccessAccept:
+		return "ACCESS_ACCEPT"
+	case CodeAccessReject:
+		return "ACCESS_REJECT"
+	case CodeAccountingRequest:
+		return "ACCOUNTING_REQUEST"
+	case CodeAccountingResponse:
+		return "ACCOUNTING_RESPONSE"
+	case CodeAccessChallenge:
+		return "ACCESS_CHALLENGE"
+	case CodeStatusServer:
+		return "STATUS_SERVER"
+	case CodeStatusClient:
+		return "STATUS_CLIENT"
+	case CodeDisconnectRequest:
+		return "DISCONNECT_REQUEST"
+	case CodeDisconnectACK:
+		return "DISCONNECT_ACK"
+	case CodeDisconnectNAK:
+		return "DISCONNECT_NAK"
+	case CodeCoARequest:
+		return "COA_REQUEST"
+	case CodeCoAACK:
+		return "COA_ACK"
+	case CodeCoANAK:
+		return "COA_NAK"
+	}
+	return "UNKNOWN"
+}
+
+// Known reports whether the code is one of the defined codes.
+func (c Code) Known() bool {
+	return c.String() != "UNKNOWN"
+}
@@ -0,0 +1,55 @@
+package radius
+
+import "fmt"
+
+// Codec converts the data of attributes with a proprietary format, such as
+// packed structs or BCD-coded numbers, to and from Go values.
+type Codec struct {
+	// Decode converts the data of an attribute to its value.
+	Decode func(data []byte) (any, error)
+	// Encode converts a value to the data of an attribute.
+	Encode func(value any) ([]byte, error)
+}
+
+// AddCodec registers the codec of the attribute with the type and vendor ID,
+// which TypedValue then decodes with it ahead of its data type.
+func (d *Dictionary) AddCodec(attributeType AttributeType, vendorId VendorId, codec Codec) *Dictionary {
+	d.codecs[attributeKey{attributeType, vendorId}] = codec
+	return d
+}
+
+// Codec retrieves the codec of the attribute with the type and vendor ID.
+func (d *Dictionary) Codec(attributeType AttributeType, vendorId VendorId) *Codec {
+	if d == nil {
+		return nil
+	}
+	codec, ok := d.codecs[attributeKey{attributeType, vendorId}]
+	if !ok {
+		return nil
+	}
+	return &codec
+}
+
+// NewAvpValue creates an attribute from a value encoded with the codec the
+// dictionary holds for the type and vendor ID.
+func NewAvpValue(dictionary *Dictionary, attributeType AttributeType, vendorId VendorId, value any) (Avp, error) {
+	codec := dictionary.Codec(attributeType, vendorId)
+	if codec == nil || codec.Encode == nil {
+		return Avp{}, fmt.Errorf("no codec encodes attribute %d (vendor %d)", attributeType, vendorId)
+	}
+	data, err := codec.Encode(value)
+	if err != nil {
+		return Avp{}, fmt.Errorf("attribute %d (vendor %d): %w", attributeType, vendorId, err)
+	}
+	return NewAvp(attributeType, vendorId, data), nil
+}
+
+// decode converts the data of the attribute with the codec, reporting false
+// if it has none or the data is malformed.
+func (c *Codec) decode(data []byte) (any, bool) {
+	if c == nil || c.Decode == nil {
+		return nil, false
+	}
+	value, err := c.Decode(data)
+	return value, err == nil
+}
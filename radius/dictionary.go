@@ -0,0 +1,202 @@
+package radius
+
+import "encoding/binary"
+
+// DataType represents the data type of a RADIUS attribute value, as RFC 8044
+// names them.
+type DataType byte
+
+const (
+	String DataType = iota
+	Text
+	Integer
+	Enum
+	Time
+	IPv4Addr
+	IPv6Addr
+	IPv4Prefix
+	IPv6Prefix
+	Integer64
+	InterfaceIdentifier
+	Concat
+	TLV
+	VSA
+)
+
+var dataTypeNames = map[DataType]string{
+	String:              "string",
+	Text:                "text",
+	Integer:             "integer",
+	Enum:                "enum",
+	Time:                "time",
+	IPv4Addr:            "ipv4addr",
+	IPv6Addr:            "ipv6addr",
+	IPv4Prefix:          "ipv4prefix",
+	IPv6Prefix:          "ipv6prefix",
+	Integer64:           "integer64",
+	InterfaceIdentifier: "ifid",
+	Concat:              "concat",
+	TLV:                 "tlv",
+	VSA:                 "vsa",
+}
+
+// String returns the RFC 8044 name of the data type.
+func (t DataType) String() string {
+	if name, ok := dataTypeNames[t]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// AttributeDefinition describes a RADIUS attribute. Values names the values
+// of an Enum attribute.
+type AttributeDefinition struct {
+	Name     string
+	Type     AttributeType
+	VendorId VendorId
+	DataType DataType
+	Values   map[uint32]string
+}
+
+// attributeKey identifies an attribute by type and vendor ID.
+type attributeKey struct {
+	attributeType AttributeType
+	vendorId      VendorId
+}
+
+// Dictionary maps attribute types to names and data types.
+type Dictionary struct {
+	attributes map[attributeKey]AttributeDefinition
+	names      map[string]AttributeDefinition
+	codecs     map[attributeKey]Codec
+}
+
+// NewDictionary creates a new empty Dictionary.
+func NewDictionary() *Dictionary {
+	return &Dictionary{
+		attributes: make(map[attributeKey]AttributeDefinition),
+		names:      make(map[string]AttributeDefinition),
+		codecs:     make(map[attributeKey]Codec),
+	}
+}
+
+// AddAttribute adds attribute definitions to the dictionary.
+func (d *Dictionary) AddAttribute(definitions ...AttributeDefinition) *Dictionary {
+	for _, definition := range definitions {
+		d.attributes[attributeKey{definition.Type, definition.VendorId}] = definition
+		d.names[definition.Name] = definition
+	}
+	return d
+}
+
+// Attribute retrieves the definition of the attribute with the given type and vendor ID.
+func (d *Dictionary) Attribute(attributeType AttributeType, vendorId VendorId) *AttributeDefinition {
+	if d == nil {
+		return nil
+	}
+	definition, ok := d.attributes[attributeKey{attributeType, vendorId}]
+	if !ok {
+		return nil
+	}
+	return &definition
+}
+
+// AttributeByName retrieves the definition of the attribute with the given name.
+func (d *Dictionary) AttributeByName(name string) *AttributeDefinition {
+	if d == nil {
+		return nil
+	}
+	definition, ok := d.names[name]
+	if !ok {
+		return nil
+	}
+	return &definition
+}
+
+// GetByName retrieves all AVPs of the attribute the dictionary gives the name to.
+func (a Avps) GetByName(dictionary *Dictionary, name string) []Avp {
+	definition := dictionary.AttributeByName(name)
+	if definition == nil {
+		return nil
+	}
+	return a.Get(definition.Type, definition.VendorId)
+}
+
+// GetFirstByName retrieves the first AVP of the attribute the dictionary gives the name to.
+func (a Avps) GetFirstByName(dictionary *Dictionary, name string) *Avp {
+	definition := dictionary.AttributeByName(name)
+	if definition == nil {
+		return nil
+	}
+	return a.GetFirst(definition.Type, definition.VendorId)
+}
+
+// TypedValue decodes the AVP with the codec the dictionary holds for it, or
+// else as the Go value of the data type the dictionary gives it: a string for text, the name of an enum value, or a uint32 when
+// the value has no name, a uint32 or uint64 for integers, a time.Time, a
+// net.IP for addresses, a net.IPNet for prefixes, an InterfaceId, Avps for a
+// TLV, and the data as []byte for other types, attributes the dictionary
+// does not know and malformed values.
+func (a *Avp) TypedValue(dictionary *Dictionary) any {
+	if a == nil {
+		return nil
+	}
+	if a.Extended == 0 {
+		if value, ok := dictionary.Codec(a.Type, a.VendorId).decode(a.Data); ok {
+			return value
+		}
+	}
+	definition := dictionary.Attribute(a.Type, a.VendorId)
+	if definition == nil || a.Extended != 0 {
+		return []byte(a.Data)
+	}
+	switch definition.DataType {
+	case Text:
+		return string(a.Data)
+	case Integer:
+		if len(a.Data) == 4 {
+			return binary.BigEndian.Uint32(a.Data)
+		}
+	case Enum:
+		if len(a.Data) == 4 {
+			value := binary.BigEndian.Uint32(a.Data)
+			if name, ok := definition.Values[value]; ok {
+				return name
+			}
+			return value
+		}
+	case Time:
+		if len(a.Data) == 4 {
+			return a.ToTimeOrDefault()
+		}
+	case IPv4Addr:
+		if value, ok := a.ToIPv4Ok(); ok {
+			return value
+		}
+	case IPv6Addr:
+		if value, ok := a.ToIPv6Ok(); ok {
+			return value
+		}
+	case IPv4Prefix:
+		if value, ok := a.ToIPv4PrefixOk(); ok {
+			return value
+		}
+	case IPv6Prefix:
+		if value, ok := a.ToIPv6PrefixOk(); ok {
+			return value
+		}
+	case Integer64:
+		if len(a.Data) == 8 {
+			return binary.BigEndian.Uint64(a.Data)
+		}
+	case InterfaceIdentifier:
+		if value, ok := a.ToInterfaceIdOk(); ok {
+			return value
+		}
+	case TLV:
+		if value := a.ToTLV(); value != nil {
+			return value
+		}
+	}
+	return []byte(a.Data)
+}
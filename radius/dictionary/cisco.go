@@ -0,0 +1,24 @@
+package dictionary
+
+import "github.com/tinybluerobots/radius-diameter-message/radius"
+
+// Cisco describes the common vendor-specific attributes of Cisco.
+var Cisco = Vendor{
+	Id:   9,
+	Name: "Cisco",
+	Attributes: []radius.AttributeDefinition{
+		{Name: "Cisco-AVPair", Type: 1, DataType: radius.Text},
+		{Name: "Cisco-NAS-Port", Type: 2, DataType: radius.Text},
+		{Name: "Cisco-Multilink-ID", Type: 187, DataType: radius.Integer},
+		{Name: "Cisco-Num-In-Multilink", Type: 188, DataType: radius.Integer},
+		{Name: "Cisco-Pre-Input-Octets", Type: 190, DataType: radius.Integer},
+		{Name: "Cisco-Pre-Output-Octets", Type: 191, DataType: radius.Integer},
+		{Name: "Cisco-Pre-Input-Packets", Type: 192, DataType: radius.Integer},
+		{Name: "Cisco-Pre-Output-Packets", Type: 193, DataType: radius.Integer},
+		{Name: "Cisco-Maximum-Time", Type: 194, DataType: radius.Integer},
+		{Name: "Cisco-Disconnect-Cause", Type: 195, DataType: radius.Integer},
+		{Name: "Cisco-Account-Info", Type: 250, DataType: radius.Text},
+		{Name: "Cisco-Service-Info", Type: 251, DataType: radius.Text},
+		{Name: "Cisco-Command-Code", Type: 252, DataType: radius.String},
+	},
+}
@@ -0,0 +1,95 @@
+// Package dictionary bundles RADIUS dictionaries of the standard attributes
+// and of common vendors, so that attributes decode to names and typed values
+// without further setup, and lets further vendors be registered at runtime.
+package dictionary
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/tinybluerobots/radius-diameter-message/enterprise"
+	"github.com/tinybluerobots/radius-diameter-message/radius"
+	"github.com/tinybluerobots/radius-diameter-message/radius/rfc2865"
+	"github.com/tinybluerobots/radius-diameter-message/radius/rfc2866"
+	"github.com/tinybluerobots/radius-diameter-message/radius/rfc2869"
+	"github.com/tinybluerobots/radius-diameter-message/radius/rfc3162"
+)
+
+// Vendor describes the vendor-specific attributes of a vendor. The VendorId
+// of each attribute definition is set from Id when the vendor is registered.
+type Vendor struct {
+	Id         radius.VendorId
+	Name       string
+	Attributes []radius.AttributeDefinition
+}
+
+var (
+	mutex   sync.RWMutex
+	vendors = map[radius.VendorId]Vendor{}
+)
+
+func init() {
+	Register(ThreeGPP, Cisco, Mikrotik, WISPr)
+}
+
+// Register adds or replaces the dictionaries of vendors, and registers their
+// names as enterprise names.
+func Register(registered ...Vendor) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	for _, vendor := range registered {
+		attributes := make([]radius.AttributeDefinition, len(vendor.Attributes))
+		for i, definition := range vendor.Attributes {
+			definition.VendorId = vendor.Id
+			attributes[i] = definition
+		}
+		vendor.Attributes = attributes
+		vendors[vendor.Id] = vendor
+		enterprise.Register(uint32(vendor.Id), vendor.Name)
+	}
+}
+
+// Unregister removes the dictionary of a vendor.
+func Unregister(vendorId radius.VendorId) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	delete(vendors, vendorId)
+}
+
+// Lookup retrieves the registered dictionary of a vendor.
+func Lookup(vendorId radius.VendorId) *Vendor {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	vendor, ok := vendors[vendorId]
+	if !ok {
+		return nil
+	}
+	return &vendor
+}
+
+// Standard creates a dictionary of the attributes of RFC 2865, 2866, 2869
+// and 3162.
+func Standard() *radius.Dictionary {
+	return radius.NewDictionary().
+		AddAttribute(rfc2865.Attributes...).
+		AddAttribute(rfc2866.Attributes...).
+		AddAttribute(rfc2869.Attributes...).
+		AddAttribute(rfc3162.Attributes...)
+}
+
+// Default creates a dictionary of the standard attributes and those of every
+// registered vendor.
+func Default() *radius.Dictionary {
+	dictionary := Standard()
+	mutex.RLock()
+	defer mutex.RUnlock()
+	ids := make([]radius.VendorId, 0, len(vendors))
+	for id := range vendors {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	for _, id := range ids {
+		dictionary.AddAttribute(vendors[id].Attributes...)
+	}
+	return dictionary
+}
@@ -0,0 +1,46 @@
+package dictionary
+
+import "github.com/tinybluerobots/radius-diameter-message/radius"
+
+// Mikrotik describes the vendor-specific attributes of MikroTik RouterOS.
+var Mikrotik = Vendor{
+	Id:   14988,
+	Name: "Mikrotik",
+	Attributes: []radius.AttributeDefinition{
+		{Name: "Mikrotik-Recv-Limit", Type: 1, DataType: radius.Integer},
+		{Name: "Mikrotik-Xmit-Limit", Type: 2, DataType: radius.Integer},
+		{Name: "Mikrotik-Group", Type: 3, DataType: radius.Text},
+		{Name: "Mikrotik-Wireless-Forward", Type: 4, DataType: radius.Integer},
+		{Name: "Mikrotik-Wireless-Skip-Dot1x", Type: 5, DataType: radius.Integer},
+		{Name: "Mikrotik-Wireless-Enc-Algo", Type: 6, DataType: radius.Enum, Values: map[uint32]string{
+			0: "No-Encryption",
+			1: "40-bit-WEP",
+			2: "104-bit-WEP",
+			3: "AES-CCM",
+			4: "TKIP",
+		}},
+		{Name: "Mikrotik-Wireless-Enc-Key", Type: 7, DataType: radius.Text},
+		{Name: "Mikrotik-Rate-Limit", Type: 8, DataType: radius.Text},
+		{Name: "Mikrotik-Realm", Type: 9, DataType: radius.Text},
+		{Name: "Mikrotik-Host-IP", Type: 10, DataType: radius.IPv4Addr},
+		{Name: "Mikrotik-Mark-Id", Type: 11, DataType: radius.Text},
+		{Name: "Mikrotik-Advertise-URL", Type: 12, DataType: radius.Text},
+		{Name: "Mikrotik-Advertise-Interval", Type: 13, DataType: radius.Integer},
+		{Name: "Mikrotik-Recv-Limit-Gigawords", Type: 14, DataType: radius.Integer},
+		{Name: "Mikrotik-Xmit-Limit-Gigawords", Type: 15, DataType: radius.Integer},
+		{Name: "Mikrotik-Wireless-PSK", Type: 16, DataType: radius.Text},
+		{Name: "Mikrotik-Total-Limit", Type: 17, DataType: radius.Integer},
+		{Name: "Mikrotik-Total-Limit-Gigawords", Type: 18, DataType: radius.Integer},
+		{Name: "Mikrotik-Address-List", Type: 19, DataType: radius.Text},
+		{Name: "Mikrotik-Wireless-MPKey", Type: 20, DataType: radius.Text},
+		{Name: "Mikrotik-Wireless-Comment", Type: 21, DataType: radius.Text},
+		{Name: "Mikrotik-Delegated-IPv6-Pool", Type: 22, DataType: radius.Text},
+		{Name: "Mikrotik-DHCP-Option-Set", Type: 23, DataType: radius.Text},
+		{Name: "Mikrotik-DHCP-Option-Param-STR1", Type: 24, DataType: radius.Text},
+		{Name: "Mikrotik-DHCP-Option-Param-STR2", Type: 25, DataType: radius.Text},
+		{Name: "Mikrotik-Wireless-VLANID", Type: 26, DataType: radius.Integer},
+		{Name: "Mikrotik-Wireless-VLANID-Type", Type: 27, DataType: radius.Integer},
+		{Name: "Mikrotik-Wireless-Minsignal", Type: 28, DataType: radius.Text},
+		{Name: "Mikrotik-Wireless-Maxsignal", Type: 29, DataType: radius.Text},
+	},
+}
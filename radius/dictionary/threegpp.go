@@ -0,0 +1,46 @@
+package dictionary
+
+import "github.com/tinybluerobots/radius-diameter-message/radius"
+
+// ThreeGPP describes the 3GPP vendor-specific attributes of TS 29.061.
+var ThreeGPP = Vendor{
+	Id:   radius.Vendor3GPP,
+	Name: "3GPP",
+	Attributes: []radius.AttributeDefinition{
+		{Name: "3GPP-IMSI", Type: 1, DataType: radius.Text},
+		{Name: "3GPP-Charging-ID", Type: 2, DataType: radius.Integer},
+		{Name: "3GPP-PDP-Type", Type: 3, DataType: radius.Enum, Values: map[uint32]string{
+			0: "IPv4",
+			1: "PPP",
+			2: "IPv6",
+			3: "IPv4v6",
+			4: "Non-IP",
+			5: "Unstructured",
+			6: "Ethernet",
+		}},
+		{Name: "3GPP-CG-Address", Type: 4, DataType: radius.IPv4Addr},
+		{Name: "3GPP-GPRS-Negotiated-QoS-Profile", Type: 5, DataType: radius.Text},
+		{Name: "3GPP-SGSN-Address", Type: 6, DataType: radius.IPv4Addr},
+		{Name: "3GPP-GGSN-Address", Type: 7, DataType: radius.IPv4Addr},
+		{Name: "3GPP-IMSI-MCC-MNC", Type: 8, DataType: radius.Text},
+		{Name: "3GPP-GGSN-MCC-MNC", Type: 9, DataType: radius.Text},
+		{Name: "3GPP-NSAPI", Type: 10, DataType: radius.Text},
+		{Name: "3GPP-Session-Stop-Indicator", Type: 11, DataType: radius.String},
+		{Name: "3GPP-Selection-Mode", Type: 12, DataType: radius.Text},
+		{Name: "3GPP-Charging-Characteristics", Type: 13, DataType: radius.Text},
+		{Name: "3GPP-CG-IPv6-Address", Type: 14, DataType: radius.IPv6Addr},
+		{Name: "3GPP-SGSN-IPv6-Address", Type: 15, DataType: radius.IPv6Addr},
+		{Name: "3GPP-GGSN-IPv6-Address", Type: 16, DataType: radius.IPv6Addr},
+		{Name: "3GPP-IPv6-DNS-Servers", Type: 17, DataType: radius.String},
+		{Name: "3GPP-SGSN-MCC-MNC", Type: 18, DataType: radius.Text},
+		{Name: "3GPP-Teardown-Indicator", Type: 19, DataType: radius.String},
+		{Name: "3GPP-IMEISV", Type: 20, DataType: radius.Text},
+		{Name: "3GPP-RAT-Type", Type: 21, DataType: radius.String},
+		{Name: "3GPP-User-Location-Info", Type: 22, DataType: radius.String},
+		{Name: "3GPP-MS-TimeZone", Type: 23, DataType: radius.String},
+		{Name: "3GPP-Camel-Charging-Info", Type: 24, DataType: radius.String},
+		{Name: "3GPP-Packet-Filter", Type: 25, DataType: radius.String},
+		{Name: "3GPP-Negotiated-DSCP", Type: 26, DataType: radius.String},
+		{Name: "3GPP-Allocate-IP-Type", Type: 27, DataType: radius.String},
+	},
+}
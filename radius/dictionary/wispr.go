@@ -0,0 +1,22 @@
+package dictionary
+
+import "github.com/tinybluerobots/radius-diameter-message/radius"
+
+// WISPr describes the vendor-specific attributes of the Wi-Fi Alliance WISPr recommendation.
+var WISPr = Vendor{
+	Id:   14122,
+	Name: "WISPr",
+	Attributes: []radius.AttributeDefinition{
+		{Name: "WISPr-Location-ID", Type: 1, DataType: radius.Text},
+		{Name: "WISPr-Location-Name", Type: 2, DataType: radius.Text},
+		{Name: "WISPr-Logoff-URL", Type: 3, DataType: radius.Text},
+		{Name: "WISPr-Redirection-URL", Type: 4, DataType: radius.Text},
+		{Name: "WISPr-Bandwidth-Min-Up", Type: 5, DataType: radius.Integer},
+		{Name: "WISPr-Bandwidth-Min-Down", Type: 6, DataType: radius.Integer},
+		{Name: "WISPr-Bandwidth-Max-Up", Type: 7, DataType: radius.Integer},
+		{Name: "WISPr-Bandwidth-Max-Down", Type: 8, DataType: radius.Integer},
+		{Name: "WISPr-Session-Terminate-Time", Type: 9, DataType: radius.Text},
+		{Name: "WISPr-Session-Terminate-End-Of-Day", Type: 10, DataType: radius.Text},
+		{Name: "WISPr-Billing-Class-Of-Service", Type: 11, DataType: radius.Text},
+	},
+}
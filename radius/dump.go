@@ -0,0 +1,194 @@
+package radius
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tinybluerobots/radius-diameter-message/enterprise"
+)
+
+// Dump returns the bytes of a RADIUS message as hex, each field on its own
+// lines annotated with its name and decoded value, like the bytes pane of
+// Wireshark. The dictionary, which may be nil, names and decodes attributes.
+// The bytes need not be a valid message: dumping stops at the first
+// malformed field, which is annotated with the problem.
+func Dump(bytes []byte, dictionary *Dictionary) string {
+	d := &dumper{bytes: bytes}
+	if len(bytes) < 20 {
+		d.field(len(bytes), "Malformed: truncated header")
+		return d.String()
+	}
+	code := Code(bytes[0])
+	length := int(binary.BigEndian.Uint16(bytes[2:4]))
+	d.field(1, fmt.Sprintf("Code: %d (%s)", code, code))
+	d.field(1, fmt.Sprintf("Identifier: %d", bytes[1]))
+	d.field(2, fmt.Sprintf("Length: %d", length))
+	d.field(16, "Authenticator")
+	end := len(bytes)
+	if length < 20 || length > len(bytes) {
+		d.note(fmt.Sprintf("Malformed: length %d does not match %d bytes", length, len(bytes)))
+	} else {
+		end = length
+	}
+	d.attributes(dictionary, end)
+	if d.offset < len(bytes) {
+		d.field(len(bytes)-d.offset, "Padding")
+	}
+	return d.String()
+}
+
+// dumper writes annotated hex lines for consecutive fields of a message.
+type dumper struct {
+	bytes   []byte
+	offset  int
+	builder strings.Builder
+}
+
+// field writes the next length bytes, up to 16 a line, annotating the first
+// line.
+func (d *dumper) field(length int, annotation string) {
+	length = min(length, len(d.bytes)-d.offset)
+	for i := 0; i < length || i == 0; i += 16 {
+		chunk := d.bytes[d.offset+i : d.offset+min(i+16, length)]
+		hex := fmt.Sprintf("% x", chunk)
+		if i > 0 {
+			annotation = ""
+		}
+		fmt.Fprintf(&d.builder, "%04x  %-47s  %s\n", d.offset+i, hex, annotation)
+	}
+	d.offset += length
+}
+
+// note writes an annotation without bytes.
+func (d *dumper) note(annotation string) {
+	fmt.Fprintf(&d.builder, "%4s  %-47s  %s\n", "", "", annotation)
+}
+
+// String returns the lines written.
+func (d *dumper) String() string {
+	return d.builder.String()
+}
+
+// attributes writes the attributes up to the end offset, including the
+// sub-attributes of Vendor-Specific attributes and the headers of extended
+// attributes.
+func (d *dumper) attributes(dictionary *Dictionary, end int) {
+	for d.offset < end {
+		if end-d.offset < 2 {
+			d.field(end-d.offset, "Malformed: truncated attribute header")
+			return
+		}
+		attributeType := AttributeType(d.bytes[d.offset])
+		length := int(d.bytes[d.offset+1])
+		d.field(1, fmt.Sprintf("Attribute Type: %d (%s)", attributeType, attributeName(dictionary, attributeType, 0)))
+		if length < 2 || d.offset-1+length > end {
+			d.field(1, fmt.Sprintf("Malformed: attribute length %d", length))
+			d.field(end-d.offset, "Remaining bytes")
+			return
+		}
+		d.field(1, fmt.Sprintf("Attribute Length: %d", length))
+		data := d.bytes[d.offset : d.offset+length-2]
+		switch {
+		case attributeType == 26 && len(data) >= 6:
+			if _, ok := vendorAttributes(data); ok {
+				vendorId := VendorId(binary.BigEndian.Uint32(data[0:4]))
+				d.field(4, fmt.Sprintf("  Vendor-Id: %d (%s)", vendorId, vendorLabel(vendorId)))
+				d.subAttributes(dictionary, vendorId, d.offset+len(data)-4)
+				continue
+			}
+			d.field(len(data), "Value")
+		case isExtended(attributeType) && len(data) >= 1:
+			d.extended(dictionary, attributeType, data)
+		default:
+			d.value(dictionary, attributeType, 0, data, "")
+		}
+	}
+}
+
+// subAttributes writes the sub-attributes of a Vendor-Specific attribute up
+// to the end offset.
+func (d *dumper) subAttributes(dictionary *Dictionary, vendorId VendorId, end int) {
+	for d.offset < end {
+		vendorType := AttributeType(d.bytes[d.offset])
+		length := int(d.bytes[d.offset+1])
+		d.field(1, fmt.Sprintf("  Vendor Type: %d (%s)", vendorType, attributeName(dictionary, vendorType, vendorId)))
+		d.field(1, fmt.Sprintf("  Vendor Length: %d", length))
+		d.value(dictionary, vendorType, vendorId, d.bytes[d.offset:d.offset+length-2], "  ")
+	}
+}
+
+// extended writes the Extended-Type, flags and vendor of an RFC 6929
+// extended attribute, and its value.
+func (d *dumper) extended(dictionary *Dictionary, attributeType AttributeType, data []byte) {
+	extendedType := AttributeType(data[0])
+	d.field(1, fmt.Sprintf("  Extended-Type: %d", extendedType))
+	data = data[1:]
+	if isLongExtended(attributeType) && len(data) >= 1 {
+		d.field(1, fmt.Sprintf("  Flags: 0x%02x", data[0]))
+		data = data[1:]
+	}
+	if extendedType == ExtendedVendorSpecific && len(data) >= 5 {
+		vendorId := VendorId(binary.BigEndian.Uint32(data[0:4]))
+		d.field(4, fmt.Sprintf("  Vendor-Id: %d (%s)", vendorId, vendorLabel(vendorId)))
+		d.field(1, fmt.Sprintf("  Vendor Type: %d", data[4]))
+		data = data[5:]
+	}
+	if len(data) > 0 {
+		d.field(len(data), "  Value")
+	}
+}
+
+// value writes the value of an attribute, decoded if the dictionary knows
+// it.
+func (d *dumper) value(dictionary *Dictionary, attributeType AttributeType, vendorId VendorId, data []byte, indent string) {
+	avp := NewAvp(attributeType, vendorId, data)
+	if value, ok := formatValue(dictionary, &avp); ok {
+		d.field(len(data), fmt.Sprintf("%sValue: %s", indent, value))
+	} else if len(data) > 0 {
+		d.field(len(data), indent+"Value")
+	}
+}
+
+// attributeName returns the dictionary name of an attribute, or Unknown.
+func attributeName(dictionary *Dictionary, attributeType AttributeType, vendorId VendorId) string {
+	if definition := dictionary.Attribute(attributeType, vendorId); definition != nil {
+		return definition.Name
+	}
+	return "Unknown"
+}
+
+// vendorLabel returns the registered name of a vendor ID, or the number if it has none.
+func vendorLabel(vendorId VendorId) string {
+	if name := enterprise.Name(uint32(vendorId)); name != nil {
+		return *name
+	}
+	return strconv.FormatUint(uint64(vendorId), 10)
+}
+
+// formatValue formats the value of an attribute as the dictionary types it,
+// reporting false when it does not know the attribute or the value is
+// opaque or malformed.
+func formatValue(dictionary *Dictionary, avp *Avp) (string, bool) {
+	definition := dictionary.Attribute(avp.Type, avp.VendorId)
+	if definition == nil {
+		return "", false
+	}
+	switch value := avp.TypedValue(dictionary).(type) {
+	case string:
+		if definition.DataType == Enum {
+			return fmt.Sprintf("%s (%d)", value, binary.BigEndian.Uint32(avp.Data)), true
+		}
+		return strconv.Quote(value), true
+	case uint32, uint64:
+		return fmt.Sprint(value), true
+	case time.Time:
+		return value.UTC().Format("2006-01-02 15:04:05 UTC"), true
+	case net.IP, net.IPNet, InterfaceId:
+		return fmt.Sprint(value), true
+	}
+	return "", false
+}
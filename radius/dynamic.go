@@ -0,0 +1,253 @@
+package radius
+
+import (
+	"errors"
+	"net"
+)
+
+// Attributes of dynamic authorization messages.
+const (
+	AttributeChargeableUserIdentity AttributeType = 89
+	AttributeErrorCause             AttributeType = 101
+)
+
+// ErrorCause represents the value of an Error-Cause attribute.
+type ErrorCause uint32
+
+const (
+	ErrorCauseResidualSessionContextRemoved ErrorCause = 201
+	ErrorCauseInvalidEAPPacket              ErrorCause = 202
+	ErrorCauseUnsupportedAttribute          ErrorCause = 401
+	ErrorCauseMissingAttribute              ErrorCause = 402
+	ErrorCauseNASIdentificationMismatch     ErrorCause = 403
+	ErrorCauseInvalidRequest                ErrorCause = 404
+	ErrorCauseUnsupportedService            ErrorCause = 405
+	ErrorCauseUnsupportedExtension          ErrorCause = 406
+	ErrorCauseInvalidAttributeValue         ErrorCause = 407
+	ErrorCauseAdministrativelyProhibited    ErrorCause = 501
+	ErrorCauseRequestNotRoutable            ErrorCause = 502
+	ErrorCauseSessionContextNotFound        ErrorCause = 503
+	ErrorCauseSessionContextNotRemovable    ErrorCause = 504
+	ErrorCauseOtherProxyProcessingError     ErrorCause = 505
+	ErrorCauseResourcesUnavailable          ErrorCause = 506
+	ErrorCauseRequestInitiated              ErrorCause = 507
+	ErrorCauseMultipleSessionSelection      ErrorCause = 508
+)
+
+// String returns the RFC 5176 name of the cause.
+func (e ErrorCause) String() string {
+	switch e {
+	case ErrorCauseResidualSessionContextRemoved:
+		return "RESIDUAL_SESSION_CONTEXT_REMOVED"
+	case ErrorCauseInvalidEAPPacket:
+		return "INVALID_EAP_PACKET"
+	case ErrorCauseUnsupportedAttribute:
+		return "UNSUPPORTED_ATTRIBUTE"
+	case ErrorCauseMissingAttribute:
+		return "MISSING_ATTRIBUTE"
+	case ErrorCauseNASIdentificationMismatch:
+		return "NAS_IDENTIFICATION_MISMATCH"
+	case ErrorCauseInvalidRequest:
+		return "INVALID_REQUEST"
+	case ErrorCauseUnsupportedService:
+		return "UNSUPPORTED_SERVICE"
+	case ErrorCauseUnsupportedExtension:
+		return "UNSUPPORTED_EXTENSION"
+	case ErrorCauseInvalidAttributeValue:
+		return "INVALID_ATTRIBUTE_VALUE"
+	case ErrorCauseAdministrativelyProhibited:
+		return "ADMINISTRATIVELY_PROHIBITED"
+	case ErrorCauseRequestNotRoutable:
+		return "REQUEST_NOT_ROUTABLE"
+	case ErrorCauseSessionContextNotFound:
+		return "SESSION_CONTEXT_NOT_FOUND"
+	case ErrorCauseSessionContextNotRemovable:
+		return "SESSION_CONTEXT_NOT_REMOVABLE"
+	case ErrorCauseOtherProxyProcessingError:
+		return "OTHER_PROXY_PROCESSING_ERROR"
+	case ErrorCauseResourcesUnavailable:
+		return "RESOURCES_UNAVAILABLE"
+	case ErrorCauseRequestInitiated:
+		return "REQUEST_INITIATED"
+	case ErrorCauseMultipleSessionSelection:
+		return "MULTIPLE_SESSION_SELECTION_UNSUPPORTED"
+	}
+	return "UNKNOWN"
+}
+
+// SessionIdentification holds the attributes RFC 5176 uses to identify the
+// NAS and the session a dynamic authorization request applies to. Zero
+// fields are absent.
+type SessionIdentification struct {
+	NASIPAddress           net.IP
+	NASIPv6Address         net.IP
+	NASIdentifier          string
+	UserName               string
+	NASPort                uint32
+	NASPortId              string
+	FramedIPAddress        net.IP
+	CalledStationId        string
+	CallingStationId       string
+	AcctSessionId          string
+	AcctMultiSessionId     string
+	ChargeableUserIdentity string
+}
+
+// sessionIdentificationTypes are the attribute types SessionIdentification has fields for.
+var sessionIdentificationTypes = []AttributeType{
+	AttributeNASIPAddress, AttributeNASIPv6Address, AttributeNASIdentifier, AttributeUserName,
+	AttributeNASPort, AttributeNASPortId, AttributeFramedIPAddress, AttributeCalledStationId,
+	AttributeCallingStationId, AttributeAcctSessionId, AttributeAcctMultiSessionId,
+	AttributeChargeableUserIdentity,
+}
+
+// ToAvps converts the session identification to attributes.
+func (s SessionIdentification) ToAvps() Avps {
+	avps := NewAvps()
+	if s.NASIPAddress != nil {
+		avps = avps.AddIPv4(AttributeNASIPAddress, 0, s.NASIPAddress)
+	}
+	if s.NASIPv6Address != nil {
+		avps = avps.AddIPv6(AttributeNASIPv6Address, 0, s.NASIPv6Address)
+	}
+	if s.NASIdentifier != "" {
+		avps = avps.AddString(AttributeNASIdentifier, 0, s.NASIdentifier)
+	}
+	if s.UserName != "" {
+		avps = avps.AddString(AttributeUserName, 0, s.UserName)
+	}
+	if s.NASPort != 0 {
+		avps = avps.AddUint32(AttributeNASPort, 0, s.NASPort)
+	}
+	if s.NASPortId != "" {
+		avps = avps.AddString(AttributeNASPortId, 0, s.NASPortId)
+	}
+	if s.FramedIPAddress != nil {
+		avps = avps.AddIPv4(AttributeFramedIPAddress, 0, s.FramedIPAddress)
+	}
+	if s.CalledStationId != "" {
+		avps = avps.AddString(AttributeCalledStationId, 0, s.CalledStationId)
+	}
+	if s.CallingStationId != "" {
+		avps = avps.AddString(AttributeCallingStationId, 0, s.CallingStationId)
+	}
+	if s.AcctSessionId != "" {
+		avps = avps.AddString(AttributeAcctSessionId, 0, s.AcctSessionId)
+	}
+	if s.AcctMultiSessionId != "" {
+		avps = avps.AddString(AttributeAcctMultiSessionId, 0, s.AcctMultiSessionId)
+	}
+	if s.ChargeableUserIdentity != "" {
+		avps = avps.AddString(AttributeChargeableUserIdentity, 0, s.ChargeableUserIdentity)
+	}
+	return avps
+}
+
+// FromAvps reads the session identification from attributes.
+func (s *SessionIdentification) FromAvps(avps Avps) {
+	s.NASIPAddress = avps.GetFirst(AttributeNASIPAddress, 0).ToIPv4OrDefault()
+	s.NASIPv6Address = avps.GetFirst(AttributeNASIPv6Address, 0).ToIPv6OrDefault()
+	s.NASIdentifier = avps.GetFirst(AttributeNASIdentifier, 0).ToStringOrDefault()
+	s.UserName = avps.GetFirst(AttributeUserName, 0).ToStringOrDefault()
+	s.NASPort = avps.GetFirst(AttributeNASPort, 0).ToUint32OrDefault()
+	s.NASPortId = avps.GetFirst(AttributeNASPortId, 0).ToStringOrDefault()
+	s.FramedIPAddress = avps.GetFirst(AttributeFramedIPAddress, 0).ToIPv4OrDefault()
+	s.CalledStationId = avps.GetFirst(AttributeCalledStationId, 0).ToStringOrDefault()
+	s.CallingStationId = avps.GetFirst(AttributeCallingStationId, 0).ToStringOrDefault()
+	s.AcctSessionId = avps.GetFirst(AttributeAcctSessionId, 0).ToStringOrDefault()
+	s.AcctMultiSessionId = avps.GetFirst(AttributeAcctMultiSessionId, 0).ToStringOrDefault()
+	s.ChargeableUserIdentity = avps.GetFirst(AttributeChargeableUserIdentity, 0).ToStringOrDefault()
+}
+
+// DisconnectRequest represents a Disconnect-Request. Attributes without a
+// field are carried in Avps and appended after the others.
+type DisconnectRequest struct {
+	Identifier byte
+	Session    SessionIdentification
+	Avps       Avps
+}
+
+// Build converts the request to a RADIUS message with its Request
+// Authenticator computed from the shared secret.
+func (d DisconnectRequest) Build(secret []byte) Message {
+	return buildDynamicRequest(CodeDisconnectRequest, d.Identifier, d.Session, d.Avps, secret)
+}
+
+// FromMessage reads a Disconnect-Request from a RADIUS message.
+func (d *DisconnectRequest) FromMessage(message Message) error {
+	if message.Code != CodeDisconnectRequest {
+		return errors.New("message is not a Disconnect-Request")
+	}
+	d.Identifier = message.Identifier
+	d.Session.FromAvps(message.Avps)
+	d.Avps = message.Avps.Without(0, sessionIdentificationTypes...)
+	return nil
+}
+
+// CoARequest represents a CoA-Request. The authorization changes, and any
+// other attributes without a field, are carried in Avps and appended after
+// the others.
+type CoARequest struct {
+	Identifier byte
+	Session    SessionIdentification
+	Avps       Avps
+}
+
+// Build converts the request to a RADIUS message with its Request
+// Authenticator computed from the shared secret.
+func (c CoARequest) Build(secret []byte) Message {
+	return buildDynamicRequest(CodeCoARequest, c.Identifier, c.Session, c.Avps, secret)
+}
+
+// FromMessage reads a CoA-Request from a RADIUS message.
+func (c *CoARequest) FromMessage(message Message) error {
+	if message.Code != CodeCoARequest {
+		return errors.New("message is not a CoA-Request")
+	}
+	c.Identifier = message.Identifier
+	c.Session.FromAvps(message.Avps)
+	c.Avps = message.Avps.Without(0, sessionIdentificationTypes...)
+	return nil
+}
+
+// buildDynamicRequest builds a dynamic authorization request, whose Request
+// Authenticator is computed as for an Accounting-Request.
+func buildDynamicRequest(code Code, identifier byte, session SessionIdentification, others Avps, secret []byte) Message {
+	avps := append(session.ToAvps(), others...)
+	message := NewMessage(code, identifier, [16]byte{}, avps...)
+	if message.Avps.GetFirst(AttributeMessageAuthenticator, 0) != nil {
+		message.AddMessageAuthenticator(secret)
+	}
+	message.SetAccountingAuthenticator(secret)
+	return message
+}
+
+// VerifyDynamicRequest reports whether the Request Authenticator of a
+// Disconnect-Request or CoA-Request is correct, along with its
+// Message-Authenticator if it has one.
+func (m Message) VerifyDynamicRequest(secret []byte) bool {
+	return m.VerifyAccountingAuthenticator(secret) && m.VerifyMessageAuthenticator(secret, false) == nil
+}
+
+// NewDynamicACK creates the ACK answering a Disconnect-Request or
+// CoA-Request, with its Response Authenticator set.
+func NewDynamicACK(request Message, secret []byte, avps ...Avp) Message {
+	response := NewMessage(request.Code+1, request.Identifier, [16]byte{}, avps...)
+	response.SetResponseAuthenticator(request.Authenticator, secret)
+	return response
+}
+
+// NewDynamicNAK creates the NAK answering a Disconnect-Request or
+// CoA-Request with the Error-Cause, with its Response Authenticator set.
+func NewDynamicNAK(request Message, cause ErrorCause, secret []byte, avps ...Avp) Message {
+	avps = append(Avps{NewAvpUint32(AttributeErrorCause, 0, uint32(cause))}, avps...)
+	response := NewMessage(request.Code+2, request.Identifier, [16]byte{}, avps...)
+	response.SetResponseAuthenticator(request.Authenticator, secret)
+	return response
+}
+
+// ErrorCause returns the Error-Cause of the message, if it has one.
+func (m Message) ErrorCause() (ErrorCause, bool) {
+	value, ok := m.Avps.GetFirst(AttributeErrorCause, 0).ToUint32Ok()
+	return ErrorCause(value), ok
+}
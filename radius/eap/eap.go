@@ -0,0 +1,195 @@
+package eap
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/tinybluerobots/radius-diameter-message/radius"
+)
+
+// ErrInvalidPacket is returned when bytes are not a well formed EAP packet.
+var ErrInvalidPacket = errors.New("invalid EAP packet")
+
+// Code represents the code of an EAP packet.
+type Code byte
+
+const (
+	CodeRequest  Code = 1
+	CodeResponse Code = 2
+	CodeSuccess  Code = 3
+	CodeFailure  Code = 4
+)
+
+// String returns the RFC 3748 name of the code.
+func (c Code) String() string {
+	switch c {
+	case CodeRequest:
+		return "REQUEST"
+	case CodeResponse:
+		return "RESPONSE"
+	case CodeSuccess:
+		return "SUCCESS"
+	case CodeFailure:
+		return "FAILURE"
+	}
+	return "UNKNOWN"
+}
+
+// Type represents the method type of an EAP Request or Response.
+type Type byte
+
+const (
+	TypeIdentity     Type = 1
+	TypeNotification Type = 2
+	TypeNak          Type = 3
+	TypeMD5Challenge Type = 4
+	TypeTLS          Type = 13
+	TypeTTLS         Type = 21
+	TypePEAP         Type = 25
+	TypeMSCHAPv2     Type = 26
+)
+
+// String returns the name of the type.
+func (t Type) String() string {
+	switch t {
+	case TypeIdentity:
+		return "IDENTITY"
+	case TypeNotification:
+		return "NOTIFICATION"
+	case TypeNak:
+		return "NAK"
+	case TypeMD5Challenge:
+		return "MD5_CHALLENGE"
+	case TypeTLS:
+		return "TLS"
+	case TypeTTLS:
+		return "TTLS"
+	case TypePEAP:
+		return "PEAP"
+	case TypeMSCHAPv2:
+		return "MSCHAPV2"
+	}
+	return "UNKNOWN"
+}
+
+// Packet represents an EAP packet. Type and Data are only present in
+// Requests and Responses.
+type Packet struct {
+	Code       Code
+	Identifier byte
+	Type       Type
+	Data       []byte
+}
+
+// hasType reports whether packets with the code carry a type.
+func (c Code) hasType() bool {
+	return c == CodeRequest || c == CodeResponse
+}
+
+// Read reads an EAP packet from bytes, ignoring any beyond its Length field.
+func Read(bytes []byte) (Packet, error) {
+	if len(bytes) < 4 {
+		return Packet{}, ErrInvalidPacket
+	}
+	length := int(binary.BigEndian.Uint16(bytes[2:4]))
+	if length < 4 || length > len(bytes) {
+		return Packet{}, fmt.Errorf("%w: length %d", ErrInvalidPacket, length)
+	}
+	packet := Packet{Code: Code(bytes[0]), Identifier: bytes[1]}
+	if packet.Code.hasType() {
+		if length < 5 {
+			return Packet{}, fmt.Errorf("%w: missing type", ErrInvalidPacket)
+		}
+		packet.Type = Type(bytes[4])
+		packet.Data = bytes[5:length]
+	}
+	return packet, nil
+}
+
+// ToBytes converts the EAP packet to bytes.
+func (p Packet) ToBytes() []byte {
+	length := 4
+	if p.Code.hasType() {
+		length += 1 + len(p.Data)
+	}
+	bytes := make([]byte, 4, length)
+	bytes[0] = byte(p.Code)
+	bytes[1] = p.Identifier
+	binary.BigEndian.PutUint16(bytes[2:4], uint16(length))
+	if p.Code.hasType() {
+		bytes = append(bytes, byte(p.Type))
+		bytes = append(bytes, p.Data...)
+	}
+	return bytes
+}
+
+// FromMessage reads the EAP packet carried in the EAP-Message attributes of
+// a RADIUS message.
+func FromMessage(message radius.Message) (Packet, error) {
+	bytes := message.EAPMessage()
+	if bytes == nil {
+		return Packet{}, errors.New("message has no EAP-Message")
+	}
+	return Read(bytes)
+}
+
+// AddTo sets the packet as the EAP-Message of the RADIUS message.
+func (p Packet) AddTo(message *radius.Message) {
+	message.SetEAPMessage(p.ToBytes())
+}
+
+// NewIdentity creates an Identity packet. A Request may carry a displayable
+// message; a Response carries the peer's identity.
+func NewIdentity(code Code, identifier byte, identity string) Packet {
+	return Packet{Code: code, Identifier: identifier, Type: TypeIdentity, Data: []byte(identity)}
+}
+
+// Identity returns the identity of an Identity packet.
+func (p Packet) Identity() (string, bool) {
+	if p.Type != TypeIdentity || !p.Code.hasType() {
+		return "", false
+	}
+	return string(p.Data), true
+}
+
+// NewNak creates a Nak Response proposing the desired authentication types.
+func NewNak(identifier byte, desired ...Type) Packet {
+	data := make([]byte, len(desired))
+	for i, t := range desired {
+		data[i] = byte(t)
+	}
+	return Packet{Code: CodeResponse, Identifier: identifier, Type: TypeNak, Data: data}
+}
+
+// MD5Challenge represents the data of an MD5-Challenge packet.
+type MD5Challenge struct {
+	Value []byte
+	Name  string
+}
+
+// ToPacket converts the MD5-Challenge to an EAP packet.
+func (c MD5Challenge) ToPacket(code Code, identifier byte) Packet {
+	data := append([]byte{byte(len(c.Value))}, c.Value...)
+	data = append(data, c.Name...)
+	return Packet{Code: code, Identifier: identifier, Type: TypeMD5Challenge, Data: data}
+}
+
+// FromPacket reads an MD5-Challenge from an EAP packet.
+func (c *MD5Challenge) FromPacket(packet Packet) error {
+	if packet.Type != TypeMD5Challenge || len(packet.Data) < 1 || int(packet.Data[0]) > len(packet.Data)-1 {
+		return fmt.Errorf("%w: not an MD5-Challenge", ErrInvalidPacket)
+	}
+	size := int(packet.Data[0])
+	c.Value = packet.Data[1 : 1+size]
+	c.Name = string(packet.Data[1+size:])
+	return nil
+}
+
+// MD5ChallengeResponse computes the value of the Response to an
+// MD5-Challenge Request with the identifier: the MD5 hash of the
+// identifier, the password and the challenge value.
+func MD5ChallengeResponse(identifier byte, password []byte, challenge []byte) []byte {
+	response := radius.CHAPResponse(identifier, password, challenge)
+	return response[:]
+}
@@ -0,0 +1,79 @@
+package eap
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// OpCode represents the operation of an EAP-MS-CHAPv2 packet.
+type OpCode byte
+
+const (
+	OpCodeChallenge      OpCode = 1
+	OpCodeResponse       OpCode = 2
+	OpCodeSuccess        OpCode = 3
+	OpCodeFailure        OpCode = 4
+	OpCodeChangePassword OpCode = 7
+)
+
+// String returns the name of the operation.
+func (o OpCode) String() string {
+	switch o {
+	case OpCodeChallenge:
+		return "CHALLENGE"
+	case OpCodeResponse:
+		return "RESPONSE"
+	case OpCodeSuccess:
+		return "SUCCESS"
+	case OpCodeFailure:
+		return "FAILURE"
+	case OpCodeChangePassword:
+		return "CHANGE_PASSWORD"
+	}
+	return "UNKNOWN"
+}
+
+// MSCHAPv2 represents the envelope of an EAP-MS-CHAPv2 packet: its
+// operation, MS-CHAPv2 identifier and the MS-CHAPv2 data, which this package
+// does not interpret. The Success and Failure acknowledgements sent by the
+// peer carry only the operation, and are represented with Short set.
+type MSCHAPv2 struct {
+	OpCode OpCode
+	Id     byte
+	Data   []byte
+	Short  bool
+}
+
+// ToPacket converts the envelope to an EAP packet.
+func (m MSCHAPv2) ToPacket(code Code, identifier byte) Packet {
+	if m.Short {
+		return Packet{Code: code, Identifier: identifier, Type: TypeMSCHAPv2, Data: []byte{byte(m.OpCode)}}
+	}
+	data := make([]byte, 4, 4+len(m.Data))
+	data[0] = byte(m.OpCode)
+	data[1] = m.Id
+	binary.BigEndian.PutUint16(data[2:4], uint16(4+len(m.Data)))
+	return Packet{Code: code, Identifier: identifier, Type: TypeMSCHAPv2, Data: append(data, m.Data...)}
+}
+
+// FromPacket reads the envelope from an EAP packet.
+func (m *MSCHAPv2) FromPacket(packet Packet) error {
+	if packet.Type != TypeMSCHAPv2 || len(packet.Data) < 1 {
+		return fmt.Errorf("%w: not an EAP-MS-CHAPv2 packet", ErrInvalidPacket)
+	}
+	*m = MSCHAPv2{OpCode: OpCode(packet.Data[0])}
+	if len(packet.Data) == 1 {
+		m.Short = true
+		return nil
+	}
+	if len(packet.Data) < 4 {
+		return fmt.Errorf("%w: truncated EAP-MS-CHAPv2 header", ErrInvalidPacket)
+	}
+	length := int(binary.BigEndian.Uint16(packet.Data[2:4]))
+	if length < 4 || length > len(packet.Data) {
+		return fmt.Errorf("%w: MS-Length %d", ErrInvalidPacket, length)
+	}
+	m.Id = packet.Data[1]
+	m.Data = packet.Data[4:length]
+	return nil
+}
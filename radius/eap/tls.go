@@ -0,0 +1,97 @@
+package eap
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// TLSFlags are the flags of an EAP-TLS, EAP-TTLS or PEAP packet.
+type TLSFlags byte
+
+const (
+	// TLSLengthIncluded means the packet carries the total TLS message length.
+	TLSLengthIncluded TLSFlags = 0x80
+	// TLSMoreFragments means more fragments of the TLS message follow.
+	TLSMoreFragments TLSFlags = 0x40
+	// TLSStart marks the Request that starts the method.
+	TLSStart TLSFlags = 0x20
+)
+
+// Has reports whether the flag is set.
+func (f TLSFlags) Has(flag TLSFlags) bool {
+	return f&flag == flag
+}
+
+// TLS represents the data of an EAP-TLS packet, which EAP-TTLS and PEAP
+// share apart from the version bits in the low bits of the flags. Length is
+// the total length of the TLS message, present with TLSLengthIncluded.
+type TLS struct {
+	Flags  TLSFlags
+	Length uint32
+	Data   []byte
+}
+
+// ToPacket converts the TLS data to an EAP packet of the method type.
+func (t TLS) ToPacket(code Code, identifier byte, methodType Type) Packet {
+	data := []byte{byte(t.Flags)}
+	if t.Flags.Has(TLSLengthIncluded) {
+		data = binary.BigEndian.AppendUint32(data, t.Length)
+	}
+	return Packet{Code: code, Identifier: identifier, Type: methodType, Data: append(data, t.Data...)}
+}
+
+// FromPacket reads the TLS data from an EAP packet.
+func (t *TLS) FromPacket(packet Packet) error {
+	if len(packet.Data) < 1 {
+		return fmt.Errorf("%w: missing TLS flags", ErrInvalidPacket)
+	}
+	*t = TLS{Flags: TLSFlags(packet.Data[0]), Data: packet.Data[1:]}
+	if t.Flags.Has(TLSLengthIncluded) {
+		if len(t.Data) < 4 {
+			return fmt.Errorf("%w: missing TLS message length", ErrInvalidPacket)
+		}
+		t.Length = binary.BigEndian.Uint32(t.Data[0:4])
+		t.Data = t.Data[4:]
+	}
+	return nil
+}
+
+// FragmentTLS splits a TLS message into fragments of at most size bytes.
+// The first carries the total length and every fragment but the last has
+// TLSMoreFragments set. A message that fits in one fragment is not split
+// and carries no length.
+func FragmentTLS(message []byte, size int) []TLS {
+	if len(message) <= size {
+		return []TLS{{Data: message}}
+	}
+	fragments := make([]TLS, 0, (len(message)+size-1)/size)
+	for offset := 0; offset < len(message); offset += size {
+		fragment := TLS{Data: message[offset:min(offset+size, len(message))]}
+		if offset == 0 {
+			fragment.Flags |= TLSLengthIncluded
+			fragment.Length = uint32(len(message))
+		}
+		if offset+size < len(message) {
+			fragment.Flags |= TLSMoreFragments
+		}
+		fragments = append(fragments, fragment)
+	}
+	return fragments
+}
+
+// JoinTLS reassembles a TLS message from its fragments, checking that only
+// the last lacks TLSMoreFragments and that the message has the length given
+// in the first.
+func JoinTLS(fragments ...TLS) ([]byte, error) {
+	var message []byte
+	for i, fragment := range fragments {
+		if fragment.Flags.Has(TLSMoreFragments) == (i == len(fragments)-1) {
+			return nil, fmt.Errorf("%w: fragment %d has the wrong more fragments flag", ErrInvalidPacket, i)
+		}
+		message = append(message, fragment.Data...)
+	}
+	if len(fragments) > 0 && fragments[0].Flags.Has(TLSLengthIncluded) && int(fragments[0].Length) != len(message) {
+		return nil, fmt.Errorf("%w: TLS message length %d, got %d", ErrInvalidPacket, fragments[0].Length, len(message))
+	}
+	return message, nil
+}
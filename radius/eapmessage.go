@@ -0,0 +1,47 @@
+package radius
+
+import "crypto/md5"
+
+// AttributeEAPMessage is the type of the EAP-Message attribute.
+const AttributeEAPMessage AttributeType = 79
+
+// maxAttributeData is the most data a single standard attribute can carry.
+const maxAttributeData = 253
+
+// SetEAPMessage replaces the EAP-Message attributes of the message with the
+// EAP packet, split across as many consecutive attributes as it needs. As
+// RFC 3579 requires a Message-Authenticator alongside EAP-Message, one is
+// added as the first attribute if missing; it must be computed with
+// AddMessageAuthenticator or AddResponseMessageAuthenticator once the
+// message is complete.
+func (m *Message) SetEAPMessage(eap []byte) {
+	fragments := NewAvps()
+	for offset := 0; offset < len(eap); offset += maxAttributeData {
+		fragments = append(fragments, NewAvp(AttributeEAPMessage, 0, eap[offset:min(offset+maxAttributeData, len(eap))]))
+	}
+	avps := make(Avps, 0, len(m.Avps)+len(fragments)+1)
+	if m.Avps.GetFirst(AttributeMessageAuthenticator, 0) == nil {
+		avps = append(avps, NewAvp(AttributeMessageAuthenticator, 0, make(avpData, md5.Size)))
+	}
+	for _, avp := range m.Avps {
+		if !avp.is(AttributeEAPMessage, 0) {
+			avps = append(avps, avp)
+		} else if fragments != nil {
+			avps = append(avps, fragments...)
+			fragments = nil
+		}
+	}
+	m.Avps = append(avps, fragments...)
+}
+
+// EAPMessage returns the EAP packet carried by the message, joining its
+// EAP-Message attributes in order, or nil if it has none.
+func (m Message) EAPMessage() []byte {
+	var eap []byte
+	for _, avp := range m.Avps {
+		if avp.is(AttributeEAPMessage, 0) {
+			eap = append(eap, avp.Data...)
+		}
+	}
+	return eap
+}
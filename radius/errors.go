@@ -0,0 +1,56 @@
+package radius
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrTruncated is matched by errors reading a message or attribute whose
+	// bytes end before its header does, or before the last fragment of a Long
+	// Extended Type attribute.
+	ErrTruncated = errors.New("message truncated")
+	// ErrInvalidLength is matched by every *InvalidLengthError.
+	ErrInvalidLength = errors.New("invalid length")
+	// ErrBadAuthenticator is matched by errors verifying a Response
+	// Authenticator or a Message-Authenticator that does not match the
+	// shared secret.
+	ErrBadAuthenticator = errors.New("bad authenticator")
+)
+
+// InvalidLengthError describes the length field of a message or attribute
+// that is shorter than its header, longer than the bytes holding it or
+// longer than RFC 2865 allows.
+type InvalidLengthError struct {
+	// Offset is the offset of the message or attribute in the bytes read.
+	Offset int
+	// Declared is the value of the length field.
+	Declared int
+	// Available is the number of bytes from the offset to the end of those
+	// read.
+	Available int
+}
+
+// Error returns the length declared and the bytes available.
+func (e *InvalidLengthError) Error() string {
+	return fmt.Sprintf("invalid length %d at offset %d with %d bytes available", e.Declared, e.Offset, e.Available)
+}
+
+// Is reports whether the target is ErrInvalidLength.
+func (e *InvalidLengthError) Is(target error) bool {
+	return target == ErrInvalidLength
+}
+
+// authenticatorError is an authenticator that does not match, matching
+// ErrBadAuthenticator.
+type authenticatorError string
+
+// Error returns the authenticator that does not match.
+func (e authenticatorError) Error() string {
+	return string(e)
+}
+
+// Is reports whether the target is ErrBadAuthenticator.
+func (e authenticatorError) Is(target error) bool {
+	return target == ErrBadAuthenticator
+}
@@ -0,0 +1,202 @@
+package radius
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Extended attribute types of RFC 6929. The first four are Extended Type
+// attributes and the last two Long Extended Type attributes, whose values
+// may be split across several attributes.
+const (
+	ExtendedAttribute1     AttributeType = 241
+	ExtendedAttribute2     AttributeType = 242
+	ExtendedAttribute3     AttributeType = 243
+	ExtendedAttribute4     AttributeType = 244
+	LongExtendedAttribute1 AttributeType = 245
+	LongExtendedAttribute2 AttributeType = 246
+)
+
+// ExtendedVendorSpecific is the Extended-Type of an Extended-Vendor-Specific attribute.
+const ExtendedVendorSpecific AttributeType = 26
+
+// moreFlag is set on every fragment of a Long Extended Type attribute but the last.
+const moreFlag = 0x80
+
+// NewAvpExtended creates an RFC 6929 extended attribute of the attribute
+// type, from 241 to 246, with the Extended-Type. A value too long for one
+// Long Extended Type attribute is split across several when written.
+func NewAvpExtended(attributeType AttributeType, extendedType AttributeType, avpData avpData) Avp {
+	return Avp{Type: extendedType, Extended: attributeType, Data: avpData}
+}
+
+// NewAvpExtendedVendorSpecific creates an RFC 6929 Extended-Vendor-Specific
+// attribute of the attribute type, from 241 to 246, with the vendor's type.
+func NewAvpExtendedVendorSpecific(attributeType AttributeType, vendorId VendorId, vendorType AttributeType, avpData avpData) Avp {
+	return Avp{Type: vendorType, VendorId: vendorId, Extended: attributeType, Data: avpData}
+}
+
+// AddExtended adds an extended attribute to the slice.
+func (a Avps) AddExtended(attributeType AttributeType, extendedType AttributeType, data avpData) Avps {
+	return append(a, NewAvpExtended(attributeType, extendedType, data))
+}
+
+// AddExtendedVendorSpecific adds an Extended-Vendor-Specific attribute to the slice.
+func (a Avps) AddExtendedVendorSpecific(attributeType AttributeType, vendorId VendorId, vendorType AttributeType, data avpData) Avps {
+	return append(a, NewAvpExtendedVendorSpecific(attributeType, vendorId, vendorType, data))
+}
+
+// GetExtended retrieves all extended attributes of the attribute type with
+// the Extended-Type, or the Vendor-Type if the vendor ID is not zero.
+func (a Avps) GetExtended(attributeType AttributeType, extendedType AttributeType, vendorId VendorId) []Avp {
+	if a == nil {
+		return nil
+	}
+	filteredAvps := NewAvps()
+	for _, avp := range a {
+		if avp.Extended == attributeType && avp.Type == extendedType && avp.VendorId == vendorId {
+			filteredAvps = append(filteredAvps, avp)
+		}
+	}
+	return filteredAvps
+}
+
+// GetFirstExtended retrieves the first extended attribute of the attribute
+// type with the Extended-Type, or the Vendor-Type if the vendor ID is not zero.
+func (a Avps) GetFirstExtended(attributeType AttributeType, extendedType AttributeType, vendorId VendorId) *Avp {
+	for _, avp := range a {
+		if avp.Extended == attributeType && avp.Type == extendedType && avp.VendorId == vendorId {
+			return &avp
+		}
+	}
+	return nil
+}
+
+// isExtended reports whether the attribute type is one of RFC 6929.
+func isExtended(attributeType AttributeType) bool {
+	return attributeType >= ExtendedAttribute1 && attributeType <= LongExtendedAttribute2
+}
+
+// isLongExtended reports whether the attribute type is a Long Extended Type.
+func isLongExtended(attributeType AttributeType) bool {
+	return attributeType == LongExtendedAttribute1 || attributeType == LongExtendedAttribute2
+}
+
+// extendedHeader appends to the buffer the bytes after the Length field
+// that start every attribute, or every fragment, of the extended attribute.
+func (a Avp) extendedHeader(buffer []byte) []byte {
+	if a.VendorId == 0 {
+		buffer = append(buffer, byte(a.Type))
+	} else {
+		buffer = append(buffer, byte(ExtendedVendorSpecific))
+	}
+	if isLongExtended(a.Extended) {
+		buffer = append(buffer, 0)
+	}
+	if a.VendorId != 0 {
+		buffer = binary.BigEndian.AppendUint32(buffer, uint32(a.VendorId))
+		buffer = append(buffer, byte(a.Type))
+	}
+	return buffer
+}
+
+// appendExtended appends an extended attribute to the byte slice, splitting
+// the value of a Long Extended Type attribute into fragments that fit.
+func (a Avp) appendExtended(dst []byte) []byte {
+	var buffer [7]byte
+	header := a.extendedHeader(buffer[:0])
+	if !isLongExtended(a.Extended) {
+		dst = append(dst, byte(a.Extended), byte(len(header)+len(a.Data)+2))
+		dst = append(dst, header...)
+		return append(dst, a.Data...)
+	}
+	size := 255 - 2 - len(header)
+	data := a.Data
+	for {
+		fragment := data[:min(len(data), size)]
+		data = data[len(fragment):]
+		dst = append(dst, byte(a.Extended), byte(len(header)+len(fragment)+2))
+		dst = append(dst, header...)
+		if len(data) > 0 {
+			dst[len(dst)-len(header)+1] |= moreFlag
+		}
+		dst = append(dst, fragment...)
+		if len(data) == 0 {
+			return dst
+		}
+	}
+}
+
+// extendedSize returns the number of bytes of an encoded extended attribute,
+// counting the header of every fragment.
+func (a Avp) extendedSize() int {
+	var buffer [7]byte
+	header := len(a.extendedHeader(buffer[:0])) + 2
+	if !isLongExtended(a.Extended) {
+		return header + len(a.Data)
+	}
+	size := 255 - header
+	fragments := max((len(a.Data)+size-1)/size, 1)
+	return fragments*header + len(a.Data)
+}
+
+// readExtended reads the extended attribute at the offset, joining the
+// fragments of a Long Extended Type attribute, and returns the offset of the
+// attribute that follows it.
+func readExtended(bytes []byte, offset int, start int) (Avp, int, error) {
+	attributeType := AttributeType(bytes[offset])
+	long := isLongExtended(attributeType)
+	minimum := 3
+	if long {
+		minimum = 4
+	}
+	var avp Avp
+	for first := true; ; first = false {
+		if len(bytes)-offset < 2 {
+			return Avp{}, 0, &AttributeError{Offset: start + offset, Type: attributeType, Reason: "incomplete long extended attribute", Err: ErrTruncated}
+		}
+		length := int(bytes[offset+1])
+		if AttributeType(bytes[offset]) != attributeType || length < minimum || offset+length > len(bytes) {
+			err := &AttributeError{
+				Offset: start + offset,
+				Type:   attributeType,
+				Reason: fmt.Sprintf("invalid extended attribute length %d", length),
+				Err:    &InvalidLengthError{Offset: start + offset, Declared: length, Available: len(bytes) - offset},
+			}
+			if !first {
+				err.Reason, err.Err = "incomplete long extended attribute", ErrTruncated
+			}
+			return Avp{}, 0, err
+		}
+		data := bytes[offset+2 : offset+length]
+		extendedType := AttributeType(data[0])
+		more := false
+		if long {
+			more = data[1]&moreFlag != 0
+			data = data[2:]
+		} else {
+			data = data[1:]
+		}
+		fragment := Avp{Type: extendedType, Extended: attributeType}
+		if extendedType == ExtendedVendorSpecific {
+			if len(data) < 5 {
+				return Avp{}, 0, &AttributeError{Offset: start + offset, Type: attributeType, Reason: "invalid extended vendor specific attribute"}
+			}
+			fragment.VendorId = VendorId(binary.BigEndian.Uint32(data[0:4]))
+			fragment.Type = AttributeType(data[4])
+			data = data[5:]
+		}
+		if first {
+			avp = fragment
+			avp.Data = data
+		} else if fragment.Type != avp.Type || fragment.VendorId != avp.VendorId {
+			return Avp{}, 0, &AttributeError{Offset: start + offset, Type: attributeType, Reason: "incomplete long extended attribute", Err: ErrTruncated}
+		} else {
+			avp.Data = append(append(avpData(nil), avp.Data...), data...)
+		}
+		offset += length
+		if !more {
+			return avp, offset, nil
+		}
+	}
+}
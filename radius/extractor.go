@@ -0,0 +1,73 @@
+package radius
+
+import "encoding/binary"
+
+// Field identifies an attribute by type and vendor ID.
+type Field struct {
+	Type     AttributeType
+	VendorId VendorId
+}
+
+// Extractor scans the bytes of a RADIUS message and returns the first
+// attribute found for each of its fields, or nil where a field is absent.
+// Bytes beyond the packet's Length field are padding and are not scanned.
+// The returned AVPs alias the scanned bytes.
+type Extractor func(bytes []byte) ([]*Avp, error)
+
+// NewExtractor compiles the fields into an Extractor, which reads only the
+// attribute headers needed to find them.
+func NewExtractor(fields ...Field) Extractor {
+	indexes := make(map[Field][]int)
+	for i, field := range fields {
+		indexes[field] = append(indexes[field], i)
+	}
+	return func(bytes []byte) ([]*Avp, error) {
+		if len(bytes) < 20 {
+			return nil, ErrTruncated
+		}
+		length := int(binary.BigEndian.Uint16(bytes[2:4]))
+		if length < 20 || length > maxMessageLength || length > len(bytes) {
+			return nil, &InvalidLengthError{Declared: length, Available: len(bytes)}
+		}
+		bytes = bytes[:length]
+		values := make([]*Avp, len(fields))
+		remaining := len(fields)
+		offset := 20
+		for offset < len(bytes) && remaining > 0 {
+			if len(bytes)-offset < 2 {
+				return values, ErrTruncated
+			}
+			length := int(bytes[offset+1])
+			if length < 2 || offset+length > len(bytes) {
+				return values, &InvalidLengthError{Offset: offset, Declared: length, Available: len(bytes) - offset}
+			}
+			field := Field{Type: AttributeType(bytes[offset])}
+			data := bytes[offset+2 : offset+length]
+			offset += length
+			if field.Type == 26 {
+				if subAttributes, ok := vendorAttributes(data); ok {
+					for _, avp := range subAttributes {
+						remaining -= extract(values, indexes[Field{avp.Type, avp.VendorId}], avp)
+					}
+					continue
+				}
+			}
+			remaining -= extract(values, indexes[field], NewAvp(field.Type, 0, data))
+		}
+		return values, nil
+	}
+}
+
+// extract sets the values at the indexes that are still nil to the AVP,
+// returning how many it set.
+func extract(values []*Avp, indexes []int, avp Avp) int {
+	found := 0
+	for _, index := range indexes {
+		if values[index] == nil {
+			value := avp
+			values[index] = &value
+			found++
+		}
+	}
+	return found
+}
@@ -0,0 +1,136 @@
+package radius
+
+import (
+	"fmt"
+	"net"
+	"time"
+)
+
+// Value is the set of types an attribute can be converted to with As and
+// Get. Prefixes are not among them, since the length of an IPv4 prefix does
+// not tell it from an IPv6 one; use ToIPv4PrefixOk or ToIPv6PrefixOk.
+type Value interface {
+	string | []byte | uint32 | uint64 | net.IP | time.Time | InterfaceId
+}
+
+// As converts the attribute to the type parameter, reporting whether the
+// attribute is present and well formed for it, as the ToXxxOk method of the
+// type does.
+func As[T Value](avp *Avp) (T, bool) {
+	var value T
+	var ok bool
+	switch target := any(&value).(type) {
+	case *string:
+		*target, ok = avp.ToStringOk()
+	case *[]byte:
+		*target, ok = avp.ToData(), avp != nil && avp.Data != nil
+	case *uint32:
+		*target, ok = avp.ToUint32Ok()
+	case *uint64:
+		*target, ok = avp.ToUint64Ok()
+	case *net.IP:
+		*target, ok = avp.ToNetIPOk()
+	case *time.Time:
+		*target, ok = avp.ToTimeOk()
+	case *InterfaceId:
+		*target, ok = avp.ToInterfaceIdOk()
+	}
+	return value, ok
+}
+
+// Get converts the first attribute with the given type and vendor ID to the
+// type parameter, reporting whether it is present and well formed, as in
+// radius.Get[uint32](avps, AttributeNASPort, 0).
+func Get[T Value](avps Avps, attributeType AttributeType, vendorId VendorId) (T, bool) {
+	return As[T](avps.GetFirst(attributeType, vendorId))
+}
+
+// GetAll converts every attribute with the given type and vendor ID to the
+// type parameter, skipping those malformed for it.
+func GetAll[T Value](avps Avps, attributeType AttributeType, vendorId VendorId) []T {
+	var values []T
+	for i := range avps {
+		if !avps[i].is(attributeType, vendorId) {
+			continue
+		}
+		if value, ok := As[T](&avps[i]); ok {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// ToStrings converts every attribute with the given type and vendor ID to a
+// string, such as the Class attributes of an Access-Accept.
+func (a Avps) ToStrings(attributeType AttributeType, vendorId VendorId) []string {
+	return GetAll[string](a, attributeType, vendorId)
+}
+
+// ToUint32s converts every well formed attribute with the given type and
+// vendor ID to a uint32.
+func (a Avps) ToUint32s(attributeType AttributeType, vendorId VendorId) []uint32 {
+	return GetAll[uint32](a, attributeType, vendorId)
+}
+
+// ToUint64s converts every well formed attribute with the given type and
+// vendor ID to a uint64.
+func (a Avps) ToUint64s(attributeType AttributeType, vendorId VendorId) []uint64 {
+	return GetAll[uint64](a, attributeType, vendorId)
+}
+
+// ToNetIPs converts every well formed attribute with the given type and
+// vendor ID to a net.IP.
+func (a Avps) ToNetIPs(attributeType AttributeType, vendorId VendorId) []net.IP {
+	return GetAll[net.IP](a, attributeType, vendorId)
+}
+
+// ToTimes converts every well formed attribute with the given type and
+// vendor ID to a time.Time.
+func (a Avps) ToTimes(attributeType AttributeType, vendorId VendorId) []time.Time {
+	return GetAll[time.Time](a, attributeType, vendorId)
+}
+
+// Must converts the first attribute with the given type and vendor ID to the
+// type parameter, panicking with the type, vendor ID and data length if it
+// is missing or malformed. It suits tests and scripts.
+func Must[T Value](avps Avps, attributeType AttributeType, vendorId VendorId) T {
+	avp := avps.GetFirst(attributeType, vendorId)
+	if avp == nil {
+		panic(fmt.Sprintf("radius: attribute %d (vendor %d) is missing", attributeType, vendorId))
+	}
+	value, ok := As[T](avp)
+	if !ok {
+		panic(fmt.Sprintf("radius: attribute %d (vendor %d) of %d bytes is not a valid %T", attributeType, vendorId, len(avp.Data), value))
+	}
+	return value
+}
+
+// MustString converts the first attribute with the given type and vendor ID
+// to a string, panicking if it is missing.
+func (a Avps) MustString(attributeType AttributeType, vendorId VendorId) string {
+	return Must[string](a, attributeType, vendorId)
+}
+
+// MustUint32 converts the first attribute with the given type and vendor ID
+// to a uint32, panicking if it is missing or malformed.
+func (a Avps) MustUint32(attributeType AttributeType, vendorId VendorId) uint32 {
+	return Must[uint32](a, attributeType, vendorId)
+}
+
+// MustUint64 converts the first attribute with the given type and vendor ID
+// to a uint64, panicking if it is missing or malformed.
+func (a Avps) MustUint64(attributeType AttributeType, vendorId VendorId) uint64 {
+	return Must[uint64](a, attributeType, vendorId)
+}
+
+// MustNetIP converts the first attribute with the given type and vendor ID
+// to a net.IP, panicking if it is missing or malformed.
+func (a Avps) MustNetIP(attributeType AttributeType, vendorId VendorId) net.IP {
+	return Must[net.IP](a, attributeType, vendorId)
+}
+
+// MustTime converts the first attribute with the given type and vendor ID to
+// a time.Time, panicking if it is missing or malformed.
+func (a Avps) MustTime(attributeType AttributeType, vendorId VendorId) time.Time {
+	return Must[time.Time](a, attributeType, vendorId)
+}
@@ -0,0 +1,289 @@
+package radius
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/tinybluerobots/radius-diameter-message/enterprise"
+)
+
+// jsonMessage is the JSON representation of a RADIUS message.
+type jsonMessage struct {
+	Code          Code      `json:"code"`
+	Name          string    `json:"name,omitempty"`
+	Identifier    byte      `json:"identifier"`
+	Authenticator []byte    `json:"authenticator"`
+	Avps          []jsonAvp `json:"avps"`
+}
+
+// jsonAvp is the JSON representation of a RADIUS attribute. Typed values are
+// written to Value and anything else to Data as base64.
+type jsonAvp struct {
+	Type     AttributeType   `json:"type"`
+	VendorId VendorId        `json:"vendorId,omitempty"`
+	Vendor   string          `json:"vendor,omitempty"`
+	Extended AttributeType   `json:"extended,omitempty"`
+	Name     string          `json:"name,omitempty"`
+	DataType string          `json:"dataType,omitempty"`
+	Value    json.RawMessage `json:"value,omitempty"`
+	Data     []byte          `json:"data,omitempty"`
+}
+
+// MarshalJSON converts the RADIUS message to JSON.
+func (m Message) MarshalJSON() ([]byte, error) {
+	return MarshalMessageJSON(m, nil)
+}
+
+// UnmarshalJSON reads JSON produced by MarshalJSON into the RADIUS message.
+func (m *Message) UnmarshalJSON(bytes []byte) error {
+	message, err := UnmarshalMessageJSON(bytes, nil)
+	if err != nil {
+		return err
+	}
+	*m = *message
+	return nil
+}
+
+// MarshalJSON converts the attribute to JSON.
+func (a Avp) MarshalJSON() ([]byte, error) {
+	return json.Marshal(toJSONAvp(a, nil))
+}
+
+// UnmarshalJSON reads JSON produced by MarshalJSON into the attribute.
+func (a *Avp) UnmarshalJSON(bytes []byte) error {
+	var value jsonAvp
+	if err := json.Unmarshal(bytes, &value); err != nil {
+		return err
+	}
+	avp, err := fromJSONAvp(value, nil)
+	if err != nil {
+		return err
+	}
+	*a = avp
+	return nil
+}
+
+// MarshalMessageJSON converts the RADIUS message to JSON, using the
+// dictionary, which may be nil, to name and type attributes.
+func MarshalMessageJSON(m Message, dictionary *Dictionary) ([]byte, error) {
+	value := jsonMessage{
+		Code:          m.Code,
+		Identifier:    m.Identifier,
+		Authenticator: m.Authenticator[:],
+		Avps:          make([]jsonAvp, 0, len(m.Avps)),
+	}
+	if m.Code.Known() {
+		value.Name = m.Code.String()
+	}
+	for _, avp := range m.Avps {
+		value.Avps = append(value.Avps, toJSONAvp(avp, dictionary))
+	}
+	return json.Marshal(value)
+}
+
+// UnmarshalMessageJSON reads JSON into a RADIUS message, using the
+// dictionary, which may be nil, to resolve attribute names and types.
+func UnmarshalMessageJSON(bytes []byte, dictionary *Dictionary) (*Message, error) {
+	var value jsonMessage
+	if err := json.Unmarshal(bytes, &value); err != nil {
+		return nil, err
+	}
+	if value.Authenticator != nil && len(value.Authenticator) != 16 {
+		return nil, fmt.Errorf("invalid authenticator length %d", len(value.Authenticator))
+	}
+	message := Message{
+		Code:       value.Code,
+		Identifier: value.Identifier,
+		Avps:       NewAvps(),
+	}
+	copy(message.Authenticator[:], value.Authenticator)
+	for _, jsonAvp := range value.Avps {
+		avp, err := fromJSONAvp(jsonAvp, dictionary)
+		if err != nil {
+			return nil, err
+		}
+		message.Avps = append(message.Avps, avp)
+	}
+	return &message, nil
+}
+
+// toJSONAvp converts an attribute to its JSON representation.
+func toJSONAvp(avp Avp, dictionary *Dictionary) jsonAvp {
+	value := jsonAvp{
+		Type:     avp.Type,
+		VendorId: avp.VendorId,
+		Extended: avp.Extended,
+	}
+	if avp.VendorId != 0 {
+		if name := enterprise.Name(uint32(avp.VendorId)); name != nil {
+			value.Vendor = *name
+		}
+	}
+	definition := dictionary.Attribute(avp.Type, avp.VendorId)
+	if definition == nil || avp.Extended != 0 {
+		value.Data = avp.Data
+		return value
+	}
+	value.Name = definition.Name
+	typed, ok := typedJSONValue(definition.DataType, avp)
+	if !ok {
+		value.Data = avp.Data
+		return value
+	}
+	value.DataType = definition.DataType.String()
+	value.Value, _ = json.Marshal(typed)
+	return value
+}
+
+// typedJSONValue decodes an attribute as a Go value suitable for JSON,
+// reporting false when the type has no typed form or the data is malformed
+// for its type.
+func typedJSONValue(dataType DataType, avp Avp) (any, bool) {
+	switch dataType {
+	case Text:
+		return string(avp.Data), true
+	case Integer, Enum:
+		return avp.ToUint32Ok()
+	case Integer64:
+		return avp.ToUint64Ok()
+	case Time:
+		if len(avp.Data) == 4 {
+			return avp.ToTimeOrDefault().UTC().Format(time.RFC3339), true
+		}
+	case IPv4Addr:
+		if ip, ok := avp.ToIPv4Ok(); ok {
+			return ip.String(), true
+		}
+	case IPv6Addr:
+		if ip, ok := avp.ToIPv6Ok(); ok {
+			return ip.String(), true
+		}
+	case IPv4Prefix:
+		if prefix, ok := avp.ToIPv4PrefixOk(); ok {
+			return prefix.String(), true
+		}
+	case IPv6Prefix:
+		if prefix, ok := avp.ToIPv6PrefixOk(); ok {
+			return prefix.String(), true
+		}
+	}
+	return nil, false
+}
+
+// fromJSONAvp converts a JSON representation back into an attribute.
+func fromJSONAvp(value jsonAvp, dictionary *Dictionary) (Avp, error) {
+	attributeType, vendorId := value.Type, value.VendorId
+	if vendorId == 0 && value.Vendor != "" {
+		number := enterprise.Number(value.Vendor)
+		if number == nil {
+			return Avp{}, fmt.Errorf("unknown vendor %q", value.Vendor)
+		}
+		vendorId = VendorId(*number)
+	}
+	var definition *AttributeDefinition
+	if value.Name != "" && attributeType == 0 {
+		definition = dictionary.AttributeByName(value.Name)
+		if definition == nil {
+			return Avp{}, fmt.Errorf("unknown attribute name %q", value.Name)
+		}
+		attributeType, vendorId = definition.Type, definition.VendorId
+	} else {
+		definition = dictionary.Attribute(attributeType, vendorId)
+	}
+	if value.Value == nil {
+		data := value.Data
+		if data == nil {
+			data = make([]byte, 0)
+		}
+		avp := NewAvp(attributeType, vendorId, data)
+		avp.Extended = value.Extended
+		return avp, nil
+	}
+	dataType, ok := parseDataType(value.DataType)
+	if !ok {
+		if definition == nil {
+			return Avp{}, fmt.Errorf("attribute %d has a value but no data type", attributeType)
+		}
+		dataType = definition.DataType
+	}
+	avp, err := newTypedAvp(attributeType, vendorId, dataType, value.Value, definition)
+	if err != nil {
+		return Avp{}, fmt.Errorf("attribute %d: %w", attributeType, err)
+	}
+	avp.Extended = value.Extended
+	return avp, nil
+}
+
+// parseDataType converts the name of a data type back to a DataType.
+func parseDataType(name string) (DataType, bool) {
+	for dataType, dataTypeName := range dataTypeNames {
+		if dataTypeName == name {
+			return dataType, true
+		}
+	}
+	return 0, false
+}
+
+// newTypedAvp creates an attribute from a JSON value of the given data type.
+// The value of an enum may be the name its definition gives it.
+func newTypedAvp(attributeType AttributeType, vendorId VendorId, dataType DataType, raw json.RawMessage, definition *AttributeDefinition) (Avp, error) {
+	switch dataType {
+	case Text:
+		var value string
+		err := json.Unmarshal(raw, &value)
+		return NewAvpString(attributeType, vendorId, value), err
+	case Enum:
+		var name string
+		if json.Unmarshal(raw, &name) == nil && definition != nil {
+			for number, valueName := range definition.Values {
+				if valueName == name {
+					return NewAvpUint32(attributeType, vendorId, number), nil
+				}
+			}
+			return Avp{}, fmt.Errorf("unknown value %q", name)
+		}
+		fallthrough
+	case Integer:
+		var value uint32
+		err := json.Unmarshal(raw, &value)
+		return NewAvpUint32(attributeType, vendorId, value), err
+	case Integer64:
+		var value uint64
+		err := json.Unmarshal(raw, &value)
+		return NewAvpUint64(attributeType, vendorId, value), err
+	case Time:
+		var value time.Time
+		err := json.Unmarshal(raw, &value)
+		return NewAvpTime(attributeType, vendorId, value), err
+	case IPv4Addr, IPv6Addr:
+		var value string
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return Avp{}, err
+		}
+		ip := net.ParseIP(value)
+		if ip == nil {
+			return Avp{}, fmt.Errorf("invalid address %q", value)
+		}
+		if dataType == IPv4Addr {
+			return NewAvpIPv4(attributeType, vendorId, ip), nil
+		}
+		return NewAvpIPv6(attributeType, vendorId, ip), nil
+	case IPv4Prefix, IPv6Prefix:
+		var value string
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return Avp{}, err
+		}
+		_, prefix, err := net.ParseCIDR(value)
+		if err != nil {
+			return Avp{}, err
+		}
+		if dataType == IPv4Prefix {
+			return NewAvpIPv4Prefix(attributeType, vendorId, *prefix), nil
+		}
+		return NewAvpIPv6Prefix(attributeType, vendorId, *prefix), nil
+	}
+	return Avp{}, errors.New("unsupported value type " + dataType.String())
+}
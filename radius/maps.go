@@ -0,0 +1,42 @@
+package radius
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// ToMap converts the RADIUS message to a generic map with the same layout as
+// its JSON, using the dictionary, which may be nil, to name and type
+// attributes. Numbers are json.Number values. It suits templating engines
+// and policy scripts that work on messages without knowing attribute codes
+// at compile time.
+func (m Message) ToMap(dictionary *Dictionary) map[string]any {
+	bytes, err := MarshalMessageJSON(m, dictionary)
+	if err != nil {
+		return nil
+	}
+	values, _ := decodeMap(bytes)
+	return values
+}
+
+// FromMap converts a generic map with the layout of ToMap back into a RADIUS
+// message, using the dictionary, which may be nil, to resolve attribute
+// names and types. As in JSON, an attribute may be given by name alone and
+// an enum value by its name. Values may be any Go values that encode to the
+// JSON of the attribute, such as numbers of any type, times and addresses.
+func FromMap(values map[string]any, dictionary *Dictionary) (*Message, error) {
+	bytes, err := json.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+	return UnmarshalMessageJSON(bytes, dictionary)
+}
+
+// decodeMap decodes a JSON object as a map, keeping numbers exact.
+func decodeMap(data []byte) (map[string]any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var values map[string]any
+	err := decoder.Decode(&values)
+	return values, err
+}
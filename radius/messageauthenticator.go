@@ -0,0 +1,118 @@
+package radius
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"errors"
+)
+
+// AttributeMessageAuthenticator is the type of the Message-Authenticator attribute.
+const AttributeMessageAuthenticator AttributeType = 80
+
+var (
+	// ErrMessageAuthenticatorMissing is returned when a required Message-Authenticator is absent.
+	ErrMessageAuthenticatorMissing = errors.New("message-authenticator missing")
+	// ErrMessageAuthenticatorInvalid is returned when a Message-Authenticator does not match, and matches ErrBadAuthenticator.
+	ErrMessageAuthenticatorInvalid error = authenticatorError("message-authenticator invalid")
+)
+
+// hasAccountingAuthenticator reports whether requests with the code carry an
+// authenticator computed from the packet, so their Message-Authenticator is
+// computed with an Authenticator of zeros: Accounting-Request,
+// Disconnect-Request and CoA-Request.
+func hasAccountingAuthenticator(code Code) bool {
+	return code == CodeAccountingRequest || code == CodeDisconnectRequest || code == CodeCoARequest
+}
+
+// messageAuthenticator computes the HMAC-MD5 of the message, with its
+// Authenticator field replaced by the given one and the data of its
+// Message-Authenticator zeroed.
+func (m Message) messageAuthenticator(authenticator [16]byte, secret []byte) [16]byte {
+	m.Authenticator = authenticator
+	m.Avps = append(Avps(nil), m.Avps...)
+	for i, avp := range m.Avps {
+		if avp.is(AttributeMessageAuthenticator, 0) {
+			m.Avps[i].Data = make(avpData, md5.Size)
+		}
+	}
+	mac := hmac.New(md5.New, secret)
+	mac.Write(m.ToBytes())
+	result := [16]byte{}
+	copy(result[:], mac.Sum(nil))
+	return result
+}
+
+// setMessageAuthenticator sets the Message-Authenticator, adding it as the
+// first attribute if there is none.
+func (m *Message) setMessageAuthenticator(authenticator [16]byte, secret []byte) {
+	found := false
+	for _, avp := range m.Avps {
+		found = found || avp.is(AttributeMessageAuthenticator, 0)
+	}
+	if !found {
+		m.Avps = append(Avps{NewAvp(AttributeMessageAuthenticator, 0, make(avpData, md5.Size))}, m.Avps...)
+	}
+	value := m.messageAuthenticator(authenticator, secret)
+	for i, avp := range m.Avps {
+		if avp.is(AttributeMessageAuthenticator, 0) {
+			m.Avps[i].Data = value[:]
+			return
+		}
+	}
+}
+
+// AddMessageAuthenticator adds a Message-Authenticator to a request as its
+// first attribute, or updates the one it has. It is computed with the
+// request's own Request Authenticator, or with zeros for an
+// Accounting-Request, CoA-Request or Disconnect-Request, whose authenticator
+// must be set afterwards with SetAccountingAuthenticator.
+func (m *Message) AddMessageAuthenticator(secret []byte) {
+	authenticator := m.Authenticator
+	if hasAccountingAuthenticator(m.Code) {
+		authenticator = [16]byte{}
+	}
+	m.setMessageAuthenticator(authenticator, secret)
+}
+
+// AddResponseMessageAuthenticator adds a Message-Authenticator to a response
+// as its first attribute, or updates the one it has, computed with the
+// Request Authenticator of the request. The Response Authenticator must be
+// set afterwards with SetResponseAuthenticator.
+func (m *Message) AddResponseMessageAuthenticator(requestAuthenticator [16]byte, secret []byte) {
+	m.setMessageAuthenticator(requestAuthenticator, secret)
+}
+
+// verifyMessageAuthenticator checks the Message-Authenticator computed with the authenticator.
+func (m Message) verifyMessageAuthenticator(authenticator [16]byte, secret []byte, required bool) error {
+	avp := m.Avps.GetFirst(AttributeMessageAuthenticator, 0)
+	if avp == nil {
+		if required || m.Avps.GetFirst(AttributeEAPMessage, 0) != nil {
+			return ErrMessageAuthenticatorMissing
+		}
+		return nil
+	}
+	expected := m.messageAuthenticator(authenticator, secret)
+	if !hmac.Equal(expected[:], avp.Data) {
+		return ErrMessageAuthenticatorInvalid
+	}
+	return nil
+}
+
+// VerifyMessageAuthenticator checks the Message-Authenticator of a request.
+// A request without one is accepted unless required is set, which servers
+// should do for Access-Requests to defend against forged responses such as
+// the Blast-RADIUS attack, or it carries an EAP-Message.
+func (m Message) VerifyMessageAuthenticator(secret []byte, required bool) error {
+	authenticator := m.Authenticator
+	if hasAccountingAuthenticator(m.Code) {
+		authenticator = [16]byte{}
+	}
+	return m.verifyMessageAuthenticator(authenticator, secret, required)
+}
+
+// VerifyResponseMessageAuthenticator checks the Message-Authenticator of a
+// response to the request with the Request Authenticator. A response without
+// one is accepted unless required is set or it carries an EAP-Message.
+func (m Message) VerifyResponseMessageAuthenticator(requestAuthenticator [16]byte, secret []byte, required bool) error {
+	return m.verifyMessageAuthenticator(requestAuthenticator, secret, required)
+}
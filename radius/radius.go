@@ -2,8 +2,9 @@ package radius
 
 import (
 	"encoding/binary"
-	"errors"
+	"fmt"
 	"net"
+	"slices"
 	"time"
 )
 
@@ -19,25 +20,21 @@ type avpData []byte
 // Avp represents a RADIUS Attribute-Value Pair (AVP).
 type Avp struct {
 	Type     AttributeType
-	length   byte
 	VendorId VendorId
+	// Extended is the RFC 6929 attribute type, from 241 to 246, of an
+	// extended attribute, whose Type is then its Extended-Type or, with a
+	// VendorId, its Vendor-Type. It is zero for other attributes.
+	Extended AttributeType
 	Data     avpData
 }
 
 // NewAvp creates a new AVP with the given attribute type, vendor ID, and data.
 func NewAvp(attributeType AttributeType, vendorId VendorId, avpData avpData) Avp {
-	a := Avp{
-		Type: attributeType,
-		Data: avpData,
+	return Avp{
+		Type:     attributeType,
+		VendorId: vendorId,
+		Data:     avpData,
 	}
-	if vendorId == 0 {
-		a.length = byte(len(avpData) + 2)
-	} else {
-		a.VendorId = vendorId
-		a.length = byte(len(avpData) + 8)
-		a.Data = avpData
-	}
-	return a
 }
 
 // NewAvpString creates a new AVP with a string value.
@@ -52,9 +49,13 @@ func NewAvpUint32(attributeType AttributeType, vendorId VendorId, value uint32)
 	return NewAvp(attributeType, vendorId, buffer)
 }
 
-// NewAvpNetIP creates a new AVP with a net.IP value.
+// NewAvpNetIP creates a new AVP with a net.IP value, written as four bytes
+// for an IPv4 address and sixteen for an IPv6 address.
 func NewAvpNetIP(attributeType AttributeType, vendorId VendorId, value net.IP) Avp {
-	return NewAvp(attributeType, vendorId, avpData(value.To4()))
+	if ipv4 := value.To4(); ipv4 != nil {
+		return NewAvp(attributeType, vendorId, avpData(ipv4))
+	}
+	return NewAvp(attributeType, vendorId, avpData(value.To16()))
 }
 
 // NewAvpTime creates a new AVP with a time.Time value.
@@ -66,21 +67,37 @@ func NewAvpTime(attributeType AttributeType, vendorId VendorId, value time.Time)
 
 // ToBytes converts the AVP to a byte slice.
 func (a Avp) ToBytes() []byte {
-	bytes := make([]byte, 0)
-	if a.VendorId == 0 {
-		bytes = append(bytes, byte(a.Type))
-		bytes = append(bytes, a.length)
-	} else {
-		bytes = append(bytes, 26)
-		bytes = append(bytes, a.length)
-		buffer := make([]byte, 4)
-		binary.BigEndian.PutUint32(buffer, uint32(a.VendorId))
-		bytes = append(bytes, buffer...)
-		bytes = append(bytes, byte(a.Type))
-		bytes = append(bytes, byte(len(a.Data)+2))
+	return a.AppendBytes(make([]byte, 0, a.size()))
+}
+
+// AppendBytes appends the AVP to the byte slice and returns the extended
+// slice, so that a buffer can be reused across messages.
+func (a Avp) AppendBytes(dst []byte) []byte {
+	if a.Extended != 0 {
+		return a.appendExtended(dst)
 	}
-	bytes = append(bytes, a.Data...)
-	return bytes
+	return append(a.appendHeader(dst), a.Data...)
+}
+
+// appendHeader appends the bytes before the value of an AVP that is not
+// extended to the byte slice.
+func (a Avp) appendHeader(dst []byte) []byte {
+	if a.VendorId != 0 {
+		dst = append(dst, 26, byte(len(a.Data)+8))
+		dst = binary.BigEndian.AppendUint32(dst, uint32(a.VendorId))
+	}
+	return append(dst, byte(a.Type), byte(len(a.Data)+2))
+}
+
+// size returns the number of bytes of the encoded AVP.
+func (a Avp) size() int {
+	if a.Extended != 0 {
+		return a.extendedSize()
+	}
+	if a.VendorId != 0 {
+		return len(a.Data) + 8
+	}
+	return len(a.Data) + 2
 }
 
 // Avps represents a slice of AVPs.
@@ -123,15 +140,26 @@ func (a Avps) AddTime(attributeType AttributeType, vendorId VendorId, value time
 
 // ToBytes converts the slice of AVPs to a byte slice.
 func (a Avps) ToBytes() []byte {
-	bytes := make([]byte, 0)
+	return a.AppendBytes(make([]byte, 0, a.size()))
+}
+
+// AppendBytes appends the AVPs to the byte slice and returns the extended
+// slice.
+func (a Avps) AppendBytes(dst []byte) []byte {
 	for _, avp := range a {
-		bytes = append(bytes, avp.ToBytes()...)
+		dst = avp.AppendBytes(dst)
 	}
-	return bytes
+	return dst
 }
 
-// Code represents the code in a RADIUS message.
-type Code uint32
+// size returns the number of bytes of the encoded AVPs.
+func (a Avps) size() int {
+	size := 0
+	for _, avp := range a {
+		size += avp.size()
+	}
+	return size
+}
 
 // Message represents a RADIUS message.
 type Message struct {
@@ -143,19 +171,11 @@ type Message struct {
 
 // length calculates the length of the RADIUS message.
 func (m Message) length() uint16 {
-	length := uint16(20)
-	for _, avp := range m.Avps {
-		length += uint16(avp.length)
-	}
-	return length
+	return uint16(20 + m.Avps.size())
 }
 
 // NewMessage creates a new RADIUS message.
 func NewMessage(code Code, identifier byte, authenticator [16]byte, avps ...Avp) Message {
-	length := uint16(20)
-	for _, avp := range avps {
-		length += uint16(avp.length)
-	}
 	return Message{
 		Code:          code,
 		Identifier:    identifier,
@@ -166,15 +186,20 @@ func NewMessage(code Code, identifier byte, authenticator [16]byte, avps ...Avp)
 
 // ToBytes converts the RADIUS message to a byte slice.
 func (m Message) ToBytes() []byte {
-	bytes := make([]byte, 0)
-	bytes = append(bytes, byte(m.Code))
-	bytes = append(bytes, m.Identifier)
-	buffer := make([]byte, 2)
-	binary.BigEndian.PutUint16(buffer, m.length())
-	bytes = append(bytes, buffer...)
-	bytes = append(bytes, m.Authenticator[:]...)
-	bytes = append(bytes, m.Avps.ToBytes()...)
-	return bytes
+	return m.AppendBytes(make([]byte, 0, m.length()))
+}
+
+// AppendBytes appends the RADIUS message to the byte slice and returns the
+// extended slice, so that a buffer can be reused across messages.
+func (m Message) AppendBytes(dst []byte) []byte {
+	return m.Avps.AppendBytes(m.appendHeader(dst))
+}
+
+// appendHeader appends the 20 byte header of the message to the byte slice.
+func (m Message) appendHeader(dst []byte) []byte {
+	dst = append(dst, byte(m.Code), m.Identifier)
+	dst = binary.BigEndian.AppendUint16(dst, m.length())
+	return append(dst, m.Authenticator[:]...)
 }
 
 // Get retrieves all AVPs with the given attribute type and vendor ID.
@@ -184,7 +209,7 @@ func (a Avps) Get(attributeType AttributeType, vendorId VendorId) []Avp {
 	}
 	filteredAvps := NewAvps()
 	for _, avp := range a {
-		if avp.Type == attributeType && avp.VendorId == vendorId {
+		if avp.is(attributeType, vendorId) {
 			filteredAvps = append(filteredAvps, avp)
 		}
 	}
@@ -194,13 +219,32 @@ func (a Avps) Get(attributeType AttributeType, vendorId VendorId) []Avp {
 // GetFirst retrieves the first AVP with the given attribute type and vendor ID.
 func (a Avps) GetFirst(attributeType AttributeType, vendorId VendorId) *Avp {
 	for _, avp := range a {
-		if avp.Type == attributeType && avp.VendorId == vendorId {
+		if avp.is(attributeType, vendorId) {
 			return &avp
 		}
 	}
 	return nil
 }
 
+// Without returns the attributes other than those, not extended, with the
+// vendor ID and one of the types, such as those left over once a packet's
+// own attributes are read.
+func (a Avps) Without(vendorId VendorId, types ...AttributeType) Avps {
+	var others Avps
+	for _, avp := range a {
+		if avp.VendorId != vendorId || avp.Extended != 0 || !slices.Contains(types, avp.Type) {
+			others = append(others, avp)
+		}
+	}
+	return others
+}
+
+// is reports whether the AVP is the attribute, which is not extended, with
+// the type and vendor ID.
+func (a Avp) is(attributeType AttributeType, vendorId VendorId) bool {
+	return a.Type == attributeType && a.VendorId == vendorId && a.Extended == 0
+}
+
 // ToData converts the AVP to a byte slice.
 func (a *Avp) ToData() []byte {
 	if a == nil {
@@ -211,109 +255,188 @@ func (a *Avp) ToData() []byte {
 
 // ToString converts the AVP to a string.
 func (a *Avp) ToString() *string {
-	if a == nil || a.Data == nil {
+	value, ok := a.ToStringOk()
+	if !ok {
 		return nil
 	}
-	value := string(a.Data)
 	return &value
 }
 
 // ToStringOrDefault converts the AVP to a string or returns a default value.
 func (a *Avp) ToStringOrDefault() string {
-	value := a.ToString()
-	if value == nil {
+	value, _ := a.ToStringOk()
+	return value
+}
+
+// ToStringOk converts the AVP to a string, reporting whether the AVP is present and well formed.
+func (a *Avp) ToStringOk() (string, bool) {
+	if a == nil || a.Data == nil {
 		var value string
-		return value
+		return value, false
 	}
-	return *value
+	return string(a.Data), true
 }
 
 // ToUint32 converts the AVP to a uint32.
 func (a *Avp) ToUint32() *uint32 {
-	if a == nil || a.Data == nil {
+	value, ok := a.ToUint32Ok()
+	if !ok {
 		return nil
 	}
-	value := binary.BigEndian.Uint32(a.Data)
 	return &value
 }
 
 // ToUint32OrDefault converts the AVP to a uint32 or returns a default value.
 func (a *Avp) ToUint32OrDefault() uint32 {
-	value := a.ToUint32()
-	if value == nil {
+	value, _ := a.ToUint32Ok()
+	return value
+}
+
+// ToUint32Ok converts the AVP to a uint32, reporting whether the AVP is present and well formed.
+func (a *Avp) ToUint32Ok() (uint32, bool) {
+	if a == nil || a.Data == nil {
 		var value uint32
-		return value
+		return value, false
+	}
+	if len(a.Data) < 4 {
+		return 0, false
 	}
-	return *value
+	return binary.BigEndian.Uint32(a.Data), true
 }
 
 // ToNetIP converts the AVP to a net.IP.
 func (a *Avp) ToNetIP() *net.IP {
-	if a == nil || a.Data == nil {
+	value, ok := a.ToNetIPOk()
+	if !ok {
 		return nil
 	}
-	value := net.IP(a.Data)
 	return &value
 }
 
 // ToNetIPOrDefault converts the AVP to a net.IP or returns a default value.
 func (a *Avp) ToNetIPOrDefault() net.IP {
-	value := a.ToNetIP()
-	if value == nil {
+	value, _ := a.ToNetIPOk()
+	return value
+}
+
+// ToNetIPOk converts the AVP to a net.IP, reporting whether the AVP is present and well formed.
+func (a *Avp) ToNetIPOk() (net.IP, bool) {
+	if a == nil || a.Data == nil {
 		var value net.IP
-		return value
+		return value, false
 	}
-	return *value
+	return net.IP(a.Data), true
 }
 
 // ToTime converts the AVP to a time.Time.
 func (a *Avp) ToTime() *time.Time {
-	if a == nil || a.Data == nil {
+	value, ok := a.ToTimeOk()
+	if !ok {
 		return nil
 	}
-	timestamp := int64(binary.BigEndian.Uint32(a.Data))
-	value := time.Unix(timestamp, 0)
 	return &value
 }
 
 // ToTimeOrDefault converts the AVP to a time.Time or returns a default value.
 func (a *Avp) ToTimeOrDefault() time.Time {
-	value := a.ToTime()
-	if value == nil {
+	value, _ := a.ToTimeOk()
+	return value
+}
+
+// ToTimeOk converts the AVP to a time.Time, reporting whether the AVP is present and well formed.
+func (a *Avp) ToTimeOk() (time.Time, bool) {
+	if a == nil || a.Data == nil {
 		var value time.Time
-		return value
+		return value, false
+	}
+	if len(a.Data) < 4 {
+		return time.Time{}, false
 	}
-	return *value
+	timestamp := int64(binary.BigEndian.Uint32(a.Data))
+	return time.Unix(timestamp, 0), true
 }
 
-// readAvps reads a byte slice and converts it to a slice of AVPs.
-func readAvps(bytes []byte) Avps {
+// maxMessageLength is the largest RADIUS packet RFC 2865 allows.
+const maxMessageLength = 4096
+
+// AttributeError describes an attribute that could not be decoded.
+type AttributeError struct {
+	// Offset is the offset of the attribute from the start of the message.
+	Offset int
+	Type   AttributeType
+	Reason string
+	// Err is ErrTruncated or an *InvalidLengthError, or nil for a malformed
+	// extended attribute header.
+	Err error
+}
+
+// Error returns the reason for the error and the offset of the attribute.
+func (e *AttributeError) Error() string {
+	return fmt.Sprintf("%s at offset %d (attribute %d)", e.Reason, e.Offset, e.Type)
+}
+
+// Unwrap returns the error the reason describes.
+func (e *AttributeError) Unwrap() error {
+	return e.Err
+}
+
+// readAvps reads the attributes of a message, starting at the offset in the
+// message of the first byte. It returns the attributes read so far and an
+// *AttributeError if one is malformed. Each sub-attribute of a Vendor-Specific
+// attribute is read as an AVP with the vendor's ID; one whose contents are not
+// well formed sub-attributes is kept as attribute 26.
+func readAvps(bytes []byte, start int) (Avps, error) {
 	offset := 0
 	avps := NewAvps()
 	for offset < len(bytes) {
+		if len(bytes)-offset < 2 {
+			return avps, &AttributeError{Offset: start + offset, Type: AttributeType(bytes[offset]), Reason: "truncated attribute header", Err: ErrTruncated}
+		}
 		attributeType := AttributeType(bytes[offset])
-		length := bytes[offset+1]
-		var avpData avpData
-		var vendorId VendorId
+		length := int(bytes[offset+1])
+		if length < 2 || offset+length > len(bytes) {
+			return avps, &AttributeError{
+				Offset: start + offset,
+				Type:   attributeType,
+				Reason: fmt.Sprintf("invalid attribute length %d", length),
+				Err:    &InvalidLengthError{Offset: start + offset, Declared: length, Available: len(bytes) - offset},
+			}
+		}
+		if isExtended(attributeType) {
+			avp, next, err := readExtended(bytes, offset, start)
+			if err != nil {
+				return avps, err
+			}
+			avps = append(avps, avp)
+			offset = next
+			continue
+		}
+		data := bytes[offset+2 : offset+length]
+		offset += length
 		if attributeType == 26 {
-			vendorId = VendorId(binary.BigEndian.Uint32(bytes[offset+2 : offset+6]))
-			attributeType = AttributeType(bytes[offset+6])
-			avpLength := bytes[offset+7]
-			avpData = bytes[offset+8 : offset+6+int(avpLength)]
-		} else {
-			avpLength := bytes[offset+1]
-			avpData = bytes[offset+2 : offset+int(avpLength)]
+			if subAttributes, ok := vendorAttributes(data); ok {
+				avps = append(avps, subAttributes...)
+				continue
+			}
 		}
-		avps = append(avps, NewAvp(attributeType, vendorId, avpData))
-		offset += int(length)
+		avps = append(avps, NewAvp(attributeType, 0, data))
 	}
-	return avps
+	return avps, nil
 }
 
-// ReadMessage reads a byte slice and converts it to a RADIUS message.
+// ReadMessage reads a byte slice and converts it to a RADIUS message. Bytes
+// beyond the Length field of the header are padding and are ignored.
 func ReadMessage(bytes []byte) (*Message, error) {
 	if len(bytes) < 20 {
-		return nil, errors.New("invalid message length")
+		return nil, ErrTruncated
+	}
+	length := int(binary.BigEndian.Uint16(bytes[2:4]))
+	if length < 20 || length > maxMessageLength || length > len(bytes) {
+		return nil, &InvalidLengthError{Declared: length, Available: len(bytes)}
+	}
+	avps, err := readAvps(bytes[20:length], 20)
+	if err != nil {
+		return nil, err
 	}
 	authenticator := [16]byte{}
 	copy(authenticator[:], bytes[4:20])
@@ -321,7 +444,20 @@ func ReadMessage(bytes []byte) (*Message, error) {
 		Code:          Code(bytes[0]),
 		Identifier:    bytes[1],
 		Authenticator: authenticator,
-		Avps:          readAvps(bytes[20:]),
+		Avps:          avps,
 	}
 	return &message, nil
 }
+
+// ReadMessageStrict reads a RADIUS message like ReadMessage but rejects
+// messages whose code is not a known one.
+func ReadMessageStrict(bytes []byte) (*Message, error) {
+	message, err := ReadMessage(bytes)
+	if err != nil {
+		return nil, err
+	}
+	if !message.Code.Known() {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownCode, message.Code)
+	}
+	return message, nil
+}
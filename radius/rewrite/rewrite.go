@@ -0,0 +1,195 @@
+// Package rewrite rewrites the attributes of RADIUS messages according to
+// rules loaded from configuration, so that operators can normalize values
+// such as Calling-Station-Id or strip vendor attributes without code
+// changes.
+package rewrite
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/tinybluerobots/radius-diameter-message/radius"
+	"github.com/tinybluerobots/radius-diameter-message/radius/server"
+	"gopkg.in/yaml.v3"
+)
+
+// Action is what a rule does to the attributes it matches.
+type Action string
+
+// Actions of rules.
+const (
+	// ActionAdd adds an attribute with the Value of the rule.
+	ActionAdd Action = "add"
+	// ActionRemove removes the matching attributes.
+	ActionRemove Action = "remove"
+	// ActionReplace replaces the value of the matching attributes.
+	ActionReplace Action = "replace"
+)
+
+// Config is a list of rules, applied in order.
+type Config struct {
+	Rules []Rule `json:"rules" yaml:"rules"`
+}
+
+// Rule matches attributes by the code of their message, their type and
+// vendor ID and a regular expression on their value, and acts on them.
+type Rule struct {
+	// Codes restricts the rule to messages with the codes. It applies to
+	// every message if empty.
+	Codes []uint32 `json:"codes" yaml:"codes"`
+	// Attribute is the type of the attributes matched. Zero matches every
+	// attribute of the vendor, which an add rule may not do.
+	Attribute uint8 `json:"attribute" yaml:"attribute"`
+	// VendorId is the vendor ID of the attributes matched, zero for standard
+	// attributes.
+	VendorId uint32 `json:"vendorId" yaml:"vendorId"`
+	// Match is a regular expression the value of an attribute must match. An
+	// empty expression matches every value.
+	Match string `json:"match" yaml:"match"`
+	// Action is what the rule does to the attributes it matches.
+	Action Action `json:"action" yaml:"action"`
+	// Value is the value of an added attribute, or the replacement value of
+	// a matching one, in which $1 and ${name} expand to the submatches of
+	// Match.
+	Value string `json:"value" yaml:"value"`
+}
+
+// Engine applies compiled rules to messages. It is safe for concurrent use.
+type Engine struct {
+	rules []rule
+}
+
+// rule is a Rule with its expression compiled.
+type rule struct {
+	Rule
+	match *regexp.Regexp
+}
+
+// Load reads the rules from a file, as YAML if its extension is .yaml or
+// .yml and as JSON otherwise, and compiles them.
+func Load(path string) (*Engine, error) {
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return ParseYAML(bytes)
+	}
+	return ParseJSON(bytes)
+}
+
+// ParseJSON parses and compiles JSON rules.
+func ParseJSON(bytes []byte) (*Engine, error) {
+	config := Config{}
+	if err := json.Unmarshal(bytes, &config); err != nil {
+		return nil, err
+	}
+	return New(config.Rules...)
+}
+
+// ParseYAML parses and compiles YAML rules.
+func ParseYAML(bytes []byte) (*Engine, error) {
+	config := Config{}
+	if err := yaml.Unmarshal(bytes, &config); err != nil {
+		return nil, err
+	}
+	return New(config.Rules...)
+}
+
+// New compiles the rules, reporting every one that is not valid.
+func New(rules ...Rule) (*Engine, error) {
+	engine := &Engine{}
+	var errs []error
+	for i, r := range rules {
+		compiled := rule{Rule: r}
+		switch r.Action {
+		case ActionAdd:
+			if r.Attribute == 0 {
+				errs = append(errs, fmt.Errorf("rules[%d]: add requires an attribute", i))
+			}
+		case ActionRemove, ActionReplace:
+		default:
+			errs = append(errs, fmt.Errorf("rules[%d]: unknown action %q", i, r.Action))
+		}
+		if r.Match != "" {
+			match, err := regexp.Compile(r.Match)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("rules[%d]: %w", i, err))
+			}
+			compiled.match = match
+		}
+		engine.rules = append(engine.rules, compiled)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return engine, nil
+}
+
+// Apply returns a copy of the message with the rules applied in order.
+func (e *Engine) Apply(message radius.Message) radius.Message {
+	message = message.Clone()
+	for _, r := range e.rules {
+		if len(r.Codes) > 0 && !slices.Contains(r.Codes, uint32(message.Code)) {
+			continue
+		}
+		message.Avps = r.apply(message.Avps)
+	}
+	return message
+}
+
+// apply applies the rule to the attributes of a message.
+func (r rule) apply(avps radius.Avps) radius.Avps {
+	if r.Action == ActionAdd {
+		return append(avps, radius.NewAvpString(radius.AttributeType(r.Attribute), radius.VendorId(r.VendorId), r.Value))
+	}
+	result := avps[:0]
+	for _, avp := range avps {
+		if !r.matches(avp) {
+			result = append(result, avp)
+			continue
+		}
+		if r.Action == ActionReplace {
+			avp.Data = []byte(r.replace(string(avp.Data)))
+			result = append(result, avp)
+		}
+	}
+	return result
+}
+
+// matches reports whether the attribute is one the rule acts on.
+func (r rule) matches(avp radius.Avp) bool {
+	if avp.Extended != 0 || avp.VendorId != radius.VendorId(r.VendorId) {
+		return false
+	}
+	if r.Attribute != 0 && avp.Type != radius.AttributeType(r.Attribute) {
+		return false
+	}
+	return r.match == nil || r.match.Match(avp.Data)
+}
+
+// replace returns the replacement of a matching value.
+func (r rule) replace(value string) string {
+	if r.match == nil {
+		return r.Value
+	}
+	return r.match.ReplaceAllString(value, r.Value)
+}
+
+// Middleware returns server middleware that applies the rules to every
+// request before it is handled.
+func (e *Engine) Middleware() server.Middleware {
+	return func(next server.Handler) server.Handler {
+		return func(ctx context.Context, writer server.ResponseWriter, request radius.Message) {
+			next(ctx, writer, e.Apply(request))
+		}
+	}
+}
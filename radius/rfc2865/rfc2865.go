@@ -0,0 +1,157 @@
+// Package rfc2865 defines the attributes of RFC 2865, Remote
+// Authentication Dial In User Service, with the data type of each.
+package rfc2865
+
+import "github.com/tinybluerobots/radius-diameter-message/radius"
+
+// Attribute types defined by RFC 2865.
+const (
+	UserName               radius.AttributeType = 1
+	UserPassword           radius.AttributeType = 2
+	CHAPPassword           radius.AttributeType = 3
+	NASIPAddress           radius.AttributeType = 4
+	NASPort                radius.AttributeType = 5
+	ServiceType            radius.AttributeType = 6
+	FramedProtocol         radius.AttributeType = 7
+	FramedIPAddress        radius.AttributeType = 8
+	FramedIPNetmask        radius.AttributeType = 9
+	FramedRouting          radius.AttributeType = 10
+	FilterId               radius.AttributeType = 11
+	FramedMTU              radius.AttributeType = 12
+	FramedCompression      radius.AttributeType = 13
+	LoginIPHost            radius.AttributeType = 14
+	LoginService           radius.AttributeType = 15
+	LoginTCPPort           radius.AttributeType = 16
+	ReplyMessage           radius.AttributeType = 18
+	CallbackNumber         radius.AttributeType = 19
+	CallbackId             radius.AttributeType = 20
+	FramedRoute            radius.AttributeType = 22
+	FramedIPXNetwork       radius.AttributeType = 23
+	State                  radius.AttributeType = 24
+	Class                  radius.AttributeType = 25
+	VendorSpecific         radius.AttributeType = 26
+	SessionTimeout         radius.AttributeType = 27
+	IdleTimeout            radius.AttributeType = 28
+	TerminationAction      radius.AttributeType = 29
+	CalledStationId        radius.AttributeType = 30
+	CallingStationId       radius.AttributeType = 31
+	NASIdentifier          radius.AttributeType = 32
+	ProxyState             radius.AttributeType = 33
+	LoginLATService        radius.AttributeType = 34
+	LoginLATNode           radius.AttributeType = 35
+	LoginLATGroup          radius.AttributeType = 36
+	FramedAppleTalkLink    radius.AttributeType = 37
+	FramedAppleTalkNetwork radius.AttributeType = 38
+	FramedAppleTalkZone    radius.AttributeType = 39
+	CHAPChallenge          radius.AttributeType = 60
+	NASPortType            radius.AttributeType = 61
+	PortLimit              radius.AttributeType = 62
+	LoginLATPort           radius.AttributeType = 63
+)
+
+// Attributes are the definitions of the attributes of RFC 2865.
+var Attributes = []radius.AttributeDefinition{
+	{Name: "User-Name", Type: UserName, DataType: radius.Text},
+	{Name: "User-Password", Type: UserPassword, DataType: radius.String},
+	{Name: "CHAP-Password", Type: CHAPPassword, DataType: radius.String},
+	{Name: "NAS-IP-Address", Type: NASIPAddress, DataType: radius.IPv4Addr},
+	{Name: "NAS-Port", Type: NASPort, DataType: radius.Integer},
+	{Name: "Service-Type", Type: ServiceType, DataType: radius.Enum, Values: map[uint32]string{
+		1:  "Login-User",
+		2:  "Framed-User",
+		3:  "Callback-Login-User",
+		4:  "Callback-Framed-User",
+		5:  "Outbound-User",
+		6:  "Administrative-User",
+		7:  "NAS-Prompt-User",
+		8:  "Authenticate-Only",
+		9:  "Callback-NAS-Prompt",
+		10: "Call-Check",
+		11: "Callback-Administrative",
+	}},
+	{Name: "Framed-Protocol", Type: FramedProtocol, DataType: radius.Enum, Values: map[uint32]string{
+		1: "PPP",
+		2: "SLIP",
+		3: "ARAP",
+		4: "Gandalf-SLML",
+		5: "Xylogics-IPX-SLIP",
+		6: "X.75-Synchronous",
+	}},
+	{Name: "Framed-IP-Address", Type: FramedIPAddress, DataType: radius.IPv4Addr},
+	{Name: "Framed-IP-Netmask", Type: FramedIPNetmask, DataType: radius.IPv4Addr},
+	{Name: "Framed-Routing", Type: FramedRouting, DataType: radius.Enum, Values: map[uint32]string{
+		0: "None",
+		1: "Send-Routing-Packets",
+		2: "Listen-For-Routing-Packets",
+		3: "Send-And-Listen",
+	}},
+	{Name: "Filter-Id", Type: FilterId, DataType: radius.Text},
+	{Name: "Framed-MTU", Type: FramedMTU, DataType: radius.Integer},
+	{Name: "Framed-Compression", Type: FramedCompression, DataType: radius.Enum, Values: map[uint32]string{
+		0: "None",
+		1: "Van-Jacobson-TCP-IP",
+		2: "IPX-Header-Compression",
+		3: "Stac-LZS",
+	}},
+	{Name: "Login-IP-Host", Type: LoginIPHost, DataType: radius.IPv4Addr},
+	{Name: "Login-Service", Type: LoginService, DataType: radius.Enum, Values: map[uint32]string{
+		0: "Telnet",
+		1: "Rlogin",
+		2: "TCP-Clear",
+		3: "PortMaster",
+		4: "LAT",
+		5: "X25-PAD",
+		6: "X25-T3POS",
+		8: "TCP-Clear-Quiet",
+	}},
+	{Name: "Login-TCP-Port", Type: LoginTCPPort, DataType: radius.Integer},
+	{Name: "Reply-Message", Type: ReplyMessage, DataType: radius.Text},
+	{Name: "Callback-Number", Type: CallbackNumber, DataType: radius.Text},
+	{Name: "Callback-Id", Type: CallbackId, DataType: radius.Text},
+	{Name: "Framed-Route", Type: FramedRoute, DataType: radius.Text},
+	{Name: "Framed-IPX-Network", Type: FramedIPXNetwork, DataType: radius.IPv4Addr},
+	{Name: "State", Type: State, DataType: radius.String},
+	{Name: "Class", Type: Class, DataType: radius.String},
+	{Name: "Vendor-Specific", Type: VendorSpecific, DataType: radius.VSA},
+	{Name: "Session-Timeout", Type: SessionTimeout, DataType: radius.Integer},
+	{Name: "Idle-Timeout", Type: IdleTimeout, DataType: radius.Integer},
+	{Name: "Termination-Action", Type: TerminationAction, DataType: radius.Enum, Values: map[uint32]string{
+		0: "Default",
+		1: "RADIUS-Request",
+	}},
+	{Name: "Called-Station-Id", Type: CalledStationId, DataType: radius.Text},
+	{Name: "Calling-Station-Id", Type: CallingStationId, DataType: radius.Text},
+	{Name: "NAS-Identifier", Type: NASIdentifier, DataType: radius.Text},
+	{Name: "Proxy-State", Type: ProxyState, DataType: radius.String},
+	{Name: "Login-LAT-Service", Type: LoginLATService, DataType: radius.Text},
+	{Name: "Login-LAT-Node", Type: LoginLATNode, DataType: radius.Text},
+	{Name: "Login-LAT-Group", Type: LoginLATGroup, DataType: radius.String},
+	{Name: "Framed-AppleTalk-Link", Type: FramedAppleTalkLink, DataType: radius.Integer},
+	{Name: "Framed-AppleTalk-Network", Type: FramedAppleTalkNetwork, DataType: radius.Integer},
+	{Name: "Framed-AppleTalk-Zone", Type: FramedAppleTalkZone, DataType: radius.Text},
+	{Name: "CHAP-Challenge", Type: CHAPChallenge, DataType: radius.String},
+	{Name: "NAS-Port-Type", Type: NASPortType, DataType: radius.Enum, Values: map[uint32]string{
+		0:  "Async",
+		1:  "Sync",
+		2:  "ISDN-Sync",
+		3:  "ISDN-Async-V.120",
+		4:  "ISDN-Async-V.110",
+		5:  "Virtual",
+		6:  "PIAFS",
+		7:  "HDLC-Clear-Channel",
+		8:  "X.25",
+		9:  "X.75",
+		10: "G.3-Fax",
+		11: "SDSL",
+		12: "ADSL-CAP",
+		13: "ADSL-DMT",
+		14: "IDSL",
+		15: "Ethernet",
+		16: "xDSL",
+		17: "Cable",
+		18: "Wireless-Other",
+		19: "Wireless-802.11",
+	}},
+	{Name: "Port-Limit", Type: PortLimit, DataType: radius.Integer},
+	{Name: "Login-LAT-Port", Type: LoginLATPort, DataType: radius.Text},
+}
@@ -0,0 +1,66 @@
+// Package rfc2866 defines the attributes of RFC 2866, RADIUS Accounting,
+// with the data type of each.
+package rfc2866
+
+import "github.com/tinybluerobots/radius-diameter-message/radius"
+
+// Attribute types defined by RFC 2866.
+const (
+	AcctStatusType     radius.AttributeType = 40
+	AcctDelayTime      radius.AttributeType = 41
+	AcctInputOctets    radius.AttributeType = 42
+	AcctOutputOctets   radius.AttributeType = 43
+	AcctSessionId      radius.AttributeType = 44
+	AcctAuthentic      radius.AttributeType = 45
+	AcctSessionTime    radius.AttributeType = 46
+	AcctInputPackets   radius.AttributeType = 47
+	AcctOutputPackets  radius.AttributeType = 48
+	AcctTerminateCause radius.AttributeType = 49
+	AcctMultiSessionId radius.AttributeType = 50
+	AcctLinkCount      radius.AttributeType = 51
+)
+
+// Attributes are the definitions of the attributes of RFC 2866.
+var Attributes = []radius.AttributeDefinition{
+	{Name: "Acct-Status-Type", Type: AcctStatusType, DataType: radius.Enum, Values: map[uint32]string{
+		1: "Start",
+		2: "Stop",
+		3: "Interim-Update",
+		7: "Accounting-On",
+		8: "Accounting-Off",
+	}},
+	{Name: "Acct-Delay-Time", Type: AcctDelayTime, DataType: radius.Integer},
+	{Name: "Acct-Input-Octets", Type: AcctInputOctets, DataType: radius.Integer},
+	{Name: "Acct-Output-Octets", Type: AcctOutputOctets, DataType: radius.Integer},
+	{Name: "Acct-Session-Id", Type: AcctSessionId, DataType: radius.Text},
+	{Name: "Acct-Authentic", Type: AcctAuthentic, DataType: radius.Enum, Values: map[uint32]string{
+		1: "RADIUS",
+		2: "Local",
+		3: "Remote",
+	}},
+	{Name: "Acct-Session-Time", Type: AcctSessionTime, DataType: radius.Integer},
+	{Name: "Acct-Input-Packets", Type: AcctInputPackets, DataType: radius.Integer},
+	{Name: "Acct-Output-Packets", Type: AcctOutputPackets, DataType: radius.Integer},
+	{Name: "Acct-Terminate-Cause", Type: AcctTerminateCause, DataType: radius.Enum, Values: map[uint32]string{
+		1:  "User-Request",
+		2:  "Lost-Carrier",
+		3:  "Lost-Service",
+		4:  "Idle-Timeout",
+		5:  "Session-Timeout",
+		6:  "Admin-Reset",
+		7:  "Admin-Reboot",
+		8:  "Port-Error",
+		9:  "NAS-Error",
+		10: "NAS-Request",
+		11: "NAS-Reboot",
+		12: "Port-Unneeded",
+		13: "Port-Preempted",
+		14: "Port-Suspended",
+		15: "Service-Unavailable",
+		16: "Callback",
+		17: "User-Error",
+		18: "Host-Request",
+	}},
+	{Name: "Acct-Multi-Session-Id", Type: AcctMultiSessionId, DataType: radius.Text},
+	{Name: "Acct-Link-Count", Type: AcctLinkCount, DataType: radius.Integer},
+}
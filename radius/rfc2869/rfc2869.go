@@ -0,0 +1,56 @@
+// Package rfc2869 defines the attributes of RFC 2869, RADIUS Extensions,
+// with the data type of each.
+package rfc2869
+
+import "github.com/tinybluerobots/radius-diameter-message/radius"
+
+// Attribute types defined by RFC 2869.
+const (
+	AcctInputGigawords    radius.AttributeType = 52
+	AcctOutputGigawords   radius.AttributeType = 53
+	EventTimestamp        radius.AttributeType = 55
+	ARAPPassword          radius.AttributeType = 70
+	ARAPFeatures          radius.AttributeType = 71
+	ARAPZoneAccess        radius.AttributeType = 72
+	ARAPSecurity          radius.AttributeType = 73
+	ARAPSecurityData      radius.AttributeType = 74
+	PasswordRetry         radius.AttributeType = 75
+	Prompt                radius.AttributeType = 76
+	ConnectInfo           radius.AttributeType = 77
+	ConfigurationToken    radius.AttributeType = 78
+	EAPMessage            radius.AttributeType = 79
+	MessageAuthenticator  radius.AttributeType = 80
+	ARAPChallengeResponse radius.AttributeType = 84
+	AcctInterimInterval   radius.AttributeType = 85
+	NASPortId             radius.AttributeType = 87
+	FramedPool            radius.AttributeType = 88
+)
+
+// Attributes are the definitions of the attributes of RFC 2869.
+var Attributes = []radius.AttributeDefinition{
+	{Name: "Acct-Input-Gigawords", Type: AcctInputGigawords, DataType: radius.Integer},
+	{Name: "Acct-Output-Gigawords", Type: AcctOutputGigawords, DataType: radius.Integer},
+	{Name: "Event-Timestamp", Type: EventTimestamp, DataType: radius.Time},
+	{Name: "ARAP-Password", Type: ARAPPassword, DataType: radius.String},
+	{Name: "ARAP-Features", Type: ARAPFeatures, DataType: radius.String},
+	{Name: "ARAP-Zone-Access", Type: ARAPZoneAccess, DataType: radius.Enum, Values: map[uint32]string{
+		1: "Default-Zone",
+		2: "Zone-Filter-Inclusive",
+		4: "Zone-Filter-Exclusive",
+	}},
+	{Name: "ARAP-Security", Type: ARAPSecurity, DataType: radius.Integer},
+	{Name: "ARAP-Security-Data", Type: ARAPSecurityData, DataType: radius.Text},
+	{Name: "Password-Retry", Type: PasswordRetry, DataType: radius.Integer},
+	{Name: "Prompt", Type: Prompt, DataType: radius.Enum, Values: map[uint32]string{
+		0: "No-Echo",
+		1: "Echo",
+	}},
+	{Name: "Connect-Info", Type: ConnectInfo, DataType: radius.Text},
+	{Name: "Configuration-Token", Type: ConfigurationToken, DataType: radius.Text},
+	{Name: "EAP-Message", Type: EAPMessage, DataType: radius.Concat},
+	{Name: "Message-Authenticator", Type: MessageAuthenticator, DataType: radius.String},
+	{Name: "ARAP-Challenge-Response", Type: ARAPChallengeResponse, DataType: radius.String},
+	{Name: "Acct-Interim-Interval", Type: AcctInterimInterval, DataType: radius.Integer},
+	{Name: "NAS-Port-Id", Type: NASPortId, DataType: radius.Text},
+	{Name: "Framed-Pool", Type: FramedPool, DataType: radius.Text},
+}
@@ -0,0 +1,25 @@
+// Package rfc3162 defines the attributes of RFC 3162, RADIUS and IPv6,
+// with the data type of each.
+package rfc3162
+
+import "github.com/tinybluerobots/radius-diameter-message/radius"
+
+// Attribute types defined by RFC 3162.
+const (
+	NASIPv6Address    radius.AttributeType = 95
+	FramedInterfaceId radius.AttributeType = 96
+	FramedIPv6Prefix  radius.AttributeType = 97
+	LoginIPv6Host     radius.AttributeType = 98
+	FramedIPv6Route   radius.AttributeType = 99
+	FramedIPv6Pool    radius.AttributeType = 100
+)
+
+// Attributes are the definitions of the attributes of RFC 3162.
+var Attributes = []radius.AttributeDefinition{
+	{Name: "NAS-IPv6-Address", Type: NASIPv6Address, DataType: radius.IPv6Addr},
+	{Name: "Framed-Interface-Id", Type: FramedInterfaceId, DataType: radius.InterfaceIdentifier},
+	{Name: "Framed-IPv6-Prefix", Type: FramedIPv6Prefix, DataType: radius.IPv6Prefix},
+	{Name: "Login-IPv6-Host", Type: LoginIPv6Host, DataType: radius.IPv6Addr},
+	{Name: "Framed-IPv6-Route", Type: FramedIPv6Route, DataType: radius.Text},
+	{Name: "Framed-IPv6-Pool", Type: FramedIPv6Pool, DataType: radius.Text},
+}
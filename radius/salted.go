@@ -0,0 +1,118 @@
+package radius
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"errors"
+)
+
+// Attributes encrypted with the salted scheme of RFC 2868 and RFC 2548.
+const (
+	AttributeTunnelPassword AttributeType = 69
+	// VendorMicrosoft is the vendor ID of the Microsoft vendor-specific attributes.
+	VendorMicrosoft        VendorId      = 311
+	AttributeMSMPPESendKey AttributeType = 16
+	AttributeMSMPPERecvKey AttributeType = 17
+)
+
+var (
+	// ErrInvalidSaltedValue is returned when a salted value cannot be decrypted.
+	ErrInvalidSaltedValue = errors.New("invalid salted value")
+	// ErrSaltedValueTooLong is returned when a value is too long to encrypt
+	// into an attribute.
+	ErrSaltedValueTooLong = errors.New("salted value too long")
+)
+
+// maxSaltedLength is the longest encrypted value, salt included, that fits
+// in an attribute, leaving room for the tag of a Tunnel-Password.
+const maxSaltedLength = 253 - 1
+
+// NewSalt returns a random salt for a salted value, with its most
+// significant bit set as RFC 2868 requires.
+func NewSalt() ([2]byte, error) {
+	salt := [2]byte{}
+	_, err := rand.Read(salt[:])
+	salt[0] |= 0x80
+	return salt, err
+}
+
+// EncryptSalted encrypts the value with the salted scheme of RFC 2868
+// section 3.5, used by Tunnel-Password, MS-MPPE-Send-Key and
+// MS-MPPE-Recv-Key. The value is prefixed with its length and padded to a
+// multiple of 16 bytes, then hidden with MD5 over the shared secret, the
+// Request Authenticator of the request and the salt. The salt is returned
+// ahead of the ciphertext. It returns ErrSaltedValueTooLong if the length
+// does not fit in its byte or the result does not fit in an attribute.
+func EncryptSalted(value []byte, secret []byte, requestAuthenticator [16]byte, salt [2]byte) ([]byte, error) {
+	if len(value) > 255 {
+		return nil, ErrSaltedValueTooLong
+	}
+	plaintext := append([]byte{byte(len(value))}, value...)
+	if padding := len(plaintext) % md5.Size; padding != 0 {
+		plaintext = append(plaintext, make([]byte, md5.Size-padding)...)
+	}
+	if len(salt)+len(plaintext) > maxSaltedLength {
+		return nil, ErrSaltedValueTooLong
+	}
+	vector := append(requestAuthenticator[:], salt[:]...)
+	return append(salt[:], md5Chain(plaintext, secret, vector, true)...), nil
+}
+
+// DecryptSalted decrypts a value encrypted with EncryptSalted, given the
+// salt followed by the ciphertext.
+func DecryptSalted(data []byte, secret []byte, requestAuthenticator [16]byte) ([]byte, error) {
+	if len(data) < 2+md5.Size || (len(data)-2)%md5.Size != 0 {
+		return nil, ErrInvalidSaltedValue
+	}
+	plaintext := md5Chain(data[2:], secret, append(requestAuthenticator[:], data[:2]...), false)
+	length := int(plaintext[0])
+	if length > len(plaintext)-1 {
+		return nil, ErrInvalidSaltedValue
+	}
+	return plaintext[1 : 1+length], nil
+}
+
+// NewAvpSalted creates a new AVP with the value encrypted with a random salt
+// for a response to the request with the Request Authenticator.
+func NewAvpSalted(attributeType AttributeType, vendorId VendorId, value []byte, secret []byte, requestAuthenticator [16]byte) (Avp, error) {
+	salt, err := NewSalt()
+	if err != nil {
+		return Avp{}, err
+	}
+	encrypted, err := EncryptSalted(value, secret, requestAuthenticator, salt)
+	if err != nil {
+		return Avp{}, err
+	}
+	return NewAvp(attributeType, vendorId, encrypted), nil
+}
+
+// NewAvpTunnelPassword creates a Tunnel-Password attribute with the tag and
+// the password encrypted with a random salt.
+func NewAvpTunnelPassword(tag byte, password []byte, secret []byte, requestAuthenticator [16]byte) (Avp, error) {
+	salt, err := NewSalt()
+	if err != nil {
+		return Avp{}, err
+	}
+	encrypted, err := EncryptSalted(password, secret, requestAuthenticator, salt)
+	if err != nil {
+		return Avp{}, err
+	}
+	return NewAvp(AttributeTunnelPassword, 0, append([]byte{tag}, encrypted...)), nil
+}
+
+// ToSalted decrypts the salted value of the AVP.
+func (a *Avp) ToSalted(secret []byte, requestAuthenticator [16]byte) ([]byte, error) {
+	if a == nil {
+		return nil, ErrInvalidSaltedValue
+	}
+	return DecryptSalted(a.Data, secret, requestAuthenticator)
+}
+
+// ToTunnelPassword decrypts a Tunnel-Password attribute, returning its tag and password.
+func (a *Avp) ToTunnelPassword(secret []byte, requestAuthenticator [16]byte) (byte, []byte, error) {
+	if a == nil || len(a.Data) < 1 {
+		return 0, nil, ErrInvalidSaltedValue
+	}
+	password, err := DecryptSalted(a.Data[1:], secret, requestAuthenticator)
+	return a.Data[0], password, err
+}
@@ -0,0 +1,145 @@
+package server
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+type dedupKey struct {
+	source        string
+	identifier    byte
+	authenticator [16]byte
+}
+
+type dedupEntry struct {
+	key      dedupKey
+	response []byte
+	expires  time.Time
+}
+
+// DedupObserver receives the events of a DedupCache, such as to count them
+// as metrics. Implementations must be safe for concurrent use and should not
+// block.
+type DedupObserver interface {
+	// Missed is called for a request that is not a retransmission.
+	Missed()
+	// Replayed is called when a retransmission is answered with the
+	// response already sent.
+	Replayed()
+	// Dropped is called when a retransmission is dropped because the
+	// original is still being handled.
+	Dropped()
+	// Evicted is called when a request is forgotten before its TTL expires
+	// to keep the cache within its maximum size.
+	Evicted()
+}
+
+// NopDedupObserver is a DedupObserver that ignores every event.
+type NopDedupObserver struct{}
+
+func (NopDedupObserver) Missed()   {}
+func (NopDedupObserver) Replayed() {}
+func (NopDedupObserver) Dropped()  {}
+func (NopDedupObserver) Evicted()  {}
+
+// DedupCache remembers requests by source address, Identifier and Request
+// Authenticator, so a retransmitted request is answered with the response
+// already sent, or dropped while it is still being handled, instead of being
+// handled again, as RFC 5080 section 2.2.2 recommends. Requests are kept in
+// the order they expire, so remembering one costs constant time however
+// many are remembered. It is safe for concurrent use.
+type DedupCache struct {
+	ttl        time.Duration
+	maxEntries int
+	observer   DedupObserver
+	mutex      sync.Mutex
+	entries    map[dedupKey]*list.Element
+	// order holds the *dedupEntry of every request, closest to expiring first.
+	order *list.List
+}
+
+// NewDedupCache creates a cache that remembers requests for the TTL.
+func NewDedupCache(ttl time.Duration) *DedupCache {
+	return &DedupCache{ttl: ttl, observer: NopDedupObserver{}, entries: make(map[dedupKey]*list.Element), order: list.New()}
+}
+
+// WithMaxEntries limits how many requests the cache remembers, forgetting
+// those closest to expiring first. Zero, the default, is no limit.
+func (d *DedupCache) WithMaxEntries(maxEntries int) *DedupCache {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.maxEntries = maxEntries
+	return d
+}
+
+// WithObserver sets the observer of the events of the cache.
+func (d *DedupCache) WithObserver(observer DedupObserver) *DedupCache {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if observer == nil {
+		observer = NopDedupObserver{}
+	}
+	d.observer = observer
+	return d
+}
+
+// begin records the request, reporting false, with the response already sent
+// if any, if it is a retransmission of one remembered.
+func (d *DedupCache) begin(key dedupKey) ([]byte, bool) {
+	now := time.Now()
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if element, ok := d.entries[key]; ok {
+		if entry := element.Value.(*dedupEntry); now.Before(entry.expires) {
+			if entry.response == nil {
+				d.observer.Dropped()
+			} else {
+				d.observer.Replayed()
+			}
+			return entry.response, false
+		}
+	}
+	d.observer.Missed()
+	d.expire(now)
+	if element, ok := d.entries[key]; ok {
+		d.remove(element)
+	}
+	for d.maxEntries > 0 && len(d.entries) >= d.maxEntries {
+		d.remove(d.order.Front())
+		d.observer.Evicted()
+	}
+	d.entries[key] = d.order.PushBack(&dedupEntry{key: key, expires: now.Add(d.ttl)})
+	return nil, true
+}
+
+// expire forgets the requests whose TTL has passed, which are at the front
+// of the order since every request is remembered for the same TTL.
+func (d *DedupCache) expire(now time.Time) {
+	for element := d.order.Front(); element != nil && !now.Before(element.Value.(*dedupEntry).expires); element = d.order.Front() {
+		d.remove(element)
+	}
+}
+
+// remove forgets a request.
+func (d *DedupCache) remove(element *list.Element) {
+	delete(d.entries, element.Value.(*dedupEntry).key)
+	d.order.Remove(element)
+}
+
+// store remembers the response sent to the request.
+func (d *DedupCache) store(key dedupKey, response []byte) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if element, ok := d.entries[key]; ok {
+		element.Value.(*dedupEntry).response = response
+	}
+}
+
+// Len returns the number of remembered requests, including any that have
+// expired but not yet been removed.
+func (d *DedupCache) Len() int {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return len(d.entries)
+}
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"net"
+	"sync"
+
+	"github.com/tinybluerobots/radius-diameter-message/radius"
+)
+
+// ServeDTLS accepts DTLS sessions from the listener, which must come from a
+// third-party DTLS implementation, until the server is closed, serving each
+// in its own goroutine. Each Read and Write of an accepted connection
+// carries a single datagram. Requests are verified with the shared secret
+// RFC 7360 fixes; clients are authenticated by the DTLS configuration of the
+// listener.
+func (s *Server) ServeDTLS(listener net.Listener) error {
+	if !s.track(listener) {
+		listener.Close()
+		return ErrServerClosed
+	}
+	defer s.untrack(listener)
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if s.isClosed() {
+				return ErrServerClosed
+			}
+			return err
+		}
+		go s.serveDatagramConn(conn)
+	}
+}
+
+// serveDatagramConn reads requests from a DTLS session until it closes,
+// refusing to write responses larger than the MTU.
+func (s *Server) serveDatagramConn(conn net.Conn) {
+	if !s.track(conn) {
+		conn.Close()
+		return
+	}
+	defer func() {
+		conn.Close()
+		s.untrack(conn)
+	}()
+	source := client{address: conn.RemoteAddr(), secret: radius.DTLSSecret}
+	var writeMutex sync.Mutex
+	write := func(bytes []byte) error {
+		if len(bytes) > s.config.MTU {
+			return ErrTooLarge
+		}
+		writeMutex.Lock()
+		defer writeMutex.Unlock()
+		_, err := conn.Write(bytes)
+		return err
+	}
+	buffer := make([]byte, 4096)
+	for {
+		n, err := conn.Read(buffer)
+		if err != nil {
+			return
+		}
+		request, err := radius.ReadMessage(append([]byte(nil), buffer[:n]...))
+		if err != nil {
+			continue
+		}
+		s.receive(*request, source, write)
+	}
+}
@@ -0,0 +1,354 @@
+// Package server serves RADIUS requests over UDP, TCP, RadSec and DTLS.
+// DTLS sessions are accepted from a listener supplied by a third-party DTLS
+// implementation, as the package does not implement DTLS itself.
+package server
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/tinybluerobots/radius-diameter-message/radius"
+)
+
+var (
+	// ErrServerClosed is returned by Serve after the server is closed.
+	ErrServerClosed = errors.New("server closed")
+	// ErrResponseWritten is returned when a second response is written to a request.
+	ErrResponseWritten = errors.New("response already written")
+	// ErrTooLarge is returned when a DTLS response is larger than the configured MTU.
+	ErrTooLarge = errors.New("response larger than MTU")
+)
+
+// SecretFunc returns the shared secret of the client with the IP address, or
+// nil if it is not a known client, whose requests are then dropped.
+type SecretFunc func(ip net.IP) []byte
+
+// ResponseWriter writes the response to a request back to the client that
+// sent it.
+type ResponseWriter interface {
+	// WriteResponse sets the Identifier and authenticators of the response
+	// and writes it. A Message-Authenticator is added if the request had one.
+	WriteResponse(response radius.Message) error
+}
+
+// Handler handles a request, writing its response, if any, with the
+// ResponseWriter. The context is cancelled when the server is closed.
+type Handler func(ctx context.Context, writer ResponseWriter, request radius.Message)
+
+// Config configures a Server.
+type Config struct {
+	// Secret resolves the shared secret of each client.
+	Secret SecretFunc
+	// RequireMessageAuthenticator drops Access-Requests without a
+	// Message-Authenticator, as defence against the Blast-RADIUS attack.
+	RequireMessageAuthenticator bool
+	// Dedup remembers requests to recognise retransmissions. A cache
+	// remembering them for 30 seconds is used if nil.
+	Dedup *DedupCache
+	// AuthorizeCertificate identifies RadSec clients by the certificate they
+	// presented, in place of the IP address, closing the connections of
+	// those it rejects. If nil, every client whose certificate the TLS
+	// configuration verified is accepted.
+	AuthorizeCertificate func(certificate *x509.Certificate) bool
+	// MTU limits the size of responses over DTLS, so they are not
+	// fragmented, defaulting to the 4096 bytes RADIUS allows.
+	MTU int
+}
+
+type clientKey struct{}
+
+// client is the address, shared secret and, over TLS, certificate of the
+// client that sent a request.
+type client struct {
+	address     net.Addr
+	secret      []byte
+	certificate *x509.Certificate
+}
+
+// Server receives RADIUS requests over UDP, TCP, TLS or DTLS, verifies their authenticators
+// with the shared secret of the client and dispatches them to the handlers
+// registered for their code. Requests from unknown clients, that fail
+// verification or that no handler is registered for are dropped.
+type Server struct {
+	config     Config
+	mutex      sync.Mutex
+	handlers   map[radius.Code]Handler
+	middleware []Middleware
+	conns      map[io.Closer]struct{}
+	handling   sync.WaitGroup
+	ctx        context.Context
+	cancel     context.CancelFunc
+	closed     bool
+}
+
+// New creates a server with the configuration.
+func New(config Config) *Server {
+	if config.Dedup == nil {
+		config.Dedup = NewDedupCache(30 * time.Second)
+	}
+	if config.MTU <= 0 || config.MTU > 4096 {
+		config.MTU = 4096
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Server{
+		config:   config,
+		handlers: make(map[radius.Code]Handler),
+		conns:    make(map[io.Closer]struct{}),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Handle registers the handler for requests with the code, replacing any
+// handler already registered.
+func (s *Server) Handle(code radius.Code, handler Handler) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.handlers[code] = handler
+}
+
+// RemoteAddrFromContext returns the address of the client that sent the
+// request being handled.
+func RemoteAddrFromContext(ctx context.Context) (net.Addr, bool) {
+	c, ok := ctx.Value(clientKey{}).(client)
+	return c.address, ok
+}
+
+// SecretFromContext returns the shared secret of the client that sent the
+// request being handled, such as to reveal its User-Password.
+func SecretFromContext(ctx context.Context) ([]byte, bool) {
+	c, ok := ctx.Value(clientKey{}).(client)
+	return c.secret, ok
+}
+
+// PeerCertificateFromContext returns the certificate of the RadSec client
+// that sent the request being handled.
+func PeerCertificateFromContext(ctx context.Context) (*x509.Certificate, bool) {
+	c, ok := ctx.Value(clientKey{}).(client)
+	return c.certificate, ok && c.certificate != nil
+}
+
+// ListenAndServe listens on the UDP address and serves requests from it.
+func (s *Server) ListenAndServe(address string) error {
+	conn, err := net.ListenPacket("udp", address)
+	if err != nil {
+		return err
+	}
+	return s.Serve(conn)
+}
+
+// Serve reads requests from the connection until the server is closed,
+// handling each in its own goroutine.
+func (s *Server) Serve(conn net.PacketConn) error {
+	if !s.track(conn) {
+		conn.Close()
+		return ErrServerClosed
+	}
+	defer s.untrack(conn)
+	buffer := make([]byte, 4096)
+	for {
+		n, address, err := conn.ReadFrom(buffer)
+		if err != nil {
+			if s.isClosed() {
+				return ErrServerClosed
+			}
+			return err
+		}
+		udp, ok := address.(*net.UDPAddr)
+		if !ok {
+			continue
+		}
+		secret := s.secret(udp.IP)
+		if secret == nil {
+			continue
+		}
+		request, err := radius.ReadMessage(append([]byte(nil), buffer[:n]...))
+		if err != nil {
+			continue
+		}
+		s.receive(*request, client{address: address, secret: secret}, func(bytes []byte) error {
+			_, err := conn.WriteTo(bytes, address)
+			return err
+		})
+	}
+}
+
+// Close closes every connection and cancels the context of the requests
+// being handled.
+func (s *Server) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.closed = true
+	s.cancel()
+	var err error
+	for conn := range s.conns {
+		err = errors.Join(err, conn.Close())
+	}
+	return err
+}
+
+// Shutdown closes the server gracefully: it closes every listener and drops
+// new requests, waits for the handlers of the requests already received to
+// finish writing their responses, then closes every connection as Close
+// does. If the context is done first, the server is closed without waiting
+// longer and the context's error is returned.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mutex.Lock()
+	s.closed = true
+	var err error
+	for closer := range s.conns {
+		if listener, ok := closer.(net.Listener); ok {
+			err = errors.Join(err, listener.Close())
+			delete(s.conns, closer)
+		}
+	}
+	s.mutex.Unlock()
+	done := make(chan struct{})
+	go func() {
+		s.handling.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		err = errors.Join(err, ctx.Err())
+	}
+	return errors.Join(err, s.Close())
+}
+
+// track records the connection or listener so it is closed with the server.
+func (s *Server) track(closer io.Closer) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.closed {
+		return false
+	}
+	s.conns[closer] = struct{}{}
+	return true
+}
+
+// untrack forgets a closed connection or listener.
+func (s *Server) untrack(closer io.Closer) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.conns, closer)
+}
+
+// startHandling records a request about to be handled, reporting false if
+// the server is closed or shutting down.
+func (s *Server) startHandling() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.closed {
+		return false
+	}
+	s.handling.Add(1)
+	return true
+}
+
+// secret returns the shared secret of the client with the IP address, or nil
+// if it is not a known client.
+func (s *Server) secret(ip net.IP) []byte {
+	if s.config.Secret == nil {
+		return nil
+	}
+	return s.config.Secret(ip)
+}
+
+// isClosed reports whether the server has been closed.
+func (s *Server) isClosed() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.closed
+}
+
+// receive verifies a request and dispatches it to its handler, or answers a
+// retransmission with the response already sent.
+func (s *Server) receive(request radius.Message, source client, write func(bytes []byte) error) {
+	if !s.verify(request, source.secret) {
+		return
+	}
+	handler := s.handler(request.Code)
+	if handler == nil {
+		return
+	}
+	if !s.startHandling() {
+		return
+	}
+	key := dedupKey{source.address.String(), request.Identifier, request.Authenticator}
+	if response, ok := s.config.Dedup.begin(key); !ok {
+		s.handling.Done()
+		if response != nil {
+			write(response)
+		}
+		return
+	}
+	writer := &responseWriter{write: write, secret: source.secret, request: request, dedup: s.config.Dedup, key: key}
+	ctx := context.WithValue(s.ctx, clientKey{}, source)
+	go func() {
+		defer s.handling.Done()
+		handler(ctx, writer, request)
+	}()
+}
+
+// verify reports whether the authenticators of the request are correct.
+func (s *Server) verify(request radius.Message, secret []byte) bool {
+	switch request.Code {
+	case radius.CodeAccessRequest:
+		return request.VerifyMessageAuthenticator(secret, s.config.RequireMessageAuthenticator) == nil
+	case radius.CodeStatusServer:
+		return request.VerifyMessageAuthenticator(secret, true) == nil
+	case radius.CodeAccountingRequest, radius.CodeDisconnectRequest, radius.CodeCoARequest:
+		return request.VerifyAccountingAuthenticator(secret) && request.VerifyMessageAuthenticator(secret, false) == nil
+	}
+	return false
+}
+
+// handler returns the handler for the code wrapped in the middleware, or nil
+// if none is registered.
+func (s *Server) handler(code radius.Code) Handler {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	handler, ok := s.handlers[code]
+	if !ok {
+		return nil
+	}
+	return chain(handler, s.middleware)
+}
+
+// responseWriter signs and writes the response to a request, remembering it
+// to answer retransmissions.
+type responseWriter struct {
+	write   func(bytes []byte) error
+	secret  []byte
+	request radius.Message
+	dedup   *DedupCache
+	key     dedupKey
+	mutex   sync.Mutex
+	written bool
+}
+
+// WriteResponse signs and writes the response.
+func (w *responseWriter) WriteResponse(response radius.Message) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.written {
+		return ErrResponseWritten
+	}
+	w.written = true
+	response = response.Clone()
+	response.Identifier = w.request.Identifier
+	if response.Avps.GetFirst(radius.AttributeMessageAuthenticator, 0) != nil ||
+		w.request.Avps.GetFirst(radius.AttributeMessageAuthenticator, 0) != nil {
+		response.AddResponseMessageAuthenticator(w.request.Authenticator, w.secret)
+	}
+	response.SetResponseAuthenticator(w.request.Authenticator, w.secret)
+	bytes := response.ToBytes()
+	w.dedup.store(w.key, bytes)
+	return w.write(bytes)
+}
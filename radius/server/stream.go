@@ -0,0 +1,110 @@
+package server
+
+import (
+	"bufio"
+	"crypto/tls"
+	"net"
+	"sync"
+
+	"github.com/tinybluerobots/radius-diameter-message/radius"
+)
+
+// ListenAndServeTLS listens on the TCP address for RadSec connections,
+// authenticated with the TLS configuration, and serves requests from them.
+func (s *Server) ListenAndServeTLS(address string, tlsConfig *tls.Config) error {
+	listener, err := tls.Listen("tcp", address, tlsConfig)
+	if err != nil {
+		return err
+	}
+	return s.ServeStream(listener)
+}
+
+// ListenAndServeTCP listens on the TCP address for RADIUS over TCP
+// connections, as RFC 6613 describes, and serves requests from them.
+func (s *Server) ListenAndServeTCP(address string) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	return s.ServeStream(listener)
+}
+
+// ServeStream accepts connections from the listener until the server is
+// closed, serving each in its own goroutine. Requests on a TLS connection
+// are verified with the shared secret RFC 6614 fixes and its client is
+// identified by its certificate; requests on a TCP connection are verified
+// with the shared secret of its client's IP address, as RFC 6613 describes.
+func (s *Server) ServeStream(listener net.Listener) error {
+	if !s.track(listener) {
+		listener.Close()
+		return ErrServerClosed
+	}
+	defer s.untrack(listener)
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if s.isClosed() {
+				return ErrServerClosed
+			}
+			return err
+		}
+		go s.serveConn(conn)
+	}
+}
+
+// serveConn identifies the client of a stream connection and reads requests
+// from it until it closes.
+func (s *Server) serveConn(conn net.Conn) {
+	if !s.track(conn) {
+		conn.Close()
+		return
+	}
+	defer func() {
+		conn.Close()
+		s.untrack(conn)
+	}()
+	source, ok := s.identify(conn)
+	if !ok {
+		return
+	}
+	var writeMutex sync.Mutex
+	write := func(bytes []byte) error {
+		writeMutex.Lock()
+		defer writeMutex.Unlock()
+		_, err := conn.Write(bytes)
+		return err
+	}
+	reader := bufio.NewReader(conn)
+	for {
+		request, err := radius.ReadMessageFrom(reader)
+		if err != nil {
+			return
+		}
+		s.receive(*request, source, write)
+	}
+}
+
+// identify returns the client of a stream connection, reporting false if it
+// is not a known or authorized client.
+func (s *Server) identify(conn net.Conn) (client, bool) {
+	source := client{address: conn.RemoteAddr()}
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		if err := tlsConn.HandshakeContext(s.ctx); err != nil {
+			return client{}, false
+		}
+		if certificates := tlsConn.ConnectionState().PeerCertificates; len(certificates) > 0 {
+			source.certificate = certificates[0]
+		}
+		if s.config.AuthorizeCertificate != nil && (source.certificate == nil || !s.config.AuthorizeCertificate(source.certificate)) {
+			return client{}, false
+		}
+		source.secret = radius.RadSecSecret
+		return source, true
+	}
+	tcp, ok := conn.RemoteAddr().(*net.TCPAddr)
+	if !ok {
+		return client{}, false
+	}
+	source.secret = s.secret(tcp.IP)
+	return source, source.secret != nil
+}
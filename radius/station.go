@@ -0,0 +1,139 @@
+package radius
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// MACFormat selects how a StationId writes its MAC address.
+type MACFormat byte
+
+const (
+	// MACHyphen writes AA-BB-CC-DD-EE-FF, as RFC 3580 recommends.
+	MACHyphen MACFormat = iota
+	// MACColon writes aa:bb:cc:dd:ee:ff.
+	MACColon
+	// MACDot writes aabb.ccdd.eeff.
+	MACDot
+	// MACBare writes aabbccddeeff.
+	MACBare
+)
+
+// StationId is a Called-Station-Id or Calling-Station-Id holding the MAC
+// address of an access point or client, and for an access point the SSID it
+// may follow with, as in "AA-BB-CC-DD-EE-FF:corp".
+type StationId struct {
+	MAC  net.HardwareAddr
+	SSID string
+}
+
+// ParseStationId parses a station ID in any of the MAC formats, in either
+// case, with an optional SSID after a colon.
+func ParseStationId(value string) (StationId, error) {
+	mac, ssid, err := parseMAC(value)
+	if err != nil {
+		return StationId{}, fmt.Errorf("invalid station ID %q", value)
+	}
+	return StationId{MAC: mac, SSID: ssid}, nil
+}
+
+// parseMAC parses the MAC address at the start of the value, returning it and
+// what follows the colon after it.
+func parseMAC(value string) (net.HardwareAddr, string, error) {
+	var mac net.HardwareAddr
+	var err error
+	var rest string
+	switch {
+	case len(value) >= 17 && (value[2] == '-' || value[2] == ':'):
+		mac, err = net.ParseMAC(value[:17])
+		rest = value[17:]
+	case len(value) >= 14 && value[4] == '.':
+		mac, err = net.ParseMAC(value[:14])
+		rest = value[14:]
+	case len(value) >= 12:
+		mac, err = hex.DecodeString(value[:12])
+		rest = value[12:]
+	default:
+		return nil, "", fmt.Errorf("invalid MAC address %q", value)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	if rest == "" {
+		return mac, "", nil
+	}
+	ssid, ok := strings.CutPrefix(rest, ":")
+	if !ok {
+		return nil, "", fmt.Errorf("invalid MAC address %q", value)
+	}
+	return mac, ssid, nil
+}
+
+// String returns the station ID in the format RFC 3580 recommends.
+func (s StationId) String() string {
+	return s.Format(MACHyphen)
+}
+
+// Format returns the station ID with its MAC address in the format, followed
+// by a colon and the SSID if it has one.
+func (s StationId) Format(format MACFormat) string {
+	digits := hex.EncodeToString(s.MAC)
+	var builder strings.Builder
+	for i := 0; i < len(digits); i += 2 {
+		switch {
+		case i == 0:
+		case format == MACHyphen:
+			builder.WriteByte('-')
+		case format == MACColon:
+			builder.WriteByte(':')
+		case format == MACDot && i%4 == 0:
+			builder.WriteByte('.')
+		}
+		builder.WriteString(digits[i : i+2])
+	}
+	value := builder.String()
+	if format == MACHyphen {
+		value = strings.ToUpper(value)
+	}
+	if s.SSID != "" {
+		value += ":" + s.SSID
+	}
+	return value
+}
+
+// NewAvpStationId creates a new Called-Station-Id or Calling-Station-Id AVP
+// in the format RFC 3580 recommends.
+func NewAvpStationId(attributeType AttributeType, value StationId) Avp {
+	return NewAvpString(attributeType, 0, value.String())
+}
+
+// AddStationId adds a new Called-Station-Id or Calling-Station-Id AVP to the slice.
+func (a Avps) AddStationId(attributeType AttributeType, value StationId) Avps {
+	return append(a, NewAvpStationId(attributeType, value))
+}
+
+// ToStationId converts the AVP to a station ID.
+func (a *Avp) ToStationId() *StationId {
+	value, ok := a.ToStationIdOk()
+	if !ok {
+		return nil
+	}
+	return &value
+}
+
+// ToStationIdOrDefault converts the AVP to a station ID or returns a default value.
+func (a *Avp) ToStationIdOrDefault() StationId {
+	value, _ := a.ToStationIdOk()
+	return value
+}
+
+// ToStationIdOk converts the AVP to a station ID, reporting whether the AVP is present and well formed.
+func (a *Avp) ToStationIdOk() (StationId, bool) {
+	if a == nil || a.Data == nil {
+		return StationId{}, false
+	}
+	value, err := ParseStationId(string(a.Data))
+	return value, err == nil
+}
@@ -0,0 +1,143 @@
+package radius
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+)
+
+// RadSecSecret is the shared secret RFC 6614 fixes for RADIUS over TLS,
+// whose security comes from TLS instead.
+var RadSecSecret = []byte("radsec")
+
+// DTLSSecret is the shared secret RFC 7360 fixes for RADIUS over DTLS.
+var DTLSSecret = []byte("radius/dtls")
+
+// ReadMessageFrom reads a single message from a stream such as a TCP or TLS
+// connection, using the Length field of the header to frame it as RFC 6613
+// describes. It returns io.EOF if the stream ends cleanly before the message
+// starts.
+func ReadMessageFrom(reader io.Reader) (*Message, error) {
+	bytes, _, err := readFrame(reader)
+	if err != nil {
+		return nil, err
+	}
+	return ReadMessage(bytes)
+}
+
+// ReadFrom reads a single message from a stream into the message, as
+// ReadMessageFrom does, returning the number of bytes read. It implements
+// io.ReaderFrom, though it stops at the end of the message rather than of
+// the stream.
+func (m *Message) ReadFrom(reader io.Reader) (int64, error) {
+	bytes, n, err := readFrame(reader)
+	if err != nil {
+		return int64(n), err
+	}
+	message, err := ReadMessage(bytes)
+	if err != nil {
+		return int64(n), err
+	}
+	*m = *message
+	return int64(n), nil
+}
+
+// readFrame reads the bytes of a single message framed by its Length field,
+// returning them and the number of bytes read.
+func readFrame(reader io.Reader) ([]byte, int, error) {
+	header := make([]byte, 4)
+	n, err := io.ReadFull(reader, header)
+	if err != nil {
+		return nil, n, err
+	}
+	length := int(binary.BigEndian.Uint16(header[2:4]))
+	if length < 20 || length > maxMessageLength {
+		return nil, n, &InvalidLengthError{Declared: length, Available: n}
+	}
+	bytes := make([]byte, length)
+	copy(bytes, header)
+	read, err := io.ReadFull(reader, bytes[4:])
+	n += read
+	if err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, n, err
+	}
+	return bytes, n, nil
+}
+
+// WriteTo writes the message to a stream such as a connection, as a vector
+// of its header and the values of its attributes rather than encoded into
+// one byte slice, returning the number of bytes written. It implements
+// io.WriterTo.
+func (m Message) WriteTo(writer io.Writer) (int64, error) {
+	headers := m.appendHeader(make([]byte, 0, 20+8*len(m.Avps)))
+	buffers := make(net.Buffers, 1, 1+2*len(m.Avps))
+	buffers[0] = headers
+	for _, avp := range m.Avps {
+		start := len(headers)
+		if avp.Extended != 0 {
+			headers = avp.appendExtended(headers)
+			buffers = append(buffers, headers[start:])
+			continue
+		}
+		headers = avp.appendHeader(headers)
+		buffers = append(buffers, headers[start:], avp.Data)
+	}
+	return buffers.WriteTo(writer)
+}
+
+// EncodeAll appends the messages back to back to the byte slice and returns
+// the extended slice, so that a burst of messages can share one write to a
+// stream.
+func EncodeAll(messages []Message, dst []byte) []byte {
+	for _, message := range messages {
+		dst = message.AppendBytes(dst)
+	}
+	return dst
+}
+
+// ScanMessages is a bufio.SplitFunc framing the messages of a stream such as
+// a TCP or TLS connection by their Length field, for a bufio.Scanner. A
+// message split across reads is returned once the rest of it arrives. It
+// stops the scanner with an *InvalidLengthError at an invalid length, and
+// with io.ErrUnexpectedEOF if the stream ends inside a message.
+func ScanMessages(data []byte, atEOF bool) (int, []byte, error) {
+	if len(data) >= 4 {
+		length := int(binary.BigEndian.Uint16(data[2:4]))
+		if length < 20 || length > maxMessageLength {
+			return 0, nil, &InvalidLengthError{Declared: length, Available: len(data)}
+		}
+		if length <= len(data) {
+			return length, data[:length:length], nil
+		}
+	}
+	if atEOF && len(data) > 0 {
+		return 0, nil, io.ErrUnexpectedEOF
+	}
+	return 0, nil, nil
+}
+
+// SplitMessages frames the messages written back to back in a byte slice,
+// such as those read from a stream, using the Length field of each header.
+// It returns the complete messages, referring to the byte slice, and the
+// bytes of an incomplete message that follows them. It returns an error if a
+// header has an invalid length, along with the messages before it.
+func SplitMessages(bytes []byte) ([][]byte, []byte, error) {
+	var messages [][]byte
+	offset := 0
+	for len(bytes) >= 4 {
+		length := int(binary.BigEndian.Uint16(bytes[2:4]))
+		if length < 20 || length > maxMessageLength {
+			return messages, bytes, &InvalidLengthError{Offset: offset, Declared: length, Available: len(bytes)}
+		}
+		if length > len(bytes) {
+			break
+		}
+		messages = append(messages, bytes[:length:length])
+		bytes = bytes[length:]
+		offset += length
+	}
+	return messages, bytes, nil
+}
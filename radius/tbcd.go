@@ -0,0 +1,37 @@
+package radius
+
+import "github.com/tinybluerobots/radius-diameter-message/threegpp"
+
+// NewAvpTBCD creates a new attribute holding digits such as an IMSI or MSISDN
+// encoded as a TBCD string, returning an error if they are not TBCD digits.
+func NewAvpTBCD(attributeType AttributeType, vendorId VendorId, digits string) (Avp, error) {
+	data, err := threegpp.EncodeTBCD(digits)
+	if err != nil {
+		return Avp{}, err
+	}
+	return NewAvp(attributeType, vendorId, data), nil
+}
+
+// ToTBCD converts the AVP from a TBCD string to its digits.
+func (a *Avp) ToTBCD() *string {
+	value, ok := a.ToTBCDOk()
+	if !ok {
+		return nil
+	}
+	return &value
+}
+
+// ToTBCDOrDefault converts the AVP from a TBCD string to its digits or returns a default value.
+func (a *Avp) ToTBCDOrDefault() string {
+	value, _ := a.ToTBCDOk()
+	return value
+}
+
+// ToTBCDOk converts the AVP from a TBCD string to its digits, reporting whether the AVP is present and well formed.
+func (a *Avp) ToTBCDOk() (string, bool) {
+	if a == nil || a.Data == nil {
+		return "", false
+	}
+	value, err := threegpp.DecodeTBCD(a.Data)
+	return value, err == nil
+}
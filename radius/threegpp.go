@@ -0,0 +1,116 @@
+package radius
+
+import (
+	"net"
+
+	"github.com/tinybluerobots/radius-diameter-message/threegpp"
+)
+
+// Vendor types of the 3GPP vendor-specific attributes of TS 29.061.
+const (
+	Attribute3GPPIMSI                    AttributeType = 1
+	Attribute3GPPChargingId              AttributeType = 2
+	Attribute3GPPSGSNAddress             AttributeType = 6
+	Attribute3GPPChargingCharacteristics AttributeType = 13
+	Attribute3GPPIMEISV                  AttributeType = 20
+	Attribute3GPPUserLocationInfo        AttributeType = 22
+)
+
+// NewAvpIMSI creates a new 3GPP-IMSI AVP.
+func NewAvpIMSI(imsi string) Avp {
+	return NewAvpString(Attribute3GPPIMSI, Vendor3GPP, imsi)
+}
+
+// AddIMSI adds a new 3GPP-IMSI AVP to the slice.
+func (a Avps) AddIMSI(imsi string) Avps {
+	return append(a, NewAvpIMSI(imsi))
+}
+
+// IMSI retrieves the 3GPP-IMSI attribute value.
+func (a Avps) IMSI() *string {
+	return a.GetFirst(Attribute3GPPIMSI, Vendor3GPP).ToString()
+}
+
+// NewAvpChargingId creates a new 3GPP-Charging-ID AVP.
+func NewAvpChargingId(chargingId uint32) Avp {
+	return NewAvpUint32(Attribute3GPPChargingId, Vendor3GPP, chargingId)
+}
+
+// AddChargingId adds a new 3GPP-Charging-ID AVP to the slice.
+func (a Avps) AddChargingId(chargingId uint32) Avps {
+	return append(a, NewAvpChargingId(chargingId))
+}
+
+// ChargingId retrieves the 3GPP-Charging-ID attribute value.
+func (a Avps) ChargingId() *uint32 {
+	return a.GetFirst(Attribute3GPPChargingId, Vendor3GPP).ToUint32()
+}
+
+// NewAvpSGSNAddress creates a new 3GPP-SGSN-Address AVP.
+func NewAvpSGSNAddress(address net.IP) Avp {
+	return NewAvpIPv4(Attribute3GPPSGSNAddress, Vendor3GPP, address)
+}
+
+// AddSGSNAddress adds a new 3GPP-SGSN-Address AVP to the slice.
+func (a Avps) AddSGSNAddress(address net.IP) Avps {
+	return append(a, NewAvpSGSNAddress(address))
+}
+
+// SGSNAddress retrieves the 3GPP-SGSN-Address attribute value.
+func (a Avps) SGSNAddress() *net.IP {
+	return a.GetFirst(Attribute3GPPSGSNAddress, Vendor3GPP).ToIPv4()
+}
+
+// NewAvpIMEISV creates a new 3GPP-IMEISV AVP.
+func NewAvpIMEISV(imeisv string) Avp {
+	return NewAvpString(Attribute3GPPIMEISV, Vendor3GPP, imeisv)
+}
+
+// AddIMEISV adds a new 3GPP-IMEISV AVP to the slice.
+func (a Avps) AddIMEISV(imeisv string) Avps {
+	return append(a, NewAvpIMEISV(imeisv))
+}
+
+// IMEISV retrieves the 3GPP-IMEISV attribute value.
+func (a Avps) IMEISV() *string {
+	return a.GetFirst(Attribute3GPPIMEISV, Vendor3GPP).ToString()
+}
+
+// NewAvpUserLocationInfo creates a new 3GPP-User-Location-Info AVP,
+// returning an error if the location cannot be encoded.
+func NewAvpUserLocationInfo(value threegpp.UserLocationInfo) (Avp, error) {
+	data, err := threegpp.EncodeUserLocationInfo(value)
+	if err != nil {
+		return Avp{}, err
+	}
+	return NewAvp(Attribute3GPPUserLocationInfo, Vendor3GPP, data), nil
+}
+
+// UserLocationInfo retrieves the 3GPP-User-Location-Info attribute value.
+func (a Avps) UserLocationInfo() *threegpp.UserLocationInfo {
+	return a.GetFirst(Attribute3GPPUserLocationInfo, Vendor3GPP).ToUserLocationInfo()
+}
+
+// ToUserLocationInfo converts the AVP to a 3GPP user location.
+func (a *Avp) ToUserLocationInfo() *threegpp.UserLocationInfo {
+	value, ok := a.ToUserLocationInfoOk()
+	if !ok {
+		return nil
+	}
+	return &value
+}
+
+// ToUserLocationInfoOrDefault converts the AVP to a 3GPP user location or returns a default value.
+func (a *Avp) ToUserLocationInfoOrDefault() threegpp.UserLocationInfo {
+	value, _ := a.ToUserLocationInfoOk()
+	return value
+}
+
+// ToUserLocationInfoOk converts the AVP to a 3GPP user location, reporting whether the AVP is present and well formed.
+func (a *Avp) ToUserLocationInfoOk() (threegpp.UserLocationInfo, bool) {
+	if a == nil || a.Data == nil {
+		return threegpp.UserLocationInfo{}, false
+	}
+	value, err := threegpp.DecodeUserLocationInfo(a.Data)
+	return value, err == nil
+}
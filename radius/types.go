@@ -0,0 +1,293 @@
+package radius
+
+import (
+	"encoding/binary"
+	"net"
+)
+
+// InterfaceId is an 8 byte IPv6 interface identifier, the ifid data type.
+type InterfaceId [8]byte
+
+// NewAvpUint64 creates a new AVP with an integer64 value.
+func NewAvpUint64(attributeType AttributeType, vendorId VendorId, value uint64) Avp {
+	return NewAvp(attributeType, vendorId, binary.BigEndian.AppendUint64(nil, value))
+}
+
+// NewAvpIPv4 creates a new AVP with an ipaddr value. An address that is not
+// IPv4 gives an AVP with no data.
+func NewAvpIPv4(attributeType AttributeType, vendorId VendorId, value net.IP) Avp {
+	return NewAvp(attributeType, vendorId, avpData(value.To4()))
+}
+
+// NewAvpIPv6 creates a new AVP with an ipv6addr value. An IPv4 address is
+// written in its IPv4-mapped IPv6 form.
+func NewAvpIPv6(attributeType AttributeType, vendorId VendorId, value net.IP) Avp {
+	return NewAvp(attributeType, vendorId, avpData(value.To16()))
+}
+
+// NewAvpIPv6Prefix creates a new AVP with an ipv6prefix value: a reserved
+// byte, the prefix length and as many bytes of the prefix as the length
+// covers, with the bits beyond it cleared. An IPv4 prefix is written as the
+// matching IPv4-mapped IPv6 prefix.
+func NewAvpIPv6Prefix(attributeType AttributeType, vendorId VendorId, value net.IPNet) Avp {
+	ones := prefixLength(value.Mask, 128)
+	prefix := value.IP.To16().Mask(net.CIDRMask(ones, 128))
+	data := append([]byte{0, byte(ones)}, prefix[:(ones+7)/8]...)
+	return NewAvp(attributeType, vendorId, data)
+}
+
+// NewAvpIPv4Prefix creates a new AVP with an ipv4prefix value: a reserved
+// byte, the prefix length and the four bytes of the prefix, with the bits
+// beyond the length cleared. An IPv4-mapped IPv6 prefix is written as the
+// matching IPv4 prefix.
+func NewAvpIPv4Prefix(attributeType AttributeType, vendorId VendorId, value net.IPNet) Avp {
+	ones := prefixLength(value.Mask, 32)
+	prefix := value.IP.To4().Mask(net.CIDRMask(ones, 32))
+	return NewAvp(attributeType, vendorId, append([]byte{0, byte(ones)}, prefix...))
+}
+
+// prefixLength returns the length of the mask as a prefix of an address of
+// the given bits, converting between IPv4 and IPv4-mapped IPv6 masks. A
+// mask that is not a prefix gives zero.
+func prefixLength(mask net.IPMask, bits int) int {
+	ones, maskBits := mask.Size()
+	switch {
+	case maskBits == 32 && bits == 128:
+		ones += 96
+	case maskBits == 128 && bits == 32:
+		ones = max(ones-96, 0)
+	}
+	return ones
+}
+
+// NewAvpInterfaceId creates a new AVP with an ifid value.
+func NewAvpInterfaceId(attributeType AttributeType, vendorId VendorId, value InterfaceId) Avp {
+	return NewAvp(attributeType, vendorId, value[:])
+}
+
+// NewAvpTLV creates a new AVP with a tlv value holding the TLVs, each given
+// as an AVP whose type is the TLV type and whose vendor ID is ignored. TLVs
+// may be nested by passing AVPs created with NewAvpTLV.
+func NewAvpTLV(attributeType AttributeType, vendorId VendorId, tlvs ...Avp) Avp {
+	data := make([]byte, 0)
+	for _, tlv := range tlvs {
+		data = append(data, byte(tlv.Type), byte(len(tlv.Data)+2))
+		data = append(data, tlv.Data...)
+	}
+	return NewAvp(attributeType, vendorId, data)
+}
+
+// AddUint64 adds a new AVP with an integer64 value to the slice.
+func (a Avps) AddUint64(attributeType AttributeType, vendorId VendorId, value uint64) Avps {
+	return append(a, NewAvpUint64(attributeType, vendorId, value))
+}
+
+// AddIPv4 adds a new AVP with an ipaddr value to the slice.
+func (a Avps) AddIPv4(attributeType AttributeType, vendorId VendorId, value net.IP) Avps {
+	return append(a, NewAvpIPv4(attributeType, vendorId, value))
+}
+
+// AddIPv6 adds a new AVP with an ipv6addr value to the slice.
+func (a Avps) AddIPv6(attributeType AttributeType, vendorId VendorId, value net.IP) Avps {
+	return append(a, NewAvpIPv6(attributeType, vendorId, value))
+}
+
+// AddIPv6Prefix adds a new AVP with an ipv6prefix value to the slice.
+func (a Avps) AddIPv6Prefix(attributeType AttributeType, vendorId VendorId, value net.IPNet) Avps {
+	return append(a, NewAvpIPv6Prefix(attributeType, vendorId, value))
+}
+
+// AddIPv4Prefix adds a new AVP with an ipv4prefix value to the slice.
+func (a Avps) AddIPv4Prefix(attributeType AttributeType, vendorId VendorId, value net.IPNet) Avps {
+	return append(a, NewAvpIPv4Prefix(attributeType, vendorId, value))
+}
+
+// AddInterfaceId adds a new AVP with an ifid value to the slice.
+func (a Avps) AddInterfaceId(attributeType AttributeType, vendorId VendorId, value InterfaceId) Avps {
+	return append(a, NewAvpInterfaceId(attributeType, vendorId, value))
+}
+
+// AddTLV adds a new AVP with a tlv value to the slice.
+func (a Avps) AddTLV(attributeType AttributeType, vendorId VendorId, tlvs ...Avp) Avps {
+	return append(a, NewAvpTLV(attributeType, vendorId, tlvs...))
+}
+
+// ToUint64 converts the AVP to a uint64.
+func (a *Avp) ToUint64() *uint64 {
+	value, ok := a.ToUint64Ok()
+	if !ok {
+		return nil
+	}
+	return &value
+}
+
+// ToUint64OrDefault converts the AVP to a uint64 or returns a default value.
+func (a *Avp) ToUint64OrDefault() uint64 {
+	value, _ := a.ToUint64Ok()
+	return value
+}
+
+// ToUint64Ok converts the AVP to a uint64, reporting whether the AVP is present and well formed.
+func (a *Avp) ToUint64Ok() (uint64, bool) {
+	if a == nil || len(a.Data) < 8 {
+		return 0, false
+	}
+	return binary.BigEndian.Uint64(a.Data), true
+}
+
+// ToIPv4 converts the AVP to an IPv4 address.
+func (a *Avp) ToIPv4() *net.IP {
+	value, ok := a.ToIPv4Ok()
+	if !ok {
+		return nil
+	}
+	return &value
+}
+
+// ToIPv4OrDefault converts the AVP to an IPv4 address or returns a default value.
+func (a *Avp) ToIPv4OrDefault() net.IP {
+	value, _ := a.ToIPv4Ok()
+	return value
+}
+
+// ToIPv4Ok converts the AVP to an IPv4 address, reporting whether the AVP is
+// present and holds exactly four bytes.
+func (a *Avp) ToIPv4Ok() (net.IP, bool) {
+	if a == nil || len(a.Data) != net.IPv4len {
+		return nil, false
+	}
+	return net.IP(a.Data), true
+}
+
+// ToIPv6 converts the AVP to an IPv6 address.
+func (a *Avp) ToIPv6() *net.IP {
+	value, ok := a.ToIPv6Ok()
+	if !ok {
+		return nil
+	}
+	return &value
+}
+
+// ToIPv6OrDefault converts the AVP to an IPv6 address or returns a default value.
+func (a *Avp) ToIPv6OrDefault() net.IP {
+	value, _ := a.ToIPv6Ok()
+	return value
+}
+
+// ToIPv6Ok converts the AVP to an IPv6 address, reporting whether the AVP is
+// present and holds exactly sixteen bytes.
+func (a *Avp) ToIPv6Ok() (net.IP, bool) {
+	if a == nil || len(a.Data) != net.IPv6len {
+		return nil, false
+	}
+	return net.IP(a.Data), true
+}
+
+// ToIPv6Prefix converts the AVP to an IPv6 prefix.
+func (a *Avp) ToIPv6Prefix() *net.IPNet {
+	value, ok := a.ToIPv6PrefixOk()
+	if !ok {
+		return nil
+	}
+	return &value
+}
+
+// ToIPv6PrefixOrDefault converts the AVP to an IPv6 prefix or returns a default value.
+func (a *Avp) ToIPv6PrefixOrDefault() net.IPNet {
+	value, _ := a.ToIPv6PrefixOk()
+	return value
+}
+
+// ToIPv6PrefixOk converts the AVP to an IPv6 prefix, reporting whether the
+// AVP is present and well formed. Bits of the prefix beyond its length are
+// cleared.
+func (a *Avp) ToIPv6PrefixOk() (net.IPNet, bool) {
+	if a == nil || len(a.Data) < 2 || len(a.Data) > 2+net.IPv6len || a.Data[1] > 128 {
+		return net.IPNet{}, false
+	}
+	prefix := make(net.IP, net.IPv6len)
+	copy(prefix, a.Data[2:])
+	mask := net.CIDRMask(int(a.Data[1]), 128)
+	return net.IPNet{IP: prefix.Mask(mask), Mask: mask}, true
+}
+
+// ToIPv4Prefix converts the AVP to an IPv4 prefix.
+func (a *Avp) ToIPv4Prefix() *net.IPNet {
+	value, ok := a.ToIPv4PrefixOk()
+	if !ok {
+		return nil
+	}
+	return &value
+}
+
+// ToIPv4PrefixOrDefault converts the AVP to an IPv4 prefix or returns a default value.
+func (a *Avp) ToIPv4PrefixOrDefault() net.IPNet {
+	value, _ := a.ToIPv4PrefixOk()
+	return value
+}
+
+// ToIPv4PrefixOk converts the AVP to an IPv4 prefix, reporting whether the
+// AVP is present and well formed. Bits of the prefix beyond its length are
+// cleared.
+func (a *Avp) ToIPv4PrefixOk() (net.IPNet, bool) {
+	if a == nil || len(a.Data) != 2+net.IPv4len || a.Data[1] > 32 {
+		return net.IPNet{}, false
+	}
+	mask := net.CIDRMask(int(a.Data[1]), 32)
+	return net.IPNet{IP: net.IP(a.Data[2:]).Mask(mask), Mask: mask}, true
+}
+
+// ToInterfaceId converts the AVP to an interface identifier.
+func (a *Avp) ToInterfaceId() *InterfaceId {
+	value, ok := a.ToInterfaceIdOk()
+	if !ok {
+		return nil
+	}
+	return &value
+}
+
+// ToInterfaceIdOrDefault converts the AVP to an interface identifier or returns a default value.
+func (a *Avp) ToInterfaceIdOrDefault() InterfaceId {
+	value, _ := a.ToInterfaceIdOk()
+	return value
+}
+
+// ToInterfaceIdOk converts the AVP to an interface identifier, reporting whether the AVP is present and well formed.
+func (a *Avp) ToInterfaceIdOk() (InterfaceId, bool) {
+	if a == nil || len(a.Data) != 8 {
+		return InterfaceId{}, false
+	}
+	return InterfaceId(a.Data), true
+}
+
+// ToTLV converts the AVP to the TLVs of its tlv value, each an AVP with the
+// TLV type and the AVP's vendor ID, or nil if it is not well formed. Nested
+// TLVs are converted by calling ToTLV on them.
+func (a *Avp) ToTLV() Avps {
+	if a == nil {
+		return nil
+	}
+	tlvs, ok := readTLVs(a.Data, a.VendorId)
+	if !ok {
+		return nil
+	}
+	return tlvs
+}
+
+// readTLVs splits the data into type-length-value items, reporting whether
+// each is well formed and they fill the data.
+func readTLVs(data []byte, vendorId VendorId) (Avps, bool) {
+	avps := NewAvps()
+	for offset := 0; offset < len(data); {
+		if len(data)-offset < 2 {
+			return nil, false
+		}
+		length := int(data[offset+1])
+		if length < 2 || offset+length > len(data) {
+			return nil, false
+		}
+		avps = append(avps, NewAvp(AttributeType(data[offset]), vendorId, data[offset+2:offset+length]))
+		offset += length
+	}
+	return avps, true
+}
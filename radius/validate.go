@@ -0,0 +1,141 @@
+package radius
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+)
+
+// Problem describes one way the bytes of a message break RFC 2865 or the
+// RFCs extending it, at the offset of the field concerned.
+type Problem struct {
+	Offset int
+	Reason string
+}
+
+// Error returns the reason for the problem and its offset.
+func (p Problem) Error() string {
+	return fmt.Sprintf("%s at offset %d", p.Reason, p.Offset)
+}
+
+// ValidateBytes walks the bytes of a message and returns every problem it
+// finds, rather than stopping at the first as ReadMessage does, which helps
+// when debugging a broken encoder: the code, the Length field, the length of
+// every attribute and the fragments of extended attributes. The dictionary,
+// which may be nil, adds checks of the size of the values it knows,
+// including those of Vendor-Specific sub-attributes. An attribute whose
+// length runs past the message ends the walk. It returns nil for a well
+// formed message.
+func ValidateBytes(bytes []byte, dictionary *Dictionary) []Problem {
+	v := &validator{dictionary: dictionary}
+	if len(bytes) < 20 {
+		v.report(0, "truncated header")
+		return v.problems
+	}
+	if code := Code(bytes[0]); !code.Known() {
+		v.report(0, "unknown code %d", code)
+	}
+	length := int(binary.BigEndian.Uint16(bytes[2:4]))
+	end := len(bytes)
+	switch {
+	case length < 20 || length > maxMessageLength:
+		v.report(2, "invalid message length %d", length)
+	case length > len(bytes):
+		v.report(2, "message length %d exceeds %d bytes", length, len(bytes))
+	default:
+		end = length
+	}
+	v.attributes(bytes[:end])
+	return v.problems
+}
+
+// fixedSizes holds the size of the values of each fixed size data type.
+var fixedSizes = map[DataType]int{
+	Integer:             4,
+	Enum:                4,
+	Time:                4,
+	IPv4Addr:            4,
+	IPv6Addr:            16,
+	IPv4Prefix:          6,
+	Integer64:           8,
+	InterfaceIdentifier: 8,
+}
+
+// validator collects the problems found walking a message.
+type validator struct {
+	dictionary *Dictionary
+	problems   []Problem
+}
+
+// report adds a problem at the offset.
+func (v *validator) report(offset int, format string, args ...any) {
+	v.problems = append(v.problems, Problem{Offset: offset, Reason: fmt.Sprintf(format, args...)})
+}
+
+// attributes checks the attributes following the header.
+func (v *validator) attributes(bytes []byte) {
+	for offset := 20; offset < len(bytes); {
+		if len(bytes)-offset < 2 {
+			v.report(offset, "truncated attribute header")
+			return
+		}
+		attributeType := AttributeType(bytes[offset])
+		length := int(bytes[offset+1])
+		if length < 2 || offset+length > len(bytes) {
+			v.report(offset+1, "invalid attribute length %d", length)
+			return
+		}
+		if isExtended(attributeType) {
+			_, next, err := readExtended(bytes[20:], offset-20, 20)
+			var attributeError *AttributeError
+			if errors.As(err, &attributeError) {
+				v.report(attributeError.Offset, "%s", attributeError.Reason)
+				return
+			}
+			offset = next + 20
+			continue
+		}
+		data := bytes[offset+2 : offset+length]
+		if attributeType == 26 && len(data) >= 6 {
+			if _, ok := vendorAttributes(data); ok {
+				v.subAttributes(VendorId(binary.BigEndian.Uint32(data[0:4])), data[4:], offset+6)
+			}
+		} else {
+			v.value(attributeType, 0, data, offset)
+		}
+		offset += length
+	}
+}
+
+// subAttributes checks the well formed sub-attributes of a Vendor-Specific
+// attribute, whose first is at the offset.
+func (v *validator) subAttributes(vendorId VendorId, data []byte, offset int) {
+	for i := 0; i < len(data); {
+		length := int(data[i+1])
+		v.value(AttributeType(data[i]), vendorId, data[i+2:i+length], offset+i)
+		i += length
+	}
+}
+
+// value checks the size of the value of an attribute at the offset against
+// its data type.
+func (v *validator) value(attributeType AttributeType, vendorId VendorId, data []byte, offset int) {
+	if vendorId == 0 && attributeType == AttributeMessageAuthenticator && len(data) != 16 {
+		v.report(offset, "Message-Authenticator has %d bytes", len(data))
+	}
+	definition := v.dictionary.Attribute(attributeType, vendorId)
+	if definition == nil {
+		return
+	}
+	switch size, ok := fixedSizes[definition.DataType]; {
+	case ok && len(data) != size:
+		v.report(offset, "%s attribute %s has %d bytes", definition.DataType, definition.Name, len(data))
+	case definition.DataType == IPv6Prefix && (len(data) < 2 || len(data) > 18):
+		v.report(offset, "%s attribute %s has %d bytes", definition.DataType, definition.Name, len(data))
+	case (definition.DataType == String || definition.DataType == Text) && len(data) == 0:
+		v.report(offset, "%s attribute %s is empty", definition.DataType, definition.Name)
+	case definition.DataType == Text && !utf8.Valid(data):
+		v.report(offset, "text attribute %s is not valid UTF-8", definition.Name)
+	}
+}
@@ -0,0 +1,46 @@
+package radius
+
+import "encoding/binary"
+
+// NewAvpVendorSpecific creates a Vendor-Specific attribute that carries
+// several sub-attributes of the vendor under one header. The sub-attributes
+// are written with their own types and data; their vendor IDs are ignored.
+// Reading the message back yields one AVP per sub-attribute.
+func NewAvpVendorSpecific(vendorId VendorId, subAttributes ...Avp) Avp {
+	data := binary.BigEndian.AppendUint32(make([]byte, 0, 4), uint32(vendorId))
+	for _, subAttribute := range subAttributes {
+		data = append(data, byte(subAttribute.Type), byte(len(subAttribute.Data)+2))
+		data = append(data, subAttribute.Data...)
+	}
+	return NewAvp(26, 0, data)
+}
+
+// AddVendorSpecific adds a Vendor-Specific attribute grouping the sub-attributes to the slice.
+func (a Avps) AddVendorSpecific(vendorId VendorId, subAttributes ...Avp) Avps {
+	return append(a, NewAvpVendorSpecific(vendorId, subAttributes...))
+}
+
+// VendorAttributes returns the sub-attributes of a Vendor-Specific attribute
+// kept as attribute 26, such as one built with NewAvpVendorSpecific, or nil if
+// it is not one or its contents are not well formed sub-attributes.
+func (a *Avp) VendorAttributes() Avps {
+	if a == nil || a.Type != 26 || a.VendorId != 0 {
+		return nil
+	}
+	avps, ok := vendorAttributes(a.Data)
+	if !ok {
+		return nil
+	}
+	return avps
+}
+
+// vendorAttributes splits the data of a Vendor-Specific attribute into the
+// vendor's sub-attributes, reporting whether the data is a vendor ID followed
+// by one or more well formed sub-attributes.
+func vendorAttributes(data []byte) (Avps, bool) {
+	if len(data) < 6 {
+		return nil, false
+	}
+	vendorId := VendorId(binary.BigEndian.Uint32(data[0:4]))
+	return readTLVs(data[4:], vendorId)
+}
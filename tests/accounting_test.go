@@ -0,0 +1,60 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tinybluerobots/radius-diameter-message/diameter"
+	"github.com/tinybluerobots/radius-diameter-message/diameter/accounting"
+)
+
+func Test_accounting_messages(t *testing.T) {
+	acr := accounting.ACR{
+		SessionId:        "cscf.example.com;1",
+		OriginHost:       "cscf.example.com",
+		OriginRealm:      "example.com",
+		DestinationRealm: "example.com",
+		RecordType:       accounting.InterimRecord,
+		RecordNumber:     2,
+		RealtimeRequired: accounting.GrantAndStore,
+		EventTimestamp:   time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+	request := acr.ToMessage([4]byte{0, 0, 0, 1}, [4]byte{0, 0, 0, 2})
+	assert.Equal(t, diameter.ApplicationBaseAccounting, request.ApplicationId)
+	decoded := accounting.ACR{}
+	assert.NoError(t, decoded.FromMessage(request))
+	assert.True(t, acr.EventTimestamp.Equal(decoded.EventTimestamp))
+	assert.Equal(t, diameter.ApplicationBaseAccounting, decoded.AcctApplicationId)
+	assert.Equal(t, accounting.InterimRecord, decoded.RecordType)
+	assert.Equal(t, uint32(2), decoded.RecordNumber)
+	assert.Equal(t, "INTERIM_RECORD", decoded.RecordType.String())
+	assert.True(t, decoded.RealtimeRequired.StoreOnFailure())
+	assert.True(t, decoded.RealtimeRequired.GrantOnFailure())
+	assert.False(t, accounting.DeliverAndGrant.GrantOnFailure())
+
+	aca := accounting.NewACA(decoded, diameter.ResultCodeSuccess, "ofcs.example.com", "example.com")
+	decodedAca := accounting.ACA{}
+	assert.NoError(t, decodedAca.FromMessage(aca.ToMessage(request)))
+	assert.Equal(t, aca, decodedAca)
+	assert.Error(t, decodedAca.FromMessage(request))
+}
+
+func Test_accounting_sequencer(t *testing.T) {
+	sequencer := accounting.NewSequencer()
+	acr := accounting.ACR{SessionId: "s", RecordType: accounting.InterimRecord}
+	assert.ErrorIs(t, sequencer.Sequence(&acr), accounting.ErrSessionNotStarted)
+
+	for i, recordType := range []accounting.RecordType{accounting.StartRecord, accounting.InterimRecord, accounting.InterimRecord, accounting.StopRecord} {
+		acr.RecordType = recordType
+		assert.NoError(t, sequencer.Sequence(&acr))
+		assert.Equal(t, uint32(i), acr.RecordNumber)
+	}
+	assert.False(t, sequencer.Active("s"))
+
+	_, err := sequencer.Next("t", accounting.StartRecord)
+	assert.NoError(t, err)
+	assert.True(t, sequencer.Active("t"))
+	_, err = sequencer.Next("t", accounting.EventRecord)
+	assert.ErrorIs(t, err, accounting.ErrSessionStarted)
+}
@@ -0,0 +1,84 @@
+package tests
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tinybluerobots/radius-diameter-message/diameter"
+	"github.com/tinybluerobots/radius-diameter-message/diameter/archive"
+)
+
+// recordingDriver is a database/sql driver and connector that records
+// executed statements, opened with sql.OpenDB so that it is not registered.
+type recordingDriver struct {
+	statements []string
+	args       [][]driver.Value
+}
+
+func (d *recordingDriver) Open(string) (driver.Conn, error) { return recordingConn{d}, nil }
+func (d *recordingDriver) Connect(context.Context) (driver.Conn, error) {
+	return recordingConn{d}, nil
+}
+func (d *recordingDriver) Driver() driver.Driver { return d }
+
+type recordingConn struct{ driver *recordingDriver }
+
+func (c recordingConn) Prepare(query string) (driver.Stmt, error) {
+	return recordingStmt{c.driver, query}, nil
+}
+func (c recordingConn) Close() error              { return nil }
+func (c recordingConn) Begin() (driver.Tx, error) { return recordingTx{}, nil }
+
+type recordingTx struct{}
+
+func (recordingTx) Commit() error   { return nil }
+func (recordingTx) Rollback() error { return nil }
+
+type recordingStmt struct {
+	driver *recordingDriver
+	query  string
+}
+
+func (s recordingStmt) Close() error  { return nil }
+func (s recordingStmt) NumInput() int { return -1 }
+func (s recordingStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.driver.statements = append(s.driver.statements, s.query)
+	s.driver.args = append(s.driver.args, args)
+	return driver.RowsAffected(1), nil
+}
+func (s recordingStmt) Query([]driver.Value) (driver.Rows, error) { return nil, driver.ErrSkip }
+
+func Test_archive_sql_sink(t *testing.T) {
+	recorder := &recordingDriver{}
+	db := sql.OpenDB(recorder)
+	defer db.Close()
+	message := diameter.NewMessage(1, requestFlags, 272, 4, [4]byte{0, 0, 0, 1}, [4]byte{0, 0, 0, 2},
+		diameter.NewAvpString(diameter.AvpSessionId, mandatoryFlags, 0, "session"),
+		diameter.NewAvpString(diameter.AvpOriginHost, mandatoryFlags, 0, "client.example.com"),
+	)
+	record, err := archive.NewRecord(message, time.Unix(0, 0), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "session", record.SessionId)
+	assert.Equal(t, uint32(1), record.HopByHopId)
+	assert.True(t, strings.HasPrefix(record.Message, `{"version":1`))
+
+	_, err = archive.NewSQLSink(db, "messages; DROP TABLE x", archive.QuestionPlaceholder)
+	assert.Error(t, err)
+	sink, err := archive.NewSQLSink(db, "messages", archive.DollarPlaceholder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	assert.NoError(t, sink.CreateTable(ctx))
+	assert.NoError(t, sink.Write(ctx, record))
+	assert.True(t, strings.HasPrefix(recorder.statements[0], "CREATE TABLE IF NOT EXISTS messages (timestamp TIMESTAMP"))
+	assert.True(t, strings.HasSuffix(recorder.statements[1], "VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)"))
+	assert.Equal(t, "client.example.com", recorder.args[1][7])
+}
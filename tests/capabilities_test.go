@@ -0,0 +1,53 @@
+package tests
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tinybluerobots/radius-diameter-message/diameter"
+	"github.com/tinybluerobots/radius-diameter-message/diameter/capabilities"
+)
+
+func Test_capabilities_exchange(t *testing.T) {
+	cer := capabilities.CER{Capabilities: capabilities.Capabilities{
+		OriginHost:         "client.example.com",
+		OriginRealm:        "example.com",
+		HostIPAddresses:    []net.IP{net.IPv4(10, 0, 0, 1).To4()},
+		VendorId:           10415,
+		ProductName:        "client",
+		AuthApplicationIds: []diameter.ApplicationId{diameter.ApplicationCreditControl},
+		VendorSpecificApplicationIds: []capabilities.VendorSpecificApplicationId{
+			{VendorId: 10415, AuthApplicationId: diameter.ApplicationGx},
+		},
+		FirmwareRevision: 1,
+	}}
+	message := cer.ToMessage([4]byte{0, 0, 0, 1}, [4]byte{0, 0, 0, 2})
+	decoded, err := diameter.ReadMessage(message.ToBytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var received capabilities.CER
+	assert.NoError(t, received.FromMessage(*decoded))
+	assert.Equal(t, cer, received)
+
+	local := capabilities.Capabilities{
+		OriginHost:         "server.example.com",
+		OriginRealm:        "example.com",
+		AuthApplicationIds: []diameter.ApplicationId{diameter.ApplicationGx, diameter.ApplicationRx},
+	}
+	assert.Equal(t, []diameter.ApplicationId{diameter.ApplicationGx}, capabilities.Negotiate(local, received.Capabilities).Auth)
+	cea := capabilities.Answer(local, received)
+	assert.Equal(t, diameter.ResultCodeSuccess, cea.ResultCode)
+	answer := cea.ToMessage(*decoded)
+	assert.Equal(t, decoded.HopByHopId, answer.HopByHopId)
+	var receivedCEA capabilities.CEA
+	assert.NoError(t, receivedCEA.FromMessage(answer))
+	assert.Equal(t, "server.example.com", receivedCEA.OriginHost)
+	assert.Error(t, receivedCEA.FromMessage(*decoded))
+
+	local.AuthApplicationIds = []diameter.ApplicationId{diameter.ApplicationS6a}
+	assert.Equal(t, diameter.ResultCodeNoCommonApplication, capabilities.Answer(local, received).ResultCode)
+	local.AuthApplicationIds = []diameter.ApplicationId{diameter.ApplicationRelay}
+	assert.Len(t, capabilities.Negotiate(local, received.Capabilities).Auth, 2)
+}
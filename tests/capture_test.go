@@ -0,0 +1,252 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tinybluerobots/radius-diameter-message/capture"
+	"github.com/tinybluerobots/radius-diameter-message/diameter"
+	"github.com/tinybluerobots/radius-diameter-message/radius"
+)
+
+// ipv4Frame wraps a transport layer payload in Ethernet and IPv4 headers.
+func ipv4Frame(protocol byte, source string, destination string, payload []byte) []byte {
+	frame := make([]byte, 34, 34+len(payload))
+	binary.BigEndian.PutUint16(frame[12:14], 0x0800)
+	ip := frame[14:]
+	ip[0] = 0x45
+	binary.BigEndian.PutUint16(ip[2:4], uint16(20+len(payload)))
+	ip[8], ip[9] = 64, protocol
+	copy(ip[12:16], netip.MustParseAddr(source).AsSlice())
+	copy(ip[16:20], netip.MustParseAddr(destination).AsSlice())
+	return append(frame, payload...)
+}
+
+// udpPayload prefixes a payload with a UDP header.
+func udpPayload(source uint16, destination uint16, payload []byte) []byte {
+	header := make([]byte, 8)
+	binary.BigEndian.PutUint16(header[0:2], source)
+	binary.BigEndian.PutUint16(header[2:4], destination)
+	binary.BigEndian.PutUint16(header[4:6], uint16(8+len(payload)))
+	return append(header, payload...)
+}
+
+// tcpPayload prefixes a payload with a TCP header.
+func tcpPayload(source uint16, destination uint16, sequence uint32, flags byte, payload []byte) []byte {
+	header := make([]byte, 20)
+	binary.BigEndian.PutUint16(header[0:2], source)
+	binary.BigEndian.PutUint16(header[2:4], destination)
+	binary.BigEndian.PutUint32(header[4:8], sequence)
+	header[12], header[13] = 5<<4, flags
+	return append(header, payload...)
+}
+
+// sctpPayload prefixes DATA chunks, each carrying a fragment with its B and
+// E flags, with an SCTP common header.
+func sctpPayload(source uint16, destination uint16, fragments [][]byte, flags []byte) []byte {
+	packet := make([]byte, 12)
+	binary.BigEndian.PutUint16(packet[0:2], source)
+	binary.BigEndian.PutUint16(packet[2:4], destination)
+	for i, fragment := range fragments {
+		chunk := make([]byte, 16, 16+len(fragment)+3)
+		chunk[1] = flags[i]
+		binary.BigEndian.PutUint16(chunk[2:4], uint16(16+len(fragment)))
+		binary.BigEndian.PutUint32(chunk[12:16], 46)
+		chunk = append(chunk, fragment...)
+		for len(chunk)%4 != 0 {
+			chunk = append(chunk, 0)
+		}
+		packet = append(packet, chunk...)
+	}
+	return packet
+}
+
+// pcapFile writes Ethernet frames to a little-endian pcap file with
+// microsecond timestamps a second apart.
+func pcapFile(start time.Time, frames ...[]byte) []byte {
+	file := make([]byte, 24)
+	binary.LittleEndian.PutUint32(file[0:4], 0xa1b2c3d4)
+	binary.LittleEndian.PutUint16(file[4:6], 2)
+	binary.LittleEndian.PutUint16(file[6:8], 4)
+	binary.LittleEndian.PutUint32(file[16:20], 65535)
+	binary.LittleEndian.PutUint32(file[20:24], 1)
+	for i, frame := range frames {
+		record := make([]byte, 16)
+		timestamp := start.Add(time.Duration(i) * time.Second)
+		binary.LittleEndian.PutUint32(record[0:4], uint32(timestamp.Unix()))
+		binary.LittleEndian.PutUint32(record[4:8], uint32(timestamp.Nanosecond()/1000))
+		binary.LittleEndian.PutUint32(record[8:12], uint32(len(frame)))
+		binary.LittleEndian.PutUint32(record[12:16], uint32(len(frame)))
+		file = append(append(file, record...), frame...)
+	}
+	return file
+}
+
+// pcapngBlock encodes a big-endian pcapng block.
+func pcapngBlock(blockType uint32, body []byte) []byte {
+	for len(body)%4 != 0 {
+		body = append(body, 0)
+	}
+	block := make([]byte, 8, 12+len(body))
+	binary.BigEndian.PutUint32(block[0:4], blockType)
+	binary.BigEndian.PutUint32(block[4:8], uint32(12+len(body)))
+	block = append(block, body...)
+	return binary.BigEndian.AppendUint32(block, uint32(12+len(body)))
+}
+
+func Test_capture_pcap(t *testing.T) {
+	secret := []byte("secret")
+	request, _ := radius.AccessRequest{UserName: "nemo", UserPassword: []byte("arctangent")}.Build(secret)
+	ccr := diameter.NewMessage(1, requestFlags, 272, 4, [4]byte{0, 0, 0, 1}, [4]byte{0, 0, 0, 2},
+		diameter.NewAvpString(diameter.AvpSessionId, mandatoryFlags, 0, "session"))
+	cca := ccr.NewAnswer(diameter.NewAvpUint32(diameter.AvpResultCode, mandatoryFlags, 0, 2001))
+	ccrBytes, ccaBytes := ccr.ToBytes(), cca.ToBytes()
+
+	start := time.Date(2024, time.May, 15, 16, 50, 37, 0, time.UTC)
+	file := pcapFile(start,
+		ipv4Frame(17, "192.0.2.1", "192.0.2.2", udpPayload(50000, 1812, request.ToBytes())),
+		ipv4Frame(17, "192.0.2.1", "192.0.2.2", udpPayload(50000, 53, []byte{1, 2, 3})),
+		ipv4Frame(6, "192.0.2.1", "192.0.2.3", tcpPayload(40000, 3868, 99, 0x02, nil)),
+		ipv4Frame(6, "192.0.2.1", "192.0.2.3", tcpPayload(40000, 3868, 110, 0x18, ccrBytes[10:])),
+		ipv4Frame(6, "192.0.2.1", "192.0.2.3", tcpPayload(40000, 3868, 100, 0x18, ccrBytes[:10])),
+		ipv4Frame(6, "192.0.2.1", "192.0.2.3", tcpPayload(40000, 3868, 100, 0x18, ccrBytes[:10])),
+		ipv4Frame(6, "192.0.2.3", "192.0.2.1", tcpPayload(3868, 40000, 500, 0x18, append(ccaBytes, ccaBytes...))),
+	)
+	reader, err := capture.NewReader(bytes.NewReader(file), capture.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	packet, err := reader.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, capture.RADIUS, packet.Protocol)
+	assert.Equal(t, capture.TransportUDP, packet.Transport)
+	assert.Equal(t, start, packet.Timestamp)
+	assert.Equal(t, netip.MustParseAddrPort("192.0.2.1:50000"), packet.Source)
+	assert.Equal(t, netip.MustParseAddrPort("192.0.2.2:1812"), packet.Destination)
+	assert.Equal(t, "nemo", packet.RADIUS.Avps.GetFirst(radius.AttributeUserName, 0).ToStringOrDefault())
+
+	packet, err = reader.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, capture.Diameter, packet.Protocol)
+	assert.Equal(t, capture.TransportTCP, packet.Transport)
+	assert.Equal(t, start.Add(4*time.Second), packet.Timestamp)
+	assert.Equal(t, ccrBytes, packet.Diameter.ToBytes())
+
+	for range 2 {
+		packet, err = reader.Next()
+		assert.NoError(t, err)
+		assert.Equal(t, netip.MustParseAddrPort("192.0.2.3:3868"), packet.Source)
+		assert.Equal(t, ccaBytes, packet.Diameter.ToBytes())
+	}
+	_, err = reader.Next()
+	assert.Equal(t, io.EOF, err)
+
+	_, err = capture.NewReader(bytes.NewReader([]byte("not a capture file")), capture.Config{})
+	assert.ErrorIs(t, err, capture.ErrFormat)
+}
+
+func Test_capture_tcp_resync_oversized_length(t *testing.T) {
+	ccr := diameter.NewMessage(1, requestFlags, 272, 4, [4]byte{0, 0, 0, 1}, [4]byte{0, 0, 0, 2},
+		diameter.NewAvpString(diameter.AvpSessionId, mandatoryFlags, 0, "session"))
+	ccrBytes := ccr.ToBytes()
+	garbage := make([]byte, 20)
+	garbage[0], garbage[1], garbage[2], garbage[3] = 1, 0xff, 0xff, 0xff
+
+	file := pcapFile(time.Date(2024, time.May, 15, 16, 50, 37, 0, time.UTC),
+		ipv4Frame(6, "192.0.2.1", "192.0.2.3", tcpPayload(40000, 3868, 100, 0x18, garbage)),
+		ipv4Frame(6, "192.0.2.1", "192.0.2.3", tcpPayload(40000, 3868, 120, 0x18, ccrBytes)),
+	)
+	reader, err := capture.NewReader(bytes.NewReader(file), capture.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	packet, err := reader.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, ccrBytes, packet.Diameter.ToBytes())
+	_, err = reader.Next()
+	assert.Equal(t, io.EOF, err)
+}
+
+func Test_capture_pcapng_sctp(t *testing.T) {
+	ccr := diameter.NewMessage(1, requestFlags, 272, 4, [4]byte{0, 0, 0, 1}, [4]byte{0, 0, 0, 2},
+		diameter.NewAvpString(diameter.AvpSessionId, mandatoryFlags, 0, "session"))
+	data := ccr.ToBytes()
+
+	section := binary.BigEndian.AppendUint32(nil, 0x1a2b3c4d)
+	section = append(section, 0, 1, 0, 0, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff)
+	description := []byte{0, 1, 0, 0, 0, 0, 0xff, 0xff, 0, 9, 0, 1, 9, 0, 0, 0, 0, 0, 0, 0}
+	frame := ipv4Frame(132, "192.0.2.1", "192.0.2.3", sctpPayload(2905, 2905, [][]byte{data[:12], data[12:]}, []byte{0x02, 0x01}))
+	nanoseconds := uint64(time.Date(2024, time.May, 15, 16, 50, 37, 123456789, time.UTC).UnixNano())
+	packet := binary.BigEndian.AppendUint32(nil, 0)
+	packet = binary.BigEndian.AppendUint32(packet, uint32(nanoseconds>>32))
+	packet = binary.BigEndian.AppendUint32(packet, uint32(nanoseconds))
+	packet = binary.BigEndian.AppendUint32(packet, uint32(len(frame)))
+	packet = binary.BigEndian.AppendUint32(packet, uint32(len(frame)))
+	packet = append(packet, frame...)
+	file := append(append(pcapngBlock(0x0a0d0d0a, section), pcapngBlock(1, description)...), pcapngBlock(6, packet)...)
+
+	reader, err := capture.NewReader(bytes.NewReader(file), capture.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	captured, err := reader.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, capture.TransportSCTP, captured.Transport)
+	assert.Equal(t, int64(nanoseconds), captured.Timestamp.UnixNano())
+	assert.Equal(t, data, captured.Diameter.ToBytes())
+	_, err = reader.Next()
+	assert.Equal(t, io.EOF, err)
+}
+
+func Test_capture_writer(t *testing.T) {
+	secret := []byte("secret")
+	request, _ := radius.AccessRequest{UserName: "nemo"}.Build(secret)
+	ccr := diameter.NewMessage(1, requestFlags, 272, 4, [4]byte{0, 0, 0, 1}, [4]byte{0, 0, 0, 2},
+		diameter.NewAvpString(diameter.AvpSessionId, mandatoryFlags, 0, "session"))
+	cca := ccr.NewAnswer(diameter.NewAvpUint32(diameter.AvpResultCode, mandatoryFlags, 0, 2001))
+	timestamp := time.Date(2024, time.May, 15, 16, 50, 37, 123456789, time.UTC)
+	client, server := netip.MustParseAddrPort("192.0.2.1:40000"), netip.MustParseAddrPort("192.0.2.3:3868")
+	packets := []capture.Packet{
+		{Timestamp: timestamp, Source: netip.MustParseAddrPort("[2001:db8::1]:50000"), Destination: netip.MustParseAddrPort("[2001:db8::2]:1812"), RADIUS: &request},
+		{Timestamp: timestamp, Source: client, Destination: server, Diameter: &ccr},
+		{Timestamp: timestamp, Source: server, Destination: client, Diameter: &cca},
+		{Timestamp: timestamp, Source: client, Destination: server, Diameter: &ccr},
+		{Timestamp: timestamp, Source: client, Destination: server, Transport: capture.TransportSCTP, Diameter: &ccr},
+	}
+
+	var file bytes.Buffer
+	writer, err := capture.NewWriter(&file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, packet := range packets {
+		assert.NoError(t, writer.WritePacket(packet))
+	}
+	assert.Error(t, writer.WritePacket(capture.Packet{Source: client, Destination: netip.MustParseAddrPort("[2001:db8::2]:1812"), RADIUS: &request}))
+
+	reader, err := capture.NewReader(&file, capture.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range packets {
+		packet, err := reader.Next()
+		assert.NoError(t, err)
+		assert.Equal(t, timestamp, packet.Timestamp)
+		assert.Equal(t, expected.Source, packet.Source)
+		assert.Equal(t, expected.Destination, packet.Destination)
+		if expected.RADIUS != nil {
+			assert.Equal(t, capture.TransportUDP, packet.Transport)
+			assert.Equal(t, expected.RADIUS.ToBytes(), packet.RADIUS.ToBytes())
+		} else {
+			assert.Equal(t, expected.Diameter.ToBytes(), packet.Diameter.ToBytes())
+		}
+	}
+	_, err = reader.Next()
+	assert.Equal(t, io.EOF, err)
+}
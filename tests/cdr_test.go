@@ -0,0 +1,94 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tinybluerobots/radius-diameter-message/cdr"
+	"github.com/tinybluerobots/radius-diameter-message/diameter"
+	"github.com/tinybluerobots/radius-diameter-message/diameter/accounting"
+	"github.com/tinybluerobots/radius-diameter-message/radius"
+)
+
+func Test_cdr_radius_session(t *testing.T) {
+	var output bytes.Buffer
+	exporter := cdr.NewExporter(cdr.NewCSVWriter(&output))
+	start := time.Date(2024, time.May, 15, 16, 0, 0, 0, time.UTC)
+	secret := []byte("secret")
+	request := radius.AccountingRequest{
+		StatusType:       radius.AcctStart,
+		SessionId:        "0001",
+		UserName:         "alice",
+		NASIPAddress:     net.IPv4(192, 0, 2, 1),
+		FramedIPAddress:  net.IPv4(10, 0, 0, 1),
+		CallingStationId: "00-11-22-33-44-55",
+		EventTimestamp:   start,
+	}
+	assert.NoError(t, exporter.AddRADIUS(request.Build(secret)))
+	request.StatusType = radius.AcctInterimUpdate
+	request.InputOctets, request.OutputOctets = 1000, 5<<32
+	request.EventTimestamp = start.Add(5 * time.Minute)
+	assert.NoError(t, exporter.AddRADIUS(request.Build(secret)))
+	assert.NoError(t, exporter.AddRADIUS(radius.NewMessage(radius.CodeAccessRequest, 1, [16]byte{})))
+	assert.Equal(t, 1, exporter.Sessions())
+	assert.Empty(t, output.String())
+
+	request.StatusType = radius.AcctStop
+	request.InputOctets = 2000
+	request.SessionTime = 10 * time.Minute
+	request.TerminateCause = radius.TerminateUserRequest
+	request.EventTimestamp = start.Add(10 * time.Minute)
+	assert.NoError(t, exporter.AddRADIUS(request.Build(secret)))
+	assert.Equal(t, 0, exporter.Sessions())
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	assert.Len(t, lines, 2)
+	assert.True(t, strings.HasPrefix(lines[0], "protocol,session_id,user_name,nas,"))
+	assert.Equal(t, "radius,0001,alice,192.0.2.1,00-11-22-33-44-55,,10.0.0.1,2024-05-15T16:00:00Z,2024-05-15T16:10:00Z,600,2000,21474836480,0,0,USER_REQUEST,3,true", lines[1])
+}
+
+func Test_cdr_diameter_session(t *testing.T) {
+	var output bytes.Buffer
+	exporter := cdr.NewExporter(cdr.NewJSONWriter(&output))
+	start := time.Date(2024, time.May, 15, 16, 0, 0, 0, time.UTC)
+	acr := accounting.ACR{
+		SessionId:      "pgw;1;1",
+		OriginHost:     "pgw.example.com",
+		OriginRealm:    "example.com",
+		RecordType:     accounting.StartRecord,
+		UserName:       "bob",
+		EventTimestamp: start,
+	}
+	assert.NoError(t, exporter.AddDiameter(acr.ToMessage([4]byte{}, [4]byte{})))
+	acr.RecordType = accounting.StopRecord
+	acr.EventTimestamp = start.Add(90 * time.Second)
+	acr.Avps = diameter.NewAvps().
+		AddUint64(363, mandatoryFlags, 0, 4096).
+		AddUint64(364, mandatoryFlags, 0, 8192).
+		AddUint32(diameter.AvpTerminationCause, mandatoryFlags, 0, 11)
+	assert.NoError(t, exporter.AddDiameter(acr.ToMessage([4]byte{}, [4]byte{})))
+
+	acr = accounting.ACR{SessionId: "pgw;1;2", OriginHost: "pgw.example.com", RecordType: accounting.InterimRecord, EventTimestamp: start}
+	assert.NoError(t, exporter.AddDiameter(acr.ToMessage([4]byte{}, [4]byte{})))
+	assert.NoError(t, exporter.Flush())
+	assert.Equal(t, 0, exporter.Sessions())
+
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	assert.Len(t, lines, 2)
+	var record map[string]any
+	assert.NoError(t, json.Unmarshal([]byte(lines[0]), &record))
+	assert.Equal(t, "diameter", record["protocol"])
+	assert.Equal(t, "pgw.example.com", record["nas"])
+	assert.Equal(t, "2024-05-15T16:01:30Z", record["stop"])
+	assert.Equal(t, float64(90), record["duration"])
+	assert.Equal(t, float64(4096), record["inputOctets"])
+	assert.Equal(t, "USER_REQUEST", record["terminateCause"])
+	assert.Equal(t, true, record["complete"])
+	assert.NoError(t, json.Unmarshal([]byte(lines[1]), &record))
+	assert.Equal(t, "pgw;1;2", record["sessionId"])
+	assert.Equal(t, false, record["complete"])
+}
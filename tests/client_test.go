@@ -0,0 +1,351 @@
+package tests
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tinybluerobots/radius-diameter-message/diameter"
+	"github.com/tinybluerobots/radius-diameter-message/diameter/capabilities"
+	"github.com/tinybluerobots/radius-diameter-message/diameter/client"
+	"github.com/tinybluerobots/radius-diameter-message/diameter/disconnect"
+	"github.com/tinybluerobots/radius-diameter-message/diameter/metrics"
+	"github.com/tinybluerobots/radius-diameter-message/diameter/transaction"
+	"github.com/tinybluerobots/radius-diameter-message/diameter/watchdog"
+)
+
+var clientCapabilities = capabilities.Capabilities{
+	OriginHost:         "client.example.com",
+	OriginRealm:        "example.com",
+	ProductName:        "client",
+	AuthApplicationIds: []diameter.ApplicationId{diameter.ApplicationCreditControl},
+}
+
+var serverCapabilities = capabilities.Capabilities{
+	OriginHost:         "server.example.com",
+	OriginRealm:        "example.com",
+	ProductName:        "server",
+	AuthApplicationIds: []diameter.ApplicationId{diameter.ApplicationCreditControl},
+}
+
+// fakePeer accepts one connection, answers the CER, then answers every request
+// with DIAMETER_SUCCESS and passes every answer to the answers channel.
+func fakePeer(t *testing.T, local capabilities.Capabilities) (string, chan diameter.Message, chan net.Conn) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	answers := make(chan diameter.Message, 10)
+	conns := make(chan net.Conn, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		conns <- conn
+		reader := bufio.NewReader(conn)
+		for {
+			message, err := diameter.ReadMessageFrom(reader)
+			if err != nil {
+				conn.Close()
+				return
+			}
+			if !message.IsRequest() {
+				answers <- *message
+				continue
+			}
+			var answer diameter.Message
+			cer := capabilities.CER{}
+			if cer.FromMessage(*message) == nil {
+				answer = capabilities.Answer(local, cer).ToMessage(*message)
+			} else {
+				answer = message.NewAnswer(diameter.NewAvpUint32(diameter.AvpResultCode, mandatoryFlags, 0, uint32(diameter.ResultCodeSuccess)))
+			}
+			conn.Write(answer.ToBytes())
+		}
+	}()
+	return listener.Addr().String(), answers, conns
+}
+
+func Test_client_send_request(t *testing.T) {
+	address, _, _ := fakePeer(t, serverCapabilities)
+	c, err := client.Dial(context.Background(), address, client.Config{Capabilities: clientCapabilities})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	assert.Equal(t, "server.example.com", c.Peer().OriginHost)
+	assert.Equal(t, []diameter.ApplicationId{diameter.ApplicationCreditControl}, c.Applications().Auth)
+
+	results := make(chan diameter.Message, 5)
+	for range 5 {
+		go func() {
+			request := diameter.NewMessage(1, 0, diameter.CommandCreditControl, diameter.ApplicationCreditControl, [4]byte{}, [4]byte{})
+			answer, err := c.SendRequest(context.Background(), request)
+			assert.NoError(t, err)
+			results <- answer
+		}()
+	}
+	seen := map[[4]byte]bool{}
+	for range 5 {
+		answer := <-results
+		assert.False(t, answer.IsRequest())
+		assert.Equal(t, uint32(diameter.ResultCodeSuccess), answer.Avps.GetFirst(diameter.AvpResultCode, 0).ToUint32OrDefault())
+		seen[answer.HopByHopId] = true
+	}
+	assert.Len(t, seen, 5)
+}
+
+func Test_client_no_common_application(t *testing.T) {
+	server := serverCapabilities
+	server.AuthApplicationIds = []diameter.ApplicationId{diameter.ApplicationS6a}
+	address, _, _ := fakePeer(t, server)
+	_, err := client.Dial(context.Background(), address, client.Config{Capabilities: clientCapabilities})
+	assert.Error(t, err)
+}
+
+func Test_client_answers_peer_requests(t *testing.T) {
+	address, answers, conns := fakePeer(t, serverCapabilities)
+	c, err := client.Dial(context.Background(), address, client.Config{Capabilities: clientCapabilities})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	conn := <-conns
+
+	dwr := diameter.NewMessage(1, diameter.FlagRequest, diameter.CommandDeviceWatchdog, diameter.ApplicationCommon, [4]byte{0, 0, 0, 1}, [4]byte{0, 0, 0, 1},
+		diameter.NewAvpString(diameter.AvpOriginHost, mandatoryFlags, 0, "server.example.com"),
+		diameter.NewAvpString(diameter.AvpOriginRealm, mandatoryFlags, 0, "example.com"))
+	conn.Write(dwr.ToBytes())
+	dwa := <-answers
+	assert.Equal(t, diameter.CommandDeviceWatchdog, dwa.CommandCode)
+	assert.Equal(t, dwr.HopByHopId, dwa.HopByHopId)
+
+	rar := diameter.NewMessage(1, diameter.FlagRequest, diameter.CommandReAuth, diameter.ApplicationCreditControl, [4]byte{0, 0, 0, 2}, [4]byte{0, 0, 0, 2})
+	conn.Write(rar.ToBytes())
+	raa := <-answers
+	assert.True(t, raa.IsError())
+	assert.Equal(t, uint32(diameter.ResultCodeCommandUnsupported), raa.Avps.GetFirst(diameter.AvpResultCode, 0).ToUint32OrDefault())
+
+	dpr := disconnect.DPR{OriginHost: "server.example.com", OriginRealm: "example.com", DisconnectCause: disconnect.CauseRebooting}
+	conn.Write(dpr.ToMessage([4]byte{0, 0, 0, 3}, [4]byte{0, 0, 0, 3}).ToBytes())
+	dpa := <-answers
+	assert.True(t, disconnect.IsDPA(dpa))
+	select {
+	case <-c.Done():
+	case <-time.After(time.Second):
+		t.Fatal("client did not close after DPR")
+	}
+	_, err = c.SendRequest(context.Background(), diameter.NewMessage(1, 0, diameter.CommandCreditControl, diameter.ApplicationCreditControl, [4]byte{}, [4]byte{}))
+	assert.Error(t, err)
+}
+
+func Test_client_disconnect(t *testing.T) {
+	address, _, _ := fakePeer(t, serverCapabilities)
+	c, err := client.Dial(context.Background(), address, client.Config{Capabilities: clientCapabilities})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, c.Disconnect(context.Background(), disconnect.CauseRebooting))
+	assert.ErrorIs(t, c.Err(), client.ErrClosed)
+}
+
+func Test_client_send_async(t *testing.T) {
+	address, answers, _ := fakePeer(t, serverCapabilities)
+	c, err := client.Dial(context.Background(), address, client.Config{Capabilities: clientCapabilities})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	results := make([]<-chan client.Answer, 0, 3)
+	for range 3 {
+		result, err := c.SendAsync(diameter.NewMessage(1, 0, diameter.CommandCreditControl, diameter.ApplicationCreditControl, [4]byte{}, [4]byte{}))
+		assert.NoError(t, err)
+		results = append(results, result)
+	}
+	for _, result := range results {
+		answer := <-result
+		assert.NoError(t, answer.Err)
+		assert.Equal(t, uint32(diameter.ResultCodeSuccess), answer.Message.Avps.GetFirst(diameter.AvpResultCode, 0).ToUint32OrDefault())
+	}
+
+	rar := diameter.NewMessage(1, diameter.FlagRequest, diameter.CommandReAuth, diameter.ApplicationCreditControl, [4]byte{0, 0, 0, 5}, [4]byte{0, 0, 0, 5})
+	assert.Error(t, c.SendAnswer(rar))
+	assert.NoError(t, c.SendAnswer(rar.NewAnswer(diameter.NewAvpUint32(diameter.AvpResultCode, mandatoryFlags, 0, uint32(diameter.ResultCodeSuccess)))))
+	answer := <-answers
+	assert.Equal(t, [4]byte{0, 0, 0, 5}, answer.HopByHopId)
+}
+
+func Test_client_concurrent_send(t *testing.T) {
+	address, answers, _ := fakePeer(t, serverCapabilities)
+	c, err := client.Dial(context.Background(), address, client.Config{Capabilities: clientCapabilities, WriteQueue: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	padding := strings.Repeat("x", 1<<16)
+	sent := make(chan error, 20)
+	for i := range 20 {
+		go func() {
+			answer := diameter.NewMessage(1, 0, diameter.CommandReAuth, diameter.ApplicationCreditControl, [4]byte{byte(i)}, [4]byte{},
+				diameter.NewAvpString(diameter.AvpErrorMessage, 0, 0, padding))
+			sent <- c.Send(context.Background(), answer)
+		}()
+	}
+	seen := make(map[byte]bool)
+	for range 20 {
+		answer := <-answers
+		assert.Equal(t, padding, answer.Avps.GetFirst(diameter.AvpErrorMessage, 0).ToStringOrDefault())
+		seen[answer.HopByHopId[0]] = true
+		assert.NoError(t, <-sent)
+	}
+	assert.Len(t, seen, 20)
+}
+
+// pipeClient connects a client over a pipe to a peer that answers the CER,
+// returning the client and the peer's end of the pipe.
+func pipeClient(t *testing.T, config client.Config) (*client.Client, net.Conn) {
+	local, remote := net.Pipe()
+	t.Cleanup(func() { remote.Close() })
+	exchanged := make(chan struct{})
+	go func() {
+		defer close(exchanged)
+		message, err := diameter.ReadMessageFrom(bufio.NewReader(remote))
+		if err != nil {
+			return
+		}
+		cer := capabilities.CER{}
+		cer.FromMessage(*message)
+		remote.Write(capabilities.Answer(serverCapabilities, cer).ToMessage(*message).ToBytes())
+	}()
+	config.Capabilities = clientCapabilities
+	c, err := client.New(context.Background(), local, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	<-exchanged
+	return c, remote
+}
+
+func Test_client_write_timeout(t *testing.T) {
+	c, _ := pipeClient(t, client.Config{WriteTimeout: 20 * time.Millisecond})
+	err := c.Send(context.Background(), diameter.NewMessage(1, 0, diameter.CommandReAuth, diameter.ApplicationCreditControl, [4]byte{}, [4]byte{}))
+	assert.ErrorIs(t, err, os.ErrDeadlineExceeded)
+	<-c.Done()
+	assert.Error(t, c.Err())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Error(t, c.Send(ctx, diameter.NewMessage(1, 0, diameter.CommandReAuth, diameter.ApplicationCreditControl, [4]byte{}, [4]byte{})))
+}
+
+func Test_client_write_priority(t *testing.T) {
+	c, remote := pipeClient(t, client.Config{WriteQueue: 1, OnQueueFull: client.RejectRequests})
+	message := func(hopByHopId byte, flags diameter.Flags) diameter.Message {
+		return diameter.NewMessage(1, flags, diameter.CommandCreditControl, diameter.ApplicationCreditControl, [4]byte{hopByHopId}, [4]byte{})
+	}
+
+	go c.Send(context.Background(), message(1, diameter.FlagRequest))
+	first := make([]byte, 1)
+	if _, err := io.ReadFull(remote, first); err != nil {
+		t.Fatal(err)
+	}
+	go c.Send(context.Background(), message(2, diameter.FlagRequest))
+	assert.Eventually(t, func() bool { return c.QueueLen(client.PriorityRequest) == 1 }, time.Second, time.Millisecond)
+	assert.ErrorIs(t, c.Send(context.Background(), message(3, diameter.FlagRequest)), client.ErrQueueFull)
+	go c.Send(context.Background(), message(4, 0))
+	assert.Eventually(t, func() bool { return c.QueueLen(client.PriorityAnswer) == 1 }, time.Second, time.Millisecond)
+
+	reader := bufio.NewReader(io.MultiReader(bytes.NewReader(first), remote))
+	var order []byte
+	for range 3 {
+		written, err := diameter.ReadMessageFrom(reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		order = append(order, written.HopByHopId[0])
+	}
+	assert.Equal(t, []byte{1, 4, 2}, order)
+}
+
+func Test_client_unsolicited_answers(t *testing.T) {
+	sink := metrics.NewPrometheus()
+	type unmatched struct {
+		hopByHopId byte
+		outcome    transaction.Outcome
+	}
+	received := make(chan unmatched, 2)
+	c, remote := pipeClient(t, client.Config{Metrics: sink, OnUnsolicited: func(answer diameter.Message, outcome transaction.Outcome) {
+		received <- unmatched{answer.HopByHopId[3], outcome}
+	}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	requests := make(chan diameter.Message, 1)
+	go func() {
+		request, err := diameter.ReadMessageFrom(remote)
+		if err == nil {
+			requests <- *request
+		}
+	}()
+	_, err := c.SendRequest(ctx, diameter.NewMessage(1, 0, diameter.CommandCreditControl, diameter.ApplicationCreditControl, [4]byte{}, [4]byte{}))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	late := (<-requests).NewAnswer()
+	remote.Write(late.ToBytes())
+	assert.Equal(t, unmatched{late.HopByHopId[3], transaction.Late}, <-received)
+
+	remote.Write(diameter.NewMessage(1, 0, diameter.CommandCreditControl, diameter.ApplicationCreditControl, [4]byte{0xff, 0, 0, 0xff}, [4]byte{}).ToBytes())
+	assert.Equal(t, unmatched{0xff, transaction.Unsolicited}, <-received)
+
+	body := strings.Builder{}
+	sink.WriteTo(&body)
+	assert.Contains(t, body.String(), `diameter_unmatched_answers_total{outcome="late"} 1`)
+	assert.Contains(t, body.String(), `diameter_unmatched_answers_total{outcome="unsolicited"} 1`)
+	assert.Equal(t, uint64(1), c.Transactions().Late)
+	assert.Equal(t, uint64(1), c.Transactions().Unsolicited)
+}
+
+func Test_client_unsolicited_policies(t *testing.T) {
+	answer := diameter.NewMessage(1, 0, diameter.CommandCreditControl, diameter.ApplicationCreditControl, [4]byte{0, 0, 0, 7}, [4]byte{},
+		diameter.NewAvpString(diameter.AvpOriginHost, mandatoryFlags, 0, "server.example.com"))
+	var logged bytes.Buffer
+	client.LogUnsolicited(slog.New(slog.NewTextHandler(&logged, nil)))(answer, transaction.Unsolicited)
+	assert.Contains(t, logged.String(), "msg=\"unmatched Diameter answer\" outcome=UNSOLICITED command=272 hop_by_hop_id=0x00000007 origin_host=server.example.com")
+
+	c, remote := pipeClient(t, client.Config{OnUnsolicited: client.DropUnsolicited})
+	remote.Write(answer.ToBytes())
+	assert.Eventually(t, func() bool { return c.Transactions().Unsolicited == 1 }, time.Second, time.Millisecond)
+	assert.NoError(t, c.Err())
+}
+
+func Test_client_reads_while_writes_stall(t *testing.T) {
+	unsolicited := make(chan diameter.Message, 1)
+	c, remote := pipeClient(t, client.Config{OnUnsolicited: func(answer diameter.Message, _ transaction.Outcome) {
+		unsolicited <- answer
+	}})
+	go c.Send(context.Background(), diameter.NewMessage(1, diameter.FlagRequest, diameter.CommandCreditControl, diameter.ApplicationCreditControl, [4]byte{1}, [4]byte{}))
+
+	written := make(chan struct{})
+	go func() {
+		defer close(written)
+		remote.Write(watchdog.DWR{OriginHost: "server.example.com", OriginRealm: "example.com"}.ToMessage([4]byte{2}, [4]byte{}).ToBytes())
+		remote.Write(diameter.NewMessage(1, 0, diameter.CommandCreditControl, diameter.ApplicationCreditControl, [4]byte{3}, [4]byte{}).ToBytes())
+	}()
+	select {
+	case answer := <-unsolicited:
+		assert.Equal(t, [4]byte{3}, answer.HopByHopId)
+	case <-time.After(time.Second):
+		t.Fatal("reading stopped while the peer was not reading")
+	}
+	<-written
+}
@@ -0,0 +1,187 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tinybluerobots/radius-diameter-message/diameter"
+	"github.com/tinybluerobots/radius-diameter-message/diameter/creditcontrol"
+)
+
+func Test_creditcontrol_ccr(t *testing.T) {
+	ratingGroup := uint32(100)
+	ccr := creditcontrol.CCR{
+		SessionId:        "pgw.example.com;1;2",
+		OriginHost:       "pgw.example.com",
+		OriginRealm:      "example.com",
+		DestinationRealm: "ocs.example.com",
+		ServiceContextId: "32251@3gpp.org",
+		RequestType:      creditcontrol.UpdateRequest,
+		RequestNumber:    1,
+		EventTimestamp:   time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		SubscriptionIds: []creditcontrol.SubscriptionId{
+			{Type: creditcontrol.EndUserE164, Data: "447700900123"},
+			{Type: creditcontrol.EndUserIMSI, Data: "234150999999999"},
+		},
+		MultipleServicesIndicator: true,
+		MultipleServicesCreditControl: []creditcontrol.MSCC{{
+			RatingGroup:          &ratingGroup,
+			ServiceIdentifiers:   []uint32{1},
+			RequestedServiceUnit: &creditcontrol.ServiceUnit{},
+			UsedServiceUnits:     []creditcontrol.ServiceUnit{{Time: 60, InputOctets: 1000, OutputOctets: 2000}},
+		}},
+		Avps: diameter.NewAvps().AddString(873, mandatoryFlags, 10415, "info"),
+	}
+	message := ccr.ToMessage([4]byte{0, 0, 0, 1}, [4]byte{0, 0, 0, 2})
+	assert.True(t, message.IsRequest())
+	assert.True(t, message.IsProxiable())
+	assert.Equal(t, diameter.ApplicationCreditControl, message.ApplicationId)
+
+	decoded, err := diameter.ReadMessage(message.ToBytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ccr2 := creditcontrol.CCR{}
+	assert.NoError(t, ccr2.FromMessage(*decoded))
+	assert.Equal(t, ccr.SubscriptionIds, ccr2.SubscriptionIds)
+	assert.Equal(t, ccr.MultipleServicesCreditControl, ccr2.MultipleServicesCreditControl)
+	assert.True(t, ccr.EventTimestamp.Equal(ccr2.EventTimestamp))
+	assert.Equal(t, creditcontrol.UpdateRequest, ccr2.RequestType)
+	assert.Equal(t, "UPDATE_REQUEST", ccr2.RequestType.String())
+	assert.Equal(t, "32251@3gpp.org", ccr2.ServiceContextId)
+	assert.True(t, ccr2.MultipleServicesIndicator)
+	assert.Len(t, ccr2.Avps, 1)
+	assert.Equal(t, "info", ccr2.Avps[0].ToStringOrDefault())
+}
+
+func Test_creditcontrol_cca(t *testing.T) {
+	request := creditcontrol.CCR{SessionId: "s", RequestType: creditcontrol.InitialRequest}.ToMessage([4]byte{0, 0, 0, 1}, [4]byte{0, 0, 0, 2})
+	cca := creditcontrol.CCA{
+		ResultCode:    diameter.ResultCodeSuccess,
+		OriginHost:    "ocs.example.com",
+		OriginRealm:   "example.com",
+		RequestType:   creditcontrol.InitialRequest,
+		RequestNumber: 0,
+		MultipleServicesCreditControl: []creditcontrol.MSCC{{
+			GrantedServiceUnit: &creditcontrol.ServiceUnit{TotalOctets: 1 << 20},
+			ResultCode:         diameter.ResultCodeSuccess,
+		}},
+	}
+	answer := cca.ToMessage(request)
+	assert.Equal(t, "s", answer.Avps.GetFirst(diameter.AvpSessionId, 0).ToStringOrDefault())
+	cca2 := creditcontrol.CCA{}
+	assert.NoError(t, cca2.FromMessage(answer))
+	assert.Equal(t, cca, cca2)
+	assert.Error(t, cca2.FromMessage(request))
+}
+
+func Test_creditcontrol_mscc(t *testing.T) {
+	ratingGroup := uint32(10)
+	mscc := creditcontrol.MSCC{
+		RatingGroup:         &ratingGroup,
+		GrantedServiceUnit:  &creditcontrol.ServiceUnit{TotalOctets: 1000},
+		ResultCode:          diameter.ResultCodeSuccess,
+		FinalUnitIndication: &creditcontrol.FinalUnitIndication{FinalUnitAction: creditcontrol.Terminate},
+		ValidityTime:        3600,
+	}
+	avp := mscc.ToAvp()
+	mscc2 := creditcontrol.MSCC{}
+	mscc2.FromAvp(&avp)
+	assert.Equal(t, mscc, mscc2)
+	assert.Equal(t, creditcontrol.ServiceKey{RatingGroup: 10}, mscc2.Key())
+}
+
+func Test_creditcontrol_usage_aggregator(t *testing.T) {
+	ratingGroup := uint32(10)
+	otherRatingGroup := uint32(20)
+	aggregator := creditcontrol.NewUsageAggregator()
+	aggregator.AddCCR(creditcontrol.CCR{MultipleServicesCreditControl: []creditcontrol.MSCC{
+		{RatingGroup: &ratingGroup, UsedServiceUnits: []creditcontrol.ServiceUnit{{Time: 60, InputOctets: 100, OutputOctets: 200}}},
+		{RatingGroup: &otherRatingGroup, UsedServiceUnits: []creditcontrol.ServiceUnit{{TotalOctets: 5}}},
+	}})
+	aggregator.Add(creditcontrol.MSCC{RatingGroup: &ratingGroup, UsedServiceUnits: []creditcontrol.ServiceUnit{{Time: 30, InputOctets: 1}, {OutputOctets: 2}}})
+	assert.Equal(t, creditcontrol.ServiceUnit{Time: 90, InputOctets: 101, OutputOctets: 202}, aggregator.Total(creditcontrol.ServiceKey{RatingGroup: 10}))
+	assert.Equal(t, creditcontrol.ServiceUnit{TotalOctets: 5}, aggregator.Total(creditcontrol.ServiceKey{RatingGroup: 20}))
+	assert.Len(t, aggregator.Totals(), 2)
+}
+
+func Test_creditcontrol_final_unit_indication(t *testing.T) {
+	redirect := creditcontrol.NewRedirect(creditcontrol.RedirectURL, "http://topup.example.com")
+	avp := redirect.ToAvp()
+	decoded := creditcontrol.FinalUnitIndication{}
+	decoded.FromAvp(&avp)
+	assert.Equal(t, redirect, decoded)
+	assert.Equal(t, "REDIRECT", decoded.FinalUnitAction.String())
+	assert.Equal(t, "URL", decoded.RedirectServer.AddressType.String())
+
+	restrict := creditcontrol.NewRestrictAccess("permit out ip from any to 10.0.0.1")
+	restrict.FilterIds = []string{"topup"}
+	avp = restrict.ToAvp()
+	decoded.FromAvp(&avp)
+	assert.Equal(t, restrict, decoded)
+	assert.Nil(t, decoded.RedirectServer)
+}
+
+func Test_creditcontrol_subscription_and_equipment(t *testing.T) {
+	avps := diameter.NewAvps().AddAvps(
+		creditcontrol.NewSubscriptionId(creditcontrol.EndUserIMSI, "234150999999999"),
+		creditcontrol.NewSubscriptionId(creditcontrol.EndUserE164, "447700900123"),
+		creditcontrol.NewUserEquipmentInfo(creditcontrol.IMEISV, []byte("3566660123456701")),
+	)
+	assert.Equal(t, []creditcontrol.SubscriptionId{
+		{Type: creditcontrol.EndUserIMSI, Data: "234150999999999"},
+		{Type: creditcontrol.EndUserE164, Data: "447700900123"},
+	}, creditcontrol.SubscriptionIds(avps))
+	userEquipmentInfo, ok := creditcontrol.ReadUserEquipmentInfo(avps)
+	assert.True(t, ok)
+	assert.Equal(t, creditcontrol.IMEISV, userEquipmentInfo.Type)
+
+	message := diameter.NewMessage(1, diameter.FlagRequest, diameter.CommandCreditControl, diameter.ApplicationCreditControl, [4]byte{}, [4]byte{}, avps...)
+	ccr := creditcontrol.CCR{}
+	assert.NoError(t, ccr.FromMessage(message))
+	imsi, ok := ccr.IMSI()
+	assert.True(t, ok)
+	assert.Equal(t, "234150999999999", imsi)
+	msisdn, _ := ccr.MSISDN()
+	assert.Equal(t, "447700900123", msisdn)
+	imeisv, _ := ccr.IMEISV()
+	assert.Equal(t, "3566660123456701", imeisv)
+	assert.Empty(t, ccr.Avps)
+
+	_, ok = creditcontrol.CCR{}.IMSI()
+	assert.False(t, ok)
+}
+
+func Test_creditcontrol_sequencer(t *testing.T) {
+	sequencer := creditcontrol.NewSequencer()
+	ccr := creditcontrol.CCR{SessionId: "a", RequestType: creditcontrol.InitialRequest}
+	assert.NoError(t, sequencer.Sequence(&ccr))
+	assert.Equal(t, uint32(0), ccr.RequestNumber)
+	_, err := sequencer.Next("a", creditcontrol.InitialRequest)
+	assert.ErrorIs(t, err, creditcontrol.ErrSessionStarted)
+	number, _ := sequencer.Next("a", creditcontrol.UpdateRequest)
+	assert.Equal(t, uint32(1), number)
+	number, _ = sequencer.Next("a", creditcontrol.TerminationRequest)
+	assert.Equal(t, uint32(2), number)
+	assert.False(t, sequencer.Active("a"))
+	_, err = sequencer.Next("a", creditcontrol.UpdateRequest)
+	assert.ErrorIs(t, err, creditcontrol.ErrSessionNotStarted)
+}
+
+func Test_creditcontrol_validator(t *testing.T) {
+	validator := creditcontrol.NewValidator()
+	assert.NoError(t, validator.Check("a", creditcontrol.InitialRequest, 0))
+	assert.NoError(t, validator.Check("a", creditcontrol.UpdateRequest, 1))
+	assert.ErrorIs(t, validator.Check("a", creditcontrol.UpdateRequest, 1), creditcontrol.ErrRequestNumberReplay)
+	assert.ErrorIs(t, validator.Check("a", creditcontrol.UpdateRequest, 3), creditcontrol.ErrRequestNumberGap)
+	assert.NoError(t, validator.Validate(creditcontrol.CCR{SessionId: "a", RequestType: creditcontrol.TerminationRequest, RequestNumber: 2}))
+	assert.ErrorIs(t, validator.Check("a", creditcontrol.UpdateRequest, 3), creditcontrol.ErrSessionNotStarted)
+
+	assert.ErrorIs(t, validator.Check("b", creditcontrol.EventRequest, 1), creditcontrol.ErrRequestNumberGap)
+	assert.NoError(t, validator.Check("b", creditcontrol.EventRequest, 0))
+	assert.NoError(t, validator.Check("c", creditcontrol.InitialRequest, 0))
+	assert.ErrorIs(t, validator.Check("c", creditcontrol.InitialRequest, 0), creditcontrol.ErrSessionStarted)
+	validator.Forget("c")
+	assert.NoError(t, validator.Check("c", creditcontrol.InitialRequest, 0))
+}
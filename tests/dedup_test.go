@@ -0,0 +1,103 @@
+package tests
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tinybluerobots/radius-diameter-message/diameter"
+	"github.com/tinybluerobots/radius-diameter-message/diameter/client"
+	"github.com/tinybluerobots/radius-diameter-message/diameter/server"
+)
+
+func Test_dedup_cache(t *testing.T) {
+	cache := server.NewDedupCache(time.Minute)
+	request := diameter.NewMessage(1, requestFlags, diameter.CommandAccounting, diameter.ApplicationBaseAccounting, [4]byte{0, 0, 0, 1}, [4]byte{0, 0, 0, 2},
+		diameter.NewAvpString(diameter.AvpOriginHost, mandatoryFlags, 0, "client.example.com"))
+	_, ok := cache.Lookup(request)
+	assert.False(t, ok)
+	cache.Store(request, request.NewAnswer())
+
+	retransmitted := request
+	retransmitted.HopByHopId = [4]byte{0, 0, 0, 3}
+	retransmitted.SetRetransmitted(true)
+	answer, ok := cache.Lookup(retransmitted)
+	assert.True(t, ok)
+	assert.Equal(t, [4]byte{0, 0, 0, 3}, answer.HopByHopId)
+
+	other := request
+	other.Avps = diameter.NewAvps().AddString(diameter.AvpOriginHost, mandatoryFlags, 0, "other.example.com")
+	_, ok = cache.Lookup(other)
+	assert.False(t, ok)
+	assert.Equal(t, 1, cache.Len())
+}
+
+func Test_dedup_cache_expires(t *testing.T) {
+	cache := server.NewDedupCache(time.Millisecond)
+	request := diameter.NewMessage(1, requestFlags, diameter.CommandAccounting, diameter.ApplicationBaseAccounting, [4]byte{0, 0, 0, 1}, [4]byte{0, 0, 0, 2})
+	cache.Store(request, request.NewAnswer())
+	time.Sleep(5 * time.Millisecond)
+	_, ok := cache.Lookup(request)
+	assert.False(t, ok)
+}
+
+func Test_server_dedup(t *testing.T) {
+	var handled atomic.Int32
+	s := server.New(server.Config{Capabilities: serverCapabilities, Dedup: server.NewDedupCache(time.Minute)})
+	s.Handle(diameter.ApplicationCreditControl, diameter.CommandCreditControl, func(ctx context.Context, writer server.AnswerWriter, request diameter.Message) {
+		handled.Add(1)
+		writer.WriteAnswer(request.NewAnswer(diameter.NewAvpUint32(diameter.AvpResultCode, mandatoryFlags, 0, uint32(diameter.ResultCodeSuccess))))
+	})
+	c, err := client.Dial(context.Background(), startServer(t, s), client.Config{Capabilities: clientCapabilities})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	request := diameter.NewMessage(1, 0, diameter.CommandCreditControl, diameter.ApplicationCreditControl, [4]byte{}, [4]byte{0, 0, 0, 7},
+		diameter.NewAvpString(diameter.AvpOriginHost, mandatoryFlags, 0, "client.example.com"))
+	_, err = c.SendRequest(context.Background(), request)
+	assert.NoError(t, err)
+	request.SetRetransmitted(true)
+	answer, err := c.SendRequest(context.Background(), request)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(diameter.ResultCodeSuccess), answer.Avps.GetFirst(diameter.AvpResultCode, 0).ToUint32OrDefault())
+	assert.Equal(t, int32(1), handled.Load())
+}
+
+func Test_server_dedup_in_flight(t *testing.T) {
+	var handled atomic.Int32
+	release := make(chan struct{})
+	s := server.New(server.Config{Capabilities: serverCapabilities, Dedup: server.NewDedupCache(time.Minute)})
+	s.Handle(diameter.ApplicationCreditControl, diameter.CommandCreditControl, func(ctx context.Context, writer server.AnswerWriter, request diameter.Message) {
+		handled.Add(1)
+		<-release
+		writer.WriteAnswer(request.NewAnswer(diameter.NewAvpUint32(diameter.AvpResultCode, mandatoryFlags, 0, uint32(diameter.ResultCodeSuccess))))
+	})
+	c, err := client.Dial(context.Background(), startServer(t, s), client.Config{Capabilities: clientCapabilities})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	request := diameter.NewMessage(1, 0, diameter.CommandCreditControl, diameter.ApplicationCreditControl, [4]byte{}, [4]byte{0, 0, 0, 7},
+		diameter.NewAvpString(diameter.AvpOriginHost, mandatoryFlags, 0, "client.example.com"))
+	answered := make(chan error, 1)
+	go func() {
+		_, err := c.SendRequest(context.Background(), request)
+		answered <- err
+	}()
+	assert.Eventually(t, func() bool { return handled.Load() == 1 }, time.Second, time.Millisecond)
+
+	request.SetRetransmitted(true)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = c.SendRequest(ctx, request)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	close(release)
+	assert.NoError(t, <-answered)
+	answer, err := c.SendRequest(context.Background(), request)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(diameter.ResultCodeSuccess), answer.Avps.GetFirst(diameter.AvpResultCode, 0).ToUint32OrDefault())
+	assert.Equal(t, int32(1), handled.Load())
+}
@@ -1,13 +1,21 @@
 package tests
 
 import (
+	"bytes"
 	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/tinybluerobots/radius-diameter-message/diameter"
+	"github.com/tinybluerobots/radius-diameter-message/threegpp"
 )
 
 const (
@@ -42,13 +50,243 @@ func Test_diameter_message(t *testing.T) {
 	expectedAvp = []byte{0x0, 0x0, 0x1, 0x1, byte(mandatoryFlags), 0x0, 0x0, 0xe, 0x0, 0x1, 0x64, 0x62, 0xb3, 0xae, 0x0, 0x0}
 	assert.Equal(t, expectedAvp, actualAvps[12:])
 
-	message = *diameter.ReadMessage(bytes)
+	decoded, err := diameter.ReadMessage(bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	message = *decoded
 	avp := message.Avps.GetFirst(258, 0)
 	assert.Equal(t, uint32(1), *avp.ToUint32())
 	avp = message.Avps.GetFirst(257, 0)
 	assert.Equal(t, ipAddress.To4(), *avp.ToNetIP())
 }
 
+func Test_diameter_append_bytes(t *testing.T) {
+	avps := diameter.NewAvps()
+	avps = avps.AddString(263, mandatoryFlags, 0, "session")
+	avps = avps.AddGroup(443, mandatoryFlags, 10415, diameter.NewAvpString(444, mandatoryFlags, 0, "901280064290558"))
+	message := diameter.NewMessage(1, requestFlags, 272, 4, [4]byte{0, 0, 0, 1}, [4]byte{0, 0, 0, 2}, avps...)
+	expected := message.ToBytes()
+
+	buffer := make([]byte, 256)
+	for i := range buffer {
+		buffer[i] = 0xff
+	}
+	buffer = message.AppendBytes(buffer[:1])
+	assert.Equal(t, byte(0xff), buffer[0])
+	assert.Equal(t, expected, buffer[1:])
+	decoded, err := diameter.ReadMessage(buffer[1:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "session", *decoded.Avps.GetFirst(263, 0).ToString())
+	assert.Equal(t, expected[20:], avps.AppendBytes(nil))
+	allocations := testing.AllocsPerRun(10, func() {
+		buffer = message.AppendBytes(buffer[:0])
+	})
+	assert.Zero(t, allocations)
+}
+
+func Test_diameter_write_to_read_from(t *testing.T) {
+	avps := diameter.NewAvps()
+	avps = avps.AddString(263, mandatoryFlags, 0, "session")
+	avps = avps.AddUint32(999, 0x80, 10415, 1)
+	message := diameter.NewMessage(1, requestFlags, 272, 4, [4]byte{0, 0, 0, 1}, [4]byte{0, 0, 0, 2}, avps...)
+	decoded, err := diameter.ReadMessage(message.ToBytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var stream bytes.Buffer
+	n, err := message.WriteTo(&stream)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(message.ToBytes())), n)
+	_, err = decoded.WriteTo(&stream)
+	assert.NoError(t, err)
+	assert.Equal(t, append(message.ToBytes(), message.ToBytes()...), stream.Bytes())
+
+	var read diameter.Message
+	n, err = read.ReadFrom(&stream)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(message.ToBytes())), n)
+	assert.Equal(t, message.ToBytes(), read.ToBytes())
+	_, err = read.ReadFrom(&stream)
+	assert.NoError(t, err)
+	_, err = read.ReadFrom(&stream)
+	assert.Equal(t, io.EOF, err)
+	n, err = read.ReadFrom(bytes.NewReader(message.ToBytes()[:30]))
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+	assert.Equal(t, int64(30), n)
+}
+
+func Test_diameter_encode_all(t *testing.T) {
+	messages := []diameter.Message{
+		diameter.NewMessage(1, requestFlags, 280, 0, [4]byte{0, 0, 0, 1}, [4]byte{0, 0, 0, 1}, diameter.NewAvpString(264, mandatoryFlags, 0, "host")),
+		diameter.NewMessage(1, requestFlags, 280, 0, [4]byte{0, 0, 0, 2}, [4]byte{0, 0, 0, 2}),
+	}
+	buffer := diameter.EncodeAll(messages, nil)
+	assert.Equal(t, append(messages[0].ToBytes(), messages[1].ToBytes()...), buffer)
+
+	frames, rest, err := diameter.SplitMessages(buffer[:len(buffer)-5])
+	assert.NoError(t, err)
+	assert.Len(t, frames, 1)
+	assert.Equal(t, messages[0].ToBytes(), frames[0])
+	assert.Len(t, rest, 15)
+	frames, rest, err = diameter.SplitMessages(buffer)
+	assert.NoError(t, err)
+	assert.Len(t, frames, 2)
+	assert.Empty(t, rest)
+	decoded, err := diameter.ReadMessage(frames[1])
+	assert.NoError(t, err)
+	assert.Equal(t, [4]byte{0, 0, 0, 2}, decoded.HopByHopId)
+
+	_, _, err = diameter.SplitMessages(append(messages[0].ToBytes(), 1, 0, 0, 4))
+	assert.Error(t, err)
+}
+
+func Test_diameter_without(t *testing.T) {
+	avps := diameter.NewAvps().
+		AddString(diameter.AvpSessionId, mandatoryFlags, 0, "session").
+		AddString(diameter.AvpOriginHost, mandatoryFlags, 0, "client.example.com").
+		AddUint32(diameter.AvpResultCode, mandatoryFlags, 10415, 5030).
+		AddUint32(diameter.AvpResultCode, mandatoryFlags, 0, 2001)
+	others := avps.Without(0, diameter.AvpSessionId, diameter.AvpResultCode)
+	assert.Equal(t, diameter.Avps{avps[1], avps[2]}, others)
+	assert.Equal(t, diameter.Avps{avps[0], avps[1], avps[3]}, avps.Without(10415, diameter.AvpResultCode))
+	assert.Nil(t, avps.Without(0, diameter.AvpSessionId, diameter.AvpOriginHost, diameter.AvpResultCode).Without(10415, diameter.AvpResultCode))
+}
+
+func Test_diameter_get(t *testing.T) {
+	timestamp := time.Date(2024, time.May, 15, 16, 50, 37, 0, time.UTC)
+	avps := diameter.NewAvps()
+	avps = avps.AddString(263, mandatoryFlags, 0, "session")
+	avps = avps.AddUint32(268, mandatoryFlags, 0, 2001)
+	avps = avps.AddUint32(999, mandatoryFlags, 0, 0xffffffff)
+	avps = avps.AddUint64(287, mandatoryFlags, 0, 42)
+	avps = avps.AddFloat64(998, 0, 10415, 1.5)
+	avps = avps.AddTime(55, mandatoryFlags, 0, timestamp)
+	avps = avps.AddNetIP(257, mandatoryFlags, 0, net.IPv4(192, 0, 2, 1))
+	avps = avps.AddGroup(443, mandatoryFlags, 0, diameter.NewAvpString(444, mandatoryFlags, 0, "901280064290558"))
+
+	sessionId, ok := diameter.Get[string](avps, 263, 0)
+	assert.True(t, ok)
+	assert.Equal(t, "session", sessionId)
+	resultCode, _ := diameter.Get[uint32](avps, 268, 0)
+	assert.Equal(t, uint32(2001), resultCode)
+	signed, _ := diameter.Get[int32](avps, 999, 0)
+	assert.Equal(t, int32(-1), signed)
+	subSessionId, _ := diameter.Get[uint64](avps, 287, 0)
+	assert.Equal(t, uint64(42), subSessionId)
+	float, _ := diameter.Get[float64](avps, 998, 10415)
+	assert.Equal(t, 1.5, float)
+	eventTimestamp, _ := diameter.Get[time.Time](avps, 55, 0)
+	assert.True(t, timestamp.Equal(eventTimestamp))
+	address, _ := diameter.Get[net.IP](avps, 257, 0)
+	assert.Equal(t, "192.0.2.1", address.String())
+	group, ok := diameter.Get[diameter.Avps](avps, 443, 0)
+	assert.True(t, ok)
+	assert.Equal(t, "901280064290558", *group.GetFirst(444, 0).ToString())
+
+	_, ok = diameter.Get[uint32](avps, 1, 0)
+	assert.False(t, ok)
+	_, ok = diameter.Get[uint64](avps, 268, 0)
+	assert.False(t, ok)
+	_, ok = diameter.As[[]byte](nil)
+	assert.False(t, ok)
+}
+
+func Test_diameter_multi_value(t *testing.T) {
+	avps := diameter.NewAvps()
+	avps = avps.AddString(diameter.AvpRouteRecord, mandatoryFlags, 0, "relay1.example.com")
+	avps = avps.AddString(diameter.AvpRouteRecord, mandatoryFlags, 0, "relay2.example.com")
+	avps = avps.AddNetIP(diameter.AvpHostIPAddress, mandatoryFlags, 0, net.IPv4(192, 0, 2, 1))
+	avps = avps.AddNetIP(diameter.AvpHostIPAddress, mandatoryFlags, 0, net.ParseIP("2001:db8::1"))
+	avps = avps.AddUint32(diameter.AvpSupportedVendorId, mandatoryFlags, 0, 10415)
+	avps = avps.Add(diameter.AvpSupportedVendorId, mandatoryFlags, 0, []byte{1})
+	avps = avps.AddUint32(diameter.AvpSupportedVendorId, mandatoryFlags, 0, 5535)
+	avps = avps.AddGroup(443, mandatoryFlags, 0, diameter.NewAvpString(444, mandatoryFlags, 0, "1"))
+	avps = avps.AddGroup(443, mandatoryFlags, 0, diameter.NewAvpString(444, mandatoryFlags, 0, "2"))
+
+	assert.Equal(t, []string{"relay1.example.com", "relay2.example.com"}, avps.ToStrings(diameter.AvpRouteRecord, 0))
+	addresses := avps.ToNetIPs(diameter.AvpHostIPAddress, 0)
+	assert.Len(t, addresses, 2)
+	assert.Equal(t, "2001:db8::1", addresses[1].String())
+	assert.Equal(t, []uint32{10415, 5535}, avps.ToUint32s(diameter.AvpSupportedVendorId, 0))
+	groups := avps.ToGroups(443, 0)
+	assert.Len(t, groups, 2)
+	assert.Equal(t, "2", *groups[1].GetFirst(444, 0).ToString())
+	assert.Empty(t, avps.ToTimes(diameter.AvpEventTimestamp, 0))
+}
+
+func Test_diameter_must(t *testing.T) {
+	avps := diameter.NewAvps()
+	avps = avps.AddString(263, mandatoryFlags, 0, "session")
+	avps = avps.AddUint32(268, mandatoryFlags, 0, 2001)
+	avps = avps.Add(999, 0, 10415, []byte{1, 2, 3})
+	avps = avps.AddGroup(443, mandatoryFlags, 0, diameter.NewAvpUint32(450, mandatoryFlags, 0, 1))
+
+	assert.Equal(t, "session", avps.MustString(263, 0))
+	assert.Equal(t, uint32(2001), avps.MustUint32(268, 0))
+	assert.Equal(t, uint32(1), avps.MustGroup(443, 0).MustUint32(450, 0))
+	assert.PanicsWithValue(t, "diameter: AVP 264 (vendor 0) is missing", func() { avps.MustString(264, 0) })
+	assert.PanicsWithValue(t, "diameter: AVP 999 (vendor 10415) of 3 bytes is not a valid uint32", func() { avps.MustUint32(999, 10415) })
+}
+
+func Test_diameter_pool(t *testing.T) {
+	avps := diameter.NewAvps()
+	avps = avps.AddString(263, mandatoryFlags, 0, "session")
+	avps = avps.AddUint32(258, mandatoryFlags, 0, 4)
+	message := diameter.NewMessage(1, requestFlags, 272, 4, [4]byte{0, 0, 0, 1}, [4]byte{0, 0, 0, 2}, avps...)
+	pool := &diameter.Pool{}
+	decoder := diameter.Decoder{Pool: pool}
+
+	buffer := pool.AcquireBuffer()
+	*buffer = message.AppendBytes(*buffer)
+	decoded, err := decoder.ReadMessage(*buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, message.ToBytes(), decoded.ToBytes())
+	pool.ReleaseMessage(decoded)
+	assert.Empty(t, decoded.Avps)
+
+	allocations := testing.AllocsPerRun(100, func() {
+		decoded, _ := decoder.ReadMessage(*buffer)
+		pool.ReleaseMessage(decoded)
+	})
+	assert.Less(t, allocations, 1.0)
+	pool.ReleaseBuffer(buffer)
+
+	list := pool.AcquireAvps()
+	*list = list.AddString(263, mandatoryFlags, 0, "session")
+	assert.Len(t, *list, 1)
+	pool.ReleaseAvps(list)
+	_, err = pool.ReadMessage([]byte{1, 0})
+	assert.Error(t, err)
+
+	var none *diameter.Pool
+	decoded, err = none.ReadMessage(message.ToBytes())
+	assert.NoError(t, err)
+	none.ReleaseMessage(decoded)
+	assert.Len(t, decoded.Avps, 2)
+}
+
+func Test_diameter_decoder_copy_data(t *testing.T) {
+	message := diameter.NewMessage(1, requestFlags, 272, 4, [4]byte{}, [4]byte{}, diameter.NewAvpString(263, mandatoryFlags, 0, "session"))
+	buffer := message.ToBytes()
+	aliased, err := diameter.Decoder{}.ReadMessage(buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	copied, err := diameter.Decoder{CopyData: true}.ReadMessage(buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	copy(buffer[28:], "reused!")
+	assert.Equal(t, "reused!", *aliased.Avps.GetFirst(263, 0).ToString())
+	assert.Equal(t, "session", *copied.Avps.GetFirst(263, 0).ToString())
+}
+
 func Test_diameter_read_grouped_avp(t *testing.T) {
 	base64Data := "AAADaYAAANQAACivAAADaoAAAMgAACivAAAD+IAAADwAACivAAAD/IAAAA8AACivUy01AAAABBCAAAAQAAAorw7msoAAAAQRgAAAEAAAKK8HoSAAAAAABoAAABAAACivUH2ryQAAAAqAAAANAAAorzUAAAAAAAAeAAAAE2RhdGFjb25uZWN0AAAAAA2AAAAQAAAorzA4MDAAAAASgAAAEQAAKK8yMzQxMAAAAAAAA+yAAAATAAAor2RlZmF1bHQAAAAAFoAAABQAACivAAAAAAAAAAA="
 	decodedData, err := base64.StdEncoding.DecodeString(base64Data)
@@ -57,7 +295,11 @@ func Test_diameter_read_grouped_avp(t *testing.T) {
 	}
 	messageData := make([]byte, 20+len(decodedData))
 	copy(messageData[20:], decodedData)
-	message := *diameter.ReadMessage(messageData)
+	decoded, err := diameter.ReadMessage(messageData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := *decoded
 	apn := message.Avps.GetFirst(873, 10415).ToGroup().GetFirst(874, 10415).ToGroup().GetFirst(30, 0).ToString()
 	assert.Equal(t, "dataconnect", *apn)
 }
@@ -92,7 +334,11 @@ func Test_diameter_write_grouped_avp(t *testing.T) {
 	avps = avps.AddGroup(456, 0, 0, group...)
 	message := diameter.NewMessage(1, 0, 265, 1, [4]byte{0, 0, 0, 0}, [4]byte{0, 0, 0, 0}, avps...)
 	bytes := message.ToBytes()
-	message = *diameter.ReadMessage(bytes)
+	decoded, err := diameter.ReadMessage(bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	message = *decoded
 	avp := message.Avps.GetFirst(456, 0).ToGroup().GetFirst(432, 0)
 	assert.Equal(t, uint32(1), *avp.ToUint32())
 }
@@ -101,7 +347,11 @@ func Test_diameter_write_grouped_avp_with_spread(t *testing.T) {
 	avps := diameter.NewAvpGroup(456, 0, 0, diameter.NewAvpUint32(432, 0, 0, 1))
 	message := diameter.NewMessage(1, 0, 265, 1, [4]byte{0, 0, 0, 0}, [4]byte{0, 0, 0, 0}, avps)
 	bytes := message.ToBytes()
-	message = *diameter.ReadMessage(bytes)
+	decoded, err := diameter.ReadMessage(bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	message = *decoded
 	avp := message.Avps.GetFirst(456, 0).ToGroup().GetFirst(432, 0)
 	assert.Equal(t, uint32(1), *avp.ToUint32())
 }
@@ -114,12 +364,23 @@ func Test_diameter_timestamp(t *testing.T) {
 	}
 	messageData := make([]byte, 20+len(decodedData))
 	copy(messageData[20:], decodedData)
-	message := *diameter.ReadMessage(messageData)
+	decoded, err := diameter.ReadMessage(messageData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := *decoded
 	avp := message.Avps.GetFirst(55, 0)
 	expected := time.Time(time.Date(2024, time.May, 15, 17, 50, 37, 0, time.Local))
 	assert.Equal(t, expected, *avp.ToTime())
 }
 
+func Test_diameter_time_encoding(t *testing.T) {
+	value := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	avp := diameter.NewAvpTime(55, 0, 0, value)
+	assert.Equal(t, []byte{0xe9, 0x3c, 0x7f, 0x00}, []byte(avp.Data))
+	assert.True(t, value.Equal(avp.ToTimeOrDefault()))
+}
+
 func Test_diameter_bytes(t *testing.T) {
 	avp := diameter.NewAvp(1, 0, 0, []byte{0x0, 0x0, 0x0, 0x1})
 	assert.Equal(t, []byte{0x0, 0x0, 0x0, 0x1, 0x0, 0x0, 0x0, 0xc, 0x0, 0x0, 0x0, 0x1}, avp.ToBytes())
@@ -134,3 +395,629 @@ func Test_diameter_vendor_avp(t *testing.T) {
 	avp := diameter.NewAvpUint32(869, 0xc0, 10415, 83311718)
 	assert.Equal(t, decodedData, avp.ToBytes())
 }
+
+func Test_diameter_clone(t *testing.T) {
+	avps := diameter.NewAvps()
+	avps = avps.AddGroup(456, 0, 0, diameter.NewAvpString(1, 0, 0, "foo"))
+	message := diameter.NewMessage(1, 0, 265, 1, [4]byte{0, 0, 0, 0}, [4]byte{0, 0, 0, 0}, avps...)
+	bytes := message.ToBytes()
+	decoded, err := diameter.ReadMessage(bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clone := decoded.Clone()
+	for i := range bytes {
+		bytes[i] = 0
+	}
+	value := clone.Avps.GetFirst(456, 0).ToGroup().GetFirst(1, 0).ToString()
+	assert.Equal(t, "foo", *value)
+}
+
+func Test_diameter_string(t *testing.T) {
+	dictionary := diameter.NewDictionary().
+		AddCommand(272, "Credit-Control").
+		AddAvp(
+			diameter.AvpDefinition{Name: "Session-Id", Code: 263, Type: diameter.UTF8String},
+			diameter.AvpDefinition{Name: "CC-Request-Type", Code: 416, Type: diameter.Enumerated, Values: map[uint32]string{1: "INITIAL_REQUEST"}},
+			diameter.AvpDefinition{Name: "Subscription-Id", Code: 443, Type: diameter.Grouped},
+			diameter.AvpDefinition{Name: "Subscription-Id-Data", Code: 444, Type: diameter.UTF8String},
+		)
+	avps := diameter.NewAvps()
+	avps = avps.AddString(263, mandatoryFlags, 0, "session")
+	avps = avps.AddUint32(416, mandatoryFlags, 0, 1)
+	avps = avps.AddGroup(443, mandatoryFlags, 0, diameter.NewAvpString(444, mandatoryFlags, 0, "901280064290558"))
+	avps = avps.Add(999, 0, 0, []byte{0xde, 0xad, 0xbe, 0xef})
+	message := diameter.NewMessage(1, requestFlags, 272, 4, [4]byte{0, 0, 0, 1}, [4]byte{0, 0, 0, 2}, avps...)
+	dump := message.Describe(dictionary)
+	assert.Contains(t, dump, "Command Code: 272 Credit-Control-Request\n")
+	assert.Contains(t, dump, "Flags: 0x80 R---\n")
+	assert.Contains(t, dump, "  AVP: Session-Id(263) l=15 f=-M- val=\"session\"\n")
+	assert.Contains(t, dump, "  AVP: CC-Request-Type(416) l=12 f=-M- val=INITIAL_REQUEST (1)\n")
+	assert.Contains(t, dump, "  AVP: Subscription-Id(443) l=32 f=-M-\n    AVP: Subscription-Id-Data(444) l=23 f=-M- val=\"901280064290558\"\n")
+	assert.Contains(t, dump, "  AVP: Unknown(999) l=12 f=--- val=deadbeef\n")
+	assert.Contains(t, message.String(), "  AVP: Unknown(263) l=15 f=-M- val=73657373696f6e\n")
+}
+
+func Test_diameter_json(t *testing.T) {
+	dictionary := diameter.NewDictionary().
+		AddCommand(272, "Credit-Control").
+		AddAvp(
+			diameter.AvpDefinition{Name: "Session-Id", Code: 263, Type: diameter.UTF8String},
+			diameter.AvpDefinition{Name: "Event-Timestamp", Code: 55, Type: diameter.Time},
+			diameter.AvpDefinition{Name: "Subscription-Id", Code: 443, Type: diameter.Grouped},
+			diameter.AvpDefinition{Name: "Subscription-Id-Type", Code: 450, Type: diameter.Enumerated},
+		)
+	timestamp := time.Date(2024, time.May, 15, 16, 50, 37, 0, time.UTC)
+	avps := diameter.NewAvps()
+	avps = avps.AddString(263, mandatoryFlags, 0, "session")
+	avps = avps.AddTime(55, mandatoryFlags, 0, timestamp)
+	avps = avps.AddGroup(443, mandatoryFlags, 0, diameter.NewAvpUint32(450, mandatoryFlags, 0, 1))
+	avps = avps.Add(999, 0, 10415, []byte{0xde, 0xad})
+	message := diameter.NewMessage(1, requestFlags, 272, 4, [4]byte{0, 0, 0, 1}, [4]byte{0, 0, 0, 2}, avps...)
+
+	bytes, err := diameter.MarshalMessageJSON(message, dictionary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, string(bytes), `{"code":263,"flags":64,"name":"Session-Id","type":"UTF8String","value":"session"}`)
+	assert.Contains(t, string(bytes), `"value":"2024-05-15T16:50:37Z"`)
+	assert.Contains(t, string(bytes), `{"code":999,"vendorId":10415,"vendor":"3GPP","flags":128,"data":"3q0="}`)
+	decoded, err := diameter.UnmarshalMessageJSON(bytes, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, message.ToBytes(), decoded.ToBytes())
+
+	bytes, err = json.Marshal(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var replayed diameter.Message
+	if err := json.Unmarshal(bytes, &replayed); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, message.ToBytes(), replayed.ToBytes())
+	assert.Equal(t, timestamp, replayed.Avps.GetFirst(55, 0).ToTime().UTC())
+
+	fixture := `{"version":1,"flags":128,"commandCode":272,"applicationId":4,"hopByHopId":1,"endToEndId":2,"avps":[{"name":"Session-Id","flags":64,"value":"session"}]}`
+	decoded, err = diameter.UnmarshalMessageJSON([]byte(fixture), dictionary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "session", *decoded.Avps.GetFirst(263, 0).ToString())
+}
+
+func Test_diameter_map(t *testing.T) {
+	dictionary := diameter.NewDictionary().
+		AddAvp(
+			diameter.AvpDefinition{Name: "Session-Id", Code: 263, Type: diameter.UTF8String},
+			diameter.AvpDefinition{Name: "Subscription-Id", Code: 443, Type: diameter.Grouped},
+			diameter.AvpDefinition{Name: "Subscription-Id-Type", Code: 450, Type: diameter.Enumerated},
+		)
+	avps := diameter.NewAvps()
+	avps = avps.AddString(263, mandatoryFlags, 0, "session")
+	avps = avps.AddGroup(443, mandatoryFlags, 0, diameter.NewAvpUint32(450, mandatoryFlags, 0, 1))
+	message := diameter.NewMessage(1, requestFlags, 272, 4, [4]byte{0, 0, 0, 1}, [4]byte{0, 0, 0, 2}, avps...)
+
+	values := message.ToMap(dictionary)
+	assert.Equal(t, json.Number("272"), values["commandCode"])
+	entries := values["avps"].([]any)
+	assert.Equal(t, "Session-Id", entries[0].(map[string]any)["name"])
+	assert.Equal(t, "session", entries[0].(map[string]any)["value"])
+	decoded, err := diameter.FromMap(values, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, message.ToBytes(), decoded.ToBytes())
+
+	decoded, err = diameter.FromMap(map[string]any{
+		"version":     1,
+		"commandCode": 272,
+		"avps": []map[string]any{
+			{"name": "Session-Id", "flags": mandatoryFlags, "value": "templated"},
+		},
+	}, dictionary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "templated", *decoded.Avps.GetFirst(263, 0).ToString())
+	_, err = diameter.FromMap(map[string]any{"avps": []map[string]any{{"name": "Unknown-AVP"}}}, dictionary)
+	assert.Error(t, err)
+}
+
+func Test_diameter_apn_and_charging_characteristics(t *testing.T) {
+	avps := diameter.NewAvps()
+	avps = avps.AddString(diameter.AvpServiceSelection, mandatoryFlags, 0, "ims.mnc01.mcc901")
+	avps = avps.AddChargingCharacteristics(threegpp.Normal)
+	message := diameter.NewMessage(1, requestFlags, 272, 4, [4]byte{0, 0, 0, 1}, [4]byte{0, 0, 0, 2}, avps...)
+	decoded, err := diameter.ReadMessage(message.ToBytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, decoded.Avps.CalledStationId())
+	assert.Equal(t, "ims.mnc01.mcc901", *decoded.Avps.APN())
+	assert.Equal(t, threegpp.Normal, *decoded.Avps.ChargingCharacteristics())
+	assert.Equal(t, "web", threegpp.NormalizeAPN("WEB.mnc012.mcc234.gprs"))
+}
+
+func Test_diameter_validate(t *testing.T) {
+	subscriptionId := diameter.Grammar{
+		Required: []diameter.Rule{diameter.NewRule(450, 0, 1, 1), diameter.NewRule(444, 0, 1, 1)},
+	}
+	dictionary := diameter.NewDictionary().
+		AddAvp(diameter.AvpDefinition{Name: "Subscription-Id", Code: 443, Type: diameter.Grouped, Grammar: &subscriptionId}).
+		AddCCF(diameter.CCF{
+			Name:        "Credit-Control-Request",
+			CommandCode: 272,
+			Request:     true,
+			Grammar: diameter.Grammar{
+				Fixed:    []diameter.Rule{diameter.NewRule(263, 0, 1, 1)},
+				Required: []diameter.Rule{diameter.NewRule(416, 0, 1, 1), diameter.NewRule(415, 0, 1, 1)},
+				Optional: []diameter.Rule{diameter.NewRule(443, 0, 0, diameter.Unbounded), diameter.NewRule(55, 0, 0, 1)},
+			},
+		})
+	ccf := dictionary.CCF(272, true)
+	avps := diameter.NewAvps()
+	avps = avps.AddUint32(416, mandatoryFlags, 0, 1)
+	avps = avps.AddString(263, mandatoryFlags, 0, "session")
+	avps = avps.AddGroup(443, mandatoryFlags, 0, diameter.NewAvpUint32(450, mandatoryFlags, 0, 1))
+	avps = avps.AddUint32(55, mandatoryFlags, 0, 1)
+	avps = avps.AddUint32(55, mandatoryFlags, 0, 2)
+	avps = avps.AddUint32(999, mandatoryFlags, 0, 1)
+	message := diameter.NewMessage(1, requestFlags, 272, 4, [4]byte{0, 0, 0, 1}, [4]byte{0, 0, 0, 2}, avps...)
+
+	violations := message.Validate(*ccf)
+	resultCodes := make([]diameter.ResultCode, 0)
+	for _, violation := range violations {
+		resultCodes = append(resultCodes, violation.ResultCode)
+	}
+	assert.Equal(t, []diameter.ResultCode{
+		diameter.ResultCodeAvpNotAllowed,
+		diameter.ResultCodeMissingAvp,
+		diameter.ResultCodeMissingAvp,
+		diameter.ResultCodeAvpOccursTooManyTimes,
+		diameter.ResultCodeAvpNotAllowed,
+	}, resultCodes)
+	assert.Equal(t, diameter.Code(263), violations[0].Avp.Code)
+	assert.Equal(t, diameter.Code(415), violations[1].Avp.Code)
+	assert.Equal(t, diameter.Code(444), violations[2].Avp.Code)
+	assert.Equal(t, diameter.Code(443), violations[2].Parents[0].Code)
+	assert.Equal(t, uint32(2), *violations[3].Avp.ToUint32())
+	assert.Equal(t, diameter.Code(999), violations[4].Avp.Code)
+
+	message.CommandCode = 271
+	assert.Equal(t, diameter.ResultCodeCommandUnsupported, message.Validate(*ccf)[0].ResultCode)
+}
+
+func Test_diameter_message_flags(t *testing.T) {
+	message := diameter.NewMessage(1, diameter.FlagRequest|diameter.FlagProxiable, 272, 4, [4]byte{0, 0, 0, 1}, [4]byte{0, 0, 0, 2})
+	assert.True(t, message.IsRequest())
+	assert.True(t, message.IsProxiable())
+	assert.False(t, message.IsError())
+	message.SetRetransmitted(true)
+	message.SetRequest(false)
+	message.SetError(true)
+	assert.Equal(t, diameter.FlagProxiable|diameter.FlagError|diameter.FlagRetransmitted, message.Flags)
+	assert.True(t, message.Flags.IsRetransmitted())
+	message.SetProxiable(false)
+	assert.False(t, message.IsProxiable())
+}
+
+func Test_diameter_avp_flags(t *testing.T) {
+	avp := diameter.NewAvpUint32(1, 0, 10415, 1)
+	assert.True(t, avp.VendorSpecific())
+	assert.False(t, avp.Mandatory())
+	avp.WithMandatory(true).WithProtected(true)
+	assert.Equal(t, diameter.FlagVendorSpecific|diameter.FlagMandatory|diameter.FlagProtected, avp.Flags)
+	avp.WithVendor(0)
+	assert.False(t, avp.VendorSpecific())
+	assert.Equal(t, []byte{0x0, 0x0, 0x0, 0x1, 0x60, 0x0, 0x0, 0xc, 0x0, 0x0, 0x0, 0x1}, avp.ToBytes())
+	avp.WithFlags(diameter.FlagVendorSpecific)
+	assert.Equal(t, diameter.Flags(0), avp.Flags)
+	avp.WithVendor(10415)
+	decoded, err := diameter.ReadMessage(diameter.NewMessage(1, 0, 272, 4, [4]byte{}, [4]byte{}, avp).ToBytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, uint32(1), *decoded.Avps.GetFirst(1, 10415).ToUint32())
+	var missing *diameter.Avp
+	assert.False(t, missing.Mandatory())
+}
+
+func Test_diameter_extractor(t *testing.T) {
+	base64Data := "AAADaYAAANQAACivAAADaoAAAMgAACivAAAD+IAAADwAACivAAAD/IAAAA8AACivUy01AAAABBCAAAAQAAAorw7msoAAAAQRgAAAEAAAKK8HoSAAAAAABoAAABAAACivUH2ryQAAAAqAAAANAAAorzUAAAAAAAAeAAAAE2RhdGFjb25uZWN0AAAAAA2AAAAQAAAorzA4MDAAAAASgAAAEQAAKK8yMzQxMAAAAAAAA+yAAAATAAAor2RlZmF1bHQAAAAAFoAAABQAACivAAAAAAAAAAA="
+	decodedData, err := base64.StdEncoding.DecodeString(base64Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	messageData := make([]byte, 20+len(decodedData))
+	copy(messageData[20:], decodedData)
+	extract := diameter.NewExtractor(
+		diameter.NewPath(873, 10415, 874, 10415, 30, 0),
+		diameter.NewPath(873, 10415, 874, 10415, 13, 10415),
+		diameter.NewPath(263, 0),
+	)
+	values, err := extract(messageData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "dataconnect", *values[0].ToString())
+	assert.Equal(t, "0800", *values[1].ToString())
+	assert.Nil(t, values[2])
+}
+
+func Test_diameter_ok_accessors(t *testing.T) {
+	avps := diameter.NewAvps()
+	avps = avps.AddUint32(1, 0, 0, 7)
+	avps = avps.Add(2, 0, 0, []byte{0x1})
+	value, ok := avps.GetFirst(1, 0).ToUint32Ok()
+	assert.True(t, ok)
+	assert.Equal(t, uint32(7), value)
+	_, ok = avps.GetFirst(2, 0).ToUint32Ok()
+	assert.False(t, ok)
+	_, ok = avps.GetFirst(3, 0).ToStringOk()
+	assert.False(t, ok)
+	_, ok = avps.GetFirst(2, 0).ToNetIPOk()
+	assert.False(t, ok)
+	assert.Nil(t, avps.GetFirst(2, 0).ToFloat64())
+}
+
+func Test_diameter_group_builder(t *testing.T) {
+	dictionary := diameter.NewDictionary().AddAvp(
+		diameter.AvpDefinition{Name: "Service-Information", Code: 873, VendorId: 10415, Type: diameter.Grouped, Grammar: &diameter.Grammar{
+			Optional: []diameter.Rule{diameter.NewRule(874, 10415, 0, 1)},
+		}},
+		diameter.AvpDefinition{Name: "PS-Information", Code: 874, VendorId: 10415, Type: diameter.Grouped, Grammar: &diameter.Grammar{
+			Required: []diameter.Rule{diameter.NewRule(2, 10415, 1, 1)},
+			Optional: []diameter.Rule{diameter.NewRule(30, 0, 0, 1)},
+		}},
+	)
+	avp, err := diameter.NewGroupBuilder(873, mandatoryFlags, 10415).
+		WithDictionary(dictionary).
+		AddGroup(diameter.NewGroupBuilder(874, mandatoryFlags, 10415).
+			AddUint32(2, mandatoryFlags, 10415, 1).
+			AddString(30, mandatoryFlags, 0, "dataconnect")).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "dataconnect", *avp.ToGroup().GetFirst(874, 10415).ToGroup().GetFirst(30, 0).ToString())
+
+	_, err = diameter.NewGroupBuilder(873, mandatoryFlags, 10415).
+		WithDictionary(dictionary).
+		AddGroup(diameter.NewGroupBuilder(874, mandatoryFlags, 10415).AddString(30, mandatoryFlags, 0, "dataconnect")).
+		Build()
+	var violation diameter.Violation
+	assert.ErrorAs(t, err, &violation)
+	assert.Equal(t, diameter.ResultCodeMissingAvp, violation.ResultCode)
+	assert.Equal(t, diameter.Code(2), violation.Avp.Code)
+}
+
+func Test_diameter_tracked_message(t *testing.T) {
+	message := diameter.NewMessage(1, requestFlags, 272, 4, [4]byte{}, [4]byte{},
+		diameter.NewAvpString(264, mandatoryFlags, 0, "client.example.com"),
+		diameter.NewAvpString(282, mandatoryFlags, 0, "relay-1"),
+		diameter.NewAvpString(282, mandatoryFlags, 0, "relay-2"),
+	)
+	tracked := diameter.Track(message).
+		Replace(diameter.NewAvpString(264, mandatoryFlags, 0, "proxy.example.com")).
+		Remove(282, 0).
+		Add(diameter.NewAvpUint32(278, mandatoryFlags, 0, 1))
+	changes := tracked.Changes()
+	assert.Len(t, changes, 4)
+	assert.Equal(t, diameter.Modified, changes[0].Kind)
+	assert.Equal(t, "client.example.com", *changes[0].Before.ToString())
+	assert.Equal(t, "proxy.example.com", *changes[0].After.ToString())
+	assert.Equal(t, diameter.Removed, changes[1].Kind)
+	assert.Nil(t, changes[1].After)
+	assert.Equal(t, diameter.Added, changes[3].Kind)
+	assert.Equal(t, "added after=0/278:00000001", changes[3].String())
+	modified := tracked.Message()
+	assert.Len(t, modified.Avps, 2)
+	assert.Equal(t, "client.example.com", *message.Avps.GetFirst(264, 0).ToString())
+}
+
+func Test_diameter_tracked_message_long_avp(t *testing.T) {
+	value := strings.Repeat("x", 200)
+	message := diameter.NewMessage(1, requestFlags, 272, 4, [4]byte{}, [4]byte{},
+		diameter.NewAvpString(diameter.AvpErrorMessage, 0, 0, "short"),
+	)
+	change := diameter.Track(message).
+		Replace(diameter.NewAvpString(diameter.AvpErrorMessage, 0, 0, value)).
+		Changes()[0]
+	line := change.String()
+	assert.NotContains(t, line, "\n")
+	assert.Equal(t, "modified before=0/281:73686f7274 after=0/281:"+strings.Repeat("78", 200), line)
+}
+
+func Test_diameter_relay(t *testing.T) {
+	request := diameter.NewMessage(1, requestFlags|diameter.FlagProxiable, 272, 4, [4]byte{0, 0, 0, 1}, [4]byte{0, 0, 0, 2},
+		diameter.NewAvpString(diameter.AvpSessionId, mandatoryFlags, 0, "session"),
+		diameter.NewAvpString(diameter.AvpOriginHost, mandatoryFlags, 0, "client.example.com"),
+	)
+	assert.NoError(t, request.AddRouteRecord("client.example.com"))
+	assert.ErrorIs(t, request.AddRouteRecord("client.example.com"), diameter.ErrLoopDetected)
+	request.PushProxyInfo("proxy-1.example.com", []byte{1})
+	request.PushProxyInfo("proxy-2.example.com", []byte{2})
+
+	answer := request.NewAnswer(diameter.NewAvpUint32(diameter.AvpResultCode, mandatoryFlags, 0, uint32(diameter.ResultCodeSuccess)))
+	assert.False(t, answer.IsRequest())
+	assert.True(t, answer.IsProxiable())
+	assert.Equal(t, request.HopByHopId, answer.HopByHopId)
+	assert.Equal(t, diameter.AvpSessionId, answer.Avps[0].Code)
+	assert.False(t, answer.HasRouteRecord("client.example.com"))
+	assert.Len(t, answer.Avps.Get(diameter.AvpProxyInfo, 0), 2)
+
+	host, state, ok := answer.PopProxyInfo()
+	assert.True(t, ok)
+	assert.Equal(t, "proxy-2.example.com", host)
+	assert.Equal(t, []byte{2}, state)
+	answer.StripProxyInfo()
+	_, _, ok = answer.PopProxyInfo()
+	assert.False(t, ok)
+	request.StripRouteRecords()
+	assert.NoError(t, request.AddRouteRecord("client.example.com"))
+}
+
+func Test_diameter_canonicalize(t *testing.T) {
+	message := diameter.NewMessage(1, requestFlags|0x01, 272, 4, [4]byte{}, [4]byte{},
+		diameter.NewAvpString(diameter.AvpOriginHost, mandatoryFlags|diameter.FlagProtected|0x01, 0, "host"),
+		diameter.NewAvpString(diameter.AvpSessionId, mandatoryFlags, 0, "abc"),
+	)
+	bytes := message.ToBytes()
+	bytes[len(bytes)-1] = 0xff
+	decoded, err := diameter.ReadMessage(bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, bytes, decoded.Canonicalize(diameter.Preserve).ToBytes())
+
+	canonical := decoded.Canonicalize(diameter.Interop)
+	assert.Equal(t, requestFlags, canonical.Flags)
+	assert.Equal(t, diameter.AvpSessionId, canonical.Avps[0].Code)
+	assert.Equal(t, mandatoryFlags, canonical.Avps[1].Flags)
+	canonicalBytes := canonical.ToBytes()
+	assert.Equal(t, byte(0), canonicalBytes[20+11])
+	assert.Equal(t, bytes, decoded.ToBytes())
+}
+
+func Test_diameter_failed_avp(t *testing.T) {
+	grammar := diameter.Grammar{
+		Optional: []diameter.Rule{diameter.NewRule(443, 0, 0, 1).WithGroup(diameter.Grammar{
+			Required: []diameter.Rule{diameter.NewRule(450, 0, 1, 1), diameter.NewRule(444, 0, 1, 1)},
+		})},
+	}
+	avps := diameter.NewAvps().AddGroup(443, mandatoryFlags, 0, diameter.NewAvpUint32(450, mandatoryFlags, 0, 1))
+	violations := grammar.Validate(avps)
+	assert.Len(t, violations, 1)
+	failedAvp := violations[0].FailedAvp()
+	assert.Equal(t, diameter.AvpFailedAvp, failedAvp.Code)
+	assert.True(t, failedAvp.Mandatory())
+	missing := failedAvp.ToGroup().GetFirst(443, 0).ToGroup().GetFirst(444, 0)
+	assert.Equal(t, []byte{}, missing.ToData())
+
+	answer := diameter.NewMessage(1, 0, 272, 4, [4]byte{}, [4]byte{},
+		diameter.NewAvpUint32(diameter.AvpResultCode, mandatoryFlags, 0, uint32(violations[0].ResultCode)),
+		diameter.NewAvpFailedAvp(diameter.NewAvpMissing(diameter.AvpSessionId, 0, 0)),
+	)
+	assert.NotNil(t, answer.Avps.GetFirst(diameter.AvpFailedAvp, 0).ToGroup().GetFirst(diameter.AvpSessionId, 0))
+}
+
+func Test_diameter_schema_registry(t *testing.T) {
+	v1 := diameter.CCF{Name: "Credit-Control-Request", CommandCode: 272, Request: true, Grammar: diameter.Grammar{
+		Fixed: []diameter.Rule{diameter.NewRule(diameter.AvpSessionId, 0, 1, 1)},
+	}}
+	v2 := v1
+	v2.Grammar.Required = []diameter.Rule{diameter.NewRule(diameter.AvpOriginHost, 0, 1, 1)}
+	registry := diameter.NewSchemaRegistry().Register(4, "v1", v1).Register(4, "v2", v2)
+	message := diameter.NewMessage(1, requestFlags, 272, 4, [4]byte{}, [4]byte{}, diameter.NewAvpString(diameter.AvpSessionId, mandatoryFlags, 0, "session"))
+
+	violations, err := registry.Validate(message)
+	assert.NoError(t, err)
+	assert.Empty(t, violations)
+	assert.NoError(t, registry.Activate(4, "v2"))
+	assert.Equal(t, "v2", *registry.ActiveVersion(4))
+	violations, err = registry.Validate(message)
+	assert.NoError(t, err)
+	assert.Len(t, violations, 1)
+	assert.Error(t, registry.Activate(4, "v3"))
+	message.ApplicationId = 16777238
+	_, err = registry.Validate(message)
+	assert.Error(t, err)
+}
+
+func Test_diameter_reject_unknown_mandatory(t *testing.T) {
+	dictionary := diameter.NewDictionary().AddAvp(
+		diameter.AvpDefinition{Name: "Session-Id", Code: 263, Type: diameter.UTF8String},
+		diameter.AvpDefinition{Name: "Subscription-Id", Code: 443, Type: diameter.Grouped},
+	)
+	message := diameter.NewMessage(1, requestFlags, 272, 4, [4]byte{}, [4]byte{},
+		diameter.NewAvpString(263, mandatoryFlags, 0, "session"),
+		diameter.NewAvpGroup(443, mandatoryFlags, 0, diameter.NewAvpUint32(450, mandatoryFlags, 0, 1)),
+		diameter.NewAvpUint32(999, 0, 0, 1),
+		diameter.NewAvpUint32(998, mandatoryFlags, 10415, 1),
+	)
+	decoder := diameter.Decoder{Dictionary: dictionary, RejectUnknownMandatory: true}
+	decoded, err := decoder.ReadMessage(message.ToBytes())
+	assert.NotNil(t, decoded)
+	var unknownMandatory *diameter.UnknownMandatoryError
+	assert.ErrorAs(t, err, &unknownMandatory)
+	assert.Len(t, unknownMandatory.Avps, 2)
+	assert.Equal(t, diameter.Code(450), unknownMandatory.Avps[0].Code)
+	assert.Equal(t, diameter.Code(998), unknownMandatory.Avps[1].Code)
+	assert.Equal(t, diameter.ResultCodeAvpUnsupported, unknownMandatory.ResultCode())
+	failedAvp := unknownMandatory.FailedAvp()
+	assert.Len(t, failedAvp.ToGroup(), 2)
+
+	_, err = diameter.Decoder{}.ReadMessage(message.ToBytes())
+	assert.NoError(t, err)
+}
+
+func Test_diameter_errors(t *testing.T) {
+	_, err := diameter.ReadMessage(make([]byte, 10))
+	assert.ErrorIs(t, err, diameter.ErrTruncated)
+
+	message := diameter.NewMessage(1, requestFlags, 272, 4, [4]byte{}, [4]byte{}, diameter.NewAvpUint32(diameter.AvpResultCode, mandatoryFlags, 0, 2001))
+	data := message.ToBytes()
+	data[27] = 40
+	_, err = diameter.ReadMessage(data)
+	var avpError *diameter.AvpError
+	assert.ErrorAs(t, err, &avpError)
+	assert.Equal(t, diameter.ResultCodeInvalidAvpLength, avpError.ResultCode)
+	assert.ErrorIs(t, err, diameter.ErrInvalidLength)
+	var invalidLength *diameter.InvalidLengthError
+	assert.ErrorAs(t, err, &invalidLength)
+	assert.Equal(t, diameter.InvalidLengthError{Offset: 0, Declared: 40, Available: 12}, *invalidLength)
+
+	_, err = diameter.ReadMessage(message.ToBytes()[:24])
+	assert.ErrorIs(t, err, diameter.ErrTruncated)
+
+	_, _, err = diameter.SplitMessages(append(message.ToBytes(), 1, 0, 0, 4))
+	assert.ErrorAs(t, err, &invalidLength)
+	assert.Equal(t, diameter.InvalidLengthError{Offset: 32, Declared: 4, Available: 4}, *invalidLength)
+
+	_, err = diameter.ReadMessageFrom(bytes.NewReader([]byte{1, 0, 0, 4}))
+	assert.ErrorIs(t, err, diameter.ErrInvalidLength)
+
+	decoder := diameter.Decoder{Dictionary: diameter.NewDictionary(), RejectUnknownMandatory: true}
+	_, err = decoder.ReadMessage(message.ToBytes())
+	assert.ErrorIs(t, err, diameter.ErrUnknownMandatoryAvp)
+}
+
+func Test_diameter_limits(t *testing.T) {
+	dictionary := diameter.NewDictionary().AddAvp(diameter.AvpDefinition{Name: "Subscription-Id", Code: 443, Type: diameter.Grouped})
+	nested := diameter.NewAvpUint32(450, mandatoryFlags, 0, 1)
+	for range 3 {
+		nested = diameter.NewAvpGroup(443, mandatoryFlags, 0, nested)
+	}
+	message := diameter.NewMessage(1, requestFlags, 272, 4, [4]byte{}, [4]byte{}, nested, diameter.NewAvpUint32(450, mandatoryFlags, 0, 2))
+	data := message.ToBytes()
+
+	_, err := diameter.Decoder{Dictionary: dictionary}.ReadMessage(data)
+	assert.NoError(t, err)
+	_, err = diameter.Decoder{Dictionary: dictionary, Limits: diameter.Limits{MaxDepth: 2}}.ReadMessage(data)
+	assert.ErrorIs(t, err, diameter.ErrLimitExceeded)
+	var limitError *diameter.LimitError
+	assert.ErrorAs(t, err, &limitError)
+	assert.Equal(t, diameter.LimitError{Limit: "nesting depth", Max: 2}, *limitError)
+	_, err = diameter.Decoder{Dictionary: dictionary, Limits: diameter.Limits{MaxAvps: 4}}.ReadMessage(data)
+	assert.EqualError(t, err, "AVP count exceeds the limit of 4")
+	_, err = diameter.Decoder{Limits: diameter.Limits{MaxAvps: 2}}.ReadMessage(data)
+	assert.NoError(t, err)
+	_, err = diameter.Decoder{Limits: diameter.Limits{MaxMessageSize: 40}}.ReadMessage(data)
+	assert.EqualError(t, err, "message size exceeds the limit of 40")
+
+	decoder := diameter.Decoder{Limits: diameter.Limits{MaxMessageSize: 40}}
+	_, err = decoder.ReadMessageFrom(bytes.NewReader(data))
+	assert.ErrorIs(t, err, diameter.ErrLimitExceeded)
+	_, err = diameter.ReadMessageFrom(bytes.NewReader([]byte{1, 0xff, 0xff, 0xff}))
+	assert.ErrorIs(t, err, diameter.ErrLimitExceeded)
+	decoded, err := diameter.Decoder{}.ReadMessageFrom(bytes.NewReader(data))
+	assert.NoError(t, err)
+	assert.Equal(t, message.ToBytes(), decoded.ToBytes())
+}
+
+func Test_diameter_codec(t *testing.T) {
+	type version struct{ Major, Minor uint16 }
+	codec := diameter.Codec{
+		Decode: func(data []byte) (any, error) {
+			if len(data) != 4 {
+				return nil, errors.New("version must be 4 bytes")
+			}
+			return version{binary.BigEndian.Uint16(data[0:2]), binary.BigEndian.Uint16(data[2:4])}, nil
+		},
+		Encode: func(value any) ([]byte, error) {
+			v, ok := value.(version)
+			if !ok {
+				return nil, fmt.Errorf("%T is not a version", value)
+			}
+			return binary.BigEndian.AppendUint16(binary.BigEndian.AppendUint16(nil, v.Major), v.Minor), nil
+		},
+	}
+	dictionary := diameter.NewDictionary().
+		AddAvp(
+			diameter.AvpDefinition{Name: "Result-Code", Code: 268, Type: diameter.Unsigned32},
+			diameter.AvpDefinition{Name: "Disconnect-Cause", Code: 273, Type: diameter.Enumerated, Values: map[uint32]string{0: "REBOOTING"}},
+		).
+		AddCodec(5000, 10415, codec)
+
+	avp, err := diameter.NewAvpValue(dictionary, 5000, 0, 10415, version{2, 1})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0, 2, 0, 1}, avp.ToData())
+	assert.Equal(t, version{2, 1}, avp.TypedValue(dictionary))
+	_, err = diameter.NewAvpValue(dictionary, 5000, 0, 10415, "2.1")
+	assert.EqualError(t, err, "AVP 5000 (vendor 10415): string is not a version")
+	_, err = diameter.NewAvpValue(dictionary, 268, 0, 0, version{2, 1})
+	assert.EqualError(t, err, "no codec encodes AVP 268 (vendor 0)")
+
+	malformed := diameter.NewAvp(5000, 0, 10415, []byte{1})
+	assert.Equal(t, []byte{1}, malformed.TypedValue(dictionary))
+	resultCode := diameter.NewAvpUint32(268, 0, 0, 2001)
+	assert.Equal(t, uint32(2001), resultCode.TypedValue(dictionary))
+	cause := diameter.NewAvpUint32(273, 0, 0, 0)
+	assert.Equal(t, "REBOOTING", cause.TypedValue(dictionary))
+	assert.Equal(t, []byte{0, 0, 0, 0}, cause.TypedValue(nil))
+}
+
+func Test_diameter_tbcd(t *testing.T) {
+	encoded, err := threegpp.EncodeTBCD("234150999999999")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x32, 0x14, 0x05, 0x99, 0x99, 0x99, 0x99, 0xf9}, encoded)
+	decoded, err := threegpp.DecodeTBCD(encoded)
+	assert.NoError(t, err)
+	assert.Equal(t, "234150999999999", decoded)
+	encoded, _ = threegpp.EncodeTBCD("*12#")
+	assert.Equal(t, []byte{0x1a, 0xb2}, encoded)
+	_, err = threegpp.EncodeTBCD("12x")
+	assert.EqualError(t, err, `invalid TBCD digit 'x'`)
+	_, err = threegpp.DecodeTBCD([]byte{0xf1, 0x32})
+	assert.EqualError(t, err, "unexpected TBCD filler in octet 0")
+
+	encoded, err = threegpp.EncodeBCD("12345")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x12, 0x34, 0x50}, encoded)
+	decoded, err = threegpp.DecodeBCD([]byte{0x12, 0x34})
+	assert.NoError(t, err)
+	assert.Equal(t, "1234", decoded)
+	_, err = threegpp.DecodeBCD([]byte{0x1f})
+	assert.Error(t, err)
+
+	avp, err := diameter.NewAvpTBCD(701, mandatoryFlags|diameter.FlagVendorSpecific, diameter.Vendor3GPP, "447700900123")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x44, 0x77, 0x00, 0x09, 0x10, 0x32}, avp.ToData())
+	assert.Equal(t, "447700900123", avp.ToTBCDOrDefault())
+	_, err = diameter.NewAvpTBCD(701, 0, 0, "+44")
+	assert.Error(t, err)
+	assert.Nil(t, (*diameter.Avp)(nil).ToTBCD())
+}
+
+func Test_diameter_user_location_info(t *testing.T) {
+	plmn := threegpp.PLMN{MCC: "234", MNC: "15"}
+	for _, location := range []threegpp.UserLocationInfo{
+		{Type: threegpp.LocationCGI, CGI: &threegpp.CGI{PLMN: plmn, LAC: 0x1234, CI: 0x5678}},
+		{Type: threegpp.LocationSAI, SAI: &threegpp.SAI{PLMN: plmn, LAC: 0x1234, SAC: 0x0042}},
+		{Type: threegpp.LocationRAI, RAI: &threegpp.RAI{PLMN: plmn, LAC: 0x1234, RAC: 0x07}},
+		{Type: threegpp.LocationTAI, TAI: &threegpp.TAI{PLMN: plmn, TAC: 0x0101}},
+		{Type: threegpp.LocationECGI, ECGI: &threegpp.ECGI{PLMN: plmn, ECI: 0x1234567}},
+	} {
+		avp, err := diameter.NewAvpUserLocationInfo(location)
+		assert.NoError(t, err)
+		assert.Equal(t, location, avp.ToUserLocationInfoOrDefault())
+	}
+
+	avp, err := diameter.NewAvpUserLocationInfo(threegpp.UserLocationInfo{Type: threegpp.LocationRAI, RAI: &threegpp.RAI{PLMN: plmn, LAC: 1, RAC: 2}})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x02, 0x32, 0xf4, 0x51, 0x00, 0x01, 0x02, 0xff}, avp.ToData())
+	assert.True(t, avp.VendorSpecific())
+	avps := diameter.NewAvps().AddAvps(avp)
+	assert.Equal(t, uint8(2), avps.UserLocationInfo().RAI.RAC)
+
+	_, err = diameter.NewAvpUserLocationInfo(threegpp.UserLocationInfo{Type: threegpp.LocationCGI})
+	assert.EqualError(t, err, "missing identity of geographic location type 0")
+	_, err = threegpp.DecodeUserLocationInfo([]byte{0x00, 0x32, 0xf4, 0x51})
+	assert.EqualError(t, err, "invalid length 3 of geographic location type 0")
+	_, err = threegpp.DecodeUserLocationInfo([]byte{0x99})
+	assert.EqualError(t, err, "unsupported geographic location type 153")
+	empty := diameter.NewAvp(diameter.AvpThreeGPPUserLocationInfo, diameter.FlagVendorSpecific, diameter.Vendor3GPP, []byte{})
+	assert.Nil(t, empty.ToUserLocationInfo())
+}
@@ -0,0 +1,64 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tinybluerobots/radius-diameter-message/diameter"
+	"github.com/tinybluerobots/radius-diameter-message/diameter/disconnect"
+)
+
+type disconnectTransport struct {
+	received chan diameter.Message
+	sent     []diameter.Message
+	closed   bool
+	answer   bool
+}
+
+func (t *disconnectTransport) Send(message diameter.Message) error {
+	t.sent = append(t.sent, message)
+	if t.answer {
+		dpa := disconnect.DPA{ResultCode: diameter.ResultCodeSuccess, OriginHost: "server.example.com", OriginRealm: "example.com"}
+		t.received <- diameter.NewMessage(1, 0, diameter.CommandDeviceWatchdog, 0, message.HopByHopId, message.EndToEndId)
+		t.received <- dpa.ToMessage(message)
+	}
+	return nil
+}
+
+func (t *disconnectTransport) Close() error {
+	t.closed = true
+	return nil
+}
+
+func Test_disconnect_messages(t *testing.T) {
+	dpr := disconnect.DPR{OriginHost: "client.example.com", OriginRealm: "example.com", DisconnectCause: disconnect.CauseBusy}
+	request := dpr.ToMessage([4]byte{0, 0, 0, 1}, [4]byte{0, 0, 0, 2})
+	assert.True(t, disconnect.IsDPR(request))
+	decoded := disconnect.DPR{}
+	assert.NoError(t, decoded.FromMessage(request))
+	assert.Equal(t, dpr, decoded)
+	assert.Equal(t, "BUSY", decoded.DisconnectCause.String())
+	assert.Error(t, (&disconnect.DPA{}).FromMessage(request))
+}
+
+func Test_disconnect_graceful(t *testing.T) {
+	transport := &disconnectTransport{received: make(chan diameter.Message, 2), answer: true}
+	dpr := disconnect.DPR{OriginHost: "client.example.com", OriginRealm: "example.com", DisconnectCause: disconnect.CauseRebooting}
+	dpa, err := disconnect.Disconnect(context.Background(), transport, transport.received, dpr, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, diameter.ResultCodeSuccess, dpa.ResultCode)
+	assert.Len(t, transport.sent, 1)
+	assert.True(t, transport.closed)
+}
+
+func Test_disconnect_timeout(t *testing.T) {
+	transport := &disconnectTransport{received: make(chan diameter.Message)}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	dpa, err := disconnect.Disconnect(ctx, transport, transport.received, disconnect.DPR{}, nil)
+	assert.Nil(t, dpa)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.True(t, transport.closed)
+}
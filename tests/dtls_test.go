@@ -0,0 +1,124 @@
+package tests
+
+import (
+	"context"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tinybluerobots/radius-diameter-message/radius"
+	radiusclient "github.com/tinybluerobots/radius-diameter-message/radius/client"
+	radiusserver "github.com/tinybluerobots/radius-diameter-message/radius/server"
+)
+
+// pipeListener accepts the server ends of in-memory connections, standing in
+// for a DTLS listener since each Write on a pipe is read whole.
+type pipeListener struct {
+	conns chan net.Conn
+	done  chan struct{}
+}
+
+func (l *pipeListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *pipeListener) Close() error {
+	close(l.done)
+	return nil
+}
+
+func (l *pipeListener) Addr() net.Addr {
+	return &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 2083}
+}
+
+func Test_radius_over_dtls(t *testing.T) {
+	s := radiusserver.New(radiusserver.Config{})
+	s.Handle(radius.CodeAccessRequest, func(ctx context.Context, writer radiusserver.ResponseWriter, request radius.Message) {
+		secret, _ := radiusserver.SecretFromContext(ctx)
+		access := radius.AccessRequest{}
+		access.FromMessage(request, secret)
+		writer.WriteResponse(radius.NewMessage(radius.CodeAccessAccept, 0, [16]byte{},
+			radius.NewAvpString(radius.AttributeUserName, 0, string(access.UserPassword))))
+	})
+	listener := &pipeListener{conns: make(chan net.Conn), done: make(chan struct{})}
+	go s.ServeDTLS(listener)
+	defer s.Close()
+
+	var dials atomic.Int32
+	c := radiusclient.NewDTLSClient(func(ctx context.Context, address string) (net.Conn, error) {
+		dials.Add(1)
+		clientConn, serverConn := net.Pipe()
+		listener.conns <- serverConn
+		return clientConn, nil
+	}, radiusclient.Config{Timeout: time.Second, MTU: 100})
+	defer c.Close()
+
+	for _, password := range []string{"arctangent", "secant"} {
+		request, _ := radius.AccessRequest{UserName: "nemo", UserPassword: []byte(password)}.Build(radius.DTLSSecret)
+		response, err := c.Send(context.Background(), request, "127.0.0.1:2083")
+		assert.NoError(t, err)
+		assert.Equal(t, radius.CodeAccessAccept, response.Code)
+		assert.Equal(t, password, response.Avps.GetFirst(radius.AttributeUserName, 0).ToStringOrDefault())
+	}
+	assert.Equal(t, int32(1), dials.Load())
+
+	request, _ := radius.AccessRequest{UserName: string(make([]byte, 100))}.Build(radius.DTLSSecret)
+	_, err := c.Send(context.Background(), request, "127.0.0.1:2083")
+	assert.ErrorIs(t, err, radiusclient.ErrTooLarge)
+
+	c.Close()
+	_, err = c.Send(context.Background(), request, "127.0.0.1:2083")
+	assert.ErrorIs(t, err, radiusclient.ErrClosed)
+}
+
+func Test_radius_dtls_session_dials_without_lock(t *testing.T) {
+	s := radiusserver.New(radiusserver.Config{})
+	s.Handle(radius.CodeAccessRequest, func(ctx context.Context, writer radiusserver.ResponseWriter, request radius.Message) {
+		writer.WriteResponse(radius.NewMessage(radius.CodeAccessAccept, 0, [16]byte{}))
+	})
+	listener := &pipeListener{conns: make(chan net.Conn), done: make(chan struct{})}
+	go s.ServeDTLS(listener)
+	defer s.Close()
+
+	stalled := make(chan struct{})
+	var dials atomic.Int32
+	c := radiusclient.NewDTLSClient(func(ctx context.Context, address string) (net.Conn, error) {
+		if address == "192.0.2.1:2083" {
+			<-stalled
+			return nil, net.ErrClosed
+		}
+		dials.Add(1)
+		clientConn, serverConn := net.Pipe()
+		listener.conns <- serverConn
+		return clientConn, nil
+	}, radiusclient.Config{Timeout: time.Second})
+	defer c.Close()
+	defer close(stalled)
+
+	request, _ := radius.AccessRequest{UserName: "nemo", UserPassword: []byte("arctangent")}.Build(radius.DTLSSecret)
+	go c.Send(context.Background(), request, "192.0.2.1:2083")
+	time.Sleep(10 * time.Millisecond)
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			response, err := c.Send(ctx, request, "127.0.0.1:2083")
+			assert.NoError(t, err)
+			assert.Equal(t, radius.CodeAccessAccept, response.Code)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), dials.Load())
+}
@@ -0,0 +1,64 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tinybluerobots/radius-diameter-message/diameter"
+	"github.com/tinybluerobots/radius-diameter-message/radius"
+	"github.com/tinybluerobots/radius-diameter-message/radius/rfc2865"
+)
+
+func Test_diameter_dump(t *testing.T) {
+	dictionary := diameter.NewDictionary().
+		AddCommand(272, "Credit-Control").
+		AddAvp(
+			diameter.AvpDefinition{Name: "Session-Id", Code: 263, Type: diameter.UTF8String},
+			diameter.AvpDefinition{Name: "Subscription-Id", Code: 443, Type: diameter.Grouped},
+			diameter.AvpDefinition{Name: "Subscription-Id-Data", Code: 444, Type: diameter.UTF8String},
+		)
+	avps := diameter.NewAvps()
+	avps = avps.AddString(263, mandatoryFlags, 0, "session")
+	avps = avps.AddGroup(443, mandatoryFlags, 0, diameter.NewAvpString(444, mandatoryFlags, 0, "901280064290558"))
+	avps = avps.Add(999, 0x80, 10415, []byte{0xde, 0xad})
+	message := diameter.NewMessage(1, requestFlags, 272, 4, [4]byte{0, 0, 0, 1}, [4]byte{0, 0, 0, 2}, avps...)
+	dump := diameter.Dump(message.ToBytes(), dictionary)
+	t.Log("\n" + dump)
+	assert.Contains(t, dump, "0000  01                                               Version: 1\n")
+	assert.Contains(t, dump, "0005  00 01 10                                         Command Code: 272 Credit-Control-Request\n")
+	assert.Contains(t, dump, "0014  00 00 01 07                                      AVP Code: 263 (Session-Id)\n")
+	assert.Contains(t, dump, "001c  73 65 73 73 69 6f 6e                             Value: \"session\"\n")
+	assert.Contains(t, dump, "0023  00                                               Padding\n")
+	assert.Contains(t, dump, "Grouped AVPs:\n")
+	assert.Contains(t, dump, "  AVP Code: 444 (Subscription-Id-Data)\n")
+	assert.Contains(t, dump, "Vendor-Id: 10415 (3GPP)\n")
+	assert.Contains(t, dump, "de ad                                            Data\n")
+
+	truncated := diameter.Dump(message.ToBytes()[:30], dictionary)
+	assert.Contains(t, truncated, "Malformed: length")
+	assert.Contains(t, truncated, "Malformed: AVP length 15\n")
+}
+
+func Test_radius_dump(t *testing.T) {
+	dictionary := radius.NewDictionary().AddAttribute(rfc2865.Attributes...)
+	avps := radius.NewAvps().
+		AddString(rfc2865.UserName, 0, "alice").
+		AddUint32(rfc2865.ServiceType, 0, 2).
+		AddString(1, 10415, "901280064290558").
+		AddExtended(radius.ExtendedAttribute1, 1, []byte{0x01})
+	message := radius.NewMessage(radius.CodeAccessRequest, 7, [16]byte{}, avps...)
+	dump := radius.Dump(message.ToBytes(), dictionary)
+	t.Log("\n" + dump)
+	assert.Contains(t, dump, "0000  01                                               Code: 1 (ACCESS_REQUEST)\n")
+	assert.Contains(t, dump, "0001  07                                               Identifier: 7\n")
+	assert.Contains(t, dump, "0014  01                                               Attribute Type: 1 (User-Name)\n")
+	assert.Contains(t, dump, "0016  61 6c 69 63 65                                   Value: \"alice\"\n")
+	assert.Contains(t, dump, "Value: Framed-User (2)\n")
+	assert.Contains(t, dump, "  Vendor-Id: 10415 (3GPP)\n")
+	assert.Contains(t, dump, "  Vendor Type: 1 (Unknown)\n")
+	assert.Contains(t, dump, "  Extended-Type: 1\n")
+
+	truncated := radius.Dump(message.ToBytes()[:24], dictionary)
+	assert.Contains(t, truncated, "Malformed: length")
+	assert.Contains(t, truncated, "Malformed: attribute length 7\n")
+}
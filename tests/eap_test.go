@@ -0,0 +1,105 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tinybluerobots/radius-diameter-message/radius"
+	"github.com/tinybluerobots/radius-diameter-message/radius/eap"
+)
+
+func Test_eap_packet(t *testing.T) {
+	identity := eap.NewIdentity(eap.CodeResponse, 1, "user@example.com")
+	bytes := identity.ToBytes()
+	assert.Equal(t, []byte{2, 1, 0, 21, 1}, bytes[:5])
+	packet, err := eap.Read(append(bytes, 0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	value, ok := packet.Identity()
+	assert.True(t, ok)
+	assert.Equal(t, "user@example.com", value)
+
+	success, err := eap.Read(eap.Packet{Code: eap.CodeSuccess, Identifier: 2}.ToBytes())
+	assert.NoError(t, err)
+	assert.Equal(t, eap.CodeSuccess, success.Code)
+	assert.Equal(t, "SUCCESS", success.Code.String())
+
+	_, err = eap.Read([]byte{1, 1, 0, 4})
+	assert.True(t, errors.Is(err, eap.ErrInvalidPacket))
+	_, err = eap.Read([]byte{1, 1, 0, 9, 1})
+	assert.True(t, errors.Is(err, eap.ErrInvalidPacket))
+	assert.Equal(t, []byte{2, 3, 0, 7, 3, 13, 25}, eap.NewNak(3, eap.TypeTLS, eap.TypePEAP).ToBytes())
+}
+
+func Test_eap_radius(t *testing.T) {
+	message := radius.NewMessage(1, 1, [16]byte{})
+	eap.NewIdentity(eap.CodeResponse, 7, "user").AddTo(&message)
+	decoded, err := radius.ReadMessage(message.ToBytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	packet, err := eap.FromMessage(*decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, byte(7), packet.Identifier)
+	_, err = eap.FromMessage(radius.NewMessage(1, 1, [16]byte{}))
+	assert.Error(t, err)
+}
+
+func Test_eap_md5_challenge(t *testing.T) {
+	request := eap.MD5Challenge{Value: []byte("0123456789abcdef"), Name: "server"}.ToPacket(eap.CodeRequest, 5)
+	challenge := eap.MD5Challenge{}
+	assert.NoError(t, challenge.FromPacket(request))
+	assert.Equal(t, "server", challenge.Name)
+	response := eap.MD5Challenge{Value: eap.MD5ChallengeResponse(5, []byte("password"), challenge.Value)}
+	chap := radius.CHAPResponse(5, []byte("password"), []byte("0123456789abcdef"))
+	assert.Equal(t, chap[:], response.Value)
+	assert.Error(t, challenge.FromPacket(eap.Packet{Code: eap.CodeRequest, Type: eap.TypeMD5Challenge, Data: []byte{9, 1}}))
+}
+
+func Test_eap_mschapv2(t *testing.T) {
+	challenge := eap.MSCHAPv2{OpCode: eap.OpCodeChallenge, Id: 9, Data: []byte{16, 1, 2}}
+	packet, err := eap.Read(challenge.ToPacket(eap.CodeRequest, 1).ToBytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := eap.MSCHAPv2{}
+	assert.NoError(t, decoded.FromPacket(packet))
+	assert.Equal(t, challenge, decoded)
+
+	ack := eap.MSCHAPv2{OpCode: eap.OpCodeSuccess, Short: true}
+	assert.NoError(t, decoded.FromPacket(ack.ToPacket(eap.CodeResponse, 2)))
+	assert.Equal(t, ack, decoded)
+}
+
+func Test_eap_tls_fragments(t *testing.T) {
+	message := make([]byte, 2500)
+	for i := range message {
+		message[i] = byte(i)
+	}
+	fragments := eap.FragmentTLS(message, 1000)
+	assert.Len(t, fragments, 3)
+	assert.True(t, fragments[0].Flags.Has(eap.TLSLengthIncluded|eap.TLSMoreFragments))
+	assert.False(t, fragments[2].Flags.Has(eap.TLSMoreFragments))
+
+	received := make([]eap.TLS, 0, len(fragments))
+	for i, fragment := range fragments {
+		packet, err := eap.Read(fragment.ToPacket(eap.CodeRequest, byte(i), eap.TypePEAP).ToBytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		tls := eap.TLS{}
+		assert.NoError(t, tls.FromPacket(packet))
+		received = append(received, tls)
+	}
+	assert.Equal(t, uint32(2500), received[0].Length)
+	joined, err := eap.JoinTLS(received...)
+	assert.NoError(t, err)
+	assert.Equal(t, message, joined)
+	_, err = eap.JoinTLS(received[:2]...)
+	assert.Error(t, err)
+	assert.Len(t, eap.FragmentTLS(message[:10], 1000), 1)
+}
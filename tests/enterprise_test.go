@@ -0,0 +1,20 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tinybluerobots/radius-diameter-message/diameter"
+	"github.com/tinybluerobots/radius-diameter-message/enterprise"
+)
+
+func Test_enterprise_registry(t *testing.T) {
+	assert.Equal(t, "3GPP", *enterprise.Name(10415))
+	assert.Equal(t, uint32(9), *enterprise.Number("Cisco"))
+	assert.Nil(t, enterprise.Name(99999))
+	enterprise.Register(99999, "Example")
+	defer enterprise.Unregister(99999)
+	assert.Equal(t, "Example", *enterprise.Name(99999))
+	avp := diameter.NewAvpUint32(1, 0x80, 99999, 1)
+	assert.Contains(t, avp.String(), "vnd=Example")
+}
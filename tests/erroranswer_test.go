@@ -0,0 +1,107 @@
+package tests
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tinybluerobots/radius-diameter-message/diameter"
+	"github.com/tinybluerobots/radius-diameter-message/diameter/capabilities"
+	"github.com/tinybluerobots/radius-diameter-message/diameter/server"
+)
+
+// malformedRequest returns a request whose last AVP claims to be longer than the message.
+func malformedRequest() []byte {
+	request := diameter.NewMessage(1, requestFlags, diameter.CommandCreditControl, diameter.ApplicationCreditControl, [4]byte{0, 0, 0, 1}, [4]byte{0, 0, 0, 2},
+		diameter.NewAvpString(diameter.AvpSessionId, mandatoryFlags, 0, "a"),
+		diameter.NewAvpUint32(diameter.AvpResultCode, mandatoryFlags, 0, 1))
+	bytes := request.ToBytes()
+	bytes[len(bytes)-7] = 64
+	return bytes
+}
+
+func Test_error_answer_avp_length(t *testing.T) {
+	bytes := malformedRequest()
+	_, err := diameter.ReadMessage(bytes)
+	var avpError *diameter.AvpError
+	assert.ErrorAs(t, err, &avpError)
+	assert.Equal(t, diameter.ResultCodeInvalidAvpLength, avpError.ResultCode)
+	assert.Equal(t, 12, avpError.Offset)
+
+	header, err := diameter.ReadHeader(bytes)
+	assert.NoError(t, err)
+	answer := diameter.ErrorAnswer(header, avpError)
+	assert.False(t, answer.IsRequest())
+	assert.False(t, answer.IsError())
+	assert.Equal(t, [4]byte{0, 0, 0, 1}, answer.HopByHopId)
+	assert.Equal(t, uint32(diameter.ResultCodeInvalidAvpLength), answer.Avps.GetFirst(diameter.AvpResultCode, 0).ToUint32OrDefault())
+	failed := answer.Avps.GetFirst(diameter.AvpFailedAvp, 0).ToGroup()
+	assert.Len(t, failed, 1)
+	assert.Equal(t, diameter.AvpResultCode, failed[0].Code)
+}
+
+func Test_error_answer_header(t *testing.T) {
+	bytes := diameter.NewMessage(2, requestFlags, diameter.CommandCreditControl, diameter.ApplicationCreditControl, [4]byte{}, [4]byte{}).ToBytes()
+	header, err := diameter.ReadHeader(bytes)
+	var headerError *diameter.HeaderError
+	assert.ErrorAs(t, err, &headerError)
+	assert.Equal(t, diameter.ResultCodeUnsupportedVersion, headerError.ResultCode)
+	assert.Equal(t, diameter.CommandCreditControl, header.CommandCode)
+
+	bytes = diameter.NewMessage(1, requestFlags|diameter.FlagError, diameter.CommandCreditControl, diameter.ApplicationCreditControl, [4]byte{}, [4]byte{}).ToBytes()
+	header, err = diameter.ReadHeader(bytes)
+	answer := diameter.ErrorAnswer(header, err)
+	assert.True(t, answer.IsError())
+	assert.Equal(t, uint32(diameter.ResultCodeInvalidHdrBits), answer.Avps.GetFirst(diameter.AvpResultCode, 0).ToUint32OrDefault())
+	assert.Equal(t, answer.Version, byte(1))
+}
+
+func Test_error_answer_other(t *testing.T) {
+	header := diameter.Header{Version: 1, Flags: requestFlags | diameter.FlagProxiable, CommandCode: diameter.CommandCreditControl}
+	answer := diameter.ErrorAnswer(header, errors.New("database unavailable"))
+	assert.Equal(t, uint32(diameter.ResultCodeUnableToComply), answer.Avps.GetFirst(diameter.AvpResultCode, 0).ToUint32OrDefault())
+	assert.Equal(t, "database unavailable", answer.Avps.GetFirst(diameter.AvpErrorMessage, 0).ToStringOrDefault())
+	assert.True(t, answer.IsProxiable())
+
+	violation := diameter.Violation{ResultCode: diameter.ResultCodeMissingAvp, Avp: diameter.NewAvpMissing(diameter.AvpOriginHost, 0, 0), Reason: "missing"}
+	answer = diameter.ErrorAnswer(header, violation)
+	assert.Equal(t, uint32(diameter.ResultCodeMissingAvp), answer.Avps.GetFirst(diameter.AvpResultCode, 0).ToUint32OrDefault())
+	assert.NotNil(t, answer.Avps.GetFirst(diameter.AvpFailedAvp, 0))
+}
+
+func Test_server_error_answer(t *testing.T) {
+	address := startServer(t, server.New(server.Config{Capabilities: serverCapabilities}))
+	conn, err := net.Dial("tcp", address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	cer := capabilities.CER{Capabilities: clientCapabilities}
+	conn.Write(cer.ToMessage([4]byte{}, [4]byte{}).ToBytes())
+	if _, err := diameter.ReadMessageFrom(reader); err != nil {
+		t.Fatal(err)
+	}
+
+	conn.Write(malformedRequest())
+	answer, err := diameter.ReadMessageFrom(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, uint32(diameter.ResultCodeInvalidAvpLength), answer.Avps.GetFirst(diameter.AvpResultCode, 0).ToUint32OrDefault())
+	assert.Equal(t, "server.example.com", answer.Avps.GetFirst(diameter.AvpOriginHost, 0).ToStringOrDefault())
+
+	request := diameter.NewMessage(1, requestFlags, diameter.CommandCreditControl, diameter.ApplicationCreditControl, [4]byte{0, 0, 0, 3}, [4]byte{})
+	bytes := request.ToBytes()
+	bytes[0] = 2
+	conn.Write(bytes)
+	answer, err = diameter.ReadMessageFrom(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, uint32(diameter.ResultCodeUnsupportedVersion), answer.Avps.GetFirst(diameter.AvpResultCode, 0).ToUint32OrDefault())
+	assert.Equal(t, uint32(3), binary.BigEndian.Uint32(answer.HopByHopId[:]))
+}
@@ -0,0 +1,82 @@
+package tests
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tinybluerobots/radius-diameter-message/diameter"
+	"github.com/tinybluerobots/radius-diameter-message/diameter/capabilities"
+	"github.com/tinybluerobots/radius-diameter-message/diameter/client"
+	"github.com/tinybluerobots/radius-diameter-message/diameter/server"
+)
+
+// failingPeer answers the CER, then closes the connection on the first request.
+func failingPeer(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		message, err := diameter.ReadMessageFrom(reader)
+		if err != nil {
+			return
+		}
+		cer := capabilities.CER{}
+		cer.FromMessage(*message)
+		conn.Write(capabilities.Answer(serverCapabilities, cer).ToMessage(*message).ToBytes())
+		diameter.ReadMessageFrom(reader)
+	}()
+	return listener.Addr().String()
+}
+
+func dialFailover(t *testing.T, config client.FailoverConfig) (*client.Failover, chan diameter.Message) {
+	received := make(chan diameter.Message, 1)
+	s := server.New(server.Config{Capabilities: serverCapabilities})
+	s.Handle(diameter.ApplicationCreditControl, diameter.CommandCreditControl, func(ctx context.Context, writer server.AnswerWriter, request diameter.Message) {
+		received <- request
+		writer.WriteAnswer(request.NewAnswer(diameter.NewAvpUint32(diameter.AvpResultCode, mandatoryFlags, 0, uint32(diameter.ResultCodeSuccess))))
+	})
+	primary, err := client.Dial(context.Background(), failingPeer(t), client.Config{Capabilities: clientCapabilities})
+	if err != nil {
+		t.Fatal(err)
+	}
+	alternate, err := client.Dial(context.Background(), startServer(t, s), client.Config{Capabilities: clientCapabilities})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		primary.Close()
+		alternate.Close()
+	})
+	return client.NewFailover(config, primary, alternate), received
+}
+
+func Test_failover_retransmits(t *testing.T) {
+	failover, received := dialFailover(t, client.FailoverConfig{})
+	request := diameter.NewMessage(1, 0, diameter.CommandCreditControl, diameter.ApplicationCreditControl, [4]byte{}, [4]byte{0, 0, 0, 9})
+	answer, err := failover.SendRequest(context.Background(), request)
+	assert.NoError(t, err)
+	assert.Equal(t, [4]byte{0, 0, 0, 9}, answer.EndToEndId)
+	retransmitted := <-received
+	assert.True(t, retransmitted.IsRetransmitted())
+	assert.Equal(t, [4]byte{0, 0, 0, 9}, retransmitted.EndToEndId)
+}
+
+func Test_failover_policy(t *testing.T) {
+	failover, _ := dialFailover(t, client.FailoverConfig{Policy: client.RetransmitCommands(diameter.CommandAccounting)})
+	request := diameter.NewMessage(1, 0, diameter.CommandCreditControl, diameter.ApplicationCreditControl, [4]byte{}, [4]byte{})
+	_, err := failover.SendRequest(context.Background(), request)
+	assert.Error(t, err)
+	assert.True(t, client.RetransmitAll(request))
+	assert.False(t, client.RetransmitNone(request))
+}
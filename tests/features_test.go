@@ -0,0 +1,32 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tinybluerobots/radius-diameter-message/diameter"
+	"github.com/tinybluerobots/radius-diameter-message/diameter/features"
+)
+
+func Test_features_negotiation(t *testing.T) {
+	local := []features.SupportedFeatures{
+		{VendorId: diameter.Vendor3GPP, FeatureListId: 1, FeatureList: features.GxRel8 | features.GxRel9 | features.GxRel10},
+		{VendorId: diameter.Vendor3GPP, FeatureListId: 2, FeatureList: 1},
+	}
+	avps := features.Add(diameter.NewAvps(), features.SupportedFeatures{VendorId: diameter.Vendor3GPP, FeatureListId: 1, FeatureList: features.GxRel8 | features.GxProvAFsignalFlow | features.GxRel10})
+	decoded, err := diameter.ReadMessage(diameter.NewMessage(1, 0, diameter.CommandCreditControl, diameter.ApplicationGx, [4]byte{}, [4]byte{}, avps...).ToBytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	remote := features.Read(decoded.Avps)
+	assert.Len(t, remote, 1)
+
+	negotiated := features.Intersect(local, remote)
+	assert.Equal(t, []features.SupportedFeatures{{VendorId: diameter.Vendor3GPP, FeatureListId: 1, FeatureList: features.GxRel8 | features.GxRel10}}, negotiated)
+	list := features.Find(negotiated, diameter.Vendor3GPP, 1)
+	assert.True(t, list.Has(features.GxRel10))
+	assert.False(t, list.Has(features.GxRel9|features.GxRel10))
+	assert.Equal(t, []string{"Rel8", "Rel10"}, list.Names(features.GxFeatureNames))
+	assert.Equal(t, features.FeatureList(0), features.Find(negotiated, diameter.Vendor3GPP, 2))
+	assert.Equal(t, []string{"Additional MSISDN"}, features.S6aAdditionalMSISDN.Names(features.S6aFeatureNames))
+}
@@ -0,0 +1,51 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tinybluerobots/radius-diameter-message/diameter"
+	"github.com/tinybluerobots/radius-diameter-message/diameter/gx"
+)
+
+func Test_gx_charging_rules(t *testing.T) {
+	ratingGroup := uint32(100)
+	precedence := uint32(0)
+	install := gx.ChargingRuleInstall{
+		Definitions: []gx.ChargingRuleDefinition{{
+			Name:        "video",
+			RatingGroup: &ratingGroup,
+			FlowInformation: []gx.FlowInformation{
+				{FlowDescription: "permit out 17 from 10.0.0.1 to any", FlowDirection: gx.Downlink},
+				{FlowDescription: "permit out 17 from any to 10.0.0.1"},
+			},
+			QoSInformation: &gx.QoSInformation{
+				QoSClassIdentifier:      2,
+				MaxRequestedBandwidthUL: 128000,
+				MaxRequestedBandwidthDL: 512000,
+				AllocationRetentionPriority: &gx.AllocationRetentionPriority{
+					PriorityLevel:           5,
+					PreEmptionCapability:    gx.PreEmptionCapabilityDisabled,
+					PreEmptionVulnerability: gx.PreEmptionVulnerabilityEnabled,
+				},
+			},
+			Precedence: &precedence,
+		}},
+		BaseNames: []string{"default"},
+	}
+	remove := gx.ChargingRuleRemove{Names: []string{"old"}}
+	avps := diameter.NewAvps().AddAvps(install.ToAvp(), remove.ToAvp())
+	avps = gx.AddEventTriggers(avps, gx.UsageReport, gx.RATChange)
+	message := diameter.NewMessage(1, 0, diameter.CommandCreditControl, diameter.ApplicationGx, [4]byte{}, [4]byte{}, avps...)
+
+	decoded, err := diameter.ReadMessage(message.ToBytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []gx.ChargingRuleInstall{install}, gx.ChargingRuleInstalls(decoded.Avps))
+	assert.Equal(t, []gx.ChargingRuleRemove{remove}, gx.ChargingRuleRemoves(decoded.Avps))
+	assert.Equal(t, []gx.EventTrigger{gx.UsageReport, gx.RATChange}, gx.EventTriggers(decoded.Avps))
+	assert.Equal(t, "USAGE_REPORT", gx.UsageReport.String())
+	assert.Equal(t, "UNKNOWN(999)", gx.EventTrigger(999).String())
+	assert.True(t, decoded.Avps[0].VendorSpecific())
+}
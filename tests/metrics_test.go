@@ -0,0 +1,61 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tinybluerobots/radius-diameter-message/diameter"
+	"github.com/tinybluerobots/radius-diameter-message/diameter/client"
+	"github.com/tinybluerobots/radius-diameter-message/diameter/metrics"
+	"github.com/tinybluerobots/radius-diameter-message/diameter/watchdog"
+)
+
+func Test_metrics_prometheus(t *testing.T) {
+	sink := metrics.NewPrometheus()
+	request := diameter.NewMessage(1, requestFlags, diameter.CommandCreditControl, diameter.ApplicationCreditControl, [4]byte{}, [4]byte{})
+	sink.MessageSent(request)
+	sink.MessageReceived(request.NewAnswer(diameter.NewAvpUint32(diameter.AvpResultCode, mandatoryFlags, 0, uint32(diameter.ResultCodeSuccess))))
+	sink.ParseError(errors.New("invalid"))
+	sink.TransactionLatency(diameter.CommandCreditControl, 20*time.Millisecond)
+	sink.WatchdogState("peer.example.com", watchdog.Suspect)
+
+	recorder := httptest.NewRecorder()
+	sink.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	body := recorder.Body.String()
+	assert.Contains(t, body, `diameter_messages_total{direction="out",command="272",request="true",result_code=""} 1`)
+	assert.Contains(t, body, `diameter_messages_total{direction="in",command="272",request="false",result_code="2001"} 1`)
+	assert.Contains(t, body, "diameter_parse_errors_total 1")
+	assert.Contains(t, body, `diameter_transaction_latency_seconds_bucket{command="272",le="0.01"} 0`)
+	assert.Contains(t, body, `diameter_transaction_latency_seconds_bucket{command="272",le="0.025"} 1`)
+	assert.Contains(t, body, `diameter_transaction_latency_seconds_count{command="272"} 1`)
+	assert.Contains(t, body, `diameter_watchdog_state{peer="peer.example.com"} 1`)
+}
+
+func Test_metrics_client(t *testing.T) {
+	sink := metrics.NewPrometheus()
+	address, _, _ := fakePeer(t, serverCapabilities)
+	c, err := client.Dial(context.Background(), address, client.Config{Capabilities: clientCapabilities, Metrics: sink})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	_, err = c.SendRequest(context.Background(), diameter.NewMessage(1, 0, diameter.CommandCreditControl, diameter.ApplicationCreditControl, [4]byte{}, [4]byte{}))
+	assert.NoError(t, err)
+	body := strings.Builder{}
+	sink.WriteTo(&body)
+	assert.Contains(t, body.String(), `diameter_messages_total{direction="out",command="257",request="true",result_code=""} 1`)
+	assert.Contains(t, body.String(), `diameter_messages_total{direction="in",command="272",request="false",result_code="2001"} 1`)
+	assert.Contains(t, body.String(), `diameter_transaction_latency_seconds_count{command="272"} 1`)
+}
+
+func Test_metrics_is_parse_error(t *testing.T) {
+	assert.False(t, metrics.IsParseError(io.EOF))
+	assert.False(t, metrics.IsParseError(nil))
+	assert.True(t, metrics.IsParseError(errors.New("invalid message length")))
+}
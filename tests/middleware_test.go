@@ -0,0 +1,60 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tinybluerobots/radius-diameter-message/diameter"
+	"github.com/tinybluerobots/radius-diameter-message/diameter/client"
+	"github.com/tinybluerobots/radius-diameter-message/diameter/server"
+)
+
+func Test_server_middleware(t *testing.T) {
+	var calls []string
+	record := func(name string) server.Middleware {
+		return func(next server.Handler) server.Handler {
+			return func(ctx context.Context, writer server.AnswerWriter, request diameter.Message) {
+				calls = append(calls, name)
+				next(ctx, writer, request)
+			}
+		}
+	}
+	answered := make(chan struct{}, 1)
+	s := server.New(server.Config{Capabilities: serverCapabilities})
+	s.Use(record("first"), record("second"))
+	s.Handle(diameter.ApplicationCreditControl, diameter.CommandCreditControl, func(ctx context.Context, writer server.AnswerWriter, request diameter.Message) {
+		calls = append(calls, "handler")
+		writer.WriteAnswer(request.NewAnswer(diameter.NewAvpUint32(diameter.AvpResultCode, mandatoryFlags, 0, uint32(diameter.ResultCodeSuccess))))
+		answered <- struct{}{}
+	})
+	c, err := client.Dial(context.Background(), startServer(t, s), client.Config{Capabilities: clientCapabilities})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	_, err = c.SendRequest(context.Background(), diameter.NewMessage(1, 0, diameter.CommandCreditControl, diameter.ApplicationCreditControl, [4]byte{}, [4]byte{}))
+	assert.NoError(t, err)
+	<-answered
+	assert.Equal(t, []string{"first", "second", "handler"}, calls)
+}
+
+func Test_client_send_middleware(t *testing.T) {
+	address, _, _ := fakePeer(t, serverCapabilities)
+	rewrite := func(next client.Sender) client.Sender {
+		return func(ctx context.Context, request diameter.Message) (diameter.Message, error) {
+			request.Avps = request.Avps.AddString(diameter.AvpDestinationRealm, mandatoryFlags, 0, "example.com")
+			answer, err := next(ctx, request)
+			answer.Avps = answer.Avps.AddString(diameter.AvpErrorMessage, 0, 0, "seen")
+			return answer, err
+		}
+	}
+	c, err := client.Dial(context.Background(), address, client.Config{Capabilities: clientCapabilities, SendMiddleware: []client.SendMiddleware{rewrite}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	answer, err := c.SendRequest(context.Background(), diameter.NewMessage(1, 0, diameter.CommandCreditControl, diameter.ApplicationCreditControl, [4]byte{}, [4]byte{}))
+	assert.NoError(t, err)
+	assert.Equal(t, "seen", answer.Avps.GetFirst(diameter.AvpErrorMessage, 0).ToStringOrDefault())
+}
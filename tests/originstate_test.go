@@ -0,0 +1,59 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tinybluerobots/radius-diameter-message/diameter"
+	"github.com/tinybluerobots/radius-diameter-message/diameter/client"
+	"github.com/tinybluerobots/radius-diameter-message/diameter/originstate"
+)
+
+func originStateMessage(originHost string, stateId uint32) diameter.Message {
+	return diameter.NewMessage(1, requestFlags, diameter.CommandCreditControl, diameter.ApplicationCreditControl, [4]byte{}, [4]byte{},
+		diameter.NewAvpString(diameter.AvpOriginHost, mandatoryFlags, 0, originHost),
+		diameter.NewAvpUint32(diameter.AvpOriginStateId, mandatoryFlags, 0, stateId))
+}
+
+func Test_originstate_restart(t *testing.T) {
+	var restarts []string
+	tracker := originstate.NewWithId(7, func(originHost string, previous uint32, current uint32) {
+		restarts = append(restarts, originHost)
+		assert.Equal(t, uint32(1), previous)
+		assert.Equal(t, uint32(2), current)
+	})
+	assert.False(t, tracker.Observe(originStateMessage("peer.example.com", 1)))
+	assert.False(t, tracker.Observe(originStateMessage("peer.example.com", 1)))
+	assert.True(t, tracker.Observe(originStateMessage("peer.example.com", 2)))
+	assert.False(t, tracker.Observe(originStateMessage("other.example.com", 5)))
+	assert.Equal(t, []string{"peer.example.com"}, restarts)
+	stateId, ok := tracker.Peer("peer.example.com")
+	assert.True(t, ok)
+	assert.Equal(t, uint32(2), stateId)
+}
+
+func Test_originstate_inject(t *testing.T) {
+	tracker := originstate.NewWithId(7, nil)
+	message := diameter.NewMessage(1, requestFlags, diameter.CommandCreditControl, diameter.ApplicationCreditControl, [4]byte{}, [4]byte{})
+	tracker.Inject(&message)
+	tracker.Inject(&message)
+	assert.Len(t, message.Avps.Get(diameter.AvpOriginStateId, 0), 1)
+	assert.Equal(t, uint32(7), message.Avps.GetFirst(diameter.AvpOriginStateId, 0).ToUint32OrDefault())
+	assert.NotZero(t, originstate.New(nil).Local())
+}
+
+func Test_originstate_client(t *testing.T) {
+	server := serverCapabilities
+	server.OriginStateId = 3
+	address, _, _ := fakePeer(t, server)
+	tracker := originstate.NewWithId(9, nil)
+	c, err := client.Dial(context.Background(), address, client.Config{Capabilities: clientCapabilities, OriginState: tracker})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	stateId, ok := tracker.Peer("server.example.com")
+	assert.True(t, ok)
+	assert.Equal(t, uint32(3), stateId)
+}
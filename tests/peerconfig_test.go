@@ -0,0 +1,85 @@
+package tests
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tinybluerobots/radius-diameter-message/diameter"
+	"github.com/tinybluerobots/radius-diameter-message/diameter/peerconfig"
+	"github.com/tinybluerobots/radius-diameter-message/diameter/server"
+)
+
+const peerConfigYAML = `
+local:
+  originHost: client.example.com
+  originRealm: example.com
+  hostIPAddresses: [127.0.0.1]
+  productName: client
+  watchdog: 10s
+  applications:
+    auth: [4]
+    vendorSpecific:
+      - vendorId: 10415
+        auth: 16777238
+peers:
+  - host: server.example.com
+    realm: example.com
+    addresses: [ADDRESS]
+    watchdog: 5s
+    applications:
+      auth: [4]
+`
+
+func Test_peerconfig_yaml(t *testing.T) {
+	config, err := peerconfig.ParseYAML([]byte(peerConfigYAML))
+	assert.NoError(t, err)
+	local := config.Local.Capabilities()
+	assert.Equal(t, "client.example.com", local.OriginHost)
+	assert.Equal(t, []diameter.ApplicationId{diameter.ApplicationCreditControl}, local.AuthApplicationIds)
+	assert.Equal(t, []diameter.VendorId{diameter.Vendor3GPP}, local.SupportedVendorIds)
+	assert.Len(t, local.VendorSpecificApplicationIds, 1)
+	assert.Equal(t, 10*time.Second, config.ServerConfig().Tw)
+
+	peer, ok := config.Peer("server.example.com")
+	assert.True(t, ok)
+	clientConfig := config.ClientConfig(peer)
+	assert.Equal(t, 5*time.Second, clientConfig.Tw)
+	assert.Empty(t, clientConfig.Capabilities.VendorSpecificApplicationIds)
+}
+
+func Test_peerconfig_json_file(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "peers.json")
+	json := `{"local": {"originHost": "client.example.com", "originRealm": "example.com", "watchdog": "30s"}, "peers": []}`
+	assert.NoError(t, os.WriteFile(path, []byte(json), 0o600))
+	config, err := peerconfig.Load(path)
+	assert.NoError(t, err)
+	assert.Equal(t, peerconfig.Duration(30*time.Second), config.Local.Watchdog)
+}
+
+func Test_peerconfig_validate(t *testing.T) {
+	_, err := peerconfig.ParseJSON([]byte(`{"local": {"hostIPAddresses": ["nope"]}, "peers": [{"host": "a", "transport": "tls"}, {"host": "a", "addresses": ["x"], "transport": "sctp"}]}`))
+	assert.ErrorContains(t, err, "originHost is required")
+	assert.ErrorContains(t, err, "invalid host IP address")
+	assert.ErrorContains(t, err, "peers[0]: at least one address is required")
+	assert.ErrorContains(t, err, "peers[0]: transport tls requires tls settings")
+	assert.ErrorContains(t, err, "peers[1]: duplicate host")
+	assert.ErrorContains(t, err, `unknown transport "sctp"`)
+}
+
+func Test_peerconfig_dial(t *testing.T) {
+	address := startServer(t, server.New(server.Config{Capabilities: serverCapabilities}))
+	config, err := peerconfig.ParseYAML([]byte(peerConfigYAML))
+	assert.NoError(t, err)
+	peer, _ := config.Peer("server.example.com")
+	peer.Addresses = []string{"127.0.0.1:1", address}
+	c, err := config.Dial(context.Background(), peer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	assert.Equal(t, "server.example.com", c.Peer().OriginHost)
+}
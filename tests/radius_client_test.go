@@ -0,0 +1,311 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tinybluerobots/radius-diameter-message/radius"
+	radiusclient "github.com/tinybluerobots/radius-diameter-message/radius/client"
+)
+
+// fakeRADIUSServer answers each request received on a UDP socket with the
+// response the respond function returns, if any, given how many times that
+// identifier has been received.
+func fakeRADIUSServer(t *testing.T, respond func(request radius.Message, received int) []radius.Message) string {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	go func() {
+		counts := map[byte]int{}
+		buffer := make([]byte, 4096)
+		for {
+			n, address, err := conn.ReadFrom(buffer)
+			if err != nil {
+				return
+			}
+			request, err := radius.ReadMessage(append([]byte(nil), buffer[:n]...))
+			if err != nil {
+				continue
+			}
+			counts[request.Identifier]++
+			for _, response := range respond(*request, counts[request.Identifier]) {
+				conn.WriteTo(response.ToBytes(), address)
+			}
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+// accept answers the request with a signed Access-Accept.
+func accept(request radius.Message, secret []byte) radius.Message {
+	response := radius.NewMessage(radius.CodeAccessAccept, request.Identifier, [16]byte{}, radius.NewAvpString(radius.AttributeUserName, 0, "nemo"))
+	response.AddResponseMessageAuthenticator(request.Authenticator, secret)
+	response.SetResponseAuthenticator(request.Authenticator, secret)
+	return response
+}
+
+func newRADIUSClient(t *testing.T, config radiusclient.Config) *radiusclient.Client {
+	c, err := radiusclient.New(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func Test_radius_client_send(t *testing.T) {
+	secret := []byte("secret")
+	received := make(chan radius.Message, 1)
+	address := fakeRADIUSServer(t, func(request radius.Message, _ int) []radius.Message {
+		received <- request
+		return []radius.Message{accept(request, secret)}
+	})
+	c := newRADIUSClient(t, radiusclient.Config{Timeout: time.Second})
+	request, err := radius.AccessRequest{UserName: "nemo", UserPassword: []byte("arctangent")}.Build(secret)
+	assert.NoError(t, err)
+	response, err := c.Send(context.Background(), request, address, secret)
+	assert.NoError(t, err)
+	assert.Equal(t, radius.CodeAccessAccept, response.Code)
+	sent := <-received
+	assert.NoError(t, sent.VerifyMessageAuthenticator(secret, true))
+	parsed := radius.AccessRequest{}
+	assert.NoError(t, parsed.FromMessage(sent, secret))
+	assert.Equal(t, []byte("arctangent"), parsed.UserPassword)
+
+	accounting := radius.AccountingRequest{StatusType: radius.AcctStart, SessionId: "0001"}.Build(secret)
+	address = fakeRADIUSServer(t, func(request radius.Message, _ int) []radius.Message {
+		received <- request
+		response := radius.NewMessage(radius.CodeAccountingResponse, request.Identifier, [16]byte{})
+		response.SetResponseAuthenticator(request.Authenticator, secret)
+		return []radius.Message{response}
+	})
+	response, err = c.Send(context.Background(), accounting, address, secret)
+	assert.NoError(t, err)
+	assert.Equal(t, radius.CodeAccountingResponse, response.Code)
+	sent = <-received
+	assert.True(t, sent.VerifyAccountingAuthenticator(secret))
+}
+
+func Test_radius_client_retransmits(t *testing.T) {
+	secret := []byte("secret")
+	var mutex sync.Mutex
+	var authenticators [][16]byte
+	address := fakeRADIUSServer(t, func(request radius.Message, received int) []radius.Message {
+		mutex.Lock()
+		authenticators = append(authenticators, request.Authenticator)
+		mutex.Unlock()
+		if received < 3 {
+			return nil
+		}
+		forged := accept(request, []byte("wrong"))
+		return []radius.Message{forged, accept(request, secret), accept(request, secret)}
+	})
+	c := newRADIUSClient(t, radiusclient.Config{Timeout: 20 * time.Millisecond, Attempts: 3, Backoff: true})
+	request, _ := radius.AccessRequest{UserName: "nemo"}.Build(secret)
+	response, err := c.Send(context.Background(), request, address, secret)
+	assert.NoError(t, err)
+	mutex.Lock()
+	assert.True(t, response.VerifyResponseAuthenticator(authenticators[0], secret))
+	assert.Len(t, authenticators, 3)
+	assert.Equal(t, authenticators[0], authenticators[2])
+	mutex.Unlock()
+}
+
+func Test_radius_client_timeout_and_failover(t *testing.T) {
+	silent := fakeRADIUSServer(t, func(radius.Message, int) []radius.Message { return nil })
+	secret := []byte("other")
+	passwords := make(chan []byte, 1)
+	answering := fakeRADIUSServer(t, func(request radius.Message, _ int) []radius.Message {
+		parsed := radius.AccessRequest{}
+		parsed.FromMessage(request, secret)
+		passwords <- parsed.UserPassword
+		return []radius.Message{accept(request, secret)}
+	})
+	c := newRADIUSClient(t, radiusclient.Config{Timeout: 10 * time.Millisecond, Attempts: 2})
+	request, _ := radius.AccessRequest{UserName: "nemo", UserPassword: []byte("arctangent")}.Build([]byte("secret"))
+
+	_, err := c.Send(context.Background(), request, silent, []byte("secret"))
+	assert.ErrorIs(t, err, radiusclient.ErrTimeout)
+
+	response, err := c.SendFailover(context.Background(), request,
+		radiusclient.Server{Address: silent, Secret: []byte("secret")},
+		radiusclient.Server{Address: answering, Secret: secret})
+	assert.NoError(t, err)
+	assert.Equal(t, radius.CodeAccessAccept, response.Code)
+	assert.Equal(t, []byte("arctangent"), <-passwords)
+
+	_, err = c.SendFailover(context.Background(), request)
+	assert.ErrorIs(t, err, radiusclient.ErrNoServer)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = c.Send(ctx, request, silent, []byte("secret"))
+	assert.ErrorIs(t, err, context.Canceled)
+
+	c.Close()
+	_, err = c.Send(context.Background(), request, silent, []byte("secret"))
+	assert.ErrorIs(t, err, radiusclient.ErrClosed)
+}
+
+func Test_radius_client_identifiers(t *testing.T) {
+	secret := []byte("secret")
+	address := fakeRADIUSServer(t, func(request radius.Message, _ int) []radius.Message {
+		return []radius.Message{accept(request, secret)}
+	})
+	c := newRADIUSClient(t, radiusclient.Config{Timeout: time.Second})
+	var wait sync.WaitGroup
+	identifiers := make(chan byte, 20)
+	for range 20 {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			request, _ := radius.AccessRequest{UserName: "nemo"}.Build(secret)
+			response, err := c.Send(context.Background(), request, address, secret)
+			assert.NoError(t, err)
+			identifiers <- response.Identifier
+		}()
+	}
+	wait.Wait()
+	close(identifiers)
+	seen := map[byte]bool{}
+	for identifier := range identifiers {
+		seen[identifier] = true
+	}
+	assert.Len(t, seen, 20)
+}
+
+func Test_radius_identifier_pool(t *testing.T) {
+	pool := radiusclient.NewIdentifierPool()
+	for i := range 256 {
+		identifier, err := pool.Allocate("192.0.2.1:1812")
+		assert.NoError(t, err)
+		assert.Equal(t, byte(i), identifier)
+	}
+	assert.Equal(t, 256, pool.Outstanding("192.0.2.1:1812"))
+	_, err := pool.Allocate("192.0.2.1:1812")
+	assert.ErrorIs(t, err, radiusclient.ErrNoIdentifier)
+	identifier, err := pool.Allocate("192.0.2.2:1812")
+	assert.NoError(t, err)
+	assert.Equal(t, byte(0), identifier)
+
+	acquired := make(chan byte)
+	go func() {
+		identifier, err := pool.Acquire(context.Background(), "192.0.2.1:1812")
+		assert.NoError(t, err)
+		acquired <- identifier
+	}()
+	time.Sleep(10 * time.Millisecond)
+	pool.Release("192.0.2.1:1812", 42)
+	assert.Equal(t, byte(42), <-acquired)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = pool.Acquire(ctx, "192.0.2.1:1812")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	pool.Close()
+	_, err = pool.Allocate("192.0.2.3:1812")
+	assert.ErrorIs(t, err, radiusclient.ErrClosed)
+}
+
+func Test_radius_client_unsolicited(t *testing.T) {
+	secret := []byte("secret")
+	address := fakeRADIUSServer(t, func(request radius.Message, received int) []radius.Message {
+		return []radius.Message{accept(request, secret), accept(request, secret)}
+	})
+	received := make(chan radius.Message, 1)
+	c := newRADIUSClient(t, radiusclient.Config{OnUnsolicited: func(response radius.Message, from net.Addr) {
+		assert.Equal(t, address, from.String())
+		received <- response
+	}})
+	request, _ := radius.AccessRequest{UserName: "nemo", UserPassword: []byte("arctangent")}.Build(secret)
+	response, err := c.Send(context.Background(), request, address, secret)
+	assert.NoError(t, err)
+	assert.Equal(t, response.Identifier, (<-received).Identifier)
+	assert.Equal(t, uint64(1), c.Unsolicited())
+
+	var logged bytes.Buffer
+	response.Identifier = 0
+	radiusclient.LogUnsolicited(slog.New(slog.NewTextHandler(&logged, nil)))(response, &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 1812})
+	assert.Contains(t, logged.String(), "msg=\"unsolicited RADIUS response\" code=ACCESS_ACCEPT identifier=0 server=127.0.0.1:1812")
+}
+
+func Test_radius_client_store_survives_restart(t *testing.T) {
+	secret := []byte("secret")
+	server, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	address := server.LocalAddr().String()
+	store := radiusclient.NewMemoryStore()
+	before := radius.NewMessage(radius.CodeAccessRequest, 7, [16]byte{})
+	store.Save(radiusclient.Record{Address: address, Request: before, Started: time.Now()})
+
+	received := make(chan radius.Message, 1)
+	c := newRADIUSClient(t, radiusclient.Config{LocalAddress: "127.0.0.1:0", Store: store, OnUnsolicited: func(response radius.Message, _ net.Addr) {
+		received <- response
+	}})
+	late := accept(before, secret)
+	server.WriteTo(late.ToBytes(), c.LocalAddr())
+	assert.Eventually(t, func() bool { return c.Late() == 1 }, time.Second, time.Millisecond)
+	records, _ := store.Load()
+	assert.Empty(t, records)
+	server.WriteTo(late.ToBytes(), c.LocalAddr())
+	assert.Equal(t, byte(7), (<-received).Identifier)
+	assert.Equal(t, uint64(1), c.Unsolicited())
+
+	store.Save(radiusclient.Record{Address: address, Request: before, Started: time.Now()})
+	restarted := newRADIUSClient(t, radiusclient.Config{Store: store})
+	go func() {
+		buffer := make([]byte, 4096)
+		n, from, err := server.ReadFrom(buffer)
+		if err != nil {
+			return
+		}
+		request, _ := radius.ReadMessage(buffer[:n])
+		records, _ := store.Load()
+		assert.Len(t, records, 2)
+		response := accept(*request, secret)
+		server.WriteTo(response.ToBytes(), from)
+	}()
+	request, _ := radius.AccessRequest{UserName: "nemo", UserPassword: []byte("arctangent")}.Build(secret)
+	response, err := restarted.Send(context.Background(), request, address, secret)
+	assert.NoError(t, err)
+	assert.Equal(t, byte(8), response.Identifier)
+	records, _ = store.Load()
+	assert.Len(t, records, 1)
+}
+
+func Test_radius_client_accounting_retransmission(t *testing.T) {
+	secret := []byte("secret")
+	requests := make(chan radius.Message, 2)
+	address := fakeRADIUSServer(t, func(request radius.Message, _ int) []radius.Message {
+		requests <- request
+		if len(requests) < 2 {
+			return nil
+		}
+		response := radius.NewMessage(radius.CodeAccountingResponse, request.Identifier, [16]byte{})
+		response.SetResponseAuthenticator(request.Authenticator, secret)
+		return []radius.Message{response}
+	})
+	c := newRADIUSClient(t, radiusclient.Config{Timeout: 1100 * time.Millisecond, Attempts: 2})
+	request := radius.AccountingRequest{StatusType: radius.AcctStart, SessionId: "0001", DelayTime: 2 * time.Second}.Build(secret)
+	response, err := c.Send(context.Background(), request, address, secret)
+	assert.NoError(t, err)
+	first, retransmitted := <-requests, <-requests
+	assert.Equal(t, retransmitted.Identifier, response.Identifier)
+	assert.NotEqual(t, first.Identifier, retransmitted.Identifier)
+	assert.NotEqual(t, first.Authenticator, retransmitted.Authenticator)
+	assert.True(t, retransmitted.VerifyAccountingAuthenticator(secret))
+	assert.Equal(t, uint32(2), first.Avps.GetFirst(radius.AttributeAcctDelayTime, 0).ToUint32OrDefault())
+	assert.Equal(t, uint32(3), retransmitted.Avps.GetFirst(radius.AttributeAcctDelayTime, 0).ToUint32OrDefault())
+}
@@ -0,0 +1,245 @@
+package tests
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tinybluerobots/radius-diameter-message/radius"
+	radiusclient "github.com/tinybluerobots/radius-diameter-message/radius/client"
+	radiusserver "github.com/tinybluerobots/radius-diameter-message/radius/server"
+)
+
+// startRADIUSServer serves the server on a local UDP port, returning its address.
+func startRADIUSServer(t *testing.T, s *radiusserver.Server) (string, net.PacketConn) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go s.Serve(conn)
+	t.Cleanup(func() { s.Close() })
+	return conn.LocalAddr().String(), conn
+}
+
+func Test_radius_server_dispatch(t *testing.T) {
+	secret := []byte("secret")
+	s := radiusserver.New(radiusserver.Config{Secret: func(ip net.IP) []byte {
+		if ip.IsLoopback() {
+			return secret
+		}
+		return nil
+	}})
+	s.Handle(radius.CodeAccessRequest, func(ctx context.Context, writer radiusserver.ResponseWriter, request radius.Message) {
+		clientSecret, _ := radiusserver.SecretFromContext(ctx)
+		access := radius.AccessRequest{}
+		access.FromMessage(request, clientSecret)
+		code := radius.CodeAccessReject
+		if string(access.UserPassword) == "arctangent" {
+			code = radius.CodeAccessAccept
+		}
+		assert.NoError(t, writer.WriteResponse(radius.NewMessage(code, 0, [16]byte{})))
+		assert.ErrorIs(t, writer.WriteResponse(radius.NewMessage(code, 0, [16]byte{})), radiusserver.ErrResponseWritten)
+	})
+	s.Handle(radius.CodeAccountingRequest, func(ctx context.Context, writer radiusserver.ResponseWriter, request radius.Message) {
+		address, ok := radiusserver.RemoteAddrFromContext(ctx)
+		assert.True(t, ok)
+		assert.NotNil(t, address)
+		writer.WriteResponse(radius.NewMessage(radius.CodeAccountingResponse, 0, [16]byte{}))
+	})
+	address, _ := startRADIUSServer(t, s)
+	c := newRADIUSClient(t, radiusclient.Config{Timeout: 50 * time.Millisecond, Attempts: 2})
+
+	request, _ := radius.AccessRequest{UserName: "nemo", UserPassword: []byte("arctangent")}.Build(secret)
+	response, err := c.Send(context.Background(), request, address, secret)
+	assert.NoError(t, err)
+	assert.Equal(t, radius.CodeAccessAccept, response.Code)
+	assert.NotNil(t, response.Avps.GetFirst(radius.AttributeMessageAuthenticator, 0))
+
+	request, _ = radius.AccessRequest{UserName: "nemo", UserPassword: []byte("wrong")}.Build(secret)
+	response, err = c.Send(context.Background(), request, address, secret)
+	assert.NoError(t, err)
+	assert.Equal(t, radius.CodeAccessReject, response.Code)
+
+	response, err = c.Send(context.Background(), radius.AccountingRequest{StatusType: radius.AcctStart}.Build(secret), address, secret)
+	assert.NoError(t, err)
+	assert.Equal(t, radius.CodeAccountingResponse, response.Code)
+
+	_, err = c.Send(context.Background(), request, address, []byte("wrong"))
+	assert.Error(t, err)
+	_, err = c.Send(context.Background(), radius.DisconnectRequest{}.Build(secret), address, secret)
+	assert.Error(t, err)
+}
+
+func Test_radius_server_deduplicates(t *testing.T) {
+	secret := []byte("secret")
+	var handled atomic.Int32
+	dedup := radiusserver.NewDedupCache(time.Minute)
+	s := radiusserver.New(radiusserver.Config{
+		Secret:                      func(net.IP) []byte { return secret },
+		RequireMessageAuthenticator: true,
+		Dedup:                       dedup,
+	})
+	s.Handle(radius.CodeAccessRequest, func(ctx context.Context, writer radiusserver.ResponseWriter, request radius.Message) {
+		handled.Add(1)
+		writer.WriteResponse(radius.NewMessage(radius.CodeAccessAccept, 0, [16]byte{}))
+	})
+	address, _ := startRADIUSServer(t, s)
+	conn, err := net.Dial("udp", address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	request, _ := radius.AccessRequest{UserName: "nemo"}.Build(secret)
+	buffer := make([]byte, 4096)
+	var responses [][]byte
+	for range 2 {
+		conn.Write(request.ToBytes())
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		n, err := conn.Read(buffer)
+		assert.NoError(t, err)
+		responses = append(responses, append([]byte(nil), buffer[:n]...))
+	}
+	assert.Equal(t, int32(1), handled.Load())
+	assert.Equal(t, responses[0], responses[1])
+
+	unsigned := radius.NewMessage(radius.CodeAccessRequest, 9, [16]byte{1}, radius.NewAvpString(radius.AttributeUserName, 0, "nemo"))
+	conn.Write(unsigned.ToBytes())
+	conn.SetReadDeadline(time.Now().Add(50 * time.Millisecond))
+	_, err = conn.Read(buffer)
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), handled.Load())
+	assert.Equal(t, 1, dedup.Len())
+}
+
+// countingDedupObserver counts the events of a dedup cache.
+type countingDedupObserver struct {
+	missed, replayed, dropped, evicted atomic.Int32
+}
+
+func (o *countingDedupObserver) Missed()   { o.missed.Add(1) }
+func (o *countingDedupObserver) Replayed() { o.replayed.Add(1) }
+func (o *countingDedupObserver) Dropped()  { o.dropped.Add(1) }
+func (o *countingDedupObserver) Evicted()  { o.evicted.Add(1) }
+
+func Test_radius_server_response_cache(t *testing.T) {
+	secret := []byte("secret")
+	observer := &countingDedupObserver{}
+	dedup := radiusserver.NewDedupCache(time.Minute).WithMaxEntries(2).WithObserver(observer)
+	s := radiusserver.New(radiusserver.Config{
+		Secret: func(net.IP) []byte { return secret },
+		Dedup:  dedup,
+	})
+	s.Handle(radius.CodeAccountingRequest, func(ctx context.Context, writer radiusserver.ResponseWriter, request radius.Message) {
+		writer.WriteResponse(radius.NewMessage(radius.CodeAccountingResponse, 0, [16]byte{}))
+	})
+	address, _ := startRADIUSServer(t, s)
+	conn, err := net.Dial("udp", address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	buffer := make([]byte, 4096)
+	send := func(request radius.Message) {
+		conn.Write(request.ToBytes())
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		_, err := conn.Read(buffer)
+		assert.NoError(t, err)
+	}
+	for i := range 3 {
+		request := radius.AccountingRequest{StatusType: radius.AcctStart, SessionId: string(rune('a' + i))}.Build(secret)
+		send(request)
+		if i == 0 {
+			send(request)
+		}
+	}
+	assert.Equal(t, int32(3), observer.missed.Load())
+	assert.Equal(t, int32(1), observer.replayed.Load())
+	assert.Equal(t, int32(1), observer.evicted.Load())
+	assert.Equal(t, 2, dedup.Len())
+}
+
+func Test_radius_server_response_cache_expiry(t *testing.T) {
+	secret := []byte("secret")
+	dedup := radiusserver.NewDedupCache(20 * time.Millisecond)
+	s := radiusserver.New(radiusserver.Config{
+		Secret: func(net.IP) []byte { return secret },
+		Dedup:  dedup,
+	})
+	s.Handle(radius.CodeAccountingRequest, func(ctx context.Context, writer radiusserver.ResponseWriter, request radius.Message) {
+		writer.WriteResponse(radius.NewMessage(radius.CodeAccountingResponse, 0, [16]byte{}))
+	})
+	address, _ := startRADIUSServer(t, s)
+	c := newRADIUSClient(t, radiusclient.Config{Timeout: time.Second})
+	send := func(sessionId string) {
+		_, err := c.Send(context.Background(), radius.AccountingRequest{StatusType: radius.AcctStart, SessionId: sessionId}.Build(secret), address, secret)
+		assert.NoError(t, err)
+	}
+	send("a")
+	send("b")
+	assert.Equal(t, 2, dedup.Len())
+	time.Sleep(30 * time.Millisecond)
+	send("c")
+	assert.Equal(t, 1, dedup.Len())
+}
+
+func Test_radius_server_shutdown(t *testing.T) {
+	secret := []byte("secret")
+	s := radiusserver.New(radiusserver.Config{Secret: func(net.IP) []byte { return secret }})
+	started, release := make(chan struct{}), make(chan struct{})
+	s.Handle(radius.CodeAccountingRequest, func(ctx context.Context, writer radiusserver.ResponseWriter, request radius.Message) {
+		if request.Avps.GetFirst(radius.AttributeAcctStatusType, 0).ToUint32OrDefault() == uint32(radius.AcctStart) {
+			close(started)
+			<-release
+		}
+		writer.WriteResponse(radius.NewMessage(radius.CodeAccountingResponse, 0, [16]byte{}))
+	})
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	served := make(chan error, 1)
+	go func() { served <- s.Serve(conn) }()
+	address := conn.LocalAddr().String()
+	c := newRADIUSClient(t, radiusclient.Config{Timeout: 20 * time.Millisecond, Attempts: 1})
+
+	responses := make(chan radius.Message, 1)
+	go func() {
+		response, err := newRADIUSClient(t, radiusclient.Config{Timeout: time.Second, Attempts: 1}).
+			Send(context.Background(), radius.AccountingRequest{StatusType: radius.AcctStart}.Build(secret), address, secret)
+		assert.NoError(t, err)
+		responses <- response
+	}()
+	<-started
+	shutdown := make(chan error, 1)
+	go func() { shutdown <- s.Shutdown(context.Background()) }()
+	assert.Eventually(t, func() bool {
+		_, err := c.Send(context.Background(), radius.AccountingRequest{StatusType: radius.AcctStop}.Build(secret), address, secret)
+		return err != nil
+	}, time.Second, time.Millisecond)
+
+	close(release)
+	assert.Equal(t, radius.CodeAccountingResponse, (<-responses).Code)
+	assert.NoError(t, <-shutdown)
+	assert.ErrorIs(t, <-served, radiusserver.ErrServerClosed)
+}
+
+func Test_radius_server_shutdown_deadline(t *testing.T) {
+	secret := []byte("secret")
+	s := radiusserver.New(radiusserver.Config{Secret: func(net.IP) []byte { return secret }})
+	started := make(chan struct{})
+	s.Handle(radius.CodeAccountingRequest, func(ctx context.Context, writer radiusserver.ResponseWriter, request radius.Message) {
+		close(started)
+		<-ctx.Done()
+	})
+	address, _ := startRADIUSServer(t, s)
+	c := newRADIUSClient(t, radiusclient.Config{Timeout: time.Second, Attempts: 1})
+	go c.Send(context.Background(), radius.AccountingRequest{StatusType: radius.AcctStart}.Build(secret), address, secret)
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, s.Shutdown(ctx), context.DeadlineExceeded)
+}
@@ -1,13 +1,20 @@
 package tests
 
 import (
+	"bytes"
 	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io"
 	"net"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/tinybluerobots/radius-diameter-message/radius"
+	"github.com/tinybluerobots/radius-diameter-message/radius/rfc2865"
+	"github.com/tinybluerobots/radius-diameter-message/threegpp"
 )
 
 func Test_radius_message(t *testing.T) {
@@ -24,7 +31,11 @@ func Test_radius_message(t *testing.T) {
 	assert.Equal(t, []byte{0x1, 0x11, 0x39, 0x30, 0x31, 0x32, 0x38, 0x30, 0x30, 0x36, 0x34, 0x32, 0x39, 0x30, 0x35, 0x35, 0x38}, bytes[20:37])
 	assert.Equal(t, []byte{0x1a, 0x17, 0x0, 0x0, 0x28, 0xaf, 0x1, 0x11, 0x39, 0x30, 0x31, 0x32, 0x38, 0x30, 0x30, 0x36, 0x34, 0x32, 0x39, 0x30, 0x35, 0x35, 0x38}, bytes[37:])
 
-	message = *radius.ReadMessage(bytes)
+	decoded, err := radius.ReadMessage(bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	message = *decoded
 	avp := message.Avps.GetFirst(1, 0).ToString()
 	assert.Equal(t, "901280064290558", *avp)
 }
@@ -36,19 +47,28 @@ func Test_radius_timestamp(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	message := radius.ReadMessage(decodedData)
+	binary.BigEndian.PutUint16(decodedData[2:4], uint16(len(decodedData)))
+	message, err := radius.ReadMessage(decodedData)
+	if err != nil {
+		t.Fatal(err)
+	}
 	avp := message.Avps.GetFirst(55, 0).ToTime()
 	expected := time.Time(time.Date(2023, time.July, 5, 10, 21, 41, 0, time.Local))
 	assert.Equal(t, expected, *avp)
 }
 
+// accountingRequestCapture is an Accounting-Request captured from a PGW.
+const accountingRequestCapture = "BIUC2ECaPuKcYNeTCdoQ+dYmU8ABETkwMTI4MDA2NDI5MDU1OCgGAAAAAgQGLr7D/iAQNDYuMTkwLjE5NS4yNTQGBgAAAAIHBgAAAAcIBgqcBJIeFHNjYW5pYS5mbXB0ZXN0LmN4bh8RODgyMzkwMDY0MjkwNTU4LBYyRTZDRkEwODAwRkYxMTExNzg2MzIWMkU2Q0ZBMDgwMEZGMTExMTc4NjMtBgAAAAE9BgAAABI3BmSlNiUqBgABIbIrBgAAs5gvBgAABEgwBgAAAzU0BgAAAAA1BgAAAAAxBgAAAAEuBgAADPIaFwAAKK8BETkwMTI4MDA2NDI5MDU1OBoMAAAorwIGARF4YxoMAAAorwMGAAAAABoMAAAorwQGLmz6CBofAAAorwUZMDgtNkMwOTAwMDAyNzEwMDAwMTg2QTAaDAAAKK8GBlAKAoMaDAAAKK8HBi5s+ggaDQAAKK8IBzkwMTI4Gg0AACivCQc5MDEyOBoJAAAorwoDNRoJAAAorwsD/xoJAAAorwwDMBoMAAAorw0GMDAwMBoNAAAorxIHMjA4MDEaGAAAKK8UEjg2ODgwODA0Njk2MTM0MDEaCQAAKK8VAwYaFQAAKK8WD4IC+BDNpwL4EAF+DAwaCgAAKK8XBIABGgkAACivGgMaIUhyZmUAAAAABWAkcu25/wUAAAAAAAAAAAACAFI9Lr7D/gAAAAAAAAAAAQAAAIW6ImLL5r8gLoBu2WLEV4AGAQQAAAAAAAAAIaFyYmUAiyZy7bn/BQAAAAAAAAAAAEMAAAD+PhOetTMDAP7aVXKHIgMAWdsrXsPdHgAQY3gRAVAKAoMAAAAAOTAxMjgAAAIAUj0uvsP+AAAAAAAAAABcCAEAhQK6ImLL5r8gLoBu2WLEV4AGDwAKnASSAAAAAAAAAAA5MDEyODAwNjQyOTA1NTgAAAAAAAAAAAAAAAAAAAAAAAAAAAACAAA="
+
 func Test_read_message_bytes(t *testing.T) {
-	base64Data := "BIUC2ECaPuKcYNeTCdoQ+dYmU8ABETkwMTI4MDA2NDI5MDU1OCgGAAAAAgQGLr7D/iAQNDYuMTkwLjE5NS4yNTQGBgAAAAIHBgAAAAcIBgqcBJIeFHNjYW5pYS5mbXB0ZXN0LmN4bh8RODgyMzkwMDY0MjkwNTU4LBYyRTZDRkEwODAwRkYxMTExNzg2MzIWMkU2Q0ZBMDgwMEZGMTExMTc4NjMtBgAAAAE9BgAAABI3BmSlNiUqBgABIbIrBgAAs5gvBgAABEgwBgAAAzU0BgAAAAA1BgAAAAAxBgAAAAEuBgAADPIaFwAAKK8BETkwMTI4MDA2NDI5MDU1OBoMAAAorwIGARF4YxoMAAAorwMGAAAAABoMAAAorwQGLmz6CBofAAAorwUZMDgtNkMwOTAwMDAyNzEwMDAwMTg2QTAaDAAAKK8GBlAKAoMaDAAAKK8HBi5s+ggaDQAAKK8IBzkwMTI4Gg0AACivCQc5MDEyOBoJAAAorwoDNRoJAAAorwsD/xoJAAAorwwDMBoMAAAorw0GMDAwMBoNAAAorxIHMjA4MDEaGAAAKK8UEjg2ODgwODA0Njk2MTM0MDEaCQAAKK8VAwYaFQAAKK8WD4IC+BDNpwL4EAF+DAwaCgAAKK8XBIABGgkAACivGgMaIUhyZmUAAAAABWAkcu25/wUAAAAAAAAAAAACAFI9Lr7D/gAAAAAAAAAAAQAAAIW6ImLL5r8gLoBu2WLEV4AGAQQAAAAAAAAAIaFyYmUAiyZy7bn/BQAAAAAAAAAAAEMAAAD+PhOetTMDAP7aVXKHIgMAWdsrXsPdHgAQY3gRAVAKAoMAAAAAOTAxMjgAAAIAUj0uvsP+AAAAAAAAAABcCAEAhQK6ImLL5r8gLoBu2WLEV4AGDwAKnASSAAAAAAAAAAA5MDEyODAwNjQyOTA1NTgAAAAAAAAAAAAAAAAAAAAAAAAAAAACAAA="
-	decodedData, err := base64.StdEncoding.DecodeString(base64Data)
+	decodedData, err := base64.StdEncoding.DecodeString(accountingRequestCapture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	message, err := radius.ReadMessage(decodedData)
 	if err != nil {
 		t.Fatal(err)
 	}
-	message := radius.ReadMessage(decodedData)
 	avp := message.Avps.GetFirst(1, 10415).ToString()
 	assert.Equal(t, "901280064290558", *avp)
 }
@@ -58,6 +78,70 @@ func Test_radius_bytes(t *testing.T) {
 	assert.Equal(t, []byte{0x1, 0x6, 0x0, 0x0, 0x0, 0x1}, avp.ToBytes())
 }
 
+func Test_radius_append_bytes(t *testing.T) {
+	avps := radius.NewAvps().
+		AddString(1, 0, "901280064290558").
+		AddString(1, 10415, "901280064290558").
+		AddExtended(radius.LongExtendedAttribute1, 3, make([]byte, 300))
+	message := radius.NewMessage(radius.CodeAccessRequest, 1, [16]byte{1}, avps...)
+	expected := message.ToBytes()
+	assert.Len(t, expected, 20+17+23+255+53)
+
+	buffer := append([]byte{0xff, 0xff}, make([]byte, 1024)...)
+	buffer = message.AppendBytes(buffer[:2])
+	assert.Equal(t, []byte{0xff, 0xff}, buffer[:2])
+	assert.Equal(t, expected, buffer[2:])
+	assert.Equal(t, expected[20:], avps.AppendBytes(nil))
+	assert.Equal(t, avps[1].ToBytes(), avps[1].AppendBytes(nil))
+	allocations := testing.AllocsPerRun(10, func() {
+		buffer = message.AppendBytes(buffer[:0])
+	})
+	assert.Zero(t, allocations)
+}
+
+func Test_radius_write_to_read_from(t *testing.T) {
+	avps := radius.NewAvps().
+		AddString(1, 0, "901280064290558").
+		AddString(1, 10415, "901280064290558").
+		AddExtended(radius.LongExtendedAttribute1, 3, make([]byte, 300))
+	message := radius.NewMessage(radius.CodeAccessRequest, 1, [16]byte{1}, avps...)
+
+	var stream bytes.Buffer
+	n, err := message.WriteTo(&stream)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(message.ToBytes())), n)
+	assert.Equal(t, message.ToBytes(), stream.Bytes())
+
+	var read radius.Message
+	n, err = read.ReadFrom(&stream)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(message.ToBytes())), n)
+	assert.Equal(t, message.ToBytes(), read.ToBytes())
+	_, err = read.ReadFrom(&stream)
+	assert.Equal(t, io.EOF, err)
+}
+
+func Test_radius_encode_all(t *testing.T) {
+	messages := []radius.Message{
+		radius.NewMessage(radius.CodeAccountingRequest, 1, [16]byte{}, radius.NewAvpString(1, 0, "alice")),
+		radius.NewMessage(radius.CodeAccountingRequest, 2, [16]byte{}),
+	}
+	buffer := radius.EncodeAll(messages, nil)
+	assert.Len(t, buffer, 27+20)
+
+	frames, rest, err := radius.SplitMessages(buffer[:30])
+	assert.NoError(t, err)
+	assert.Equal(t, [][]byte{messages[0].ToBytes()}, frames)
+	assert.Len(t, rest, 3)
+	frames, rest, err = radius.SplitMessages(buffer)
+	assert.NoError(t, err)
+	assert.Len(t, frames, 2)
+	assert.Empty(t, rest)
+
+	_, _, err = radius.SplitMessages([]byte{1, 1, 0, 4})
+	assert.Error(t, err)
+}
+
 func Test_radius_nil(t *testing.T) {
 	var avps radius.Avps
 	avp := avps.GetFirst(1, 0).ToString()
@@ -78,3 +162,841 @@ func Test_radius_string_default(t *testing.T) {
 	avpData := avps.GetFirst(1, 0).ToData()
 	assert.Nil(t, avpData)
 }
+
+func Test_radius_clone(t *testing.T) {
+	message := radius.NewMessage(1, 1, [16]byte{}, radius.NewAvpString(1, 0, "foo"))
+	bytes := message.ToBytes()
+	decoded, err := radius.ReadMessage(bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clone := decoded.Clone()
+	for i := range bytes {
+		bytes[i] = 0
+	}
+	assert.Equal(t, "foo", *clone.Avps.GetFirst(1, 0).ToString())
+}
+
+func Test_radius_apn_and_charging_characteristics(t *testing.T) {
+	avps := radius.NewAvps()
+	avps = avps.AddString(radius.AttributeCalledStationId, 0, "Internet.MNC001.MCC901.GPRS")
+	avps = avps.AddChargingCharacteristics(threegpp.Normal | threegpp.Prepaid | 0x0001)
+	message := radius.NewMessage(4, 1, [16]byte{}, avps...)
+	decoded, err := radius.ReadMessage(message.ToBytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "Internet.MNC001.MCC901.GPRS", *decoded.Avps.CalledStationId())
+	assert.Equal(t, "internet", *decoded.Avps.APN())
+	chargingCharacteristics := decoded.Avps.ChargingCharacteristics()
+	assert.Equal(t, "0c01", chargingCharacteristics.String())
+	assert.True(t, chargingCharacteristics.Has(threegpp.Prepaid))
+	assert.False(t, chargingCharacteristics.Has(threegpp.HotBilling))
+	assert.Equal(t, threegpp.Normal|threegpp.Prepaid, chargingCharacteristics.Profile())
+	assert.Equal(t, threegpp.ChargingCharacteristics(0x0001), chargingCharacteristics.Behaviour())
+	assert.Equal(t, "0c01", decoded.Avps.GetFirst(radius.Attribute3GPPChargingCharacteristics, radius.Vendor3GPP).ToStringOrDefault())
+}
+
+func Test_radius_extractor(t *testing.T) {
+	message := radius.NewMessage(4, 1, [16]byte{},
+		radius.NewAvpString(1, 0, "user"),
+		radius.NewAvpString(1, 10415, "901280064290558"),
+		radius.NewAvpString(30, 0, "internet"),
+	)
+	extract := radius.NewExtractor(radius.Field{Type: 30}, radius.Field{Type: 1, VendorId: 10415}, radius.Field{Type: 4})
+	values, err := extract(message.ToBytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "internet", *values[0].ToString())
+	assert.Equal(t, "901280064290558", *values[1].ToString())
+	assert.Nil(t, values[2])
+}
+
+func Test_radius_without(t *testing.T) {
+	avps := radius.NewAvps().
+		AddString(radius.AttributeUserName, 0, "alice").
+		AddUint32(radius.AttributeNASPort, 0, 7).
+		AddString(radius.AttributeUserName, 10415, "vendor").
+		AddExtended(241, radius.AttributeNASPort, []byte{1})
+	assert.Equal(t, radius.Avps{avps[1], avps[2], avps[3]}, avps.Without(0, radius.AttributeUserName))
+	assert.Equal(t, radius.Avps{avps[0], avps[1], avps[3]}, avps.Without(10415, radius.AttributeUserName))
+	assert.Equal(t, radius.Avps{avps[2], avps[3]}, avps.Without(0, radius.AttributeUserName, radius.AttributeNASPort, 241))
+}
+
+func Test_radius_get(t *testing.T) {
+	avps := radius.NewAvps().
+		AddString(radius.AttributeUserName, 0, "alice").
+		AddUint32(radius.AttributeNASPort, 0, 7).
+		AddIPv4(radius.AttributeNASIPAddress, 0, net.IPv4(192, 0, 2, 1)).
+		AddUint64(200, 10415, 1<<40)
+
+	userName, ok := radius.Get[string](avps, radius.AttributeUserName, 0)
+	assert.True(t, ok)
+	assert.Equal(t, "alice", userName)
+	port, _ := radius.Get[uint32](avps, radius.AttributeNASPort, 0)
+	assert.Equal(t, uint32(7), port)
+	address, _ := radius.Get[net.IP](avps, radius.AttributeNASIPAddress, 0)
+	assert.Equal(t, "192.0.2.1", address.String())
+	counter, _ := radius.Get[uint64](avps, 200, 10415)
+	assert.Equal(t, uint64(1<<40), counter)
+	data, _ := radius.As[[]byte](avps.GetFirst(radius.AttributeUserName, 0))
+	assert.Equal(t, []byte("alice"), data)
+
+	_, ok = radius.Get[uint32](avps, radius.AttributeNASIdentifier, 0)
+	assert.False(t, ok)
+	_, ok = radius.Get[radius.InterfaceId](avps, radius.AttributeNASPort, 0)
+	assert.False(t, ok)
+}
+
+func Test_radius_multi_value(t *testing.T) {
+	avps := radius.NewAvps().
+		AddString(rfc2865.Class, 0, "first").
+		AddString(rfc2865.Class, 0, "second").
+		AddUint32(radius.AttributeNASPort, 0, 1).
+		AddUint32(radius.AttributeNASPort, 0, 2).
+		AddExtended(radius.ExtendedAttribute1, rfc2865.Class, []byte("extended"))
+
+	assert.Equal(t, []string{"first", "second"}, avps.ToStrings(rfc2865.Class, 0))
+	assert.Equal(t, []uint32{1, 2}, avps.ToUint32s(radius.AttributeNASPort, 0))
+	assert.Empty(t, avps.ToNetIPs(radius.AttributeNASIPAddress, 0))
+}
+
+func Test_radius_must(t *testing.T) {
+	avps := radius.NewAvps().
+		AddString(radius.AttributeUserName, 0, "alice").
+		AddUint32(radius.AttributeNASPort, 0, 7).
+		Add(radius.AttributeNASIPAddress, 0, []byte{192, 0})
+
+	assert.Equal(t, "alice", avps.MustString(radius.AttributeUserName, 0))
+	assert.Equal(t, uint32(7), avps.MustUint32(radius.AttributeNASPort, 0))
+	assert.PanicsWithValue(t, "radius: attribute 32 (vendor 0) is missing", func() { avps.MustString(radius.AttributeNASIdentifier, 0) })
+	assert.PanicsWithValue(t, "radius: attribute 4 (vendor 0) of 2 bytes is not a valid uint32", func() { avps.MustUint32(radius.AttributeNASIPAddress, 0) })
+}
+
+func Test_radius_ok_accessors(t *testing.T) {
+	avps := radius.NewAvps()
+	avps = avps.AddUint32(5, 0, 7)
+	value, ok := avps.GetFirst(5, 0).ToUint32Ok()
+	assert.True(t, ok)
+	assert.Equal(t, uint32(7), value)
+	_, ok = avps.GetFirst(6, 0).ToUint32Ok()
+	assert.False(t, ok)
+	assert.Equal(t, "", avps.GetFirst(6, 0).ToAPNOrDefault())
+}
+
+func radiusPacket(attributes ...byte) []byte {
+	bytes := append(make([]byte, 20), attributes...)
+	bytes[0] = 1
+	binary.BigEndian.PutUint16(bytes[2:4], uint16(len(bytes)))
+	return bytes
+}
+
+func Test_radius_read_malformed(t *testing.T) {
+	for name, bytes := range map[string][]byte{
+		"zero length":      radiusPacket(1, 0),
+		"length one":       radiusPacket(1, 1),
+		"overrun":          radiusPacket(1, 6, 'a'),
+		"truncated header": radiusPacket(1, 3, 'a', 4),
+	} {
+		_, err := radius.ReadMessage(bytes)
+		attributeError := &radius.AttributeError{}
+		assert.True(t, errors.As(err, &attributeError), name)
+	}
+}
+
+func Test_radius_read_attribute_error_offset(t *testing.T) {
+	_, err := radius.ReadMessage(radiusPacket(1, 3, 'a', 30, 9, 'b'))
+	attributeError := &radius.AttributeError{}
+	assert.True(t, errors.As(err, &attributeError))
+	assert.Equal(t, 23, attributeError.Offset)
+	assert.Equal(t, radius.AttributeType(30), attributeError.Type)
+}
+
+func Test_radius_read_length_field(t *testing.T) {
+	bytes := append(radiusPacket(1, 3, 'a'), 0, 0, 0, 0)
+	message, err := radius.ReadMessage(bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, message.Avps, 1)
+	_, err = radius.ReadMessage(bytes[:22])
+	assert.Error(t, err)
+}
+
+func Test_radius_padded_packet(t *testing.T) {
+	bytes := append(radiusPacket(1, 6, 'u', 's', 'e', 'r'), 30, 5, 'a', 'p', 'n', 0, 0)
+	message, err := radius.ReadMessage(bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, message.Avps, 1)
+	assert.Nil(t, message.Avps.GetFirst(30, 0))
+
+	values, err := radius.NewExtractor(radius.Field{Type: 1}, radius.Field{Type: 30})(bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "user", values[0].ToStringOrDefault())
+	assert.Nil(t, values[1])
+
+	_, err = radius.NewExtractor(radius.Field{Type: 1})(bytes[:22])
+	invalidLengthError := &radius.InvalidLengthError{}
+	assert.True(t, errors.As(err, &invalidLengthError))
+}
+
+func Test_radius_read_malformed_vsa(t *testing.T) {
+	message, err := radius.ReadMessage(radiusPacket(26, 7, 0, 0, 0x28, 0xaf, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	avp := message.Avps.GetFirst(26, 0)
+	assert.NotNil(t, avp)
+	assert.Equal(t, []byte{0, 0, 0x28, 0xaf, 1}, []byte(avp.Data))
+	message, err = radius.ReadMessage(radiusPacket(26, 9, 0, 0, 0x28, 0xaf, 1, 9, 'a'))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotNil(t, message.Avps.GetFirst(26, 0))
+}
+
+func Test_radius_vendor_specific_multiple(t *testing.T) {
+	vsa := radius.NewAvpVendorSpecific(10415,
+		radius.NewAvpString(1, 0, "901280064290558"),
+		radius.NewAvpUint32(3, 0, 0),
+		radius.NewAvpString(8, 0, "90128"),
+	)
+	assert.Len(t, vsa.VendorAttributes(), 3)
+	message := radius.NewMessage(4, 1, [16]byte{}, radius.NewAvpString(1, 0, "user"), vsa)
+	bytes := message.ToBytes()
+	assert.Equal(t, []byte{26, 36, 0, 0, 0x28, 0xaf, 1, 17}, bytes[26:34])
+	decoded, err := radius.ReadMessage(bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, decoded.Avps, 4)
+	assert.Equal(t, "901280064290558", decoded.Avps.GetFirst(1, 10415).ToStringOrDefault())
+	assert.Equal(t, uint32(0), *decoded.Avps.GetFirst(3, 10415).ToUint32())
+	assert.Equal(t, "90128", decoded.Avps.GetFirst(8, 10415).ToStringOrDefault())
+
+	extract := radius.NewExtractor(radius.Field{Type: 8, VendorId: 10415})
+	values, err := extract(bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "90128", values[0].ToStringOrDefault())
+}
+
+func Test_radius_extended_attributes(t *testing.T) {
+	message := radius.NewMessage(4, 1, [16]byte{},
+		radius.NewAvpString(1, 0, "user"),
+		radius.NewAvpExtended(radius.ExtendedAttribute1, 1, []byte("frag")),
+		radius.NewAvpExtendedVendorSpecific(radius.ExtendedAttribute2, 10415, 5, []byte{1, 2}),
+	)
+	bytes := message.ToBytes()
+	assert.Equal(t, []byte{241, 7, 1, 'f', 'r', 'a', 'g'}, bytes[26:33])
+	assert.Equal(t, []byte{242, 10, 26, 0, 0, 0x28, 0xaf, 5, 1, 2}, bytes[33:])
+	decoded, err := radius.ReadMessage(bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "user", decoded.Avps.GetFirst(1, 0).ToStringOrDefault())
+	assert.Len(t, decoded.Avps.Get(1, 0), 1)
+	assert.Equal(t, "frag", decoded.Avps.GetFirstExtended(radius.ExtendedAttribute1, 1, 0).ToStringOrDefault())
+	assert.Equal(t, []byte{1, 2}, decoded.Avps.GetFirstExtended(radius.ExtendedAttribute2, 5, 10415).ToData())
+}
+
+func Test_radius_long_extended_attributes(t *testing.T) {
+	value := make([]byte, 600)
+	for i := range value {
+		value[i] = byte(i)
+	}
+	message := radius.NewMessage(4, 1, [16]byte{},
+		radius.NewAvpExtended(radius.LongExtendedAttribute1, 3, value),
+		radius.NewAvpExtendedVendorSpecific(radius.LongExtendedAttribute2, 10415, 7, value[:300]),
+	)
+	bytes := message.ToBytes()
+	assert.Equal(t, []byte{245, 255, 3, 0x80}, bytes[20:24])
+	assert.Equal(t, []byte{245, 255, 3, 0x80}, bytes[275:279])
+	assert.Equal(t, []byte{245, 4 + 600 - 2*251, 3, 0}, bytes[530:534])
+	decoded, err := radius.ReadMessage(bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, decoded.Avps, 2)
+	assert.Equal(t, value, decoded.Avps.GetFirstExtended(radius.LongExtendedAttribute1, 3, 0).ToData())
+	assert.Equal(t, value[:300], decoded.Avps.GetFirstExtended(radius.LongExtendedAttribute2, 7, 10415).ToData())
+}
+
+func Test_radius_long_extended_incomplete(t *testing.T) {
+	_, err := radius.ReadMessage(radiusPacket(245, 5, 1, 0x80, 'a'))
+	attributeError := &radius.AttributeError{}
+	assert.True(t, errors.As(err, &attributeError))
+	_, err = radius.ReadMessage(radiusPacket(245, 5, 1, 0x80, 'a', 1, 3, 'b'))
+	assert.True(t, errors.As(err, &attributeError))
+	assert.Equal(t, 25, attributeError.Offset)
+	_, err = radius.ReadMessage(radiusPacket(241, 2))
+	assert.True(t, errors.As(err, &attributeError))
+}
+
+func Test_radius_data_types(t *testing.T) {
+	_, ipv6Prefix, _ := net.ParseCIDR("2001:db8:1234::/44")
+	_, ipv4Prefix, _ := net.ParseCIDR("10.1.0.0/16")
+	avps := radius.NewAvps().
+		AddUint64(26, 1, 1<<40).
+		AddIPv6(168, 0, net.ParseIP("2001:db8::1")).
+		AddIPv6Prefix(97, 0, net.IPNet{IP: net.ParseIP("2001:db8:1234:5678::"), Mask: ipv6Prefix.Mask}).
+		AddIPv4Prefix(1, 0, *ipv4Prefix).
+		AddInterfaceId(96, 0, radius.InterfaceId{1, 2, 3, 4, 5, 6, 7, 8}).
+		AddTLV(2, 0, radius.NewAvpString(1, 0, "a"), radius.NewAvpTLV(2, 0, radius.NewAvpUint32(3, 0, 7)))
+	decoded, err := radius.ReadMessage(radius.NewMessage(4, 1, [16]byte{}, avps...).ToBytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, uint64(1<<40), decoded.Avps.GetFirst(26, 1).ToUint64OrDefault())
+	assert.Equal(t, "2001:db8::1", decoded.Avps.GetFirst(168, 0).ToIPv6OrDefault().String())
+	assert.Equal(t, []byte{0, 44, 0x20, 0x01, 0x0d, 0xb8, 0x12, 0x30}, decoded.Avps.GetFirst(97, 0).ToData())
+	assert.Equal(t, ipv6Prefix.String(), decoded.Avps.GetFirst(97, 0).ToIPv6Prefix().String())
+	assert.Equal(t, "10.1.0.0/16", decoded.Avps.GetFirst(1, 0).ToIPv4Prefix().String())
+	assert.Equal(t, radius.InterfaceId{1, 2, 3, 4, 5, 6, 7, 8}, *decoded.Avps.GetFirst(96, 0).ToInterfaceId())
+	tlvs := decoded.Avps.GetFirst(2, 0).ToTLV()
+	assert.Len(t, tlvs, 2)
+	assert.Equal(t, "a", tlvs.GetFirst(1, 0).ToStringOrDefault())
+	assert.Equal(t, uint32(7), tlvs.GetFirst(2, 0).ToTLV().GetFirst(3, 0).ToUint32OrDefault())
+
+	_, ok := decoded.Avps.GetFirst(168, 0).ToUint64Ok()
+	assert.True(t, ok)
+	_, ok = decoded.Avps.GetFirst(96, 0).ToIPv6Ok()
+	assert.False(t, ok)
+	invalid := radius.NewAvps().Add(97, 0, []byte{0, 129}).Add(2, 0, []byte{1, 5, 0})
+	_, ok = invalid.GetFirst(97, 0).ToIPv6PrefixOk()
+	assert.False(t, ok)
+	assert.Nil(t, invalid.GetFirst(2, 0).ToTLV())
+}
+
+func Test_radius_ip_address_family(t *testing.T) {
+	ipv4 := net.ParseIP("192.0.2.1")
+	ipv6 := net.ParseIP("2001:db8::1")
+	avps := radius.NewAvps().AddNetIP(8, 0, ipv4).AddNetIP(168, 0, ipv6).AddIPv4(4, 0, ipv4).AddIPv6(95, 0, ipv6)
+	decoded, err := radius.ReadMessage(radius.NewMessage(1, 1, [16]byte{}, avps...).ToBytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, decoded.Avps.GetFirst(8, 0).ToData(), 4)
+	assert.Equal(t, ipv6.String(), decoded.Avps.GetFirst(168, 0).ToNetIPOrDefault().String())
+	assert.Equal(t, "192.0.2.1", decoded.Avps.GetFirst(4, 0).ToIPv4OrDefault().String())
+	_, ok := decoded.Avps.GetFirst(4, 0).ToIPv6Ok()
+	assert.False(t, ok)
+	_, ok = decoded.Avps.GetFirst(95, 0).ToIPv4Ok()
+	assert.False(t, ok)
+	assert.Equal(t, ipv6.String(), decoded.Avps.GetFirst(95, 0).ToIPv6().String())
+}
+
+func Test_radius_prefix_family(t *testing.T) {
+	_, ipv4Prefix, _ := net.ParseCIDR("192.0.2.0/24")
+	ipv6 := radius.NewAvpIPv6Prefix(97, 0, *ipv4Prefix).Data
+	assert.Equal(t, byte(120), ipv6[1])
+	mapped := net.IPNet{IP: net.ParseIP("::ffff:192.0.2.0"), Mask: net.CIDRMask(120, 128)}
+	assert.Equal(t, []byte{0, 24, 192, 0, 2, 0}, []byte(radius.NewAvpIPv4Prefix(1, 0, mapped).Data))
+}
+
+func Test_radius_response_authenticator(t *testing.T) {
+	// The expected authenticator was computed independently of the package.
+	requestAuthenticator := [16]byte{0x0f, 0x40, 0x3f, 0x94, 0x73, 0x97, 0x80, 0x57, 0xbd, 0x83, 0xd5, 0xcb, 0x98, 0xf4, 0x22, 0x7a}
+	secret := []byte("xyzzy5461")
+	accept := radius.NewMessage(2, 0, [16]byte{},
+		radius.NewAvpUint32(6, 0, 2),
+		radius.NewAvpUint32(7, 0, 1),
+		radius.NewAvpNetIP(8, 0, net.ParseIP("255.255.255.254")),
+		radius.NewAvpUint32(10, 0, 0),
+		radius.NewAvpUint32(12, 0, 1500),
+		radius.NewAvpUint32(13, 0, 1),
+	)
+	accept.SetResponseAuthenticator(requestAuthenticator, secret)
+	assert.Equal(t, [16]byte{0x27, 0x6e, 0xc9, 0x35, 0x5b, 0x11, 0x42, 0x3a, 0x61, 0x1f, 0x93, 0xa4, 0x7c, 0x10, 0xa3, 0x9d}, accept.Authenticator)
+	assert.True(t, accept.VerifyResponseAuthenticator(requestAuthenticator, secret))
+	assert.False(t, accept.VerifyResponseAuthenticator(requestAuthenticator, []byte("wrong")))
+}
+
+func Test_radius_accounting_authenticator(t *testing.T) {
+	secret := []byte("secret")
+	request := radius.NewMessage(4, 7, [16]byte{}, radius.NewAvpUint32(40, 0, 1), radius.NewAvpString(44, 0, "session"))
+	request.SetAccountingAuthenticator(secret)
+	assert.NotEqual(t, [16]byte{}, request.Authenticator)
+	decoded, err := radius.ReadMessage(request.ToBytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, decoded.VerifyAccountingAuthenticator(secret))
+	decoded.Avps = decoded.Avps.AddUint32(46, 0, 10)
+	assert.False(t, decoded.VerifyAccountingAuthenticator(secret))
+
+	authenticator, err := radius.NewRequestAuthenticator()
+	assert.NoError(t, err)
+	other, _ := radius.NewRequestAuthenticator()
+	assert.NotEqual(t, authenticator, other)
+}
+
+func Test_radius_message_authenticator(t *testing.T) {
+	secret := []byte("secret")
+	authenticator, _ := radius.NewRequestAuthenticator()
+	request := radius.NewMessage(1, 3, authenticator, radius.NewAvpString(1, 0, "user"))
+	request.AddMessageAuthenticator(secret)
+	assert.Equal(t, radius.AttributeMessageAuthenticator, request.Avps[0].Type)
+	assert.Len(t, request.Avps[0].Data, 16)
+	decoded, err := radius.ReadMessage(request.ToBytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, decoded.VerifyMessageAuthenticator(secret, true))
+	assert.ErrorIs(t, decoded.VerifyMessageAuthenticator([]byte("wrong"), true), radius.ErrMessageAuthenticatorInvalid)
+	request.AddMessageAuthenticator(secret)
+	assert.Len(t, request.Avps, 2)
+
+	unsigned := radius.NewMessage(1, 4, authenticator, radius.NewAvpString(1, 0, "user"))
+	assert.NoError(t, unsigned.VerifyMessageAuthenticator(secret, false))
+	assert.ErrorIs(t, unsigned.VerifyMessageAuthenticator(secret, true), radius.ErrMessageAuthenticatorMissing)
+
+	accept := radius.NewMessage(2, 3, [16]byte{}, radius.NewAvpUint32(6, 0, 2))
+	accept.AddResponseMessageAuthenticator(request.Authenticator, secret)
+	accept.SetResponseAuthenticator(request.Authenticator, secret)
+	decoded, err = radius.ReadMessage(accept.ToBytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, decoded.VerifyResponseAuthenticator(request.Authenticator, secret))
+	assert.NoError(t, decoded.VerifyResponseMessageAuthenticator(request.Authenticator, secret, true))
+
+	accounting := radius.NewMessage(4, 5, [16]byte{}, radius.NewAvpUint32(40, 0, 1))
+	accounting.AddMessageAuthenticator(secret)
+	accounting.SetAccountingAuthenticator(secret)
+	assert.True(t, accounting.VerifyAccountingAuthenticator(secret))
+	assert.NoError(t, accounting.VerifyMessageAuthenticator(secret, true))
+}
+
+func Test_radius_salted(t *testing.T) {
+	secret := []byte("secret")
+	requestAuthenticator := [16]byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+	value := []byte("tunnel-password-that-is-long")
+	encrypted, err := radius.EncryptSalted(value, secret, requestAuthenticator, [2]byte{0x81, 0x02})
+	assert.NoError(t, err)
+	expected, _ := hex.DecodeString("81023dfb605b9c8e907786d1df6af8a6ce6980f7b10a470f6d508344642d6d6632df")
+	assert.Equal(t, expected, encrypted)
+	decrypted, err := radius.DecryptSalted(encrypted, secret, requestAuthenticator)
+	assert.NoError(t, err)
+	assert.Equal(t, value, decrypted)
+	_, err = radius.DecryptSalted(encrypted[:10], secret, requestAuthenticator)
+	assert.ErrorIs(t, err, radius.ErrInvalidSaltedValue)
+	encrypted, err = radius.EncryptSalted(make([]byte, 239), secret, requestAuthenticator, [2]byte{0x81, 0x02})
+	assert.NoError(t, err)
+	assert.Len(t, encrypted, 242)
+	_, err = radius.EncryptSalted(make([]byte, 240), secret, requestAuthenticator, [2]byte{0x81, 0x02})
+	assert.ErrorIs(t, err, radius.ErrSaltedValueTooLong)
+	_, err = radius.NewAvpTunnelPassword(1, make([]byte, 256), secret, requestAuthenticator)
+	assert.ErrorIs(t, err, radius.ErrSaltedValueTooLong)
+
+	salt, err := radius.NewSalt()
+	assert.NoError(t, err)
+	assert.Equal(t, byte(0x80), salt[0]&0x80)
+
+	tunnelPassword, err := radius.NewAvpTunnelPassword(1, []byte("password"), secret, requestAuthenticator)
+	assert.NoError(t, err)
+	sendKey, err := radius.NewAvpSalted(radius.AttributeMSMPPESendKey, radius.VendorMicrosoft, make([]byte, 32), secret, requestAuthenticator)
+	assert.NoError(t, err)
+	decoded, err := radius.ReadMessage(radius.NewMessage(2, 1, [16]byte{}, tunnelPassword, sendKey).ToBytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tag, password, err := decoded.Avps.GetFirst(radius.AttributeTunnelPassword, 0).ToTunnelPassword(secret, requestAuthenticator)
+	assert.NoError(t, err)
+	assert.Equal(t, byte(1), tag)
+	assert.Equal(t, []byte("password"), password)
+	key, err := decoded.Avps.GetFirst(radius.AttributeMSMPPESendKey, radius.VendorMicrosoft).ToSalted(secret, requestAuthenticator)
+	assert.NoError(t, err)
+	assert.Equal(t, make([]byte, 32), key)
+}
+
+func Test_radius_chap(t *testing.T) {
+	authenticator := [16]byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+	assert.Equal(t, [16]byte{0x23, 0xed, 0xe8, 0x32, 0x31, 0xc0, 0xbc, 0x7b, 0x7f, 0x00, 0xc3, 0x0f, 0xc5, 0x78, 0xca, 0xdc}, radius.CHAPResponse(7, []byte("password"), authenticator[:]))
+
+	request := radius.NewMessage(1, 1, authenticator, radius.NewAvpCHAPPassword(7, []byte("password"), authenticator[:]))
+	decoded, err := radius.ReadMessage(request.ToBytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	chapPassword := decoded.Avps.GetFirst(radius.AttributeCHAPPassword, 0).ToData()
+	assert.True(t, radius.VerifyCHAP(decoded.CHAPChallenge(), chapPassword, []byte("password")))
+	assert.False(t, radius.VerifyCHAP(decoded.CHAPChallenge(), chapPassword, []byte("wrong")))
+	assert.False(t, radius.VerifyCHAP(decoded.CHAPChallenge(), chapPassword[:5], []byte("password")))
+
+	challenge := []byte("a challenge of any length")
+	request = radius.NewMessage(1, 2, authenticator,
+		radius.NewAvpCHAPChallenge(challenge),
+		radius.NewAvpCHAPPassword(1, []byte("password"), challenge),
+	)
+	assert.Equal(t, challenge, request.CHAPChallenge())
+	assert.True(t, radius.VerifyCHAP(request.CHAPChallenge(), request.Avps.GetFirst(radius.AttributeCHAPPassword, 0).ToData(), []byte("password")))
+}
+
+func Test_radius_eap_message(t *testing.T) {
+	eap := make([]byte, 600)
+	for i := range eap {
+		eap[i] = byte(i)
+	}
+	secret := []byte("secret")
+	request := radius.NewMessage(1, 1, [16]byte{1}, radius.NewAvpString(1, 0, "user"), radius.NewAvpString(79, 0, "old"), radius.NewAvpUint32(5, 0, 1))
+	request.SetEAPMessage(eap)
+	assert.Equal(t, radius.AttributeMessageAuthenticator, request.Avps[0].Type)
+	fragments := request.Avps.Get(radius.AttributeEAPMessage, 0)
+	assert.Len(t, fragments, 3)
+	assert.Len(t, fragments[0].Data, 253)
+	assert.Len(t, fragments[2].Data, 94)
+	assert.Equal(t, radius.AttributeType(79), request.Avps[2].Type)
+	assert.Equal(t, radius.AttributeType(5), request.Avps[5].Type)
+	request.AddMessageAuthenticator(secret)
+
+	decoded, err := radius.ReadMessage(request.ToBytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, eap, decoded.EAPMessage())
+	assert.NoError(t, decoded.VerifyMessageAuthenticator(secret, false))
+
+	unsigned := radius.NewMessage(1, 1, [16]byte{}, radius.NewAvpString(79, 0, "eap"))
+	assert.ErrorIs(t, unsigned.VerifyMessageAuthenticator(secret, false), radius.ErrMessageAuthenticatorMissing)
+	assert.Nil(t, radius.NewMessage(1, 1, [16]byte{}).EAPMessage())
+}
+
+func Test_radius_user_password(t *testing.T) {
+	// RFC 2865 section 7.1.
+	requestAuthenticator := [16]byte{0x0f, 0x40, 0x3f, 0x94, 0x73, 0x97, 0x80, 0x57, 0xbd, 0x83, 0xd5, 0xcb, 0x98, 0xf4, 0x22, 0x7a}
+	secret := []byte("xyzzy5461")
+	hidden, err := radius.EncryptUserPassword([]byte("arctangent"), secret, requestAuthenticator)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x0d, 0xbe, 0x70, 0x8d, 0x93, 0xd4, 0x13, 0xce, 0x31, 0x96, 0xe4, 0x3f, 0x78, 0x2a, 0x0a, 0xee}, hidden)
+	password, err := radius.DecryptUserPassword(hidden, secret, requestAuthenticator)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("arctangent"), password)
+	long := []byte("a password longer than sixteen bytes")
+	hidden, err = radius.EncryptUserPassword(long, secret, requestAuthenticator)
+	assert.NoError(t, err)
+	password, err = radius.DecryptUserPassword(hidden, secret, requestAuthenticator)
+	assert.NoError(t, err)
+	assert.Equal(t, long, password)
+	_, err = radius.DecryptUserPassword(hidden[:5], secret, requestAuthenticator)
+	assert.ErrorIs(t, err, radius.ErrInvalidUserPassword)
+
+	hidden, err = radius.EncryptUserPassword(make([]byte, 128), secret, requestAuthenticator)
+	assert.NoError(t, err)
+	assert.Len(t, hidden, 128)
+	_, err = radius.EncryptUserPassword(make([]byte, 129), secret, requestAuthenticator)
+	assert.ErrorIs(t, err, radius.ErrUserPasswordTooLong)
+	_, err = radius.AccessRequest{UserName: "nemo", UserPassword: make([]byte, 129)}.Build(secret)
+	assert.ErrorIs(t, err, radius.ErrUserPasswordTooLong)
+}
+
+func Test_radius_access_request(t *testing.T) {
+	secret := []byte("secret")
+	request := radius.AccessRequest{
+		Identifier:       9,
+		UserName:         "nemo",
+		UserPassword:     []byte("arctangent"),
+		NASIPAddress:     net.ParseIP("192.168.1.16"),
+		NASPort:          3,
+		CallingStationId: "447700900000",
+		Avps:             radius.NewAvps().AddString(26, 10415, "extra"),
+	}
+	message, err := request.Build(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, radius.Code(1), message.Code)
+	assert.NotEqual(t, []byte("arctangent"), message.Avps.GetFirst(radius.AttributeUserPassword, 0).ToData())
+	decoded, err := radius.ReadMessage(message.ToBytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, decoded.VerifyMessageAuthenticator(secret, true))
+	received := radius.AccessRequest{}
+	assert.NoError(t, received.FromMessage(*decoded, secret))
+	assert.Equal(t, "nemo", received.UserName)
+	assert.Equal(t, []byte("arctangent"), received.UserPassword)
+	assert.Equal(t, "192.168.1.16", received.NASIPAddress.String())
+	assert.Equal(t, uint32(3), received.NASPort)
+	assert.Equal(t, "447700900000", received.CallingStationId)
+	assert.Len(t, received.Avps, 1)
+
+	chap := radius.AccessRequest{UserName: "nemo"}
+	assert.NoError(t, chap.UseCHAP(1, []byte("arctangent")))
+	message, err = chap.Build(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, message.Avps.GetFirst(radius.AttributeUserPassword, 0))
+	received = radius.AccessRequest{}
+	assert.NoError(t, received.FromMessage(message, secret))
+	assert.True(t, received.VerifyCHAP([]byte("arctangent")))
+	assert.False(t, received.VerifyCHAP([]byte("wrong")))
+	assert.Error(t, received.FromMessage(radius.NewMessage(4, 1, [16]byte{}), secret))
+}
+
+func Test_radius_accounting_request(t *testing.T) {
+	secret := []byte("secret")
+	request := radius.AccountingRequest{
+		Identifier:     3,
+		StatusType:     radius.AcctStop,
+		SessionId:      "0001",
+		UserName:       "nemo",
+		NASIPAddress:   net.ParseIP("192.168.1.16").To4(),
+		Authentic:      radius.AuthenticRADIUS,
+		SessionTime:    90 * time.Second,
+		InputOctets:    5<<32 | 7,
+		OutputOctets:   1000,
+		TerminateCause: radius.TerminateIdleTimeout,
+		DelayTime:      2 * time.Second,
+	}
+	message := request.Build(secret)
+	assert.True(t, message.VerifyAccountingAuthenticator(secret))
+	assert.Equal(t, uint32(7), message.Avps.GetFirst(radius.AttributeAcctInputOctets, 0).ToUint32OrDefault())
+	assert.Equal(t, uint32(5), message.Avps.GetFirst(radius.AttributeAcctInputGigawords, 0).ToUint32OrDefault())
+	assert.Nil(t, message.Avps.GetFirst(radius.AttributeAcctOutputGigawords, 0))
+
+	decoded, err := radius.ReadMessage(message.ToBytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	received := radius.AccountingRequest{}
+	assert.NoError(t, received.FromMessage(*decoded))
+	assert.Equal(t, request, received)
+	assert.Equal(t, "STOP", received.StatusType.String())
+	assert.Equal(t, "IDLE_TIMEOUT", received.TerminateCause.String())
+
+	retransmitted := radius.RetransmitAccounting(message, 4, 3*time.Second, secret)
+	assert.Equal(t, byte(4), retransmitted.Identifier)
+	assert.Equal(t, uint32(5), retransmitted.Avps.GetFirst(radius.AttributeAcctDelayTime, 0).ToUint32OrDefault())
+	assert.True(t, retransmitted.VerifyAccountingAuthenticator(secret))
+	assert.Equal(t, uint32(2), message.Avps.GetFirst(radius.AttributeAcctDelayTime, 0).ToUint32OrDefault())
+
+	event := radius.AccountingRequest{StatusType: radius.AcctStart, EventTimestamp: time.Now().Add(-10 * time.Second).Truncate(time.Second)}
+	delay := event.Build(secret).Avps.GetFirst(radius.AttributeAcctDelayTime, 0).ToUint32OrDefault()
+	assert.GreaterOrEqual(t, delay, uint32(10))
+}
+
+func Test_radius_dynamic_authorization(t *testing.T) {
+	secret := []byte("secret")
+	disconnect := radius.DisconnectRequest{
+		Identifier: 1,
+		Session:    radius.SessionIdentification{NASIPAddress: net.ParseIP("192.0.2.1").To4(), UserName: "nemo", AcctSessionId: "0001"},
+	}
+	message := disconnect.Build(secret)
+	decoded, err := radius.ReadMessage(message.ToBytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, decoded.VerifyDynamicRequest(secret))
+	received := radius.DisconnectRequest{}
+	assert.NoError(t, received.FromMessage(*decoded))
+	assert.Equal(t, disconnect, received)
+	assert.Error(t, (&radius.CoARequest{}).FromMessage(*decoded))
+
+	nak := radius.NewDynamicNAK(*decoded, radius.ErrorCauseSessionContextNotFound, secret)
+	assert.Equal(t, radius.CodeDisconnectNAK, nak.Code)
+	assert.True(t, nak.VerifyResponseAuthenticator(message.Authenticator, secret))
+	cause, ok := nak.ErrorCause()
+	assert.True(t, ok)
+	assert.Equal(t, "SESSION_CONTEXT_NOT_FOUND", cause.String())
+
+	coa := radius.CoARequest{
+		Identifier: 2,
+		Session:    radius.SessionIdentification{AcctSessionId: "0001"},
+		Avps:       radius.NewAvps().AddUint32(27, 0, 3600).Add(radius.AttributeMessageAuthenticator, 0, make([]byte, 16)),
+	}
+	message = coa.Build(secret)
+	assert.True(t, message.VerifyDynamicRequest(secret))
+	assert.NoError(t, message.VerifyMessageAuthenticator(secret, true))
+	receivedCoA := radius.CoARequest{}
+	assert.NoError(t, receivedCoA.FromMessage(message))
+	assert.Equal(t, uint32(3600), receivedCoA.Avps.GetFirst(27, 0).ToUint32OrDefault())
+	ack := radius.NewDynamicACK(message, secret)
+	assert.Equal(t, radius.CodeCoAACK, ack.Code)
+	assert.True(t, ack.VerifyResponseAuthenticator(message.Authenticator, secret))
+	_, ok = ack.ErrorCause()
+	assert.False(t, ok)
+}
+
+func Test_radius_code(t *testing.T) {
+	assert.Equal(t, "ACCESS_CHALLENGE", radius.CodeAccessChallenge.String())
+	assert.Equal(t, "COA_NAK", radius.CodeCoANAK.String())
+	assert.Equal(t, "UNKNOWN", radius.Code(99).String())
+	assert.True(t, radius.CodeStatusServer.Known())
+	assert.False(t, radius.Code(99).Known())
+
+	bytes := radius.NewMessage(99, 1, [16]byte{}).ToBytes()
+	_, err := radius.ReadMessage(bytes)
+	assert.NoError(t, err)
+	_, err = radius.ReadMessageStrict(bytes)
+	assert.ErrorIs(t, err, radius.ErrUnknownCode)
+	message, err := radius.ReadMessageStrict(radius.NewMessage(radius.CodeAccessAccept, 1, [16]byte{}).ToBytes())
+	assert.NoError(t, err)
+	assert.Equal(t, radius.CodeAccessAccept, message.Code)
+}
+
+func Test_radius_errors(t *testing.T) {
+	_, err := radius.ReadMessage(make([]byte, 10))
+	assert.ErrorIs(t, err, radius.ErrTruncated)
+
+	message := radius.NewMessage(1, 1, [16]byte{}, radius.NewAvpString(rfc2865.UserName, 0, "user"))
+	data := message.ToBytes()
+	binary.BigEndian.PutUint16(data[2:4], 40)
+	_, err = radius.ReadMessage(data)
+	var invalidLength *radius.InvalidLengthError
+	assert.ErrorAs(t, err, &invalidLength)
+	assert.Equal(t, radius.InvalidLengthError{Offset: 0, Declared: 40, Available: 26}, *invalidLength)
+
+	data = message.ToBytes()
+	data[21] = 10
+	_, err = radius.ReadMessage(data)
+	var attributeError *radius.AttributeError
+	assert.ErrorAs(t, err, &attributeError)
+	assert.ErrorIs(t, err, radius.ErrInvalidLength)
+	assert.ErrorAs(t, err, &invalidLength)
+	assert.Equal(t, radius.InvalidLengthError{Offset: 20, Declared: 10, Available: 6}, *invalidLength)
+
+	_, _, err = radius.SplitMessages(append(message.ToBytes(), 1, 2, 0, 4))
+	assert.ErrorAs(t, err, &invalidLength)
+	assert.Equal(t, radius.InvalidLengthError{Offset: 26, Declared: 4, Available: 4}, *invalidLength)
+
+	secret := []byte("secret")
+	accept := radius.NewMessage(2, 1, [16]byte{})
+	accept.AddResponseMessageAuthenticator(message.Authenticator, secret)
+	accept.SetResponseAuthenticator(message.Authenticator, secret)
+	assert.NoError(t, accept.VerifyResponse(message.Authenticator, secret, true))
+	err = accept.VerifyResponse(message.Authenticator, []byte("wrong"), true)
+	assert.ErrorIs(t, err, radius.ErrResponseAuthenticatorInvalid)
+	assert.ErrorIs(t, err, radius.ErrBadAuthenticator)
+	accept.Authenticator = accept.ResponseAuthenticator(message.Authenticator, []byte("wrong"))
+	err = accept.VerifyResponse(message.Authenticator, []byte("wrong"), true)
+	assert.ErrorIs(t, err, radius.ErrMessageAuthenticatorInvalid)
+	assert.ErrorIs(t, err, radius.ErrBadAuthenticator)
+	assert.ErrorIs(t, radius.NewMessage(2, 1, [16]byte{}).VerifyResponseMessageAuthenticator(message.Authenticator, secret, true), radius.ErrMessageAuthenticatorMissing)
+	assert.NotErrorIs(t, radius.ErrMessageAuthenticatorMissing, radius.ErrBadAuthenticator)
+}
+
+func Test_radius_codec(t *testing.T) {
+	codec := radius.Codec{
+		Decode: func(data []byte) (any, error) {
+			if len(data) != 2 {
+				return nil, errors.New("port must be 2 bytes")
+			}
+			return binary.BigEndian.Uint16(data), nil
+		},
+		Encode: func(value any) ([]byte, error) {
+			port, ok := value.(uint16)
+			if !ok {
+				return nil, errors.New("port must be a uint16")
+			}
+			return binary.BigEndian.AppendUint16(nil, port), nil
+		},
+	}
+	dictionary := radius.NewDictionary().AddAttribute(rfc2865.Attributes...).AddCodec(1, 9, codec)
+
+	avp, err := radius.NewAvpValue(dictionary, 1, 9, uint16(1812))
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(1812), avp.TypedValue(dictionary))
+	_, err = radius.NewAvpValue(dictionary, 1, 9, 1812)
+	assert.EqualError(t, err, "attribute 1 (vendor 9): port must be a uint16")
+	_, err = radius.NewAvpValue(dictionary, rfc2865.UserName, 0, "user")
+	assert.EqualError(t, err, "no codec encodes attribute 1 (vendor 0)")
+
+	malformed := radius.NewAvp(1, 9, []byte{1})
+	assert.Equal(t, []byte{1}, malformed.TypedValue(dictionary))
+	userName := radius.NewAvpString(rfc2865.UserName, 0, "user")
+	assert.Equal(t, "user", userName.TypedValue(dictionary))
+}
+
+func Test_radius_tbcd(t *testing.T) {
+	avp, err := radius.NewAvpTBCD(1, radius.Vendor3GPP, "234150999999999")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x32, 0x14, 0x05, 0x99, 0x99, 0x99, 0x99, 0xf9}, []byte(avp.Data))
+	assert.Equal(t, "234150999999999", *avp.ToTBCD())
+	invalid := radius.NewAvp(1, radius.Vendor3GPP, []byte{0xff, 0x01})
+	_, ok := invalid.ToTBCDOk()
+	assert.False(t, ok)
+}
+
+func Test_radius_station_id(t *testing.T) {
+	mac := net.HardwareAddr{0x00, 0x10, 0xa4, 0x23, 0x19, 0xc0}
+	for _, value := range []string{"00-10-A4-23-19-C0", "00:10:a4:23:19:c0", "0010.a423.19c0", "0010A42319C0"} {
+		stationId, err := radius.ParseStationId(value)
+		assert.NoError(t, err, value)
+		assert.Equal(t, radius.StationId{MAC: mac}, stationId, value)
+	}
+	stationId, err := radius.ParseStationId("00-10-a4-23-19-c0:corp:guest")
+	assert.NoError(t, err)
+	assert.Equal(t, radius.StationId{MAC: mac, SSID: "corp:guest"}, stationId)
+	stationId, err = radius.ParseStationId("0010.a423.19c0:corp")
+	assert.NoError(t, err)
+	assert.Equal(t, "corp", stationId.SSID)
+	for _, value := range []string{"", "00-10-A4-23-19", "00-10-A4-23-19-C0corp", "zz10a42319c0", "447700900123x"} {
+		_, err := radius.ParseStationId(value)
+		assert.Error(t, err, value)
+	}
+
+	stationId = radius.StationId{MAC: mac, SSID: "corp"}
+	assert.Equal(t, "00-10-A4-23-19-C0:corp", stationId.String())
+	assert.Equal(t, "00:10:a4:23:19:c0:corp", stationId.Format(radius.MACColon))
+	assert.Equal(t, "0010.a423.19c0:corp", stationId.Format(radius.MACDot))
+	assert.Equal(t, "0010a42319c0", radius.StationId{MAC: mac}.Format(radius.MACBare))
+
+	avps := radius.NewAvps().AddStationId(rfc2865.CalledStationId, stationId)
+	assert.Equal(t, "00-10-A4-23-19-C0:corp", avps[0].ToStringOrDefault())
+	assert.Equal(t, stationId, avps[0].ToStationIdOrDefault())
+	assert.Nil(t, avps.GetFirst(rfc2865.CallingStationId, 0).ToStationId())
+}
+
+func Test_radius_3gpp_attributes(t *testing.T) {
+	data, err := base64.StdEncoding.DecodeString(accountingRequestCapture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	message, err := radius.ReadMessage(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	avps := message.Avps
+	assert.Equal(t, "901280064290558", *avps.IMSI())
+	assert.Equal(t, uint32(0x01117863), *avps.ChargingId())
+	assert.Equal(t, net.IPv4(80, 10, 2, 131).To4(), avps.SGSNAddress().To4())
+	assert.Equal(t, "8688080469613401", *avps.IMEISV())
+	location := avps.UserLocationInfo()
+	assert.Equal(t, threegpp.LocationTAIAndECGI, location.Type)
+	assert.Equal(t, threegpp.TAI{PLMN: threegpp.PLMN{MCC: "208", MNC: "01"}, TAC: 0xcda7}, *location.TAI)
+	assert.Equal(t, threegpp.ECGI{PLMN: threegpp.PLMN{MCC: "208", MNC: "01"}, ECI: 0x17e0c0c}, *location.ECGI)
+
+	uli, err := radius.NewAvpUserLocationInfo(*location)
+	assert.NoError(t, err)
+	assert.Equal(t, avps.GetFirst(radius.Attribute3GPPUserLocationInfo, radius.Vendor3GPP).ToData(), uli.ToData())
+	_, err = radius.NewAvpUserLocationInfo(threegpp.UserLocationInfo{Type: threegpp.LocationTAI})
+	assert.EqualError(t, err, "missing TAI")
+
+	built := radius.NewAvps().
+		AddIMSI("001010123456789").
+		AddChargingId(7).
+		AddSGSNAddress(net.IPv4(10, 0, 0, 1)).
+		AddIMEISV("3534900698733190")
+	assert.Equal(t, "001010123456789", *built.IMSI())
+	assert.Equal(t, uint32(7), *built.ChargingId())
+	assert.Equal(t, "10.0.0.1", built.SGSNAddress().String())
+	assert.Equal(t, "3534900698733190", *built.IMEISV())
+	assert.Nil(t, built.UserLocationInfo())
+
+	plmn, err := threegpp.EncodePLMN(threegpp.PLMN{MCC: "310", MNC: "410"})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x13, 0x00, 0x14}, plmn)
+	decoded, err := threegpp.DecodePLMN(plmn)
+	assert.NoError(t, err)
+	assert.Equal(t, "310-410", decoded.String())
+	_, err = threegpp.EncodePLMN(threegpp.PLMN{MCC: "31", MNC: "410"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,180 @@
+package tests
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"math/big"
+	"net"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tinybluerobots/radius-diameter-message/radius"
+	radiusclient "github.com/tinybluerobots/radius-diameter-message/radius/client"
+	radiusserver "github.com/tinybluerobots/radius-diameter-message/radius/server"
+)
+
+// testCertificate creates a certificate for the common name signed by the
+// parent, or self-signed if parent is nil.
+func testCertificate(t *testing.T, name string, parent *tls.Certificate) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  parent == nil,
+	}
+	signer, signerKey := template, any(key)
+	if parent != nil {
+		signer, signerKey = parent.Leaf, parent.PrivateKey
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, _ := x509.ParseCertificate(der)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+func Test_radsec(t *testing.T) {
+	ca := testCertificate(t, "ca", nil)
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.Leaf)
+	serverCertificate := testCertificate(t, "server", &ca)
+	trusted := testCertificate(t, "nas1", &ca)
+	untrusted := testCertificate(t, "nas2", &ca)
+
+	s := radiusserver.New(radiusserver.Config{
+		AuthorizeCertificate: func(certificate *x509.Certificate) bool {
+			return certificate.Subject.CommonName == "nas1"
+		},
+	})
+	s.Handle(radius.CodeAccessRequest, func(ctx context.Context, writer radiusserver.ResponseWriter, request radius.Message) {
+		certificate, _ := radiusserver.PeerCertificateFromContext(ctx)
+		secret, _ := radiusserver.SecretFromContext(ctx)
+		access := radius.AccessRequest{}
+		access.FromMessage(request, secret)
+		writer.WriteResponse(radius.NewMessage(radius.CodeAccessAccept, 0, [16]byte{},
+			radius.NewAvpString(radius.AttributeUserName, 0, certificate.Subject.CommonName+"/"+string(access.UserPassword))))
+	})
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{serverCertificate},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    pool,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go s.ServeStream(listener)
+	defer s.Close()
+
+	dial := func(certificate tls.Certificate) *radiusclient.Stream {
+		stream, err := radiusclient.DialTLS(context.Background(), listener.Addr().String(), &tls.Config{
+			Certificates: []tls.Certificate{certificate},
+			RootCAs:      pool,
+		}, radiusclient.Config{Timeout: time.Second})
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { stream.Close() })
+		return stream
+	}
+	stream := dial(trusted)
+	for _, password := range []string{"arctangent", "secant"} {
+		request, _ := radius.AccessRequest{UserName: "nemo", UserPassword: []byte(password)}.Build(radius.RadSecSecret)
+		response, err := stream.Send(context.Background(), request)
+		assert.NoError(t, err)
+		assert.Equal(t, radius.CodeAccessAccept, response.Code)
+		assert.Equal(t, "nas1/"+password, response.Avps.GetFirst(radius.AttributeUserName, 0).ToStringOrDefault())
+	}
+
+	rejected := dial(untrusted)
+	request, _ := radius.AccessRequest{UserName: "nemo"}.Build(radius.RadSecSecret)
+	_, err = rejected.Send(context.Background(), request)
+	assert.Error(t, err)
+	<-rejected.Done()
+	assert.Error(t, rejected.Err())
+
+	stream.Close()
+	_, err = stream.Send(context.Background(), request)
+	assert.ErrorIs(t, err, radiusclient.ErrClosed)
+}
+
+func Test_radius_over_tcp(t *testing.T) {
+	secret := []byte("secret")
+	s := radiusserver.New(radiusserver.Config{Secret: func(net.IP) []byte { return secret }})
+	s.Handle(radius.CodeAccountingRequest, func(ctx context.Context, writer radiusserver.ResponseWriter, request radius.Message) {
+		writer.WriteResponse(radius.NewMessage(radius.CodeAccountingResponse, 0, [16]byte{}))
+	})
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go s.ServeStream(listener)
+	defer s.Close()
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream := radiusclient.NewStream(conn, secret, radiusclient.Config{Timeout: time.Second})
+	defer stream.Close()
+	for range 3 {
+		response, err := stream.Send(context.Background(), radius.AccountingRequest{StatusType: radius.AcctStart}.Build(secret))
+		assert.NoError(t, err)
+		assert.Equal(t, radius.CodeAccountingResponse, response.Code)
+	}
+
+	dialed, err := radiusclient.DialTCP(context.Background(), listener.Addr().String(), secret, radiusclient.Config{Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dialed.Close()
+	response, err := dialed.Send(context.Background(), radius.AccountingRequest{StatusType: radius.AcctStop}.Build(secret))
+	assert.NoError(t, err)
+	assert.Equal(t, radius.CodeAccountingResponse, response.Code)
+}
+
+func Test_radius_scan_messages(t *testing.T) {
+	first := radius.NewMessage(radius.CodeAccessRequest, 1, [16]byte{}, radius.NewAvpString(radius.AttributeUserName, 0, "nemo"))
+	second := radius.NewMessage(radius.CodeAccessRequest, 2, [16]byte{})
+	stream := radius.EncodeAll([]radius.Message{first, second}, nil)
+
+	scanner := bufio.NewScanner(iotest.OneByteReader(bytes.NewReader(stream)))
+	scanner.Split(radius.ScanMessages)
+	var identifiers []byte
+	for scanner.Scan() {
+		message, err := radius.ReadMessage(scanner.Bytes())
+		assert.NoError(t, err)
+		identifiers = append(identifiers, message.Identifier)
+	}
+	assert.NoError(t, scanner.Err())
+	assert.Equal(t, []byte{1, 2}, identifiers)
+
+	scanner = bufio.NewScanner(bytes.NewReader(stream[:30]))
+	scanner.Split(radius.ScanMessages)
+	assert.True(t, scanner.Scan())
+	assert.False(t, scanner.Scan())
+	assert.ErrorIs(t, scanner.Err(), io.ErrUnexpectedEOF)
+
+	scanner = bufio.NewScanner(bytes.NewReader([]byte{1, 1, 0, 4}))
+	scanner.Split(radius.ScanMessages)
+	assert.False(t, scanner.Scan())
+	assert.ErrorIs(t, scanner.Err(), radius.ErrInvalidLength)
+}
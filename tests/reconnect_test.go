@@ -0,0 +1,69 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tinybluerobots/radius-diameter-message/diameter"
+	"github.com/tinybluerobots/radius-diameter-message/diameter/client"
+	"github.com/tinybluerobots/radius-diameter-message/diameter/server"
+)
+
+func Test_peer_reconnect(t *testing.T) {
+	address := startServer(t, server.New(server.Config{Capabilities: serverCapabilities}))
+	var attempts atomic.Int32
+	dial := func(ctx context.Context) (*client.Client, error) {
+		if attempts.Add(1) == 1 {
+			return nil, errors.New("connection refused")
+		}
+		return client.Dial(ctx, address, client.Config{Capabilities: clientCapabilities})
+	}
+	states := make(chan client.PeerState, 20)
+	peer := client.NewPeer(dial, client.ReconnectConfig{
+		Tc:      10 * time.Millisecond,
+		Backoff: true,
+		Jitter:  0.1,
+		OnStateChange: func(state client.PeerState, err error) {
+			states <- state
+		},
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- peer.Run(ctx) }()
+
+	waitForState := func(want client.PeerState) {
+		for {
+			select {
+			case state := <-states:
+				if state == want {
+					return
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatalf("peer did not reach %s", want)
+			}
+		}
+	}
+	waitForState(client.PeerWaiting)
+	waitForState(client.PeerOpen)
+	c, ok := peer.Client()
+	assert.True(t, ok)
+	c.Close()
+	waitForState(client.PeerWaiting)
+	waitForState(client.PeerOpen)
+	assert.Equal(t, int32(3), attempts.Load())
+
+	_, err := peer.SendRequest(context.Background(), diameter.NewMessage(1, 0, diameter.CommandCreditControl, diameter.ApplicationCreditControl, [4]byte{}, [4]byte{}))
+	assert.NoError(t, err)
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+	assert.Equal(t, client.PeerClosed, peer.State())
+	_, ok = peer.Client()
+	assert.False(t, ok)
+	_, err = peer.SendRequest(context.Background(), diameter.NewMessage(1, 0, diameter.CommandCreditControl, diameter.ApplicationCreditControl, [4]byte{}, [4]byte{}))
+	assert.ErrorIs(t, err, client.ErrNoPeer)
+}
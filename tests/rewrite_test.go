@@ -0,0 +1,46 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tinybluerobots/radius-diameter-message/radius"
+	"github.com/tinybluerobots/radius-diameter-message/radius/rewrite"
+)
+
+const rewriteYAML = `
+rules:
+  - attribute: 31
+    match: '^([0-9a-fA-F]{2})[:.]?([0-9a-fA-F]{2})[:.]?([0-9a-fA-F]{2})[:.]?([0-9a-fA-F]{2})[:.]?([0-9a-fA-F]{2})[:.]?([0-9a-fA-F]{2})$'
+    action: replace
+    value: $1-$2-$3-$4-$5-$6
+  - vendorId: 9
+    action: remove
+  - codes: [1]
+    attribute: 32
+    action: add
+    value: proxy
+`
+
+func Test_rewrite_rules(t *testing.T) {
+	engine, err := rewrite.ParseYAML([]byte(rewriteYAML))
+	assert.NoError(t, err)
+	message := radius.NewMessage(radius.CodeAccessRequest, 1, [16]byte{},
+		radius.NewAvpString(radius.AttributeCallingStationId, 0, "00:11:22:aa:bb:cc"),
+		radius.NewAvpString(1, 9, "cisco-avpair"),
+		radius.NewAvpString(1, 14988, "mikrotik"),
+	)
+	rewritten := engine.Apply(message)
+	assert.Equal(t, "00-11-22-aa-bb-cc", rewritten.Avps.GetFirst(radius.AttributeCallingStationId, 0).ToStringOrDefault())
+	assert.Nil(t, rewritten.Avps.GetFirst(1, 9))
+	assert.NotNil(t, rewritten.Avps.GetFirst(1, 14988))
+	assert.Equal(t, "proxy", rewritten.Avps.GetFirst(radius.AttributeNASIdentifier, 0).ToStringOrDefault())
+	assert.Equal(t, "00:11:22:aa:bb:cc", message.Avps.GetFirst(radius.AttributeCallingStationId, 0).ToStringOrDefault())
+
+	accounting := engine.Apply(radius.NewMessage(radius.CodeAccountingRequest, 1, [16]byte{}))
+	assert.Nil(t, accounting.Avps.GetFirst(radius.AttributeNASIdentifier, 0))
+
+	_, err = rewrite.New(rewrite.Rule{Action: "rename"}, rewrite.Rule{Action: rewrite.ActionRemove, Match: "("})
+	assert.ErrorContains(t, err, "rules[0]")
+	assert.ErrorContains(t, err, "rules[1]")
+}
@@ -0,0 +1,175 @@
+package tests
+
+import (
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tinybluerobots/radius-diameter-message/enterprise"
+	"github.com/tinybluerobots/radius-diameter-message/radius"
+	"github.com/tinybluerobots/radius-diameter-message/radius/dictionary"
+	"github.com/tinybluerobots/radius-diameter-message/radius/rfc2865"
+	"github.com/tinybluerobots/radius-diameter-message/radius/rfc2866"
+	"github.com/tinybluerobots/radius-diameter-message/radius/rfc2869"
+	"github.com/tinybluerobots/radius-diameter-message/radius/rfc3162"
+)
+
+func Test_radius_rfc_attributes(t *testing.T) {
+	assert.Equal(t, radius.AttributeCallingStationId, rfc2865.CallingStationId)
+	assert.Equal(t, radius.AttributeAcctStatusType, rfc2866.AcctStatusType)
+	assert.Equal(t, radius.AttributeMessageAuthenticator, rfc2869.MessageAuthenticator)
+	assert.Equal(t, radius.AttributeNASIPv6Address, rfc3162.NASIPv6Address)
+
+	seen := map[radius.AttributeType]string{}
+	for _, attributes := range [][]radius.AttributeDefinition{rfc2865.Attributes, rfc2866.Attributes, rfc2869.Attributes, rfc3162.Attributes} {
+		for _, definition := range attributes {
+			assert.NotContains(t, seen, definition.Type, definition.Name)
+			seen[definition.Type] = definition.Name
+			assert.Equal(t, definition.DataType == radius.Enum, definition.Values != nil, definition.Name)
+		}
+	}
+	assert.Equal(t, "Calling-Station-Id", seen[rfc2865.CallingStationId])
+	assert.Equal(t, "ifid", rfc3162.Attributes[1].DataType.String())
+	assert.Equal(t, "Interim-Update", rfc2866.Attributes[0].Values[3])
+}
+
+func Test_radius_dictionary_typed_value(t *testing.T) {
+	dictionary := radius.NewDictionary().AddAttribute(rfc2865.Attributes...).AddAttribute(rfc2866.Attributes...).AddAttribute(rfc2869.Attributes...).AddAttribute(rfc3162.Attributes...)
+	_, prefix, _ := net.ParseCIDR("2001:db8::/32")
+	avps := radius.NewAvps().
+		AddString(rfc2865.CallingStationId, 0, "00-11-22-33-44-55").
+		AddUint32(rfc2865.ServiceType, 0, 2).
+		AddUint32(rfc2865.ServiceType, 0, 99).
+		AddUint32(rfc2865.SessionTimeout, 0, 3600).
+		AddIPv4(rfc2865.NASIPAddress, 0, net.ParseIP("192.0.2.1")).
+		AddTime(rfc2869.EventTimestamp, 0, time.Unix(1700000000, 0)).
+		AddIPv6Prefix(rfc3162.FramedIPv6Prefix, 0, *prefix).
+		Add(rfc2865.State, 0, []byte{1, 2}).
+		Add(rfc2865.NASPort, 0, []byte{1}).
+		AddString(200, 0, "unknown")
+
+	assert.Equal(t, "00-11-22-33-44-55", avps.GetFirstByName(dictionary, "Calling-Station-Id").TypedValue(dictionary))
+	serviceTypes := avps.GetByName(dictionary, "Service-Type")
+	assert.Len(t, serviceTypes, 2)
+	assert.Equal(t, "Framed-User", serviceTypes[0].TypedValue(dictionary))
+	assert.Equal(t, uint32(99), serviceTypes[1].TypedValue(dictionary))
+	assert.Equal(t, uint32(3600), avps.GetFirstByName(dictionary, "Session-Timeout").TypedValue(dictionary))
+	assert.Equal(t, net.ParseIP("192.0.2.1").To4(), avps.GetFirstByName(dictionary, "NAS-IP-Address").TypedValue(dictionary))
+	assert.Equal(t, int64(1700000000), avps.GetFirstByName(dictionary, "Event-Timestamp").TypedValue(dictionary).(time.Time).Unix())
+	framedPrefix := avps.GetFirstByName(dictionary, "Framed-IPv6-Prefix").TypedValue(dictionary).(net.IPNet)
+	assert.Equal(t, "2001:db8::/32", framedPrefix.String())
+	assert.Equal(t, []byte{1, 2}, avps.GetFirstByName(dictionary, "State").TypedValue(dictionary))
+	assert.Equal(t, []byte{1}, avps.GetFirstByName(dictionary, "NAS-Port").TypedValue(dictionary))
+	assert.Equal(t, []byte("unknown"), avps.GetFirst(200, 0).TypedValue(dictionary))
+	assert.Nil(t, avps.GetFirstByName(dictionary, "Reply-Message"))
+	assert.Nil(t, avps.GetByName(nil, "State"))
+}
+
+func Test_radius_vendor_dictionaries(t *testing.T) {
+	defaults := dictionary.Default()
+	avps := radius.NewAvps().
+		AddString(1, radius.Vendor3GPP, "901280064290558").
+		AddUint32(3, radius.Vendor3GPP, 3).
+		AddString(1, 9, "ip:inacl#1=permit ip any any").
+		AddString(8, 14988, "10M/10M").
+		AddUint32(7, 14122, 1024).
+		AddString(1, 0, "nemo")
+	assert.Equal(t, "901280064290558", avps.GetFirstByName(defaults, "3GPP-IMSI").TypedValue(defaults))
+	assert.Equal(t, "IPv4v6", avps.GetFirstByName(defaults, "3GPP-PDP-Type").TypedValue(defaults))
+	assert.Equal(t, "ip:inacl#1=permit ip any any", avps.GetFirstByName(defaults, "Cisco-AVPair").TypedValue(defaults))
+	assert.Equal(t, "10M/10M", avps.GetFirstByName(defaults, "Mikrotik-Rate-Limit").TypedValue(defaults))
+	assert.Equal(t, uint32(1024), avps.GetFirstByName(defaults, "WISPr-Bandwidth-Max-Up").TypedValue(defaults))
+	assert.Equal(t, "nemo", avps.GetFirstByName(defaults, "User-Name").TypedValue(defaults))
+
+	dictionary.Register(dictionary.Vendor{Id: 65535, Name: "Example", Attributes: []radius.AttributeDefinition{
+		{Name: "Example-Plan", Type: 1, DataType: radius.Text},
+	}})
+	defer dictionary.Unregister(65535)
+	defer enterprise.Unregister(65535)
+	assert.Equal(t, radius.VendorId(65535), dictionary.Lookup(65535).Attributes[0].VendorId)
+	assert.Equal(t, "Example", *enterprise.Name(65535))
+	extended := dictionary.Default()
+	assert.Equal(t, "gold", radius.NewAvps().AddString(1, 65535, "gold").GetFirstByName(extended, "Example-Plan").TypedValue(extended))
+	assert.Nil(t, defaults.AttributeByName("Example-Plan"))
+	assert.Nil(t, dictionary.Standard().AttributeByName("3GPP-IMSI"))
+}
+
+func Test_radius_json(t *testing.T) {
+	defaults := dictionary.Default()
+	message := radius.NewMessage(radius.CodeAccessRequest, 7, [16]byte{1, 2, 3},
+		radius.NewAvpString(rfc2865.UserName, 0, "nemo"),
+		radius.NewAvpUint32(rfc2865.ServiceType, 0, 2),
+		radius.NewAvpIPv4(rfc2865.FramedIPAddress, 0, net.IPv4(192, 0, 2, 1)),
+		radius.NewAvp(rfc2865.UserPassword, 0, []byte{0xde, 0xad}),
+		radius.NewAvp(200, 10415, []byte{0xbe, 0xef}),
+	)
+
+	bytes, err := radius.MarshalMessageJSON(message, defaults)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, string(bytes), `"code":1,"name":"ACCESS_REQUEST","identifier":7`)
+	assert.Contains(t, string(bytes), `{"type":1,"name":"User-Name","dataType":"text","value":"nemo"}`)
+	assert.Contains(t, string(bytes), `{"type":6,"name":"Service-Type","dataType":"enum","value":2}`)
+	assert.Contains(t, string(bytes), `"value":"192.0.2.1"`)
+	assert.Contains(t, string(bytes), `{"type":2,"name":"User-Password","data":"3q0="}`)
+	assert.Contains(t, string(bytes), `{"type":200,"vendorId":10415,"vendor":"3GPP","data":"vu8="}`)
+	decoded, err := radius.UnmarshalMessageJSON(bytes, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, message.ToBytes(), decoded.ToBytes())
+
+	fixture := `{"code":1,"identifier":1,"avps":[{"name":"Service-Type","value":"Framed-User"}]}`
+	decoded, err = radius.UnmarshalMessageJSON([]byte(fixture), defaults)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, uint32(2), decoded.Avps.GetFirst(rfc2865.ServiceType, 0).ToUint32OrDefault())
+
+	bytes, err = json.Marshal(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var replayed radius.Message
+	if err := json.Unmarshal(bytes, &replayed); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, message.ToBytes(), replayed.ToBytes())
+}
+
+func Test_radius_map(t *testing.T) {
+	defaults := dictionary.Default()
+	message := radius.NewMessage(radius.CodeAccessRequest, 7, [16]byte{1, 2, 3},
+		radius.NewAvpString(rfc2865.UserName, 0, "nemo"),
+		radius.NewAvpUint32(rfc2865.ServiceType, 0, 2),
+		radius.NewAvp(200, 10415, []byte{0xbe, 0xef}),
+	)
+
+	values := message.ToMap(defaults)
+	assert.Equal(t, "ACCESS_REQUEST", values["name"])
+	entries := values["avps"].([]any)
+	assert.Equal(t, "User-Name", entries[0].(map[string]any)["name"])
+	assert.Equal(t, json.Number("2"), entries[1].(map[string]any)["value"])
+	decoded, err := radius.FromMap(values, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, message.ToBytes(), decoded.ToBytes())
+
+	decoded, err = radius.FromMap(map[string]any{
+		"code":       radius.CodeAccessRequest,
+		"identifier": 1,
+		"avps": []any{
+			map[string]any{"name": "Service-Type", "value": "Framed-User"},
+			map[string]any{"name": "Framed-IP-Address", "value": net.IPv4(192, 0, 2, 1)},
+		},
+	}, defaults)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, uint32(2), decoded.Avps.GetFirst(rfc2865.ServiceType, 0).ToUint32OrDefault())
+	assert.Equal(t, "192.0.2.1", decoded.Avps.GetFirst(rfc2865.FramedIPAddress, 0).ToNetIP().String())
+}
@@ -0,0 +1,60 @@
+package tests
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tinybluerobots/radius-diameter-message/diameter"
+	"github.com/tinybluerobots/radius-diameter-message/diameter/rx"
+)
+
+func Test_rx_aar(t *testing.T) {
+	mediaType := rx.Audio
+	flowStatus := rx.Enabled
+	flowUsage := rx.RTCP
+	aar := rx.AAR{
+		SessionId:        "pcscf.example.com;1",
+		OriginHost:       "pcscf.example.com",
+		OriginRealm:      "example.com",
+		DestinationRealm: "example.com",
+		MediaComponentDescriptions: []rx.MediaComponentDescription{{
+			Number:    1,
+			MediaType: &mediaType,
+			SubComponents: []rx.MediaSubComponent{
+				{FlowNumber: 1, FlowDescriptions: []string{"permit out 17 from 10.0.0.1 49152 to 10.0.0.2 50000", "permit in 17 from 10.0.0.2 50000 to 10.0.0.1 49152"}},
+				{FlowNumber: 2, FlowUsage: &flowUsage},
+			},
+			MaxRequestedBandwidthUL: 64000,
+			MaxRequestedBandwidthDL: 64000,
+			FlowStatus:              &flowStatus,
+			CodecData:               [][]byte{[]byte("uplink\noffer\nm=audio")},
+		}},
+		SpecificActions: []rx.SpecificAction{rx.IndicationOfLossOfBearer, rx.IndicationOfReleaseOfBearer},
+		FramedIPAddress: net.IPv4(10, 0, 0, 1).To4(),
+		Avps:            diameter.NewAvps().AddUint32(1, mandatoryFlags, 12345, 1),
+	}
+	request := aar.ToMessage([4]byte{0, 0, 0, 1}, [4]byte{0, 0, 0, 2})
+	assert.Equal(t, diameter.ApplicationRx, request.ApplicationId)
+	decoded, err := diameter.ReadMessage(request.ToBytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	aar2 := rx.AAR{}
+	assert.NoError(t, aar2.FromMessage(*decoded))
+	assert.Equal(t, aar, aar2)
+	assert.Equal(t, "INDICATION_OF_LOSS_OF_BEARER", aar2.SpecificActions[0].String())
+	assert.Equal(t, "ENABLED", aar2.MediaComponentDescriptions[0].FlowStatus.String())
+
+	malformed := diameter.NewMessage(1, requestFlags, diameter.CommandAA, diameter.ApplicationRx, [4]byte{}, [4]byte{},
+		diameter.NewAvp(rx.AvpFramedIPAddress, mandatoryFlags, 0, []byte{10, 0, 0}))
+	assert.NoError(t, aar2.FromMessage(malformed))
+	assert.Nil(t, aar2.FramedIPAddress)
+	assert.Equal(t, malformed.Avps, aar2.Avps)
+
+	aaa := rx.AAA{ResultCode: diameter.ResultCodeSuccess, OriginHost: "pcrf.example.com", OriginRealm: "example.com"}
+	aaa2 := rx.AAA{}
+	assert.NoError(t, aaa2.FromMessage(aaa.ToMessage(request)))
+	assert.Equal(t, aaa, aaa2)
+	assert.Error(t, aaa2.FromMessage(request))
+}
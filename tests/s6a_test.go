@@ -0,0 +1,83 @@
+package tests
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tinybluerobots/radius-diameter-message/diameter"
+	"github.com/tinybluerobots/radius-diameter-message/diameter/gx"
+	"github.com/tinybluerobots/radius-diameter-message/diameter/s6a"
+)
+
+func Test_s6a_update_location(t *testing.T) {
+	header := s6a.Header{
+		SessionId:        "mme.example.com;1",
+		OriginHost:       "mme.example.com",
+		OriginRealm:      "epc.mnc015.mcc234.3gppnetwork.org",
+		DestinationRealm: "epc.mnc015.mcc234.3gppnetwork.org",
+		UserName:         "234150999999999",
+	}
+	ulr := s6a.ULR{
+		Header:        header,
+		RATType:       s6a.RATTypeEUTRAN,
+		ULRFlags:      s6a.S6aS6dIndicator | s6a.InitialAttachIndicator,
+		VisitedPLMNId: []byte{0x32, 0xf4, 0x51},
+	}
+	request := ulr.ToMessage([4]byte{0, 0, 0, 1}, [4]byte{0, 0, 0, 2})
+	decodedUlr := s6a.ULR{}
+	assert.NoError(t, decodedUlr.FromMessage(request))
+	assert.Equal(t, ulr, decodedUlr)
+
+	ula := s6a.ULA{
+		Result: s6a.Result{ResultCode: diameter.ResultCodeSuccess, OriginHost: "hss.example.com", OriginRealm: header.OriginRealm},
+		SubscriptionData: &s6a.SubscriptionData{
+			MSISDN: []byte{0x44, 0x77, 0x00, 0x09, 0x21, 0xf3},
+			AMBR:   &s6a.AMBR{MaxRequestedBandwidthUL: 50000000, MaxRequestedBandwidthDL: 100000000},
+			APNConfigurationProfile: &s6a.APNConfigurationProfile{
+				ContextIdentifier:            1,
+				AllAPNConfigurationsIncluded: true,
+				APNConfigurations: []s6a.APNConfiguration{{
+					ContextIdentifier:      1,
+					PDNType:                s6a.IPv4v6,
+					ServiceSelection:       "internet",
+					ServedPartyIPAddresses: []net.IP{net.IPv4(10, 0, 0, 1).To4()},
+					EPSSubscribedQoSProfile: &s6a.EPSSubscribedQoSProfile{
+						QoSClassIdentifier:          9,
+						AllocationRetentionPriority: gx.AllocationRetentionPriority{PriorityLevel: 8, PreEmptionCapability: gx.PreEmptionCapabilityDisabled},
+					},
+				}},
+			},
+		},
+	}
+	decoded, err := diameter.ReadMessage(ula.ToMessage(request).ToBytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	decodedUla := s6a.ULA{}
+	assert.NoError(t, decodedUla.FromMessage(*decoded))
+	assert.Equal(t, ula, decodedUla)
+	assert.Equal(t, "internet", decodedUla.SubscriptionData.APNConfigurationProfile.Default().ServiceSelection)
+	assert.Nil(t, decodedUla.SubscriptionData.APNConfigurationProfile.APN(2))
+}
+
+func Test_s6a_authentication_information(t *testing.T) {
+	air := s6a.AIR{
+		Header:                   s6a.Header{SessionId: "mme.example.com;2", UserName: "234150999999999"},
+		NumberOfRequestedVectors: 1,
+		VisitedPLMNId:            []byte{0x32, 0xf4, 0x51},
+	}
+	request := air.ToMessage([4]byte{0, 0, 0, 1}, [4]byte{0, 0, 0, 2})
+	decodedAir := s6a.AIR{}
+	assert.NoError(t, decodedAir.FromMessage(request))
+	assert.Equal(t, air, decodedAir)
+
+	aia := s6a.AIA{
+		Result:        s6a.Result{ExperimentalResult: &s6a.ExperimentalResult{VendorId: diameter.Vendor3GPP, Code: s6a.ErrorUserUnknown}},
+		EUTRANVectors: []s6a.EUTRANVector{{RAND: []byte{1}, XRES: []byte{2}, AUTN: []byte{3}, KASME: []byte{4}}},
+	}
+	decodedAia := s6a.AIA{}
+	assert.NoError(t, decodedAia.FromMessage(aia.ToMessage(request)))
+	assert.Equal(t, aia, decodedAia)
+	assert.Error(t, decodedAia.FromMessage(request))
+}
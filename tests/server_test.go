@@ -0,0 +1,121 @@
+package tests
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tinybluerobots/radius-diameter-message/diameter"
+	"github.com/tinybluerobots/radius-diameter-message/diameter/client"
+	"github.com/tinybluerobots/radius-diameter-message/diameter/disconnect"
+	"github.com/tinybluerobots/radius-diameter-message/diameter/server"
+)
+
+func startServer(t *testing.T, s *server.Server) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go s.Serve(listener)
+	t.Cleanup(func() { s.Close() })
+	return listener.Addr().String()
+}
+
+func Test_server_dispatch(t *testing.T) {
+	s := server.New(server.Config{Capabilities: serverCapabilities})
+	s.Handle(diameter.ApplicationCreditControl, diameter.CommandCreditControl, func(ctx context.Context, writer server.AnswerWriter, request diameter.Message) {
+		peer, ok := server.PeerFromContext(ctx)
+		assert.True(t, ok)
+		assert.Equal(t, "client.example.com", peer.OriginHost)
+		writer.WriteAnswer(request.NewAnswer(diameter.NewAvpUint32(diameter.AvpResultCode, mandatoryFlags, 0, uint32(diameter.ResultCodeSuccess))))
+	})
+	address := startServer(t, s)
+
+	c, err := client.Dial(context.Background(), address, client.Config{Capabilities: clientCapabilities})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "server.example.com", c.Peer().OriginHost)
+
+	answer, err := c.SendRequest(context.Background(), diameter.NewMessage(1, 0, diameter.CommandCreditControl, diameter.ApplicationCreditControl, [4]byte{}, [4]byte{}))
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(diameter.ResultCodeSuccess), answer.Avps.GetFirst(diameter.AvpResultCode, 0).ToUint32OrDefault())
+
+	answer, err = c.SendRequest(context.Background(), diameter.NewMessage(1, 0, diameter.CommandReAuth, diameter.ApplicationCreditControl, [4]byte{}, [4]byte{}))
+	assert.NoError(t, err)
+	assert.True(t, answer.IsError())
+	assert.Equal(t, uint32(diameter.ResultCodeCommandUnsupported), answer.Avps.GetFirst(diameter.AvpResultCode, 0).ToUint32OrDefault())
+
+	assert.NoError(t, c.Disconnect(context.Background(), disconnect.CauseRebooting))
+}
+
+func Test_server_no_common_application(t *testing.T) {
+	local := serverCapabilities
+	local.AuthApplicationIds = []diameter.ApplicationId{diameter.ApplicationS6a}
+	address := startServer(t, server.New(server.Config{Capabilities: local}))
+	_, err := client.Dial(context.Background(), address, client.Config{Capabilities: clientCapabilities})
+	assert.Error(t, err)
+}
+
+func Test_server_close(t *testing.T) {
+	s := server.New(server.Config{Capabilities: serverCapabilities})
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	served := make(chan error, 1)
+	go func() { served <- s.Serve(listener) }()
+	c, err := client.Dial(context.Background(), listener.Addr().String(), client.Config{Capabilities: clientCapabilities})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, s.Close())
+	assert.ErrorIs(t, <-served, server.ErrServerClosed)
+	<-c.Done()
+	assert.Error(t, c.Err())
+}
+
+func Test_server_shutdown(t *testing.T) {
+	s := server.New(server.Config{Capabilities: serverCapabilities})
+	started, release := make(chan struct{}), make(chan struct{})
+	s.Handle(diameter.ApplicationCreditControl, diameter.CommandCreditControl, func(ctx context.Context, writer server.AnswerWriter, request diameter.Message) {
+		close(started)
+		<-release
+		writer.WriteAnswer(request.NewAnswer(diameter.NewAvpUint32(diameter.AvpResultCode, mandatoryFlags, 0, uint32(diameter.ResultCodeSuccess))))
+	})
+	s.Handle(diameter.ApplicationCreditControl, diameter.CommandReAuth, func(ctx context.Context, writer server.AnswerWriter, request diameter.Message) {
+		writer.WriteAnswer(request.NewAnswer(diameter.NewAvpUint32(diameter.AvpResultCode, mandatoryFlags, 0, uint32(diameter.ResultCodeSuccess))))
+	})
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	served := make(chan error, 1)
+	go func() { served <- s.Serve(listener) }()
+	c, err := client.Dial(context.Background(), listener.Addr().String(), client.Config{Capabilities: clientCapabilities})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	answers := make(chan diameter.Message, 1)
+	go func() {
+		answer, err := c.SendRequest(context.Background(), diameter.NewMessage(1, 0, diameter.CommandCreditControl, diameter.ApplicationCreditControl, [4]byte{}, [4]byte{}))
+		assert.NoError(t, err)
+		answers <- answer
+	}()
+	<-started
+	shutdown := make(chan error, 1)
+	go func() { shutdown <- s.Shutdown(context.Background()) }()
+	assert.Eventually(t, func() bool {
+		answer, err := c.SendRequest(context.Background(), diameter.NewMessage(1, 0, diameter.CommandReAuth, diameter.ApplicationCreditControl, [4]byte{}, [4]byte{}))
+		return err == nil && answer.Avps.GetFirst(diameter.AvpResultCode, 0).ToUint32OrDefault() == uint32(diameter.ResultCodeTooBusy)
+	}, time.Second, time.Millisecond)
+
+	close(release)
+	assert.Equal(t, uint32(diameter.ResultCodeSuccess), (<-answers).Avps.GetFirst(diameter.AvpResultCode, 0).ToUint32OrDefault())
+	assert.NoError(t, <-shutdown)
+	assert.ErrorIs(t, <-served, server.ErrServerClosed)
+	<-c.Done()
+}
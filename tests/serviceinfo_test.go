@@ -0,0 +1,59 @@
+package tests
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tinybluerobots/radius-diameter-message/diameter"
+	"github.com/tinybluerobots/radius-diameter-message/diameter/creditcontrol"
+	"github.com/tinybluerobots/radius-diameter-message/diameter/serviceinfo"
+)
+
+func Test_serviceinfo(t *testing.T) {
+	role := serviceinfo.OriginatingRole
+	messageType := serviceinfo.Submission
+	dataCodingScheme := int32(0)
+	serviceInformation := serviceinfo.ServiceInformation{
+		PSInformation: &serviceinfo.PSInformation{
+			ChargingId:       12345,
+			PDPAddresses:     []net.IP{net.IPv4(10, 0, 0, 1).To4()},
+			GGSNAddresses:    []net.IP{net.IPv4(192, 168, 0, 1).To4()},
+			GGSNMCCMNC:       "23415",
+			CalledStationId:  "internet",
+			RATType:          []byte{6},
+			UserLocationInfo: []byte{0x82, 0x32, 0xf4, 0x51},
+		},
+		IMSInformation: &serviceinfo.IMSInformation{
+			RoleOfNode:            &role,
+			NodeFunctionality:     serviceinfo.SCSCF,
+			CallingPartyAddresses: []string{"sip:alice@example.com"},
+			CalledPartyAddress:    "tel:+447700900123",
+		},
+		SMSInformation: &serviceinfo.SMSInformation{
+			DataCodingScheme: &dataCodingScheme,
+			SMMessageType:    &messageType,
+			SMSCAddress:      net.IPv4(10, 1, 1, 1).To4(),
+			SMDischargeTime:  time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		},
+	}
+	ccr := creditcontrol.CCR{SessionId: "s", Avps: diameter.NewAvps().AddAvps(serviceInformation.ToAvp())}
+	decoded, err := diameter.ReadMessage(ccr.ToMessage([4]byte{}, [4]byte{}).ToBytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ccr2 := creditcontrol.CCR{}
+	assert.NoError(t, ccr2.FromMessage(*decoded))
+	serviceInformation2, ok := serviceinfo.Read(ccr2.Avps)
+	assert.True(t, ok)
+	assert.Equal(t, serviceInformation.PSInformation, serviceInformation2.PSInformation)
+	assert.Equal(t, serviceInformation.IMSInformation, serviceInformation2.IMSInformation)
+	assert.True(t, serviceInformation.SMSInformation.SMDischargeTime.Equal(serviceInformation2.SMSInformation.SMDischargeTime))
+	assert.Equal(t, serviceInformation.SMSInformation.SMSCAddress, serviceInformation2.SMSInformation.SMSCAddress)
+	assert.Equal(t, serviceinfo.Submission, *serviceInformation2.SMSInformation.SMMessageType)
+	assert.Nil(t, serviceInformation2.SMSInformation.OriginatorSCCPAddress)
+
+	_, ok = serviceinfo.Read(diameter.NewAvps())
+	assert.False(t, ok)
+}
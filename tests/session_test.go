@@ -0,0 +1,91 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tinybluerobots/radius-diameter-message/diameter"
+	"github.com/tinybluerobots/radius-diameter-message/diameter/session"
+)
+
+func Test_session_reauth(t *testing.T) {
+	rar := session.RAR{
+		SessionId:         "pgw.example.com;1;2",
+		OriginHost:        "pcrf.example.com",
+		OriginRealm:       "example.com",
+		DestinationRealm:  "example.com",
+		DestinationHost:   "pgw.example.com",
+		AuthApplicationId: diameter.ApplicationGx,
+		ReAuthRequestType: session.AuthorizeOnly,
+		Avps:              diameter.NewAvps().AddUint32(1006, mandatoryFlags, 10415, 1),
+	}
+	request := rar.ToMessage([4]byte{0, 0, 0, 1}, [4]byte{0, 0, 0, 2})
+	assert.Equal(t, diameter.ApplicationGx, request.ApplicationId)
+	decoded := session.RAR{}
+	assert.NoError(t, decoded.FromMessage(request))
+	assert.Equal(t, rar, decoded)
+	assert.Equal(t, "AUTHORIZE_ONLY", decoded.ReAuthRequestType.String())
+
+	sessions := map[string]bool{"pgw.example.com;1;2": true}
+	reauthorize := func(rar session.RAR) (diameter.ResultCode, bool) {
+		return diameter.ResultCodeSuccess, sessions[rar.SessionId]
+	}
+	answer, err := session.AnswerReAuth(request, "pgw.example.com", "example.com", reauthorize)
+	assert.NoError(t, err)
+	raa := session.RAA{}
+	assert.NoError(t, raa.FromMessage(answer))
+	assert.Equal(t, diameter.ResultCodeSuccess, raa.ResultCode)
+	assert.Equal(t, "pgw.example.com;1;2", answer.Avps.GetFirst(diameter.AvpSessionId, 0).ToStringOrDefault())
+
+	delete(sessions, "pgw.example.com;1;2")
+	answer, err = session.AnswerReAuth(request, "pgw.example.com", "example.com", reauthorize)
+	assert.NoError(t, err)
+	assert.NoError(t, raa.FromMessage(answer))
+	assert.Equal(t, diameter.ResultCodeUnknownSessionId, raa.ResultCode)
+
+	_, err = session.AnswerReAuth(answer, "pgw.example.com", "example.com", reauthorize)
+	assert.Error(t, err)
+
+	raa = session.RAA{ResultCode: diameter.ResultCodeSuccess, OriginHost: "nas.example.com", OriginRealm: "example.com", ErrorMessage: "reauthorized"}
+	decodedRaa := session.RAA{}
+	assert.NoError(t, decodedRaa.FromMessage(raa.ToMessage(request)))
+	assert.Equal(t, raa.ErrorMessage, decodedRaa.ErrorMessage)
+	assert.Equal(t, raa.OriginHost, decodedRaa.OriginHost)
+}
+
+func Test_session_abort_and_termination(t *testing.T) {
+	request := diameter.NewMessage(1, diameter.FlagRequest|diameter.FlagProxiable, diameter.CommandAA, diameter.ApplicationNASREQ, [4]byte{0, 0, 0, 1}, [4]byte{0, 0, 0, 2},
+		diameter.NewAvpString(diameter.AvpSessionId, mandatoryFlags, 0, "nas.example.com;1"),
+		diameter.NewAvpString(diameter.AvpOriginHost, mandatoryFlags, 0, "nas.example.com"),
+		diameter.NewAvpString(diameter.AvpOriginRealm, mandatoryFlags, 0, "example.com"),
+		diameter.NewAvpString(diameter.AvpDestinationRealm, mandatoryFlags, 0, "example.net"),
+		diameter.NewAvpString(diameter.AvpUserName, mandatoryFlags, 0, "alice"),
+	)
+	answer := request.NewAnswer(
+		diameter.NewAvpString(diameter.AvpOriginHost, mandatoryFlags, 0, "aaa.example.net"),
+		diameter.NewAvpString(diameter.AvpOriginRealm, mandatoryFlags, 0, "example.net"),
+		diameter.NewAvp(diameter.AvpClass, mandatoryFlags, 0, []byte{1, 2}),
+	)
+
+	str := session.NewSTR(request, &answer, session.Logout)
+	assert.Equal(t, "aaa.example.net", str.DestinationHost)
+	assert.Equal(t, [][]byte{{1, 2}}, str.Class)
+	decodedStr := session.STR{}
+	assert.NoError(t, decodedStr.FromMessage(str.ToMessage([4]byte{0, 0, 0, 3}, [4]byte{0, 0, 0, 4})))
+	assert.Equal(t, str, decodedStr)
+	assert.Equal(t, "DIAMETER_LOGOUT", decodedStr.TerminationCause.String())
+
+	asr := session.NewASR(request, "aaa.example.net", "example.net")
+	assert.Equal(t, "nas.example.com", asr.DestinationHost)
+	assert.Equal(t, "example.com", asr.DestinationRealm)
+	asrMessage := asr.ToMessage([4]byte{0, 0, 0, 5}, [4]byte{0, 0, 0, 6})
+	decodedAsr := session.ASR{}
+	assert.NoError(t, decodedAsr.FromMessage(asrMessage))
+	assert.Equal(t, asr, decodedAsr)
+
+	asa := session.ASA{ResultCode: diameter.ResultCodeSuccess, OriginHost: "nas.example.com", OriginRealm: "example.com"}
+	decodedAsa := session.ASA{}
+	assert.NoError(t, decodedAsa.FromMessage(asa.ToMessage(asrMessage)))
+	assert.Equal(t, asa, decodedAsa)
+	assert.Error(t, (&session.STA{}).FromMessage(asa.ToMessage(asrMessage)))
+}
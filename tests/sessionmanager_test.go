@@ -0,0 +1,89 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tinybluerobots/radius-diameter-message/diameter"
+	"github.com/tinybluerobots/radius-diameter-message/diameter/creditcontrol"
+	"github.com/tinybluerobots/radius-diameter-message/diameter/session"
+)
+
+func managedRequest(sessionId string) diameter.Message {
+	return diameter.NewMessage(1, requestFlags, diameter.CommandCreditControl, diameter.ApplicationCreditControl, [4]byte{}, [4]byte{},
+		diameter.NewAvpString(diameter.AvpSessionId, mandatoryFlags, 0, sessionId),
+		diameter.NewAvpString(diameter.AvpOriginHost, mandatoryFlags, 0, "pgw.example.com"),
+		diameter.NewAvpString(diameter.AvpOriginRealm, mandatoryFlags, 0, "example.com"),
+		diameter.NewAvpString(diameter.AvpDestinationRealm, mandatoryFlags, 0, "example.com"))
+}
+
+func Test_session_lifetime(t *testing.T) {
+	request := managedRequest("a")
+	answer := request.NewAnswer(
+		diameter.NewAvpUint32(diameter.AvpAuthorizationLifetime, mandatoryFlags, 0, 600),
+		diameter.NewAvpUint32(diameter.AvpAuthGracePeriod, mandatoryFlags, 0, 30),
+	)
+	lifetime, ok := session.Lifetime(answer)
+	assert.True(t, ok)
+	assert.Equal(t, 630*time.Second, lifetime)
+
+	mscc := creditcontrol.MSCC{ValidityTime: 60}
+	answer.Avps = append(answer.Avps, mscc.ToAvp())
+	lifetime, _ = session.Lifetime(answer)
+	assert.Equal(t, 60*time.Second, lifetime)
+
+	_, ok = session.Lifetime(request.NewAnswer(diameter.NewAvpUint32(diameter.AvpAuthorizationLifetime, mandatoryFlags, 0, 0xFFFFFFFF)))
+	assert.False(t, ok)
+}
+
+func Test_session_manager_expiry(t *testing.T) {
+	var strs []session.STR
+	manager := session.NewManager(session.ManagerConfig{
+		IdleTimeout: time.Minute,
+		OnExpire:    session.SendSTR(func(str session.STR) { strs = append(strs, str) }),
+	})
+	idle := managedRequest("idle")
+	manager.Start(idle, nil, "state")
+	limited := managedRequest("limited")
+	answer := limited.NewAnswer(diameter.NewAvpUint32(diameter.AvpAuthorizationLifetime, mandatoryFlags, 0, 10))
+	started := manager.Start(limited, &answer, nil)
+	assert.False(t, started.Expires.IsZero())
+	assert.True(t, manager.SetState("limited", 42))
+	assert.Equal(t, 2, manager.Len())
+
+	assert.Empty(t, manager.Expire(time.Now()))
+	expired := manager.Expire(time.Now().Add(20 * time.Second))
+	assert.Len(t, expired, 1)
+	assert.Equal(t, "limited", expired[0].Id)
+	assert.Equal(t, 42, expired[0].State)
+
+	expired = manager.Expire(time.Now().Add(2 * time.Minute))
+	assert.Len(t, expired, 1)
+	assert.Equal(t, 0, manager.Len())
+
+	assert.Len(t, strs, 2)
+	causes := []session.TerminationCause{strs[0].TerminationCause, strs[1].TerminationCause}
+	assert.ElementsMatch(t, []session.TerminationCause{session.AuthExpired, session.SessionTimeout}, causes)
+	assert.Equal(t, "pgw.example.com", strs[0].OriginHost)
+}
+
+func Test_session_manager_update(t *testing.T) {
+	var asrs []session.ASR
+	manager := session.NewManager(session.ManagerConfig{
+		OnExpire: session.SendASR("ocs.example.com", "example.com", func(asr session.ASR) { asrs = append(asrs, asr) }),
+	})
+	request := managedRequest("a")
+	answer := request.NewAnswer(diameter.NewAvpUint32(diameter.AvpAuthorizationLifetime, mandatoryFlags, 0, 10))
+	manager.Start(request, &answer, nil)
+	assert.True(t, manager.Update("a", request.NewAnswer(diameter.NewAvpUint32(diameter.AvpAuthorizationLifetime, mandatoryFlags, 0, 100))))
+	assert.Empty(t, manager.Expire(time.Now().Add(20*time.Second)))
+	assert.True(t, manager.Touch("a"))
+	assert.Len(t, manager.Expire(time.Now().Add(200*time.Second)), 1)
+	assert.Len(t, asrs, 1)
+	assert.Equal(t, "pgw.example.com", asrs[0].DestinationHost)
+	assert.False(t, manager.Touch("a"))
+	_, ok := manager.Remove("a")
+	assert.False(t, ok)
+	assert.Equal(t, "LIFETIME", session.ExpiredLifetime.String())
+}
@@ -0,0 +1,158 @@
+package tests
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tinybluerobots/radius-diameter-message/diameter"
+	"github.com/tinybluerobots/radius-diameter-message/diameter/transaction"
+)
+
+func transactionRequest(hopByHopId byte) diameter.Message {
+	return diameter.NewMessage(1, requestFlags, diameter.CommandCreditControl, diameter.ApplicationCreditControl, [4]byte{0, 0, 0, hopByHopId}, [4]byte{0, 0, 0, hopByHopId})
+}
+
+func Test_transaction_delivered(t *testing.T) {
+	table := transaction.New(transaction.Config{})
+	request := transactionRequest(1)
+	pending, err := table.Add(request)
+	assert.NoError(t, err)
+	_, err = table.Add(request)
+	assert.ErrorIs(t, err, transaction.ErrDuplicate)
+	assert.Equal(t, 1, table.Stats().Outstanding)
+	assert.Len(t, table.Pending(), 1)
+
+	assert.Equal(t, transaction.Delivered, table.Deliver(request.NewAnswer()))
+	answer, err := pending.Wait(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, request.HopByHopId, answer.HopByHopId)
+
+	stats := table.Stats()
+	assert.Equal(t, 0, stats.Outstanding)
+	assert.Equal(t, uint64(1), stats.Answered)
+}
+
+func Test_transaction_late_and_unsolicited(t *testing.T) {
+	table := transaction.New(transaction.Config{Timeout: 10 * time.Millisecond})
+	request := transactionRequest(2)
+	pending, err := table.Add(request)
+	assert.NoError(t, err)
+	_, err = pending.Wait(context.Background())
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	assert.Equal(t, transaction.Late, table.Deliver(request.NewAnswer()))
+	assert.Equal(t, transaction.Unsolicited, table.Deliver(transactionRequest(3).NewAnswer()))
+	assert.Equal(t, "LATE", transaction.Late.String())
+
+	stats := table.Stats()
+	assert.Equal(t, uint64(1), stats.Expired)
+	assert.Equal(t, uint64(1), stats.Late)
+	assert.Equal(t, uint64(1), stats.Unsolicited)
+}
+
+func Test_transaction_late_window(t *testing.T) {
+	table := transaction.New(transaction.Config{LateWindow: 20 * time.Millisecond})
+	for id := range byte(3) {
+		pending, err := table.Add(transactionRequest(id))
+		assert.NoError(t, err)
+		pending.Cancel()
+	}
+	assert.Equal(t, transaction.Late, table.Deliver(transactionRequest(0).NewAnswer()))
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, transaction.Unsolicited, table.Deliver(transactionRequest(1).NewAnswer()))
+	assert.Equal(t, transaction.Unsolicited, table.Deliver(transactionRequest(2).NewAnswer()))
+}
+
+func Test_transaction_close(t *testing.T) {
+	table := transaction.New(transaction.Config{})
+	pending, err := table.Add(transactionRequest(4))
+	assert.NoError(t, err)
+	failure := errors.New("connection lost")
+	table.Close(failure)
+	_, err = pending.Wait(context.Background())
+	assert.ErrorIs(t, err, failure)
+	_, err = table.Add(transactionRequest(5))
+	assert.ErrorIs(t, err, failure)
+}
+
+func Test_transaction_callback(t *testing.T) {
+	table := transaction.New(transaction.Config{Timeout: 10 * time.Millisecond})
+	answers := make(chan error, 3)
+	callback := func(answer diameter.Message, err error) { answers <- err }
+
+	request := transactionRequest(6)
+	_, err := table.AddCallback(request, callback)
+	assert.NoError(t, err)
+	assert.Equal(t, transaction.Delivered, table.Deliver(request.NewAnswer()))
+	assert.NoError(t, <-answers)
+
+	_, err = table.AddCallback(transactionRequest(7), callback)
+	assert.NoError(t, err)
+	assert.ErrorIs(t, <-answers, context.DeadlineExceeded)
+
+	cancelled, err := table.AddCallback(transactionRequest(8), callback)
+	assert.NoError(t, err)
+	cancelled.Cancel()
+	_, err = table.AddCallback(transactionRequest(9), callback)
+	assert.NoError(t, err)
+	table.Close(nil)
+	assert.ErrorIs(t, <-answers, transaction.ErrClosed)
+	assert.Empty(t, answers)
+}
+
+func Test_transaction_store_survives_restart(t *testing.T) {
+	store := transaction.NewMemoryStore()
+	table := transaction.New(transaction.Config{Store: store})
+	answered, unanswered := transactionRequest(6), transactionRequest(7)
+	_, err := table.Add(answered)
+	assert.NoError(t, err)
+	_, err = table.Add(unanswered)
+	assert.NoError(t, err)
+	assert.Equal(t, transaction.Delivered, table.Deliver(answered.NewAnswer()))
+	records, _ := store.Load()
+	assert.Len(t, records, 1)
+	assert.Equal(t, unanswered.HopByHopId, records[0].Request.HopByHopId)
+
+	restarted := transaction.New(transaction.Config{Store: store})
+	assert.NoError(t, restarted.Restore())
+	assert.Equal(t, transaction.Late, restarted.Deliver(unanswered.NewAnswer()))
+	assert.Equal(t, transaction.Unsolicited, restarted.Deliver(answered.NewAnswer()))
+	records, _ = store.Load()
+	assert.Empty(t, records)
+}
+
+func Test_transaction_store_resumes_ids(t *testing.T) {
+	store := transaction.NewMemoryStore()
+	ids := diameter.NewIdGenerator()
+	table := transaction.New(transaction.Config{Store: store, Ids: ids})
+	var last diameter.Message
+	for range 3 {
+		last = transactionRequest(0)
+		last.HopByHopId, last.EndToEndId = ids.NextHopByHopId(), ids.NextEndToEndId()
+		_, err := table.Add(last)
+		assert.NoError(t, err)
+	}
+
+	resumed := diameter.NewIdGenerator()
+	restarted := transaction.New(transaction.Config{Store: store, Ids: resumed})
+	assert.NoError(t, restarted.Restore())
+	assert.Equal(t, ids.State(), resumed.State())
+	next := resumed.NextHopByHopId()
+	assert.Equal(t, binary.BigEndian.Uint32(last.HopByHopId[:])+1, binary.BigEndian.Uint32(next[:]))
+	assert.Equal(t, transaction.Late, restarted.Deliver(last.NewAnswer()))
+}
+
+type failingStore struct{ transaction.MemoryStore }
+
+func (*failingStore) Save(transaction.Record) error { return errors.New("store unavailable") }
+
+func Test_transaction_store_failure(t *testing.T) {
+	table := transaction.New(transaction.Config{Store: &failingStore{}})
+	_, err := table.Add(transactionRequest(8))
+	assert.EqualError(t, err, "store unavailable")
+	assert.Equal(t, 0, table.Stats().Outstanding)
+}
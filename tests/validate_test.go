@@ -0,0 +1,70 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tinybluerobots/radius-diameter-message/diameter"
+	"github.com/tinybluerobots/radius-diameter-message/radius"
+	"github.com/tinybluerobots/radius-diameter-message/radius/rfc2865"
+)
+
+func Test_diameter_validate_bytes(t *testing.T) {
+	dictionary := diameter.NewDictionary().AddAvp(
+		diameter.AvpDefinition{Name: "Session-Id", Code: 263, Type: diameter.UTF8String},
+		diameter.AvpDefinition{Name: "Result-Code", Code: 268, Type: diameter.Unsigned32},
+	)
+	message := diameter.NewMessage(1, requestFlags|0x21, 272, 4, [4]byte{}, [4]byte{},
+		diameter.NewAvp(263, mandatoryFlags, 0, []byte("session")),
+		diameter.NewAvp(268, mandatoryFlags|0x01, 0, []byte{0, 1}),
+		diameter.NewAvpUint32(999, mandatoryFlags, 0, 1),
+	)
+	data := message.ToBytes()
+	data[35] = 0xff
+	assert.Equal(t, []diameter.Problem{
+		{Offset: 4, Reason: "reserved header bits set"},
+		{Offset: 4, Reason: "E bit set in request"},
+		{Offset: 35, Reason: "non-zero padding after AVP 263"},
+		{Offset: 40, Reason: "reserved bits set in AVP 268 flags"},
+		{Offset: 44, Reason: "Unsigned32 AVP 268 has 2 bytes"},
+		{Offset: 56, Reason: "unknown mandatory AVP 999 (vendor 0)"},
+	}, diameter.ValidateBytes(data, dictionary))
+	assert.Len(t, diameter.ValidateBytes(data, nil), 4)
+
+	valid := diameter.NewMessage(1, requestFlags, 272, 4, [4]byte{}, [4]byte{}, diameter.NewAvpString(263, mandatoryFlags, 0, "session"))
+	assert.Nil(t, diameter.ValidateBytes(valid.ToBytes(), dictionary))
+	problems := diameter.ValidateBytes(valid.ToBytes()[:34], dictionary)
+	assert.Equal(t, []diameter.Problem{
+		{Offset: 1, Reason: "message length 36 does not match 34 bytes"},
+		{Offset: 25, Reason: "invalid AVP length 15"},
+	}, problems)
+	assert.EqualError(t, problems[1], "invalid AVP length 15 at offset 25")
+}
+
+func Test_radius_validate_bytes(t *testing.T) {
+	dictionary := radius.NewDictionary().AddAttribute(rfc2865.Attributes...)
+	message := radius.NewMessage(1, 1, [16]byte{},
+		radius.NewAvp(rfc2865.UserName, 0, []byte{}),
+		radius.NewAvp(rfc2865.NASPort, 0, []byte{0, 1}),
+		radius.NewAvp(rfc2865.ReplyMessage, 0, []byte{0xff}),
+		radius.NewAvp(radius.AttributeMessageAuthenticator, 0, []byte{1}),
+	)
+	data := message.ToBytes()
+	data[0] = 99
+	assert.Equal(t, []radius.Problem{
+		{Offset: 0, Reason: "unknown code 99"},
+		{Offset: 20, Reason: "text attribute User-Name is empty"},
+		{Offset: 22, Reason: "integer attribute NAS-Port has 2 bytes"},
+		{Offset: 26, Reason: "text attribute Reply-Message is not valid UTF-8"},
+		{Offset: 29, Reason: "Message-Authenticator has 1 bytes"},
+	}, radius.ValidateBytes(data, dictionary))
+	assert.Len(t, radius.ValidateBytes(data, nil), 2)
+
+	valid := radius.NewMessage(1, 1, [16]byte{}, radius.NewAvpString(rfc2865.UserName, 0, "user"))
+	assert.Nil(t, radius.ValidateBytes(valid.ToBytes(), dictionary))
+	problems := radius.ValidateBytes(valid.ToBytes()[:24], dictionary)
+	assert.Equal(t, []radius.Problem{
+		{Offset: 2, Reason: "message length 26 exceeds 24 bytes"},
+		{Offset: 21, Reason: "invalid attribute length 6"},
+	}, problems)
+}
@@ -0,0 +1,123 @@
+package tests
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tinybluerobots/radius-diameter-message/diameter"
+	"github.com/tinybluerobots/radius-diameter-message/diameter/watchdog"
+)
+
+func Test_watchdog_messages(t *testing.T) {
+	dwr := watchdog.DWR{OriginHost: "client.example.com", OriginRealm: "example.com", OriginStateId: 7}
+	request := dwr.ToMessage([4]byte{0, 0, 0, 1}, [4]byte{0, 0, 0, 2})
+	assert.True(t, watchdog.IsDWR(request))
+	decodedDwr := watchdog.DWR{}
+	assert.NoError(t, decodedDwr.FromMessage(request))
+	assert.Equal(t, dwr, decodedDwr)
+
+	dwa := watchdog.DWA{ResultCode: diameter.ResultCodeSuccess, OriginHost: "server.example.com", OriginRealm: "example.com"}
+	answer := dwa.ToMessage(request)
+	assert.True(t, watchdog.IsDWA(answer))
+	assert.Equal(t, request.HopByHopId, answer.HopByHopId)
+	decodedDwa := watchdog.DWA{}
+	assert.NoError(t, decodedDwa.FromMessage(answer))
+	assert.Equal(t, dwa, decodedDwa)
+	assert.Error(t, decodedDwa.FromMessage(request))
+}
+
+func Test_watchdog_answers_dwr(t *testing.T) {
+	var sent []diameter.Message
+	w := watchdog.New(watchdog.Config{OriginHost: "server.example.com", OriginRealm: "example.com"}, func(m diameter.Message) error {
+		sent = append(sent, m)
+		return nil
+	})
+	request := watchdog.DWR{OriginHost: "client.example.com", OriginRealm: "example.com"}.ToMessage([4]byte{0, 0, 0, 1}, [4]byte{0, 0, 0, 2})
+	handled, err := w.Handle(request)
+	assert.NoError(t, err)
+	assert.True(t, handled)
+	assert.Len(t, sent, 1)
+	assert.True(t, watchdog.IsDWA(sent[0]))
+	assert.Equal(t, uint32(diameter.ResultCodeSuccess), sent[0].Avps.GetFirst(diameter.AvpResultCode, 0).ToUint32OrDefault())
+}
+
+func Test_watchdog_peer_failure(t *testing.T) {
+	var mutex sync.Mutex
+	var states []watchdog.State
+	sent := 0
+	w := watchdog.New(watchdog.Config{
+		Tw:          20 * time.Millisecond,
+		OriginHost:  "client.example.com",
+		OriginRealm: "example.com",
+		OnStateChange: func(s watchdog.State) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			states = append(states, s)
+		},
+	}, func(m diameter.Message) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+		assert.True(t, watchdog.IsDWR(m))
+		sent++
+		return nil
+	})
+	err := w.Run(context.Background())
+	assert.ErrorIs(t, err, watchdog.ErrPeerFailed)
+	assert.Equal(t, watchdog.Down, w.State())
+	assert.Equal(t, []watchdog.State{watchdog.Suspect, watchdog.Down}, states)
+	assert.Equal(t, 1, sent)
+	select {
+	case <-w.Failed():
+	default:
+		t.Fatal("failed channel not closed")
+	}
+}
+
+func Test_watchdog_dwa_keeps_peer_okay(t *testing.T) {
+	var w *watchdog.Watchdog
+	w = watchdog.New(watchdog.Config{Tw: 20 * time.Millisecond}, func(m diameter.Message) error {
+		go w.Handle(watchdog.DWA{ResultCode: diameter.ResultCodeSuccess}.ToMessage(m))
+		return nil
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 150*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, w.Run(ctx), context.DeadlineExceeded)
+	assert.Equal(t, watchdog.Okay, w.State())
+}
+
+func Test_watchdog_run_after_down(t *testing.T) {
+	w := watchdog.New(watchdog.Config{Tw: 10 * time.Millisecond}, func(diameter.Message) error { return nil })
+	assert.ErrorIs(t, w.Run(context.Background()), watchdog.ErrPeerFailed)
+	assert.ErrorIs(t, w.Run(context.Background()), watchdog.ErrPeerFailed)
+	assert.Equal(t, watchdog.Down, w.State())
+}
+
+func Test_watchdog_send_does_not_block_handle(t *testing.T) {
+	sending, release := make(chan struct{}), make(chan struct{})
+	var once sync.Once
+	w := watchdog.New(watchdog.Config{Tw: 10 * time.Millisecond}, func(m diameter.Message) error {
+		if watchdog.IsDWR(m) {
+			once.Do(func() { close(sending) })
+			<-release
+		}
+		return nil
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go w.Run(ctx)
+	<-sending
+	handled := make(chan struct{})
+	go func() {
+		w.Handle(diameter.NewMessage(1, 0, diameter.CommandCreditControl, diameter.ApplicationCreditControl, [4]byte{}, [4]byte{}))
+		close(handled)
+	}()
+	select {
+	case <-handled:
+	case <-time.After(time.Second):
+		t.Fatal("Handle blocked by a pending DWR send")
+	}
+	close(release)
+}
@@ -0,0 +1,75 @@
+package threegpp
+
+import (
+	"fmt"
+	"strings"
+)
+
+// tbcdDigits holds the characters of the TBCD digits, indexed by their value
+// (TS 29.002). The value 0xf is the filler of an odd number of digits.
+const tbcdDigits = "0123456789*#abc"
+
+// EncodeTBCD encodes digits such as an IMSI or MSISDN as a TBCD string, two
+// digits an octet with the first in the low nibble, and a filler in the high
+// nibble of the last octet of an odd number of digits. Besides decimal
+// digits it accepts '*', '#', 'a', 'b' and 'c'.
+func EncodeTBCD(digits string) ([]byte, error) {
+	encoded := make([]byte, (len(digits)+1)/2)
+	for i := 0; i < len(digits); i++ {
+		value := strings.IndexByte(tbcdDigits, digits[i])
+		if value < 0 {
+			return nil, fmt.Errorf("invalid TBCD digit %q", digits[i])
+		}
+		if i%2 == 0 {
+			encoded[i/2] = 0xf0 | byte(value)
+		} else {
+			encoded[i/2] = encoded[i/2]&0x0f | byte(value)<<4
+		}
+	}
+	return encoded, nil
+}
+
+// DecodeTBCD decodes a TBCD string to its digits, stopping at a filler in
+// the high nibble of the last octet.
+func DecodeTBCD(data []byte) (string, error) {
+	var builder strings.Builder
+	builder.Grow(len(data) * 2)
+	for i, octet := range data {
+		for j, value := range [2]byte{octet & 0x0f, octet >> 4} {
+			if value == 0x0f {
+				if j == 1 && i == len(data)-1 {
+					break
+				}
+				return "", fmt.Errorf("unexpected TBCD filler in octet %d", i)
+			}
+			builder.WriteByte(tbcdDigits[value])
+		}
+	}
+	return builder.String(), nil
+}
+
+// EncodeBCD encodes decimal digits as packed BCD, two digits an octet with
+// the first in the high nibble, and the last octet of an odd number of
+// digits padded with a zero nibble.
+func EncodeBCD(digits string) ([]byte, error) {
+	encoded := make([]byte, (len(digits)+1)/2)
+	for i := 0; i < len(digits); i++ {
+		if digits[i] < '0' || digits[i] > '9' {
+			return nil, fmt.Errorf("invalid BCD digit %q", digits[i])
+		}
+		encoded[i/2] |= (digits[i] - '0') << (4 * (1 - i%2))
+	}
+	return encoded, nil
+}
+
+// DecodeBCD decodes packed BCD to its decimal digits, two for every octet.
+func DecodeBCD(data []byte) (string, error) {
+	digits := make([]byte, 0, len(data)*2)
+	for i, octet := range data {
+		if octet>>4 > 9 || octet&0x0f > 9 {
+			return "", fmt.Errorf("invalid BCD octet 0x%02x at %d", octet, i)
+		}
+		digits = append(digits, '0'+octet>>4, '0'+octet&0x0f)
+	}
+	return string(digits), nil
+}
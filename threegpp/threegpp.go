@@ -0,0 +1,82 @@
+package threegpp
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ChargingCharacteristics represents the 16 bit 3GPP Charging-Characteristics
+// value, made up of a 4 bit profile index and 12 behaviour bits (TS 32.251).
+type ChargingCharacteristics uint16
+
+const (
+	HotBilling ChargingCharacteristics = 0x0100
+	FlatRate   ChargingCharacteristics = 0x0200
+	Prepaid    ChargingCharacteristics = 0x0400
+	Normal     ChargingCharacteristics = 0x0800
+)
+
+// ParseChargingCharacteristics parses the four hex digit string form, e.g. "0800".
+func ParseChargingCharacteristics(value string) (ChargingCharacteristics, error) {
+	if len(value) != 4 {
+		return 0, fmt.Errorf("invalid charging characteristics %q", value)
+	}
+	parsed, err := strconv.ParseUint(value, 16, 16)
+	if err != nil {
+		return 0, fmt.Errorf("invalid charging characteristics %q", value)
+	}
+	return ChargingCharacteristics(parsed), nil
+}
+
+// String returns the four hex digit string form of the charging characteristics.
+func (c ChargingCharacteristics) String() string {
+	return fmt.Sprintf("%04x", uint16(c))
+}
+
+// Profile returns the profile index bits.
+func (c ChargingCharacteristics) Profile() ChargingCharacteristics {
+	return c & 0x0f00
+}
+
+// Behaviour returns the behaviour bits.
+func (c ChargingCharacteristics) Behaviour() ChargingCharacteristics {
+	return c & 0xf0ff
+}
+
+// Has reports whether all the bits of the given profile are set.
+func (c ChargingCharacteristics) Has(profile ChargingCharacteristics) bool {
+	return c&profile == profile
+}
+
+// NormalizeAPN lowercases an APN and strips any operator identifier
+// (".mncXXX.mccYYY.gprs"), leaving only the network identifier.
+func NormalizeAPN(apn string) string {
+	apn = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(apn), "."))
+	labels := strings.Split(apn, ".")
+	for i := 1; i < len(labels); i++ {
+		if isOperatorIdentifier(labels[i:]) {
+			return strings.Join(labels[:i], ".")
+		}
+	}
+	return apn
+}
+
+// isOperatorIdentifier reports whether the labels form an APN operator
+// identifier, with or without the trailing "gprs" label.
+func isOperatorIdentifier(labels []string) bool {
+	if len(labels) == 3 && labels[2] == "gprs" {
+		labels = labels[:2]
+	}
+	return len(labels) == 2 && isDigitsLabel(labels[0], "mnc") && isDigitsLabel(labels[1], "mcc")
+}
+
+// isDigitsLabel reports whether the label is the prefix followed by three digits.
+func isDigitsLabel(label string, prefix string) bool {
+	digits, ok := strings.CutPrefix(label, prefix)
+	if !ok || len(digits) != 3 {
+		return false
+	}
+	_, err := strconv.Atoi(digits)
+	return err == nil
+}
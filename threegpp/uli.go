@@ -0,0 +1,252 @@
+package threegpp
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// PLMN identifies a public land mobile network by its mobile country code
+// and two or three digit mobile network code.
+type PLMN struct {
+	MCC string
+	MNC string
+}
+
+// DecodePLMN decodes the three octet PLMN identity of TS 24.008, whose third
+// MNC digit is a filler for two digit MNCs.
+func DecodePLMN(data []byte) (PLMN, error) {
+	if len(data) != 3 {
+		return PLMN{}, fmt.Errorf("invalid PLMN length %d", len(data))
+	}
+	digits := [6]byte{data[0] & 0x0f, data[0] >> 4, data[1] & 0x0f, data[2] & 0x0f, data[2] >> 4, data[1] >> 4}
+	mnc := digits[3:]
+	if digits[5] == 0x0f {
+		mnc = digits[3:5]
+	}
+	var plmn PLMN
+	for i, digit := range append(digits[:3:3], mnc...) {
+		if digit > 9 {
+			return PLMN{}, fmt.Errorf("invalid PLMN digit 0x%x", digit)
+		}
+		if i < 3 {
+			plmn.MCC += string('0' + digit)
+		} else {
+			plmn.MNC += string('0' + digit)
+		}
+	}
+	return plmn, nil
+}
+
+// EncodePLMN encodes the PLMN as the three octet PLMN identity of TS 24.008.
+func EncodePLMN(plmn PLMN) ([]byte, error) {
+	digits := plmn.MCC + plmn.MNC
+	if len(plmn.MCC) != 3 || len(plmn.MNC) < 2 || len(plmn.MNC) > 3 {
+		return nil, fmt.Errorf("invalid PLMN %s-%s", plmn.MCC, plmn.MNC)
+	}
+	for i := 0; i < len(digits); i++ {
+		if digits[i] < '0' || digits[i] > '9' {
+			return nil, fmt.Errorf("invalid PLMN %s-%s", plmn.MCC, plmn.MNC)
+		}
+	}
+	mnc3 := byte(0x0f)
+	if len(plmn.MNC) == 3 {
+		mnc3 = plmn.MNC[2] - '0'
+	}
+	return []byte{
+		(plmn.MCC[1]-'0')<<4 | (plmn.MCC[0] - '0'),
+		mnc3<<4 | (plmn.MCC[2] - '0'),
+		(plmn.MNC[1]-'0')<<4 | (plmn.MNC[0] - '0'),
+	}, nil
+}
+
+// String returns the MCC and MNC, e.g. "208-01".
+func (p PLMN) String() string {
+	return p.MCC + "-" + p.MNC
+}
+
+// LocationType is the Geographic Location Type that starts a 3GPP
+// User-Location-Info (TS 29.061).
+type LocationType byte
+
+const (
+	LocationCGI        LocationType = 0
+	LocationSAI        LocationType = 1
+	LocationRAI        LocationType = 2
+	LocationTAI        LocationType = 128
+	LocationECGI       LocationType = 129
+	LocationTAIAndECGI LocationType = 130
+)
+
+// CGI is a GERAN or UTRAN cell global identity.
+type CGI struct {
+	PLMN PLMN
+	LAC  uint16
+	CI   uint16
+}
+
+// SAI is a UTRAN service area identity.
+type SAI struct {
+	PLMN PLMN
+	LAC  uint16
+	SAC  uint16
+}
+
+// RAI is a GERAN or UTRAN routing area identity.
+type RAI struct {
+	PLMN PLMN
+	LAC  uint16
+	RAC  uint8
+}
+
+// TAI is an E-UTRAN tracking area identity.
+type TAI struct {
+	PLMN PLMN
+	TAC  uint16
+}
+
+// ECGI is an E-UTRAN cell global identity, with its 28 bit cell identity.
+type ECGI struct {
+	PLMN PLMN
+	ECI  uint32
+}
+
+// UserLocationInfo is the location of a user in a 3GPP-User-Location-Info,
+// holding the identities its type gives it.
+type UserLocationInfo struct {
+	Type LocationType
+	CGI  *CGI
+	SAI  *SAI
+	RAI  *RAI
+	TAI  *TAI
+	ECGI *ECGI
+}
+
+// DecodeUserLocationInfo decodes a 3GPP-User-Location-Info, carried by the
+// RADIUS vendor-specific attribute and the Diameter AVP alike: the
+// Geographic Location Type followed by the identities it names.
+func DecodeUserLocationInfo(data []byte) (UserLocationInfo, error) {
+	if len(data) == 0 {
+		return UserLocationInfo{}, errors.New("empty user location info")
+	}
+	info := UserLocationInfo{Type: LocationType(data[0])}
+	data = data[1:]
+	var err error
+	switch info.Type {
+	case LocationCGI, LocationSAI, LocationRAI:
+		err = info.decodeUTRAN(data)
+	case LocationTAI:
+		info.TAI, err = decodeTAI(data)
+	case LocationECGI:
+		info.ECGI, err = decodeECGI(data)
+	case LocationTAIAndECGI:
+		if len(data) != 12 {
+			return UserLocationInfo{}, fmt.Errorf("invalid TAI and ECGI length %d", len(data))
+		}
+		if info.TAI, err = decodeTAI(data[:5]); err == nil {
+			info.ECGI, err = decodeECGI(data[5:])
+		}
+	default:
+		err = fmt.Errorf("unsupported geographic location type %d", info.Type)
+	}
+	if err != nil {
+		return UserLocationInfo{}, err
+	}
+	return info, nil
+}
+
+// decodeUTRAN decodes the seven octets of a CGI, SAI or RAI: the PLMN, the
+// LAC, and the CI or SAC, or the RAC and a spare octet.
+func (info *UserLocationInfo) decodeUTRAN(data []byte) error {
+	if len(data) != 7 {
+		return fmt.Errorf("invalid length %d of geographic location type %d", len(data), info.Type)
+	}
+	plmn, err := DecodePLMN(data[0:3])
+	if err != nil {
+		return err
+	}
+	lac, value := binary.BigEndian.Uint16(data[3:5]), binary.BigEndian.Uint16(data[5:7])
+	switch info.Type {
+	case LocationCGI:
+		info.CGI = &CGI{PLMN: plmn, LAC: lac, CI: value}
+	case LocationSAI:
+		info.SAI = &SAI{PLMN: plmn, LAC: lac, SAC: value}
+	case LocationRAI:
+		info.RAI = &RAI{PLMN: plmn, LAC: lac, RAC: data[5]}
+	}
+	return nil
+}
+
+// decodeTAI decodes the five octets of a TAI.
+func decodeTAI(data []byte) (*TAI, error) {
+	if len(data) != 5 {
+		return nil, fmt.Errorf("invalid TAI length %d", len(data))
+	}
+	plmn, err := DecodePLMN(data[0:3])
+	if err != nil {
+		return nil, err
+	}
+	return &TAI{PLMN: plmn, TAC: binary.BigEndian.Uint16(data[3:5])}, nil
+}
+
+// decodeECGI decodes the seven octets of an ECGI.
+func decodeECGI(data []byte) (*ECGI, error) {
+	if len(data) != 7 {
+		return nil, fmt.Errorf("invalid ECGI length %d", len(data))
+	}
+	plmn, err := DecodePLMN(data[0:3])
+	if err != nil {
+		return nil, err
+	}
+	return &ECGI{PLMN: plmn, ECI: binary.BigEndian.Uint32(data[3:7]) & 0x0fffffff}, nil
+}
+
+// EncodeUserLocationInfo encodes the identities its type names as a
+// 3GPP-User-Location-Info.
+func EncodeUserLocationInfo(info UserLocationInfo) ([]byte, error) {
+	data := []byte{byte(info.Type)}
+	switch {
+	case info.Type == LocationCGI && info.CGI != nil:
+		return appendUTRAN(data, info.CGI.PLMN, info.CGI.LAC, info.CGI.CI)
+	case info.Type == LocationSAI && info.SAI != nil:
+		return appendUTRAN(data, info.SAI.PLMN, info.SAI.LAC, info.SAI.SAC)
+	case info.Type == LocationRAI && info.RAI != nil:
+		return appendUTRAN(data, info.RAI.PLMN, info.RAI.LAC, uint16(info.RAI.RAC)<<8|0xff)
+	case info.Type <= LocationRAI:
+		return nil, fmt.Errorf("missing identity of geographic location type %d", info.Type)
+	}
+	if info.Type == LocationTAI || info.Type == LocationTAIAndECGI {
+		if info.TAI == nil {
+			return nil, errors.New("missing TAI")
+		}
+		plmn, err := EncodePLMN(info.TAI.PLMN)
+		if err != nil {
+			return nil, err
+		}
+		data = binary.BigEndian.AppendUint16(append(data, plmn...), info.TAI.TAC)
+	}
+	if info.Type == LocationECGI || info.Type == LocationTAIAndECGI {
+		if info.ECGI == nil {
+			return nil, errors.New("missing ECGI")
+		}
+		plmn, err := EncodePLMN(info.ECGI.PLMN)
+		if err != nil {
+			return nil, err
+		}
+		data = binary.BigEndian.AppendUint32(append(data, plmn...), info.ECGI.ECI&0x0fffffff)
+	}
+	if len(data) == 1 {
+		return nil, fmt.Errorf("unsupported geographic location type %d", info.Type)
+	}
+	return data, nil
+}
+
+// appendUTRAN appends the PLMN, LAC and value of a CGI, SAI or RAI.
+func appendUTRAN(data []byte, plmn PLMN, lac uint16, value uint16) ([]byte, error) {
+	encoded, err := EncodePLMN(plmn)
+	if err != nil {
+		return nil, err
+	}
+	data = binary.BigEndian.AppendUint16(append(data, encoded...), lac)
+	return binary.BigEndian.AppendUint16(data, value), nil
+}